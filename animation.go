@@ -18,6 +18,29 @@ const (
 	InterpolationCubic // Unimplemented
 )
 
+const (
+	// TrackTypeMorphWeights is the type of AnimationTracks that animate a Model's morph weights (see Model.SetMorphWeights()); each of
+	// their keyframes is a vector with a weight for each of the Model's MorphTargets.
+	TrackTypeMorphWeights = "Wgt"
+
+	// TrackTypeColor is the type of AnimationTracks that animate a Material's Color, for channels in an Animation's MaterialChannels.
+	// Each of their keyframes is a vector of the color's R, G, B, and (optionally) A values; if a keyframe has no alpha value, the
+	// Material's alpha is left alone.
+	TrackTypeColor = "Col"
+
+	// TrackTypeUVOffset is the type of AnimationTracks that animate a Material's UVOffset, for channels in an Animation's
+	// MaterialChannels. Each of their keyframes is a 2D vector.
+	TrackTypeUVOffset = "UVO"
+
+	// TrackTypeUVScale is the type of AnimationTracks that animate a Material's UVScale, for channels in an Animation's
+	// MaterialChannels. Each of their keyframes is a 2D vector.
+	TrackTypeUVScale = "UVS"
+
+	// TrackTypeUVRotation is the type of AnimationTracks that animate a Material's UVRotation, for channels in an Animation's
+	// MaterialChannels. Each of their keyframes is a 1D vector holding the rotation in radians.
+	TrackTypeUVRotation = "UVR"
+)
+
 type Data struct {
 	contents interface{}
 }
@@ -93,7 +116,8 @@ func (track *AnimationTrack) ValueAsVector(time float64) vector.Vector {
 				return fd
 			} else {
 				// We still need to implement InterpolationCubic
-				if track.Type == TrackTypePosition || track.Type == TrackTypeScale {
+				if track.Type == TrackTypePosition || track.Type == TrackTypeScale || track.Type == TrackTypeMorphWeights || track.Type == TrackTypeColor ||
+					track.Type == TrackTypeUVOffset || track.Type == TrackTypeUVScale || track.Type == TrackTypeUVRotation {
 					return fd.Add(ld.Sub(fd).Scale(t))
 				}
 			}
@@ -187,16 +211,21 @@ type Animation struct {
 	library  *Library
 	Name     string
 	Channels map[string]*AnimationChannel
-	Length   float64  // Length of the animation in seconds
-	Markers  []Marker // Markers as specified in the Animation from the modeler
+	// MaterialChannels are channels that animate Materials rather than Nodes (i.e. their colors or UV offsets), keyed by the name of
+	// the Material they animate. When played, they animate the Materials with matching names used by the Models under the
+	// AnimationPlayer's root Node.
+	MaterialChannels map[string]*AnimationChannel
+	Length           float64  // Length of the animation in seconds
+	Markers          []Marker // Markers as specified in the Animation from the modeler
 }
 
 // NewAnimation creates a new Animation of the name specified.
 func NewAnimation(name string) *Animation {
 	return &Animation{
-		Name:     name,
-		Channels: map[string]*AnimationChannel{},
-		Markers:  []Marker{},
+		Name:             name,
+		Channels:         map[string]*AnimationChannel{},
+		MaterialChannels: map[string]*AnimationChannel{},
+		Markers:          []Marker{},
 	}
 }
 
@@ -206,16 +235,32 @@ func (animation *Animation) AddChannel(name string) *AnimationChannel {
 	return newChannel
 }
 
+// AddMaterialChannel adds a channel that animates Materials with the given name to the Animation, and returns it.
+func (animation *Animation) AddMaterialChannel(materialName string) *AnimationChannel {
+	newChannel := NewAnimationChannel(materialName)
+	animation.MaterialChannels[materialName] = newChannel
+	return newChannel
+}
+
 // Library returns the Library from which this Animation was loaded. If it was created in code, this function would return nil.
 func (animation *Animation) Library() *Library {
 	return animation.library
 }
 
-// AnimationValues indicate the current position, scale, and rotation for a Node.
+// AnimationValues indicate the current position, scale, and rotation for a Node, as well as its morph weights if it's a Model.
 type AnimationValues struct {
-	Position vector.Vector
-	Scale    vector.Vector
-	Rotation *Quaternion
+	Position     vector.Vector
+	Scale        vector.Vector
+	Rotation     *Quaternion
+	MorphWeights vector.Vector
+}
+
+// MaterialAnimationValues indicate the current color and UV transform for a Material.
+type MaterialAnimationValues struct {
+	Color      vector.Vector
+	UVOffset   vector.Vector
+	UVScale    vector.Vector
+	UVRotation vector.Vector
 }
 
 // AnimationPlayer is an object that allows you to play back an animation on a Node.
@@ -241,6 +286,10 @@ type AnimationPlayer struct {
 	// Otherwise, it will only play frames 1 - 9, which can be good if your last frame is a repeat of the first to make a cyclical animation.
 	// The default for PlayLastFrame is false.
 	PlayLastFrame bool
+
+	ChannelsToMaterials            map[*AnimationChannel][]*Material      // The Materials animated by each of the Animation's MaterialChannels
+	AnimatedMaterialProperties     map[*Material]*MaterialAnimationValues // The Material properties that have been animated
+	prevAnimatedMaterialProperties map[*Material]*MaterialAnimationValues
 }
 
 // NewAnimationPlayer returns a new AnimationPlayer for the Node.
@@ -252,6 +301,9 @@ func NewAnimationPlayer(node INode) *AnimationPlayer {
 		AnimatedProperties:     map[INode]*AnimationValues{},
 		prevAnimatedProperties: map[INode]*AnimationValues{},
 		PlayLastFrame:          false,
+
+		AnimatedMaterialProperties:     map[*Material]*MaterialAnimationValues{},
+		prevAnimatedMaterialProperties: map[*Material]*MaterialAnimationValues{},
 	}
 }
 
@@ -263,6 +315,10 @@ func (ap *AnimationPlayer) Clone() *AnimationPlayer {
 	for channel, node := range ap.ChannelsToNodes {
 		newAP.ChannelsToNodes[channel] = node
 	}
+	newAP.ChannelsToMaterials = map[*AnimationChannel][]*Material{}
+	for channel, materials := range ap.ChannelsToMaterials {
+		newAP.ChannelsToMaterials[channel] = append([]*Material{}, materials...)
+	}
 	newAP.ChannelsUpdated = ap.ChannelsUpdated
 
 	newAP.Animation = ap.Animation
@@ -304,6 +360,10 @@ func (ap *AnimationPlayer) Play(animation *Animation) {
 		for n, v := range ap.AnimatedProperties {
 			ap.prevAnimatedProperties[n] = v
 		}
+		ap.prevAnimatedMaterialProperties = map[*Material]*MaterialAnimationValues{}
+		for m, v := range ap.AnimatedMaterialProperties {
+			ap.prevAnimatedMaterialProperties[m] = v
+		}
 		ap.blendStart = time.Now()
 	}
 
@@ -351,6 +411,40 @@ func (ap *AnimationPlayer) assignChannels() {
 
 		}
 
+		ap.AnimatedMaterialProperties = map[*Material]*MaterialAnimationValues{}
+
+		ap.ChannelsToMaterials = map[*AnimationChannel][]*Material{}
+
+		for _, channel := range ap.Animation.MaterialChannels {
+
+			for _, n := range append([]INode{ap.RootNode}, childrenRecursive...) {
+
+				model, isModel := n.(*Model)
+
+				if !isModel {
+					continue
+				}
+
+				for _, meshPart := range model.Mesh.MeshParts {
+
+					mat := meshPart.Material
+
+					if mat == nil || mat.Name != channel.Name {
+						continue
+					}
+
+					// Models can share Materials, so each Material is only animated once.
+					if _, exists := ap.AnimatedMaterialProperties[mat]; !exists {
+						ap.ChannelsToMaterials[channel] = append(ap.ChannelsToMaterials[channel], mat)
+						ap.AnimatedMaterialProperties[mat] = &MaterialAnimationValues{}
+					}
+
+				}
+
+			}
+
+		}
+
 	}
 
 	ap.ChannelsUpdated = true
@@ -391,6 +485,34 @@ func (ap *AnimationPlayer) updateValues(dt float64) {
 						ap.AnimatedProperties[node].Rotation = quat
 					}
 
+					if track, exists := channel.Tracks[TrackTypeMorphWeights]; exists {
+						ap.AnimatedProperties[node].MorphWeights = track.ValueAsVector(ap.Playhead)
+					}
+
+				}
+
+			}
+
+			for _, channel := range ap.Animation.MaterialChannels {
+
+				for _, mat := range ap.ChannelsToMaterials[channel] {
+
+					if track, exists := channel.Tracks[TrackTypeColor]; exists {
+						ap.AnimatedMaterialProperties[mat].Color = track.ValueAsVector(ap.Playhead)
+					}
+
+					if track, exists := channel.Tracks[TrackTypeUVOffset]; exists {
+						ap.AnimatedMaterialProperties[mat].UVOffset = track.ValueAsVector(ap.Playhead)
+					}
+
+					if track, exists := channel.Tracks[TrackTypeUVScale]; exists {
+						ap.AnimatedMaterialProperties[mat].UVScale = track.ValueAsVector(ap.Playhead)
+					}
+
+					if track, exists := channel.Tracks[TrackTypeUVRotation]; exists {
+						ap.AnimatedMaterialProperties[mat].UVRotation = track.ValueAsVector(ap.Playhead)
+					}
+
 				}
 
 			}
@@ -527,9 +649,20 @@ func (ap *AnimationPlayer) Update(dt float64) {
 				node.SetLocalRotation(start.Rotation.ToMatrix4())
 			}
 
+			if model, isModel := node.(*Model); isModel {
+				if start.MorphWeights != nil && props.MorphWeights != nil && len(start.MorphWeights) == len(props.MorphWeights) {
+					model.SetMorphWeights(start.MorphWeights.Add(props.MorphWeights.Sub(start.MorphWeights).Scale(bp))...)
+				} else if props.MorphWeights != nil {
+					model.SetMorphWeights(props.MorphWeights...)
+				} else if start.MorphWeights != nil {
+					model.SetMorphWeights(start.MorphWeights...)
+				}
+			}
+
 			if bp == 1 {
 				ap.blendStart = time.Time{}
 				ap.prevAnimatedProperties = map[INode]*AnimationValues{}
+				ap.prevAnimatedMaterialProperties = map[*Material]*MaterialAnimationValues{}
 			}
 
 		} else {
@@ -543,7 +676,73 @@ func (ap *AnimationPlayer) Update(dt float64) {
 			if props.Rotation != nil {
 				node.SetLocalRotation(props.Rotation.ToMatrix4())
 			}
+			if model, isModel := node.(*Model); isModel && props.MorphWeights != nil {
+				model.SetMorphWeights(props.MorphWeights...)
+			}
+
+		}
+
+	}
+
+	for mat, props := range ap.AnimatedMaterialProperties {
+
+		color := props.Color
+		uvOffset := props.UVOffset
+		uvScale := props.UVScale
+		uvRotation := props.UVRotation
+
+		if start, prevExists := ap.prevAnimatedMaterialProperties[mat]; !ap.blendStart.IsZero() && prevExists {
+
+			bp := float64(time.Since(ap.blendStart).Milliseconds()) / (ap.BlendTime * 1000)
+			if bp > 1 {
+				bp = 1
+			}
+
+			if start.Color != nil && color != nil && len(start.Color) == len(color) {
+				color = start.Color.Add(color.Sub(start.Color).Scale(bp))
+			} else if color == nil {
+				color = start.Color
+			}
+
+			if start.UVOffset != nil && uvOffset != nil {
+				uvOffset = start.UVOffset.Add(uvOffset.Sub(start.UVOffset).Scale(bp))
+			} else if uvOffset == nil {
+				uvOffset = start.UVOffset
+			}
+
+			if start.UVScale != nil && uvScale != nil {
+				uvScale = start.UVScale.Add(uvScale.Sub(start.UVScale).Scale(bp))
+			} else if uvScale == nil {
+				uvScale = start.UVScale
+			}
+
+			if len(start.UVRotation) > 0 && len(uvRotation) > 0 {
+				uvRotation = vector.Vector{start.UVRotation[0] + (uvRotation[0]-start.UVRotation[0])*bp}
+			} else if uvRotation == nil {
+				uvRotation = start.UVRotation
+			}
+
+		}
+
+		if len(color) >= 3 {
+			mat.Color.R = float32(color[0])
+			mat.Color.G = float32(color[1])
+			mat.Color.B = float32(color[2])
+			if len(color) >= 4 {
+				mat.Color.A = float32(color[3])
+			}
+		}
+
+		if len(uvOffset) >= 2 {
+			mat.UVOffset = vector.Vector{uvOffset[0], uvOffset[1]}
+		}
+
+		if len(uvScale) >= 2 {
+			mat.UVScale = vector.Vector{uvScale[0], uvScale[1]}
+		}
 
+		if len(uvRotation) >= 1 {
+			mat.UVRotation = uvRotation[0]
 		}
 
 	}
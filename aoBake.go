@@ -0,0 +1,640 @@
+package tetra3d
+
+import (
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/kvartborg/vector"
+)
+
+// BakeJob represents an ambient occlusion bake that runs in the background; see Model.BakeAOAsync().
+type BakeJob struct {
+	completed int64 // Accessed atomically, so it's the first field to keep it 64-bit aligned
+	total     int64
+	finished  chan struct{}
+	bake      *aoBake
+	applied   bool
+}
+
+// Progress returns how far along the BakeJob is, ranging from 0 (just started) to 1 (finished).
+func (job *BakeJob) Progress() float64 {
+	if job.total <= 0 {
+		return 1
+	}
+	return float64(atomic.LoadInt64(&job.completed)) / float64(job.total)
+}
+
+// Done returns if the BakeJob has finished. The baked ambient occlusion is applied to the Model's vertex colors by the first call to
+// Done() that returns true, so it should be called from the same goroutine that renders the Model (i.e. your game's Update() or Draw() function).
+func (job *BakeJob) Done() bool {
+
+	select {
+	case <-job.finished:
+		job.apply()
+		return true
+	default:
+		return false
+	}
+
+}
+
+// Wait blocks until the BakeJob is finished, and then applies the baked ambient occlusion to the Model's vertex colors.
+func (job *BakeJob) Wait() {
+	<-job.finished
+	job.apply()
+}
+
+func (job *BakeJob) apply() {
+	if !job.applied {
+		job.applied = true
+		if job.bake != nil {
+			job.bake.apply()
+		}
+	}
+}
+
+// BakeAOAsync bakes ambient occlusion like Model.BakeAO(), but does so across multiple goroutines in the background, returning a BakeJob
+// that can be used to check the bake's progress (to display a loading bar, for example). The result is identical to BakeAO()'s.
+// The Model's vertex colors are only altered once the bake is finished and BakeJob.Done() returns true (or BakeJob.Wait() returns).
+// Note that the Meshes of the Model and the OtherModels in the bake options shouldn't be modified while the bake is in progress.
+func (model *Model) BakeAOAsync(bakeOptions *AOBakeOptions) *BakeJob {
+
+	job := &BakeJob{
+		finished: make(chan struct{}),
+		bake:     model.newAOBake(bakeOptions),
+	}
+
+	if job.bake == nil {
+		close(job.finished)
+		return job
+	}
+
+	job.total = int64(job.bake.triangleCount())
+
+	go func() {
+		job.bake.run(&job.completed)
+		close(job.finished)
+	}()
+
+	return job
+
+}
+
+// aoBake holds the data necessary to bake ambient occlusion for a Model. All of the information that requires a Model's transform is
+// gathered up front, so that the baking itself only reads Mesh data and can be done on other goroutines.
+type aoBake struct {
+	model     *Model
+	options   *AOBakeOptions
+	passes    []*aoBakePass
+	occluders []aoOccluder // The Models whose triangles rays are cast against, when baking with AOBakeModeRaycast
+}
+
+// aoOccluder is a Model whose triangles can occlude the baking Model's vertices when baking with AOBakeModeRaycast.
+type aoOccluder struct {
+	mesh      *Mesh
+	transform Matrix4
+}
+
+// aoBakePass is a single pass of an ambient occlusion bake; the first pass is for AO within the baking Model itself, while further
+// passes are for AO caused by other Models.
+type aoBakePass struct {
+	other          *Model
+	transform      Matrix4
+	otherTransform Matrix4
+	occlusion      [][3]float32 // How much AO each vertex of each triangle receives, indexed by the triangle's index in Mesh.Triangles
+}
+
+func (model *Model) newAOBake(bakeOptions *AOBakeOptions) *aoBake {
+
+	if bakeOptions == nil {
+		bakeOptions = NewDefaultAOBakeOptions()
+	}
+
+	if model.Mesh == nil || bakeOptions.TargetChannel < 0 {
+		return nil
+	}
+
+	model.Mesh.ensureEnoughVertexColorChannels(bakeOptions.TargetChannel)
+
+	bake := &aoBake{
+		model:   model,
+		options: bakeOptions,
+		passes:  []*aoBakePass{{}},
+	}
+
+	transform := model.Transform()
+
+	// When raycasting, there's just the one pass, as rays are cast against the triangles of all of the Models at once.
+	if bakeOptions.Mode == AOBakeModeRaycast {
+
+		bake.passes[0].transform = transform
+		bake.passes[0].occlusion = make([][3]float32, len(model.Mesh.Triangles))
+
+		bake.occluders = append(bake.occluders, aoOccluder{mesh: model.Mesh, transform: transform})
+
+		for _, other := range bakeOptions.OtherModels {
+
+			reach := model.BoundingSphere.WorldRadius() + other.BoundingSphere.WorldRadius() + bakeOptions.RayDistance
+			if model == other || other.Mesh == nil || fastVectorDistanceSquared(model.WorldPosition(), other.WorldPosition()) > reach*reach {
+				continue
+			}
+
+			bake.occluders = append(bake.occluders, aoOccluder{mesh: other.Mesh, transform: other.Transform()})
+
+		}
+
+		return bake
+
+	}
+
+	for _, other := range bakeOptions.OtherModels {
+
+		rad := model.BoundingSphere.WorldRadius()
+		if or := other.BoundingSphere.WorldRadius(); or > rad {
+			rad = or
+		}
+		if model == other || fastVectorDistanceSquared(model.WorldPosition(), other.WorldPosition()) > rad*rad {
+			continue
+		}
+
+		bake.passes = append(bake.passes, &aoBakePass{
+			other:          other,
+			transform:      transform,
+			otherTransform: other.Transform(),
+		})
+
+	}
+
+	for _, pass := range bake.passes {
+		pass.occlusion = make([][3]float32, len(model.Mesh.Triangles))
+	}
+
+	return bake
+
+}
+
+func (bake *aoBake) triangleCount() int {
+	return len(bake.model.Mesh.Triangles) * len(bake.passes)
+}
+
+// run calculates the ambient occlusion for each pass, splitting the Model's triangles across goroutines. If progress is non-nil,
+// it's atomically incremented for each triangle processed.
+func (bake *aoBake) run(progress *int64) {
+
+	for _, pass := range bake.passes {
+
+		var process func(triIndex int, scratch *aoGridQuery)
+
+		if bake.options.Mode == AOBakeModeRaycast {
+			process = bake.raycastOcclusion(pass)
+		} else if pass.other == nil {
+			process = bake.selfOcclusion(pass)
+		} else {
+			process = bake.interModelOcclusion(pass)
+		}
+
+		triCount := len(bake.model.Mesh.Triangles)
+		workerCount := runtime.NumCPU()
+		if workerCount > triCount {
+			workerCount = triCount
+		}
+
+		wg := sync.WaitGroup{}
+
+		for w := 0; w < workerCount; w++ {
+
+			start := triCount * w / workerCount
+			end := triCount * (w + 1) / workerCount
+
+			wg.Add(1)
+
+			go func() {
+
+				defer wg.Done()
+
+				scratch := &aoGridQuery{}
+
+				for i := start; i < end; i++ {
+
+					process(i, scratch)
+
+					if progress != nil && (i-start)%64 == 63 {
+						atomic.AddInt64(progress, 64)
+					}
+
+				}
+
+				if progress != nil {
+					atomic.AddInt64(progress, int64((end-start)%64))
+				}
+
+			}()
+
+		}
+
+		wg.Wait()
+
+	}
+
+}
+
+// selfOcclusion returns a function that calculates the AO for a triangle caused by other triangles in the same Model.
+func (bake *aoBake) selfOcclusion(pass *aoBakePass) func(triIndex int, scratch *aoGridQuery) {
+
+	triangles := bake.model.Mesh.Triangles
+
+	centers := make([]vector.Vector, len(triangles))
+	radii := make([]float64, len(triangles))
+
+	for i, tri := range triangles {
+		centers[i] = tri.Center
+		radii[i] = tri.MaxSpan * 0.66
+	}
+
+	grid := newAOGrid(centers, radii)
+
+	return func(triIndex int, scratch *aoGridQuery) {
+
+		tri := triangles[triIndex]
+		ao := &pass.occlusion[triIndex]
+
+		for _, otherIndex := range grid.candidates(scratch, centers[triIndex], radii[triIndex]) {
+
+			other := triangles[otherIndex]
+
+			if tri == other || vectorsEqual(tri.Normal, other.Normal) {
+				continue
+			}
+
+			span := tri.MaxSpan
+			if other.MaxSpan > span {
+				span = other.MaxSpan
+			}
+
+			span *= 0.66
+
+			if fastVectorDistanceSquared(tri.Center, other.Center) > span*span {
+				continue
+			}
+
+			angle := tri.Normal.Angle(other.Normal)
+			if angle < bake.options.OcclusionAngle {
+				continue
+			}
+
+			if shared := tri.SharesVertexPositions(other); shared != nil {
+
+				if shared[0] >= 0 {
+					ao[0] = 1
+				}
+				if shared[1] >= 0 {
+					ao[1] = 1
+				}
+				if shared[2] >= 0 {
+					ao[2] = 1
+				}
+
+			}
+
+		}
+
+	}
+
+}
+
+// interModelOcclusion returns a function that calculates the AO for a triangle caused by the triangles of another Model.
+func (bake *aoBake) interModelOcclusion(pass *aoBakePass) func(triIndex int, scratch *aoGridQuery) {
+
+	mesh := bake.model.Mesh
+	otherMesh := pass.other.Mesh
+
+	distanceSquared := bake.options.InterModelDistance * bake.options.InterModelDistance
+
+	// Triangles are compared by the squared distance between their centers against their spans, so the distances triangles
+	// can influence each other from are the square roots of their spans.
+	otherCenters := make([]vector.Vector, len(otherMesh.Triangles))
+	otherRadii := make([]float64, len(otherMesh.Triangles))
+	otherVerts := make([][3]vector.Vector, len(otherMesh.Triangles))
+
+	for i, otherTri := range otherMesh.Triangles {
+		otherCenters[i] = pass.otherTransform.MultVec(otherTri.Center)
+		otherRadii[i] = math.Sqrt(otherTri.MaxSpan * 0.66)
+		indices := otherTri.VertexIndices()
+		otherVerts[i] = [3]vector.Vector{
+			pass.otherTransform.MultVec(otherMesh.VertexPositions[indices[0]]),
+			pass.otherTransform.MultVec(otherMesh.VertexPositions[indices[1]]),
+			pass.otherTransform.MultVec(otherMesh.VertexPositions[indices[2]]),
+		}
+	}
+
+	grid := newAOGrid(otherCenters, otherRadii)
+
+	return func(triIndex int, scratch *aoGridQuery) {
+
+		tri := mesh.Triangles[triIndex]
+		ao := &pass.occlusion[triIndex]
+
+		verts := tri.VertexIndices()
+
+		transformedTriVerts := [3]vector.Vector{
+			pass.transform.MultVec(mesh.VertexPositions[verts[0]]),
+			pass.transform.MultVec(mesh.VertexPositions[verts[1]]),
+			pass.transform.MultVec(mesh.VertexPositions[verts[2]]),
+		}
+
+		center := pass.transform.MultVec(tri.Center)
+
+		for _, otherIndex := range grid.candidates(scratch, center, math.Sqrt(tri.MaxSpan*0.66)) {
+
+			otherTri := otherMesh.Triangles[otherIndex]
+
+			span := tri.MaxSpan
+			if otherTri.MaxSpan > span {
+				span = otherTri.MaxSpan
+			}
+
+			span *= 0.66
+
+			if fastVectorDistanceSquared(center, otherCenters[otherIndex]) > span {
+				continue
+			}
+
+			for i := 0; i < 3; i++ {
+				for j := 0; j < 3; j++ {
+					if fastVectorDistanceSquared(transformedTriVerts[i], otherVerts[otherIndex][j]) <= distanceSquared {
+						ao[i] = 1
+						break
+					}
+				}
+			}
+
+		}
+
+	}
+
+}
+
+// raycastOcclusion returns a function that calculates the AO for a triangle's vertices by casting rays across the hemispheres above
+// them against the triangles of the occluding Models.
+func (bake *aoBake) raycastOcclusion(pass *aoBakePass) func(triIndex int, scratch *aoGridQuery) {
+
+	mesh := bake.model.Mesh
+
+	rayCount := bake.options.RayCount
+	if rayCount < 1 {
+		rayCount = 1
+	}
+
+	rayDistance := bake.options.RayDistance
+
+	// Rays start a little above the vertices, so they don't hit the triangles the vertices belong to.
+	bias := rayDistance * 0.001
+
+	// The rays are spread in a spiral across the hemisphere, and weighted towards its pole (so that occluders directly above a vertex
+	// occlude it more than those off to the side).
+	rays := make([]vector.Vector, rayCount)
+	goldenAngle := math.Pi * (3 - math.Sqrt(5))
+
+	for i := range rays {
+		r := math.Sqrt((float64(i) + 0.5) / float64(rayCount))
+		sin, cos := math.Sincos(float64(i) * goldenAngle)
+		rays[i] = vector.Vector{r * cos, r * sin, math.Sqrt(1 - r*r)}
+	}
+
+	occluderVerts := [][3]vector.Vector{}
+	occluderCenters := []vector.Vector{}
+	occluderRadii := []float64{}
+
+	for _, occluder := range bake.occluders {
+
+		for _, tri := range occluder.mesh.Triangles {
+
+			indices := tri.VertexIndices()
+
+			verts := [3]vector.Vector{
+				occluder.transform.MultVec(occluder.mesh.VertexPositions[indices[0]]),
+				occluder.transform.MultVec(occluder.mesh.VertexPositions[indices[1]]),
+				occluder.transform.MultVec(occluder.mesh.VertexPositions[indices[2]]),
+			}
+
+			center := vector.Vector(vector.In(verts[0].Clone()).Add(verts[1]).Add(verts[2]).Scale(1.0 / 3.0))
+
+			radius := 0.0
+			for _, v := range verts {
+				radius = math.Max(radius, math.Sqrt(fastVectorDistanceSquared(center, v)))
+			}
+
+			occluderVerts = append(occluderVerts, verts)
+			occluderCenters = append(occluderCenters, center)
+			occluderRadii = append(occluderRadii, radius)
+
+		}
+
+	}
+
+	grid := newAOGrid(occluderCenters, occluderRadii)
+
+	_, _, rotation := pass.transform.Decompose()
+
+	return func(triIndex int, scratch *aoGridQuery) {
+
+		tri := mesh.Triangles[triIndex]
+		ao := &pass.occlusion[triIndex]
+
+		for i, vertIndex := range tri.VertexIndices() {
+
+			normal := rotation.MultVec(mesh.VertexNormals[vertIndex])
+			if normal.Magnitude() == 0 {
+				continue
+			}
+			normal = normal.Unit()
+
+			// The tangent and bitangent complete the frame the rays are cast in.
+			up := vector.Vector{0, 1, 0}
+			if math.Abs(normal[1]) > 0.9 {
+				up = vector.Vector{1, 0, 0}
+			}
+			tangent, _ := up.Cross(normal)
+			tangent = tangent.Unit()
+			bitangent, _ := normal.Cross(tangent)
+
+			origin := pass.transform.MultVec(mesh.VertexPositions[vertIndex])
+			vector.In(origin).Add(normal.Scale(bias))
+
+			candidates := grid.candidates(scratch, origin, rayDistance)
+
+			hits := 0
+
+			for _, ray := range rays {
+
+				direction := vector.Vector{
+					tangent[0]*ray[0] + bitangent[0]*ray[1] + normal[0]*ray[2],
+					tangent[1]*ray[0] + bitangent[1]*ray[1] + normal[1]*ray[2],
+					tangent[2]*ray[0] + bitangent[2]*ray[1] + normal[2]*ray[2],
+				}
+
+				for _, c := range candidates {
+					verts := occluderVerts[c]
+					if t, hit := rayTriangleIntersection(origin, direction, verts[0], verts[1], verts[2]); hit && t <= rayDistance {
+						hits++
+						break
+					}
+				}
+
+			}
+
+			ao[i] = float32(hits) / float32(rayCount)
+
+		}
+
+	}
+
+}
+
+// apply mixes the AO color into the Model's vertex colors according to the occlusion calculated for each pass.
+func (bake *aoBake) apply() {
+
+	mesh := bake.model.Mesh
+
+	for _, pass := range bake.passes {
+
+		for triIndex, tri := range mesh.Triangles {
+
+			verts := tri.VertexIndices()
+
+			for i := 0; i < 3; i++ {
+				mesh.VertexColors[verts[i]][bake.options.TargetChannel].Mix(bake.options.Color, pass.occlusion[triIndex][i])
+			}
+
+		}
+
+	}
+
+}
+
+// aoGrid is a uniform spatial grid used to quickly find triangles that may be close enough to influence each other when baking AO.
+// Each item has a center and a radius of influence, and two items are candidates for each other if either one's center lies within
+// the other's radius. Items with very large radii are kept in a separate list, as they're candidates for everything.
+type aoGrid struct {
+	cellSize float64
+	cells    map[[3]int][]int
+	large    []int
+	count    int
+}
+
+// aoGridQuery holds scratch memory for querying an aoGrid; each goroutine querying the grid needs its own.
+type aoGridQuery struct {
+	visited []uint32
+	stamp   uint32
+	results []int
+}
+
+// aoGridLargeRadius is how many cells an item's radius can span before it's considered large.
+const aoGridLargeRadius = 4
+
+func newAOGrid(centers []vector.Vector, radii []float64) *aoGrid {
+
+	grid := &aoGrid{
+		cells: map[[3]int][]int{},
+		count: len(centers),
+	}
+
+	for _, r := range radii {
+		grid.cellSize += r
+	}
+
+	if len(radii) > 0 {
+		grid.cellSize /= float64(len(radii))
+	}
+
+	if grid.cellSize <= 0 {
+		grid.cellSize = 1
+	}
+
+	for i, center := range centers {
+
+		if radii[i] > grid.cellSize*aoGridLargeRadius {
+			grid.large = append(grid.large, i)
+			continue
+		}
+
+		min, max := grid.cellRange(center, radii[i])
+
+		for x := min[0]; x <= max[0]; x++ {
+			for y := min[1]; y <= max[1]; y++ {
+				for z := min[2]; z <= max[2]; z++ {
+					cell := [3]int{x, y, z}
+					grid.cells[cell] = append(grid.cells[cell], i)
+				}
+			}
+		}
+
+	}
+
+	return grid
+
+}
+
+// cellRange returns the range of cells covered by a sphere with the given center and radius; the radius is padded slightly so that
+// floating-point error can't cause any candidates to be missed.
+func (grid *aoGrid) cellRange(center vector.Vector, radius float64) ([3]int, [3]int) {
+
+	radius = radius*1.001 + 0.000001
+
+	min := [3]int{}
+	max := [3]int{}
+
+	for i := 0; i < 3; i++ {
+		min[i] = int(math.Floor((center[i] - radius) / grid.cellSize))
+		max[i] = int(math.Floor((center[i] + radius) / grid.cellSize))
+	}
+
+	return min, max
+
+}
+
+// candidates returns the indices of the items that may influence (or be influenced by) a sphere with the given center and radius.
+// The returned slice is owned by the query and is only valid until the next call.
+func (grid *aoGrid) candidates(query *aoGridQuery, center vector.Vector, radius float64) []int {
+
+	query.results = query.results[:0]
+
+	if radius > grid.cellSize*aoGridLargeRadius {
+		for i := 0; i < grid.count; i++ {
+			query.results = append(query.results, i)
+		}
+		return query.results
+	}
+
+	if len(query.visited) < grid.count {
+		query.visited = make([]uint32, grid.count)
+		query.stamp = 0
+	}
+
+	query.stamp++
+
+	query.results = append(query.results, grid.large...)
+	for _, i := range grid.large {
+		query.visited[i] = query.stamp
+	}
+
+	min, max := grid.cellRange(center, radius)
+
+	for x := min[0]; x <= max[0]; x++ {
+		for y := min[1]; y <= max[1]; y++ {
+			for z := min[2]; z <= max[2]; z++ {
+				for _, i := range grid.cells[[3]int{x, y, z}] {
+					if query.visited[i] != query.stamp {
+						query.visited[i] = query.stamp
+						query.results = append(query.results, i)
+					}
+				}
+			}
+		}
+	}
+
+	return query.results
+
+}
@@ -0,0 +1,251 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kvartborg/vector"
+)
+
+// bakeAOReference is the original brute-force implementation of Model.BakeAO(), used to ensure that the grid-accelerated version
+// produces the same results.
+func bakeAOReference(model *Model, bakeOptions *AOBakeOptions) {
+
+	if bakeOptions == nil {
+		bakeOptions = NewDefaultAOBakeOptions()
+	}
+
+	if model.Mesh == nil || bakeOptions.TargetChannel < 0 {
+		return
+	}
+
+	model.Mesh.ensureEnoughVertexColorChannels(bakeOptions.TargetChannel)
+
+	// Same model AO first
+
+	for _, tri := range model.Mesh.Triangles {
+
+		ao := [3]float32{0, 0, 0}
+
+		verts := tri.VertexIndices()
+
+		for _, other := range model.Mesh.Triangles {
+
+			if tri == other || vectorsEqual(tri.Normal, other.Normal) {
+				continue
+			}
+
+			span := tri.MaxSpan
+			if other.MaxSpan > span {
+				span = other.MaxSpan
+			}
+
+			span *= 0.66
+
+			if fastVectorDistanceSquared(tri.Center, other.Center) > span*span {
+				continue
+			}
+
+			angle := tri.Normal.Angle(other.Normal)
+			if angle < bakeOptions.OcclusionAngle {
+				continue
+			}
+
+			if shared := tri.SharesVertexPositions(other); shared != nil {
+
+				if shared[0] >= 0 {
+					ao[0] = 1
+				}
+				if shared[1] >= 0 {
+					ao[1] = 1
+				}
+				if shared[2] >= 0 {
+					ao[2] = 1
+				}
+
+			}
+
+		}
+
+		for i := 0; i < 3; i++ {
+			model.Mesh.VertexColors[verts[i]][bakeOptions.TargetChannel].Mix(bakeOptions.Color, ao[i])
+		}
+
+	}
+
+	// Inter-object AO next; this is kinda slow and janky, but it does work OK, I think
+
+	transform := model.Transform()
+
+	distanceSquared := bakeOptions.InterModelDistance * bakeOptions.InterModelDistance
+
+	for _, other := range bakeOptions.OtherModels {
+
+		rad := model.BoundingSphere.WorldRadius()
+		if or := other.BoundingSphere.WorldRadius(); or > rad {
+			rad = or
+		}
+		if model == other || fastVectorDistanceSquared(model.WorldPosition(), other.WorldPosition()) > rad*rad {
+			continue
+		}
+
+		otherTransform := other.Transform()
+
+		for _, tri := range model.Mesh.Triangles {
+
+			ao := [3]float32{0, 0, 0}
+
+			verts := tri.VertexIndices()
+
+			transformedTriVerts := [3]vector.Vector{
+				transform.MultVec(model.Mesh.VertexPositions[verts[0]]),
+				transform.MultVec(model.Mesh.VertexPositions[verts[1]]),
+				transform.MultVec(model.Mesh.VertexPositions[verts[2]]),
+			}
+
+			for _, otherTri := range other.Mesh.Triangles {
+
+				otherVerts := otherTri.VertexIndices()
+
+				span := tri.MaxSpan
+				if otherTri.MaxSpan > span {
+					span = otherTri.MaxSpan
+				}
+
+				span *= 0.66
+
+				if fastVectorDistanceSquared(transform.MultVec(tri.Center), otherTransform.MultVec(otherTri.Center)) > span {
+					continue
+				}
+
+				transformedOtherVerts := [3]vector.Vector{
+					otherTransform.MultVec(other.Mesh.VertexPositions[otherVerts[0]]),
+					otherTransform.MultVec(other.Mesh.VertexPositions[otherVerts[1]]),
+					otherTransform.MultVec(other.Mesh.VertexPositions[otherVerts[2]]),
+				}
+
+				for i := 0; i < 3; i++ {
+					for j := 0; j < 3; j++ {
+						if fastVectorDistanceSquared(transformedTriVerts[i], transformedOtherVerts[j]) <= distanceSquared {
+							ao[i] = 1
+							break
+						}
+					}
+				}
+
+			}
+
+			for i := 0; i < 3; i++ {
+				model.Mesh.VertexColors[verts[i]][bakeOptions.TargetChannel].Mix(bakeOptions.Color, ao[i])
+			}
+
+		}
+
+	}
+
+}
+
+// newAOTestScene creates a few overlapping cubes to bake AO on.
+func newAOTestScene() (*Model, []*Model) {
+
+	target := NewModel(NewCube(), "target")
+	target.SetLocalScale(4, 1, 4)
+
+	others := []*Model{}
+
+	for i := 0; i < 3; i++ {
+		other := NewModel(NewCube(), "other")
+		other.SetLocalPosition(float64(i)*1.5-1.5, 1.5, 0.5)
+		others = append(others, other)
+	}
+
+	return target, others
+
+}
+
+func TestBakeAOMatchesReference(t *testing.T) {
+
+	sameColors := func(a, b *Mesh) bool {
+		for i := range a.VertexColors {
+			for c := range a.VertexColors[i] {
+				ca, cb := a.VertexColors[i][c], b.VertexColors[i][c]
+				if ca.R != cb.R || ca.G != cb.G || ca.B != cb.B || ca.A != cb.A {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	reference, referenceOthers := newAOTestScene()
+	options := NewDefaultAOBakeOptions()
+	options.OtherModels = referenceOthers
+	bakeAOReference(reference, options)
+
+	baked, bakedOthers := newAOTestScene()
+	options = NewDefaultAOBakeOptions()
+	options.OtherModels = bakedOthers
+	baked.BakeAO(options)
+
+	if !sameColors(reference.Mesh, baked.Mesh) {
+		t.Fatal("BakeAO() results differ from the brute-force reference implementation")
+	}
+
+	async, asyncOthers := newAOTestScene()
+	options = NewDefaultAOBakeOptions()
+	options.OtherModels = asyncOthers
+	job := async.BakeAOAsync(options)
+	job.Wait()
+
+	if !job.Done() || job.Progress() != 1 {
+		t.Fatalf("BakeJob should be done with a progress of 1 after waiting; progress is %f", job.Progress())
+	}
+
+	if !sameColors(reference.Mesh, async.Mesh) {
+		t.Fatal("BakeAOAsync() results differ from the brute-force reference implementation")
+	}
+
+}
+
+func TestBakeAORaycast(t *testing.T) {
+
+	// A floor with a wall standing on its left edge; the vertices in the corner are occluded, while the ones on the far edge aren't.
+	floor := NewModel(NewPlane(), "floor")
+	wall := NewModel(NewPlane(), "wall")
+	wall.SetLocalRotation(NewMatrix4Rotate(0, 0, 1, math.Pi/2))
+	wall.SetLocalPosition(-1, 1, 0)
+
+	options := NewDefaultAOBakeOptions()
+	options.Mode = AOBakeModeRaycast
+	options.OtherModels = []*Model{wall}
+	floor.BakeAO(options)
+
+	for i, pos := range floor.Mesh.VertexPositions {
+
+		shade := floor.Mesh.VertexColors[i][0].R
+
+		if pos[0] < 0 && (shade >= 0.95 || shade < 0.4) {
+			t.Errorf("expected vertex %d in the corner to be partially occluded, got %f", i, shade)
+		} else if pos[0] > 0 && shade != 1 {
+			t.Errorf("expected vertex %d away from the wall to be unoccluded, got %f", i, shade)
+		}
+
+	}
+
+}
+
+func BenchmarkBakeAO(b *testing.B) {
+
+	mesh := NewIcosphere(3) // 5120 triangles
+
+	options := NewDefaultAOBakeOptions()
+	options.OcclusionAngle = ToRadians(5)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		model := NewModel(mesh, "icosphere")
+		model.BakeAO(options)
+	}
+
+}
@@ -0,0 +1,217 @@
+package tetra3d
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/kvartborg/vector"
+)
+
+// BlobShadow is a cheap alternative to real shadows; it's a soft, dark, circular shadow that is projected straight down from a target Model
+// onto the ground beneath it. The ground is found by casting a ray straight down from the center of the target Model's BoundingSphere
+// against the triangles of the Models specified as the ground. The shadow is displayed using a transparent, shadeless quad Model aligned
+// to the normal of the ground triangle it lands on.
+// To use it, create a BlobShadow, add the BlobShadow's Model to your scene, and then call BlobShadow.Update() every frame after moving
+// the target Model.
+type BlobShadow struct {
+	Model  *Model  // The Model used to display the shadow. Add this to your scene's hierarchy to render it.
+	Target *Model  // The Model casting the shadow.
+	Ground []INode // The Nodes that the shadow can land on. Models in the Ground Nodes' hierarchies are also checked.
+	Color  *Color  // The color of the shadow; defaults to black at 50% opacity.
+
+	MaxDistance      float64 // The maximum distance from the bottom of the Target's BoundingSphere to the ground for the shadow to be visible.
+	FadeWithHeight   bool    // If the shadow should fade out the further the Target is from the ground. Defaults to true.
+	ShrinkWithHeight bool    // If the shadow should shrink the further the Target is from the ground. Defaults to true.
+	Scale            float64 // A multiplier for the size of the shadow; at 1 (the default), it's as large as the Target's BoundingSphere.
+	Offset           float64 // How far the shadow is lifted from the ground along the ground's normal to prevent z-fighting. Defaults to 0.01.
+}
+
+// NewBlobShadow creates a new BlobShadow for the target Model, with the shadow fading out over the maxDistance specified, and landing on
+// the ground Nodes provided.
+func NewBlobShadow(target *Model, maxDistance float64, ground ...INode) *BlobShadow {
+
+	mesh := NewPlane()
+	mesh.Name = target.name + " Blob Shadow"
+
+	mat := mesh.MeshParts[0].Material
+	mat.Name = mesh.Name
+	mat.Texture = newBlobShadowTexture(64)
+	mat.TextureWrapMode = ebiten.AddressClampToZero
+	mat.TextureFilterMode = ebiten.FilterLinear
+	mat.TransparencyMode = TransparencyModeTransparent
+	mat.Shadeless = true
+
+	blob := &BlobShadow{
+		Model:            NewModel(mesh, mesh.Name),
+		Target:           target,
+		Ground:           ground,
+		Color:            NewColor(0, 0, 0, 0.5),
+		MaxDistance:      maxDistance,
+		FadeWithHeight:   true,
+		ShrinkWithHeight: true,
+		Scale:            1,
+		Offset:           0.01,
+	}
+
+	blob.Model.SetVisible(false, false)
+
+	return blob
+
+}
+
+// newBlobShadowTexture creates a square texture of the given size containing a soft, white radial gradient.
+func newBlobShadowTexture(size int) *ebiten.Image {
+
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	half := float64(size) / 2
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dist := math.Hypot(float64(x)+0.5-half, float64(y)+0.5-half) / half
+			alpha := math.Max(0, 1-dist)
+			alpha *= alpha
+			v := uint8(alpha * 255) // Premultiplied alpha, so the color components match the alpha component
+			img.SetRGBA(x, y, color.RGBA{v, v, v, v})
+		}
+	}
+
+	return ebiten.NewImageFromImage(img)
+
+}
+
+// Update updates the BlobShadow's Model to be positioned on the ground beneath the Target Model. If there is no ground beneath the Target
+// within the BlobShadow's MaxDistance, the shadow's Model is made invisible.
+func (blob *BlobShadow) Update() {
+
+	blob.Target.Transform()
+
+	origin := blob.Target.BoundingSphere.WorldPosition()
+	radius := blob.Target.BoundingSphere.WorldRadius()
+
+	hitPosition, hitNormal, hitDistance, hit := blob.groundBelow(origin, radius+blob.MaxDistance)
+
+	if !hit {
+		blob.Model.SetVisible(false, false)
+		return
+	}
+
+	heightPerc := 0.0
+	if blob.MaxDistance > 0 {
+		heightPerc = math.Max(0, hitDistance-radius) / blob.MaxDistance
+	}
+
+	alpha := blob.Color.A
+	size := radius * blob.Scale
+
+	if blob.FadeWithHeight {
+		alpha *= float32(1 - heightPerc)
+	}
+
+	if blob.ShrinkWithHeight {
+		size *= 1 - (heightPerc * 0.5)
+	}
+
+	blob.Model.SetVisible(alpha > 0, false)
+	blob.Model.Color.Set(blob.Color.R, blob.Color.G, blob.Color.B, alpha)
+
+	// The plane Mesh faces up (+Y), so we align its up vector with the ground's normal.
+	perpendicular := vector.Vector{0, 0, 1}
+	if math.Abs(dot(perpendicular, hitNormal)) > 0.99 {
+		perpendicular = vector.Vector{1, 0, 0}
+	}
+	perpendicular = perpendicular.Sub(hitNormal.Scale(dot(perpendicular, hitNormal))).Unit()
+
+	blob.Model.SetWorldRotation(NewLookAtMatrix(vector.Vector{0, 0, 0}, perpendicular, hitNormal))
+	blob.Model.SetWorldScale(size, size, size)
+	blob.Model.SetWorldPositionVec(hitPosition.Add(hitNormal.Scale(blob.Offset)))
+
+}
+
+// groundBelow casts a ray straight down from the origin provided against the triangles of the Models in the BlobShadow's Ground,
+// returning the closest hit position, the ground's normal at that position, and the distance from the origin.
+func (blob *BlobShadow) groundBelow(origin vector.Vector, maxDistance float64) (vector.Vector, vector.Vector, float64, bool) {
+
+	var hitPosition, hitNormal vector.Vector
+	hitDistance := math.MaxFloat64
+	hit := false
+
+	down := vector.Vector{0, -1, 0}
+
+	models := []*Model{}
+
+	for _, node := range blob.Ground {
+		if model, ok := node.(*Model); ok {
+			models = append(models, model)
+		}
+		models = append(models, node.ChildrenRecursive().Models()...)
+	}
+
+	for _, model := range models {
+
+		if model == blob.Target || model == blob.Model || model.Mesh == nil {
+			continue
+		}
+
+		transform := model.Transform()
+
+		// Skip Models that the ray can't possibly hit.
+		modelPos := model.BoundingSphere.WorldPosition()
+		modelRadius := model.BoundingSphere.WorldRadius()
+		if math.Hypot(modelPos[0]-origin[0], modelPos[2]-origin[2]) > modelRadius || modelPos[1]-modelRadius > origin[1] {
+			continue
+		}
+
+		// Rather than transforming all of the triangles, we transform the ray into the Model's local space.
+		inverted := transform.Inverted()
+		localOrigin := inverted.MultVec(origin)
+		localDir := inverted.MultVec(origin.Add(down)).Sub(localOrigin)
+
+		for _, tri := range model.Mesh.Triangles {
+
+			v0 := model.Mesh.VertexPositions[tri.ID*3]
+			v1 := model.Mesh.VertexPositions[tri.ID*3+1]
+			v2 := model.Mesh.VertexPositions[tri.ID*3+2]
+
+			t, ok := rayTriangleIntersection(localOrigin, localDir, v0, v1, v2)
+			if !ok {
+				continue
+			}
+
+			worldHit := transform.MultVec(localOrigin.Add(localDir.Scale(t)))
+			distance := origin[1] - worldHit[1]
+
+			if distance < hitDistance && distance <= maxDistance {
+				hitDistance = distance
+				hitPosition = worldHit
+				// The normal's calculated from the triangle's world-space vertices so that it stays correct for non-uniformly scaled Models.
+				hitNormal = calculateNormal(transform.MultVec(v0), transform.MultVec(v1), transform.MultVec(v2))
+				if hitNormal[1] < 0 {
+					hitNormal = hitNormal.Invert()
+				}
+				hit = true
+			}
+
+		}
+
+	}
+
+	return hitPosition, hitNormal, hitDistance, hit
+
+}
+
+// rayTriangleIntersection returns the distance (as a multiple of the direction vector) along the ray from the origin to the triangle
+// composed of the vertices v0, v1, and v2, and a boolean indicating if the ray intersects the triangle at all. Triangles are hit
+// regardless of which way they face.
+func rayTriangleIntersection(origin, direction, v0, v1, v2 vector.Vector) (float64, bool) {
+
+	t, _, _, _, hit := rayTriangleBarycentric(origin, direction, v0, v1, v2)
+
+	if !hit || t < 0 {
+		return 0, false
+	}
+
+	return t, true
+
+}
@@ -0,0 +1,83 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kvartborg/vector"
+)
+
+func TestBlobShadow(t *testing.T) {
+
+	scene := NewScene("blob shadow test")
+
+	ground := NewModel(NewPlane(), "ground")
+	ground.SetLocalScale(10, 1, 10)
+
+	target := NewModel(NewCube(), "target")
+	target.SetLocalPosition(2, 3, 1)
+
+	scene.Root.AddChildren(ground, target)
+
+	blob := NewBlobShadow(target, 5, ground)
+	scene.Root.AddChildren(blob.Model)
+
+	blob.Update()
+
+	if !blob.Model.Visible() {
+		t.Fatalf("the blob shadow should be visible on the ground beneath the target")
+	}
+
+	if pos := blob.Model.WorldPosition(); pos.Sub(vector.Vector{2, blob.Offset, 1}).Magnitude() > 1e-6 {
+		t.Fatalf("expected the blob shadow to lie just above the ground beneath the target, got %v", pos)
+	}
+
+	if up := blob.Model.WorldRotation().Up(); up.Sub(vector.Vector{0, 1, 0}).Magnitude() > 1e-6 {
+		t.Fatalf("expected the blob shadow to face up from the flat ground, got %v", up)
+	}
+
+	// Too far above the ground, the shadow disappears.
+	target.SetLocalPosition(2, 20, 1)
+	blob.Update()
+
+	if blob.Model.Visible() {
+		t.Fatalf("the blob shadow shouldn't be visible when the target is beyond its MaxDistance from the ground")
+	}
+
+	// A slope where y = x, which the Model's non-uniform scale stretches to y = 2x.
+	slopeMesh := NewMesh("slope")
+	slopeMesh.AddMeshPart(NewMaterial("slope")).AddTriangles(
+		NewVertex(1, 1, -1, 1, 0),
+		NewVertex(-1, -1, -1, 0, 0),
+		NewVertex(1, 1, 1, 1, 1),
+
+		NewVertex(-1, -1, -1, 0, 0),
+		NewVertex(-1, -1, 1, 0, 1),
+		NewVertex(1, 1, 1, 1, 1),
+	)
+	slopeMesh.UpdateBounds()
+	slopeMesh.AutoNormal()
+
+	slope := NewModel(slopeMesh, "slope")
+	slope.SetLocalScale(1, 2, 1)
+	scene.Root.AddChildren(slope)
+
+	blob.Ground = []INode{slope}
+	target.SetLocalPosition(0.5, 4, 0)
+	blob.Update()
+
+	normal := vector.Vector{-2, 1, 0}.Unit()
+
+	if pos := blob.Model.WorldPosition(); pos.Sub(vector.Vector{0.5, 1, 0}.Add(normal.Scale(blob.Offset))).Magnitude() > 1e-6 {
+		t.Fatalf("expected the blob shadow to lie just above the slope beneath the target, got %v", pos)
+	}
+
+	if up := blob.Model.WorldRotation().Up(); up.Sub(normal).Magnitude() > 1e-6 {
+		t.Fatalf("expected the blob shadow to face along the scaled slope's normal %v, got %v", normal, up)
+	}
+
+	if angle := math.Acos(dot(blob.Model.WorldRotation().Up(), vector.Vector{0, 1, 0})); math.Abs(angle-math.Atan(2)) > 1e-6 {
+		t.Fatalf("expected the blob shadow to tilt %f radians on the slope, got %f", math.Atan(2), angle)
+	}
+
+}
@@ -170,6 +170,15 @@ type IBoundingObject interface {
 	// object.
 	// Collisions will be sorted in order of distance. If no Collisions occurred, it will return an empty slice.
 	CollisionTestVec(moveVec vector.Vector, others ...INode) []*Collision
+	// Sweep moves the BoundingObject along the given movement vector in world space, returning where it first hits any of the
+	// BoundingObjects in the trees of the INodes provided in others, or nil if it doesn't hit anything; unlike CollisionTestVec(),
+	// this catches everything along the way, rather than just at the end. The BoundingObject isn't actually moved.
+	Sweep(moveVec vector.Vector, others ...INode) *SweepHit
+	// CollidesWith returns if the BoundingObject's CollisionMask includes any of the other BoundingObject's CollisionLayers (that is,
+	// if collision tests of this BoundingObject test against the other one); see CollisionFilter.
+	CollidesWith(other IBoundingObject) bool
+
+	collisionFilter() *CollisionFilter
 }
 
 // The below set of bt functions are used to test for intersection between BoundingObject pairs.
@@ -234,19 +243,24 @@ func btSphereAABB(sphere *BoundingSphere, aabb *BoundingAABB) *Collision {
 
 func btSphereTriangles(sphere *BoundingSphere, triangles *BoundingTriangles) *Collision {
 
+	// Getting the transform first also updates the triangles' bounding AABB.
+	triTrans := triangles.Transform()
+
 	// If we're not intersecting the triangle's bounding AABB, we couldn't possibly be colliding with any of the triangles, so we're good
 	if !sphere.Colliding(triangles.BoundingAABB) {
 		return nil
 	}
 
-	triTrans := triangles.Transform()
 	invertedTransform := triTrans.Inverted()
 	transformNoLoc := triTrans.Clone()
 	transformNoLoc.SetRow(3, vector.Vector{0, 0, 0, 1})
 
 	sphereWorldPosition := sphere.WorldPosition()
 	spherePos := invertedTransform.MultVec(sphereWorldPosition)
-	sphereRadius := sphere.WorldRadius() * math.Abs(math.Max(invertedTransform[0][0], math.Max(invertedTransform[1][1], invertedTransform[2][2])))
+	// The radius is divided by the triangles' smallest scale so that it's never smaller in their local space than it is in world space
+	// (the inverted transform's diagonal can't be used for this, as it includes the triangles' rotation).
+	triScale := triangles.WorldScale()
+	sphereRadius := sphere.WorldRadius() / math.Min(math.Abs(triScale[0]), math.Min(math.Abs(triScale[1]), math.Abs(triScale[2])))
 
 	result := newCollision(triangles)
 
@@ -372,6 +386,9 @@ func btAABBAABB(aabbA, aabbB *BoundingAABB) *Collision {
 func btAABBTriangles(box *BoundingAABB, triangles *BoundingTriangles) *Collision {
 	// See https://gdbooks.gitbooks.io/3dcollisions/content/Chapter4/aabb-triangle.html
 
+	// Getting the transform first also updates the triangles' bounding AABB.
+	triangles.Transform()
+
 	// If we're not intersecting the triangle's bounding AABB, we couldn't possibly be colliding with any of the triangles, so we're good
 	if !box.Colliding(triangles.BoundingAABB) {
 		return nil
@@ -485,14 +502,15 @@ func btAABBTriangles(box *BoundingAABB, triangles *BoundingTriangles) *Collision
 func btTrianglesTriangles(trianglesA, trianglesB *BoundingTriangles) *Collision {
 	// See https://gdbooks.gitbooks.io/3dcollisions/content/Chapter4/aabb-triangle.html
 
+	// Getting the transforms first also updates the triangles' bounding AABBs.
+	transformA := trianglesA.Transform()
+	transformB := trianglesB.Transform()
+
 	// If we're not intersecting the triangle's bounding AABB, we couldn't possibly be colliding with any of the triangles, so we're good
 	if !trianglesA.BoundingAABB.Colliding(trianglesB.BoundingAABB) {
 		return nil
 	}
 
-	transformA := trianglesA.Transform()
-	transformB := trianglesB.Transform()
-
 	transformedA := [][]vector.Vector{}
 	transformedB := [][]vector.Vector{}
 
@@ -633,18 +651,15 @@ func btTrianglesTriangles(trianglesA, trianglesB *BoundingTriangles) *Collision
 }
 
 func btCapsuleCapsule(capsuleA, capsuleB *BoundingCapsule) *Collision {
-	capsuleA.internalSphere.SetLocalScaleVec(capsuleA.LocalScale())
 
-	// By getting the closest point to the world position (center), and then getting it again, we get closer to the
-	// true closest point for both capsules, which is good enough for now lol
-	caClosest := capsuleA.ClosestPoint(capsuleB.WorldPosition())
-	cbClosest := capsuleB.ClosestPoint(capsuleA.WorldPosition())
+	aPoint, bPoint := closestPointsBetweenSegments(capsuleA.lineBottom(), capsuleA.lineTop(), capsuleB.lineBottom(), capsuleB.lineTop())
 
-	capsuleA.internalSphere.SetLocalPositionVec(capsuleA.ClosestPoint(cbClosest))
+	capsuleA.internalSphere.SetLocalScaleVec(capsuleA.LocalScale())
+	capsuleA.internalSphere.SetLocalPositionVec(aPoint)
 	capsuleA.internalSphere.Radius = capsuleA.Radius
 
 	capsuleB.internalSphere.SetLocalScaleVec(capsuleB.LocalScale())
-	capsuleB.internalSphere.SetLocalPositionVec(capsuleB.ClosestPoint(caClosest))
+	capsuleB.internalSphere.SetLocalPositionVec(bPoint)
 	capsuleB.internalSphere.Radius = capsuleB.Radius
 
 	return btSphereSphere(capsuleA.internalSphere, capsuleB.internalSphere)
@@ -658,47 +673,51 @@ func btSphereCapsule(sphere *BoundingSphere, capsule *BoundingCapsule) *Collisio
 }
 
 func btCapsuleAABB(capsule *BoundingCapsule, aabb *BoundingAABB) *Collision {
+
+	bottom, top := capsule.lineBottom(), capsule.lineTop()
+
+	// Alternately finding the closest point on the box to the capsule's line and the closest point on the line to the box
+	// converges on the point on the line that's closest to the box.
+	point := closestPointOnSegment(aabb.WorldPosition(), bottom, top)
+	for i := 0; i < capsuleAABBIterations; i++ {
+		point = closestPointOnSegment(aabb.ClosestPoint(point), bottom, top)
+	}
+
 	capsule.internalSphere.SetLocalScaleVec(capsule.LocalScale())
-	capsule.internalSphere.SetLocalPositionVec(capsule.ClosestPoint(aabb.WorldPosition()))
+	capsule.internalSphere.SetLocalPositionVec(point)
 	capsule.internalSphere.Radius = capsule.Radius
 	return btSphereAABB(capsule.internalSphere, aabb)
+
 }
 
 func btCapsuleTriangles(capsule *BoundingCapsule, triangles *BoundingTriangles) *Collision {
 
-	capsule.internalSphere.SetLocalScaleVec(capsule.LocalScale())
-	capsule.internalSphere.SetLocalPositionVec(capsule.ClosestPoint(triangles.BoundingAABB.WorldPosition()))
-	capsule.internalSphere.Radius = capsule.Radius
+	// Getting the transform first also updates the triangles' bounding AABB.
+	triTrans := triangles.Transform()
 
 	// If we're not intersecting the triangle's bounding AABB, we couldn't possibly be colliding with any of the triangles, so we're good
-	if !capsule.internalSphere.Colliding(triangles.BoundingAABB) {
+	if btCapsuleAABB(capsule, triangles.BoundingAABB) == nil {
 		return nil
 	}
 
-	triTrans := triangles.Transform()
 	invertedTransform := triTrans.Inverted()
 	transformNoLoc := triTrans.Clone()
 	transformNoLoc.SetRow(3, vector.Vector{0, 0, 0, 1})
 
-	capsuleRadius := capsule.WorldRadius() * math.Abs(math.Max(invertedTransform[0][0], math.Max(invertedTransform[1][1], invertedTransform[2][2])))
+	// The radius is divided by the triangles' smallest scale so that it's never smaller in their local space than it is in world space
+	// (the inverted transform's diagonal can't be used for this, as it includes the triangles' rotation).
+	triScale := triangles.WorldScale()
+	capsuleRadius := capsule.WorldRadius() / math.Min(math.Abs(triScale[0]), math.Min(math.Abs(triScale[1]), math.Abs(triScale[2])))
 
 	capsuleTop := invertedTransform.MultVec(capsule.lineTop())
 	capsuleBottom := invertedTransform.MultVec(capsule.lineBottom())
 	capsulePosition := invertedTransform.MultVec(capsule.WorldPosition())
-	capsuleLine := capsuleTop.Sub(capsuleBottom)
-	capSpread := capsuleLine.Magnitude() + capsuleRadius
-	capDot := dot(capsuleLine, capsuleLine)
-
-	var closestCapsulePoint vector.Vector
+	capSpread := capsuleTop.Sub(capsuleBottom).Magnitude() + capsuleRadius
 
 	result := newCollision(triangles)
 
 	tris := triangles.Broadphase.TrianglesFromBounding(capsule)
 
-	spherePos := vector.Vector{0, 0, 0}
-
-	closestSub := vector.Vector{0, 0, 0}
-
 	for triID := range tris {
 
 		tri := triangles.Mesh.Triangles[triID]
@@ -707,89 +726,199 @@ func btCapsuleTriangles(capsule *BoundingCapsule, triangles *BoundingTriangles)
 			continue
 		}
 
-		if fastVectorDistanceSquared(tri.Center, capsuleTop) < fastVectorDistanceSquared(tri.Center, capsuleBottom) {
-			closestCapsulePoint = capsuleTop
-		} else {
-			closestCapsulePoint = capsuleBottom
-		}
-
 		v0 := triangles.Mesh.VertexPositions[tri.ID*3]
 		v1 := triangles.Mesh.VertexPositions[tri.ID*3+1]
 		v2 := triangles.Mesh.VertexPositions[tri.ID*3+2]
 
-		closest := closestPointOnTri(closestCapsulePoint, v0, v1, v2)
-		closestSub[0] = closest[0] - capsuleBottom[0]
-		closestSub[1] = closest[1] - capsuleBottom[1]
-		closestSub[2] = closest[2] - capsuleBottom[2]
+		// Testing against the closest point anywhere along the capsule's line (rather than just at its ends) means that edges
+		// (like those of steps) poking into the side of the capsule are caught, too.
+		linePoint, closest := closestPointsSegmentTriangle(capsuleBottom, capsuleTop, v0, v1, v2)
 
-		// Doing this manually to avoid doing as much as possible~
+		delta := linePoint.Sub(closest)
+		mag := delta.Magnitude()
 
-		t := dot(closestSub, capsuleLine) / capDot
-
-		if t > 1 {
-			t = 1
+		if mag > capsuleRadius {
+			continue
 		}
-		if t < 0 {
-			t = 0
+
+		var mtv vector.Vector
+
+		if mag > 0 {
+			mtv = delta.Unit().Scale(capsuleRadius - mag)
+		} else {
+			// The capsule's line passes through the triangle, so we push it out along the triangle's normal, on the side its center is on.
+			mtv = tri.Normal.Scale(capsuleRadius)
+			if dot(mtv, capsulePosition.Sub(closest)) < 0 {
+				mtv = mtv.Invert()
+			}
 		}
 
-		spherePos[0] = capsuleBottom[0] + (capsuleLine[0] * t)
-		spherePos[1] = capsuleBottom[1] + (capsuleLine[1] * t)
-		spherePos[2] = capsuleBottom[2] + (capsuleLine[2] * t)
+		result.add(
+			&Intersection{
+				StartingPoint: triTrans.MultVec(linePoint),
+				ContactPoint:  triTrans.MultVec(closest),
+				MTV:           transformNoLoc.MultVec(mtv),
+				Triangle:      tri,
+				Normal:        transformNoLoc.MultVec(tri.Normal).Unit(),
+			},
+		)
 
-		delta := fastVectorSub(spherePos, closest)
+	}
 
-		if mag := delta.Magnitude(); mag <= capsuleRadius {
+	if len(result.Intersections) == 0 {
+		return nil
+	}
 
-			result.add(
-				&Intersection{
-					StartingPoint: closestCapsulePoint,
-					ContactPoint:  triTrans.MultVec(closest),
-					MTV:           transformNoLoc.MultVec(delta.Unit().Scale(capsuleRadius - mag)),
-					Triangle:      tri,
-					Normal:        transformNoLoc.MultVec(tri.Normal).Unit(),
-				},
-			)
+	result.sortResults()
+
+	return result
+
+}
+
+func btOBBOBB(boxA, boxB *BoundingOBB) *Collision {
+	return btBoxBox(boxA.shape(), boxB.shape(), boxB)
+}
+
+func btOBBAABB(box *BoundingOBB, aabb *BoundingAABB) *Collision {
+	return btBoxBox(box.shape(), aabbShape(aabb), aabb)
+}
+
+// btBoxBox tests the two boxes against each other using the separating axis theorem, returning a Collision against the given other
+// BoundingObject (which should be the second box) if they overlap.
+func btBoxBox(boxA, boxB boxShape, other INode) *Collision {
+
+	mtv := separatingAxesMTV(boxBoxAxes(boxA, boxB), boxA.corners(), boxB.corners())
+
+	if mtv == nil {
+		return nil
+	}
+
+	return newCollision(other).add(
+		&Intersection{
+			StartingPoint: boxA.center,
+			ContactPoint:  boxB.closestPoint(boxA.closestPoint(boxB.center)),
+			MTV:           mtv,
+			Normal:        mtv.Unit(),
+		},
+	)
+
+}
+
+func btSphereOBB(sphere *BoundingSphere, box *BoundingOBB) *Collision {
+
+	shape := box.shape()
+	spherePos := sphere.WorldPosition()
+	sphereRadius := sphere.WorldRadius()
+
+	closest := shape.closestPoint(spherePos)
+	delta := spherePos.Sub(closest)
+	distance := delta.Magnitude()
+
+	if distance > sphereRadius {
+		return nil
+	}
+
+	var mtv, normal vector.Vector
+
+	if distance > 0 {
+		mtv = delta.Unit().Scale(sphereRadius - distance)
+		normal = shape.faceNormal(spherePos.Sub(shape.center))
+	} else {
+
+		// The sphere's center is inside of the box, so we push it out through the closest face.
+		fromCenter := spherePos.Sub(shape.center)
+		shallowest := math.MaxFloat64
+
+		for i, axis := range shape.axes {
+
+			d := dot(fromCenter, axis)
+
+			if depth := shape.half[i] - math.Abs(d); depth < shallowest {
+				shallowest = depth
+				normal = axis.Clone()
+				if d < 0 {
+					normal = normal.Invert()
+				}
+			}
 
 		}
 
-		// if fastVectorSub(capsulePosition, tri.Center).Magnitude() > (tri.MaxSpan*0.66)+capSpread {
-		// 	continue
-		// }
+		mtv = normal.Scale(shallowest + sphereRadius)
 
-		// if fastVectorDistanceSquared(tri.Center, capsuleTop) < fastVectorDistanceSquared(tri.Center, capsuleBottom) {
-		// 	closestCapsulePoint = capsuleTop
-		// } else {
-		// 	closestCapsulePoint = capsuleBottom
-		// }
+	}
+
+	return newCollision(box).add(
+		&Intersection{
+			StartingPoint: spherePos,
+			ContactPoint:  closest,
+			MTV:           mtv,
+			Normal:        normal,
+		},
+	)
+
+}
+
+func btCapsuleOBB(capsule *BoundingCapsule, box *BoundingOBB) *Collision {
+
+	shape := box.shape()
+	bottom, top := capsule.lineBottom(), capsule.lineTop()
+
+	// See btCapsuleAABB().
+	point := closestPointOnSegment(shape.center, bottom, top)
+	for i := 0; i < capsuleAABBIterations; i++ {
+		point = closestPointOnSegment(shape.closestPoint(point), bottom, top)
+	}
+
+	capsule.internalSphere.SetLocalScaleVec(capsule.LocalScale())
+	capsule.internalSphere.SetLocalPositionVec(point)
+	capsule.internalSphere.Radius = capsule.Radius
+	return btSphereOBB(capsule.internalSphere, box)
+
+}
+
+func btOBBTriangles(box *BoundingOBB, triangles *BoundingTriangles) *Collision {
+
+	// Getting the transform first also updates the triangles' bounding AABB.
+	transform := triangles.Transform()
+
+	// If we're not intersecting the triangle's bounding AABB, we couldn't possibly be colliding with any of the triangles, so we're good
+	if btOBBAABB(box, triangles.BoundingAABB) == nil {
+		return nil
+	}
 
-		// v0 := triangles.Mesh.VertexPositions[tri.ID*3]
-		// v1 := triangles.Mesh.VertexPositions[tri.ID*3+1]
-		// v2 := triangles.Mesh.VertexPositions[tri.ID*3+2]
+	shape := box.shape()
+	corners := shape.corners()
 
-		// closest := closestPointOnTri(closestCapsulePoint, v0, v1, v2)
+	transformNoLoc := transform.Clone()
+	transformNoLoc.SetRow(3, vector.Vector{0, 0, 0, 1})
 
-		// // Doing this manually to avoid doing as much as possible~
+	result := newCollision(triangles)
 
-		// t := dot(closest.Sub(capsuleBottom), capsuleLine) / capDot
-		// t = math.Max(math.Min(t, 1), 0)
-		// spherePos := capsuleBottom.Add(capsuleLine.Scale(t))
+	tris := triangles.Broadphase.TrianglesFromBounding(box)
 
-		// delta := fastVectorSub(spherePos, closest)
+	for triID := range tris {
 
-		// if mag := delta.Magnitude(); mag <= capsuleRadius {
+		tri := triangles.Mesh.Triangles[triID]
 
-		// 	result.add(
-		// 		&Intersection{
-		// 			StartingPoint: closest,
-		// 			ContactPoint:  triangles.Transform().MultVec(closest),
-		// 			MTV:           transformNoLoc.MultVec(delta.Unit().Scale(capsuleRadius - mag)),
-		// 			Triangle:      tri,
-		// 			Normal:        transformNoLoc.MultVec(tri.Normal).Unit(),
-		// 		},
-		// 	)
+		v0 := transform.MultVec(triangles.Mesh.VertexPositions[tri.ID*3])
+		v1 := transform.MultVec(triangles.Mesh.VertexPositions[tri.ID*3+1])
+		v2 := transform.MultVec(triangles.Mesh.VertexPositions[tri.ID*3+2])
 
-		// }
+		mtv := separatingAxesMTV(boxTriangleAxes(shape, v0, v1, v2), corners, []vector.Vector{v0, v1, v2})
+
+		if mtv == nil {
+			continue
+		}
+
+		result.add(
+			&Intersection{
+				StartingPoint: shape.center,
+				ContactPoint:  closestPointOnTri(shape.center, v0, v1, v2).Clone(),
+				MTV:           mtv,
+				Triangle:      tri,
+				Normal:        transformNoLoc.MultVec(tri.Normal).Unit(),
+			},
+		)
 
 	}
 
@@ -803,6 +932,81 @@ func btCapsuleTriangles(capsule *BoundingCapsule, triangles *BoundingTriangles)
 
 }
 
+func btConvexShape(convex *BoundingConvex, other IBoundingObject) *Collision {
+
+	supportA, centerA := shapeSupport(convex)
+	supportB, centerB := shapeSupport(other)
+
+	mtv, contact := convexCollision(supportA, supportB, centerA, centerB)
+
+	if mtv == nil {
+		return nil
+	}
+
+	return newCollision(other.(INode)).add(
+		&Intersection{
+			StartingPoint: centerA,
+			ContactPoint:  contact,
+			MTV:           mtv,
+			Normal:        mtv.Scale(1 / mtv.Magnitude()),
+		},
+	)
+
+}
+
+func btConvexTriangles(convex *BoundingConvex, triangles *BoundingTriangles) *Collision {
+
+	// Getting the transform first also updates the triangles' bounding AABB.
+	transform := triangles.Transform()
+
+	// If we're not intersecting the triangle's bounding AABB, we couldn't possibly be colliding with any of the triangles, so we're good
+	if btConvexShape(convex, triangles.BoundingAABB) == nil {
+		return nil
+	}
+
+	transformNoLoc := transform.Clone()
+	transformNoLoc.SetRow(3, vector.Vector{0, 0, 0, 1})
+
+	support, center := shapeSupport(convex)
+
+	result := newCollision(triangles)
+
+	tris := triangles.Broadphase.TrianglesFromBounding(convex)
+
+	for triID := range tris {
+
+		tri := triangles.Mesh.Triangles[triID]
+
+		v0 := transform.MultVec(triangles.Mesh.VertexPositions[tri.ID*3])
+		v1 := transform.MultVec(triangles.Mesh.VertexPositions[tri.ID*3+1])
+		v2 := transform.MultVec(triangles.Mesh.VertexPositions[tri.ID*3+2])
+
+		mtv, contact := convexCollision(support, pointsSupport([]vector.Vector{v0, v1, v2}), center, v0.Add(v1).Add(v2).Scale(1.0/3))
+
+		if mtv == nil {
+			continue
+		}
+
+		result.add(
+			&Intersection{
+				StartingPoint: center,
+				ContactPoint:  contact,
+				MTV:           mtv,
+				Triangle:      tri,
+				Normal:        transformNoLoc.MultVec(tri.Normal).Unit(),
+			},
+		)
+
+	}
+
+	if len(result.Intersections) == 0 {
+		return nil
+	}
+
+	return result
+
+}
+
 func commonCollisionTest(node INode, dx, dy, dz float64, others ...INode) []*Collision {
 
 	var ogPos vector.Vector
@@ -822,7 +1026,7 @@ func commonCollisionTest(node INode, dx, dy, dz float64, others ...INode) []*Col
 
 	test = func(checking, parent INode) {
 
-		if c, ok := checking.(IBoundingObject); ok {
+		if c, ok := checking.(IBoundingObject); ok && node.(IBoundingObject).CollidesWith(c) {
 
 			if collision := node.(IBoundingObject).Collision(c); collision != nil {
 				collision.Root = parent
@@ -11,6 +11,7 @@ import (
 // BoundingObject Nodes.
 type BoundingAABB struct {
 	*Node
+	CollisionFilter
 	internalSize vector.Vector
 	Dimensions   Dimensions
 }
@@ -28,8 +29,9 @@ func NewBoundingAABB(name string, width, height, depth float64) *BoundingAABB {
 		depth = min
 	}
 	bounds := &BoundingAABB{
-		Node:         NewNode(name),
-		internalSize: vector.Vector{width, height, depth},
+		Node:            NewNode(name),
+		CollisionFilter: newCollisionFilter(),
+		internalSize:    vector.Vector{width, height, depth},
 	}
 	bounds.Node.onTransformUpdate = bounds.updateSize
 	bounds.updateSize()
@@ -123,6 +125,7 @@ func (box *BoundingAABB) SetDimensions(newWidth, newHeight, newDepth float64) {
 func (box *BoundingAABB) Clone() INode {
 	clone := NewBoundingAABB(box.name, box.internalSize[0], box.internalSize[1], box.internalSize[2])
 	clone.Node = box.Node.Clone().(*Node)
+	clone.CollisionFilter = box.CollisionFilter
 	clone.Node.onTransformUpdate = clone.updateSize
 	return clone
 }
@@ -247,6 +250,28 @@ func (box *BoundingAABB) Collision(other IBoundingObject) *Collision {
 		}
 		return intersection
 
+	case *BoundingOBB:
+		intersection := btOBBAABB(otherBounds, box)
+		if intersection != nil {
+			for _, inter := range intersection.Intersections {
+				inter.MTV = inter.MTV.Invert()
+				vector.In(inter.Normal).Invert()
+			}
+			intersection.BoundingObject = otherBounds
+		}
+		return intersection
+
+	case *BoundingConvex:
+		intersection := btConvexShape(otherBounds, box)
+		if intersection != nil {
+			for _, inter := range intersection.Intersections {
+				inter.MTV = inter.MTV.Invert()
+				vector.In(inter.Normal).Invert()
+			}
+			intersection.BoundingObject = otherBounds
+		}
+		return intersection
+
 	}
 
 	panic("Unimplemented bounds type")
@@ -6,9 +6,14 @@ import (
 	"github.com/kvartborg/vector"
 )
 
+// capsuleAABBIterations is how many times the closest point on a BoundingCapsule's line to a BoundingAABB is refined when testing
+// them against each other.
+const capsuleAABBIterations = 8
+
 // BoundingCapsule represents a 3D capsule, whose primary purpose is to perform intersection testing between itself and other Bounding Nodes.
 type BoundingCapsule struct {
 	*Node
+	CollisionFilter
 	Height         float64
 	Radius         float64
 	internalSphere *BoundingSphere
@@ -18,10 +23,11 @@ type BoundingCapsule struct {
 // height of the Capsule, and radius is how big around the capsule is. Height has to be at least radius (otherwise, it would no longer be a capsule).
 func NewBoundingCapsule(name string, height, radius float64) *BoundingCapsule {
 	return &BoundingCapsule{
-		Node:           NewNode(name),
-		Height:         math.Max(radius, height),
-		Radius:         radius,
-		internalSphere: NewBoundingSphere("internal sphere", 0),
+		Node:            NewNode(name),
+		CollisionFilter: newCollisionFilter(),
+		Height:          math.Max(radius, height),
+		Radius:          radius,
+		internalSphere:  NewBoundingSphere("internal sphere", 0),
 	}
 }
 
@@ -29,6 +35,7 @@ func NewBoundingCapsule(name string, height, radius float64) *BoundingCapsule {
 func (capsule *BoundingCapsule) Clone() INode {
 	clone := NewBoundingCapsule(capsule.name, capsule.Height, capsule.Radius)
 	clone.Node = capsule.Node.Clone().(*Node)
+	clone.CollisionFilter = capsule.CollisionFilter
 	return clone
 }
 
@@ -84,6 +91,20 @@ func (capsule *BoundingCapsule) Collision(other IBoundingObject) *Collision {
 	case *BoundingTriangles:
 		return btCapsuleTriangles(capsule, otherBounds)
 
+	case *BoundingOBB:
+		return btCapsuleOBB(capsule, otherBounds)
+
+	case *BoundingConvex:
+		intersection := btConvexShape(otherBounds, capsule)
+		if intersection != nil {
+			for _, inter := range intersection.Intersections {
+				inter.MTV = inter.MTV.Invert()
+				vector.In(inter.Normal).Invert()
+			}
+			intersection.BoundingObject = otherBounds
+		}
+		return intersection
+
 	}
 
 	panic("Unimplemented bounds type")
@@ -185,3 +206,93 @@ func (capsule *BoundingCapsule) Bottom() vector.Vector {
 func (capsule *BoundingCapsule) Type() NodeType {
 	return NodeTypeBoundingCapsule
 }
+
+// closestPointsBetweenSegments returns the closest points between the line segment from startA to endA and the one from startB
+// to endB, in that order.
+func closestPointsBetweenSegments(startA, endA, startB, endB vector.Vector) (vector.Vector, vector.Vector) {
+
+	segmentA := endA.Sub(startA)
+	segmentB := endB.Sub(startB)
+	between := startA.Sub(startB)
+
+	lengthA := dot(segmentA, segmentA)
+	lengthB := dot(segmentB, segmentB)
+	f := dot(segmentB, between)
+
+	const epsilon = 1e-12
+
+	clamp := func(x float64) float64 { return math.Max(0, math.Min(1, x)) }
+
+	var s, t float64
+
+	if lengthA <= epsilon && lengthB <= epsilon {
+		// Both segments are points.
+		s, t = 0, 0
+	} else if lengthA <= epsilon {
+		s = 0
+		t = clamp(f / lengthB)
+	} else {
+
+		c := dot(segmentA, between)
+
+		if lengthB <= epsilon {
+			t = 0
+			s = clamp(-c / lengthA)
+		} else {
+
+			b := dot(segmentA, segmentB)
+
+			// Parallel segments have no single closest pair of points, so any will do, starting from startA.
+			if denom := lengthA*lengthB - b*b; denom > epsilon {
+				s = clamp((b*f - c*lengthB) / denom)
+			}
+
+			t = (b*s + f) / lengthB
+
+			if t < 0 {
+				t = 0
+				s = clamp(-c / lengthA)
+			} else if t > 1 {
+				t = 1
+				s = clamp((b - c) / lengthA)
+			}
+
+		}
+
+	}
+
+	return startA.Add(segmentA.Scale(s)), startB.Add(segmentB.Scale(t))
+
+}
+
+// closestPointsSegmentTriangle returns the closest points between the line segment from start to end and the triangle composed of
+// the vertices v0, v1, and v2; the point on the segment comes first. If the segment passes through the triangle, both points are
+// where it does.
+func closestPointsSegmentTriangle(start, end, v0, v1, v2 vector.Vector) (vector.Vector, vector.Vector) {
+
+	segment := end.Sub(start)
+
+	if t, _, _, _, hit := rayTriangleBarycentric(start, segment, v0, v1, v2); hit && t >= 0 && t <= 1 {
+		point := start.Add(segment.Scale(t))
+		return point, point.Clone()
+	}
+
+	// Otherwise, the closest points are between one of the segment's ends and the triangle, or the segment and one of the
+	// triangle's edges. closestPointOnTri() reuses its results, so they're cloned.
+	closestSegment, closestTri := start, closestPointOnTri(start, v0, v1, v2).Clone()
+	closestDist := fastVectorDistanceSquared(closestSegment, closestTri)
+
+	consider := func(segmentPoint, triPoint vector.Vector) {
+		if dist := fastVectorDistanceSquared(segmentPoint, triPoint); dist < closestDist {
+			closestSegment, closestTri, closestDist = segmentPoint, triPoint, dist
+		}
+	}
+
+	consider(end, closestPointOnTri(end, v0, v1, v2).Clone())
+	consider(closestPointsBetweenSegments(start, end, v0, v1))
+	consider(closestPointsBetweenSegments(start, end, v1, v2))
+	consider(closestPointsBetweenSegments(start, end, v2, v0))
+
+	return closestSegment.Clone(), closestTri
+
+}
@@ -0,0 +1,84 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kvartborg/vector"
+)
+
+func TestClosestPointsBetweenSegments(t *testing.T) {
+
+	a, b := closestPointsBetweenSegments(vector.Vector{-1, 0, 0}, vector.Vector{1, 0, 0}, vector.Vector{0.5, 1, -1}, vector.Vector{0.5, 1, 1})
+
+	if a.Sub(vector.Vector{0.5, 0, 0}).Magnitude() > 1e-9 || b.Sub(vector.Vector{0.5, 1, 0}).Magnitude() > 1e-9 {
+		t.Fatalf("crossing segments should be closest where they cross; got %v and %v", a, b)
+	}
+
+	a, b = closestPointsBetweenSegments(vector.Vector{0, 0, 0}, vector.Vector{1, 0, 0}, vector.Vector{3, 1, 0}, vector.Vector{4, 1, 0})
+
+	if a.Sub(vector.Vector{1, 0, 0}).Magnitude() > 1e-9 || b.Sub(vector.Vector{3, 1, 0}).Magnitude() > 1e-9 {
+		t.Fatalf("parallel segments that don't overlap should be closest at their nearest ends; got %v and %v", a, b)
+	}
+
+	a, b = closestPointsSegmentTriangle(vector.Vector{0, -1, 0}, vector.Vector{0, 1, 0}, vector.Vector{-1, 0, -1}, vector.Vector{1, 0, -1}, vector.Vector{0, 0, 1})
+
+	if a.Magnitude() > 1e-9 || b.Magnitude() > 1e-9 {
+		t.Fatalf("a segment passing through a triangle should be closest to it where it passes through; got %v and %v", a, b)
+	}
+
+}
+
+func TestCapsuleCollisions(t *testing.T) {
+
+	// Two capsules lying across each other
+	capsuleA := NewBoundingCapsule("capsule a", 10, 0.5)
+	capsuleA.SetLocalRotation(NewMatrix4Rotate(0, 0, 1, math.Pi/2))
+
+	capsuleB := NewBoundingCapsule("capsule b", 10, 0.5)
+	capsuleB.SetLocalRotation(NewMatrix4Rotate(1, 0, 0, math.Pi/2))
+	capsuleB.SetLocalPosition(3, 0.9, -2)
+
+	collision := capsuleA.Collision(capsuleB)
+
+	if collision == nil || collision.AverageMTV().Sub(vector.Vector{0, -0.1, 0}).Magnitude() > 1e-6 {
+		t.Fatalf("crossing capsules should be pushed 0.1 units apart; got %v", collision)
+	}
+
+	capsuleB.SetLocalPosition(3, 1.1, -2)
+
+	if capsuleA.Colliding(capsuleB) {
+		t.Fatalf("capsules crossing 1.1 units apart shouldn't collide")
+	}
+
+	// A capsule standing against a step, whose edge pokes into the middle of the capsule's side
+	capsule := NewBoundingCapsule("capsule", 4, 0.5)
+
+	step := NewBoundingTriangles("step", NewCube(), 0)
+	step.SetLocalPosition(1.3, -0.5, 0)
+
+	collision = capsule.Collision(step)
+
+	if collision == nil || collision.AverageMTV()[0] >= 0 {
+		t.Fatalf("a capsule pushing into a step should be pushed away from it; got %v", collision)
+	}
+
+	// A floor slicing through the capsule
+	floor := NewBoundingTriangles("floor", NewPlane(), 0)
+	floor.SetLocalScale(10, 1, 10)
+	capsule.SetLocalPosition(0, 0.2, 0)
+
+	collision = capsule.Collision(floor)
+
+	if collision == nil {
+		t.Fatalf("a floor slicing through a capsule should collide with it")
+	}
+
+	for _, intersection := range collision.Intersections {
+		mtv := intersection.MTV
+		if math.IsNaN(mtv.Magnitude()) || mtv[1] <= 0 {
+			t.Fatalf("a capsule sliced through by a floor should be pushed up out of it, but the MTV was %v", mtv)
+		}
+	}
+
+}
@@ -0,0 +1,229 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/kvartborg/vector"
+)
+
+// BoundingConvex represents a convex shape, made from the convex hull of a Mesh (see Mesh.ConvexHull()). It rotates and scales along
+// with its Node, and is tested against other BoundingObjects using GJK. This makes it a good middle ground between the simple shapes
+// (which might not fit a prop well) and a BoundingTriangles (which is accurate, but slow, and can't tell when something's entirely
+// inside of it). The primary purpose of a BoundingConvex is, like the other Bounding* Nodes, to perform intersection testing between
+// itself and other BoundingObject Nodes.
+type BoundingConvex struct {
+	*Node
+	CollisionFilter
+	Hull   *Mesh // The convex hull Mesh used by the BoundingConvex, or nil if the Mesh it was made from was flat.
+	points []vector.Vector
+	faces  [][3]int
+}
+
+// NewBoundingConvex returns a new BoundingConvex Node, shaped like the convex hull of the given Mesh. If maxVertices is greater than
+// 0, the hull is simplified to have at most that many vertices (though never fewer than 4), which makes collision testing faster at
+// the cost of accuracy; otherwise, every vertex on the hull is kept. If the Mesh is flat, the BoundingConvex is flat, too (and rays
+// won't hit it).
+func NewBoundingConvex(name string, mesh *Mesh, maxVertices int) *BoundingConvex {
+
+	points, faces := convexHull(mesh.VertexPositions, maxVertices)
+
+	if len(points) == 0 {
+		points = []vector.Vector{{0, 0, 0}}
+	}
+
+	return &BoundingConvex{
+		Node:            NewNode(name),
+		CollisionFilter: newCollisionFilter(),
+		Hull:            newHullMesh(mesh.Name+"_hull", points, faces),
+		points:          points,
+		faces:           faces,
+	}
+
+}
+
+// Clone returns a new BoundingConvex. The clone shares the original's hull.
+func (convex *BoundingConvex) Clone() INode {
+	clone := &BoundingConvex{
+		Node:            convex.Node.Clone().(*Node),
+		CollisionFilter: convex.CollisionFilter,
+		Hull:            convex.Hull,
+		points:          convex.points,
+		faces:           convex.faces,
+	}
+	return clone
+}
+
+// AddChildren parents the provided children Nodes to the passed parent Node, inheriting its transformations and being under it in the scenegraph
+// hierarchy. If the children are already parented to other Nodes, they are unparented before doing so.
+func (convex *BoundingConvex) AddChildren(children ...INode) {
+	// We do this manually so that addChildren() parents the children to the Model, rather than to the Model.NodeBase.
+	convex.addChildren(convex, children...)
+}
+
+// Points returns the world positions of the points making up the BoundingConvex's hull.
+func (convex *BoundingConvex) Points() []vector.Vector {
+	transform := convex.Transform()
+	points := make([]vector.Vector, len(convex.points))
+	for i, p := range convex.points {
+		points[i] = transform.MultVec(p)
+	}
+	return points
+}
+
+// planes returns the outward normals and the distances from the origin of the planes of the BoundingConvex's faces in world space.
+func (convex *BoundingConvex) planes(points []vector.Vector) ([]vector.Vector, []float64) {
+
+	normals := make([]vector.Vector, 0, len(convex.faces))
+	distances := make([]float64, 0, len(convex.faces))
+
+	// A negative scale turns the hull inside-out, so the normals are flipped back.
+	scale := convex.WorldScale()
+	flip := scale[0]*scale[1]*scale[2] < 0
+
+	for _, face := range convex.faces {
+		p0 := points[face[0]]
+		normal := gjkCross(points[face[1]].Sub(p0), points[face[2]].Sub(p0))
+		if mag := normal.Magnitude(); mag > 0 {
+			normal = normal.Scale(1 / mag)
+		}
+		if flip {
+			normal = normal.Invert()
+		}
+		normals = append(normals, normal)
+		distances = append(distances, dot(normal, p0))
+	}
+
+	return normals, distances
+
+}
+
+// PointInside returns true if the point provided is within the BoundingConvex.
+func (convex *BoundingConvex) PointInside(point vector.Vector) bool {
+
+	if len(convex.faces) == 0 {
+		return false
+	}
+
+	normals, distances := convex.planes(convex.Points())
+
+	for i, normal := range normals {
+		if dot(normal, point) > distances[i] {
+			return false
+		}
+	}
+
+	return true
+
+}
+
+// Colliding returns true if the BoundingConvex collides with another IBoundingObject.
+func (convex *BoundingConvex) Colliding(other IBoundingObject) bool {
+	return convex.Collision(other) != nil
+}
+
+// Collision returns the Collision between the BoundingConvex and the other IBoundingObject. If
+// there is no intersection, the function returns nil.
+func (convex *BoundingConvex) Collision(other IBoundingObject) *Collision {
+
+	if other == convex {
+		return nil
+	}
+
+	switch otherBounds := other.(type) {
+
+	case *BoundingConvex, *BoundingSphere, *BoundingAABB, *BoundingOBB, *BoundingCapsule:
+		return btConvexShape(convex, otherBounds)
+
+	case *BoundingTriangles:
+		return btConvexTriangles(convex, otherBounds)
+
+	}
+
+	panic("Unimplemented bounds type")
+
+}
+
+// CollisionTest performs an collision test if the bounding object were to move in the given direction in world space.
+// It returns all valid Collisions across all recursive children of the INodes slice passed in as others, testing against BoundingObjects in those trees.
+// To exemplify this, if you had a Model that had a BoundingObject child, and then tested the Model for collision,
+// the Model's children would be tested for collision (which means the BoundingObject), and the Model would be the
+// collided object. Of course, if you simply tested the BoundingObject directly, then it would return the BoundingObject as the collided
+// object.
+// Collisions will be sorted in order of distance. If no Collisions occurred, it will return an empty slice.
+func (convex *BoundingConvex) CollisionTest(dx, dy, dz float64, others ...INode) []*Collision {
+	return commonCollisionTest(convex, dx, dy, dz, others...)
+}
+
+// CollisionTestVec performs an collision test if the bounding object were to move in the given direction in world space using a vector.
+// It returns all valid Collisions across all recursive children of the INodes slice passed in as others, testing against BoundingObjects in those trees.
+// To exemplify this, if you had a Model that had a BoundingObject child, and then tested the Model for collision,
+// the Model's children would be tested for collision (which means the BoundingObject), and the Model would be the
+// collided object. Of course, if you simply tested the BoundingObject directly, then it would return the BoundingObject as the collided
+// object.
+// Collisions will be sorted in order of distance. If no Collisions occurred, it will return an empty slice.
+func (convex *BoundingConvex) CollisionTestVec(moveVec vector.Vector, others ...INode) []*Collision {
+	if moveVec == nil {
+		return commonCollisionTest(convex, 0, 0, 0, others...)
+	}
+	return commonCollisionTest(convex, moveVec[0], moveVec[1], moveVec[2], others...)
+}
+
+// Sweep moves the BoundingConvex along the given movement vector (in world space), returning where it first hits any of the
+// BoundingObjects in the trees of the INodes provided in others, or nil if it doesn't hit anything. The BoundingConvex isn't actually
+// moved. If it's already overlapping something, the returned SweepHit's Time is 0.
+func (convex *BoundingConvex) Sweep(moveVec vector.Vector, others ...INode) *SweepHit {
+	return sweep(convex, moveVec, others...)
+}
+
+// Type returns the NodeType for this object.
+func (convex *BoundingConvex) Type() NodeType {
+	return NodeTypeBoundingConvex
+}
+
+// rayIntersection returns the distance along the given ray (in multiples of dir) at which it enters the BoundingConvex, and the normal
+// of the face it enters through, by clipping the ray against each of the hull's faces in turn.
+func (convex *BoundingConvex) rayIntersection(origin, dir vector.Vector) (float64, vector.Vector, bool) {
+
+	if len(convex.faces) == 0 {
+		return 0, nil, false
+	}
+
+	normals, distances := convex.planes(convex.Points())
+
+	enter := -math.MaxFloat64
+	exit := math.MaxFloat64
+	var enterNormal vector.Vector
+
+	for i, normal := range normals {
+
+		towards := dot(normal, dir)
+		outside := dot(normal, origin) - distances[i]
+
+		if math.Abs(towards) < 1e-12 {
+			if outside > 0 {
+				return 0, nil, false
+			}
+			continue
+		}
+
+		t := -outside / towards
+
+		if towards < 0 {
+			if t > enter {
+				enter = t
+				enterNormal = normal
+			}
+		} else if t < exit {
+			exit = t
+		}
+
+	}
+
+	// Like with the other BoundingObjects, rays starting inside of the hull don't enter it.
+	if enter > exit || enter < 0 {
+		return 0, nil, false
+	}
+
+	return enter, enterNormal, true
+
+}
@@ -0,0 +1,101 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kvartborg/vector"
+)
+
+func TestConvexHull(t *testing.T) {
+
+	hull := NewCube().ConvexHull(0)
+
+	if hull == nil {
+		t.Fatalf("a cube should have a convex hull")
+	}
+
+	if points, faces := convexHull(NewCube().VertexPositions, 0); len(points) != 8 || len(faces) != 12 {
+		t.Fatalf("a cube's hull should have 8 points and 12 faces; got %d and %d", len(points), len(faces))
+	}
+
+	if size := hull.Dimensions.Size(); size.Sub(vector.Vector{2, 2, 2}).Magnitude() > 1e-6 {
+		t.Fatalf("a cube's hull should be the same size as the cube; got %v", size)
+	}
+
+	// An icosphere has lots of vertices, all of which are on its hull.
+	sphere := NewIcosphere(2)
+
+	if points, _ := convexHull(sphere.VertexPositions, 0); len(points) != len(NewBoundingConvex("sphere", sphere, 0).points) {
+		t.Fatalf("every point on an icosphere should be on its hull")
+	}
+
+	if points, _ := convexHull(sphere.VertexPositions, 12); len(points) != 12 {
+		t.Fatalf("a simplified hull should have 12 points; got %d", len(points))
+	}
+
+	if NewPlane().ConvexHull(0) != nil {
+		t.Fatalf("a flat mesh shouldn't have a convex hull")
+	}
+
+}
+
+func TestBoundingConvex(t *testing.T) {
+
+	// A cube-shaped hull should collide just like a BoundingOBB of the same size.
+	convex := NewBoundingConvex("convex", NewCube(), 0)
+	convex.SetLocalRotation(NewMatrix4Rotate(0, 1, 0, math.Pi/4))
+
+	if !convex.PointInside(vector.Vector{1.3, 0, 0}) || convex.PointInside(vector.Vector{1, 0, 1}) {
+		t.Fatalf("PointInside should follow the hull's rotation")
+	}
+
+	sphere := NewBoundingSphere("sphere", 0.5)
+	sphere.SetLocalPosition(math.Sqrt2+0.25, 0, 0)
+
+	collision := convex.Collision(sphere)
+	if collision == nil || collision.AverageMTV().Sub(vector.Vector{-0.25, 0, 0}).Magnitude() > 1e-2 {
+		t.Fatalf("the hull's corner should be pushed out of the sphere along the -X axis by 0.25; got %v", collision)
+	}
+
+	if collision = sphere.Collision(convex); collision == nil || collision.BoundingObject != convex || collision.AverageMTV()[0] <= 0 {
+		t.Fatalf("the sphere should be pushed out of the hull along the +X axis; got %v", collision)
+	}
+
+	sphere.SetLocalPosition(2, 0, 0)
+	if convex.Colliding(sphere) || sphere.Colliding(convex) {
+		t.Fatalf("the sphere shouldn't collide with the hull once it's moved away")
+	}
+
+	obb := NewBoundingOBB("obb", 2, 2, 2)
+	obb.SetLocalPosition(0, 1.5, 0)
+	convex.SetLocalRotation(NewMatrix4())
+
+	collision = convex.Collision(obb)
+	if collision == nil || collision.AverageMTV().Sub(vector.Vector{0, -0.5, 0}).Magnitude() > 1e-3 {
+		t.Fatalf("the hull should be pushed down out of the OBB by 0.5; got %v", collision)
+	}
+
+	// Hulls should stand on triangle meshes.
+	floor := NewBoundingTriangles("floor", NewPlane(), 0)
+	floor.SetLocalScale(10, 1, 10)
+	convex.SetLocalPosition(0, 0.75, 0)
+
+	collision = convex.Collision(floor)
+	if collision == nil || collision.AverageMTV().Sub(vector.Vector{0, 0.25, 0}).Magnitude() > 1e-3 {
+		t.Fatalf("the hull should be pushed up out of the floor by 0.25; got %v", collision)
+	}
+
+	if collision = floor.Collision(convex); collision == nil || collision.BoundingObject != convex || collision.AverageMTV()[1] >= 0 {
+		t.Fatalf("the floor should be pushed down out of the hull; got %v", collision)
+	}
+
+	scene := NewScene("convex test")
+	scene.Root.AddChildren(convex)
+
+	hits := scene.RayTest(vector.Vector{0, 5, 0}, vector.Vector{0, -5, 0})
+	if len(hits) == 0 || hits[0].BoundingObject != convex || math.Abs(hits[0].Distance-3.25) > 1e-6 || hits[0].Normal.Sub(vector.Vector{0, 1, 0}).Magnitude() > 1e-6 {
+		t.Fatalf("a ray cast down should hit the top of the hull; got %v", hits)
+	}
+
+}
@@ -0,0 +1,394 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/kvartborg/vector"
+)
+
+// BoundingOBB represents a 3D OBB (Oriented Bounding Box), a 3D box of varying width, height, and depth that, unlike a BoundingAABB,
+// rotates along with its Node. This makes it a much tighter fit than a BoundingAABB for long, thin objects that can turn (like swords,
+// planks, or vehicles). The primary purpose of a BoundingOBB is, like the other Bounding* Nodes, to perform intersection testing between
+// itself and other BoundingObject Nodes.
+type BoundingOBB struct {
+	*Node
+	CollisionFilter
+	internalSize vector.Vector
+}
+
+// NewBoundingOBB returns a new BoundingOBB Node. The width, height, and depth are the size of the box along its Node's local X, Y, and
+// Z axes, prior to scaling.
+func NewBoundingOBB(name string, width, height, depth float64) *BoundingOBB {
+	min := 0.0001
+	if width <= 0 {
+		width = min
+	}
+	if height <= 0 {
+		height = min
+	}
+	if depth <= 0 {
+		depth = min
+	}
+	return &BoundingOBB{
+		Node:            NewNode(name),
+		CollisionFilter: newCollisionFilter(),
+		internalSize:    vector.Vector{width, height, depth},
+	}
+}
+
+// Clone returns a new BoundingOBB.
+func (box *BoundingOBB) Clone() INode {
+	clone := NewBoundingOBB(box.name, box.internalSize[0], box.internalSize[1], box.internalSize[2])
+	clone.Node = box.Node.Clone().(*Node)
+	clone.CollisionFilter = box.CollisionFilter
+	return clone
+}
+
+// AddChildren parents the provided children Nodes to the passed parent Node, inheriting its transformations and being under it in the scenegraph
+// hierarchy. If the children are already parented to other Nodes, they are unparented before doing so.
+func (box *BoundingOBB) AddChildren(children ...INode) {
+	// We do this manually so that addChildren() parents the children to the Model, rather than to the Model.NodeBase.
+	box.addChildren(box, children...)
+}
+
+// SetDimensions sets the BoundingOBB's internal dimensions (prior to scaling the Node).
+func (box *BoundingOBB) SetDimensions(newWidth, newHeight, newDepth float64) {
+
+	min := 0.00001
+	if newWidth <= 0 {
+		newWidth = min
+	}
+	if newHeight <= 0 {
+		newHeight = min
+	}
+	if newDepth <= 0 {
+		newDepth = min
+	}
+
+	box.internalSize[0] = newWidth
+	box.internalSize[1] = newHeight
+	box.internalSize[2] = newDepth
+
+}
+
+// Dimensions returns the BoundingOBB's internal width, height, and depth (prior to scaling the Node).
+func (box *BoundingOBB) Dimensions() vector.Vector {
+	return box.internalSize.Clone()
+}
+
+// HalfExtents returns half of the BoundingOBB's width, height, and depth in world units, after taking into account its scale; these
+// are how far the box extends from its center along each of its Axes().
+func (box *BoundingOBB) HalfExtents() vector.Vector {
+	scale := box.WorldScale()
+	return vector.Vector{
+		math.Abs(box.internalSize[0]*scale[0]) / 2,
+		math.Abs(box.internalSize[1]*scale[1]) / 2,
+		math.Abs(box.internalSize[2]*scale[2]) / 2,
+	}
+}
+
+// Axes returns the BoundingOBB's local X, Y, and Z axes in world space, as unit vectors.
+func (box *BoundingOBB) Axes() [3]vector.Vector {
+	rotation := box.WorldRotation()
+	return [3]vector.Vector{rotation.Right(), rotation.Up(), rotation.Forward()}
+}
+
+// Corners returns the world positions of the BoundingOBB's eight corners.
+func (box *BoundingOBB) Corners() []vector.Vector {
+	return box.shape().corners()
+}
+
+// ClosestPoint returns the closest point, to the point given, on the inside or surface of the BoundingOBB in world space.
+func (box *BoundingOBB) ClosestPoint(point vector.Vector) vector.Vector {
+	return box.shape().closestPoint(point)
+}
+
+// PointInside returns true if the point provided is within the BoundingOBB.
+func (box *BoundingOBB) PointInside(point vector.Vector) bool {
+
+	shape := box.shape()
+	delta := point.Sub(shape.center)
+
+	for i, axis := range shape.axes {
+		if math.Abs(dot(delta, axis)) > shape.half[i] {
+			return false
+		}
+	}
+
+	return true
+
+}
+
+// Colliding returns true if the BoundingOBB collides with another IBoundingObject.
+func (box *BoundingOBB) Colliding(other IBoundingObject) bool {
+	return box.Collision(other) != nil
+}
+
+// Collision returns the Collision between the BoundingOBB and the other IBoundingObject. If
+// there is no intersection, the function returns nil.
+func (box *BoundingOBB) Collision(other IBoundingObject) *Collision {
+
+	if other == box {
+		return nil
+	}
+
+	switch otherBounds := other.(type) {
+
+	case *BoundingOBB:
+		return btOBBOBB(box, otherBounds)
+
+	case *BoundingAABB:
+		return btOBBAABB(box, otherBounds)
+
+	case *BoundingSphere:
+		intersection := btSphereOBB(otherBounds, box)
+		if intersection != nil {
+			for _, inter := range intersection.Intersections {
+				inter.MTV = inter.MTV.Invert()
+				vector.In(inter.Normal).Invert()
+			}
+			intersection.BoundingObject = otherBounds
+		}
+		return intersection
+
+	case *BoundingCapsule:
+		intersection := btCapsuleOBB(otherBounds, box)
+		if intersection != nil {
+			for _, inter := range intersection.Intersections {
+				inter.MTV = inter.MTV.Invert()
+				vector.In(inter.Normal).Invert()
+			}
+			intersection.BoundingObject = otherBounds
+		}
+		return intersection
+
+	case *BoundingTriangles:
+		return btOBBTriangles(box, otherBounds)
+
+	case *BoundingConvex:
+		intersection := btConvexShape(otherBounds, box)
+		if intersection != nil {
+			for _, inter := range intersection.Intersections {
+				inter.MTV = inter.MTV.Invert()
+				vector.In(inter.Normal).Invert()
+			}
+			intersection.BoundingObject = otherBounds
+		}
+		return intersection
+
+	}
+
+	panic("Unimplemented bounds type")
+
+}
+
+// CollisionTest performs an collision test if the bounding object were to move in the given direction in world space.
+// It returns all valid Collisions across all recursive children of the INodes slice passed in as others, testing against BoundingObjects in those trees.
+// To exemplify this, if you had a Model that had a BoundingObject child, and then tested the Model for collision,
+// the Model's children would be tested for collision (which means the BoundingObject), and the Model would be the
+// collided object. Of course, if you simply tested the BoundingObject directly, then it would return the BoundingObject as the collided
+// object.
+// Collisions will be sorted in order of distance. If no Collisions occurred, it will return an empty slice.
+func (box *BoundingOBB) CollisionTest(dx, dy, dz float64, others ...INode) []*Collision {
+	return commonCollisionTest(box, dx, dy, dz, others...)
+}
+
+// CollisionTestVec performs an collision test if the bounding object were to move in the given direction in world space using a vector.
+// It returns all valid Collisions across all recursive children of the INodes slice passed in as others, testing against BoundingObjects in those trees.
+// To exemplify this, if you had a Model that had a BoundingObject child, and then tested the Model for collision,
+// the Model's children would be tested for collision (which means the BoundingObject), and the Model would be the
+// collided object. Of course, if you simply tested the BoundingObject directly, then it would return the BoundingObject as the collided
+// object.
+// Collisions will be sorted in order of distance. If no Collisions occurred, it will return an empty slice.
+func (box *BoundingOBB) CollisionTestVec(moveVec vector.Vector, others ...INode) []*Collision {
+	if moveVec == nil {
+		return commonCollisionTest(box, 0, 0, 0, others...)
+	}
+	return commonCollisionTest(box, moveVec[0], moveVec[1], moveVec[2], others...)
+}
+
+// Type returns the NodeType for this object.
+func (box *BoundingOBB) Type() NodeType {
+	return NodeTypeBoundingOBB
+}
+
+// shape returns the BoundingOBB as a boxShape in world space.
+func (box *BoundingOBB) shape() boxShape {
+	half := box.HalfExtents()
+	return boxShape{
+		center: box.WorldPosition(),
+		axes:   box.Axes(),
+		half:   [3]float64{half[0], half[1], half[2]},
+	}
+}
+
+// boxShape is a box in world space, with a center, three unit axes, and how far the box extends along each axis; it's used to test
+// BoundingOBBs and BoundingAABBs against each other the same way.
+type boxShape struct {
+	center vector.Vector
+	axes   [3]vector.Vector
+	half   [3]float64
+}
+
+// aabbShape returns the given BoundingAABB as a boxShape.
+func aabbShape(aabb *BoundingAABB) boxShape {
+	position := aabb.WorldPosition() // Getting the position first updates the Dimensions, if they need it.
+	size := aabb.Dimensions.Size()
+	return boxShape{
+		center: position.Add(aabb.Dimensions.Center()),
+		axes:   [3]vector.Vector{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+		half:   [3]float64{size[0] / 2, size[1] / 2, size[2] / 2},
+	}
+}
+
+// corners returns the world positions of the box's eight corners.
+func (box boxShape) corners() []vector.Vector {
+
+	corners := make([]vector.Vector, 0, 8)
+
+	for _, x := range []float64{-1, 1} {
+		for _, y := range []float64{-1, 1} {
+			for _, z := range []float64{-1, 1} {
+				corners = append(corners, box.center.
+					Add(box.axes[0].Scale(x*box.half[0])).
+					Add(box.axes[1].Scale(y*box.half[1])).
+					Add(box.axes[2].Scale(z*box.half[2])))
+			}
+		}
+	}
+
+	return corners
+
+}
+
+// closestPoint returns the closest point to the given point on the inside or surface of the box.
+func (box boxShape) closestPoint(point vector.Vector) vector.Vector {
+
+	delta := point.Sub(box.center)
+	out := box.center.Clone()
+
+	for i, axis := range box.axes {
+		d := math.Max(-box.half[i], math.Min(box.half[i], dot(delta, axis)))
+		out[0] += axis[0] * d
+		out[1] += axis[1] * d
+		out[2] += axis[2] * d
+	}
+
+	return out
+
+}
+
+// radius returns how far the box extends from its center along the given unit axis.
+func (box boxShape) radius(axis vector.Vector) float64 {
+	return box.half[0]*math.Abs(dot(box.axes[0], axis)) +
+		box.half[1]*math.Abs(dot(box.axes[1], axis)) +
+		box.half[2]*math.Abs(dot(box.axes[2], axis))
+}
+
+// faceNormal returns the normal of the face of the box that the given direction (from the box's center) points through the most.
+func (box boxShape) faceNormal(dir vector.Vector) vector.Vector {
+
+	bestAxis := 0
+	bestDot := 0.0
+
+	for i, axis := range box.axes {
+		// Dividing by the half-size accounts for the box not being a cube.
+		if d := math.Abs(dot(dir, axis)) / math.Max(box.half[i], 1e-12); d > bestDot {
+			bestDot = d
+			bestAxis = i
+		}
+	}
+
+	normal := box.axes[bestAxis].Clone()
+	if dot(dir, normal) < 0 {
+		normal = normal.Invert()
+	}
+	return normal
+
+}
+
+// separatingAxesMTV tests the two sets of points (for example, the corners of two boxes) against each other along the given axes,
+// returning the shortest vector that would move the first set of points out of the second, or nil if they aren't overlapping
+// along any one of the axes. Axes that are too short to have a direction (for example, the cross product of two parallel edges)
+// are skipped.
+func separatingAxesMTV(axes []vector.Vector, pointsA, pointsB []vector.Vector) vector.Vector {
+
+	var mtv vector.Vector
+	smallest := math.MaxFloat64
+
+	for _, axis := range axes {
+
+		if axis.Magnitude() < 1e-9 {
+			continue
+		}
+
+		axis = axis.Unit()
+
+		a := project(axis, pointsA...)
+		b := project(axis, pointsB...)
+
+		// How far A would have to move backward or forward along the axis to no longer overlap B
+		back := a.Max - b.Min
+		forward := b.Max - a.Min
+
+		if back <= 0 || forward <= 0 {
+			return nil
+		}
+
+		if back < smallest {
+			smallest = back
+			mtv = axis.Scale(-back)
+		}
+
+		if forward < smallest {
+			smallest = forward
+			mtv = axis.Scale(forward)
+		}
+
+	}
+
+	return mtv
+
+}
+
+// boxBoxAxes returns the axes that have to be tested to tell if two boxes are separated: each box's face normals, and the cross
+// products of each pair of their edges.
+func boxBoxAxes(a, b boxShape) []vector.Vector {
+
+	axes := make([]vector.Vector, 0, 15)
+	axes = append(axes, a.axes[:]...)
+	axes = append(axes, b.axes[:]...)
+
+	for _, axisA := range a.axes {
+		for _, axisB := range b.axes {
+			cross, _ := axisA.Cross(axisB)
+			axes = append(axes, cross)
+		}
+	}
+
+	return axes
+
+}
+
+// boxTriangleAxes returns the axes that have to be tested to tell if a box and a triangle (in world space) are separated: the
+// box's face normals, the triangle's normal, and the cross products of the box's edges with the triangle's edges.
+func boxTriangleAxes(box boxShape, v0, v1, v2 vector.Vector) []vector.Vector {
+
+	edges := []vector.Vector{v1.Sub(v0), v2.Sub(v1), v0.Sub(v2)}
+
+	normal, _ := edges[0].Cross(edges[1])
+
+	axes := make([]vector.Vector, 0, 13)
+	axes = append(axes, box.axes[:]...)
+	axes = append(axes, normal)
+
+	for _, axis := range box.axes {
+		for _, edge := range edges {
+			cross, _ := axis.Cross(edge)
+			axes = append(axes, cross)
+		}
+	}
+
+	return axes
+
+}
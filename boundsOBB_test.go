@@ -0,0 +1,103 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kvartborg/vector"
+)
+
+func TestBoundingOBB(t *testing.T) {
+
+	// A long, thin plank turned 45 degrees, which an AABB would fit badly
+	plank := NewBoundingOBB("plank", 10, 0.2, 0.2)
+	plank.SetLocalRotation(NewMatrix4Rotate(0, 1, 0, math.Pi/4))
+
+	along := plank.Axes()[0]
+	across := plank.Axes()[2]
+
+	sphere := NewBoundingSphere("sphere", 0.2)
+
+	sphere.SetLocalPositionVec(along.Scale(3))
+	if !plank.Colliding(sphere) || !sphere.Colliding(plank) {
+		t.Fatalf("a sphere on the plank should collide with it")
+	}
+
+	sphere.SetLocalPositionVec(across.Scale(3))
+	if plank.Colliding(sphere) {
+		t.Fatalf("a sphere beside the plank shouldn't collide with it, even though it's inside of the plank's axis-aligned bounds")
+	}
+
+	// Two cubes, one turned so that its corner pokes into the other's side
+	cubeA := NewBoundingOBB("cube a", 1, 1, 1)
+	cubeB := NewBoundingOBB("cube b", 1, 1, 1)
+	cubeB.SetLocalRotation(NewMatrix4Rotate(0, 1, 0, math.Pi/4))
+	cubeB.SetLocalPosition(1.2, 0, 0)
+
+	overlap := 0.5 + math.Sqrt2/2 - 1.2
+
+	collision := cubeA.Collision(cubeB)
+	if collision == nil || collision.AverageMTV().Sub(vector.Vector{-overlap, 0, 0}).Magnitude() > 1e-6 {
+		t.Fatalf("the cubes should be pushed apart along the X axis by %f; got %v", overlap, collision)
+	}
+
+	box := NewBoundingAABB("box", 1, 1, 1)
+
+	collision = cubeB.Collision(box)
+	if collision == nil || collision.AverageMTV().Sub(vector.Vector{overlap, 0, 0}).Magnitude() > 1e-6 {
+		t.Fatalf("the turned cube should be pushed out of the AABB along the X axis by %f; got %v", overlap, collision)
+	}
+
+	if collision = box.Collision(cubeB); collision == nil || collision.BoundingObject != cubeB || collision.AverageMTV()[0] >= 0 {
+		t.Fatalf("the AABB should be pushed out of the turned cube along the -X axis; got %v", collision)
+	}
+
+	cubeB.SetLocalPosition(1.25, 0, 0)
+	if cubeA.Colliding(cubeB) || cubeB.Colliding(box) {
+		t.Fatalf("the cubes should no longer collide once they're moved apart")
+	}
+
+	capsule := NewBoundingCapsule("capsule", 2, 0.5)
+	capsule.SetLocalPosition(1.25+math.Sqrt2/2+0.4, 0, 0)
+	if !capsule.Colliding(cubeB) || !cubeB.Colliding(capsule) {
+		t.Fatalf("a capsule touching the turned cube's corner should collide with it")
+	}
+
+	// The plank tilted down so that one end dips into the floor
+	floor := NewBoundingTriangles("floor", NewPlane(), 0)
+	floor.SetLocalScale(10, 1, 10)
+
+	plank.SetLocalRotation(NewMatrix4Rotate(0, 0, 1, math.Pi/6))
+	plank.SetLocalPosition(0, 2.5, 0)
+
+	collision = plank.Collision(floor)
+	if collision == nil || collision.AverageMTV().Sub(vector.Vector{0, 5*0.5 + 0.1*math.Sqrt(3)/2 - 2.5, 0}).Magnitude() > 1e-6 {
+		t.Fatalf("the plank should be pushed up out of the floor; got %v", collision)
+	}
+
+	if collision = floor.Collision(plank); collision == nil || collision.AverageMTV()[1] >= 0 {
+		t.Fatalf("the floor should be pushed down out of the plank; got %v", collision)
+	}
+
+	plank.SetLocalPosition(0, 2.6, 0)
+	if plank.Colliding(floor) {
+		t.Fatalf("the plank shouldn't collide with the floor once it's lifted out of it")
+	}
+
+	// Ray tests and sweeps work with OBBs, too
+	hits := RayTest(vector.Vector{1.25, 5, 0}, vector.Vector{1.25, -5, 0}, cubeB)
+	if len(hits) != 1 || math.Abs(hits[0].Distance-4.5) > 1e-6 || hits[0].Normal.Sub(vector.Vector{0, 1, 0}).Magnitude() > 1e-6 {
+		t.Fatalf("a ray cast down onto the turned cube should hit its top 4.5 units down")
+	}
+
+	hits = RayTest(vector.Vector{5, 0, 0}, vector.Vector{-5, 0, 0}, cubeB)
+	if len(hits) != 1 || math.Abs(hits[0].Distance-(5-1.25-math.Sqrt2/2)) > 1e-6 {
+		t.Fatalf("a ray cast across the turned cube should hit its corner")
+	}
+
+	sphere.SetLocalPosition(-5, 0, 0)
+	if hit := sphere.Sweep(vector.Vector{10, 0, 0}, cubeA); hit == nil || hit.BoundingObject != cubeA || math.Abs(hit.Time-0.43) > 1e-4 {
+		t.Fatalf("a sphere swept into a cube should hit it; got %v", hit)
+	}
+
+}
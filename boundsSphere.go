@@ -9,14 +9,16 @@ import (
 // BoundingSphere represents a 3D sphere.
 type BoundingSphere struct {
 	*Node
+	CollisionFilter
 	Radius float64
 }
 
 // NewBoundingSphere returns a new BoundingSphere instance.
 func NewBoundingSphere(name string, radius float64) *BoundingSphere {
 	return &BoundingSphere{
-		Node:   NewNode(name),
-		Radius: radius,
+		Node:            NewNode(name),
+		CollisionFilter: newCollisionFilter(),
+		Radius:          radius,
 	}
 }
 
@@ -24,6 +26,7 @@ func NewBoundingSphere(name string, radius float64) *BoundingSphere {
 func (sphere *BoundingSphere) Clone() INode {
 	clone := NewBoundingSphere(sphere.name, sphere.Radius)
 	clone.Node = sphere.Node.Clone().(*Node)
+	clone.CollisionFilter = sphere.CollisionFilter
 	return clone
 }
 
@@ -71,6 +74,20 @@ func (sphere *BoundingSphere) Collision(other IBoundingObject) *Collision {
 	case *BoundingCapsule:
 		return btSphereCapsule(sphere, otherBounds)
 
+	case *BoundingOBB:
+		return btSphereOBB(sphere, otherBounds)
+
+	case *BoundingConvex:
+		intersection := btConvexShape(otherBounds, sphere)
+		if intersection != nil {
+			for _, inter := range intersection.Intersections {
+				inter.MTV = inter.MTV.Invert()
+				vector.In(inter.Normal).Invert()
+			}
+			intersection.BoundingObject = otherBounds
+		}
+		return intersection
+
 	}
 
 	panic("Unimplemented bounds type")
@@ -9,6 +9,7 @@ import (
 // BoundingTriangles is a Node specifically for detecting a collision between any of the triangles from a mesh instance and another BoundingObject.
 type BoundingTriangles struct {
 	*Node
+	CollisionFilter
 	BoundingAABB *BoundingAABB
 	Broadphase   *Broadphase
 	Mesh         *Mesh
@@ -23,9 +24,10 @@ type BoundingTriangles struct {
 func NewBoundingTriangles(name string, mesh *Mesh, broadphaseGridSize float64) *BoundingTriangles {
 	margin := 0.25 // An additional margin to help ensure the broadphase is crossed before checking for collisions
 	bt := &BoundingTriangles{
-		Node:         NewNode(name),
-		BoundingAABB: NewBoundingAABB("triangle broadphase aabb", mesh.Dimensions.Width()+margin, mesh.Dimensions.Height()+margin, mesh.Dimensions.Depth()+margin),
-		Mesh:         mesh,
+		Node:            NewNode(name),
+		CollisionFilter: newCollisionFilter(),
+		BoundingAABB:    NewBoundingAABB("triangle broadphase aabb", mesh.Dimensions.Width()+margin, mesh.Dimensions.Height()+margin, mesh.Dimensions.Depth()+margin),
+		Mesh:            mesh,
 	}
 	bt.Node.onTransformUpdate = bt.UpdateTransform
 
@@ -76,6 +78,7 @@ func (bt *BoundingTriangles) Clone() INode {
 	clone := NewBoundingTriangles(bt.name, bt.Mesh, 0) // Broadphase size is set to 0 so cloning doesn't create the broadphase triangle sets
 	clone.Broadphase = bt.Broadphase.Clone()
 	clone.Node = bt.Node.Clone().(*Node)
+	clone.CollisionFilter = bt.CollisionFilter
 	clone.Node.onTransformUpdate = clone.UpdateTransform
 	return clone
 }
@@ -138,6 +141,28 @@ func (bt *BoundingTriangles) Collision(other IBoundingObject) *Collision {
 		}
 		return intersection
 
+	case *BoundingOBB:
+		intersection := otherBounds.Collision(bt)
+		if intersection != nil {
+			for _, inter := range intersection.Intersections {
+				inter.MTV = inter.MTV.Invert()
+				vector.In(inter.Normal).Invert()
+			}
+			intersection.BoundingObject = otherBounds
+		}
+		return intersection
+
+	case *BoundingConvex:
+		intersection := btConvexTriangles(otherBounds, bt)
+		if intersection != nil {
+			for _, inter := range intersection.Intersections {
+				inter.MTV = inter.MTV.Invert()
+				vector.In(inter.Normal).Invert()
+			}
+			intersection.BoundingObject = otherBounds
+		}
+		return intersection
+
 	}
 
 	panic("Unimplemented bounds type")
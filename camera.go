@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"image/color"
 	"math"
+	"math/rand"
 	"sort"
 	"time"
 
@@ -31,6 +32,28 @@ type DebugInfo struct {
 	TotalTris        int // Total number of triangles
 	LightCount       int // Total number of lights
 	ActiveLightCount int // Total active number of lights
+
+	// These are only reset when Camera.Clear() is called, rather than being averaged over time
+	renderTime          time.Duration
+	renderAnimationTime time.Duration
+	skinnedVertices     int
+	frustumCulledModels int
+}
+
+// RenderStats holds statistics about everything a Camera has rendered since it was last cleared, for logging or profiling; see Camera.Stats().
+type RenderStats struct {
+	TrianglesProcessed int // Total number of triangles processed, including those culled
+	TrianglesCulled    int // Number of processed triangles that weren't drawn (because they were frustum-culled, backface-culled, or outside of the near / far planes)
+
+	// BatchesDrawn is the number of batches of triangles drawn, a batch being a MeshPart (along with the Models dynamically batched
+	// with it) or a Camera.RenderPoints() call. A MeshPart with too many triangles to fit in one batch is drawn in several. Each batch
+	// takes at least one draw call, and more when rendering depth, normals, outlines, or per-pixel lighting.
+	BatchesDrawn int
+
+	VerticesSkinned     int           // Number of vertices skinned; vertices reused through SkinningLOD aren't counted
+	FrustumCulledModels int           // Number of Models skipped entirely because they were outside of the Camera's view frustum
+	AnimationTime       time.Duration // CPU time spent skinning vertices
+	FrameTime           time.Duration // CPU time spent rendering. Doesn't necessarily include the time taken by the GPU to actually draw the results.
 }
 
 const (
@@ -46,6 +69,25 @@ type Camera struct {
 
 	RenderDepth bool // If the Camera should attempt to render a depth texture; if this is true, then DepthTexture will hold the depth texture render results.
 
+	// RenderNormals indicates if the Camera should render the normals of opaque triangles to a normal texture (see
+	// Camera.NormalTexture()), for effects like edge-detected outlines or screen-space ambient occlusion. NormalSpace is the space
+	// the normals are rendered in (NormalSpaceWorld or NormalSpaceView). If the Camera's also rendering depth, triangles hidden
+	// behind others don't overwrite their normals. RenderNormals defaults to false, and NormalSpace to NormalSpaceWorld.
+	RenderNormals bool
+	NormalSpace   int
+
+	// RenderModelIDs indicates if the Camera should render an ID for each Model it renders to a model ID texture (see
+	// Camera.ModelIDTexture()), so that the Model visible at any pixel can be looked up with Camera.ModelAtScreenPosition(); for dense
+	// Scenes, this is faster and more precise than casting rays (see Camera.MouseRayCast()). It requires the Camera to be rendering
+	// depth (see Camera.RenderDepth), so that Models hidden behind others don't overwrite their IDs. IDs are handed out each time the
+	// Camera's cleared; Models that are dynamically batched share the ID of the Model batching them. Defaults to false.
+	RenderModelIDs bool
+
+	// LayerMask is the set of render layers the Camera renders; Models that aren't on any of these layers (see Node.Layers) are
+	// skipped, so that one Scene can be rendered differently by different Cameras (for example, with one Camera rendering the game
+	// world, and another rendering only a first-person weapon on top of it). Defaults to RenderLayersAll.
+	LayerMask RenderLayers
+
 	resultColorTexture    *ebiten.Image // ColorTexture holds the color results of rendering any models.
 	resultDepthTexture    *ebiten.Image // DepthTexture holds the depth results of rendering any models, if Camera.RenderDepth is on.
 	colorIntermediate     *ebiten.Image
@@ -62,6 +104,92 @@ type Camera struct {
 	FieldOfView float64 // Vertical field of view in degrees for a perspective projection camera
 	OrthoScale  float64 // Scale of the view for an orthographic projection camera in units horizontally
 
+	// NearClipTriangles indicates if triangles that cross the near clipping plane of a perspective Camera should be clipped against it
+	// (creating one or two smaller triangles with interpolated UVs and colors), rather than being drawn with distorted vertices that lie
+	// behind the Camera. Defaults to true; turning it off is slightly faster, but large triangles close to the Camera will render incorrectly.
+	NearClipTriangles bool
+
+	// SubpixelCorrection snaps the screen positions of rendered vertices to a grid of SubpixelPrecision steps per pixel. Vertices shared
+	// by adjacent triangles can end up at very slightly different screen positions due to floating-point error (particularly with merged,
+	// batched, or separately transformed geometry), which can show up as cracks or shimmering pixels along the shared edges; snapping
+	// makes such vertices land in exactly the same place. Defaults to false.
+	SubpixelCorrection bool
+	SubpixelPrecision  int // How many steps each pixel is divided into when SubpixelCorrection is on; defaults to 16 (4 bits of subpixel precision, like most GPUs).
+
+	// VertexSnapping snaps the screen positions of rendered vertices to a grid of VertexSnappingGridSize pixels, reproducing the
+	// "wobbly" vertices of the original PlayStation, which lacked subpixel precision; as objects and the Camera move, their vertices
+	// jump from grid cell to grid cell rather than moving smoothly. Snapping happens after any Model.VertexClipFunction. Defaults to false.
+	VertexSnapping         bool
+	VertexSnappingGridSize float64 // The size of the grid cells vertices are snapped to when VertexSnapping is on, in pixels; defaults to 1.
+
+	// PerspectiveCorrectTextures controls how textures are mapped onto triangles for Materials that leave the choice to the Camera (see
+	// Material.TextureMapping). When it's false, textures are mapped affinely, which is cheaper, but makes textures warp along triangles
+	// that recede into the distance, like on the original PlayStation. When it's true, those triangles are subdivided so that textures
+	// appear perspective-correct; the subdivided triangles count towards the limit of triangles rendered at once. Only perspective
+	// Cameras are affected. Defaults to false.
+	PerspectiveCorrectTextures bool
+
+	// BloomEnabled enables a bloom post-effect, where bright areas of the Camera's color texture (like emissive Materials; see
+	// Material.EmissionColor) glow, bleeding light into their surroundings. The effect is applied to the texture returned by
+	// Camera.ColorTexture(). Defaults to false.
+	BloomEnabled   bool
+	BloomThreshold float64 // How bright (0 - 1) a pixel must be to bloom; defaults to 0.8.
+	BloomStrength  float64 // A multiplier for the brightness of the bloom; defaults to 1.
+	BloomRadius    float64 // How far the bloom spreads, in pixels; defaults to 8.
+
+	// DepthOfFieldEnabled enables a depth of field post-effect, where things that are out of focus (closer or further away from the
+	// Camera than the focus distance) are blurred, like with a real camera lens. It's applied to the texture returned by
+	// Camera.ColorTexture() (before bloom), and requires the Camera to be rendering depth (see Camera.RenderDepth). Defaults to false.
+	DepthOfFieldEnabled       bool
+	DepthOfFieldFocusDistance float64 // The distance from the Camera that's in focus, in world units; defaults to 10.
+	DepthOfFieldFocusRange    float64 // The size of the range around the focus distance that's completely in focus; defaults to 5.
+	DepthOfFieldFalloff       float64 // How far beyond the focus range things become fully blurred, in world units; defaults to 10.
+	DepthOfFieldRadius        float64 // How far the blur spreads for things that are fully blurred, in pixels; defaults to 8.
+
+	// FXAAEnabled enables FXAA (Fast Approximate Anti-Aliasing), a post-effect that softens the jagged edges of triangles by blurring
+	// along high-contrast edges in the texture returned by Camera.ColorTexture(). It's applied after bloom, but before any PostEffects.
+	// As it's a single full-screen pass, it's much cheaper than rendering at a higher resolution, but can slightly blur fine details
+	// (like text or pixel art textures). Defaults to false.
+	FXAAEnabled bool
+
+	// PostEffects are full-screen shader passes applied in order to the texture returned by Camera.ColorTexture() (after bloom and FXAA);
+	// see Camera.AddPostEffect(). The slice can be reordered or modified directly.
+	PostEffects []*PostEffect
+
+	// RenderTarget is an image that the Camera's result (the texture returned by Camera.ColorTexture()) is drawn to at the end of each
+	// Render() or RenderPoints() call, for rendering into a texture that a Material then uses (for security monitors, portals, or
+	// picture-in-picture views, for example). Unlike the Camera's own textures, which are recreated whenever the Camera's resized,
+	// the RenderTarget stays the same image, so Materials can keep using it; if it's a different size than the Camera, the result is
+	// stretched to fill it (using RenderTargetFilter). The RenderTarget shouldn't be used by Materials rendered by the same Camera,
+	// and isn't copied when the Camera's cloned (as two Cameras would overwrite each other's results). Defaults to nil.
+	RenderTarget       *ebiten.Image
+	RenderTargetFilter ebiten.Filter // The filter used when the Camera's result is stretched to fill its RenderTarget; defaults to ebiten.FilterNearest.
+
+	// OverlayNear and OverlayFar are the near and far clipping planes used when rendering overlays, like a first-person "viewmodel"
+	// (see Camera.RenderOverlay()); they default to 0.01 and 10. OverlayFieldOfView is the vertical field of view (in degrees) used
+	// for overlays if it's greater than 0 (otherwise, the Camera's own FieldOfView is used); defaults to 0. OverlayLayerMask is the
+	// set of render layers rendered in overlays, in place of the Camera's LayerMask; defaults to RenderLayersAll.
+	OverlayNear        float64
+	OverlayFar         float64
+	OverlayFieldOfView float64
+	OverlayLayerMask   RenderLayers
+
+	// DynamicResolution enables dynamic resolution, which lowers the resolution the Camera renders at while frames take longer than
+	// DynamicResolutionBudget, and raises it back up to the Camera's full size (the size it was created or last resized at) once
+	// there's time to spare, to keep the frame rate steady. Frame time is measured between calls to Camera.Clear(), so it includes
+	// everything done in a frame, not just rendering. The Camera's textures are resized when it's cleared, so Camera.Size() returns
+	// the resolution currently being rendered at, while Camera.FullSize() returns the full size; the Camera's results should be drawn
+	// scaled to fit (as a Viewport or the Camera's RenderTarget does). Defaults to false.
+	DynamicResolution bool
+
+	// DynamicResolutionBudget is the frame time that dynamic resolution aims to stay under. With vsync, frames can't take less time
+	// than the display's refresh interval, so the budget should be a little longer than that. Defaults to time.Second / 50.
+	DynamicResolutionBudget time.Duration
+
+	// DynamicResolutionMinScale is the lowest resolution dynamic resolution can render at, relative to the Camera's full size;
+	// defaults to 0.5.
+	DynamicResolutionMinScale float64
+
 	DebugInfo DebugInfo
 
 	backfacePool             *VectorPool
@@ -70,6 +198,12 @@ type Camera struct {
 	clipAlphaRenderShader    *ebiten.Shader
 	colorShader              *ebiten.Shader
 	sprite3DShader           *ebiten.Shader
+	ditheredDepthShader      *ebiten.Shader
+	ditheredColorShader      *ebiten.Shader
+	paletteShader            *ebiten.Shader
+	colorDepthShader         *ebiten.Shader
+	bloomExtractShader       *ebiten.Shader
+	bloomBlurShader          *ebiten.Shader
 
 	// Visibility check variables
 	cameraForward          vector.Vector
@@ -79,6 +213,179 @@ type Camera struct {
 	sphereFactorX          float64
 	sphereFactorTang       float64
 	sphereFactorCalculated bool
+
+	projectedPoints []projectedPoint // Reused between RenderPoints() calls to avoid reallocating
+
+	renderFrame uint64 // Incremented every time the Camera is cleared (so, generally, every frame)
+
+	shakeIntensity float64
+	shakeDuration  float64
+	shakeStart     time.Time
+	shakeOffset    vector.Vector // The offset applied to the Camera's position while rendering this frame
+
+	nearClipTriangles []nearClipTriangle // Near-plane clipping results for the MeshPart currently being rendered
+
+	// vertexTransforms holds the clip-space positions of the vertices of the Mesh currently being rendered, and sortingTriangles
+	// holds the triangles of the MeshPart currently being rendered, sorted in the order they're drawn. They're kept by the Camera
+	// rather than by the Mesh so that Cameras rendering the same Mesh don't overwrite each other's results.
+	vertexTransforms []vector.Vector
+	sortingTriangles []sortingTriangle
+
+	perspectiveLists [][]ebiten.Vertex                // The vertex lists being subdivided for perspective correction
+	perspectiveInput []perspectiveVertex              // The vertices of the triangles being subdivided for perspective correction
+	nearClipPoints   [maxClippedCorners]vector.Vector // Clip-space positions of a clipped triangle's vertices
+	nearClipScreen   [maxClippedCorners]vector.Vector // Screen-space positions of a clipped triangle's vertices
+
+	// clipPlane is a plane (in world space, as its normal followed by its offset) that triangles are clipped against when rendering,
+	// so that only what's on the side the normal faces is rendered; it's used to render reflections (see ReflectionPlane). It's nil
+	// if the Camera has no clip plane. clipPlaneCoefficients is the plane in clip space, updated at the start of each render.
+	clipPlane             vector.Vector
+	clipPlaneCoefficients [5]float64
+
+	bloomTexture       *ebiten.Image // The color texture with bloom applied
+	bloomIntermediateA *ebiten.Image
+	bloomIntermediateB *ebiten.Image
+	postProcessDirty   bool // If the color texture has changed since bloom and post effects were last applied
+
+	postEffectBuffers [2]*ebiten.Image // Ping-pong buffers for the PostEffects; created when first needed.
+
+	postProcessResult *ebiten.Image // The color texture with bloom and post effects applied
+
+	depthOfFieldShader  *ebiten.Shader
+	depthOfFieldTexture *ebiten.Image // The color texture with depth of field applied; created when first needed.
+
+	fxaaShader  *ebiten.Shader
+	fxaaTexture *ebiten.Image // The color texture with FXAA applied; created when first needed.
+
+	// Per-pixel lighting (see LightingModePixel); the shaders and buffers are created when first needed.
+	pixelLightShader           *ebiten.Shader
+	pixelLightCompositeShader  *ebiten.Shader
+	pixelVertexLitIntermediate *ebiten.Image
+	pixelUnlitIntermediate     *ebiten.Image
+	pixelLightIntermediate     *ebiten.Image
+	pixelLights                pixelLightSet
+
+	// Normal mapping (see Material.NormalMap), which is done as part of per-pixel lighting.
+	normalMapShader        *ebiten.Shader
+	normalMapIntermediates [3]*ebiten.Image
+
+	gBufferColorIntermediate *ebiten.Image // Used to render Materials using FragmentShaderGBuffer or TransparencyModeDithered; created when first needed.
+
+	// Normal rendering (see Camera.RenderNormals); the shader and textures are created when first needed.
+	normalCompositeShader *ebiten.Shader
+	resultNormalTexture   *ebiten.Image
+	normalIntermediate    *ebiten.Image
+
+	// Model ID rendering (see Camera.RenderModelIDs); the shader and texture are created when first needed.
+	modelIDShader        *ebiten.Shader
+	resultModelIDTexture *ebiten.Image
+	modelIDs             map[*Model]int // The ID of each Model rendered since the Camera was last cleared
+	modelsByID           []*Model       // The Models rendered since the Camera was last cleared, in order of their IDs (starting from 1)
+
+	fogVolumes      []*FogVolume  // The active FogVolumes in the Scene being rendered
+	fogIntermediate *ebiten.Image // Used to render local fog (see World.HeightFogEnabled and FogVolume); created when first needed.
+
+	skyboxShader *ebiten.Shader // Used to render the World's Skybox; created when first needed.
+
+	overlayDepthTexture *ebiten.Image // The depth texture used when rendering overlays; created when first needed.
+	renderingOverlay    bool          // If the Camera's currently rendering an overlay
+
+	// Dynamic resolution (see Camera.DynamicResolution)
+	fullWidth, fullHeight int           // The size the Camera was created or last resized at
+	resolutionScale       float64       // The resolution currently rendered at, relative to the full size
+	lastClearTime         time.Time     // When the Camera was last cleared
+	averageFrameTime      time.Duration // The smoothed time between recent calls to Clear()
+	resolutionCooldown    int           // How many frames to wait before lowering the resolution again
+	resolutionHeadroom    int           // How many frames in a row have had time to spare
+}
+
+// nearClipVertex is a vertex created by clipping a triangle against the near plane (or the Camera's clip plane); it's a blend of the
+// corners of the original triangle, using the given weights.
+type nearClipVertex struct {
+	Weights [3]float64
+}
+
+// dominantCorner returns the index of the corner of the original triangle that the vertex is closest to.
+func (v nearClipVertex) dominantCorner() int {
+	corner := 0
+	for i := 1; i < 3; i++ {
+		if v.Weights[i] > v.Weights[corner] {
+			corner = i
+		}
+	}
+	return corner
+}
+
+const (
+	// maxClippedCorners is the most corners a triangle can have after clipping; clipping against a plane adds at most one corner, and
+	// a triangle is clipped against the near plane and the Camera's clip plane.
+	maxClippedCorners = 5
+	// maxClippedVertices is the most vertices a clipped triangle can take up in the vertex lists, as its corners are split into a fan
+	// of triangles.
+	maxClippedVertices = (maxClippedCorners - 2) * 3
+)
+
+// nearClipTriangle holds the vertices that result from clipping a triangle against the near plane (and the Camera's clip plane); the
+// clipped shape can have up to five corners, and is split into a fan of triangles, and so there can be up to nine vertices.
+type nearClipTriangle struct {
+	Vertices    [maxClippedVertices]nearClipVertex
+	VertexCount int
+}
+
+// clipPolygon is a convex polygon being clipped against planes in clip space; each of its corners is a blend of the corners of the
+// original triangle, using the weights given.
+type clipPolygon struct {
+	Count   int
+	Points  [maxClippedCorners][4]float64
+	Weights [maxClippedCorners][3]float64
+}
+
+// clip clips the polygon against the given plane in clip space (the first four values being multiplied by a point's coordinates,
+// and the last being added), keeping the part where the plane's value is positive.
+func (polygon *clipPolygon) clip(plane [5]float64) {
+
+	distance := func(p [4]float64) float64 {
+		return p[0]*plane[0] + p[1]*plane[1] + p[2]*plane[2] + p[3]*plane[3] + plane[4]
+	}
+
+	in := *polygon
+	polygon.Count = 0
+
+	for a := 0; a < in.Count; a++ {
+
+		b := (a + 1) % in.Count
+
+		distA := distance(in.Points[a])
+		distB := distance(in.Points[b])
+
+		if distA >= 0 {
+			polygon.Points[polygon.Count] = in.Points[a]
+			polygon.Weights[polygon.Count] = in.Weights[a]
+			polygon.Count++
+		}
+
+		if (distA >= 0) != (distB >= 0) {
+
+			t := distA / (distA - distB)
+
+			for i := 0; i < 4; i++ {
+				polygon.Points[polygon.Count][i] = in.Points[a][i] + (in.Points[b][i]-in.Points[a][i])*t
+			}
+			for i := 0; i < 3; i++ {
+				polygon.Weights[polygon.Count][i] = in.Weights[a][i] + (in.Weights[b][i]-in.Weights[a][i])*t
+			}
+
+			polygon.Count++
+
+		}
+
+	}
+
+}
+
+// projectedPoint is a point projected to the screen for rendering with Camera.RenderPoints().
+type projectedPoint struct {
+	X, Y, Depth, HalfSize float32
 }
 
 // NewCamera creates a new Camera with the specified width and height.
@@ -87,13 +394,41 @@ func NewCamera(w, h int) *Camera {
 	cam := &Camera{
 		Node:        NewNode("Camera"),
 		RenderDepth: true,
+		LayerMask:   RenderLayersAll,
 		Near:        0.1,
 		Far:         100,
 
+		NearClipTriangles: true,
+		SubpixelPrecision: 16,
+
+		VertexSnappingGridSize: 1,
+
+		BloomThreshold: 0.8,
+		BloomStrength:  1,
+		BloomRadius:    8,
+
+		DepthOfFieldFocusDistance: 10,
+		DepthOfFieldFocusRange:    5,
+		DepthOfFieldFalloff:       10,
+		DepthOfFieldRadius:        8,
+
+		OverlayNear:      0.01,
+		OverlayFar:       10,
+		OverlayLayerMask: RenderLayersAll,
+
+		DynamicResolutionBudget:   time.Second / 50,
+		DynamicResolutionMinScale: 0.5,
+		resolutionScale:           1,
+
 		backfacePool:          NewVectorPool(3, true),
 		AccumulateDrawOptions: &ebiten.DrawImageOptions{},
 	}
 
+	for i := range cam.nearClipPoints {
+		cam.nearClipPoints[i] = vector.Vector{0, 0, 0, 0}
+		cam.nearClipScreen[i] = vector.Vector{0, 0, 0, 0}
+	}
+
 	depthShaderText := []byte(
 		`package main
 
@@ -197,6 +532,7 @@ func NewCamera(w, h int) *Camera {
 		var Fog vec4
 		var FogRange [2]float
 		var DitherSize float
+		var LocalFog float
 
 		var BayerMatrix [16]float
 
@@ -229,6 +565,12 @@ func NewCamera(w, h int) *Camera {
 					colorTex.rgb = mix(vec3(0, 0, 0), colorTex.rgb, colorTex.a)
 				}
 
+				// Local fog (height fog and fog volumes) is blended over the result, holding its premultiplied color and opacity.
+				if LocalFog > 0 {
+					localFog := imageSrc2UnsafeAt(texCoord)
+					colorTex.rgb = colorTex.rgb * (1 - localFog.a) + localFog.rgb * colorTex.a
+				}
+
 				return colorTex
 			}
 
@@ -274,6 +616,219 @@ func NewCamera(w, h int) *Camera {
 		panic(err)
 	}
 
+	// The dithered shaders discard fragments in a Bayer pattern according to the vertex color's alpha channel; for the depth shader,
+	// the alpha channel of the depth vertices is set to the alpha of the color vertices before drawing.
+	ditheredDepthShaderText := []byte(
+		`package main
+
+		var BayerMatrix [16]float
+
+		func encodeDepth(depth float) vec4 {
+			r := floor(depth * 255) / 255
+			g := floor(fract(depth * 255) * 255) / 255
+			b := fract(depth * 255*255)
+			return vec4(r, g, b, 1);
+		}
+
+		func decodeDepth(rgba vec4) float {
+			return rgba.r + (rgba.g / 255) + (rgba.b / 65025)
+		}
+
+		func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+
+			yc := int(position.y)%4
+			xc := int(position.x)%4
+
+			if color.a < BayerMatrix[(yc*4) + xc] {
+				discard()
+			}
+
+			existingDepth := imageSrc0At(position.xy / imageSrcTextureSize())
+
+			if existingDepth.a == 0 || decodeDepth(existingDepth) > color.r {
+				return encodeDepth(color.r)
+			}
+
+			discard()
+
+		}
+
+		`,
+	)
+
+	cam.ditheredDepthShader, err = ebiten.NewShader(ditheredDepthShaderText)
+
+	if err != nil {
+		panic(err)
+	}
+
+	ditheredColorShaderText := []byte(
+		`package main
+
+		var BayerMatrix [16]float
+
+		func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+
+			yc := int(position.y)%4
+			xc := int(position.x)%4
+
+			if color.a < BayerMatrix[(yc*4) + xc] {
+				discard()
+			}
+
+			return imageSrc0At(texCoord)
+
+		}
+
+		`,
+	)
+
+	cam.ditheredColorShader, err = ebiten.NewShader(ditheredColorShaderText)
+
+	if err != nil {
+		panic(err)
+	}
+
+	paletteShaderText := []byte(
+		`package main
+
+		var Palette [256]vec4
+		var PaletteSize float
+		var DitherStrength float
+
+		var BayerMatrix [16]float
+
+		func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+
+			src := imageSrc0UnsafeAt(texCoord)
+
+			if src.a == 0 {
+				return vec4(0, 0, 0, 0)
+			}
+
+			yc := int(position.y)%4
+			xc := int(position.x)%4
+
+			c := src.rgb / src.a
+			c += (BayerMatrix[(yc*4) + xc] - 0.5) * DitherStrength
+
+			best := c
+			bestDist := 1000.0
+
+			for i := 0; i < 256; i++ {
+				if float(i) < PaletteSize {
+					d := distance(c, Palette[i].rgb)
+					if d < bestDist {
+						bestDist = d
+						best = Palette[i].rgb
+					}
+				}
+			}
+
+			return vec4(best * src.a, src.a)
+
+		}
+
+		`,
+	)
+
+	cam.paletteShader, err = ebiten.NewShader(paletteShaderText)
+
+	if err != nil {
+		panic(err)
+	}
+
+	// Levels is the number of steps between the darkest and brightest value of each color channel (i.e. 2^bits - 1).
+	colorDepthShaderText := []byte(
+		`package main
+
+		var Levels float
+		var DitherStrength float
+
+		var BayerMatrix [16]float
+
+		func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+
+			src := imageSrc0UnsafeAt(texCoord)
+
+			if src.a == 0 {
+				return vec4(0, 0, 0, 0)
+			}
+
+			yc := int(position.y)%4
+			xc := int(position.x)%4
+
+			c := src.rgb / src.a
+			c += (BayerMatrix[(yc*4) + xc] - 0.5) * DitherStrength / Levels
+
+			c = clamp(floor(c * Levels + 0.5) / Levels, 0, 1)
+
+			return vec4(c * src.a, src.a)
+
+		}
+
+		`,
+	)
+
+	cam.colorDepthShader, err = ebiten.NewShader(colorDepthShaderText)
+
+	if err != nil {
+		panic(err)
+	}
+
+	bloomExtractShaderText := []byte(
+		`package main
+
+		var Threshold float
+
+		func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+
+			src := imageSrc0UnsafeAt(texCoord)
+
+			brightness := dot(src.rgb, vec3(0.299, 0.587, 0.114))
+
+			return src * clamp((brightness - Threshold) / max(1 - Threshold, 0.0001), 0, 1)
+
+		}
+
+		`,
+	)
+
+	cam.bloomExtractShader, err = ebiten.NewShader(bloomExtractShaderText)
+
+	if err != nil {
+		panic(err)
+	}
+
+	// A 9-tap separable Gaussian blur; Direction is the distance between taps in pixels.
+	bloomBlurShaderText := []byte(
+		`package main
+
+		var Direction vec2
+
+		func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+
+			offset := Direction / imageSrcTextureSize()
+
+			sum := imageSrc0At(texCoord) * 0.227027
+			sum += (imageSrc0At(texCoord + offset) + imageSrc0At(texCoord - offset)) * 0.1945946
+			sum += (imageSrc0At(texCoord + offset * 2) + imageSrc0At(texCoord - offset * 2)) * 0.1216216
+			sum += (imageSrc0At(texCoord + offset * 3) + imageSrc0At(texCoord - offset * 3)) * 0.054054
+			sum += (imageSrc0At(texCoord + offset * 4) + imageSrc0At(texCoord - offset * 4)) * 0.016216
+
+			return sum
+
+		}
+
+		`,
+	)
+
+	cam.bloomBlurShader, err = ebiten.NewShader(bloomBlurShaderText)
+
+	if err != nil {
+		panic(err)
+	}
+
 	if w != 0 && h != 0 {
 		cam.Resize(w, h)
 	}
@@ -285,8 +840,7 @@ func NewCamera(w, h int) *Camera {
 
 func (camera *Camera) Clone() INode {
 
-	w, h := camera.resultColorTexture.Size()
-	clone := NewCamera(w, h)
+	clone := NewCamera(camera.FullSize())
 
 	clone.RenderDepth = camera.RenderDepth
 	clone.Near = camera.Near
@@ -294,20 +848,72 @@ func (camera *Camera) Clone() INode {
 	clone.Perspective = camera.Perspective
 	clone.FieldOfView = camera.FieldOfView
 	clone.OrthoScale = camera.OrthoScale
+	clone.NearClipTriangles = camera.NearClipTriangles
+	clone.SubpixelCorrection = camera.SubpixelCorrection
+	clone.SubpixelPrecision = camera.SubpixelPrecision
+	clone.VertexSnapping = camera.VertexSnapping
+	clone.VertexSnappingGridSize = camera.VertexSnappingGridSize
+	clone.PerspectiveCorrectTextures = camera.PerspectiveCorrectTextures
+
+	clone.BloomEnabled = camera.BloomEnabled
+	clone.BloomThreshold = camera.BloomThreshold
+	clone.BloomStrength = camera.BloomStrength
+	clone.BloomRadius = camera.BloomRadius
+
+	clone.DepthOfFieldEnabled = camera.DepthOfFieldEnabled
+	clone.DepthOfFieldFocusDistance = camera.DepthOfFieldFocusDistance
+	clone.DepthOfFieldFocusRange = camera.DepthOfFieldFocusRange
+	clone.DepthOfFieldFalloff = camera.DepthOfFieldFalloff
+	clone.DepthOfFieldRadius = camera.DepthOfFieldRadius
+
+	clone.FXAAEnabled = camera.FXAAEnabled
+	clone.RenderTargetFilter = camera.RenderTargetFilter
+
+	clone.OverlayNear = camera.OverlayNear
+	clone.OverlayFar = camera.OverlayFar
+	clone.OverlayFieldOfView = camera.OverlayFieldOfView
+	clone.OverlayLayerMask = camera.OverlayLayerMask
+
+	clone.DynamicResolution = camera.DynamicResolution
+	clone.DynamicResolutionBudget = camera.DynamicResolutionBudget
+	clone.DynamicResolutionMinScale = camera.DynamicResolutionMinScale
+
+	if camera.clipPlane != nil {
+		clone.clipPlane = camera.clipPlane.Clone()
+	}
+
+	clone.LayerMask = camera.LayerMask
+	clone.RenderNormals = camera.RenderNormals
+	clone.RenderModelIDs = camera.RenderModelIDs
+	clone.NormalSpace = camera.NormalSpace
+
+	for _, effect := range camera.PostEffects {
+		clone.AddPostEffect(effect.Shader, effect.clonedUniforms()).Active = effect.Active
+	}
 
 	clone.AccumulateColorMode = camera.AccumulateColorMode
 	clone.AccumulateDrawOptions = camera.AccumulateDrawOptions
 
 	clone.Node = camera.Node.Clone().(*Node)
-	for _, child := range camera.children {
-		child.setParent(camera)
+	for _, child := range clone.children {
+		child.setParent(clone)
 	}
 
 	return clone
 
 }
 
+// Resize resizes the Camera's backing textures to the given width and height, recreating them (and so clearing them) if the size
+// has changed; resizing a Camera to the size it already is does nothing. If the Camera's using dynamic resolution (see
+// Camera.DynamicResolution), the given size is its full size, and its textures are scaled down from it as necessary.
 func (camera *Camera) Resize(w, h int) {
+	camera.fullWidth = w
+	camera.fullHeight = h
+	camera.resizeTextures(camera.scaledSize())
+}
+
+// resizeTextures recreates the Camera's backing textures at the given size, if they aren't that size already.
+func (camera *Camera) resizeTextures(w, h int) {
 
 	if camera.resultColorTexture != nil {
 
@@ -323,6 +929,71 @@ func (camera *Camera) Resize(w, h int) {
 		camera.colorIntermediate.Dispose()
 		camera.depthIntermediate.Dispose()
 		camera.clipAlphaIntermediate.Dispose()
+		camera.bloomTexture.Dispose()
+		camera.bloomIntermediateA.Dispose()
+		camera.bloomIntermediateB.Dispose()
+
+		if camera.pixelVertexLitIntermediate != nil {
+			camera.pixelVertexLitIntermediate.Dispose()
+			camera.pixelUnlitIntermediate.Dispose()
+			camera.pixelLightIntermediate.Dispose()
+			camera.pixelVertexLitIntermediate = nil
+			camera.pixelUnlitIntermediate = nil
+			camera.pixelLightIntermediate = nil
+		}
+
+		if camera.normalMapIntermediates[0] != nil {
+			for i, img := range camera.normalMapIntermediates {
+				img.Dispose()
+				camera.normalMapIntermediates[i] = nil
+			}
+		}
+
+		if camera.gBufferColorIntermediate != nil {
+			camera.gBufferColorIntermediate.Dispose()
+			camera.gBufferColorIntermediate = nil
+		}
+
+		if camera.fogIntermediate != nil {
+			camera.fogIntermediate.Dispose()
+			camera.fogIntermediate = nil
+		}
+
+		if camera.postEffectBuffers[0] != nil {
+			for i, img := range camera.postEffectBuffers {
+				img.Dispose()
+				camera.postEffectBuffers[i] = nil
+			}
+		}
+
+		camera.postProcessResult = nil
+
+		if camera.depthOfFieldTexture != nil {
+			camera.depthOfFieldTexture.Dispose()
+			camera.depthOfFieldTexture = nil
+		}
+
+		if camera.overlayDepthTexture != nil {
+			camera.overlayDepthTexture.Dispose()
+			camera.overlayDepthTexture = nil
+		}
+
+		if camera.fxaaTexture != nil {
+			camera.fxaaTexture.Dispose()
+			camera.fxaaTexture = nil
+		}
+
+		if camera.resultModelIDTexture != nil {
+			camera.resultModelIDTexture.Dispose()
+			camera.resultModelIDTexture = nil
+		}
+
+		if camera.resultNormalTexture != nil {
+			camera.resultNormalTexture.Dispose()
+			camera.normalIntermediate.Dispose()
+			camera.resultNormalTexture = nil
+			camera.normalIntermediate = nil
+		}
 	}
 
 	camera.resultAccumulatedColorTexture = ebiten.NewImage(w, h)
@@ -332,6 +1003,10 @@ func (camera *Camera) Resize(w, h int) {
 	camera.colorIntermediate = ebiten.NewImage(w, h)
 	camera.depthIntermediate = ebiten.NewImage(w, h)
 	camera.clipAlphaIntermediate = ebiten.NewImage(w, h)
+	camera.bloomTexture = ebiten.NewImage(w, h)
+	camera.bloomIntermediateA = ebiten.NewImage(w, h)
+	camera.bloomIntermediateB = ebiten.NewImage(w, h)
+	camera.postProcessDirty = true
 	camera.sphereFactorCalculated = false
 
 }
@@ -416,10 +1091,30 @@ func (camera *Camera) clipToScreen(vert, outVec vector.Vector, vertID int, model
 		outVec = model.VertexClipFunction(outVec, vertID)
 	}
 
+	if camera.VertexSnapping {
+		outVec[0] = snapToGrid(outVec[0], camera.VertexSnappingGridSize)
+		outVec[1] = snapToGrid(outVec[1], camera.VertexSnappingGridSize)
+	}
+
+	if camera.SubpixelCorrection && camera.SubpixelPrecision > 0 {
+		// With a power-of-two precision, snapped coordinates are also exactly representable when converted to float32 for rendering.
+		step := 1 / float64(camera.SubpixelPrecision)
+		outVec[0] = snapToGrid(outVec[0], step)
+		outVec[1] = snapToGrid(outVec[1], step)
+	}
+
 	return outVec
 
 }
 
+// snapToGrid rounds a screen coordinate to the nearest multiple of the given grid size (which can be a fraction of a pixel).
+func snapToGrid(value float64, size float64) float64 {
+	if size <= 0 {
+		return value
+	}
+	return math.Round(value/size) * size
+}
+
 // ClipToScreen projects the pre-transformed vertex in View space and remaps it to screen coordinates.
 func (camera *Camera) ClipToScreen(vert vector.Vector) vector.Vector {
 	width, height := camera.resultColorTexture.Size()
@@ -438,6 +1133,50 @@ func (camera *Camera) WorldToClip(vert vector.Vector) vector.Vector {
 	return mat.MultVecW(vert)
 }
 
+// ScreenToWorldRay returns a ray (as its origin on the Camera's near plane and its normalized direction, both in world space) running
+// from the Camera through the given screen position, for mouse picking and the like. Like Camera.WorldToScreen(), the screen
+// position is in the Camera's texture's pixels, so if the Camera's result is drawn scaled, mouse positions should be scaled to match.
+func (camera *Camera) ScreenToWorldRay(x, y float64) (origin, direction vector.Vector) {
+	near, far := camera.screenToNearFar(x, y)
+	return near, far.Sub(near).Unit()
+}
+
+// Unproject returns the world position at the given screen position (see Camera.ScreenToWorldRay()) that's the given depth in
+// front of the Camera (along its view direction, rather than along the ray), reversing Camera.WorldToScreen(). Only the X and Y
+// values of the screen position are used.
+func (camera *Camera) Unproject(screenPos vector.Vector, depth float64) vector.Vector {
+	near, far := camera.screenToNearFar(screenPos[0], screenPos[1])
+	return near.Add(far.Sub(near).Scale((depth - camera.Near) / (camera.Far - camera.Near)))
+}
+
+// screenToNearFar returns the world positions on the Camera's near and far planes at the given screen position.
+func (camera *Camera) screenToNearFar(x, y float64) (near, far vector.Vector) {
+
+	projection := camera.Projection()
+	inverse := camera.ViewMatrix().Mult(projection).Inverted()
+
+	w, h := camera.resultColorTexture.Size()
+	ndcX := (x - float64(w)/2) / float64(w)
+	ndcY := -(y - float64(h)/2) / float64(h)
+
+	// unproject transforms a point in normalized device coordinates back to world space.
+	unproject := func(depth float64) vector.Vector {
+
+		clip := projection.MultVecW(vector.Vector{0, 0, -depth})
+		ndcZ := clip[2] / clip[3]
+
+		out := vector.Vector{0, 0, 0}
+		for i := 0; i < 3; i++ {
+			out[i] = ndcX*inverse[0][i] + ndcY*inverse[1][i] + ndcZ*inverse[2][i] + inverse[3][i]
+		}
+		return out.Scale(1 / (ndcX*inverse[0][3] + ndcY*inverse[1][3] + ndcZ*inverse[2][3] + inverse[3][3]))
+
+	}
+
+	return unproject(camera.Near), unproject(camera.Far)
+
+}
+
 // PointInFrustum returns true if the point is visible through the camera frustum.
 func (camera *Camera) PointInFrustum(point vector.Vector) bool {
 
@@ -545,6 +1284,28 @@ func (camera *Camera) SphereInFrustum(sphere *BoundingSphere) bool {
 
 }
 
+// screenSize returns the fraction of the Camera's height that the given BoundingSphere spans on screen.
+func (camera *Camera) screenSize(sphere *BoundingSphere) float64 {
+
+	span := camera.Projection()[1][1] * sphere.WorldRadius()
+
+	if camera.Perspective {
+
+		depth := dot(fastVectorSub(sphere.WorldPosition(), camera.WorldPosition()), camera.WorldRotation().Forward().Invert())
+
+		// Spheres that reach the near plane fill the screen, or are about to.
+		if depth <= camera.Near+sphere.WorldRadius() {
+			return 1
+		}
+
+		span /= depth
+
+	}
+
+	return span
+
+}
+
 // AspectRatio returns the camera's aspect ratio (width / height).
 func (camera *Camera) AspectRatio() float64 {
 	w, h := camera.resultColorTexture.Size()
@@ -555,6 +1316,8 @@ func (camera *Camera) AspectRatio() float64 {
 // It also resets the debug values.
 func (camera *Camera) Clear() {
 
+	camera.updateDynamicResolution()
+
 	if camera.AccumulateColorMode != AccumlateColorModeNone {
 		camera.accumulatedBackBuffer.Clear()
 		camera.accumulatedBackBuffer.DrawImage(camera.resultAccumulatedColorTexture, nil)
@@ -572,11 +1335,25 @@ func (camera *Camera) Clear() {
 	}
 
 	camera.resultColorTexture.Clear()
+	camera.postProcessDirty = true
 
 	if camera.RenderDepth {
 		camera.resultDepthTexture.Clear()
 	}
 
+	if camera.RenderNormals {
+		camera.prepareNormalRendering()
+		camera.resultNormalTexture.Clear()
+	}
+
+	if camera.RenderModelIDs {
+		camera.clearModelIDs()
+	}
+
+	camera.updateShake()
+
+	camera.renderFrame++
+
 	if time.Since(camera.DebugInfo.tickTime).Milliseconds() >= 100 {
 
 		if !camera.DebugInfo.tickTime.IsZero() {
@@ -603,6 +1380,10 @@ func (camera *Camera) Clear() {
 	camera.DebugInfo.DrawnTris = 0
 	camera.DebugInfo.LightCount = 0
 	camera.DebugInfo.ActiveLightCount = 0
+	camera.DebugInfo.renderTime = 0
+	camera.DebugInfo.renderAnimationTime = 0
+	camera.DebugInfo.skinnedVertices = 0
+	camera.DebugInfo.frustumCulledModels = 0
 
 	cameraRot := camera.WorldRotation()
 	camera.cameraForward = cameraRot.Forward().Invert()
@@ -611,9 +1392,166 @@ func (camera *Camera) Clear() {
 
 }
 
+// Shake shakes the Camera, randomly offsetting its position along its local X and Y axes by up to intensity units when rendering.
+// The shaking lasts duration seconds, decaying linearly over that time. The offset changes once per frame (when Camera.Clear() is
+// called), and only applies while rendering, so the Camera's transform isn't altered. Calling Shake() while the Camera is already
+// shaking restarts the shake with the new values.
+func (camera *Camera) Shake(intensity, duration float64) {
+	camera.shakeIntensity = intensity
+	camera.shakeDuration = duration
+	camera.shakeStart = time.Now()
+}
+
+// Shaking returns if the Camera is currently shaking from a call to Camera.Shake().
+func (camera *Camera) Shaking() bool {
+	return camera.shakeDuration > 0 && time.Since(camera.shakeStart).Seconds() < camera.shakeDuration
+}
+
+// StopShaking stops the Camera from shaking.
+func (camera *Camera) StopShaking() {
+	camera.shakeDuration = 0
+	camera.shakeOffset = nil
+}
+
+// updateShake updates the Camera's shake offset for the current frame.
+func (camera *Camera) updateShake() {
+
+	if !camera.Shaking() {
+		camera.shakeOffset = nil
+		return
+	}
+
+	strength := camera.shakeIntensity * (1 - time.Since(camera.shakeStart).Seconds()/camera.shakeDuration)
+
+	rotation := camera.WorldRotation()
+	right := rotation.Right()
+	up := rotation.Up()
+
+	x := (rand.Float64()*2 - 1) * strength
+	y := (rand.Float64()*2 - 1) * strength
+
+	camera.shakeOffset = vector.Vector{
+		right[0]*x + up[0]*y,
+		right[1]*x + up[1]*y,
+		right[2]*x + up[2]*y,
+	}
+
+}
+
+// SetMotionBlur sets up the Camera's accumulation buffer to blend previous frames over the current one, creating a motion blur effect.
+// factor is the opacity of the previous frames (from 0 to 1), with higher values leaving longer trails; a factor of 0 or less turns
+// the accumulation buffer off. The blurred result is available through Camera.AccumulationColorTexture() after calling Camera.Clear()
+// on the next frame.
+func (camera *Camera) SetMotionBlur(factor float64) {
+
+	if factor <= 0 {
+		camera.AccumulateColorMode = AccumlateColorModeNone
+		return
+	}
+
+	if factor > 1 {
+		factor = 1
+	}
+
+	camera.AccumulateColorMode = AccumlateColorModeAbove
+	camera.AccumulateDrawOptions = &ebiten.DrawImageOptions{}
+	camera.AccumulateDrawOptions.ColorM.Scale(1, 1, 1, factor)
+
+}
+
+// MaxPaletteSize is the maximum number of colors that can be used with Camera.PaletteConstraint().
+const MaxPaletteSize = 256
+
+// PaletteConstraint constrains the colors of the Camera's color texture to the colors in the palette provided (up to MaxPaletteSize
+// colors), snapping each pixel to the nearest palette color. ditherStrength controls the strength of an ordered (Bayer) dither applied
+// before snapping, which helps to smooth out gradients; 0 means no dithering, while 1 offsets colors by up to half of the full color
+// range. As this operates on the Camera's color texture at its rendering resolution, call it after rendering, but before drawing the
+// color texture to the screen, so that the palette look survives scaling.
+func (camera *Camera) PaletteConstraint(palette []color.Color, ditherStrength float64) {
+
+	if len(palette) == 0 {
+		return
+	}
+
+	if len(palette) > MaxPaletteSize {
+		palette = palette[:MaxPaletteSize]
+	}
+
+	paletteData := make([]float32, MaxPaletteSize*4)
+	paletteSize := 0
+
+	for _, c := range palette {
+		r, g, b, a := c.RGBA()
+		if a == 0 {
+			continue // Fully transparent colors can't be un-premultiplied, and wouldn't be useful anyway
+		}
+		// RGBA() returns premultiplied colors, so we un-premultiply them here.
+		paletteData[paletteSize*4] = float32(r) / float32(a)
+		paletteData[paletteSize*4+1] = float32(g) / float32(a)
+		paletteData[paletteSize*4+2] = float32(b) / float32(a)
+		paletteData[paletteSize*4+3] = 1
+		paletteSize++
+	}
+
+	if paletteSize == 0 {
+		return
+	}
+
+	w, h := camera.resultColorTexture.Size()
+
+	camera.colorIntermediate.Clear()
+	camera.colorIntermediate.DrawRectShader(w, h, camera.paletteShader, &ebiten.DrawRectShaderOptions{
+		Images: [4]*ebiten.Image{camera.resultColorTexture},
+		Uniforms: map[string]interface{}{
+			"Palette":        paletteData,
+			"PaletteSize":    float32(paletteSize),
+			"DitherStrength": float32(ditherStrength),
+			"BayerMatrix":    bayerMatrix,
+		},
+	})
+
+	camera.resultColorTexture.Clear()
+	camera.resultColorTexture.DrawImage(camera.colorIntermediate, nil)
+	camera.postProcessDirty = true
+
+}
+
+// ColorDepthConstraint constrains the colors of the Camera's color texture to the given number of bits per color channel (from 1 to
+// 8), snapping each channel of each pixel to the nearest value available at that bit depth (so 1 bit gives 8 colors, 2 bits give
+// 64 colors, 5 bits give the 15-bit colors of the PlayStation, and so on). ditherStrength controls the strength of an ordered (Bayer)
+// dither applied before snapping, which helps to smooth out color banding; 0 means no dithering, while 1 offsets colors by up to half
+// of the difference between two available values. Like Camera.PaletteConstraint(), call it after rendering, but before drawing the
+// color texture to the screen.
+func (camera *Camera) ColorDepthConstraint(bitsPerChannel int, ditherStrength float64) {
+
+	if bitsPerChannel < 1 {
+		bitsPerChannel = 1
+	} else if bitsPerChannel > 8 {
+		bitsPerChannel = 8
+	}
+
+	w, h := camera.resultColorTexture.Size()
+
+	camera.colorIntermediate.Clear()
+	camera.colorIntermediate.DrawRectShader(w, h, camera.colorDepthShader, &ebiten.DrawRectShaderOptions{
+		Images: [4]*ebiten.Image{camera.resultColorTexture},
+		Uniforms: map[string]interface{}{
+			"Levels":         float32(int(1)<<bitsPerChannel - 1),
+			"DitherStrength": float32(ditherStrength),
+			"BayerMatrix":    bayerMatrix,
+		},
+	})
+
+	camera.resultColorTexture.Clear()
+	camera.resultColorTexture.DrawImage(camera.colorIntermediate, nil)
+	camera.postProcessDirty = true
+
+}
+
 // RenderNodes renders all nodes starting with the provided rootNode using the Scene's properties (fog, for example). Note that if Camera.RenderDepth
 // is false, scenes rendered one after another in multiple RenderNodes() calls will be rendered on top of each other in the Camera's texture buffers.
 // Note that for Models, each MeshPart of a Model has a maximum renderable triangle count of 21845.
+// As with Render(), multiple Cameras can render the same Scene one after another in the same frame.
 func (camera *Camera) RenderNodes(scene *Scene, rootNode INode) {
 
 	meshes := []*Model{}
@@ -639,6 +1577,13 @@ type renderPair struct {
 	MeshPart *MeshPart
 }
 
+func (rp renderPair) sortBias() float64 {
+	if rp.MeshPart.Material != nil {
+		return rp.MeshPart.Material.SortBias
+	}
+	return 0
+}
+
 // Bayer Matrix for transparency dithering
 var bayerMatrix = []float32{
 	1.0 / 17.0, 9.0 / 17.0, 3.0 / 17.0, 11.0 / 17.0,
@@ -650,16 +1595,38 @@ var bayerMatrix = []float32{
 // Render renders all of the models passed using the provided Scene's properties (fog, for example). Note that if Camera.RenderDepth
 // is false, scenes rendered one after another in multiple Render() calls will be rendered on top of each other in the Camera's texture buffers.
 // Note that for Models, each MeshPart of a Model has a maximum renderable triangle count of 21845.
+// Each Model is fully processed and drawn before the next one, so multiple Cameras can render the same Scene one after another in
+// the same frame (for split-screen, for example) without interfering with each other. Rendering from multiple goroutines simultaneously
+// is not supported, though, as Cameras and Models reuse their vertex buffers between renders.
 func (camera *Camera) Render(scene *Scene, models ...*Model) {
 
 	frametimeStart := time.Now()
 
+	camera.postProcessDirty = true
+
+	// Shaking offsets the Camera for the duration of the render only.
+	if camera.shakeOffset != nil {
+		originalPosition := camera.LocalPosition()
+		camera.SetWorldPositionVec(camera.WorldPosition().Add(camera.shakeOffset))
+		defer camera.SetLocalPositionVec(originalPosition)
+	}
+
+	// Overlays are drawn on top of what's already been rendered, so the Skybox is already behind them.
+	if scene.World != nil && !camera.renderingOverlay {
+		camera.drawSkybox(scene.World)
+	}
+
 	sceneLights := []ILight{}
-	lights := sceneLights
+	lights := make([]ILight, 0, 8)
+	specularSources := make([]specularSource, 0, 8)
 
 	if scene.World == nil || scene.World.LightingOn {
 
 		for _, l := range scene.Root.ChildrenRecursive() {
+			// LightProbes only light Models through their LightGroups.
+			if _, isProbe := l.(*LightProbe); isProbe {
+				continue
+			}
 			if light, isLight := l.(ILight); isLight {
 				camera.DebugInfo.LightCount++
 				if light.IsOn() {
@@ -681,6 +1648,17 @@ func (camera *Camera) Render(scene *Scene, models ...*Model) {
 	// matrix, which we feed into model.TransformedVertices() to draw vertices in order of distance.
 	vpMatrix := camera.ViewMatrix().Mult(camera.Projection())
 
+	camera.updateClipPlane(vpMatrix)
+	clipPlaneActive := camera.clipPlane != nil
+
+	// Normals are rotated into view space by the inverse of the Camera's rotation.
+	var viewRotation Matrix4
+
+	if camera.RenderNormals {
+		camera.prepareNormalRendering()
+		viewRotation = camera.WorldRotation().Inverted()
+	}
+
 	rectShaderOptions := &ebiten.DrawRectShaderOptions{}
 	rectShaderOptions.Images[0] = camera.colorIntermediate
 	rectShaderOptions.Images[1] = camera.depthIntermediate
@@ -703,6 +1681,26 @@ func (camera *Camera) Render(scene *Scene, models ...*Model) {
 
 	}
 
+	// Local fog is rendered when the World has height fog, or when there are FogVolumes in the Scene.
+	camera.fogVolumes = camera.fogVolumes[:0]
+
+	if scene != nil {
+		for _, node := range scene.Root.ChildrenRecursive() {
+			if volume, ok := node.(*FogVolume); ok && volume.On {
+				volume.beginRender()
+				camera.fogVolumes = append(camera.fogVolumes, volume)
+			}
+		}
+	}
+
+	localFogged := len(camera.fogVolumes) > 0 || (scene != nil && scene.World != nil && scene.World.HeightFogEnabled)
+
+	if localFogged {
+		camera.prepareLocalFog()
+		rectShaderOptions.Images[2] = camera.fogIntermediate
+		rectShaderOptions.Uniforms["LocalFog"] = float32(1)
+	}
+
 	// Reusing vectors rather than reallocating for all triangles for all models
 	p0 := vector.Vector{0, 0, 0, 0}
 	p1 := vector.Vector{0, 0, 0, 0}
@@ -715,7 +1713,7 @@ func (camera *Camera) Render(scene *Scene, models ...*Model) {
 
 	for _, model := range models {
 
-		if !model.visible {
+		if !model.visible || !camera.LayerMask.Has(model.Layers) {
 			continue
 		}
 
@@ -729,7 +1727,7 @@ func (camera *Camera) Render(scene *Scene, models ...*Model) {
 
 				for _, child := range modelSlice {
 
-					if !child.visible {
+					if !child.visible || !camera.LayerMask.Has(child.Layers) {
 						continue
 					}
 
@@ -787,14 +1785,17 @@ func (camera *Camera) Render(scene *Scene, models ...*Model) {
 
 	}
 
-	// If the camera isn't rendering depth, then we should sort models by distance to ensure things draw in something like the correct order
-	if !camera.RenderDepth {
-
-		sort.SliceStable(solids, func(i, j int) bool {
+	// If the camera isn't rendering depth, then we should sort models by distance to ensure things draw in something like the correct order.
+	// Either way, Materials' SortBias values take precedence.
+	sort.SliceStable(solids, func(i, j int) bool {
+		if biasI, biasJ := solids[i].sortBias(), solids[j].sortBias(); biasI != biasJ {
+			return biasI < biasJ
+		}
+		if !camera.RenderDepth {
 			return depths[solids[i].Model] > depths[solids[j].Model]
-		})
-
-	}
+		}
+		return false
+	})
 
 	camWidth, camHeight := camera.resultColorTexture.Size()
 
@@ -805,7 +1806,14 @@ func (camera *Camera) Render(scene *Scene, models ...*Model) {
 		near = 0
 	}
 
-	render := func(rp renderPair) {
+	// If the triangles to be flushed were lit per-pixel, or the lightmap or normal map they were lit with.
+	flushPixelLit := false
+	var flushLightmap *ebiten.Image
+	var flushNormalMap *ebiten.Image
+
+	var flush func(rp renderPair)
+
+	render := func(rp, flushPair renderPair) {
 
 		startingVertexListIndex := vertexListIndex
 
@@ -822,12 +1830,35 @@ func (camera *Camera) Render(scene *Scene, models ...*Model) {
 		lighting := false
 		if scene.World != nil {
 			if mat != nil {
-				lighting = scene.World.LightingOn && !mat.Shadeless
+				// Matcaps and reflections hold the lighting of the Materials using them.
+				lighting = scene.World.LightingOn && !mat.Shadeless && mat.Matcap == nil && mat.reflection() == nil
 			} else {
 				lighting = scene.World.LightingOn
 			}
 		}
 
+		// Lightmapped Materials are lit by their lightmaps rather than by the Scene's lights.
+		lightmapped := lighting && mat != nil && mat.lightmapped()
+		if lightmapped {
+			lighting = false
+			flushLightmap = mat.Lightmap
+			camera.preparePixelLighting()
+		}
+
+		pixelLit := lighting && mat != nil && mat.pixelLit()
+		if pixelLit {
+			flushPixelLit = true
+		}
+
+		normalMapped := pixelLit && mat.normalMapped()
+		if normalMapped {
+			flushNormalMap = mat.NormalMap
+			camera.prepareNormalMapping()
+			if len(model.Mesh.VertexTangents) != model.Mesh.VertexCount {
+				model.Mesh.GenerateTangents()
+			}
+		}
+
 		camera.DebugInfo.TotalParts++
 		camera.DebugInfo.TotalTris += meshPart.TriangleCount()
 
@@ -836,11 +1867,23 @@ func (camera *Camera) Render(scene *Scene, models ...*Model) {
 		if model.FrustumCulling {
 
 			if !camera.SphereInFrustum(model.BoundingSphere) {
+				// Each MeshPart is rendered separately, so the Model is only counted once, for its first MeshPart
+				if meshPart == model.Mesh.MeshParts[0] {
+					camera.DebugInfo.frustumCulledModels++
+				}
 				return
 			}
 
 		}
 
+		// Models that lie entirely on the clipped side of the clip plane can be skipped.
+		if clipPlaneActive && len(model.DynamicBatchModels) == 0 {
+			center := model.BoundingSphere.WorldPosition()
+			if dot(center, camera.clipPlane)+camera.clipPlane[3] < -model.BoundingSphere.WorldRadius() {
+				return
+			}
+		}
+
 		if model.DynamicBatchOwner != nil {
 			camera.DebugInfo.BatchedParts++
 		}
@@ -848,263 +1891,786 @@ func (camera *Camera) Render(scene *Scene, models ...*Model) {
 		model.ProcessVertices(vpMatrix, camera, meshPart, scene)
 
 		backfaceCulling := true
+		flipBackfaceNormals := false
 		if mat != nil {
 			backfaceCulling = mat.BackfaceCulling
+			flipBackfaceNormals = mat.FlipBackfaceNormals && lighting
 		}
 
 		srcW := 0.0
 		srcH := 0.0
+		uvTransform := identityUVTransform
+		textureUVs := model.Mesh.VertexUVs
 
-		if mat != nil && mat.Texture != nil {
-			srcW = float64(mat.Texture.Bounds().Dx())
-			srcH = float64(mat.Texture.Bounds().Dy())
+		if mat != nil {
+			uvTransform = mat.uvTransform()
+			if mat.TextureUVSet == 1 {
+				textureUVs = model.Mesh.VertexUV2s
+			}
+			if texture := model.materialTexture(meshPart); texture != nil {
+				srcW = float64(texture.Bounds().Dx())
+				srcH = float64(texture.Bounds().Dy())
+			}
 		}
 
 		if lighting {
 
 			t := time.Now()
 
-			lights = sceneLights
+			candidateLights := sceneLights
 
 			if model.LightGroup != nil && model.LightGroup.Active {
-				lights = model.LightGroup.Lights
+				candidateLights = model.LightGroup.Lights
 				for _, l := range model.LightGroup.Lights {
 					l.beginRender() // Call this because it's relatively cheap and necessary if a light doesn't exist in the Scene
 				}
 			}
 
-			for _, light := range lights {
-				light.beginModel(model)
+			if pixelLit {
+
+				camera.preparePixelLighting()
+
+				// Dynamically batched Models are drawn together, so they share the lights of their batch owner.
+				owner := model
+				if model.DynamicBatchOwner != nil {
+					owner = model.DynamicBatchOwner
+					if owner.LightGroup != nil && owner.LightGroup.Active {
+						candidateLights = owner.LightGroup.Lights
+					} else {
+						candidateLights = sceneLights
+					}
+				}
+
+				camera.pixelLights.assign(candidateLights, owner.WorldPosition(), owner.LightLayers)
+				camera.pixelLights.assignToon(mat)
+				camera.pixelLights.assignSpecular(mat, camera)
+
 			}
 
+			// Lights that can't reach the Model are skipped entirely, rather than being checked for every triangle.
+			lights = lights[:0]
+
+			for _, light := range candidateLights {
+				if pixelLit && camera.pixelLights.lights[light] {
+					continue
+				}
+				if !model.LightLayers.Has(light.lightLayers()) {
+					continue
+				}
+				if lightInfluencesModel(light, model) {
+					light.beginModel(model)
+					lights = append(lights, light)
+				}
+			}
+
+			normalizeLightProbes(lights)
+
 			camera.DebugInfo.lightTime += time.Since(t)
 
 		}
 
 		mesh := model.Mesh
-		maxSpan := model.Mesh.Dimensions.MaxSpan()
-		modelPos := model.WorldPosition()
 
 		// Here we do all vertex transforms first because of data locality (it's faster to access all vertex transformations, then go back and do all UV values, etc)
 
-		for t := range meshPart.sortingTriangles {
+		nearClip := camera.NearClipTriangles && camera.Perspective
 
-			if !meshPart.sortingTriangles[t].rendered {
-				continue
+		perspectiveCorrected := camera.perspectiveCorrected(mat)
+		if perspectiveCorrected {
+			camera.preparePerspectiveCorrection()
+		}
+
+		// flushChunk flushes the triangles gathered so far (along with those of any dynamically batched Models rendered before this
+		// one) when the vertex lists fill up, so that the rest of the MeshPart can be rendered into empty lists.
+		flushChunk := func() {
+			flush(flushPair)
+			flushPixelLit = pixelLit
+			if lightmapped {
+				flushLightmap = mat.Lightmap
+			}
+			if normalMapped {
+				flushNormalMap = mat.NormalMap
 			}
+			startingVertexListIndex = vertexListIndex
+		}
 
-			meshPart.sortingTriangles[t].rendered = false
+		// The triangles are gathered in chunks, each one holding as many triangles as fit in the vertex lists.
+		for first := 0; first < len(camera.sortingTriangles); {
 
-			vertIndex := meshPart.sortingTriangles[t].ID * 3
-			v0 := mesh.vertexTransforms[vertIndex]
-			v1 := mesh.vertexTransforms[vertIndex+1]
-			v2 := mesh.vertexTransforms[vertIndex+2]
+			next := len(camera.sortingTriangles)
+			camera.nearClipTriangles = camera.nearClipTriangles[:0]
 
-			p0 = camera.clipToScreen(v0, p0, vertIndex, model, float64(camWidth), float64(camHeight))
-			p1 = camera.clipToScreen(v1, p1, vertIndex+1, model, float64(camWidth), float64(camHeight))
-			p2 = camera.clipToScreen(v2, p2, vertIndex+2, model, float64(camWidth), float64(camHeight))
+			for t := first; t < len(camera.sortingTriangles); t++ {
 
-			// We can skip triangles that lie entirely outside of the view horizontally and vertically.
-			if (p0[0] < 0 && p1[0] < 0 && p2[0] < 0) ||
-				(p0[1] < 0 && p1[1] < 0 && p2[1] < 0) ||
-				(p0[0] > float64(camWidth) && p1[0] > float64(camWidth) && p2[0] > float64(camWidth)) ||
-				(p0[1] > float64(camHeight) && p1[1] > float64(camHeight) && p2[1] > float64(camHeight)) {
-				continue
-			}
+				if !camera.sortingTriangles[t].rendered {
+					continue
+				}
+
+				camera.sortingTriangles[t].rendered = false
+				camera.sortingTriangles[t].clipIndex = -1
+
+				vertIndex := camera.sortingTriangles[t].ID * 3
+				v0 := camera.vertexTransforms[vertIndex]
+				v1 := camera.vertexTransforms[vertIndex+1]
+				v2 := camera.vertexTransforms[vertIndex+2]
+
+				var clipped nearClipTriangle
+				vertexCount := 3
+				pointCount := 3
+
+				crossesNear := nearClip && (v0[3] < near || v1[3] < near || v2[3] < near)
+				crossesClipPlane := clipPlaneActive && (camera.clipPlaneDistance(v0) < 0 || camera.clipPlaneDistance(v1) < 0 || camera.clipPlaneDistance(v2) < 0)
+
+				if crossesNear || crossesClipPlane {
+
+					// The triangle crosses the near plane (or the clip plane), so we clip it, creating a smaller polygon.
+					pointCount = camera.clipTriangle(v0, v1, v2, crossesNear, near, &clipped)
+
+					if pointCount < 3 {
+						continue
+					}
+
+					for i := 0; i < pointCount; i++ {
+						cv := clipped.Vertices[i]
+						camera.nearClipScreen[i] = camera.clipToScreen(camera.nearClipPoints[i], camera.nearClipScreen[i], vertIndex+cv.dominantCorner(), model, float64(camWidth), float64(camHeight))
+					}
+
+					p0 = camera.nearClipScreen[0]
+					p1 = camera.nearClipScreen[1]
+					p2 = camera.nearClipScreen[2]
+
+					// Triangulate the polygon as a fan of triangles: (0, 1, 2), (0, 2, 3), and so on.
+					corners := clipped.Vertices
+					vertexCount = (pointCount - 2) * 3
+					for i := 0; i < vertexCount; i++ {
+						clipped.Vertices[i] = corners[fanIndex(i)]
+					}
+
+					clipped.VertexCount = vertexCount
+
+				} else {
 
-			// This is a bit of a hacky way to do backface culling; it works, but it uses
-			// the screen positions of the vertices to determine if the triangle should be culled.
-			// In truth, it would be better to use the above approach, but that gives us visual
-			// errors when faces are behind the camera unless we clip triangles. I don't really
-			// feel like doing that right now, so here we are.
+					p0 = camera.clipToScreen(v0, p0, vertIndex, model, float64(camWidth), float64(camHeight))
+					p1 = camera.clipToScreen(v1, p1, vertIndex+1, model, float64(camWidth), float64(camHeight))
+					p2 = camera.clipToScreen(v2, p2, vertIndex+2, model, float64(camWidth), float64(camHeight))
 
-			if backfaceCulling {
+				}
+
+				// We can skip triangles that lie entirely outside of the view horizontally and vertically.
+				if pointCount == 3 {
 
-				camera.backfacePool.Reset()
-				n0 := camera.backfacePool.Sub(p0, p1)[:3]
-				n1 := camera.backfacePool.Sub(p1, p2)[:3]
-				nor := camera.backfacePool.Cross(n0, n1)
+					if (p0[0] < 0 && p1[0] < 0 && p2[0] < 0) ||
+						(p0[1] < 0 && p1[1] < 0 && p2[1] < 0) ||
+						(p0[0] > float64(camWidth) && p1[0] > float64(camWidth) && p2[0] > float64(camWidth)) ||
+						(p0[1] > float64(camHeight) && p1[1] > float64(camHeight) && p2[1] > float64(camHeight)) {
+						continue
+					}
 
-				if nor[2] > 0 {
+				} else if screenPointsOutside(camera.nearClipScreen[:pointCount], float64(camWidth), float64(camHeight)) {
 					continue
 				}
 
+				// This is a bit of a hacky way to do backface culling; it works, but it uses
+				// the screen positions of the vertices to determine if the triangle should be culled.
+				// Note that this relies on triangles crossing the near plane being clipped; otherwise,
+				// vertices behind the camera can flip the winding order.
+
+				camera.sortingTriangles[t].backfacing = false
+
+				if backfaceCulling || flipBackfaceNormals {
+
+					camera.backfacePool.Reset()
+					n0 := camera.backfacePool.Sub(p0, p1)[:3]
+					n1 := camera.backfacePool.Sub(p1, p2)[:3]
+					nor := camera.backfacePool.Cross(n0, n1)
+
+					if nor[2] > 0 {
+						if backfaceCulling {
+							continue
+						}
+						camera.sortingTriangles[t].backfacing = true
+					}
+
+				}
+
+				// If the vertex lists are full, we flush the triangles gathered so far and carry on from this triangle. Note that a clipped
+				// triangle can take up to maxClippedVertices vertices, rather than three.
+				if vertexListIndex+vertexCount > ebiten.MaxIndicesNum {
+
+					camera.sortingTriangles[t].rendered = true
+
+					if vertexListIndex > startingVertexListIndex {
+						next = t
+						break
+					}
+
+					// None of this MeshPart's triangles have been gathered yet, so the lists are full of the dynamically batched
+					// Models rendered before it; those can be flushed right away.
+					flushChunk()
+
+				}
+
+				if clipped.VertexCount == 0 {
+
+					colorVertexList[vertexListIndex].DstX = float32(p0[0])
+					colorVertexList[vertexListIndex].DstY = float32(p0[1])
+					colorVertexList[vertexListIndex+1].DstX = float32(p1[0])
+					colorVertexList[vertexListIndex+1].DstY = float32(p1[1])
+					colorVertexList[vertexListIndex+2].DstX = float32(p2[0])
+					colorVertexList[vertexListIndex+2].DstY = float32(p2[1])
+
+					depthVertexList[vertexListIndex].DstX = float32(p0[0])
+					depthVertexList[vertexListIndex].DstY = float32(p0[1])
+					depthVertexList[vertexListIndex+1].DstX = float32(p1[0])
+					depthVertexList[vertexListIndex+1].DstY = float32(p1[1])
+					depthVertexList[vertexListIndex+2].DstX = float32(p2[0])
+					depthVertexList[vertexListIndex+2].DstY = float32(p2[1])
+
+					if perspectiveCorrected {
+						perspectiveWList[vertexListIndex] = v0[3]
+						perspectiveWList[vertexListIndex+1] = v1[3]
+						perspectiveWList[vertexListIndex+2] = v2[3]
+					}
+
+				} else {
+
+					for i := 0; i < vertexCount; i++ {
+						pointIndex := fanIndex(i)
+						p := camera.nearClipScreen[pointIndex]
+						colorVertexList[vertexListIndex+i].DstX = float32(p[0])
+						colorVertexList[vertexListIndex+i].DstY = float32(p[1])
+						depthVertexList[vertexListIndex+i].DstX = float32(p[0])
+						depthVertexList[vertexListIndex+i].DstY = float32(p[1])
+						if perspectiveCorrected {
+							perspectiveWList[vertexListIndex+i] = camera.nearClipPoints[pointIndex][3]
+						}
+					}
+
+				}
+
+				if clipped.VertexCount > 0 {
+					camera.sortingTriangles[t].clipIndex = len(camera.nearClipTriangles)
+					camera.nearClipTriangles = append(camera.nearClipTriangles, clipped)
+				}
+
+				camera.sortingTriangles[t].rendered = true
+
+				vertexListIndex += vertexCount
+
+			}
+
+			if vertexListIndex == startingVertexListIndex {
+				return
+			}
+
+			vertexListIndex = startingVertexListIndex
+
+			lightmapW, lightmapH := 0.0, 0.0
+			if lightmapped {
+				lightmapW = float64(mat.Lightmap.Bounds().Dx())
+				lightmapH = float64(mat.Lightmap.Bounds().Dy())
+			}
+
+			var rimR, rimG, rimB float32
+			rimLit := false
+
+			var specularR, specularG, specularB float32
+			specularLit := false
+
+			if lighting && mat != nil {
+				rimR, rimG, rimB, rimLit = mat.rim()
+				specularR, specularG, specularB, specularLit = mat.specular()
+			}
+
+			// Specular highlights are calculated in world space, so the lights' world positions and orientations are gathered up front.
+			if specularLit {
+				specularSources = specularSources[:0]
+				for _, light := range lights {
+					if source, ok := newSpecularSource(light); ok {
+						specularSources = append(specularSources, source)
+					}
+				}
+			}
+
+			// Pixel lighting, rim lighting, specular highlights, triplanar mapping, matcaps, and normal rendering all need the vertices'
+			// world positions and normals.
+			var worldPositions, worldNormals []vector.Vector
+			var worldTransform, worldRotation Matrix4
+
+			triplanar := mat != nil && mat.TriplanarScale > 0
+
+			// Matcaps are mapped using the vertices' normals in view space.
+			matcapped := mat != nil && mat.Matcap != nil
+			var matcapRotation Matrix4
+			if matcapped {
+				matcapRotation = camera.WorldRotation().Inverted()
 			}
 
-			// Enforce maximum vertex count; note that this is lazy, which is NOT really a good way of doing this, as you can't really know ahead of time how many triangles may render.
-			if vertexListIndex/3 >= ebiten.MaxIndicesNum/3 {
-				if model.DynamicBatchOwner == nil {
-					panic("error in rendering mesh [" + model.Mesh.Name + "] of model [" + model.name + "]. At " + fmt.Sprintf("%d", len(model.Mesh.Triangles)) + " triangles, it exceeds the maximum of 21845 rendered triangles total for one MeshPart; please break up the mesh into multiple MeshParts using materials, or split it up into models")
+			// Reflections are mapped using the vertices' screen positions once they've been clipped, with any distortion offsetting them.
+			reflection := mat.reflection()
+			distorted := reflection != nil && reflection.Distortion != 0
+
+			renderNormals := camera.RenderNormals && !model.isTransparent(meshPart)
+
+			if pixelLit || rimLit || specularLit || triplanar || matcapped || distorted || renderNormals || localFogged {
+				if model.Skinned {
+					worldPositions, worldNormals = mesh.vertexSkinnedPositions, mesh.vertexSkinnedNormals
 				} else {
-					panic("error in rendering mesh [" + model.Mesh.Name + "] of model [" + model.name + "] underneath Dynamic merging owner " + model.DynamicBatchOwner.name + ". At " + fmt.Sprintf("%d", model.DynamicBatchOwner.DynamicBatchTriangleCount()) + " triangles, it exceeds the maximum of 21845 rendered triangles total for one MeshPart; please break up the mesh into multiple MeshParts using materials, or split it up into models")
+					worldPositions, worldNormals = model.morphedVertices()
+					worldTransform = model.Transform()
+					worldRotation = model.WorldRotation()
 				}
 			}
 
-			colorVertexList[vertexListIndex].DstX = float32(p0[0])
-			colorVertexList[vertexListIndex].DstY = float32(p0[1])
-			colorVertexList[vertexListIndex+1].DstX = float32(p1[0])
-			colorVertexList[vertexListIndex+1].DstY = float32(p1[1])
-			colorVertexList[vertexListIndex+2].DstX = float32(p2[0])
-			colorVertexList[vertexListIndex+2].DstY = float32(p2[1])
+			worldVertex := func(tri sortingTriangle, i int) (vector.Vector, vector.Vector) {
 
-			depthVertexList[vertexListIndex].DstX = float32(p0[0])
-			depthVertexList[vertexListIndex].DstY = float32(p0[1])
-			depthVertexList[vertexListIndex+1].DstX = float32(p1[0])
-			depthVertexList[vertexListIndex+1].DstY = float32(p1[1])
-			depthVertexList[vertexListIndex+2].DstX = float32(p2[0])
-			depthVertexList[vertexListIndex+2].DstY = float32(p2[1])
+				position := worldPositions[tri.ID*3+i]
+				normal := worldNormals[tri.ID*3+i]
 
-			meshPart.sortingTriangles[t].rendered = true
+				if !model.Skinned {
+					position = worldTransform.MultVec(position)
+					normal = worldRotation.MultVec(normal)
+				}
 
-			vertexListIndex += 3
+				if tri.backfacing {
+					normal = normal.Invert()
+				}
 
-		}
+				return position, normal
 
-		if vertexListIndex == startingVertexListIndex {
-			return
-		}
+			}
 
-		vertexListIndex = startingVertexListIndex
+			cameraPosition := camera.WorldPosition()
 
-		mpColor := model.Color.Clone()
+			// viewVector returns the vector pointing from the given world position to the camera; for orthographic cameras, it's the same
+			// for every position.
+			viewVector := func(position vector.Vector) vector.Vector {
+				if camera.Perspective {
+					return cameraPosition.Sub(position)
+				}
+				return camera.cameraForward.Invert()
+			}
 
-		if meshPart.Material != nil {
-			mpColor.MultiplyRGBA(meshPart.Material.Color.ToFloat32s())
-		}
+			var normalMapW, normalMapH float64
+			var normalMapRotation Matrix4
 
-		for _, tri := range meshPart.sortingTriangles {
+			if normalMapped {
+				normalMapW = float64(mat.NormalMap.Bounds().Dx())
+				normalMapH = float64(mat.NormalMap.Bounds().Dy())
+				normalMapRotation = model.WorldRotation()
+			}
 
-			if !tri.rendered {
-				continue
+			mpColor := model.Color.Clone()
+
+			if meshPart.Material != nil {
+				mpColor.MultiplyRGBA(model.materialColor(meshPart).ToFloat32s())
 			}
 
-			for i := 0; i < 3; i++ {
+			toonShaded := lighting && mat != nil && mat.toonShaded()
 
-				vertIndex := tri.ID*3 + i
+			var emissionR, emissionG, emissionB float32
+			emissive := false
 
-				// We set the UVs back here because we might need to use them if the material has clip alpha enabled.
-				u := float32(mesh.VertexUVs[vertIndex][0] * srcW)
-				// We do 1 - v here (aka Y in texture coordinates) because 1.0 is the top of the texture while 0 is the bottom in UV coordinates,
-				// but when drawing textures 0 is the top, and the sourceHeight is the bottom.
-				v := float32((1 - mesh.VertexUVs[vertIndex][1]) * srcH)
+			if lighting && mat != nil {
+				emissionR, emissionG, emissionB, emissive = mat.emission()
+			}
+
+			for _, tri := range camera.sortingTriangles[first:next] {
+
+				if !tri.rendered {
+					continue
+				}
 
-				colorVertexList[vertexListIndex+i].SrcX = u
-				colorVertexList[vertexListIndex+i].SrcY = v
+				// For triplanar mapping, the texture is projected along the world axis the triangle faces the most.
+				var triplanarPositions [3]vector.Vector
+				var triplanarU, triplanarV int
 
-				// Vertex colors
+				if triplanar {
 
-				if activeChannel := mesh.VertexActiveColorChannel[vertIndex]; activeChannel >= 0 {
-					colorVertexList[vertexListIndex+i].ColorR = mesh.VertexColors[vertIndex][activeChannel].R * mpColor.R
-					colorVertexList[vertexListIndex+i].ColorG = mesh.VertexColors[vertIndex][activeChannel].G * mpColor.G
-					colorVertexList[vertexListIndex+i].ColorB = mesh.VertexColors[vertIndex][activeChannel].B * mpColor.B
-					colorVertexList[vertexListIndex+i].ColorA = mesh.VertexColors[vertIndex][activeChannel].A * mpColor.A
-				} else {
-					colorVertexList[vertexListIndex+i].ColorR = mpColor.R
-					colorVertexList[vertexListIndex+i].ColorG = mpColor.G
-					colorVertexList[vertexListIndex+i].ColorB = mpColor.B
-					colorVertexList[vertexListIndex+i].ColorA = mpColor.A
-				}
-
-				if camera.RenderDepth {
-
-					// We're adding 0.03 for a margin because for whatever reason, at close range / wide FOV,
-					// depth can be negative but still be in front of the camera and not behind it.
-					margin := 1.0
-					depth := mesh.vertexTransforms[vertIndex][2] / (far + margin)
-					if depth < 0 {
-						depth = 0
-					} else if depth > 1 {
-						depth = 1
+					for i := range triplanarPositions {
+						triplanarPositions[i], _ = worldVertex(tri, i)
 					}
 
-					depthVertexList[vertexListIndex+i].ColorR = float32(depth)
-					depthVertexList[vertexListIndex+i].ColorG = float32(depth)
-					depthVertexList[vertexListIndex+i].ColorB = float32(depth)
-					depthVertexList[vertexListIndex+i].ColorA = 1
+					faceNormal := calculateNormal(triplanarPositions[0], triplanarPositions[1], triplanarPositions[2])
+					x, y, z := math.Abs(faceNormal[0]), math.Abs(faceNormal[1]), math.Abs(faceNormal[2])
 
-					// We set the UVs back here because we might need to use them if the material has clip alpha enabled.
-					depthVertexList[vertexListIndex+i].SrcX = u
+					if x >= y && x >= z {
+						triplanarU, triplanarV = 2, 1
+					} else if y >= z {
+						triplanarU, triplanarV = 0, 2
+					} else {
+						triplanarU, triplanarV = 0, 1
+					}
+
+				}
+
+				for i := 0; i < 3; i++ {
+
+					vertIndex := tri.ID*3 + i
 
+					// We set the UVs back here because we might need to use them if the material has clip alpha enabled.
+					var uvU, uvV float64
+					if matcapped {
+						_, normal := worldVertex(tri, i)
+						uvU, uvV = matcapUV(matcapRotation.MultVec(normal))
+					} else if triplanar {
+						position := triplanarPositions[i]
+						uvU, uvV = uvTransform.apply(position[triplanarU]/mat.TriplanarScale, position[triplanarV]/mat.TriplanarScale)
+					} else {
+						uvU, uvV = uvTransform.apply(textureUVs[vertIndex][0], textureUVs[vertIndex][1])
+					}
+					u := float32(uvU * srcW)
 					// We do 1 - v here (aka Y in texture coordinates) because 1.0 is the top of the texture while 0 is the bottom in UV coordinates,
 					// but when drawing textures 0 is the top, and the sourceHeight is the bottom.
-					depthVertexList[vertexListIndex+i].SrcY = v
+					v := float32((1 - uvV) * srcH)
+
+					if reflection != nil {
+						u, v = 0, 0
+						if distorted {
+							position, _ := worldVertex(tri, i)
+							offsetU, offsetV := reflection.distortionOffset(position)
+							u, v = float32(offsetU*srcW), float32(offsetV*srcH)
+						}
+					}
+
+					colorVertexList[vertexListIndex+i].SrcX = u
+					colorVertexList[vertexListIndex+i].SrcY = v
+
+					// Vertex colors
+
+					if activeChannel := mesh.VertexActiveColorChannel[vertIndex]; activeChannel >= 0 {
+						colorVertexList[vertexListIndex+i].ColorR = mesh.VertexColors[vertIndex][activeChannel].R * mpColor.R
+						colorVertexList[vertexListIndex+i].ColorG = mesh.VertexColors[vertIndex][activeChannel].G * mpColor.G
+						colorVertexList[vertexListIndex+i].ColorB = mesh.VertexColors[vertIndex][activeChannel].B * mpColor.B
+						colorVertexList[vertexListIndex+i].ColorA = mesh.VertexColors[vertIndex][activeChannel].A * mpColor.A
+					} else {
+						colorVertexList[vertexListIndex+i].ColorR = mpColor.R
+						colorVertexList[vertexListIndex+i].ColorG = mpColor.G
+						colorVertexList[vertexListIndex+i].ColorB = mpColor.B
+						colorVertexList[vertexListIndex+i].ColorA = mpColor.A
+					}
+
+					if camera.RenderDepth {
+
+						// We're adding 0.03 for a margin because for whatever reason, at close range / wide FOV,
+						// depth can be negative but still be in front of the camera and not behind it.
+						margin := 1.0
+						depth := camera.vertexTransforms[vertIndex][2] / (far + margin)
+						if depth < 0 {
+							depth = 0
+						} else if depth > 1 {
+							depth = 1
+						}
 
-				} else if scene.World != nil && scene.World.FogMode != FogOff {
+						depthVertexList[vertexListIndex+i].ColorR = float32(depth)
+						depthVertexList[vertexListIndex+i].ColorG = float32(depth)
+						depthVertexList[vertexListIndex+i].ColorB = float32(depth)
+						depthVertexList[vertexListIndex+i].ColorA = 1
+
+						// We set the UVs back here because we might need to use them if the material has clip alpha enabled.
+						depthVertexList[vertexListIndex+i].SrcX = u
+
+						// We do 1 - v here (aka Y in texture coordinates) because 1.0 is the top of the texture while 0 is the bottom in UV coordinates,
+						// but when drawing textures 0 is the top, and the sourceHeight is the bottom.
+						depthVertexList[vertexListIndex+i].SrcY = v
+
+					} else if scene.World != nil && scene.World.FogMode != FogOff {
+
+						// We're adding 0.03 for a margin because for whatever reason, at close range / wide FOV,
+						// depth can be negative but still be in front of the camera and not behind it.
+						depth := float32((camera.vertexTransforms[vertIndex][2]+near)/far + 0.03)
+						if depth < 0 {
+							depth = 0
+						} else if depth > 1 {
+							depth = 1
+						}
+
+						// depth = 1 - depth
+
+						depth = scene.World.FogRange[0] + ((scene.World.FogRange[1]-scene.World.FogRange[0])*1 - depth)
+
+						if scene.World.FogMode == FogAdd {
+							colorVertexList[vertexListIndex+i].ColorR += scene.World.FogColor.R * depth
+							colorVertexList[vertexListIndex+i].ColorG += scene.World.FogColor.G * depth
+							colorVertexList[vertexListIndex+i].ColorB += scene.World.FogColor.B * depth
+						} else if scene.World.FogMode == FogMultiply {
+							colorVertexList[vertexListIndex+i].ColorR *= scene.World.FogColor.R * depth
+							colorVertexList[vertexListIndex+i].ColorG *= scene.World.FogColor.G * depth
+							colorVertexList[vertexListIndex+i].ColorB *= scene.World.FogColor.B * depth
+						}
 
-					// We're adding 0.03 for a margin because for whatever reason, at close range / wide FOV,
-					// depth can be negative but still be in front of the camera and not behind it.
-					depth := float32((mesh.vertexTransforms[vertIndex][2]+near)/far + 0.03)
-					if depth < 0 {
-						depth = 0
-					} else if depth > 1 {
-						depth = 1
 					}
 
-					// depth = 1 - depth
+				}
+
+				if pixelLit {
+
+					// The unlit colors are kept, and the vertices' world positions and normals are passed to the pixel lighting shader.
+					copy(pixelUnlitVertexList[vertexListIndex:vertexListIndex+3], colorVertexList[vertexListIndex:vertexListIndex+3])
 
-					depth = scene.World.FogRange[0] + ((scene.World.FogRange[1]-scene.World.FogRange[0])*1 - depth)
+					for i := 0; i < 3; i++ {
+
+						position, normal := worldVertex(tri, i)
+
+						if normalMapped {
+							vertIndex := tri.ID*3 + i
+							tangent := mesh.VertexTangents[vertIndex]
+							worldTangent := normalMapRotation.MultVec(tangent)
+							uvU, uvV := uvTransform.apply(mesh.VertexUVs[vertIndex][0], mesh.VertexUVs[vertIndex][1])
+							srcX := float32(uvU * normalMapW)
+							srcY := float32((1 - uvV) * normalMapH)
+							setNormalMapVertices(vertexListIndex+i, colorVertexList[vertexListIndex+i], srcX, srcY, position, normal, append(worldTangent, tangent[3]))
+						} else {
+							setPixelLightVertex(&pixelLightVertexList[vertexListIndex+i], colorVertexList[vertexListIndex+i], position, normal)
+						}
 
-					if scene.World.FogMode == FogAdd {
-						colorVertexList[vertexListIndex+i].ColorR += scene.World.FogColor.R * depth
-						colorVertexList[vertexListIndex+i].ColorG += scene.World.FogColor.G * depth
-						colorVertexList[vertexListIndex+i].ColorB += scene.World.FogColor.B * depth
-					} else if scene.World.FogMode == FogMultiply {
-						colorVertexList[vertexListIndex+i].ColorR *= scene.World.FogColor.R * depth
-						colorVertexList[vertexListIndex+i].ColorG *= scene.World.FogColor.G * depth
-						colorVertexList[vertexListIndex+i].ColorB *= scene.World.FogColor.B * depth
 					}
 
 				}
 
-			}
+				if lightmapped {
 
-			if lighting {
+					// The lightmap is drawn using the Mesh's second set of UVs.
+					for i := 0; i < 3; i++ {
+						uv2 := mesh.VertexUV2s[tri.ID*3+i]
+						setLightmapVertex(&pixelLightVertexList[vertexListIndex+i], colorVertexList[vertexListIndex+i], uv2, lightmapW, lightmapH)
+					}
 
-				t := time.Now()
+				}
 
-				addLightResults := [9]float32{}
+				if lighting {
 
-				for _, light := range lights {
+					t := time.Now()
+
+					addLightResults := [9]float32{}
+
+					// Triangles seen from behind are lit as though they were facing the other way
+					facing := 1.0
+					if tri.backfacing {
+						facing = -1
+					}
+
+					for _, light := range lights {
+
+						lightResults := light.lightFacing(tri.ID, model, facing)
+						for i := 0; i < 9; i++ {
+							addLightResults[i] += lightResults[i]
+						}
+					}
+
+					// Materials lit per pixel are toon shaded by the pixel lighting shader instead.
+					if toonShaded && !pixelLit {
+						for i := 0; i < 3; i++ {
+							addLightResults[i*3], addLightResults[i*3+1], addLightResults[i*3+2] = mat.toonShade(addLightResults[i*3], addLightResults[i*3+1], addLightResults[i*3+2])
+						}
+					}
+
+					for i := 0; i < 3; i++ {
+						colorVertexList[vertexListIndex+i].ColorR *= addLightResults[i*3]
+						colorVertexList[vertexListIndex+i].ColorG *= addLightResults[i*3+1]
+						colorVertexList[vertexListIndex+i].ColorB *= addLightResults[i*3+2]
+					}
+
+					camera.DebugInfo.lightTime += time.Since(t)
+
+				}
+
+				if specularLit {
+
+					for i := 0; i < 3; i++ {
+
+						position, normal := worldVertex(tri, i)
+						view := viewVector(position)
+
+						var r, g, b float32
+						for s := range specularSources {
+							hr, hg, hb := specularSources[s].highlight(position, normal, view, mat.Shininess)
+							r += hr
+							g += hg
+							b += hb
+						}
+
+						colorVertexList[vertexListIndex+i].ColorR += r * specularR
+						colorVertexList[vertexListIndex+i].ColorG += g * specularG
+						colorVertexList[vertexListIndex+i].ColorB += b * specularB
+
+					}
+
+				}
+
+				if rimLit {
+
+					for i := 0; i < 3; i++ {
+
+						position, normal := worldVertex(tri, i)
+
+						rim := float32(rimFactor(normal, viewVector(position), mat.RimPower))
+
+						colorVertexList[vertexListIndex+i].ColorR += rimR * rim
+						colorVertexList[vertexListIndex+i].ColorG += rimG * rim
+						colorVertexList[vertexListIndex+i].ColorB += rimB * rim
+
+					}
+
+				}
+
+				if emissive {
+					for i := 0; i < 3; i++ {
+						colorVertexList[vertexListIndex+i].ColorR += emissionR
+						colorVertexList[vertexListIndex+i].ColorG += emissionG
+						colorVertexList[vertexListIndex+i].ColorB += emissionB
+					}
+				}
+
+				if renderNormals {
+					for i := 0; i < 3; i++ {
+						_, normal := worldVertex(tri, i)
+						camera.setNormalVertex(vertexListIndex+i, colorVertexList[vertexListIndex+i], normal, viewRotation)
+					}
+				}
+
+				if localFogged {
+					for i := 0; i < 3; i++ {
+						position, _ := worldVertex(tri, i)
+						if camera.RenderDepth {
+							camera.setFogVertex(vertexListIndex+i, colorVertexList[vertexListIndex+i], scene.World, camera.fogVolumes, cameraPosition, position)
+						} else {
+							fogVertexColor(&colorVertexList[vertexListIndex+i], scene.World, camera.fogVolumes, cameraPosition, position)
+						}
+					}
+				}
+
+				if tri.clipIndex < 0 {
+					vertexListIndex += 3
+					continue
+				}
+
+				// The triangle was clipped against the near plane, so the vertex data calculated above is for the triangle's original
+				// corners; we interpolate between them to get the data for the vertices created by clipping.
+
+				clipped := camera.nearClipTriangles[tri.clipIndex]
+
+				colorCorners := [3]ebiten.Vertex{}
+				depthCorners := [3]ebiten.Vertex{}
+				copy(colorCorners[:], colorVertexList[vertexListIndex:vertexListIndex+3])
+				copy(depthCorners[:], depthVertexList[vertexListIndex:vertexListIndex+3])
+
+				pixelUnlitCorners := [3]ebiten.Vertex{}
+				pixelLightCorners := [3]ebiten.Vertex{}
+				if pixelLit || lightmapped {
+					copy(pixelUnlitCorners[:], pixelUnlitVertexList[vertexListIndex:vertexListIndex+3])
+					copy(pixelLightCorners[:], pixelLightVertexList[vertexListIndex:vertexListIndex+3])
+				}
+
+				normalCorners := [3]ebiten.Vertex{}
+				if renderNormals {
+					copy(normalCorners[:], normalVertexList[vertexListIndex:vertexListIndex+3])
+				}
+
+				fogCorners := [3]ebiten.Vertex{}
+				if localFogged {
+					copy(fogCorners[:], fogVertexList[vertexListIndex:vertexListIndex+3])
+				}
+
+				normalMapCorners := [3][3]ebiten.Vertex{}
+				if normalMapped {
+					for c := range normalMapVertexLists {
+						copy(normalMapCorners[c][:], normalMapVertexLists[c][vertexListIndex:vertexListIndex+3])
+					}
+				}
+
+				for i := 0; i < clipped.VertexCount; i++ {
+
+					cv := clipped.Vertices[i]
+
+					blendVertexAttributes(&colorVertexList[vertexListIndex+i], &colorCorners, cv.Weights)
+					blendVertexAttributes(&depthVertexList[vertexListIndex+i], &depthCorners, cv.Weights)
+
+					if pixelLit || lightmapped {
+						blendVertexAttributes(&pixelUnlitVertexList[vertexListIndex+i], &pixelUnlitCorners, cv.Weights)
+						blendVertexAttributes(&pixelLightVertexList[vertexListIndex+i], &pixelLightCorners, cv.Weights)
+						pixelUnlitVertexList[vertexListIndex+i].DstX = colorVertexList[vertexListIndex+i].DstX
+						pixelUnlitVertexList[vertexListIndex+i].DstY = colorVertexList[vertexListIndex+i].DstY
+						pixelLightVertexList[vertexListIndex+i].DstX = colorVertexList[vertexListIndex+i].DstX
+						pixelLightVertexList[vertexListIndex+i].DstY = colorVertexList[vertexListIndex+i].DstY
+					}
+
+					if renderNormals {
+						blendVertexAttributes(&normalVertexList[vertexListIndex+i], &normalCorners, cv.Weights)
+						normalVertexList[vertexListIndex+i].DstX = colorVertexList[vertexListIndex+i].DstX
+						normalVertexList[vertexListIndex+i].DstY = colorVertexList[vertexListIndex+i].DstY
+					}
+
+					if localFogged {
+						blendVertexAttributes(&fogVertexList[vertexListIndex+i], &fogCorners, cv.Weights)
+						fogVertexList[vertexListIndex+i].DstX = colorVertexList[vertexListIndex+i].DstX
+						fogVertexList[vertexListIndex+i].DstY = colorVertexList[vertexListIndex+i].DstY
+					}
+
+					if normalMapped {
+						for c := range normalMapVertexLists {
+							blendVertexAttributes(&normalMapVertexLists[c][vertexListIndex+i], &normalMapCorners[c], cv.Weights)
+							normalMapVertexLists[c][vertexListIndex+i].DstX = colorVertexList[vertexListIndex+i].DstX
+							normalMapVertexLists[c][vertexListIndex+i].DstY = colorVertexList[vertexListIndex+i].DstY
+						}
+						// The pixel light shader reads the normal buffers at the vertex's screen position.
+						pixelLightVertexList[vertexListIndex+i].SrcX = colorVertexList[vertexListIndex+i].DstX
+						pixelLightVertexList[vertexListIndex+i].SrcY = colorVertexList[vertexListIndex+i].DstY
+					}
 
-					if point, ok := light.(*PointLight); ok && point.Distance > 0 {
-						dist := maxSpan + point.Distance
-						if fastVectorDistanceSquared(modelPos, point.WorldPosition()) > dist*dist {
-							continue
+					if camera.RenderDepth {
+						// Depth is clamped for the corners, so we interpolate it from the unclamped values instead.
+						z := 0.0
+						for c, weight := range cv.Weights {
+							z += camera.vertexTransforms[tri.ID*3+c][2] * weight
 						}
-					} else if cube, ok := light.(*CubeLight); ok && cube.Distance > 0 {
-						dist := maxSpan + cube.Distance
-						if fastVectorDistanceSquared(modelPos, cube.WorldPosition()) > dist*dist {
-							continue
+						depth := z / (far + 1)
+						if depth < 0 {
+							depth = 0
+						} else if depth > 1 {
+							depth = 1
 						}
+						depthVertexList[vertexListIndex+i].ColorR = float32(depth)
+						depthVertexList[vertexListIndex+i].ColorG = float32(depth)
+						depthVertexList[vertexListIndex+i].ColorB = float32(depth)
 					}
 
-					lightResults := light.Light(tri.ID, model)
-					for i := 0; i < 9; i++ {
-						addLightResults[i] += lightResults[i]
-					}
 				}
 
-				for i := 0; i < 3; i++ {
-					colorVertexList[vertexListIndex+i].ColorR *= addLightResults[i*3]
-					colorVertexList[vertexListIndex+i].ColorG *= addLightResults[i*3+1]
-					colorVertexList[vertexListIndex+i].ColorB *= addLightResults[i*3+2]
-				}
+				vertexListIndex += clipped.VertexCount
 
-				camera.DebugInfo.lightTime += time.Since(t)
+			}
 
+			if perspectiveCorrected {
+				vertexListIndex = camera.correctPerspective(startingVertexListIndex, vertexListIndex, pixelLit || lightmapped, renderNormals, normalMapped, localFogged && camera.RenderDepth)
 			}
 
-			vertexListIndex += 3
+			if reflection != nil {
+				camera.mapReflection(startingVertexListIndex, vertexListIndex, srcW, srcH)
+			}
 
-		}
+			for i := 0; i < vertexListIndex; i++ {
+				indexList[i] = uint16(i)
+			}
+
+			if next < len(camera.sortingTriangles) {
+				flushChunk()
+			}
+
+			first = next
 
-		for i := 0; i < vertexListIndex; i++ {
-			indexList[i] = uint16(i)
 		}
 
 	}
 
-	flush := func(rp renderPair) {
+	flush = func(rp renderPair) {
+
+		pixelLit := flushPixelLit
+		lightmap := flushLightmap
+		normalMap := flushNormalMap
+		flushPixelLit = false
+		flushLightmap = nil
+		flushNormalMap = nil
 
 		if vertexListIndex == 0 {
 			return
@@ -1114,16 +2680,25 @@ func (camera *Camera) Render(scene *Scene, models ...*Model) {
 		meshPart := rp.MeshPart
 		mat := meshPart.Material
 
+		if meshPart.OnRender != nil {
+			meshPart.OnRender(camera, model, meshPart, RenderStageBefore, colorVertexList[:vertexListIndex])
+		}
+
 		var img *ebiten.Image
 
 		if mat != nil {
-			img = mat.Texture
+			img = model.materialTexture(meshPart)
 		}
 
 		if img == nil {
 			img = defaultImg
 		}
 
+		// Dithered materials that are too transparent are rendered as regular transparent materials.
+		dithered := mat != nil && mat.TransparencyMode == TransparencyModeDithered && !model.isTransparent(meshPart)
+
+		outlined := mat != nil && mat.OutlineThickness > 0 && mat.OutlineColor != nil
+
 		// Render the depth map here
 		if camera.RenderDepth {
 
@@ -1153,7 +2728,27 @@ func (camera *Camera) Render(scene *Scene, models ...*Model) {
 
 			camera.depthIntermediate.Clear()
 
-			if transparencyMode == TransparencyModeAlphaClip {
+			// The outline is drawn to the depth buffer first, so that it's hidden by anything in front of it, but doesn't hide the triangles it outlines.
+			if outlined {
+				camera.drawOutline(depthVertexList[:vertexListIndex], mat.OutlineThickness, nil, func(vertices []ebiten.Vertex) {
+					camera.depthIntermediate.DrawTrianglesShader(vertices, indexList[:len(vertices)], camera.depthShader, &ebiten.DrawTrianglesShaderOptions{
+						Images: [4]*ebiten.Image{camera.resultDepthTexture},
+					})
+				})
+			}
+
+			if dithered {
+
+				for i := 0; i < vertexListIndex; i++ {
+					depthVertexList[i].ColorA = colorVertexList[i].ColorA
+				}
+
+				camera.depthIntermediate.DrawTrianglesShader(depthVertexList[:vertexListIndex], indexList[:vertexListIndex], camera.ditheredDepthShader, &ebiten.DrawTrianglesShaderOptions{
+					Images:   [4]*ebiten.Image{camera.resultDepthTexture},
+					Uniforms: map[string]interface{}{"BayerMatrix": bayerMatrix},
+				})
+
+			} else if transparencyMode == TransparencyModeAlphaClip {
 
 				camera.clipAlphaIntermediate.Clear()
 
@@ -1177,6 +2772,15 @@ func (camera *Camera) Render(scene *Scene, models ...*Model) {
 
 		}
 
+		if camera.RenderNormals && !model.isTransparent(meshPart) {
+			camera.drawNormals()
+		}
+
+		if camera.RenderModelIDs && camera.RenderDepth {
+			camera.prepareModelIDRendering()
+			camera.drawModelID(model)
+		}
+
 		t := &ebiten.DrawTrianglesOptions{}
 		if model.ColorBlendingFunc != nil {
 			t.ColorM = model.ColorBlendingFunc(model, meshPart) // Modify the model's appearance using its color blending function
@@ -1187,6 +2791,12 @@ func (camera *Camera) Render(scene *Scene, models ...*Model) {
 		}
 
 		hasFragShader := mat != nil && mat.fragmentShader != nil && mat.FragmentShaderOn
+
+		var fragmentShaderOptions *ebiten.DrawTrianglesShaderOptions
+		if hasFragShader {
+			fragmentShaderOptions = model.fragmentShaderOptions(meshPart)
+		}
+
 		w, h := camera.resultColorTexture.Size()
 
 		// If rendering depth, and rendering through a custom fragment shader, we'll need to render the tris to the ColorIntermediate buffer using the custom shader.
@@ -1205,12 +2815,30 @@ func (camera *Camera) Render(scene *Scene, models ...*Model) {
 				rectShaderOptions.CompositeMode = mat.CompositeMode
 			}
 
-			if hasFragShader {
-				camera.colorIntermediate.DrawTrianglesShader(colorVertexList[:vertexListIndex], indexList[:vertexListIndex], mat.fragmentShader, mat.FragmentShaderOptions)
+			if outlined {
+				camera.drawOutline(colorVertexList[:vertexListIndex], mat.OutlineThickness, mat.OutlineColor, func(vertices []ebiten.Vertex) {
+					camera.colorIntermediate.DrawTriangles(vertices, indexList[:len(vertices)], defaultImg, nil)
+				})
+			}
+
+			if hasFragShader && mat.FragmentShaderGBuffer {
+				camera.drawGBufferShader(camera.colorIntermediate, img, t, mat.fragmentShader, fragmentShaderOptions)
+			} else if hasFragShader {
+				camera.colorIntermediate.DrawTrianglesShader(colorVertexList[:vertexListIndex], indexList[:vertexListIndex], mat.fragmentShader, fragmentShaderOptions)
+			} else if dithered {
+				camera.drawDithered(camera.colorIntermediate, img, t, mat.CompositeMode)
+			} else if pixelLit {
+				camera.drawPixelLit(camera.colorIntermediate, img, normalMap, t, ebiten.CompositeModeSourceOver)
+			} else if lightmap != nil {
+				camera.drawLightmapped(camera.colorIntermediate, img, lightmap, t, ebiten.CompositeModeSourceOver)
 			} else {
 				camera.colorIntermediate.DrawTriangles(colorVertexList[:vertexListIndex], indexList[:vertexListIndex], img, t)
 			}
 
+			if localFogged {
+				camera.drawLocalFog()
+			}
+
 			camera.resultColorTexture.DrawRectShader(w, h, camera.colorShader, rectShaderOptions)
 
 		} else {
@@ -1219,8 +2847,22 @@ func (camera *Camera) Render(scene *Scene, models ...*Model) {
 				t.CompositeMode = mat.CompositeMode
 			}
 
-			if hasFragShader {
-				camera.resultColorTexture.DrawTrianglesShader(colorVertexList[:vertexListIndex], indexList[:vertexListIndex], mat.fragmentShader, mat.FragmentShaderOptions)
+			if outlined {
+				camera.drawOutline(colorVertexList[:vertexListIndex], mat.OutlineThickness, mat.OutlineColor, func(vertices []ebiten.Vertex) {
+					camera.resultColorTexture.DrawTriangles(vertices, indexList[:len(vertices)], defaultImg, nil)
+				})
+			}
+
+			if hasFragShader && mat.FragmentShaderGBuffer {
+				camera.drawGBufferShader(camera.resultColorTexture, img, t, mat.fragmentShader, fragmentShaderOptions)
+			} else if hasFragShader {
+				camera.resultColorTexture.DrawTrianglesShader(colorVertexList[:vertexListIndex], indexList[:vertexListIndex], mat.fragmentShader, fragmentShaderOptions)
+			} else if dithered {
+				camera.drawDithered(camera.resultColorTexture, img, t, t.CompositeMode)
+			} else if pixelLit {
+				camera.drawPixelLit(camera.resultColorTexture, img, normalMap, t, t.CompositeMode)
+			} else if lightmap != nil {
+				camera.drawLightmapped(camera.resultColorTexture, img, lightmap, t, t.CompositeMode)
 			} else {
 				camera.resultColorTexture.DrawTriangles(colorVertexList[:vertexListIndex], indexList[:vertexListIndex], img, t)
 			}
@@ -1230,6 +2872,10 @@ func (camera *Camera) Render(scene *Scene, models ...*Model) {
 		camera.DebugInfo.DrawnTris += vertexListIndex / 3
 		camera.DebugInfo.DrawnParts++
 
+		if meshPart.OnRender != nil {
+			meshPart.OnRender(camera, model, meshPart, RenderStageAfter, colorVertexList[:vertexListIndex])
+		}
+
 		vertexListIndex = 0
 
 	}
@@ -1250,18 +2896,18 @@ func (camera *Camera) Render(scene *Scene, models ...*Model) {
 
 			for _, merged := range modelSlice {
 
-				if !merged.visible {
+				if !merged.visible || !camera.LayerMask.Has(merged.Layers) {
 					continue
 				}
 
 				for _, part := range merged.Mesh.MeshParts {
-					render(renderPair{Model: merged, MeshPart: part})
+					render(renderPair{Model: merged, MeshPart: part}, pair)
 				}
 			}
 
 			flush(pair)
 		} else {
-			render(pair)
+			render(pair, pair)
 			flush(pair)
 		}
 
@@ -1270,6 +2916,9 @@ func (camera *Camera) Render(scene *Scene, models ...*Model) {
 	if len(transparents) > 0 {
 
 		sort.SliceStable(transparents, func(i, j int) bool {
+			if biasI, biasJ := transparents[i].sortBias(), transparents[j].sortBias(); biasI != biasJ {
+				return biasI < biasJ
+			}
 			return depths[transparents[i].Model] > depths[transparents[j].Model]
 		})
 
@@ -1285,18 +2934,18 @@ func (camera *Camera) Render(scene *Scene, models ...*Model) {
 
 				for _, merged := range modelSlice {
 
-					if !merged.visible {
+					if !merged.visible || !camera.LayerMask.Has(merged.Layers) {
 						continue
 					}
 
 					for _, part := range merged.Mesh.MeshParts {
-						render(renderPair{Model: merged, MeshPart: part})
+						render(renderPair{Model: merged, MeshPart: part}, pair)
 					}
 				}
 
 				flush(pair)
 			} else {
-				render(pair)
+				render(pair, pair)
 				flush(pair)
 			}
 
@@ -1304,12 +2953,345 @@ func (camera *Camera) Render(scene *Scene, models ...*Model) {
 
 	}
 
-	camera.DebugInfo.frameTime += time.Since(frametimeStart)
+	camera.drawRenderTarget()
+
+	frameTime := time.Since(frametimeStart)
+	camera.DebugInfo.frameTime += frameTime
+	camera.DebugInfo.renderTime += frameTime
 
 	camera.DebugInfo.frameCount++
 
 }
 
+// drawDithered renders the triangles in the color vertex list to the target image, discarding pixels in a Bayer pattern according to
+// their vertices' alpha. The triangles are first rendered opaque (using the given texture and options, so that the Material's texture
+// wrapping and filtering modes and the Model's color blending function apply) to a buffer, which is then drawn to the target through
+// the dithering shader.
+func (camera *Camera) drawDithered(target *ebiten.Image, img *ebiten.Image, options *ebiten.DrawTrianglesOptions, compositeMode ebiten.CompositeMode) {
+
+	camera.prepareGBuffer()
+
+	for i := 0; i < vertexListIndex; i++ {
+		gBufferVertexList[i] = colorVertexList[i]
+		gBufferVertexList[i].ColorA = 1
+	}
+
+	intermediateOptions := *options
+	intermediateOptions.CompositeMode = ebiten.CompositeModeSourceOver
+
+	camera.gBufferColorIntermediate.Clear()
+	camera.gBufferColorIntermediate.DrawTriangles(gBufferVertexList[:vertexListIndex], indexList[:vertexListIndex], img, &intermediateOptions)
+
+	// The buffer is read at the vertices' screen positions, and the vertices' alpha is used for dithering.
+	for i := 0; i < vertexListIndex; i++ {
+		gBufferVertexList[i].SrcX = gBufferVertexList[i].DstX
+		gBufferVertexList[i].SrcY = gBufferVertexList[i].DstY
+		gBufferVertexList[i].ColorA = colorVertexList[i].ColorA
+	}
+
+	target.DrawTrianglesShader(gBufferVertexList[:vertexListIndex], indexList[:vertexListIndex], camera.ditheredColorShader, &ebiten.DrawTrianglesShaderOptions{
+		Images:        [4]*ebiten.Image{camera.gBufferColorIntermediate},
+		Uniforms:      map[string]interface{}{"BayerMatrix": bayerMatrix},
+		CompositeMode: compositeMode,
+	})
+
+}
+
+// prepareRenderBuffers makes sure the Camera's vertex transform buffer is large enough to hold the vertices of the given MeshPart's
+// Mesh, and fills its triangle buffer with the MeshPart's triangles.
+func (camera *Camera) prepareRenderBuffers(meshPart *MeshPart) {
+
+	if vertexCount := meshPart.Mesh.VertexCount; len(camera.vertexTransforms) < vertexCount {
+		buffer := make([]float64, vertexCount*4)
+		camera.vertexTransforms = make([]vector.Vector, vertexCount)
+		for i := range camera.vertexTransforms {
+			camera.vertexTransforms[i] = buffer[i*4 : i*4+4 : i*4+4]
+		}
+	}
+
+	camera.sortingTriangles = append(camera.sortingTriangles[:0], meshPart.sortingTriangles...)
+
+}
+
+// clipTriangle clips the triangle formed by the clip-space vertices v0, v1, and v2 against the near plane (if nearClip is true) and
+// the Camera's clip plane (if it has one), storing the resulting polygon's vertices in clipped and their clip-space positions in
+// camera.nearClipPoints. It returns the number of vertices in the polygon, which is less than 3 if the triangle is clipped away
+// entirely, and at most 5.
+func (camera *Camera) clipTriangle(v0, v1, v2 vector.Vector, nearClip bool, near float64, clipped *nearClipTriangle) int {
+
+	polygon := clipPolygon{Count: 3}
+
+	for i, corner := range [3]vector.Vector{v0, v1, v2} {
+		copy(polygon.Points[i][:], corner)
+		polygon.Weights[i][i] = 1
+	}
+
+	if nearClip {
+		polygon.clip([5]float64{0, 0, 0, 1, -near})
+	}
+
+	if camera.clipPlane != nil {
+		polygon.clip(camera.clipPlaneCoefficients)
+	}
+
+	for i := 0; i < polygon.Count; i++ {
+		clipped.Vertices[i] = nearClipVertex{Weights: polygon.Weights[i]}
+		copy(camera.nearClipPoints[i], polygon.Points[i][:])
+	}
+
+	return polygon.Count
+
+}
+
+// updateClipPlane transforms the Camera's clip plane into clip space, using the given view-projection matrix.
+func (camera *Camera) updateClipPlane(vpMatrix Matrix4) {
+
+	if camera.clipPlane == nil {
+		return
+	}
+
+	// A clip-space position is a world-space position multiplied by the view-projection matrix, so multiplying the world-space plane
+	// by the matrix's inverse gives the plane in clip space.
+	inverse := vpMatrix.Inverted()
+
+	for i := 0; i < 4; i++ {
+		camera.clipPlaneCoefficients[i] = 0
+		for j := 0; j < 4; j++ {
+			camera.clipPlaneCoefficients[i] += inverse[i][j] * camera.clipPlane[j]
+		}
+	}
+
+	camera.clipPlaneCoefficients[4] = 0
+
+}
+
+// clipPlaneDistance returns the (scaled) distance of the given clip-space vertex from the Camera's clip plane; it's negative for
+// vertices on the side that's clipped away.
+func (camera *Camera) clipPlaneDistance(v vector.Vector) float64 {
+	c := camera.clipPlaneCoefficients
+	return v[0]*c[0] + v[1]*c[1] + v[2]*c[2] + v[3]*c[3] + c[4]
+}
+
+// fanIndex returns the index of the corner of a clipped polygon that the vertex at the given index of its triangulation (as a fan of
+// triangles: (0, 1, 2), (0, 2, 3), and so on) uses.
+func fanIndex(vertex int) int {
+	if vertex%3 == 0 {
+		return 0
+	}
+	return vertex/3 + vertex%3
+}
+
+// screenPointsOutside returns if all of the given screen positions lie to one side of a view of the given size.
+func screenPointsOutside(points []vector.Vector, width, height float64) bool {
+
+	left, top, right, bottom := true, true, true, true
+
+	for _, p := range points {
+		left = left && p[0] < 0
+		top = top && p[1] < 0
+		right = right && p[0] > width
+		bottom = bottom && p[1] > height
+	}
+
+	return left || top || right || bottom
+
+}
+
+// blendVertexAttributes sets the texture coordinates and color of the dst Vertex to be the values of the given corners blended
+// together using the given weights, leaving its destination position alone.
+func blendVertexAttributes(dst *ebiten.Vertex, corners *[3]ebiten.Vertex, weights [3]float64) {
+	w0, w1, w2 := float32(weights[0]), float32(weights[1]), float32(weights[2])
+	dst.SrcX = corners[0].SrcX*w0 + corners[1].SrcX*w1 + corners[2].SrcX*w2
+	dst.SrcY = corners[0].SrcY*w0 + corners[1].SrcY*w1 + corners[2].SrcY*w2
+	dst.ColorR = corners[0].ColorR*w0 + corners[1].ColorR*w1 + corners[2].ColorR*w2
+	dst.ColorG = corners[0].ColorG*w0 + corners[1].ColorG*w1 + corners[2].ColorG*w2
+	dst.ColorB = corners[0].ColorB*w0 + corners[1].ColorB*w1 + corners[2].ColorB*w2
+	dst.ColorA = corners[0].ColorA*w0 + corners[1].ColorA*w1 + corners[2].ColorA*w2
+}
+
+// lerpVertexAttributes sets the texture coordinates and color of the dst Vertex to be the values of a and b interpolated by t,
+// leaving its destination position alone.
+func lerpVertexAttributes(dst *ebiten.Vertex, a, b ebiten.Vertex, t float32) {
+	dst.SrcX = a.SrcX + (b.SrcX-a.SrcX)*t
+	dst.SrcY = a.SrcY + (b.SrcY-a.SrcY)*t
+	dst.ColorR = a.ColorR + (b.ColorR-a.ColorR)*t
+	dst.ColorG = a.ColorG + (b.ColorG-a.ColorG)*t
+	dst.ColorB = a.ColorB + (b.ColorB-a.ColorB)*t
+	dst.ColorA = a.ColorA + (b.ColorA-a.ColorA)*t
+}
+
+// RenderPoints renders the provided world positions as screen-aligned quads using the given image, skipping the MeshPart / Triangle
+// machinery used for Models entirely; this is useful for rendering large numbers of simple points, like stars, particles,
+// or debug visualizations. size is the size of each quad in world units (so the quads shrink with distance when the Camera is
+// using a perspective projection). The points are drawn back-to-front and are occluded by whatever has been rendered to the
+// Camera's depth texture (if Camera.RenderDepth is on), but don't write to the depth texture themselves. Lighting and fog aren't
+// applied to the points.
+func (camera *Camera) RenderPoints(positions []vector.Vector, size float64, img *ebiten.Image) {
+
+	if len(positions) == 0 {
+		return
+	}
+
+	camera.postProcessDirty = true
+
+	if img == nil {
+		img = defaultImg
+	}
+
+	vpMatrix := camera.ViewMatrix().Mult(camera.Projection())
+
+	camWidth, camHeight := camera.resultColorTexture.Size()
+	width := float64(camWidth)
+	height := float64(camHeight)
+
+	far := camera.Far
+	if !camera.Perspective {
+		far = 2.0
+	}
+
+	// The projection's Y scale converts a vertical size in view space into clip space, which we then scale to the screen.
+	sizeScale := camera.Projection()[1][1] * height * size / 2
+
+	camera.projectedPoints = camera.projectedPoints[:0]
+
+	for _, pos := range positions {
+
+		x, y, z, w := fastMatrixMultVecW(vpMatrix, pos)
+
+		if !camera.Perspective {
+			w = 1
+		}
+
+		if w <= 0 || z > far {
+			continue
+		}
+
+		halfSize := sizeScale / w
+		sx := (x/w)*width + (width / 2)
+		sy := (y/w*-1)*height + (height / 2)
+
+		if sx+halfSize < 0 || sy+halfSize < 0 || sx-halfSize > width || sy-halfSize > height {
+			continue
+		}
+
+		depth := z / (far + 1)
+		if depth < 0 {
+			depth = 0
+		} else if depth > 1 {
+			depth = 1
+		}
+
+		camera.projectedPoints = append(camera.projectedPoints, projectedPoint{
+			X:        float32(sx),
+			Y:        float32(sy),
+			Depth:    float32(depth),
+			HalfSize: float32(halfSize),
+		})
+
+	}
+
+	points := camera.projectedPoints
+
+	sort.Slice(points, func(i, j int) bool { return points[i].Depth > points[j].Depth })
+
+	srcW := float32(img.Bounds().Dx())
+	srcH := float32(img.Bounds().Dy())
+
+	rectShaderOptions := &ebiten.DrawRectShaderOptions{}
+	rectShaderOptions.Images[0] = camera.colorIntermediate
+	rectShaderOptions.Images[1] = camera.depthIntermediate
+	rectShaderOptions.Uniforms = map[string]interface{}{
+		"Fog":      []float32{0, 0, 0, 0},
+		"FogRange": []float32{0, 1},
+	}
+
+	// Each point takes 4 vertices and 6 indices.
+	maxBatchSize := ebiten.MaxIndicesNum / 6
+
+	for start := 0; start < len(points); start += maxBatchSize {
+
+		end := start + maxBatchSize
+		if end > len(points) {
+			end = len(points)
+		}
+
+		vertexCount := 0
+		indexCount := 0
+
+		for _, point := range points[start:end] {
+
+			corners := [4][4]float32{
+				{point.X - point.HalfSize, point.Y - point.HalfSize, 0, 0},
+				{point.X + point.HalfSize, point.Y - point.HalfSize, srcW, 0},
+				{point.X - point.HalfSize, point.Y + point.HalfSize, 0, srcH},
+				{point.X + point.HalfSize, point.Y + point.HalfSize, srcW, srcH},
+			}
+
+			for i, corner := range corners {
+
+				colorVertexList[vertexCount+i] = ebiten.Vertex{
+					DstX:   corner[0],
+					DstY:   corner[1],
+					SrcX:   corner[2],
+					SrcY:   corner[3],
+					ColorR: 1,
+					ColorG: 1,
+					ColorB: 1,
+					ColorA: 1,
+				}
+
+				depthVertexList[vertexCount+i] = ebiten.Vertex{
+					DstX:   corner[0],
+					DstY:   corner[1],
+					SrcX:   corner[2],
+					SrcY:   corner[3],
+					ColorR: point.Depth,
+					ColorG: point.Depth,
+					ColorB: point.Depth,
+					ColorA: 1,
+				}
+
+			}
+
+			indexList[indexCount] = uint16(vertexCount)
+			indexList[indexCount+1] = uint16(vertexCount + 1)
+			indexList[indexCount+2] = uint16(vertexCount + 2)
+			indexList[indexCount+3] = uint16(vertexCount + 1)
+			indexList[indexCount+4] = uint16(vertexCount + 3)
+			indexList[indexCount+5] = uint16(vertexCount + 2)
+
+			vertexCount += 4
+			indexCount += 6
+
+		}
+
+		if camera.RenderDepth {
+
+			camera.depthIntermediate.Clear()
+			camera.depthIntermediate.DrawTrianglesShader(depthVertexList[:vertexCount], indexList[:indexCount], camera.depthShader, &ebiten.DrawTrianglesShaderOptions{
+				Images: [4]*ebiten.Image{camera.resultDepthTexture},
+			})
+
+			camera.colorIntermediate.Clear()
+			camera.colorIntermediate.DrawTriangles(colorVertexList[:vertexCount], indexList[:indexCount], img, nil)
+
+			camera.resultColorTexture.DrawRectShader(camWidth, camHeight, camera.colorShader, rectShaderOptions)
+
+		} else {
+			camera.resultColorTexture.DrawTriangles(colorVertexList[:vertexCount], indexList[:indexCount], img, nil)
+		}
+
+		camera.DebugInfo.TotalParts++
+		camera.DebugInfo.DrawnParts++
+		camera.DebugInfo.TotalTris += indexCount / 3
+		camera.DebugInfo.DrawnTris += indexCount / 3
+
+	}
+
+	camera.drawRenderTarget()
+
+}
+
 func encodeDepth(depth float64) *Color {
 
 	r := math.Floor(depth*255) / 255
@@ -1411,6 +3393,23 @@ func (camera *Camera) drawCircle(screen *ebiten.Image, position vector.Vector, r
 
 }
 
+// Stats returns statistics about everything the Camera has rendered since Camera.Clear() was last called (so, usually, the current
+// frame), like the number of triangles drawn and culled, or the number of batches of triangles drawn. This is useful for logging or
+// graphing how different batching or merging strategies affect rendering, for example.
+func (camera *Camera) Stats() RenderStats {
+
+	return RenderStats{
+		TrianglesProcessed:  camera.DebugInfo.TotalTris,
+		TrianglesCulled:     camera.DebugInfo.TotalTris - camera.DebugInfo.DrawnTris,
+		BatchesDrawn:        camera.DebugInfo.DrawnParts,
+		VerticesSkinned:     camera.DebugInfo.skinnedVertices,
+		FrustumCulledModels: camera.DebugInfo.frustumCulledModels,
+		AnimationTime:       camera.DebugInfo.renderAnimationTime,
+		FrameTime:           camera.DebugInfo.renderTime,
+	}
+
+}
+
 // DrawDebugRenderInfo draws render debug information (like number of drawn objects, number of drawn triangles, frame time, etc)
 // at the top-left of the provided screen *ebiten.Image, using the textScale and color provided.
 func (camera *Camera) DrawDebugRenderInfo(screen *ebiten.Image, textScale float64, color *Color) {
@@ -1470,11 +3469,11 @@ func (camera *Camera) DrawDebugWireframe(screen *ebiten.Image, rootNode INode, c
 
 				model.ProcessVertices(vpMatrix, camera, meshPart, nil)
 
-				for i := 0; i < len(model.Mesh.vertexTransforms); i += 3 {
+				for _, tri := range camera.sortingTriangles {
 
-					v0 := camera.ClipToScreen(model.Mesh.vertexTransforms[i])
-					v1 := camera.ClipToScreen(model.Mesh.vertexTransforms[i+1])
-					v2 := camera.ClipToScreen(model.Mesh.vertexTransforms[i+2])
+					v0 := camera.ClipToScreen(camera.vertexTransforms[tri.ID*3])
+					v1 := camera.ClipToScreen(camera.vertexTransforms[tri.ID*3+1])
+					v2 := camera.ClipToScreen(camera.vertexTransforms[tri.ID*3+2])
 
 					if (v0[0] < 0 && v1[0] < 0 && v2[0] < 0) ||
 						(v0[1] < 0 && v1[1] < 0 && v2[1] < 0) ||
@@ -1515,6 +3514,17 @@ func (camera *Camera) DrawDebugWireframe(screen *ebiten.Image, rootNode INode, c
 				}
 			}
 
+		} else if graph, isGraph := m.(*PathGraph); isGraph {
+
+			for _, waypoint := range graph.Waypoints() {
+				p1 := camera.WorldToScreen(waypoint.WorldPosition())
+				ebitenutil.DrawCircle(screen, p1[0], p1[1], 8, color.ToRGBA64())
+				for _, link := range waypoint.Links {
+					p2 := camera.WorldToScreen(link.WorldPosition())
+					ebitenutil.DrawLine(screen, p1[0], p1[1], p2[0], p2[1], color.ToRGBA64())
+				}
+			}
+
 		}
 
 	}
@@ -1548,7 +3558,7 @@ func (camera *Camera) DrawDebugDrawOrder(screen *ebiten.Image, rootNode INode, t
 
 				triangles := model.Mesh.Triangles
 
-				for triIndex, sortingTri := range meshPart.sortingTriangles {
+				for triIndex, sortingTri := range camera.sortingTriangles {
 
 					screenPos := camera.WorldToScreen(model.Transform().MultVec(triangles[sortingTri.ID].Center))
 
@@ -1683,9 +3693,111 @@ func (camera *Camera) DebugDrawText(screen *ebiten.Image, txtStr string, posX, p
 
 }
 
-// ColorTexture returns the camera's final result color texture from any previous Render() or RenderNodes() calls.
+// drawRenderTarget draws the Camera's result to its RenderTarget, if it has one, stretching it to fit.
+func (camera *Camera) drawRenderTarget() {
+
+	if camera.RenderTarget == nil {
+		return
+	}
+
+	result := camera.ColorTexture()
+
+	w, h := result.Size()
+	targetW, targetH := camera.RenderTarget.Size()
+
+	opt := &ebiten.DrawImageOptions{Filter: camera.RenderTargetFilter}
+	opt.GeoM.Scale(float64(targetW)/float64(w), float64(targetH)/float64(h))
+
+	camera.RenderTarget.Clear()
+	camera.RenderTarget.DrawImage(result, opt)
+
+}
+
+// ColorTexture returns the camera's final result color texture from any previous Render() or RenderNodes() calls. If
+// Camera.DepthOfFieldEnabled is true, the returned texture has depth of field applied, and if Camera.BloomEnabled is true, it has
+// bloom applied afterwards, followed by FXAA if Camera.FXAAEnabled is true; if the Camera has any active PostEffects, they're applied
+// last.
 func (camera *Camera) ColorTexture() *ebiten.Image {
-	return camera.resultColorTexture
+
+	if !camera.postProcessing() {
+		return camera.resultColorTexture
+	}
+
+	if camera.postProcessDirty || camera.postProcessResult == nil {
+		camera.postProcess()
+	}
+
+	return camera.postProcessResult
+
+}
+
+// postProcessing returns if the Camera has any post-processing to apply to its color texture.
+func (camera *Camera) postProcessing() bool {
+	return camera.depthOfFieldActive() || camera.BloomEnabled || camera.FXAAEnabled || camera.hasActivePostEffects()
+}
+
+// postProcess applies depth of field, bloom, and FXAA (if they're enabled) and then the Camera's active PostEffects to the Camera's
+// color texture, storing the result in Camera.postProcessResult.
+func (camera *Camera) postProcess() {
+
+	camera.postProcessDirty = false
+
+	result := camera.resultColorTexture
+
+	if camera.depthOfFieldActive() {
+		camera.applyDepthOfField(result)
+		result = camera.depthOfFieldTexture
+	}
+
+	if camera.BloomEnabled {
+		camera.applyBloom(result)
+		result = camera.bloomTexture
+	}
+
+	if camera.FXAAEnabled {
+		camera.applyFXAA(result)
+		result = camera.fxaaTexture
+	}
+
+	camera.postProcessResult = camera.applyPostEffects(result)
+
+}
+
+// applyBloom extracts the bright pixels from the given texture, blurs them, and adds them back on top of the texture, storing the
+// result in the Camera's bloom texture.
+func (camera *Camera) applyBloom(source *ebiten.Image) {
+
+	w, h := camera.resultColorTexture.Size()
+
+	camera.bloomIntermediateA.Clear()
+	camera.bloomIntermediateA.DrawRectShader(w, h, camera.bloomExtractShader, &ebiten.DrawRectShaderOptions{
+		Images:   [4]*ebiten.Image{source},
+		Uniforms: map[string]interface{}{"Threshold": float32(camera.BloomThreshold)},
+	})
+
+	// The blur has 4 taps on each side, so the taps are spaced to reach the bloom radius.
+	spacing := float32(camera.BloomRadius / 4)
+
+	camera.bloomIntermediateB.Clear()
+	camera.bloomIntermediateB.DrawRectShader(w, h, camera.bloomBlurShader, &ebiten.DrawRectShaderOptions{
+		Images:   [4]*ebiten.Image{camera.bloomIntermediateA},
+		Uniforms: map[string]interface{}{"Direction": []float32{spacing, 0}},
+	})
+
+	camera.bloomIntermediateA.Clear()
+	camera.bloomIntermediateA.DrawRectShader(w, h, camera.bloomBlurShader, &ebiten.DrawRectShaderOptions{
+		Images:   [4]*ebiten.Image{camera.bloomIntermediateB},
+		Uniforms: map[string]interface{}{"Direction": []float32{0, spacing}},
+	})
+
+	camera.bloomTexture.Clear()
+	camera.bloomTexture.DrawImage(source, nil)
+
+	opt := &ebiten.DrawImageOptions{CompositeMode: ebiten.CompositeModeLighter}
+	strength := camera.BloomStrength
+	opt.ColorM.Scale(strength, strength, strength, strength)
+	camera.bloomTexture.DrawImage(camera.bloomIntermediateA, opt)
+
 }
 
 // DepthTexture returns the camera's final result depth texture from any previous Render() or RenderNodes() calls. If Camera.RenderDepth is set to false,
@@ -1707,8 +3819,8 @@ func (camera *Camera) AccumulationColorTexture() *ebiten.Image {
 }
 
 // DrawDebugBoundsColored will draw shapes approximating the shapes and positions of BoundingObjects underneath the rootNode. The shapes will
-// be drawn in the color provided for each kind of bounding object to the screen image provided. If the passed color is nil, that kind of shape
-// won't be debug-rendered.
+// be drawn in the color provided for each kind of bounding object to the screen image provided (BoundingOBBs are drawn in the AABB color,
+// and BoundingConvexes in the triangles color). If the passed color is nil, that kind of shape won't be debug-rendered.
 func (camera *Camera) DrawDebugBoundsColored(screen *ebiten.Image, rootNode INode, aabbColor, sphereColor, capsuleColor, trianglesColor, trianglesAABBColor, trianglesBroadphaseColor *Color) {
 
 	allModels := append([]INode{rootNode}, rootNode.ChildrenRecursive()...)
@@ -1809,6 +3921,31 @@ func (camera *Camera) DrawDebugBoundsColored(screen *ebiten.Image, rootNode INod
 
 				}
 
+			case *BoundingOBB:
+
+				if aabbColor != nil {
+
+					corners := bounds.Corners()
+					points := make([]vector.Vector, len(corners))
+					for i, corner := range corners {
+						points[i] = camera.WorldToScreen(corner)
+					}
+
+					// Corners are ordered by X, then Y, then Z (so, for example, corner 5 is at +X, -Y, +Z).
+					edges := [][2]int{
+						{0, 1}, {2, 3}, {4, 5}, {6, 7},
+						{0, 2}, {1, 3}, {4, 6}, {5, 7},
+						{0, 4}, {1, 5}, {2, 6}, {3, 7},
+					}
+
+					for _, edge := range edges {
+						start := points[edge[0]]
+						end := points[edge[1]]
+						ebitenutil.DrawLine(screen, start[0], start[1], end[0], end[1], aabbColor.ToRGBA64())
+					}
+
+				}
+
 			case *BoundingTriangles:
 
 				if trianglesBroadphaseColor != nil {
@@ -1874,6 +4011,27 @@ func (camera *Camera) DrawDebugBoundsColored(screen *ebiten.Image, rootNode INod
 					camera.DrawDebugBoundsColored(screen, bounds.BoundingAABB, trianglesAABBColor, nil, nil, nil, nil, nil)
 				}
 
+			case *BoundingConvex:
+
+				if trianglesColor != nil {
+
+					points := bounds.Points()
+					for i, point := range points {
+						points[i] = camera.WorldToScreen(point)
+					}
+
+					triColor := trianglesColor.ToRGBA64()
+
+					for _, face := range bounds.faces {
+						for e := 0; e < 3; e++ {
+							start := points[face[e]]
+							end := points[face[(e+1)%3]]
+							ebitenutil.DrawLine(screen, start[0], start[1], end[0], end[1], triColor)
+						}
+					}
+
+				}
+
 			}
 
 		}
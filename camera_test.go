@@ -0,0 +1,847 @@
+package tetra3d
+
+import (
+	"image/color"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/kvartborg/vector"
+)
+
+// readPixel returns the color of the given image at the given position. Reading pixels back needs Ebiten's game loop to be running,
+// so the calling test is skipped if it isn't; build the tests with the pixeltests tag to run them in the game loop.
+func readPixel(t *testing.T, img *ebiten.Image, x, y int) color.RGBA {
+
+	t.Helper()
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Skipf("skipping, as reading pixels back needs a running game loop: %v", r)
+		}
+	}()
+
+	return color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+
+}
+
+// readPixels returns the colors of all of the pixels of the given image, row by row, in the same way as readPixel().
+func readPixels(t *testing.T, img *ebiten.Image) []color.RGBA {
+
+	t.Helper()
+
+	w, h := img.Size()
+	pixels := make([]color.RGBA, 0, w*h)
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			pixels = append(pixels, readPixel(t, img, x, y))
+		}
+	}
+
+	return pixels
+
+}
+
+// countBlendedPixels returns how many of the given pixels are partially transparent, as happens where solid colors are blended with the
+// background (as when a Model's edges are smoothed or blurred).
+func countBlendedPixels(pixels []color.RGBA) int {
+	count := 0
+	for _, c := range pixels {
+		if c.A > 16 && c.A < 240 {
+			count++
+		}
+	}
+	return count
+}
+
+func TestVertexSnapping(t *testing.T) {
+
+	camera := NewCamera(64, 64)
+	camera.VertexSnapping = true
+	camera.VertexSnappingGridSize = 4
+
+	vert := vector.Vector{0.123, -0.456, 5, 5}
+
+	screen := camera.ClipToScreen(vert)
+
+	for i := 0; i < 2; i++ {
+		if math.Mod(screen[i], 4) != 0 {
+			t.Errorf("expected screen coordinate %d to be snapped to a multiple of 4, got %f", i, screen[i])
+		}
+	}
+
+	camera.VertexSnapping = false
+
+	if unsnapped := camera.ClipToScreen(vert); math.Abs(unsnapped[0]-screen[0]) > 2 || math.Abs(unsnapped[1]-screen[1]) > 2 {
+		t.Errorf("expected snapping to move vertices to the nearest grid point; %v was snapped to %v", unsnapped, screen)
+	}
+
+}
+
+func TestSubpixelCorrection(t *testing.T) {
+
+	for _, c := range []struct{ value, size, expected float64 }{
+		{10.3, 0.25, 10.25},
+		{10.4, 0.25, 10.5},
+		{-3.1, 0.5, -3},
+		{7.77, 0, 7.77}, // A size of 0 or less leaves the value alone
+	} {
+		if snapped := snapToGrid(c.value, c.size); snapped != c.expected {
+			t.Errorf("expected %f to snap to %f with a grid size of %f, got %f", c.value, c.expected, c.size, snapped)
+		}
+	}
+
+	camera := NewCamera(64, 64)
+
+	// Two clip-space positions that should be the same vertex, but differ very slightly due to floating-point error.
+	vert := vector.Vector{0.1234, -0.0567, 0.5, 1}
+	nudged := vector.Vector{0.1234 + 1e-9, -0.0567 - 1e-9, 0.5, 1}
+
+	off, nudgedOff := camera.ClipToScreen(vert), camera.ClipToScreen(nudged)
+
+	// Without correction, screen positions are left exactly as projected, so the two vertices land in slightly different places.
+	if off[0] != 0.1234*64+32 || off[1] != 0.0567*64+32 {
+		t.Fatalf("expected screen positions to be left alone with subpixel correction off, got %v", off)
+	}
+
+	if off[0] == nudgedOff[0] || off[1] == nudgedOff[1] {
+		t.Fatalf("expected the nudged vertex to land in a slightly different place with subpixel correction off")
+	}
+
+	camera.SubpixelCorrection = true
+
+	on, nudgedOn := camera.ClipToScreen(vert), camera.ClipToScreen(nudged)
+
+	for i := 0; i < 2; i++ {
+
+		// The positions snap to the nearest 1/16th of a pixel, keeping their sub-pixel offsets rather than being rounded to whole pixels.
+		if on[i]*16 != math.Round(on[i]*16) || math.Abs(on[i]-off[i]) > 1.0/32 {
+			t.Fatalf("expected screen coordinate %d (%f) to snap to the nearest 1/16th of a pixel, got %f", i, off[i], on[i])
+		}
+
+		if on[i] == math.Round(on[i]) {
+			t.Fatalf("expected screen coordinate %d to keep its sub-pixel offset, got %f", i, on[i])
+		}
+
+		if on[i] != nudgedOn[i] {
+			t.Fatalf("expected the nudged vertex to land in exactly the same place with subpixel correction on, got %v and %v", on, nudgedOn)
+		}
+
+	}
+
+	// A coarser precision snaps to a coarser grid.
+	camera.SubpixelPrecision = 2
+
+	if coarse := camera.ClipToScreen(vert); coarse[0] != 40 || coarse[1] != 35.5 {
+		t.Fatalf("expected screen coordinates to snap to the nearest half pixel, got %v", coarse)
+	}
+
+}
+
+func TestCameraRenderTarget(t *testing.T) {
+
+	scene := NewScene("render target")
+
+	monitor := NewCamera(32, 32)
+	monitor.Move(0, 0, 5)
+	scene.Root.AddChildren(monitor)
+
+	cube := NewModel(NewCube(), "cube")
+	scene.Root.AddChildren(cube)
+
+	target := ebiten.NewImage(16, 16)
+	monitor.RenderTarget = target
+
+	screen := NewModel(NewPlane(), "screen")
+	screen.Mesh.MeshParts[0].Material.Texture = target
+	screen.Move(0, -3, 0)
+
+	viewer := NewCamera(32, 32)
+	viewer.Move(0, 0, 5)
+
+	for i := 0; i < 2; i++ {
+
+		monitor.Clear()
+		monitor.RenderNodes(scene, scene.Root)
+
+		viewer.Clear()
+		viewer.Render(scene, screen)
+
+		// The RenderTarget stays the same image (and so keeps working as a texture) when the Camera's resized.
+		monitor.Resize(64, 48)
+
+	}
+
+	if monitor.RenderTarget != target || screen.Mesh.MeshParts[0].Material.Texture != target {
+		t.Errorf("expected the render target to be kept when resizing the Camera")
+	}
+
+	if clone := monitor.Clone().(*Camera); clone.RenderTarget != nil {
+		t.Errorf("expected cloned Cameras not to share render targets")
+	}
+
+}
+
+func TestCameraUnproject(t *testing.T) {
+
+	camera := NewCamera(160, 90)
+	camera.Move(1, 2, 8)
+	camera.Rotate(0, 1, 0, 0.4)
+	camera.Rotate(1, 0, 0, -0.2)
+
+	points := []vector.Vector{{0, 0, 0}, {1.5, -0.5, 2}, {-3, 1, -4}}
+
+	for _, perspective := range []bool{true, false} {
+
+		if perspective {
+			camera.SetPerspective(60)
+		} else {
+			camera.SetOrthographic(10)
+		}
+
+		forward := camera.WorldRotation().Forward().Invert()
+
+		for _, point := range points {
+
+			screen := camera.WorldToScreen(point)
+			depth := dot(point.Sub(camera.WorldPosition()), forward)
+
+			if unprojected := camera.Unproject(screen, depth); unprojected.Sub(point).Magnitude() > 1e-6 {
+				t.Fatalf("unprojecting %v (perspective: %t) gave %v", point, perspective, unprojected)
+			}
+
+			origin, direction := camera.ScreenToWorldRay(screen[0], screen[1])
+
+			if math.Abs(direction.Magnitude()-1) > 1e-9 || dot(direction, forward) <= 0 {
+				t.Fatalf("ray direction %v should be normalized and facing forward", direction)
+			}
+
+			toPoint := point.Sub(origin)
+			if toPoint.Sub(direction.Scale(dot(toPoint, direction))).Magnitude() > 1e-6 {
+				t.Fatalf("ray through %v (perspective: %t) misses it", point, perspective)
+			}
+
+		}
+
+	}
+
+}
+
+func TestNearClippingFlushesFullVertexLists(t *testing.T) {
+
+	// Each triangle reaches from behind the Camera to in front of it, so clipping turns it into a quad drawn as two triangles.
+	// There are few enough triangles to render in one draw unclipped, but too many once they're clipped.
+	triangleCount := 12000
+
+	mesh := NewMesh("crossing")
+	part := mesh.AddMeshPart(NewMaterial("crossing"))
+	part.Material.BackfaceCulling = false
+
+	verts := make([]VertexInfo, 0, triangleCount*3)
+	for i := 0; i < triangleCount; i++ {
+		verts = append(verts, NewVertex(0, -1, 1, 0, 0), NewVertex(-1, -1, -10, 0, 0), NewVertex(1, -1, -10, 0, 0))
+	}
+	part.AddTriangles(verts...)
+	mesh.UpdateBounds()
+
+	model := NewModel(mesh, "crossing")
+
+	draws := []int{}
+	part.OnRender = func(camera *Camera, model *Model, part *MeshPart, stage int, vertices []ebiten.Vertex) {
+		if stage == RenderStageBefore {
+			draws = append(draws, len(vertices))
+		}
+	}
+
+	camera := NewCamera(64, 64)
+	camera.Clear()
+	camera.Render(NewScene("near clipping"), model)
+
+	total := 0
+	for _, count := range draws {
+		if count > ebiten.MaxIndicesNum {
+			t.Fatalf("expected each draw to fit in the vertex lists, but one had %d vertices", count)
+		}
+		total += count
+	}
+
+	if len(draws) < 2 || total != triangleCount*6 {
+		t.Fatalf("expected the clipped triangles to be drawn across multiple draws with 6 vertices each; got draws of %v vertices", draws)
+	}
+
+	if camera.DebugInfo.DrawnTris != triangleCount*2 {
+		t.Fatalf("expected %d triangles to be drawn, got %d", triangleCount*2, camera.DebugInfo.DrawnTris)
+	}
+
+}
+
+func TestCameraStats(t *testing.T) {
+
+	scene := NewScene("stats")
+
+	camera := NewCamera(64, 64)
+	camera.Move(0, 0, 10)
+	scene.Root.AddChildren(camera)
+
+	mesh := NewCube()
+
+	cubes := []*Model{}
+	for i := 0; i < 3; i++ {
+		cube := NewModel(mesh, "cube")
+		cube.Move(float64(i-1)*3, 0, 0)
+		scene.Root.AddChildren(cube)
+		cubes = append(cubes, cube)
+	}
+
+	// This cube's behind the Camera, so it's culled entirely.
+	behind := NewModel(mesh, "behind")
+	behind.Move(0, 0, 20)
+	scene.Root.AddChildren(behind)
+
+	render := func() RenderStats {
+		camera.Clear()
+		camera.RenderNodes(scene, scene.Root)
+		return camera.Stats()
+	}
+
+	stats := render()
+
+	if stats.BatchesDrawn != 3 || stats.FrustumCulledModels != 1 {
+		t.Fatalf("expected each visible cube to be drawn in its own batch, with the other culled; got %d batches and %d culled Models", stats.BatchesDrawn, stats.FrustumCulledModels)
+	}
+
+	// The cubes' back faces are culled, so fewer than half of their triangles are drawn.
+	if stats.TrianglesProcessed != 48 || stats.TrianglesCulled <= 24 || stats.TrianglesCulled >= 48 {
+		t.Fatalf("expected 48 triangles to be processed and more than half of them culled; got %d processed and %d culled", stats.TrianglesProcessed, stats.TrianglesCulled)
+	}
+
+	batch := NewModel(NewCube(), "batch")
+	scene.Root.AddChildren(batch)
+
+	if err := batch.DynamicBatchAdd(batch.Mesh.MeshParts[0], cubes...); err != nil {
+		t.Fatal(err)
+	}
+
+	batch.Move(0, 0, 20)
+
+	if stats := render(); stats.BatchesDrawn != 1 {
+		t.Fatalf("expected the dynamically batched cubes to be drawn in one batch, got %d", stats.BatchesDrawn)
+	}
+
+	camera.RenderPoints([]vector.Vector{{0, 0, 0}, {1, 0, 0}}, 0.1, nil)
+
+	if stats := camera.Stats(); stats.BatchesDrawn != 2 {
+		t.Fatalf("expected rendering points to draw another batch, got %d batches", stats.BatchesDrawn)
+	}
+
+	camera.Clear()
+
+	if stats := camera.Stats(); stats != (RenderStats{}) {
+		t.Fatalf("expected clearing the Camera to reset its stats; got %+v", stats)
+	}
+
+}
+
+func TestRenderPoints(t *testing.T) {
+
+	camera := NewCamera(64, 64)
+	camera.Clear()
+
+	camera.RenderPoints([]vector.Vector{
+		{0, 0, -5},   // In the center of the screen
+		{2, 0, -10},  // Further away, and so drawn first and smaller
+		{0, 0, 5},    // Behind the Camera
+		{100, 0, -5}, // Off-screen
+	}, 1, nil)
+
+	points := camera.projectedPoints
+
+	if len(points) != 2 || camera.DebugInfo.DrawnTris != 4 {
+		t.Fatalf("expected the 2 points in view to be drawn as 4 triangles; got %d points and %d triangles", len(points), camera.DebugInfo.DrawnTris)
+	}
+
+	near, far := points[1], points[0]
+
+	if far.Depth <= near.Depth || math.Abs(float64(far.HalfSize*2-near.HalfSize)) > 0.01 {
+		t.Fatalf("expected the further point to be drawn first at half the size of the nearer one; got %+v", points)
+	}
+
+	if near.X != 32 || near.Y != 32 || far.X <= 32 {
+		t.Fatalf("expected the nearer point to be drawn in the center, with the further point to the right; got %+v", points)
+	}
+
+	// A 1 unit quad at 5 units away with the default 60 degree field of view takes up about a sixth of the screen.
+	if expected := 64 / (2 * 5 * math.Tan(math.Pi/6)) / 2; math.Abs(float64(near.HalfSize)-expected) > 0.01 {
+		t.Fatalf("expected the nearer point to be drawn %f pixels wide, got %f", expected*2, near.HalfSize*2)
+	}
+
+	center := readPixel(t, camera.ColorTexture(), 32, 32)
+	corner := readPixel(t, camera.ColorTexture(), 2, 2)
+
+	if center != (color.RGBA{255, 255, 255, 255}) || corner.A != 0 {
+		t.Fatalf("expected the center of the screen to be covered by a white point and the corner to be empty; got %v and %v", center, corner)
+	}
+
+}
+
+func TestRenderPointsBatches(t *testing.T) {
+
+	maxBatchSize := ebiten.MaxIndicesNum / 6
+
+	positions := make([]vector.Vector, maxBatchSize+10)
+	for i := range positions {
+		positions[i] = vector.Vector{0, 0, -float64(i)/1000 - 5}
+	}
+
+	camera := NewCamera(64, 64)
+	camera.Clear()
+	camera.RenderPoints(positions, 0.1, nil)
+
+	if stats := camera.Stats(); stats.BatchesDrawn != 2 || stats.TrianglesProcessed != len(positions)*2 || stats.TrianglesCulled != 0 {
+		t.Fatalf("expected the points to be drawn in 2 batches of up to %d; got %+v", maxBatchSize, stats)
+	}
+
+}
+
+func TestMeshPartOnRender(t *testing.T) {
+
+	scene := NewScene("on render")
+
+	camera := NewCamera(64, 64)
+	camera.Move(0, 0, 5)
+
+	cube := NewModel(NewCube(), "cube")
+	cube.Mesh.MeshParts[0].Material.Shadeless = true
+	scene.Root.AddChildren(cube)
+
+	type call struct {
+		Model       *Model
+		Stage       int
+		VertexCount int
+	}
+
+	calls := []call{}
+
+	cube.Mesh.MeshParts[0].OnRender = func(camera *Camera, model *Model, part *MeshPart, stage int, vertices []ebiten.Vertex) {
+
+		calls = append(calls, call{model, stage, len(vertices)})
+
+		// Changes made before the draw alter what's drawn.
+		if stage == RenderStageBefore {
+			for i := range vertices {
+				vertices[i].ColorG = 0
+				vertices[i].ColorB = 0
+			}
+		}
+
+	}
+
+	render := func() {
+		calls = calls[:0]
+		camera.Clear()
+		camera.RenderNodes(scene, scene.Root)
+	}
+
+	render()
+
+	// Only the cube's front face is visible, so only its 2 triangles are drawn.
+	if len(calls) != 2 || calls[0] != (call{cube, RenderStageBefore, 6}) || calls[1] != (call{cube, RenderStageAfter, 6}) {
+		t.Fatalf("expected OnRender to be called before and after drawing the cube's front face; got %+v", calls)
+	}
+
+	// If none of the MeshPart's triangles are drawn, OnRender isn't called.
+	cube.Move(0, 0, 10)
+	render()
+
+	if len(calls) != 0 {
+		t.Fatalf("expected OnRender not to be called for a cube behind the Camera; got %+v", calls)
+	}
+
+	// For a dynamic batch, OnRender is called with the batch owner, and the vertices of all of the Models batched with it.
+	owner := NewModel(cube.Mesh, "owner")
+	scene.Root.AddChildren(owner)
+
+	batched := []*Model{}
+	for i := 0; i < 3; i++ {
+		model := NewModel(NewCube(), "batched")
+		model.Move(float64(i-1)*3, 0, -5)
+		batched = append(batched, model)
+	}
+
+	if err := owner.DynamicBatchAdd(owner.Mesh.MeshParts[0], batched...); err != nil {
+		t.Fatal(err)
+	}
+
+	render()
+
+	if len(calls) != 2 || calls[0].Model != owner || calls[0].VertexCount <= 6*3 {
+		t.Fatalf("expected OnRender to be called once for the batch with the vertices of all 3 batched cubes; got %+v", calls)
+	}
+
+	owner.DynamicBatchClear()
+	cube.Move(0, 0, -10)
+	render()
+
+	if c := readPixel(t, camera.ColorTexture(), 32, 32); c != (color.RGBA{255, 0, 0, 255}) {
+		t.Fatalf("expected the cube to be drawn red after its vertex colors were altered in OnRender; got %v", c)
+	}
+
+}
+
+func TestCameraShake(t *testing.T) {
+
+	scene := NewScene("shake")
+
+	camera := NewCamera(64, 64)
+	camera.Move(0, 0, 5)
+	camera.Rotate(0, 1, 0, 0.5)
+	scene.Root.AddChildren(camera)
+
+	cube := NewModel(NewCube(), "cube")
+	scene.Root.AddChildren(cube)
+
+	// The Camera's position while rendering is recorded as the cube's drawn.
+	var renderedPosition vector.Vector
+	cube.Mesh.MeshParts[0].OnRender = func(camera *Camera, model *Model, part *MeshPart, stage int, vertices []ebiten.Vertex) {
+		renderedPosition = camera.WorldPosition()
+	}
+
+	position := camera.WorldPosition()
+
+	render := func() vector.Vector {
+		renderedPosition = nil
+		camera.Clear()
+		camera.RenderNodes(scene, scene.Root)
+		return renderedPosition
+	}
+
+	if offset := render().Sub(position); offset.Magnitude() > 1e-6 {
+		t.Fatalf("the Camera shouldn't be offset when it isn't shaking; got an offset of %v", offset)
+	}
+
+	camera.Shake(0.5, 10)
+
+	if !camera.Shaking() {
+		t.Fatalf("the Camera should be shaking")
+	}
+
+	forward := camera.WorldRotation().Forward()
+	offsets := []vector.Vector{}
+
+	for i := 0; i < 5; i++ {
+
+		offset := render().Sub(position)
+
+		// The Camera shakes along its local X and Y axes only, by up to the intensity given along each.
+		if math.Abs(dot(offset, forward)) > 1e-6 || offset.Magnitude() > 0.5*math.Sqrt2 {
+			t.Fatalf("expected the Camera to be offset by up to 0.5 units along its local X and Y axes; got an offset of %v", offset)
+		}
+
+		if camera.WorldPosition().Sub(position).Magnitude() > 1e-6 {
+			t.Fatalf("the Camera's position should only be offset while rendering")
+		}
+
+		offsets = append(offsets, offset)
+
+	}
+
+	if offsets[0].Sub(offsets[1]).Magnitude() < 1e-6 && offsets[1].Sub(offsets[2]).Magnitude() < 1e-6 {
+		t.Fatalf("the offset should change from frame to frame; got %v", offsets)
+	}
+
+	camera.StopShaking()
+
+	if camera.Shaking() || render().Sub(position).Magnitude() > 1e-6 {
+		t.Fatalf("the Camera should stop shaking when StopShaking() is called")
+	}
+
+	camera.Shake(0.5, 0.05)
+	time.Sleep(time.Millisecond * 60)
+
+	if camera.Shaking() || render().Sub(position).Magnitude() > 1e-6 {
+		t.Fatalf("the Camera should stop shaking once the duration has passed")
+	}
+
+}
+
+func TestMotionBlur(t *testing.T) {
+
+	scene := NewScene("motion blur")
+
+	camera := NewCamera(32, 32)
+	camera.Move(0, 0, 6)
+	scene.Root.AddChildren(camera)
+
+	cube := NewModel(NewCube(), "cube")
+	cube.SetLocalScale(0.5, 0.5, 0.5)
+	cube.Mesh.MeshParts[0].Material.Shadeless = true
+	cube.Color.Set(1, 0, 0, 1)
+	scene.Root.AddChildren(cube)
+
+	if camera.SetMotionBlur(0.5); camera.AccumulateColorMode != AccumlateColorModeAbove || camera.AccumulateDrawOptions.ColorM.Element(3, 3) != 0.5 {
+		t.Fatalf("SetMotionBlur() should draw previous frames above the current one at the given opacity")
+	}
+
+	if camera.SetMotionBlur(2); camera.AccumulateDrawOptions.ColorM.Element(3, 3) != 1 {
+		t.Fatalf("SetMotionBlur() should clamp the factor to 1")
+	}
+
+	if camera.SetMotionBlur(0); camera.AccumulateColorMode != AccumlateColorModeNone || camera.AccumulationColorTexture() != nil {
+		t.Fatalf("SetMotionBlur(0) should turn the accumulation buffer off")
+	}
+
+	camera.SetMotionBlur(0.5)
+
+	left, right := vector.Vector{-1.5, 0, 0}, vector.Vector{1.5, 0, 0}
+
+	// The cube is drawn on the left on the first frame and on the right on the second; the frames are accumulated as the next frame is cleared.
+	for _, position := range []vector.Vector{left, right} {
+		camera.Clear()
+		cube.SetLocalPositionVec(position)
+		camera.RenderNodes(scene, scene.Root)
+	}
+
+	camera.Clear()
+
+	leftScreen, rightScreen := camera.WorldToScreen(left), camera.WorldToScreen(right)
+
+	// The Camera's own color texture was just cleared, so the cube only remains in the accumulation buffer.
+	if c := readPixel(t, camera.ColorTexture(), int(rightScreen[0]), int(rightScreen[1])); c.A != 0 {
+		t.Fatalf("expected the cleared color texture to be empty, got %v", c)
+	}
+
+	accumulated := camera.AccumulationColorTexture()
+
+	// The last frame's cube is drawn fully opaque...
+	if c := readPixel(t, accumulated, int(rightScreen[0]), int(rightScreen[1])); c.R < 250 || c.G > 4 || c.B > 4 || c.A < 250 {
+		t.Fatalf("expected the last frame's cube to be fully visible in the accumulation buffer, got %v", c)
+	}
+
+	// ...while the frame before that is blended in at half opacity.
+	if c := readPixel(t, accumulated, int(leftScreen[0]), int(leftScreen[1])); math.Abs(float64(c.A)-128) > 4 || math.Abs(float64(c.R)-128) > 4 || c.G > 4 || c.B > 4 {
+		t.Fatalf("expected the previous frame's cube to be blended into the accumulation buffer at half opacity, got %v", c)
+	}
+
+	if blended := countBlendedPixels(readPixels(t, accumulated)); blended == 0 {
+		t.Fatalf("expected the accumulation buffer to blend in the previous frame")
+	}
+
+}
+
+// renderGradientCube renders a shadeless cube with its vertices colored according to their positions (so it's drawn with a range of
+// colors) and returns the Camera it was rendered with.
+func renderGradientCube() *Camera {
+
+	scene := NewScene("gradient cube")
+
+	camera := NewCamera(32, 32)
+	camera.Move(0, 0, 4)
+	scene.Root.AddChildren(camera)
+
+	cube := NewModel(NewCube(), "cube")
+	cube.Rotate(1, 1, 0, 0.6)
+	cube.Mesh.MeshParts[0].Material.Shadeless = true
+	scene.Root.AddChildren(cube)
+
+	mesh := cube.Mesh
+	mesh.ensureEnoughVertexColorChannels(0)
+	mesh.SelectVertices().SelectAll().SetActiveColorChannel(0)
+
+	for i := 0; i < mesh.VertexCount; i++ {
+		p := mesh.VertexPositions[i]
+		mesh.VertexColors[i][0].Set(float32(p[0]+1)/2, float32(p[1]+1)/2, float32(p[2]+1)/2, 1)
+	}
+
+	camera.Clear()
+	camera.RenderNodes(scene, scene.Root)
+
+	return camera
+
+}
+
+func TestPaletteConstraint(t *testing.T) {
+
+	palette := []color.Color{
+		color.RGBA{0, 0, 0, 255},
+		color.RGBA{255, 0, 0, 255},
+		color.RGBA{0, 255, 0, 255},
+		color.RGBA{255, 255, 255, 255},
+		color.RGBA{0, 0, 255, 0}, // Fully transparent colors are left out of the palette
+	}
+
+	camera := renderGradientCube()
+	before := readPixels(t, camera.ColorTexture())
+
+	camera.PaletteConstraint(palette, 0)
+	after := readPixels(t, camera.ColorTexture())
+
+	distance := func(a, b color.RGBA) float64 {
+		dr, dg, db := float64(a.R)-float64(b.R), float64(a.G)-float64(b.G), float64(a.B)-float64(b.B)
+		return math.Sqrt(dr*dr + dg*dg + db*db)
+	}
+
+	used := map[color.RGBA]bool{}
+
+	for i := range before {
+
+		if before[i].A == 0 {
+			if after[i].A != 0 {
+				t.Fatalf("pixel %d: expected empty pixels to stay empty, got %v", i, after[i])
+			}
+			continue
+		}
+
+		// Each pixel snaps to the nearest palette color (pixels almost equally near to two colors could go either way, and so are skipped).
+		nearest, nearestDistance, secondDistance := color.RGBA{}, math.MaxFloat64, math.MaxFloat64
+
+		for _, c := range palette[:4] {
+			c := c.(color.RGBA)
+			if d := distance(before[i], c); d < nearestDistance {
+				nearest, nearestDistance, secondDistance = c, d, nearestDistance
+			} else if d < secondDistance {
+				secondDistance = d
+			}
+		}
+
+		if secondDistance-nearestDistance < 4 {
+			continue
+		}
+
+		if distance(after[i], nearest) > 1 || after[i].A != 255 {
+			t.Fatalf("pixel %d: expected %v to be snapped to %v, got %v", i, before[i], nearest, after[i])
+		}
+
+		used[nearest] = true
+
+	}
+
+	if len(used) < 3 {
+		t.Fatalf("expected the cube's colors to be snapped to a range of palette colors, got %v", used)
+	}
+
+}
+
+func TestColorDepthConstraint(t *testing.T) {
+
+	for _, bits := range []int{1, 2} {
+
+		camera := renderGradientCube()
+		before := readPixels(t, camera.ColorTexture())
+
+		camera.ColorDepthConstraint(bits, 0)
+		after := readPixels(t, camera.ColorTexture())
+
+		levels := float64(int(1)<<bits - 1)
+		values := map[uint8]bool{}
+
+		for i := range before {
+
+			if before[i].A == 0 {
+				if after[i].A != 0 {
+					t.Fatalf("%d bits, pixel %d: expected empty pixels to stay empty, got %v", bits, i, after[i])
+				}
+				continue
+			}
+
+			channels := [][2]uint8{{before[i].R, after[i].R}, {before[i].G, after[i].G}, {before[i].B, after[i].B}}
+
+			for _, channel := range channels {
+
+				// Each channel snaps to the nearest of the available values (values almost halfway between two could go either way,
+				// and so are skipped).
+				scaled := float64(channel[0]) / 255 * levels
+				if math.Abs(scaled-math.Floor(scaled)-0.5) < 0.02 {
+					continue
+				}
+
+				expected := math.Round(scaled) / levels * 255
+
+				if math.Abs(float64(channel[1])-expected) > 1 {
+					t.Fatalf("%d bits, pixel %d: expected a channel value of %d to be snapped to %f, got %d", bits, i, channel[0], expected, channel[1])
+				}
+
+				values[channel[1]] = true
+
+			}
+
+		}
+
+		if len(values) != int(levels)+1 {
+			t.Fatalf("%d bits: expected the cube's colors to use all %d values available per channel, got %v", bits, int(levels)+1, values)
+		}
+
+	}
+
+}
+
+func TestCamerasRenderingTheSameMeshDontInterfere(t *testing.T) {
+
+	// Each triangle is at a different depth and reaches from behind the first Camera to in front of it, so that Camera clips them
+	// all, while the other Camera sees them all unclipped from afar and sorts them differently.
+	triangleCount := 200
+
+	mesh := NewMesh("crossing")
+	part := mesh.AddMeshPart(NewMaterial("crossing"))
+	part.Material.BackfaceCulling = false
+
+	verts := make([]VertexInfo, 0, triangleCount*3)
+	for i := 0; i < triangleCount; i++ {
+		x := float64(i%20)/10 - 1
+		z := -float64(i) / 400
+		verts = append(verts, NewVertex(x, -1, z+1, 0, 0), NewVertex(x-1, -1, z-10, 0, 0), NewVertex(x+1, -1, z-10, 0, 0))
+	}
+	part.AddTriangles(verts...)
+	mesh.UpdateBounds()
+
+	scene := NewScene("cameras")
+	scene.Root.AddChildren(NewModel(mesh, "crossing"))
+
+	camera := NewCamera(64, 64)
+
+	other := NewCamera(64, 64)
+	other.Move(3, 2, 30)
+	other.Rotate(0, 1, 0, 0.2)
+
+	rendered := map[*Camera][]ebiten.Vertex{}
+
+	part.OnRender = func(camera *Camera, model *Model, part *MeshPart, stage int, vertices []ebiten.Vertex) {
+		if stage == RenderStageBefore {
+			rendered[camera] = append(rendered[camera], vertices...)
+		}
+	}
+
+	render := func(camera *Camera) []ebiten.Vertex {
+		rendered[camera] = nil
+		camera.Clear()
+		camera.RenderNodes(scene, scene.Root)
+		return rendered[camera]
+	}
+
+	first := render(camera)
+	render(other)
+	second := render(camera)
+
+	if len(first) != triangleCount*6 || len(rendered[other]) != triangleCount*3 {
+		t.Fatalf("expected the first Camera to draw %d vertices and the other %d, got %d and %d", triangleCount*6, triangleCount*3, len(first), len(rendered[other]))
+	}
+
+	if len(second) != len(first) {
+		t.Fatalf("expected the first Camera to draw %d vertices after the other Camera rendered, got %d", len(first), len(second))
+	}
+
+	for i := range first {
+		if first[i].DstX != second[i].DstX || first[i].DstY != second[i].DstY {
+			t.Fatalf("expected vertex %d to be drawn at %f, %f after the other Camera rendered, got %f, %f", i, first[i].DstX, first[i].DstY, second[i].DstX, second[i].DstY)
+		}
+	}
+
+	// Sorting happens on the Camera's copy of the triangles, so the Mesh's own triangles stay in order.
+	for i, tri := range part.sortingTriangles {
+		if tri.ID != i {
+			t.Fatalf("expected rendering to leave the MeshPart's triangles in order, but triangle %d is at %d", tri.ID, i)
+		}
+	}
+
+}
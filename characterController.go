@@ -0,0 +1,274 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/kvartborg/vector"
+)
+
+// characterSkinWidth is how far a CharacterController keeps its capsule away from surfaces it moves into, so that it's never left
+// overlapping them.
+const characterSkinWidth = 0.001
+
+// CharacterController moves a Node around a level with a BoundingCapsule, like a character in a game: it slides along walls and
+// floors rather than stopping dead when it hits them, walks up steps and slopes that aren't too high or steep, sticks to the ground
+// when walking down them, and falls with gravity when it isn't on the ground. It's kinematic, so it's only moved by calls to
+// CharacterController.Move(), and isn't pushed around by anything else.
+type CharacterController struct {
+	Node    INode            // The Node that's moved; this can be the Capsule itself, or a Node that the Capsule is a child of.
+	Capsule *BoundingCapsule // The BoundingCapsule used to collide with the level.
+
+	Up vector.Vector // The world-space up direction of the CharacterController; gravity pulls it the other way. Defaults to +Y.
+
+	Gravity          float64 // How much the CharacterController's VerticalVelocity drops each time it's moved. Defaults to 0.01.
+	MaxFallSpeed     float64 // The fastest the CharacterController can fall, in world units each time it's moved. Defaults to 1.
+	VerticalVelocity float64 // How fast the CharacterController is moving along its Up vector, in world units each time it's moved.
+
+	// StepHeight is how tall a step or ledge the CharacterController can walk up onto, and how far down it sticks to the ground
+	// when walking down slopes and stairs. Defaults to 0.3.
+	StepHeight float64
+	// MaxSlopeAngle is the steepest slope (in radians) that the CharacterController can walk up and stand on; it slides down
+	// anything steeper. Defaults to 45 degrees (math.Pi / 4).
+	MaxSlopeAngle float64
+	// MaxSlides is the most times the CharacterController can slide along surfaces in a single move. Defaults to 4.
+	MaxSlides int
+
+	grounded     bool
+	groundNormal vector.Vector
+}
+
+// NewCharacterController returns a new CharacterController that moves the given Node with the given BoundingCapsule (which should
+// be the Node itself or one of its children). If node is nil, the capsule itself is moved.
+func NewCharacterController(node INode, capsule *BoundingCapsule) *CharacterController {
+
+	if node == nil {
+		node = capsule
+	}
+
+	return &CharacterController{
+		Node:          node,
+		Capsule:       capsule,
+		Up:            vector.Vector{0, 1, 0},
+		Gravity:       0.01,
+		MaxFallSpeed:  1,
+		StepHeight:    0.3,
+		MaxSlopeAngle: math.Pi / 4,
+		MaxSlides:     4,
+	}
+
+}
+
+// Move moves the CharacterController by the given movement vector (in world space) and by its VerticalVelocity (after applying
+// Gravity to it), colliding with the BoundingObjects in the trees of the INodes provided in others (like the level's geometry).
+// The part of the movement vector along the CharacterController's Up vector is ignored; use Jump() or VerticalVelocity to move up
+// and down. Move returns how far the CharacterController actually moved. You should call Move() once per game frame, even when the
+// CharacterController isn't walking, so that it keeps falling and stays grounded.
+func (cc *CharacterController) Move(movement vector.Vector, others ...INode) vector.Vector {
+
+	up := cc.Up.Unit()
+	start := cc.Node.WorldPosition()
+	wasGrounded := cc.grounded
+
+	cc.depenetrate(others)
+
+	// Walking
+
+	horizontal := movement.Sub(up.Scale(dot(movement, up)))
+
+	if horizontal.Magnitude() > 0 {
+
+		before := cc.Node.WorldPosition()
+		walked := cc.slide(horizontal, true, others)
+
+		// If a wall stopped the CharacterController while it was on the ground, it might be a step; to find out, we try the
+		// movement again from StepHeight higher up, and then step back down.
+		if walked.wall && wasGrounded && cc.StepHeight > 0 {
+
+			after := cc.Node.WorldPosition()
+			cc.Node.SetWorldPositionVec(before)
+			feet := dot(cc.Capsule.Bottom(), up)
+
+			cc.slide(up.Scale(cc.StepHeight), false, others)
+			cc.slide(horizontal, true, others)
+
+			raised := dot(cc.Node.WorldPosition().Sub(before), up)
+			hit := cc.Capsule.Sweep(up.Scale(-raised), others...)
+
+			progress := func(position vector.Vector) float64 {
+				moved := position.Sub(before)
+				return moved.Sub(up.Scale(dot(moved, up))).Magnitude()
+			}
+
+			// Stepping down usually lands on the edge of the step rather than its top, so a contact that's higher than the
+			// CharacterController's feet (but no higher than StepHeight) also counts.
+			onStep := false
+			if hit != nil {
+				height := dot(hit.ContactPoint, up) - feet
+				onStep = cc.walkable(hit.Normal) || (height > characterSkinWidth && height <= cc.StepHeight)
+			}
+
+			if onStep {
+				cc.moveBy(up.Scale(-raised * hit.Time).Add(hit.Normal.Scale(characterSkinWidth)))
+			}
+
+			if onStep && progress(cc.Node.WorldPosition()) > progress(after)+1e-6 {
+
+				// The CharacterController's on the step now, so it shouldn't fall (or slide back off of its edge) this time.
+				if cc.walkable(hit.Normal) {
+					cc.land(hit.Normal)
+				} else {
+					cc.land(up)
+				}
+
+				return cc.Node.WorldPosition().Sub(start)
+
+			}
+
+			cc.Node.SetWorldPositionVec(after)
+
+		}
+
+	}
+
+	// Falling
+
+	cc.VerticalVelocity = math.Max(cc.VerticalVelocity-cc.Gravity, -cc.MaxFallSpeed)
+	cc.grounded = false
+
+	// When walking down slopes and stairs, the CharacterController sticks to the ground rather than flying off of it.
+	if wasGrounded && cc.VerticalVelocity <= 0 {
+
+		drop := cc.StepHeight - cc.VerticalVelocity
+
+		if hit := cc.Capsule.Sweep(up.Scale(-drop), others...); hit != nil && cc.walkable(hit.Normal) {
+			cc.moveBy(up.Scale(-drop * hit.Time).Add(hit.Normal.Scale(characterSkinWidth)))
+			cc.land(hit.Normal)
+			return cc.Node.WorldPosition().Sub(start)
+		}
+
+	}
+
+	fell := cc.slide(up.Scale(cc.VerticalVelocity), false, others)
+
+	if fell.ground != nil && cc.VerticalVelocity <= 0 {
+		cc.land(fell.ground)
+	} else if fell.ceiling && cc.VerticalVelocity > 0 {
+		cc.VerticalVelocity = 0
+	}
+
+	return cc.Node.WorldPosition().Sub(start)
+
+}
+
+// Jump makes the CharacterController jump (or otherwise move upwards) by setting its VerticalVelocity to the given speed.
+func (cc *CharacterController) Jump(speed float64) {
+	cc.VerticalVelocity = speed
+	cc.grounded = false
+}
+
+// Grounded returns if the CharacterController was standing on the ground (on a surface no steeper than its MaxSlopeAngle) as of
+// the last call to Move().
+func (cc *CharacterController) Grounded() bool {
+	return cc.grounded
+}
+
+// GroundNormal returns the normal of the ground the CharacterController was standing on as of the last call to Move(), or nil if
+// it wasn't grounded.
+func (cc *CharacterController) GroundNormal() vector.Vector {
+	if !cc.grounded {
+		return nil
+	}
+	return cc.groundNormal.Clone()
+}
+
+// land marks the CharacterController as standing on ground with the given normal.
+func (cc *CharacterController) land(normal vector.Vector) {
+	cc.grounded = true
+	cc.groundNormal = normal
+	cc.VerticalVelocity = 0
+}
+
+// walkable returns if a surface with the given normal is flat enough for the CharacterController to stand on.
+func (cc *CharacterController) walkable(normal vector.Vector) bool {
+	return dot(normal, cc.Up.Unit()) >= math.Cos(cc.MaxSlopeAngle)-1e-9
+}
+
+// moveBy moves the CharacterController's Node by the given vector in world space.
+func (cc *CharacterController) moveBy(delta vector.Vector) {
+	cc.Node.SetWorldPositionVec(cc.Node.WorldPosition().Add(delta))
+}
+
+// depenetrate pushes the CharacterController out of anything it's overlapping (for example, if it was placed inside of a wall).
+func (cc *CharacterController) depenetrate(others []INode) {
+
+	for i := 0; i < cc.MaxSlides; i++ {
+
+		collisions := cc.Capsule.CollisionTest(0, 0, 0, others...)
+
+		if len(collisions) == 0 {
+			return
+		}
+
+		for _, collision := range collisions {
+			mtv := collision.AverageMTV()
+			cc.moveBy(mtv.Add(mtv.Unit().Scale(characterSkinWidth)))
+		}
+
+	}
+
+}
+
+// slideResult describes the surfaces a CharacterController hit while sliding.
+type slideResult struct {
+	ground  vector.Vector // The normal of the last walkable surface hit, if any.
+	wall    bool          // Whether any surface too steep to walk on was hit.
+	ceiling bool          // Whether any surface facing downwards was hit.
+}
+
+// slide moves the CharacterController by the given vector, sliding along anything it hits. If walking is true, surfaces too steep
+// to walk on are treated as vertical walls, so that the CharacterController can't be pushed up them.
+func (cc *CharacterController) slide(movement vector.Vector, walking bool, others []INode) slideResult {
+
+	result := slideResult{}
+	up := cc.Up.Unit()
+	remaining := movement.Clone()
+
+	for i := 0; i < cc.MaxSlides && remaining.Magnitude() > 1e-9; i++ {
+
+		hit := cc.Capsule.Sweep(remaining, others...)
+
+		if hit == nil {
+			cc.moveBy(remaining)
+			break
+		}
+
+		cc.moveBy(remaining.Scale(hit.Time).Add(hit.Normal.Scale(characterSkinWidth)))
+
+		normal := hit.Normal
+
+		if cc.walkable(normal) {
+			result.ground = normal
+		} else {
+
+			result.wall = true
+
+			if dot(normal, up) < 0 {
+				result.ceiling = true
+			}
+
+			if walking {
+				if flat := normal.Sub(up.Scale(dot(normal, up))); flat.Magnitude() > 1e-9 {
+					normal = flat.Unit()
+				}
+			}
+
+		}
+
+		remaining = remaining.Scale(1 - hit.Time)
+		remaining = remaining.Sub(normal.Scale(dot(remaining, normal)))
+
+	}
+
+	return result
+
+}
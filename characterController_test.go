@@ -0,0 +1,139 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kvartborg/vector"
+)
+
+func TestCharacterController(t *testing.T) {
+
+	scene := NewScene("character controller test")
+
+	floor := NewBoundingTriangles("floor", NewPlane(), 0)
+	floor.SetLocalScale(20, 1, 20)
+
+	wall := NewBoundingAABB("wall", 2, 4, 20)
+	wall.SetLocalPosition(-5, 2, 0)
+
+	step := NewBoundingAABB("step", 4, 0.4, 20)
+	step.SetLocalPosition(4, 0.2, 0)
+
+	scene.Root.AddChildren(floor, wall, step)
+
+	player := NewNode("player")
+	capsule := NewBoundingCapsule("player capsule", 2, 0.5)
+	capsule.SetLocalPosition(0, 1, 0)
+	player.AddChildren(capsule)
+	player.SetLocalPosition(0, 3, 0)
+
+	cc := NewCharacterController(player, capsule)
+
+	for i := 0; i < 100; i++ {
+		cc.Move(vector.Vector{0, 0, 0}, scene.Root)
+	}
+
+	if !cc.Grounded() || math.Abs(player.WorldPosition()[1]) > 0.01 || cc.GroundNormal().Sub(vector.Vector{0, 1, 0}).Magnitude() > 1e-3 {
+		t.Fatalf("the player should have fallen onto the floor; grounded: %t, position: %v", cc.Grounded(), player.WorldPosition())
+	}
+
+	// Walking into a wall slides along it.
+	for i := 0; i < 60; i++ {
+		cc.Move(vector.Vector{-0.1, 0, -0.1}, scene.Root)
+	}
+
+	if pos := player.WorldPosition(); math.Abs(pos[0]+3.5) > 0.01 || pos[2] > -5.9 || !cc.Grounded() {
+		t.Fatalf("the player should have slid along the wall, stopping against it; position: %v", pos)
+	}
+
+	// Steps lower than the StepHeight are walked up onto.
+	player.SetLocalPosition(0, 0, 0)
+	cc.StepHeight = 0.1
+
+	for i := 0; i < 30; i++ {
+		cc.Move(vector.Vector{0.1, 0, 0}, scene.Root)
+	}
+
+	if pos := player.WorldPosition(); pos[0] > 1.6 || pos[1] > 0.01 {
+		t.Fatalf("the step should be too tall for the player to walk up onto; position: %v", pos)
+	}
+
+	cc.StepHeight = 0.5
+
+	for i := 0; i < 30; i++ {
+		cc.Move(vector.Vector{0.1, 0, 0}, scene.Root)
+	}
+
+	if pos := player.WorldPosition(); pos[0] < 2.5 || math.Abs(pos[1]-0.4) > 0.01 || !cc.Grounded() {
+		t.Fatalf("the player should have walked up onto the step; position: %v", pos)
+	}
+
+	// ...and walked back down off of.
+	height := player.WorldPosition()[1]
+
+	for i := 0; i < 30; i++ {
+		cc.Move(vector.Vector{0.1, 0, 0}, scene.Root)
+		if y := player.WorldPosition()[1]; y > height+1e-6 {
+			t.Fatalf("the player shouldn't go upwards when walking down off of the step; position: %v", player.WorldPosition())
+		} else {
+			height = y
+		}
+	}
+
+	if pos := player.WorldPosition(); pos[0] < 6.5 || math.Abs(pos[1]) > 0.01 || !cc.Grounded() {
+		t.Fatalf("the player should have walked down off of the step; position: %v", pos)
+	}
+
+	cc.Jump(0.2)
+	cc.Move(vector.Vector{0, 0, 0}, scene.Root)
+
+	if cc.Grounded() || player.WorldPosition()[1] <= 0.1 {
+		t.Fatalf("the player should be in the air after jumping; position: %v", player.WorldPosition())
+	}
+
+}
+
+func TestCharacterControllerSlopes(t *testing.T) {
+
+	scene := NewScene("character controller slope test")
+
+	slope := NewBoundingTriangles("slope", NewPlane(), 0)
+	slope.SetLocalScale(20, 1, 20)
+	scene.Root.AddChildren(slope)
+
+	capsule := NewBoundingCapsule("player", 2, 0.5)
+	cc := NewCharacterController(nil, capsule)
+
+	// Walking down a gentle slope sticks to it, rather than running off into the air...
+	slope.SetLocalRotation(NewMatrix4Rotate(0, 0, 1, ToRadians(20)))
+	capsule.SetLocalPosition(5, 3, 0)
+
+	for i := 0; i < 100; i++ {
+		cc.Move(vector.Vector{0, 0, 0}, scene.Root)
+	}
+
+	for i := 0; i < 30; i++ {
+		cc.Move(vector.Vector{-0.2, 0, 0}, scene.Root)
+		if !cc.Grounded() {
+			t.Fatalf("the player should stay grounded walking down a gentle slope; position: %v", capsule.WorldPosition())
+		}
+	}
+
+	// ...but the player can't stand on a slope steeper than the MaxSlopeAngle, and slides down it.
+	slope.SetLocalRotation(NewMatrix4Rotate(0, 0, 1, ToRadians(60)))
+	capsule.SetLocalPosition(1, 3, 0)
+	cc.Jump(0)
+
+	for i := 0; i < 100; i++ {
+		cc.Move(vector.Vector{0, 0, 0}, scene.Root)
+		if cc.Grounded() {
+			t.Fatalf("the player shouldn't be able to stand on a steep slope; position: %v", capsule.WorldPosition())
+		}
+	}
+
+	if pos := capsule.WorldPosition(); pos[0] > -1 || pos[1] > 0 {
+		t.Fatalf("the player should have slid down the steep slope; position: %v", pos)
+	}
+
+}
@@ -0,0 +1,74 @@
+package tetra3d
+
+// CollisionLayers is a bitmask of up to 32 collision layers (i.e. one layer for static walls, another for bullets, and another for
+// pickups). BoundingObjects declare which layers they're on with their CollisionLayers field, and which layers they collide with with
+// their CollisionMask field (see CollisionFilter). By default, BoundingObjects are on layer 0 (CollisionLayersDefault), and collide
+// with all layers.
+type CollisionLayers uint32
+
+const (
+	CollisionLayersDefault = CollisionLayers(1)  // CollisionLayersDefault is a CollisionLayers value with only the first layer (layer 0) set.
+	CollisionLayersAll     = ^CollisionLayers(0) // CollisionLayersAll is a CollisionLayers value with all layers set.
+	CollisionLayersNone    = CollisionLayers(0)  // CollisionLayersNone is a CollisionLayers value with no layers set.
+)
+
+// NewCollisionLayers returns a CollisionLayers bitmask with the layers of the given indices (from 0 to 31) set.
+func NewCollisionLayers(layers ...int) CollisionLayers {
+	mask := CollisionLayers(0)
+	for _, layer := range layers {
+		mask |= 1 << layer
+	}
+	return mask
+}
+
+// Has returns if any of the layers in the other CollisionLayers are set in the CollisionLayers.
+func (layers CollisionLayers) Has(other CollisionLayers) bool {
+	return layers&other != 0
+}
+
+// With returns a copy of the CollisionLayers with the layers of the given indices (from 0 to 31) set.
+func (layers CollisionLayers) With(indices ...int) CollisionLayers {
+	return layers | NewCollisionLayers(indices...)
+}
+
+// Without returns a copy of the CollisionLayers with the layers of the given indices (from 0 to 31) unset.
+func (layers CollisionLayers) Without(indices ...int) CollisionLayers {
+	return layers &^ NewCollisionLayers(indices...)
+}
+
+// CollisionFilter is embedded in each BoundingObject, and controls which other BoundingObjects it collides with in collision tests
+// (IBoundingObject.CollisionTest(), CollisionTestVec(), sweeps, and so on). A BoundingObject only tests against other BoundingObjects
+// that are on at least one of the layers in its CollisionMask; for example, a bullet whose mask doesn't include the pickups' layer
+// won't be tested against pickups at all. Testing two BoundingObjects against each other directly (with IBoundingObject.Collision()
+// or Colliding()) ignores their CollisionFilters.
+type CollisionFilter struct {
+	CollisionLayers CollisionLayers // The layers the BoundingObject is on. Defaults to CollisionLayersDefault.
+	CollisionMask   CollisionLayers // The layers of the BoundingObjects the BoundingObject collides with. Defaults to CollisionLayersAll.
+}
+
+// newCollisionFilter returns a CollisionFilter with the default layers and mask.
+func newCollisionFilter() CollisionFilter {
+	return CollisionFilter{
+		CollisionLayers: CollisionLayersDefault,
+		CollisionMask:   CollisionLayersAll,
+	}
+}
+
+// CollidesWith returns if the BoundingObject's CollisionMask includes any of the other BoundingObject's CollisionLayers (that is,
+// if collision tests of this BoundingObject test against the other one).
+func (filter *CollisionFilter) CollidesWith(other IBoundingObject) bool {
+	return filter.CollisionMask.Has(other.collisionFilter().CollisionLayers)
+}
+
+func (filter *CollisionFilter) collisionFilter() *CollisionFilter {
+	return filter
+}
+
+// CollisionLayerFilter returns a filter for Scene.RayTest() that only passes BoundingObjects on at least one of the layers in the
+// given mask.
+func CollisionLayerFilter(mask CollisionLayers) func(boundingObject INode) bool {
+	return func(boundingObject INode) bool {
+		bounds, ok := boundingObject.(IBoundingObject)
+		return ok && mask.Has(bounds.collisionFilter().CollisionLayers)
+	}
+}
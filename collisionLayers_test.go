@@ -0,0 +1,54 @@
+package tetra3d
+
+import (
+	"testing"
+
+	"github.com/kvartborg/vector"
+)
+
+func TestCollisionLayers(t *testing.T) {
+
+	scene := NewScene("collision layers test")
+
+	wall := NewBoundingAABB("wall", 2, 2, 2)
+
+	pickup := NewBoundingSphere("pickup", 1)
+	pickup.CollisionLayers = NewCollisionLayers(2)
+
+	scene.Root.AddChildren(wall, pickup)
+
+	bullet := NewBoundingSphere("bullet", 0.5)
+	bullet.CollisionLayers = NewCollisionLayers(1)
+	bullet.CollisionMask = CollisionLayersAll.Without(2)
+
+	collisions := bullet.CollisionTest(0, 0, 0, scene.Root)
+
+	if len(collisions) != 1 || collisions[0].BoundingObject != wall {
+		t.Fatalf("the bullet should only collide with the wall, not the pickup; got %d collisions", len(collisions))
+	}
+
+	if !bullet.Colliding(pickup) {
+		t.Fatalf("testing the bullet against the pickup directly should ignore their collision layers")
+	}
+
+	if !pickup.CollidesWith(bullet) || bullet.CollidesWith(pickup) {
+		t.Fatalf("collision masks should only apply to the BoundingObject doing the testing")
+	}
+
+	bullet.SetLocalPosition(-5, 0, 0)
+
+	if hit := bullet.Sweep(vector.Vector{10, 0, 0}, pickup); hit != nil {
+		t.Fatalf("sweeps should skip BoundingObjects that aren't on the swept object's collision mask")
+	}
+
+	if hits := scene.RayTest(vector.Vector{0, 5, 0}, vector.Vector{0, -5, 0}, CollisionLayerFilter(bullet.CollisionMask)); len(hits) != 1 || hits[0].BoundingObject != wall {
+		t.Fatalf("a ray test filtered by the bullet's collision mask should only hit the wall")
+	}
+
+	clone := bullet.Clone().(*BoundingSphere)
+
+	if clone.CollisionLayers != bullet.CollisionLayers || clone.CollisionMask != bullet.CollisionMask {
+		t.Fatalf("clones should keep the collision layers and mask of the original")
+	}
+
+}
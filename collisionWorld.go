@@ -0,0 +1,228 @@
+package tetra3d
+
+import "sort"
+
+// CollisionEvent describes two BoundingObjects colliding in a CollisionWorld, as passed to its callbacks.
+type CollisionEvent struct {
+	A, B IBoundingObject // The colliding BoundingObjects, in the order they were added to the CollisionWorld.
+	// Collision is the Collision of A against B (so its MTV moves A out of B), as of the last call to CollisionWorld.Update(). For
+	// OnCollisionExit, this is the last Collision from before they stopped colliding.
+	Collision *Collision
+}
+
+// Other returns the BoundingObject in the CollisionEvent that isn't the one given.
+func (event *CollisionEvent) Other(boundingObject IBoundingObject) IBoundingObject {
+	if event.A == boundingObject {
+		return event.B
+	}
+	return event.A
+}
+
+type collisionPair struct {
+	A, B IBoundingObject
+}
+
+// CollisionWorld tracks collisions between a set of BoundingObjects across frames, calling functions when pairs of them start
+// colliding, keep colliding, and stop colliding, so games don't need to compare the results of their own collision tests each
+// frame. Pairs of BoundingObjects are only tested if at least one of them collides with the other according to their
+// CollisionFilters, and pairs of BoundingTriangles (which are usually static level geometry) aren't tested against each other. Every
+// pair is tested on each Update(), so CollisionWorlds are best kept to the objects that need collision callbacks.
+type CollisionWorld struct {
+	OnCollisionEnter func(event *CollisionEvent) // OnCollisionEnter is called when a pair of BoundingObjects starts colliding.
+	OnCollisionStay  func(event *CollisionEvent) // OnCollisionStay is called on each Update() for pairs that were already colliding and still are.
+	OnCollisionExit  func(event *CollisionEvent) // OnCollisionExit is called when a pair of BoundingObjects stops colliding.
+
+	objects []IBoundingObject
+	current map[collisionPair]*CollisionEvent
+}
+
+// NewCollisionWorld returns a new, empty CollisionWorld.
+func NewCollisionWorld() *CollisionWorld {
+	return &CollisionWorld{
+		objects: []IBoundingObject{},
+		current: map[collisionPair]*CollisionEvent{},
+	}
+}
+
+// Add adds the given BoundingObjects to the CollisionWorld. BoundingObjects that are already in it are skipped.
+func (world *CollisionWorld) Add(boundingObjects ...IBoundingObject) {
+
+	for _, boundingObject := range boundingObjects {
+
+		if world.Contains(boundingObject) {
+			continue
+		}
+
+		world.objects = append(world.objects, boundingObject)
+
+	}
+
+}
+
+// Remove removes the given BoundingObjects from the CollisionWorld, calling OnCollisionExit for any pairs they were colliding in.
+func (world *CollisionWorld) Remove(boundingObjects ...IBoundingObject) {
+
+	removed := map[IBoundingObject]bool{}
+
+	for _, boundingObject := range boundingObjects {
+		removed[boundingObject] = true
+	}
+
+	exited := []*CollisionEvent{}
+
+	for _, event := range world.sortedEvents() {
+		if removed[event.A] || removed[event.B] {
+			delete(world.current, collisionPair{event.A, event.B})
+			exited = append(exited, event)
+		}
+	}
+
+	remaining := make([]IBoundingObject, 0, len(world.objects))
+
+	for _, boundingObject := range world.objects {
+		if !removed[boundingObject] {
+			remaining = append(remaining, boundingObject)
+		}
+	}
+
+	world.objects = remaining
+
+	if world.OnCollisionExit != nil {
+		for _, event := range exited {
+			world.OnCollisionExit(event)
+		}
+	}
+
+}
+
+// Contains returns if the given BoundingObject is in the CollisionWorld.
+func (world *CollisionWorld) Contains(boundingObject IBoundingObject) bool {
+	for _, o := range world.objects {
+		if o == boundingObject {
+			return true
+		}
+	}
+	return false
+}
+
+// Objects returns the BoundingObjects in the CollisionWorld, in the order they were added.
+func (world *CollisionWorld) Objects() []IBoundingObject {
+	return append([]IBoundingObject{}, world.objects...)
+}
+
+// Update tests the BoundingObjects in the CollisionWorld against each other, calling OnCollisionExit for pairs that stopped colliding
+// since the last call, then OnCollisionEnter for pairs that started colliding, and then OnCollisionStay for pairs that were already
+// colliding and still are. You should call Update() once per game frame, after moving things.
+func (world *CollisionWorld) Update() {
+
+	entered := []*CollisionEvent{}
+	stayed := []*CollisionEvent{}
+	next := make(map[collisionPair]*CollisionEvent, len(world.current))
+
+	for i, a := range world.objects {
+
+		for _, b := range world.objects[i+1:] {
+
+			_, aIsTriangles := a.(*BoundingTriangles)
+			_, bIsTriangles := b.(*BoundingTriangles)
+
+			if (aIsTriangles && bIsTriangles) || (!a.CollidesWith(b) && !b.CollidesWith(a)) {
+				continue
+			}
+
+			collision := a.Collision(b)
+
+			if collision == nil {
+				continue
+			}
+
+			pair := collisionPair{a, b}
+			event := &CollisionEvent{A: a, B: b, Collision: collision}
+			next[pair] = event
+
+			if _, exists := world.current[pair]; exists {
+				stayed = append(stayed, event)
+			} else {
+				entered = append(entered, event)
+			}
+
+		}
+
+	}
+
+	exited := []*CollisionEvent{}
+
+	for _, event := range world.sortedEvents() {
+		if _, exists := next[collisionPair{event.A, event.B}]; !exists {
+			exited = append(exited, event)
+		}
+	}
+
+	world.current = next
+
+	// The callbacks are called after the CollisionWorld's state is updated, so they're free to alter the scene or the CollisionWorld.
+
+	if world.OnCollisionExit != nil {
+		for _, event := range exited {
+			world.OnCollisionExit(event)
+		}
+	}
+
+	if world.OnCollisionEnter != nil {
+		for _, event := range entered {
+			world.OnCollisionEnter(event)
+		}
+	}
+
+	if world.OnCollisionStay != nil {
+		for _, event := range stayed {
+			world.OnCollisionStay(event)
+		}
+	}
+
+}
+
+// IsColliding returns if the two given BoundingObjects were colliding as of the last call to CollisionWorld.Update().
+func (world *CollisionWorld) IsColliding(a, b IBoundingObject) bool {
+	_, ab := world.current[collisionPair{a, b}]
+	_, ba := world.current[collisionPair{b, a}]
+	return ab || ba
+}
+
+// Collisions returns the CollisionEvents for the pairs the given BoundingObject was colliding in as of the last call to
+// CollisionWorld.Update(), in the order of the other BoundingObjects in the CollisionWorld.
+func (world *CollisionWorld) Collisions(boundingObject IBoundingObject) []*CollisionEvent {
+	out := []*CollisionEvent{}
+	for _, event := range world.sortedEvents() {
+		if event.A == boundingObject || event.B == boundingObject {
+			out = append(out, event)
+		}
+	}
+	return out
+}
+
+// sortedEvents returns the CollisionWorld's current CollisionEvents, in the order of their BoundingObjects in the CollisionWorld,
+// so that callbacks are called in a consistent order (rather than that of the map).
+func (world *CollisionWorld) sortedEvents() []*CollisionEvent {
+
+	index := make(map[IBoundingObject]int, len(world.objects))
+	for i, o := range world.objects {
+		index[o] = i
+	}
+
+	events := make([]*CollisionEvent, 0, len(world.current))
+	for _, event := range world.current {
+		events = append(events, event)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		ai, aj := index[events[i].A], index[events[j].A]
+		if ai != aj {
+			return ai < aj
+		}
+		return index[events[i].B] < index[events[j].B]
+	})
+
+	return events
+
+}
@@ -0,0 +1,91 @@
+package tetra3d
+
+import (
+	"testing"
+)
+
+func TestCollisionWorld(t *testing.T) {
+
+	world := NewCollisionWorld()
+
+	player := NewBoundingSphere("player", 1)
+	wall := NewBoundingAABB("wall", 2, 2, 2)
+	wall.SetLocalPosition(5, 0, 0)
+	coin := NewBoundingSphere("coin", 0.5)
+	coin.SetLocalPosition(-5, 0, 0)
+
+	world.Add(player, wall, coin, player)
+
+	if len(world.Objects()) != 3 {
+		t.Fatalf("adding an object twice shouldn't add it again")
+	}
+
+	events := []string{}
+
+	world.OnCollisionEnter = func(event *CollisionEvent) {
+		events = append(events, "enter "+event.A.(INode).Name()+" "+event.B.(INode).Name())
+	}
+	world.OnCollisionStay = func(event *CollisionEvent) {
+		events = append(events, "stay "+event.A.(INode).Name()+" "+event.B.(INode).Name())
+	}
+	world.OnCollisionExit = func(event *CollisionEvent) {
+		events = append(events, "exit "+event.A.(INode).Name()+" "+event.B.(INode).Name())
+	}
+
+	// expect checks that the callbacks called since the last check were the ones given, in order.
+	expect := func(expected ...string) {
+		t.Helper()
+		if len(events) != len(expected) {
+			t.Fatalf("expected the events %v, but got %v", expected, events)
+		}
+		for i := range expected {
+			if events[i] != expected[i] {
+				t.Fatalf("expected the events %v, but got %v", expected, events)
+			}
+		}
+		events = events[:0]
+	}
+
+	world.Update()
+	expect()
+
+	player.SetLocalPosition(3.5, 0, 0)
+	world.Update()
+	expect("enter player wall")
+
+	if !world.IsColliding(wall, player) || len(world.Collisions(wall)) != 1 {
+		t.Fatalf("the player and the wall should be colliding")
+	}
+
+	if event := world.Collisions(wall)[0]; event.Other(wall) != player || event.Collision.AverageMTV()[0] >= 0 {
+		t.Fatalf("the collision should push the player back out of the wall")
+	}
+
+	world.Update()
+	expect("stay player wall")
+
+	player.SetLocalPosition(-4.5, 0, 0)
+	world.Update()
+	expect("exit player wall", "enter player coin")
+
+	// Objects that don't collide with each other according to their masks aren't tested.
+	coin.CollisionMask = CollisionLayersNone
+	player.CollisionMask = CollisionLayersAll.Without(0)
+	world.Update()
+	expect("exit player coin")
+
+	coin.CollisionMask = CollisionLayersAll
+	world.Update()
+	expect("enter player coin")
+
+	world.Remove(coin)
+	expect("exit player coin")
+
+	if world.Contains(coin) || world.IsColliding(player, coin) {
+		t.Fatalf("the coin should be removed from the world")
+	}
+
+	world.Update()
+	expect()
+
+}
@@ -139,6 +139,30 @@ func (color *Color) ConvertTosRGB() {
 
 }
 
+// ConvertToLinear() converts the color's R, G, and B components from the sRGB color space to linear; it's the inverse of
+// Color.ConvertTosRGB(). This is used to convert colors back to how they should be stored in GLTF.
+func (color *Color) ConvertToLinear() {
+
+	if color.R <= 0.04045 {
+		color.R /= 12.92
+	} else {
+		color.R = float32(math.Pow((float64(color.R)+0.055)/1.055, 2.4))
+	}
+
+	if color.G <= 0.04045 {
+		color.G /= 12.92
+	} else {
+		color.G = float32(math.Pow((float64(color.G)+0.055)/1.055, 2.4))
+	}
+
+	if color.B <= 0.04045 {
+		color.B /= 12.92
+	} else {
+		color.B = float32(math.Pow((float64(color.B)+0.055)/1.055, 2.4))
+	}
+
+}
+
 // NewColorFromHSV returns a new color, using hue, saturation, and value numbers, each ranging from 0 to 1. A hue of
 // 0 is red, while 1 is also red, but on the other end of the spectrum.
 // Cribbed from: https://github.com/lucasb-eyer/go-colorful/blob/master/colors.go
@@ -328,6 +352,8 @@ func (cc *ColorCurve) AddRGBA(r, g, b, a float32, percentage float64) {
 
 // Color returns the Color for the given percentage in the color curve. For example, if you have a curve composed of
 // the colors {0, 0, 0, 0} at 0 and {1, 1, 1, 1} at 1, then calling Curve.Color(0.5) would return {0.5, 0.5, 0.5, 0.5}.
+// Percentages outside of the 0-1 range are clamped. The returned Color is a new Color, so it can be modified freely without
+// altering the curve. If the curve has no points, Color returns nil.
 func (cc *ColorCurve) Color(perc float64) *Color {
 
 	if perc > 1 {
@@ -343,6 +369,7 @@ func (cc *ColorCurve) Color(perc float64) *Color {
 		c = cc.Points[i].Color
 
 		if i >= len(cc.Points)-1 || cc.Points[i].Percentage >= perc {
+			c = c.Clone()
 			break
 		}
 
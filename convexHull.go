@@ -0,0 +1,254 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/kvartborg/vector"
+)
+
+// ConvexHull returns a new Mesh that's the convex hull of the Mesh; that is, the smallest convex shape that contains all of its
+// vertices, like shrink-wrap pulled tight around it. This is mainly useful as a simplified collision shape (see BoundingConvex). If
+// maxVertices is greater than 0, the hull is simplified to have at most that many vertices (though never fewer than 4), keeping the
+// ones that stick out the furthest; otherwise, every vertex on the hull is kept. The hull is flat-shaded with a single MeshPart,
+// and has no meaningful UV values. If the Mesh is flat (and so has no volume), ConvexHull returns nil.
+func (mesh *Mesh) ConvexHull(maxVertices int) *Mesh {
+
+	points, faces := convexHull(mesh.VertexPositions, maxVertices)
+	return newHullMesh(mesh.Name+"_hull", points, faces)
+
+}
+
+// newHullMesh returns a new Mesh with the given name made from the given convex hull points and faces (as returned by
+// convexHull()), or nil if there are no faces.
+func newHullMesh(name string, points []vector.Vector, faces [][3]int) *Mesh {
+
+	if len(faces) == 0 {
+		return nil
+	}
+
+	hull := NewMesh(name)
+	part := hull.AddMeshPart(NewMaterial(name))
+
+	vertices := make([]VertexInfo, 0, len(faces)*3)
+
+	for _, face := range faces {
+		for _, index := range face {
+			p := points[index]
+			vertices = append(vertices, NewVertex(p[0], p[1], p[2], 0, 0))
+		}
+	}
+
+	part.AddTriangles(vertices...)
+
+	hull.UpdateBounds()
+	hull.AutoNormal()
+
+	return hull
+
+}
+
+// hullFace is a triangular face of a convex hull under construction, along with the points that are outside of it (and so still
+// need to be added to the hull).
+type hullFace struct {
+	indices  [3]int
+	normal   vector.Vector
+	distance float64
+	outside  []int
+	furthest int
+	height   float64
+	removed  bool
+}
+
+// convexHull returns the convex hull of the given points using the quickhull algorithm, as the points on the hull and the hull's
+// triangles (as indices into those points, wound counter-clockwise when viewed from outside). If maxVertices is greater than 0, the
+// hull stops growing once it has that many points (though never fewer than 4), having added the furthest-out points first. If the
+// points are flat, convexHull returns the unique points and no faces.
+func convexHull(points []vector.Vector, maxVertices int) ([]vector.Vector, [][3]int) {
+
+	unique := []vector.Vector{}
+	seen := map[[3]float64]bool{}
+
+	for _, p := range points {
+		key := [3]float64{math.Round(p[0] * 1e6), math.Round(p[1] * 1e6), math.Round(p[2] * 1e6)}
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, vector.Vector{p[0], p[1], p[2]})
+		}
+	}
+
+	if len(unique) < 4 {
+		return unique, nil
+	}
+
+	// The tolerance for points counting as outside of faces scales with the size of the point cloud.
+	min := unique[0].Clone()
+	max := unique[0].Clone()
+	for _, p := range unique {
+		for i := 0; i < 3; i++ {
+			min[i] = math.Min(min[i], p[i])
+			max[i] = math.Max(max[i], p[i])
+		}
+	}
+	epsilon := max.Sub(min).Magnitude() * 1e-7
+
+	// The starting tetrahedron is made of points that are as far apart from each other as possible.
+	furthestFrom := func(distance func(p vector.Vector) float64) (int, float64) {
+		best, bestDistance := -1, -1.0
+		for i, p := range unique {
+			if d := distance(p); d > bestDistance {
+				best, bestDistance = i, d
+			}
+		}
+		return best, bestDistance
+	}
+
+	i0, _ := furthestFrom(func(p vector.Vector) float64 { return -p[0] })
+	i1, d1 := furthestFrom(func(p vector.Vector) float64 { return p.Sub(unique[i0]).Magnitude() })
+	line := unique[i1].Sub(unique[i0])
+	i2, d2 := furthestFrom(func(p vector.Vector) float64 { return gjkCross(p.Sub(unique[i0]), line).Magnitude() / d1 })
+	planeNormal := gjkCross(line, unique[i2].Sub(unique[i0])).Unit()
+	i3, d3 := furthestFrom(func(p vector.Vector) float64 { return math.Abs(dot(p.Sub(unique[i0]), planeNormal)) })
+
+	if d1 <= epsilon || d2 <= epsilon || d3 <= epsilon {
+		return unique, nil
+	}
+
+	inside := unique[i0].Add(unique[i1]).Add(unique[i2]).Add(unique[i3]).Scale(0.25)
+
+	newFace := func(i, j, k int) *hullFace {
+		pi, pj, pk := unique[i], unique[j], unique[k]
+		normal := gjkCross(pj.Sub(pi), pk.Sub(pi))
+		if mag := normal.Magnitude(); mag > 0 {
+			normal = normal.Scale(1 / mag)
+		}
+		if dot(normal, pi.Sub(inside)) < 0 {
+			normal = normal.Invert()
+			j, k = k, j
+		}
+		return &hullFace{indices: [3]int{i, j, k}, normal: normal, distance: dot(normal, pi), furthest: -1}
+	}
+
+	// assign adds each of the given points to the outside set of the first of the given faces that it's outside of; points that
+	// aren't outside of any of them are inside the hull, and so are dropped.
+	assign := func(candidates []int, faces []*hullFace) {
+		for _, index := range candidates {
+			p := unique[index]
+			for _, face := range faces {
+				if height := dot(face.normal, p) - face.distance; height > epsilon {
+					face.outside = append(face.outside, index)
+					if height > face.height {
+						face.height = height
+						face.furthest = index
+					}
+					break
+				}
+			}
+		}
+	}
+
+	faces := []*hullFace{newFace(i0, i1, i2), newFace(i0, i1, i3), newFace(i0, i2, i3), newFace(i1, i2, i3)}
+
+	used := map[int]bool{i0: true, i1: true, i2: true, i3: true}
+	candidates := []int{}
+	for i := range unique {
+		if !used[i] {
+			candidates = append(candidates, i)
+		}
+	}
+	assign(candidates, faces)
+
+	for maxVertices < 4 || len(used) < maxVertices {
+
+		// The point that's furthest outside of the hull is added next.
+		var from *hullFace
+		for _, face := range faces {
+			if !face.removed && face.furthest >= 0 && (from == nil || face.height > from.height) {
+				from = face
+			}
+		}
+
+		if from == nil {
+			break
+		}
+
+		eye := from.furthest
+		eyePoint := unique[eye]
+		used[eye] = true
+
+		// The faces the new point can see are removed, and the hole they leave (bordered by the edges that only one removed face has)
+		// is filled with new faces that reach out to the new point.
+		edges := [][2]int{}
+		orphans := []int{}
+
+		for _, face := range faces {
+
+			if face.removed || dot(face.normal, eyePoint)-face.distance <= epsilon {
+				continue
+			}
+
+			face.removed = true
+
+			for _, index := range face.outside {
+				if index != eye {
+					orphans = append(orphans, index)
+				}
+			}
+
+			for e := 0; e < 3; e++ {
+
+				edge := [2]int{face.indices[e], face.indices[(e+1)%3]}
+				shared := false
+
+				for i, existing := range edges {
+					if existing[0] == edge[1] && existing[1] == edge[0] {
+						edges = append(edges[:i], edges[i+1:]...)
+						shared = true
+						break
+					}
+				}
+
+				if !shared {
+					edges = append(edges, edge)
+				}
+
+			}
+
+		}
+
+		added := make([]*hullFace, 0, len(edges))
+		for _, edge := range edges {
+			added = append(added, newFace(edge[0], edge[1], eye))
+		}
+
+		assign(orphans, added)
+
+		remaining := faces[:0]
+		for _, face := range faces {
+			if !face.removed {
+				remaining = append(remaining, face)
+			}
+		}
+		faces = append(remaining, added...)
+
+	}
+
+	// Finally, the hull's points are gathered up and its faces renumbered to match.
+	hullPoints := []vector.Vector{}
+	remap := map[int]int{}
+	hullFaces := make([][3]int, 0, len(faces))
+
+	for _, face := range faces {
+		var indices [3]int
+		for i, index := range face.indices {
+			if _, exists := remap[index]; !exists {
+				remap[index] = len(hullPoints)
+				hullPoints = append(hullPoints, unique[index])
+			}
+			indices[i] = remap[index]
+		}
+		hullFaces = append(hullFaces, indices)
+	}
+
+	return hullPoints, hullFaces
+
+}
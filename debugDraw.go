@@ -0,0 +1,207 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/kvartborg/vector"
+)
+
+// The DebugDraw functions draw gizmos (lines and points visualizing things like bounds, normals, bones, and axes) directly onto the
+// Camera's color texture, using the Camera's current view and projection; they should be called after rendering (and before drawing
+// the Camera's ColorTexture() to the screen). Unlike the DrawDebug functions, which draw onto a given screen image and so don't
+// handle geometry behind the Camera, lines that pass behind the Camera are clipped against its near plane.
+
+// DebugDrawBoundingSphere draws a Model's BoundingSphere as three circles (one around each world axis) in the color given.
+func (camera *Camera) DebugDrawBoundingSphere(model *Model, color *Color) {
+
+	vpMatrix := camera.ViewMatrix().Mult(camera.Projection())
+
+	center := model.BoundingSphere.WorldPosition()
+	radius := model.BoundingSphere.WorldRadius()
+
+	stepCount := 32
+
+	for axis := 0; axis < 3; axis++ {
+
+		for i := 0; i < stepCount; i++ {
+
+			start := camera.debugCirclePoint(center, radius, axis, float64(i)/float64(stepCount))
+			end := camera.debugCirclePoint(center, radius, axis, float64(i+1)/float64(stepCount))
+			camera.debugDrawLine(vpMatrix, start, end, color)
+
+		}
+
+	}
+
+}
+
+// debugCirclePoint returns the point the given percentage of the way around a circle with the given center and radius, where the
+// circle is perpendicular to the given axis (0 for X, 1 for Y, or 2 for Z).
+func (camera *Camera) debugCirclePoint(center vector.Vector, radius float64, axis int, percentage float64) vector.Vector {
+
+	angle := percentage * math.Pi * 2
+
+	point := center.Clone()
+	point[(axis+1)%3] += math.Cos(angle) * radius
+	point[(axis+2)%3] += math.Sin(angle) * radius
+
+	return point
+
+}
+
+// DebugDrawNormals draws the normals of a Model's triangles as lines of the given length (in world units), extending out from each
+// triangle's center, in the color given. The normals are drawn in world space, so they take the Model's transform into account.
+func (camera *Camera) DebugDrawNormals(model *Model, length float64, color *Color) {
+
+	if model.Mesh == nil {
+		return
+	}
+
+	vpMatrix := camera.ViewMatrix().Mult(camera.Projection())
+
+	transform := model.Transform()
+
+	// Normals are transformed by the inverse transpose of the transform, so they remain perpendicular to their triangles
+	// even if the Model is non-uniformly scaled.
+	normalMatrix := transform.Inverted().Transposed()
+
+	for _, tri := range model.Mesh.Triangles {
+
+		center := transform.MultVec(tri.Center)
+
+		normal := vector.Vector{
+			normalMatrix[0][0]*tri.Normal[0] + normalMatrix[1][0]*tri.Normal[1] + normalMatrix[2][0]*tri.Normal[2],
+			normalMatrix[0][1]*tri.Normal[0] + normalMatrix[1][1]*tri.Normal[1] + normalMatrix[2][1]*tri.Normal[2],
+			normalMatrix[0][2]*tri.Normal[0] + normalMatrix[1][2]*tri.Normal[1] + normalMatrix[2][2]*tri.Normal[2],
+		}.Unit()
+
+		camera.debugDrawLine(vpMatrix, center, center.Add(normal.Scale(length)), color)
+
+	}
+
+}
+
+// DebugDrawBones draws the bones of the armature starting at the given Node as lines connecting each bone to its child bones, with
+// a point at each bone's position, in the color given.
+func (camera *Camera) DebugDrawBones(armatureRoot INode, color *Color) {
+
+	vpMatrix := camera.ViewMatrix().Mult(camera.Projection())
+
+	for _, node := range append(NodeFilter{armatureRoot}, armatureRoot.ChildrenRecursive()...) {
+
+		if !node.IsBone() {
+			continue
+		}
+
+		position := node.WorldPosition()
+
+		camera.debugDrawPoint(vpMatrix, position, 3, color)
+
+		for _, child := range node.Children() {
+			if child.IsBone() {
+				camera.debugDrawLine(vpMatrix, position, child.WorldPosition(), color)
+			}
+		}
+
+	}
+
+}
+
+// DebugDrawAxes draws the local axes of the given Node as lines of the given length (in world units) extending out from its world
+// position - +X in red, +Y in green, and +Z in blue.
+func (camera *Camera) DebugDrawAxes(node INode, length float64) {
+
+	vpMatrix := camera.ViewMatrix().Mult(camera.Projection())
+
+	transform := node.Transform()
+	position := node.WorldPosition()
+
+	camera.debugDrawLine(vpMatrix, position, position.Add(transform.Right().Scale(length)), NewColor(1, 0, 0, 1))
+	camera.debugDrawLine(vpMatrix, position, position.Add(transform.Up().Scale(length)), NewColor(0, 1, 0, 1))
+	camera.debugDrawLine(vpMatrix, position, position.Add(transform.Forward().Scale(length)), NewColor(0, 0, 1, 1))
+
+}
+
+// DebugDrawNavMesh draws the edges of a NavMesh's NavPolygons in the color given, along with a point at the center of each
+// NavPortal connecting them.
+func (camera *Camera) DebugDrawNavMesh(navMesh *NavMesh, color *Color) {
+
+	vpMatrix := camera.ViewMatrix().Mult(camera.Projection())
+
+	for _, polygon := range navMesh.Polygons {
+
+		for i, start := range polygon.Vertices {
+			camera.debugDrawLine(vpMatrix, start, polygon.Vertices[(i+1)%len(polygon.Vertices)], color)
+		}
+
+		for _, portal := range polygon.Portals {
+			camera.debugDrawPoint(vpMatrix, portal.Start.Add(portal.End).Scale(0.5), 2, color)
+		}
+
+	}
+
+}
+
+// debugDrawLine draws a line between two world positions onto the Camera's color texture, clipping the line against the near plane
+// so that lines that pass behind the Camera are drawn properly.
+func (camera *Camera) debugDrawLine(vpMatrix Matrix4, start, end vector.Vector, color *Color) {
+
+	s, e, visible := camera.debugLineToScreen(vpMatrix, start, end)
+
+	if !visible {
+		return
+	}
+
+	ebitenutil.DrawLine(camera.resultColorTexture, s[0], s[1], e[0], e[1], color.ToRGBA64())
+
+	camera.postProcessDirty = true
+
+}
+
+// debugLineToScreen returns the screen positions of the ends of a line between two world positions, clipping the line against the
+// near plane. If the line is entirely behind the near plane, visible is false.
+func (camera *Camera) debugLineToScreen(vpMatrix Matrix4, start, end vector.Vector) (screenStart, screenEnd vector.Vector, visible bool) {
+
+	clipStart := vpMatrix.MultVecW(start)
+	clipEnd := vpMatrix.MultVecW(end)
+
+	if camera.Perspective {
+
+		near := camera.Near
+
+		// The W component of a clip-space position grows with its depth from the Camera; lines are clipped where it reaches the near
+		// value, as triangles are when they're rendered.
+		if clipStart[3] < near && clipEnd[3] < near {
+			return nil, nil, false
+		}
+
+		if clipStart[3] < near {
+			clipStart = clipStart.Add(clipEnd.Sub(clipStart).Scale((near - clipStart[3]) / (clipEnd[3] - clipStart[3])))
+		} else if clipEnd[3] < near {
+			clipEnd = clipEnd.Add(clipStart.Sub(clipEnd).Scale((near - clipEnd[3]) / (clipStart[3] - clipEnd[3])))
+		}
+
+	}
+
+	return camera.ClipToScreen(clipStart), camera.ClipToScreen(clipEnd), true
+
+}
+
+// debugDrawPoint draws a point (a small filled square with the given radius in pixels) at a world position onto the Camera's color
+// texture, as long as the position is in front of the Camera.
+func (camera *Camera) debugDrawPoint(vpMatrix Matrix4, position vector.Vector, radius float64, color *Color) {
+
+	clip := vpMatrix.MultVecW(position)
+
+	if camera.Perspective && clip[3] < camera.Near {
+		return
+	}
+
+	p := camera.ClipToScreen(clip)
+
+	ebitenutil.DrawRect(camera.resultColorTexture, p[0]-radius, p[1]-radius, radius*2, radius*2, color.ToRGBA64())
+
+	camera.postProcessDirty = true
+
+}
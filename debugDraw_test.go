@@ -0,0 +1,74 @@
+package tetra3d
+
+import (
+	"testing"
+
+	"github.com/kvartborg/vector"
+)
+
+func TestDebugDrawLines(t *testing.T) {
+
+	camera := NewCamera(64, 64)
+	camera.Move(0, 0, 5)
+
+	vpMatrix := camera.ViewMatrix().Mult(camera.Projection())
+
+	// A line along +X from the origin, which the Camera looks straight at, runs from the center of the screen to the right.
+	start, end, visible := camera.debugLineToScreen(vpMatrix, vector.Vector{0, 0, 0}, vector.Vector{1, 0, 0})
+
+	if !visible {
+		t.Fatalf("a line in front of the Camera should be visible")
+	}
+
+	if !vectorsEqual(start, camera.WorldToScreen(vector.Vector{0, 0, 0})) || !vectorsEqual(end, camera.WorldToScreen(vector.Vector{1, 0, 0})) {
+		t.Fatalf("expected the line to be drawn from %v to %v, got %v to %v", camera.WorldToScreen(vector.Vector{0, 0, 0}), camera.WorldToScreen(vector.Vector{1, 0, 0}), start, end)
+	}
+
+	if start[0] != 32 || start[1] != 32 || end[0] <= start[0] || end[1] != start[1] {
+		t.Fatalf("expected the line to run from the center of the screen to the right, got %v to %v", start, end)
+	}
+
+	// A line reaching from in front of the Camera to behind it is cut off where it crosses the near plane (where its clip-space W
+	// component reaches the Camera's near value), rather than its end being flipped across the screen.
+	lineStart, lineEnd := vector.Vector{1, 0, 0}, vector.Vector{1, 0, 10}
+	startW, endW := vpMatrix.MultVecW(lineStart)[3], vpMatrix.MultVecW(lineEnd)[3]
+	clippedEnd := lineStart.Add(lineEnd.Sub(lineStart).Scale((camera.Near - startW) / (endW - startW)))
+
+	if clippedEnd[2] <= 0 || clippedEnd[2] >= 5 {
+		t.Fatalf("expected the line to cross the near plane between the origin and the Camera, but it crosses at %v", clippedEnd)
+	}
+
+	start, end, visible = camera.debugLineToScreen(vpMatrix, lineStart, lineEnd)
+
+	if !visible {
+		t.Fatalf("a line passing behind the Camera should still be visible")
+	}
+
+	if expected := camera.WorldToScreen(clippedEnd); end.Sub(expected).Magnitude() > 1e-6 {
+		t.Fatalf("expected the line to be clipped at the near plane, ending at %v; got %v", expected, end)
+	}
+
+	if end[0] <= start[0] {
+		t.Fatalf("expected the clipped line to run off of the right of the screen, got %v to %v", start, end)
+	}
+
+	// The same goes for the start of the line.
+	if reversedStart, _, _ := camera.debugLineToScreen(vpMatrix, lineEnd, lineStart); reversedStart.Sub(end).Magnitude() > 1e-6 {
+		t.Fatalf("expected the line to be clipped at the near plane at either end, got %v and %v", end, reversedStart)
+	}
+
+	if _, _, visible := camera.debugLineToScreen(vpMatrix, vector.Vector{0, 0, 6}, vector.Vector{1, 0, 10}); visible {
+		t.Fatalf("a line entirely behind the Camera shouldn't be visible")
+	}
+
+	// Drawing the axes of a Node at the origin draws its +X axis in red.
+	camera.Clear()
+	camera.DebugDrawAxes(NewNode("axes"), 1)
+
+	mid := camera.WorldToScreen(vector.Vector{0.5, 0, 0})
+
+	if c := readPixel(t, camera.ColorTexture(), int(mid[0]), int(mid[1])); c.R < 200 || c.G > 50 || c.B > 50 {
+		t.Fatalf("expected the Node's X axis to be drawn in red, got %v", c)
+	}
+
+}
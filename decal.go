@@ -0,0 +1,219 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/kvartborg/vector"
+)
+
+// Decal projects a texture onto nearby geometry, like bullet holes, blood splats, or road markings. The texture is projected along the
+// Decal's Model's local -Z axis (so its +Z axis should face away from the surfaces to project onto) through a box of the Decal's Width
+// (along its local X axis), Height (along its local Y axis), and Depth (along its local Z axis), centered on the Model; the top of the
+// texture faces the Model's local +Y axis. The triangles of the target Models are clipped against the box to create a small overlay
+// Mesh for the Decal's Model that hugs their surfaces, with UV values mapping the texture across the box.
+// To use it, create a Decal, add the Decal's Model to your scene, position and rotate the Model, and then call Decal.Project() with the
+// Nodes to project onto. The Decal doesn't follow the target Models, so call Decal.Project() again if they (or the Decal) move.
+type Decal struct {
+	Model *Model // The Model used to display the Decal. Add this to your scene's hierarchy to render it.
+
+	Width  float64 // The width of the projection box, along the Model's local X axis.
+	Height float64 // The height of the projection box, along the Model's local Y axis.
+	Depth  float64 // The depth of the projection box, along the Model's local Z axis (the direction the Decal projects in).
+
+	Offset float64 // How far the Decal is lifted from the surfaces it's projected onto along their normals to prevent z-fighting. Defaults to 0.01.
+	// NormalCutoff is the minimum dot product between the normals of triangles and the Decal's local +Z axis for the triangles to be
+	// projected onto; this stops the Decal from stretching across surfaces that are perpendicular to it (like the sides of a step).
+	// Triangles facing away from the Decal are never projected onto. Defaults to 0.1.
+	NormalCutoff float64
+}
+
+// decalVertex is a vertex of a polygon being clipped against a Decal's projection box, in the Decal's local space.
+type decalVertex struct {
+	position, normal vector.Vector
+}
+
+// NewDecal creates a new Decal that projects the texture given through a box of the given width, height, and depth. The Decal's
+// Material uses the texture, clamping it to its edges, and is transparent.
+func NewDecal(name string, texture *ebiten.Image, width, height, depth float64) *Decal {
+
+	mat := NewMaterial(name)
+	mat.Texture = texture
+	mat.TextureWrapMode = ebiten.AddressClampToZero
+	mat.TransparencyMode = TransparencyModeTransparent
+
+	decal := &Decal{
+		Model:        NewModel(NewMesh(name), name),
+		Width:        width,
+		Height:       height,
+		Depth:        depth,
+		Offset:       0.01,
+		NormalCutoff: 0.1,
+	}
+
+	decal.Model.Mesh.AddMeshPart(mat)
+
+	return decal
+
+}
+
+// Material returns the Material used to display the Decal.
+func (decal *Decal) Material() *Material {
+	return decal.Model.Mesh.MeshParts[0].Material
+}
+
+// Project projects the Decal onto the Models in the hierarchies of the Nodes provided (including the Nodes themselves), replacing the
+// Decal's Mesh with the triangles of those Models that fall within the Decal's projection box. Skinned Models are projected onto using
+// their rest poses.
+func (decal *Decal) Project(targets ...INode) {
+
+	models := []*Model{}
+
+	for _, node := range targets {
+		if model, ok := node.(*Model); ok {
+			models = append(models, model)
+		}
+		models = append(models, node.ChildrenRecursive().Models()...)
+	}
+
+	decalTransform := decal.Model.Transform()
+	decalInverse := decalTransform.Inverted()
+	decalRotationInverse := decal.Model.WorldRotation().Inverted()
+
+	// The bounding sphere of the projection box, used to skip Models that can't be touched by it.
+	_, scale, _ := decalTransform.Decompose()
+	boxCenter := decal.Model.WorldPosition()
+	boxRadius := math.Sqrt(decal.Width*decal.Width+decal.Height*decal.Height+decal.Depth*decal.Depth) / 2
+	boxRadius *= math.Max(math.Abs(scale[0]), math.Max(math.Abs(scale[1]), math.Abs(scale[2])))
+
+	verts := []VertexInfo{}
+	maxVertices := ebiten.MaxIndicesNum - ebiten.MaxIndicesNum%3
+
+	for _, model := range models {
+
+		if model == decal.Model || model.Mesh == nil {
+			continue
+		}
+
+		transform := model.Transform()
+		rotation := model.WorldRotation()
+
+		if fastVectorDistanceSquared(model.BoundingSphere.WorldPosition(), boxCenter) > math.Pow(model.BoundingSphere.WorldRadius()+boxRadius, 2) {
+			continue
+		}
+
+		positions, normals := model.morphedVertices()
+
+		for _, tri := range model.Mesh.Triangles {
+
+			polygon := make([]decalVertex, 3, 9)
+
+			for i := 0; i < 3; i++ {
+				polygon[i] = decalVertex{
+					position: decalInverse.MultVec(transform.MultVec(positions[tri.ID*3+i])),
+					normal:   decalRotationInverse.MultVec(rotation.MultVec(normals[tri.ID*3+i])),
+				}
+			}
+
+			// The triangle's face normal is used to tell if it faces the Decal (this also skips degenerate triangles, whose normals are NaN).
+			facing := calculateNormal(polygon[0].position, polygon[1].position, polygon[2].position)[2]
+			if !(facing > 0 && facing >= decal.NormalCutoff) {
+				continue
+			}
+
+			polygon = decal.clip(polygon)
+
+			if len(polygon) < 3 {
+				continue
+			}
+
+			// The clipped polygon is convex, so it can be split into a fan of triangles.
+			for i := 1; i < len(polygon)-1; i++ {
+
+				if len(verts)+3 > maxVertices {
+					break
+				}
+
+				for _, v := range []decalVertex{polygon[0], polygon[i], polygon[i+1]} {
+					normal := v.normal
+					if normal.Magnitude() > 0 {
+						normal = normal.Unit()
+					}
+					position := v.position.Add(normal.Scale(decal.Offset))
+					vert := NewVertex(position[0], position[1], position[2], v.position[0]/decal.Width+0.5, v.position[1]/decal.Height+0.5)
+					vert.NormalX, vert.NormalY, vert.NormalZ = normal[0], normal[1], normal[2]
+					verts = append(verts, vert)
+				}
+
+			}
+
+		}
+
+	}
+
+	mesh := NewMesh(decal.Model.Mesh.Name)
+	part := mesh.AddMeshPart(decal.Material())
+
+	if len(verts) > 0 {
+		part.AddTriangles(verts...)
+	}
+
+	mesh.UpdateBounds()
+
+	decal.Model.Mesh = mesh
+	decal.Model.skinVectorPool = NewVectorPool(len(mesh.VertexPositions)*2, true)
+	decal.Model.TransformUpdate()
+
+}
+
+// clip returns the given polygon clipped against the Decal's projection box, using the Sutherland-Hodgman algorithm.
+func (decal *Decal) clip(polygon []decalVertex) []decalVertex {
+
+	halfSize := [3]float64{decal.Width / 2, decal.Height / 2, decal.Depth / 2}
+
+	for axis := 0; axis < 3; axis++ {
+
+		for _, side := range []float64{-1, 1} {
+
+			if len(polygon) == 0 {
+				return polygon
+			}
+
+			// inside returns how far inside of the plane the position is.
+			inside := func(position vector.Vector) float64 {
+				return halfSize[axis] - position[axis]*side
+			}
+
+			clipped := make([]decalVertex, 0, len(polygon)+1)
+
+			for i := range polygon {
+
+				current := polygon[i]
+				next := polygon[(i+1)%len(polygon)]
+
+				currentInside, nextInside := inside(current.position), inside(next.position)
+
+				if currentInside >= 0 {
+					clipped = append(clipped, current)
+				}
+
+				// The edge crosses the plane, so a new vertex is created where it does.
+				if (currentInside >= 0) != (nextInside >= 0) {
+					t := currentInside / (currentInside - nextInside)
+					clipped = append(clipped, decalVertex{
+						position: current.position.Add(next.position.Sub(current.position).Scale(t)),
+						normal:   current.normal.Add(next.normal.Sub(current.normal).Scale(t)),
+					})
+				}
+
+			}
+
+			polygon = clipped
+
+		}
+
+	}
+
+	return polygon
+
+}
@@ -0,0 +1,55 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestDecalProject(t *testing.T) {
+
+	ground := NewModel(NewPlane(), "ground")
+	ground.SetLocalScale(10, 1, 10)
+
+	// The Decal projects along its local -Z axis, so it's rotated to point its +Z axis up, away from the ground.
+	decal := NewDecal("splat", ebiten.NewImage(4, 4), 1, 2, 1)
+	decal.Model.SetLocalRotation(NewMatrix4Rotate(1, 0, 0, -math.Pi/2))
+	decal.Offset = 0
+
+	decal.Project(ground)
+
+	mesh := decal.Model.Mesh
+
+	if len(mesh.Triangles) == 0 {
+		t.Fatalf("expected the decal to be projected onto the ground")
+	}
+
+	area := 0.0
+
+	for _, tri := range mesh.Triangles {
+		v0, v1, v2 := mesh.VertexPositions[tri.ID*3], mesh.VertexPositions[tri.ID*3+1], mesh.VertexPositions[tri.ID*3+2]
+		cross, _ := v1.Sub(v0).Cross(v2.Sub(v0))
+		area += cross.Magnitude() / 2
+	}
+
+	// The ground is clipped down to the decal's 1x2 projection box.
+	if math.Abs(area-2) > 0.0001 {
+		t.Errorf("expected the decal's triangles to cover an area of 2, got %f", area)
+	}
+
+	for i := 0; i < mesh.VertexCount; i++ {
+		if uv := mesh.VertexUVs[i]; uv[0] < -0.0001 || uv[0] > 1.0001 || uv[1] < -0.0001 || uv[1] > 1.0001 {
+			t.Errorf("vertex %d: expected UV values within 0 to 1, got %v", i, uv)
+		}
+	}
+
+	// Surfaces facing away from the decal aren't projected onto.
+	ground.SetLocalRotation(NewMatrix4Rotate(1, 0, 0, math.Pi))
+	decal.Project(ground)
+
+	if count := len(decal.Model.Mesh.Triangles); count != 0 {
+		t.Errorf("expected the decal not to be projected onto the underside of the ground, got %d triangles", count)
+	}
+
+}
@@ -0,0 +1,131 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/kvartborg/vector"
+)
+
+// The depth of field shader is a 9-tap separable blur whose spacing is scaled for each pixel by how far out of focus it is, based
+// on the Camera's depth texture. Pixels are in focus between the NearSharp and FarSharp depths, and fully blurred at the NearBlur
+// and FarBlur depths (and beyond); pixels that nothing was rendered to count as being at the far plane.
+var depthOfFieldShaderText = []byte(
+	`package main
+
+	//tetra3d:include depth
+
+	var Direction vec2
+	var NearBlur float
+	var NearSharp float
+	var FarSharp float
+	var FarBlur float
+
+	func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+
+		depthValue := imageSrc1UnsafeAt(texCoord)
+
+		depth := 1.0
+		if depthValue.a > 0 {
+			depth = decodeDepth(depthValue)
+		}
+
+		blur := 0.0
+		if depth < NearSharp {
+			blur = clamp((NearSharp - depth) / max(NearSharp - NearBlur, 0.00001), 0, 1)
+		} else if depth > FarSharp {
+			blur = clamp((depth - FarSharp) / max(FarBlur - FarSharp, 0.00001), 0, 1)
+		}
+
+		if blur <= 0 {
+			return imageSrc0UnsafeAt(texCoord)
+		}
+
+		offset := Direction * blur / imageSrcTextureSize()
+
+		sum := imageSrc0At(texCoord) * 0.227027
+		sum += (imageSrc0At(texCoord + offset) + imageSrc0At(texCoord - offset)) * 0.1945946
+		sum += (imageSrc0At(texCoord + offset * 2) + imageSrc0At(texCoord - offset * 2)) * 0.1216216
+		sum += (imageSrc0At(texCoord + offset * 3) + imageSrc0At(texCoord - offset * 3)) * 0.054054
+		sum += (imageSrc0At(texCoord + offset * 4) + imageSrc0At(texCoord - offset * 4)) * 0.016216
+
+		return sum
+
+	}
+	`,
+)
+
+// depthOfFieldActive returns if depth of field should be applied to the Camera's color texture.
+func (camera *Camera) depthOfFieldActive() bool {
+	return camera.DepthOfFieldEnabled && camera.RenderDepth && camera.DepthOfFieldRadius > 0
+}
+
+// prepareDepthOfField creates the shader and texture used for depth of field if they haven't been created yet.
+func (camera *Camera) prepareDepthOfField() {
+
+	if camera.depthOfFieldShader == nil {
+
+		src, err := PreprocessShader(depthOfFieldShaderText)
+		if err != nil {
+			panic(err)
+		}
+
+		camera.depthOfFieldShader, err = ebiten.NewShader(src)
+		if err != nil {
+			panic(err)
+		}
+
+	}
+
+	if camera.depthOfFieldTexture == nil {
+		camera.depthOfFieldTexture = ebiten.NewImage(camera.resultColorTexture.Size())
+	}
+
+}
+
+// depthAtDistance returns the value that the Camera writes to its depth texture for something at the given distance in front of it.
+func (camera *Camera) depthAtDistance(distance float64) float32 {
+	depth := camera.Projection().MultVecW(vector.Vector{0, 0, -distance})[2] / (camera.Far + 1)
+	return float32(math.Max(math.Min(depth, 1), 0))
+}
+
+// applyDepthOfField blurs the given texture according to the Camera's depth texture and depth of field settings, storing the result
+// in the Camera's depth of field texture.
+func (camera *Camera) applyDepthOfField(source *ebiten.Image) {
+
+	camera.prepareDepthOfField()
+
+	w, h := camera.resultColorTexture.Size()
+
+	focus := camera.DepthOfFieldFocusDistance
+	sharp := camera.DepthOfFieldFocusRange / 2
+	falloff := math.Max(camera.DepthOfFieldFalloff, 0)
+
+	uniforms := map[string]interface{}{
+		"NearBlur":  camera.depthAtDistance(focus - sharp - falloff),
+		"NearSharp": camera.depthAtDistance(focus - sharp),
+		"FarSharp":  camera.depthAtDistance(focus + sharp),
+		"FarBlur":   camera.depthAtDistance(focus + sharp + falloff),
+	}
+
+	// The blur has 4 taps on each side, so the taps are spaced to reach the blur radius.
+	spacing := float32(camera.DepthOfFieldRadius / 4)
+
+	uniforms["Direction"] = []float32{spacing, 0}
+
+	// The bloom intermediate textures are free to use, as bloom is applied afterwards.
+	camera.bloomIntermediateA.Clear()
+	camera.bloomIntermediateA.DrawRectShader(w, h, camera.depthOfFieldShader, &ebiten.DrawRectShaderOptions{
+		Images:   [4]*ebiten.Image{source, camera.resultDepthTexture},
+		Uniforms: uniforms,
+	})
+
+	uniforms["Direction"] = []float32{0, spacing}
+
+	camera.depthOfFieldTexture.Clear()
+	camera.depthOfFieldTexture.DrawRectShader(w, h, camera.depthOfFieldShader, &ebiten.DrawRectShaderOptions{
+		Images:   [4]*ebiten.Image{camera.bloomIntermediateA, camera.resultDepthTexture},
+		Uniforms: uniforms,
+	})
+
+}
@@ -0,0 +1,78 @@
+package tetra3d
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestDepthOfField(t *testing.T) {
+
+	scene := NewScene("depth of field")
+	camera := NewCamera(32, 32)
+	scene.Root.AddChildren(camera)
+
+	cube := NewModel(NewCube(), "cube")
+	cube.Move(0, 0, -10)
+	scene.Root.AddChildren(cube)
+
+	camera.DepthOfFieldEnabled = true
+
+	camera.Clear()
+	camera.RenderNodes(scene, scene.Root)
+
+	if camera.ColorTexture() != camera.depthOfFieldTexture {
+		t.Errorf("expected the color texture to have depth of field applied")
+	}
+
+	camera.RenderDepth = false
+
+	if camera.ColorTexture() != camera.resultColorTexture {
+		t.Errorf("expected depth of field to be skipped when the camera isn't rendering depth")
+	}
+
+	if near, far := camera.depthAtDistance(5), camera.depthAtDistance(20); near <= 0 || near >= far {
+		t.Errorf("expected depth to increase with distance, got %f at 5 units and %f at 20 units", near, far)
+	}
+
+	if clone := camera.Clone().(*Camera); !clone.DepthOfFieldEnabled || clone.DepthOfFieldFocusDistance != camera.DepthOfFieldFocusDistance {
+		t.Errorf("expected cloned cameras to copy the depth of field settings")
+	}
+
+	// The cube is shadeless and white, so unblurred, its edges are hard, with every pixel being either fully covered or empty.
+	cube.Mesh.MeshParts[0].Material.Shadeless = true
+	camera.RenderDepth = true
+
+	// Things are in focus from 3 to 7 units away, and fully blurred beyond 12 units.
+	camera.DepthOfFieldFocusDistance = 5
+	camera.DepthOfFieldFocusRange = 4
+	camera.DepthOfFieldFalloff = 5
+
+	render := func(distance float64, depthOfField bool) []color.RGBA {
+		cube.SetLocalPosition(0, 0, -distance)
+		camera.DepthOfFieldEnabled = depthOfField
+		camera.Clear()
+		camera.RenderNodes(scene, scene.Root)
+		return readPixels(t, camera.ColorTexture())
+	}
+
+	// The cube is in focus at the focus distance, so it's drawn as it is without depth of field (though the background around it, which
+	// counts as being far away, is blurred).
+	sharp := render(camera.DepthOfFieldFocusDistance, false)
+	focused := render(camera.DepthOfFieldFocusDistance, true)
+
+	for i, c := range sharp {
+		if c.A == 255 && focused[i] != c {
+			t.Fatalf("expected the cube to be in focus, but pixel %d, %d went from %v to %v", i%32, i/32, c, focused[i])
+		}
+	}
+
+	// Far beyond the focus range, the cube is blurred.
+	if count := countBlendedPixels(render(15, false)); count > 0 {
+		t.Fatalf("expected the cube's edges to be hard without depth of field, but %d pixels are blended", count)
+	}
+
+	if countBlendedPixels(render(15, true)) == 0 {
+		t.Errorf("expected the cube to be blurred when it's out of focus")
+	}
+
+}
@@ -0,0 +1,107 @@
+package tetra3d
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// dynamicResolutionStep is how much dynamic resolution changes the Camera's resolution scale by at a time.
+	dynamicResolutionStep = 0.1
+	// dynamicResolutionCooldown is how many frames dynamic resolution waits after changing the resolution before lowering it again,
+	// so that the frame time has a chance to settle.
+	dynamicResolutionCooldown = 10
+	// dynamicResolutionRaiseFrames is how many frames in a row have to be well under budget before the resolution is raised; it's
+	// raised more cautiously than it's lowered, so that it doesn't flip back and forth between two resolutions.
+	dynamicResolutionRaiseFrames = 60
+)
+
+// FullSize returns the size the Camera was created or last resized at. Unless the Camera's lowered its resolution with dynamic
+// resolution (see Camera.DynamicResolution), this is the same as Camera.Size().
+func (camera *Camera) FullSize() (w, h int) {
+	return camera.fullWidth, camera.fullHeight
+}
+
+// ResolutionScale returns the resolution the Camera's currently rendering at, relative to its full size (see Camera.FullSize()).
+// This is 1 unless the Camera's lowered its resolution with dynamic resolution (see Camera.DynamicResolution).
+func (camera *Camera) ResolutionScale() float64 {
+	return camera.resolutionScale
+}
+
+// scaledSize returns the Camera's full size, scaled by its resolution scale.
+func (camera *Camera) scaledSize() (int, int) {
+	w := int(math.Max(math.Round(float64(camera.fullWidth)*camera.resolutionScale), 1))
+	h := int(math.Max(math.Round(float64(camera.fullHeight)*camera.resolutionScale), 1))
+	return w, h
+}
+
+// updateDynamicResolution measures the time since the Camera was last cleared, and lowers or raises the Camera's resolution (resizing
+// its textures) if it's over or well under the Camera's DynamicResolutionBudget. If dynamic resolution is off, the Camera is
+// returned to its full size.
+func (camera *Camera) updateDynamicResolution() {
+
+	now := time.Now()
+	last := camera.lastClearTime
+	camera.lastClearTime = now
+
+	scale := 1.0
+
+	if camera.DynamicResolution && !last.IsZero() {
+
+		frameTime := now.Sub(last)
+
+		if camera.averageFrameTime == 0 {
+			camera.averageFrameTime = frameTime
+		} else {
+			camera.averageFrameTime += (frameTime - camera.averageFrameTime) / 8
+		}
+
+		scale = camera.resolutionScale
+		budget := camera.DynamicResolutionBudget
+
+		if camera.averageFrameTime > budget {
+
+			camera.resolutionHeadroom = 0
+
+			if camera.resolutionCooldown > 0 {
+				camera.resolutionCooldown--
+			} else {
+				scale -= dynamicResolutionStep
+			}
+
+		} else {
+
+			if camera.resolutionCooldown > 0 {
+				camera.resolutionCooldown--
+			}
+
+			if camera.averageFrameTime < budget*3/4 {
+				camera.resolutionHeadroom++
+			} else {
+				camera.resolutionHeadroom = 0
+			}
+
+			if camera.resolutionHeadroom >= dynamicResolutionRaiseFrames {
+				camera.resolutionHeadroom = 0
+				scale += dynamicResolutionStep
+			}
+
+		}
+
+		// The scale is kept to multiples of the step, so that steps taken down and back up land on the same sizes.
+		scale = math.Round(scale/dynamicResolutionStep) * dynamicResolutionStep
+		scale = math.Min(math.Max(scale, math.Max(camera.DynamicResolutionMinScale, dynamicResolutionStep)), 1)
+
+	} else {
+		camera.averageFrameTime = 0
+		camera.resolutionHeadroom = 0
+		camera.resolutionCooldown = 0
+	}
+
+	if scale != camera.resolutionScale {
+		camera.resolutionScale = scale
+		camera.resolutionCooldown = dynamicResolutionCooldown
+		camera.resizeTextures(camera.scaledSize())
+	}
+
+}
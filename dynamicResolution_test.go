@@ -0,0 +1,66 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCameraDynamicResolution(t *testing.T) {
+
+	camera := NewCamera(64, 48)
+	camera.DynamicResolution = true
+	camera.DynamicResolutionBudget = time.Millisecond
+
+	// clear clears the Camera as though the given amount of time had passed since it was last cleared.
+	clear := func(frameTime time.Duration) {
+		camera.lastClearTime = time.Now().Add(-frameTime)
+		camera.Clear()
+	}
+
+	for i := 0; i < 100; i++ {
+		clear(time.Millisecond * 50)
+	}
+
+	if scale := camera.ResolutionScale(); math.Abs(scale-0.5) > 1e-9 {
+		t.Fatalf("resolution scale should have dropped to the minimum of 0.5, but is %f", scale)
+	}
+
+	if w, h := camera.Size(); w != 32 || h != 24 {
+		t.Fatalf("camera should be rendering at 32x24, but is %dx%d", w, h)
+	}
+
+	if w, h := camera.FullSize(); w != 64 || h != 48 {
+		t.Fatalf("camera's full size should still be 64x48, but is %dx%d", w, h)
+	}
+
+	camera.Resize(80, 60)
+
+	if w, h := camera.Size(); w != 40 || h != 30 {
+		t.Fatalf("resized camera should be rendering at 40x30, but is %dx%d", w, h)
+	}
+
+	camera.DynamicResolutionBudget = time.Hour
+
+	for i := 0; i < 1000 && camera.ResolutionScale() < 1; i++ {
+		clear(time.Millisecond)
+	}
+
+	if w, h := camera.Size(); w != 80 || h != 60 {
+		t.Fatalf("camera should have returned to its full size of 80x60, but is %dx%d", w, h)
+	}
+
+	camera.DynamicResolutionBudget = time.Millisecond
+
+	for i := 0; i < 20; i++ {
+		clear(time.Millisecond * 50)
+	}
+
+	camera.DynamicResolution = false
+	camera.Clear()
+
+	if w, h := camera.Size(); w != 80 || h != 60 || camera.ResolutionScale() != 1 {
+		t.Fatalf("turning off dynamic resolution should return the camera to its full size of 80x60, but it's %dx%d", w, h)
+	}
+
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/kvartborg/vector"
+	"github.com/solarlune/tetra3d"
+	"github.com/solarlune/tetra3d/colors"
+	"golang.org/x/image/font/basicfont"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+type Game struct {
+	Width, Height int
+	Scene         *tetra3d.Scene
+	Camera        *tetra3d.Camera
+	Time          float64
+	DrawDebugText bool
+}
+
+func NewGame() *Game {
+	game := &Game{
+		Width:         796,
+		Height:        448,
+		DrawDebugText: true,
+	}
+
+	game.Init()
+
+	return game
+}
+
+// In this example, we create a wall of square tiles, where each tile is made of two triangles that share an edge. Each triangle is
+// its own Model, positioned at its own center, so the vertices along the shared edges are transformed separately for each triangle.
+// Due to floating-point error, these vertices can land at very slightly different positions onscreen, which shows up as flickering
+// pixels of the background along the edges as the wall moves. Turning on Camera.SubpixelCorrection snaps the vertices to a subpixel
+// grid, closing the gaps.
+
+func (g *Game) Init() {
+
+	g.Scene = tetra3d.NewScene("subpixel correction example")
+	g.Scene.World.LightingOn = false
+
+	wall := tetra3d.NewNode("Wall")
+	g.Scene.Root.AddChildren(wall)
+
+	tileSize := 0.37
+
+	for y := 0; y < 12; y++ {
+
+		for x := 0; x < 16; x++ {
+
+			// The corners of the tile
+			tl := vector.Vector{float64(x) * tileSize, float64(y+1) * tileSize, 0}
+			tr := vector.Vector{float64(x+1) * tileSize, float64(y+1) * tileSize, 0}
+			bl := vector.Vector{float64(x) * tileSize, float64(y) * tileSize, 0}
+			br := vector.Vector{float64(x+1) * tileSize, float64(y) * tileSize, 0}
+
+			shade := 0.5 + float32((x+y)%2)*0.25
+
+			for _, corners := range [][]vector.Vector{{tl, bl, br}, {tl, br, tr}} {
+				wall.AddChildren(newTriangleModel(corners, shade))
+			}
+
+		}
+
+	}
+
+	wall.SetLocalPosition(-16*tileSize/2, -12*tileSize/2, 0)
+
+	g.Camera = tetra3d.NewCamera(g.Width, g.Height)
+	g.Camera.Move(0, 0, 5)
+	g.Scene.Root.AddChildren(g.Camera)
+
+}
+
+// newTriangleModel creates a Model consisting of a single triangle with the given world-space corners, positioned at the triangle's center.
+func newTriangleModel(corners []vector.Vector, shade float32) *tetra3d.Model {
+
+	center := corners[0].Add(corners[1]).Add(corners[2]).Scale(1.0 / 3.0)
+
+	mesh := tetra3d.NewMesh("Triangle")
+	part := mesh.AddMeshPart(tetra3d.NewMaterial("Triangle"))
+	part.Material.BackfaceCulling = false
+
+	verts := []tetra3d.VertexInfo{}
+	for _, corner := range corners {
+		local := corner.Sub(center)
+		verts = append(verts, tetra3d.NewVertex(local[0], local[1], local[2], 0, 0))
+	}
+
+	part.AddTriangles(verts...)
+	mesh.UpdateBounds()
+
+	model := tetra3d.NewModel(mesh, "Triangle")
+	model.Color.Set(shade, shade, shade, 1)
+	model.SetLocalPositionVec(center)
+
+	return model
+
+}
+
+func (g *Game) Update() error {
+
+	var err error
+
+	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
+		err = errors.New("quit")
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyF4) {
+		ebiten.SetFullscreen(!ebiten.IsFullscreen())
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
+		g.Camera.SubpixelCorrection = !g.Camera.SubpixelCorrection
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyF1) {
+		g.DrawDebugText = !g.DrawDebugText
+	}
+
+	// Slowly sway the wall so that the shared edges cross pixel boundaries.
+	g.Time += 1.0 / 60.0
+	wall := g.Scene.Root.Get("Wall")
+	wall.SetLocalRotation(tetra3d.NewMatrix4Rotate(0, 1, 0, math.Sin(g.Time*0.5)*0.3).Rotated(1, 0, 0, math.Cos(g.Time*0.3)*0.2))
+
+	return err
+}
+
+func (g *Game) Draw(screen *ebiten.Image) {
+
+	// A bright background makes any gaps between the triangles easy to see.
+	screen.Fill(color.RGBA{255, 0, 255, 255})
+
+	g.Camera.Clear()
+
+	g.Camera.RenderNodes(g.Scene, g.Scene.Root)
+
+	screen.DrawImage(g.Camera.ColorTexture(), nil)
+
+	if g.DrawDebugText {
+		g.Camera.DrawDebugRenderInfo(screen, 1, colors.White())
+		txt := fmt.Sprintf("F1 to toggle this text\nThis example shows cracks between triangles that share\nedges, but are transformed separately.\nSpace: Toggle subpixel correction (currently %t)\nF4: Toggle fullscreen\nESC: Quit", g.Camera.SubpixelCorrection)
+		text.Draw(screen, txt, basicfont.Face7x13, 0, 130, color.RGBA{200, 200, 200, 255})
+	}
+
+}
+
+func (g *Game) Layout(w, h int) (int, int) {
+	return g.Width, g.Height
+}
+
+func main() {
+
+	ebiten.SetWindowTitle("Tetra3d - Subpixel Correction Test")
+
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+
+	game := NewGame()
+
+	if err := ebiten.RunGame(game); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,250 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/kvartborg/vector"
+)
+
+const (
+	FogVolumeShapeBox    = iota // The FogVolume is a box, centered on the FogVolume, with its Size
+	FogVolumeShapeSphere        // The FogVolume is a sphere, centered on the FogVolume, with its Radius
+)
+
+// FogVolume is a Node representing a box or sphere filled with fog, for local fog like that of swamps, basements, or underwater areas.
+// Everything seen through a FogVolume is fogged by it, with the amount of fog depending on how far the view passes through the
+// FogVolume (so looking into a FogVolume from the outside fogs things less than being deep within it). FogVolumes are transformed like
+// other Nodes (so they can be moved, rotated, and scaled), and are rendered on top of the World's fog. Like the World's height fog,
+// FogVolumes are calculated for each vertex, so large triangles may be fogged unevenly; when the Camera's rendering depth, the fog is
+// blended over the rendered colors, and otherwise, it's blended into the vertex colors (and so is tinted by textures).
+type FogVolume struct {
+	*Node
+	Shape  int           // The shape of the FogVolume (FogVolumeShapeBox or FogVolumeShapeSphere).
+	Size   vector.Vector // The size of the FogVolume's box on each axis, for FogVolumeShapeBox.
+	Radius float64       // The radius of the FogVolume's sphere, for FogVolumeShapeSphere.
+
+	Color    *Color  // The color of the FogVolume's fog; its alpha is ignored.
+	Opacity  float64 // The maximum opacity of the FogVolume's fog, from 0 to 1; defaults to 1.
+	Distance float64 // How far the view has to pass through the FogVolume for its fog to reach its full Opacity; defaults to 10.
+	On       bool    // Whether the FogVolume is active; defaults to true.
+
+	inverseTransform Matrix4 // The inverse of the FogVolume's transform when rendering began
+}
+
+// NewFogVolumeBox creates a new FogVolume in the shape of a box of the given size.
+func NewFogVolumeBox(name string, width, height, depth float64) *FogVolume {
+	volume := newFogVolume(name)
+	volume.Shape = FogVolumeShapeBox
+	volume.Size = vector.Vector{width, height, depth}
+	return volume
+}
+
+// NewFogVolumeSphere creates a new FogVolume in the shape of a sphere of the given radius.
+func NewFogVolumeSphere(name string, radius float64) *FogVolume {
+	volume := newFogVolume(name)
+	volume.Shape = FogVolumeShapeSphere
+	volume.Radius = radius
+	return volume
+}
+
+func newFogVolume(name string) *FogVolume {
+	return &FogVolume{
+		Node:     NewNode(name),
+		Size:     vector.Vector{1, 1, 1},
+		Radius:   1,
+		Color:    NewColor(1, 1, 1, 1),
+		Opacity:  1,
+		Distance: 10,
+		On:       true,
+	}
+}
+
+// Clone returns a new clone of the given FogVolume.
+func (volume *FogVolume) Clone() INode {
+
+	clone := newFogVolume(volume.name)
+	clone.Shape = volume.Shape
+	clone.Size = volume.Size.Clone()
+	clone.Radius = volume.Radius
+	clone.Color = volume.Color.Clone()
+	clone.Opacity = volume.Opacity
+	clone.Distance = volume.Distance
+	clone.On = volume.On
+
+	clone.Node = volume.Node.Clone().(*Node)
+	for _, child := range volume.children {
+		child.setParent(clone)
+	}
+
+	return clone
+
+}
+
+// beginRender stores the inverse of the FogVolume's transform, which is used to test views against the FogVolume in its local space.
+func (volume *FogVolume) beginRender() {
+	volume.inverseTransform = volume.Transform().Inverted()
+}
+
+// viewFraction returns the fraction of the line segment from the start to the end positions given (in world space) that lies within
+// the FogVolume.
+func (volume *FogVolume) viewFraction(start, end vector.Vector) float64 {
+
+	from := volume.inverseTransform.MultVec(start)
+	dir := volume.inverseTransform.MultVec(end).Sub(from)
+
+	// The entry and exit points of the line through the FogVolume, as fractions of the segment.
+	var tMin, tMax float64
+
+	if volume.Shape == FogVolumeShapeSphere {
+
+		a := dot(dir, dir)
+		if a == 0 {
+			return 0
+		}
+
+		b := 2 * dot(from, dir)
+		c := dot(from, from) - volume.Radius*volume.Radius
+
+		discriminant := b*b - 4*a*c
+		if discriminant <= 0 {
+			return 0
+		}
+
+		root := math.Sqrt(discriminant)
+		tMin = (-b - root) / (2 * a)
+		tMax = (-b + root) / (2 * a)
+
+	} else {
+
+		tMin = math.Inf(-1)
+		tMax = math.Inf(1)
+
+		for axis := 0; axis < 3; axis++ {
+
+			half := volume.Size[axis] / 2
+
+			if dir[axis] == 0 {
+				if from[axis] < -half || from[axis] > half {
+					return 0
+				}
+				continue
+			}
+
+			t0 := (-half - from[axis]) / dir[axis]
+			t1 := (half - from[axis]) / dir[axis]
+			if t0 > t1 {
+				t0, t1 = t1, t0
+			}
+
+			tMin = math.Max(tMin, t0)
+			tMax = math.Min(tMax, t1)
+
+		}
+
+	}
+
+	tMin = math.Max(tMin, 0)
+	tMax = math.Min(tMax, 1)
+
+	return math.Max(tMax-tMin, 0)
+
+}
+
+// AddChildren parents the provided children Nodes to the passed parent Node, inheriting its transformations and being under it in the scenegraph
+// hierarchy. If the children are already parented to other Nodes, they are unparented before doing so.
+func (volume *FogVolume) AddChildren(children ...INode) {
+	volume.addChildren(volume, children...)
+}
+
+// Unparent unparents the FogVolume from its parent, removing it from the scenegraph.
+func (volume *FogVolume) Unparent() {
+	if volume.parent != nil {
+		volume.parent.RemoveChildren(volume)
+	}
+}
+
+// Type returns the NodeType for this object.
+func (volume *FogVolume) Type() NodeType {
+	return NodeTypeFogVolume
+}
+
+// This vertex list is only allocated once a Camera renders local fog (the World's height fog or FogVolumes); each vertex holds the
+// local fog's color (premultiplied by its opacity) and opacity.
+var fogVertexList []ebiten.Vertex
+
+// prepareLocalFog creates the buffer and vertex list used to render local fog if they haven't been created yet.
+func (camera *Camera) prepareLocalFog() {
+
+	if camera.fogIntermediate == nil {
+		camera.fogIntermediate = ebiten.NewImage(camera.resultColorTexture.Size())
+	}
+
+	if fogVertexList == nil {
+		fogVertexList = make([]ebiten.Vertex, ebiten.MaxIndicesNum)
+	}
+
+}
+
+// localFog returns the color (premultiplied by its opacity) and opacity of the local fog (the World's height fog and the FogVolumes
+// given) between the Camera and the given world position.
+func localFog(world *World, volumes []*FogVolume, cameraPosition, position vector.Vector) (r, g, b, a float32) {
+
+	// Each layer of fog is blended over the previous one.
+	blend := func(color *Color, opacity float64) {
+		opacity = math.Max(math.Min(opacity, 1), 0)
+		o := float32(opacity)
+		r = color.R*o + r*(1-o)
+		g = color.G*o + g*(1-o)
+		b = color.B*o + b*(1-o)
+		a = o + a*(1-o)
+	}
+
+	if world != nil && world.HeightFogEnabled && world.HeightFogEnd != world.HeightFogStart {
+		blend(world.HeightFogColor, world.HeightFogOpacity*(position[1]-world.HeightFogStart)/(world.HeightFogEnd-world.HeightFogStart))
+	}
+
+	var distance float64
+
+	for i, volume := range volumes {
+
+		if i == 0 {
+			distance = position.Sub(cameraPosition).Magnitude()
+		}
+
+		if fraction := volume.viewFraction(cameraPosition, position); fraction > 0 && volume.Distance > 0 {
+			blend(volume.Color, volume.Opacity*fraction*distance/volume.Distance)
+		}
+
+	}
+
+	return
+
+}
+
+// setFogVertex sets up the vertex at the given index of the fog vertex list, using the screen position of the source vertex and the
+// local fog at the given world position.
+func (camera *Camera) setFogVertex(index int, src ebiten.Vertex, world *World, volumes []*FogVolume, cameraPosition, position vector.Vector) {
+	dst := &fogVertexList[index]
+	dst.DstX = src.DstX
+	dst.DstY = src.DstY
+	dst.SrcX = 0
+	dst.SrcY = 0
+	dst.ColorR, dst.ColorG, dst.ColorB, dst.ColorA = localFog(world, volumes, cameraPosition, position)
+}
+
+// fogVertexColor blends the local fog at the given world position into the color of the given vertex, for when the Camera isn't
+// rendering depth (and so local fog can't be blended over the rendered colors).
+func fogVertexColor(dst *ebiten.Vertex, world *World, volumes []*FogVolume, cameraPosition, position vector.Vector) {
+	r, g, b, a := localFog(world, volumes, cameraPosition, position)
+	dst.ColorR = dst.ColorR*(1-a) + r
+	dst.ColorG = dst.ColorG*(1-a) + g
+	dst.ColorB = dst.ColorB*(1-a) + b
+}
+
+// drawLocalFog renders the triangles in the fog vertex list to the Camera's fog buffer, which the Camera's color shader blends over
+// the rendered colors.
+func (camera *Camera) drawLocalFog() {
+	camera.fogIntermediate.Clear()
+	camera.fogIntermediate.DrawTriangles(fogVertexList[:vertexListIndex], indexList[:vertexListIndex], defaultImg, nil)
+}
@@ -0,0 +1,68 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kvartborg/vector"
+)
+
+func TestLocalFog(t *testing.T) {
+
+	world := NewWorld("world")
+	world.HeightFogEnabled = true
+	world.HeightFogStart = 0
+	world.HeightFogEnd = -10
+
+	cameraPosition := vector.Vector{0, 5, 0}
+
+	if _, _, _, a := localFog(world, nil, cameraPosition, vector.Vector{0, 5, -5}); a != 0 {
+		t.Errorf("expected no height fog above its start, got %f", a)
+	}
+
+	if _, _, _, a := localFog(world, nil, cameraPosition, vector.Vector{0, -5, -5}); math.Abs(float64(a)-0.5) > 0.001 {
+		t.Errorf("expected height fog halfway between its start and end to be half as opaque, got %f", a)
+	}
+
+	world.HeightFogEnabled = false
+
+	box := NewFogVolumeBox("box", 4, 4, 4)
+	box.Move(0, 5, -10)
+	box.Distance = 8
+	box.beginRender()
+
+	// Looking through the whole box passes through 4 units of fog.
+	if _, _, _, a := localFog(world, []*FogVolume{box}, cameraPosition, vector.Vector{0, 5, -20}); math.Abs(float64(a)-0.5) > 0.001 {
+		t.Errorf("expected looking through the box to be fogged by half, got %f", a)
+	}
+
+	if _, _, _, a := localFog(world, []*FogVolume{box}, cameraPosition, vector.Vector{5, 5, -20}); a != 0 {
+		t.Errorf("expected looking past the box not to be fogged, got %f", a)
+	}
+
+	sphere := NewFogVolumeSphere("sphere", 20)
+	sphere.Color = NewColor(0, 0, 1, 1)
+	sphere.beginRender()
+
+	// From within the sphere, everything is fogged by how far away it is.
+	r, _, b, a := localFog(world, []*FogVolume{sphere}, cameraPosition, vector.Vector{0, 5, -5})
+	if math.Abs(float64(a)-0.5) > 0.001 || r != 0 || math.Abs(float64(b-a)) > 0.001 {
+		t.Errorf("expected blue fog half as opaque within the sphere, got a color of %f, %f with an opacity of %f", r, b, a)
+	}
+
+	scene := NewScene("local fog")
+	camera := NewCamera(32, 32)
+	scene.Root.AddChildren(camera, sphere.Clone())
+
+	cube := NewModel(NewCube(), "cube")
+	cube.Move(0, 0, -5)
+	scene.Root.AddChildren(cube)
+
+	camera.Clear()
+	camera.RenderNodes(scene, scene.Root)
+
+	if len(camera.fogVolumes) != 1 || camera.fogIntermediate == nil {
+		t.Errorf("expected the camera to render the scene's fog volume")
+	}
+
+}
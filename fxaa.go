@@ -0,0 +1,93 @@
+package tetra3d
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// The FXAA shader is a simplified version of Timothy Lottes' Fast Approximate Anti-Aliasing. It finds edges by comparing the luma
+// of each pixel with its diagonal neighbors, and blurs along (rather than across) the edges it finds; pixels that don't differ
+// enough from their neighbors are left untouched, so the rest of the image stays sharp.
+var fxaaShaderText = []byte(
+	`package main
+
+	func luma(rgb vec3) float {
+		return dot(rgb, vec3(0.299, 0.587, 0.114))
+	}
+
+	func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+
+		texel := 1 / imageSrcTextureSize()
+
+		center := imageSrc0UnsafeAt(texCoord)
+
+		lumaNW := luma(imageSrc0At(texCoord + vec2(-1, -1) * texel).rgb)
+		lumaNE := luma(imageSrc0At(texCoord + vec2(1, -1) * texel).rgb)
+		lumaSW := luma(imageSrc0At(texCoord + vec2(-1, 1) * texel).rgb)
+		lumaSE := luma(imageSrc0At(texCoord + vec2(1, 1) * texel).rgb)
+		lumaM := luma(center.rgb)
+
+		lumaMin := min(lumaM, min(min(lumaNW, lumaNE), min(lumaSW, lumaSE)))
+		lumaMax := max(lumaM, max(max(lumaNW, lumaNE), max(lumaSW, lumaSE)))
+
+		// Low contrast areas aren't edges.
+		if lumaMax - lumaMin < max(0.0312, lumaMax * 0.125) {
+			return center
+		}
+
+		dir := vec2(-((lumaNW + lumaNE) - (lumaSW + lumaSE)), (lumaNW + lumaSW) - (lumaNE + lumaSE))
+
+		dirReduce := max((lumaNW + lumaNE + lumaSW + lumaSE) * (0.25 / 8), 1.0 / 128)
+		rcpDirMin := 1 / (min(abs(dir.x), abs(dir.y)) + dirReduce)
+
+		// The edge is searched for up to 8 pixels away.
+		dir = clamp(dir * rcpDirMin, -8, 8) * texel
+
+		a := (imageSrc0At(texCoord + dir * (1.0 / 3 - 0.5)) + imageSrc0At(texCoord + dir * (2.0 / 3 - 0.5))) * 0.5
+		b := a * 0.5 + (imageSrc0At(texCoord - dir * 0.5) + imageSrc0At(texCoord + dir * 0.5)) * 0.25
+
+		// If the wider sample went past the edge (and so picked up colors that are out of the local range), the narrower one is used.
+		lumaB := luma(b.rgb)
+		if lumaB < lumaMin || lumaB > lumaMax {
+			return a
+		}
+
+		return b
+
+	}
+	`,
+)
+
+// prepareFXAA creates the shader and texture used for FXAA if they haven't been created yet.
+func (camera *Camera) prepareFXAA() {
+
+	if camera.fxaaShader == nil {
+
+		var err error
+
+		camera.fxaaShader, err = ebiten.NewShader(fxaaShaderText)
+
+		if err != nil {
+			panic(err)
+		}
+
+	}
+
+	if camera.fxaaTexture == nil {
+		camera.fxaaTexture = ebiten.NewImage(camera.resultColorTexture.Size())
+	}
+
+}
+
+// applyFXAA smooths the jagged edges of the given texture, storing the result in the Camera's FXAA texture.
+func (camera *Camera) applyFXAA(source *ebiten.Image) {
+
+	camera.prepareFXAA()
+
+	w, h := camera.resultColorTexture.Size()
+
+	camera.fxaaTexture.Clear()
+	camera.fxaaTexture.DrawRectShader(w, h, camera.fxaaShader, &ebiten.DrawRectShaderOptions{
+		Images: [4]*ebiten.Image{source},
+	})
+
+}
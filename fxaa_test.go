@@ -0,0 +1,67 @@
+package tetra3d
+
+import (
+	"testing"
+)
+
+func TestFXAA(t *testing.T) {
+
+	scene := NewScene("fxaa")
+	camera := NewCamera(32, 32)
+	scene.Root.AddChildren(camera)
+
+	cube := NewModel(NewCube(), "cube")
+	cube.Move(0, 0, -5)
+	cube.Rotate(0, 0, 1, 0.3)
+	scene.Root.AddChildren(cube)
+
+	camera.FXAAEnabled = true
+
+	camera.Clear()
+	camera.RenderNodes(scene, scene.Root)
+
+	if camera.ColorTexture() != camera.fxaaTexture {
+		t.Errorf("expected the color texture to have FXAA applied")
+	}
+
+	// FXAA's applied after bloom.
+	camera.BloomEnabled = true
+	camera.Clear()
+
+	if camera.ColorTexture() != camera.fxaaTexture {
+		t.Errorf("expected FXAA to be applied after bloom")
+	}
+
+	camera.FXAAEnabled = false
+	camera.Clear()
+
+	if camera.ColorTexture() != camera.bloomTexture {
+		t.Errorf("expected FXAA to be toggleable")
+	}
+
+	// The cube is shadeless and white, so without FXAA, its edges are hard, with every pixel being either fully covered or empty.
+	cube.Mesh.MeshParts[0].Material.Shadeless = true
+	camera.BloomEnabled = false
+
+	camera.Clear()
+	camera.RenderNodes(scene, scene.Root)
+	sharp := readPixels(t, camera.ColorTexture())
+
+	if count := countBlendedPixels(sharp); count > 0 {
+		t.Fatalf("expected the cube's edges to be hard without FXAA, but %d pixels are blended", count)
+	}
+
+	camera.FXAAEnabled = true
+	camera.Clear()
+	camera.RenderNodes(scene, scene.Root)
+	smoothed := readPixels(t, camera.ColorTexture())
+
+	if countBlendedPixels(smoothed) == 0 {
+		t.Errorf("expected FXAA to blend the pixels along the cube's edges")
+	}
+
+	if center := 16*32 + 16; smoothed[center] != sharp[center] {
+		t.Errorf("expected FXAA to leave the middle of the cube untouched; it went from %v to %v", sharp[center], smoothed[center])
+	}
+
+}
@@ -0,0 +1,74 @@
+package tetra3d
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// This vertex list is only allocated once a Material using FragmentShaderGBuffer (or TransparencyModeDithered) is rendered. Its
+// vertices sample the G-buffer images at their screen positions, and hold the depth of the vertex in their red channels and the
+// vertex's UV values in their green and blue channels.
+var gBufferVertexList []ebiten.Vertex
+
+// prepareGBuffer creates the buffer and vertex list used to render Materials using FragmentShaderGBuffer (or
+// TransparencyModeDithered) if they haven't been created yet.
+func (camera *Camera) prepareGBuffer() {
+
+	if camera.gBufferColorIntermediate == nil {
+		camera.gBufferColorIntermediate = ebiten.NewImage(camera.resultColorTexture.Size())
+	}
+
+	if gBufferVertexList == nil {
+		gBufferVertexList = make([]ebiten.Vertex, ebiten.MaxIndicesNum)
+	}
+
+}
+
+// drawGBufferShader renders the triangles in the color vertex list to the target image using the given custom fragment shader in
+// screen space; the triangles are first rendered normally (using the given texture and options) to a buffer, which is passed to the
+// shader as its first image along with the Camera's depth texture as its second image (and its normal texture as its third image, if
+// it's rendering normals and the third image isn't set).
+func (camera *Camera) drawGBufferShader(target *ebiten.Image, img *ebiten.Image, options *ebiten.DrawTrianglesOptions, shader *ebiten.Shader, shaderOptions *ebiten.DrawTrianglesShaderOptions) {
+
+	camera.prepareGBuffer()
+
+	intermediateOptions := *options
+	intermediateOptions.CompositeMode = ebiten.CompositeModeSourceOver
+
+	camera.gBufferColorIntermediate.Clear()
+	camera.gBufferColorIntermediate.DrawTriangles(colorVertexList[:vertexListIndex], indexList[:vertexListIndex], img, &intermediateOptions)
+
+	imgW, imgH := img.Size()
+
+	for i := 0; i < vertexListIndex; i++ {
+
+		src := colorVertexList[i]
+		dst := &gBufferVertexList[i]
+
+		dst.DstX = src.DstX
+		dst.DstY = src.DstY
+		dst.SrcX = src.DstX
+		dst.SrcY = src.DstY
+
+		// The depth vertices only hold depth values if the Camera's rendering depth.
+		dst.ColorR = 0
+		if camera.RenderDepth {
+			dst.ColorR = depthVertexList[i].ColorR
+		}
+
+		dst.ColorG = src.SrcX / float32(imgW)
+		dst.ColorB = 1 - src.SrcY/float32(imgH)
+		dst.ColorA = 1
+
+	}
+
+	gBufferOptions := *shaderOptions
+	gBufferOptions.Images[0] = camera.gBufferColorIntermediate
+	gBufferOptions.Images[1] = camera.resultDepthTexture
+
+	if camera.RenderNormals && gBufferOptions.Images[2] == nil {
+		gBufferOptions.Images[2] = camera.resultNormalTexture
+	}
+
+	target.DrawTrianglesShader(gBufferVertexList[:vertexListIndex], indexList[:vertexListIndex], shader, &gBufferOptions)
+
+}
@@ -0,0 +1,56 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestGBufferShader(t *testing.T) {
+
+	scene := NewScene("gbuffer")
+
+	mesh := NewPlane()
+	mat := mesh.MeshParts[0].Material
+	mat.Texture = ebiten.NewImage(4, 4)
+	mat.FragmentShaderGBuffer = true
+
+	// Soft edges where the plane meets anything behind it.
+	if _, err := mat.SetShader([]byte(`package main
+
+	//tetra3d:include depth
+
+	func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+		sceneDepth := decodeDepth(imageSrc1At(texCoord))
+		return imageSrc0At(texCoord) * clamp((sceneDepth-color.r)*50, 0, 1)
+	}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	model := NewModel(mesh, "plane")
+	model.Move(0, 0, -5)
+	model.Rotate(1, 0, 0, math.Pi/2)
+	scene.Root.AddChildren(model)
+
+	camera := NewCamera(32, 32)
+	scene.Root.AddChildren(camera)
+
+	camera.Clear()
+	camera.RenderNodes(scene, scene.Root)
+
+	if camera.gBufferColorIntermediate == nil {
+		t.Fatalf("expected the plane to be rendered through the G-buffer")
+	}
+
+	// The plane faces the camera, so its vertices should have depths and UV values from 0 to 1.
+	for i, v := range gBufferVertexList[:6] {
+		if v.ColorR <= 0 || v.ColorR >= 1 || v.ColorG < -0.0001 || v.ColorG > 1.0001 || v.ColorB < -0.0001 || v.ColorB > 1.0001 {
+			t.Errorf("vertex %d: expected a depth and UV values between 0 and 1, got %f, %f, %f", i, v.ColorR, v.ColorG, v.ColorB)
+		}
+		if v.SrcX != v.DstX || v.SrcY != v.DstY {
+			t.Errorf("vertex %d: expected the G-buffer to be sampled at the vertex's screen position", i)
+		}
+	}
+
+}
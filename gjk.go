@@ -0,0 +1,448 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/kvartborg/vector"
+)
+
+const (
+	gjkMaxIterations = 64   // gjkMaxIterations is the most times GJK refines its simplex before giving up.
+	epaMaxIterations = 64   // epaMaxIterations is the most times EPA expands its polytope before settling on the closest face so far.
+	epaTolerance     = 1e-6 // epaTolerance is how close EPA has to get to the true penetration depth to stop expanding.
+)
+
+// supportFunction returns the point on a convex shape in world space that's furthest along the given direction. GJK and EPA only
+// need a shape's support function to test it, so any convex shape can be tested against any other.
+type supportFunction func(direction vector.Vector) vector.Vector
+
+// pointsSupport returns a supportFunction for the convex hull of the given points.
+func pointsSupport(points []vector.Vector) supportFunction {
+	return func(direction vector.Vector) vector.Vector {
+		best := points[0]
+		bestDot := dot(best, direction)
+		for _, p := range points[1:] {
+			if d := dot(p, direction); d > bestDot {
+				best = p
+				bestDot = d
+			}
+		}
+		return best
+	}
+}
+
+// roundedSupport returns a supportFunction for the given supportFunction's shape, grown outwards by the given radius (so a point
+// becomes a sphere, and a line segment becomes a capsule).
+func roundedSupport(support supportFunction, radius float64) supportFunction {
+	return func(direction vector.Vector) vector.Vector {
+		point := support(direction)
+		if mag := direction.Magnitude(); mag > 0 {
+			return point.Add(direction.Scale(radius / mag))
+		}
+		return point
+	}
+}
+
+// boxSupport returns a supportFunction for the given box.
+func boxSupport(box boxShape) supportFunction {
+	return func(direction vector.Vector) vector.Vector {
+		point := box.center.Clone()
+		for i, axis := range box.axes {
+			if dot(axis, direction) >= 0 {
+				point = point.Add(axis.Scale(box.half[i]))
+			} else {
+				point = point.Sub(axis.Scale(box.half[i]))
+			}
+		}
+		return point
+	}
+}
+
+// shapeSupport returns a supportFunction and the center in world space for the given BoundingObject, which can be any convex
+// BoundingObject (that is, anything other than a BoundingTriangles).
+func shapeSupport(boundingObject IBoundingObject) (supportFunction, vector.Vector) {
+
+	switch bounds := boundingObject.(type) {
+
+	case *BoundingSphere:
+		center := bounds.WorldPosition()
+		return roundedSupport(pointsSupport([]vector.Vector{center}), bounds.WorldRadius()), center
+
+	case *BoundingCapsule:
+		return roundedSupport(pointsSupport([]vector.Vector{bounds.lineBottom(), bounds.lineTop()}), bounds.WorldRadius()), bounds.WorldPosition()
+
+	case *BoundingAABB:
+		shape := aabbShape(bounds)
+		return boxSupport(shape), shape.center
+
+	case *BoundingOBB:
+		shape := bounds.shape()
+		return boxSupport(shape), shape.center
+
+	case *BoundingConvex:
+		points := bounds.Points()
+		return pointsSupport(points), bounds.WorldPosition()
+
+	}
+
+	panic("Unimplemented bounds type")
+
+}
+
+// gjkPoint is a point on the Minkowski difference of two shapes (A - B), along with the point on shape B that it came from.
+type gjkPoint struct {
+	point vector.Vector
+	b     vector.Vector
+}
+
+// minkowskiSupport returns the point on the Minkowski difference of shapes A and B that's furthest along the given direction.
+func minkowskiSupport(a, b supportFunction, direction vector.Vector) gjkPoint {
+	pointB := b(direction.Invert())
+	return gjkPoint{point: a(direction).Sub(pointB), b: pointB}
+}
+
+// convexCollision tests two convex shapes (given by their support functions and centers) against each other using GJK, and finds how
+// far they overlap using EPA. It returns the MTV that moves shape A out of shape B and the point of contact on shape B, or nil if the
+// shapes don't overlap.
+func convexCollision(a, b supportFunction, centerA, centerB vector.Vector) (vector.Vector, vector.Vector) {
+
+	simplex, intersecting := gjk(a, b, centerA.Sub(centerB))
+
+	if !intersecting {
+		return nil, nil
+	}
+
+	normal, depth, contact, ok := epa(simplex, a, b)
+
+	if !ok || depth <= 0 {
+		return nil, nil
+	}
+
+	return normal.Scale(-depth), contact
+
+}
+
+// gjk returns if the two convex shapes given by their support functions intersect (that is, if their Minkowski difference contains
+// the origin), along with the final simplex, which EPA can start from.
+func gjk(a, b supportFunction, direction vector.Vector) ([]gjkPoint, bool) {
+
+	if direction.Magnitude() < 1e-12 {
+		direction = vector.Vector{1, 0, 0}
+	}
+
+	simplex := []gjkPoint{minkowskiSupport(a, b, direction)}
+	direction = simplex[0].point.Invert()
+
+	for i := 0; i < gjkMaxIterations; i++ {
+
+		// If there's no direction left to search in, the origin's on the simplex, so the shapes are just touching.
+		if direction.Magnitude() < 1e-12 {
+			return simplex, true
+		}
+
+		next := minkowskiSupport(a, b, direction)
+
+		// If the furthest point along the direction towards the origin doesn't reach it, the origin can't be inside.
+		if dot(next.point, direction) < 0 {
+			return nil, false
+		}
+
+		simplex = append(simplex, next)
+
+		var contains bool
+		if simplex, direction, contains = gjkSimplex(simplex); contains {
+			return simplex, true
+		}
+
+	}
+
+	return nil, false
+
+}
+
+// gjkSimplex reduces the given simplex (with the newest point last) to the part of it that's closest to the origin, returning that
+// along with the direction to search for the next point in, and if the simplex contains the origin.
+func gjkSimplex(simplex []gjkPoint) ([]gjkPoint, vector.Vector, bool) {
+
+	switch len(simplex) {
+
+	case 2:
+		return gjkLine(simplex[1], simplex[0])
+
+	case 3:
+		return gjkTriangle(simplex[2], simplex[1], simplex[0])
+
+	default:
+
+		a, b, c, d := simplex[3], simplex[2], simplex[1], simplex[0]
+		ao := a.point.Invert()
+
+		// Each face touching the newest point is checked to see if the origin's outside of it; the face's normal is flipped as
+		// necessary so that it points away from the vertex opposite it.
+		faces := [][3]gjkPoint{{c, b, d}, {d, c, b}, {b, d, c}}
+
+		for _, face := range faces {
+
+			normal := gjkCross(face[0].point.Sub(a.point), face[1].point.Sub(a.point))
+			if dot(normal, face[2].point.Sub(a.point)) > 0 {
+				normal = normal.Invert()
+			}
+
+			if dot(normal, ao) > 0 {
+				return gjkTriangle(a, face[0], face[1])
+			}
+
+		}
+
+		return simplex, nil, true
+
+	}
+
+}
+
+// gjkLine handles the line simplex from a (the newest point) to b.
+func gjkLine(a, b gjkPoint) ([]gjkPoint, vector.Vector, bool) {
+
+	ab := b.point.Sub(a.point)
+	ao := a.point.Invert()
+
+	if dot(ab, ao) > 0 {
+		return []gjkPoint{b, a}, gjkCross(gjkCross(ab, ao), ab), false
+	}
+
+	return []gjkPoint{a}, ao, false
+
+}
+
+// gjkTriangle handles the triangle simplex of a (the newest point), b, and c.
+func gjkTriangle(a, b, c gjkPoint) ([]gjkPoint, vector.Vector, bool) {
+
+	ab := b.point.Sub(a.point)
+	ac := c.point.Sub(a.point)
+	ao := a.point.Invert()
+	abc := gjkCross(ab, ac)
+
+	if dot(gjkCross(abc, ac), ao) > 0 {
+
+		if dot(ac, ao) > 0 {
+			return []gjkPoint{c, a}, gjkCross(gjkCross(ac, ao), ac), false
+		}
+
+		return gjkLine(a, b)
+
+	}
+
+	if dot(gjkCross(ab, abc), ao) > 0 {
+		return gjkLine(a, b)
+	}
+
+	if dot(abc, ao) > 0 {
+		return []gjkPoint{c, b, a}, abc, false
+	}
+
+	return []gjkPoint{b, c, a}, abc.Invert(), false
+
+}
+
+// gjkCross returns the cross product of the two given vectors.
+func gjkCross(a, b vector.Vector) vector.Vector {
+	return vector.Vector{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+// epaFace is a triangular face of EPA's polytope, with its outward normal and its distance from the origin.
+type epaFace struct {
+	indices  [3]int
+	normal   vector.Vector
+	distance float64
+}
+
+// epa expands the simplex that GJK found around the origin into a polytope that matches the shapes' Minkowski difference near the
+// origin, returning the normal and distance of its closest face to the origin (that is, the direction and depth of the overlap),
+// and the point of contact on shape B.
+func epa(simplex []gjkPoint, a, b supportFunction) (vector.Vector, float64, vector.Vector, bool) {
+
+	polytope, ok := epaTetrahedron(simplex, a, b)
+
+	if !ok {
+		return nil, 0, nil, false
+	}
+
+	// As the polytope only grows outwards, the center of the starting tetrahedron is always inside of it, so faces can be turned to
+	// face away from it.
+	inside := vector.Vector{0, 0, 0}
+	for _, p := range polytope {
+		inside = inside.Add(p.point)
+	}
+	inside = inside.Scale(0.25)
+
+	newFace := func(i, j, k int) *epaFace {
+		pi, pj, pk := polytope[i].point, polytope[j].point, polytope[k].point
+		normal := gjkCross(pj.Sub(pi), pk.Sub(pi))
+		if mag := normal.Magnitude(); mag > 1e-12 {
+			normal = normal.Scale(1 / mag)
+		}
+		if dot(normal, pi.Sub(inside)) < 0 {
+			normal = normal.Invert()
+			j, k = k, j
+		}
+		return &epaFace{indices: [3]int{i, j, k}, normal: normal, distance: dot(normal, pi)}
+	}
+
+	faces := []*epaFace{newFace(0, 1, 2), newFace(0, 1, 3), newFace(0, 2, 3), newFace(1, 2, 3)}
+
+	var closest *epaFace
+
+	for iteration := 0; iteration < epaMaxIterations; iteration++ {
+
+		closest = faces[0]
+		for _, face := range faces[1:] {
+			if face.distance < closest.distance {
+				closest = face
+			}
+		}
+
+		next := minkowskiSupport(a, b, closest.normal)
+
+		// If the polytope can't be expanded any further towards the closest face, that face is on the Minkowski difference's surface.
+		if dot(next.point, closest.normal)-closest.distance < epaTolerance {
+			break
+		}
+
+		polytope = append(polytope, next)
+		index := len(polytope) - 1
+
+		// The faces that the new point can see are removed, and the hole they leave (bordered by the edges that only one removed face
+		// has) is filled with new faces that reach out to the new point.
+		edges := [][2]int{}
+		remaining := faces[:0]
+
+		for _, face := range faces {
+
+			if dot(face.normal, next.point.Sub(polytope[face.indices[0]].point)) <= 0 {
+				remaining = append(remaining, face)
+				continue
+			}
+
+			for e := 0; e < 3; e++ {
+
+				edge := [2]int{face.indices[e], face.indices[(e+1)%3]}
+				shared := false
+
+				for i, existing := range edges {
+					if existing[0] == edge[1] && existing[1] == edge[0] {
+						edges = append(edges[:i], edges[i+1:]...)
+						shared = true
+						break
+					}
+				}
+
+				if !shared {
+					edges = append(edges, edge)
+				}
+
+			}
+
+		}
+
+		faces = remaining
+
+		for _, edge := range edges {
+			faces = append(faces, newFace(edge[0], edge[1], index))
+		}
+
+		if len(edges) == 0 {
+			break
+		}
+
+	}
+
+	// The contact point is where the closest point to the origin on the closest face lies on shape B.
+	p0, p1, p2 := polytope[closest.indices[0]], polytope[closest.indices[1]], polytope[closest.indices[2]]
+	u, v, w := barycentricCoordinates(closest.normal.Scale(closest.distance), p0.point, p1.point, p2.point)
+	contact := p0.b.Scale(u).Add(p1.b.Scale(v)).Add(p2.b.Scale(w))
+
+	return closest.normal, math.Max(closest.distance, 0), contact, true
+
+}
+
+// epaTetrahedron builds a tetrahedron for EPA to start from out of GJK's final simplex, which can have fewer than four points if the
+// shapes are just touching. It returns false if the shapes' Minkowski difference is flat.
+func epaTetrahedron(simplex []gjkPoint, a, b supportFunction) ([]gjkPoint, bool) {
+
+	polytope := append([]gjkPoint{}, simplex...)
+	axes := []vector.Vector{{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {0, 0, 1}, {0, 0, -1}}
+	const epsilon = 1e-9
+
+	if len(polytope) == 1 {
+		for _, axis := range axes {
+			if p := minkowskiSupport(a, b, axis); p.point.Sub(polytope[0].point).Magnitude() > epsilon {
+				polytope = append(polytope, p)
+				break
+			}
+		}
+	}
+
+	if len(polytope) == 2 {
+		line := polytope[1].point.Sub(polytope[0].point)
+		for _, axis := range axes {
+			direction := gjkCross(line, axis)
+			if direction.Magnitude() < epsilon {
+				continue
+			}
+			p := minkowskiSupport(a, b, direction)
+			if gjkCross(p.point.Sub(polytope[0].point), line).Magnitude() > epsilon {
+				polytope = append(polytope, p)
+				break
+			}
+		}
+	}
+
+	if len(polytope) == 3 {
+		normal := gjkCross(polytope[1].point.Sub(polytope[0].point), polytope[2].point.Sub(polytope[0].point))
+		for _, direction := range []vector.Vector{normal, normal.Invert()} {
+			p := minkowskiSupport(a, b, direction)
+			if math.Abs(dot(p.point.Sub(polytope[0].point), normal)) > epsilon {
+				polytope = append(polytope, p)
+				break
+			}
+		}
+	}
+
+	if len(polytope) != 4 {
+		return nil, false
+	}
+
+	return polytope, true
+
+}
+
+// barycentricCoordinates returns the barycentric coordinates of the given point (which should be on the plane of the triangle) in
+// the triangle of a, b, and c.
+func barycentricCoordinates(point, a, b, c vector.Vector) (float64, float64, float64) {
+
+	v0 := b.Sub(a)
+	v1 := c.Sub(a)
+	v2 := point.Sub(a)
+
+	d00 := dot(v0, v0)
+	d01 := dot(v0, v1)
+	d11 := dot(v1, v1)
+	d20 := dot(v2, v0)
+	d21 := dot(v2, v1)
+
+	denom := d00*d11 - d01*d01
+
+	if math.Abs(denom) < 1e-18 {
+		return 1, 0, 0
+	}
+
+	v := (d11*d20 - d01*d21) / denom
+	w := (d00*d21 - d01*d20) / denom
+
+	return 1 - v - w, v, w
+
+}
@@ -3,6 +3,7 @@ package tetra3d
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"image"
 	"log"
 	"math"
@@ -14,6 +15,7 @@ import (
 	"github.com/kvartborg/vector"
 	"github.com/qmuntal/gltf"
 	"github.com/qmuntal/gltf/ext/lightspuntual"
+	"github.com/qmuntal/gltf/ext/texturetransform"
 	"github.com/qmuntal/gltf/modeler"
 
 	_ "image/png"
@@ -34,6 +36,10 @@ type GLTFLoadOptions struct {
 	// You could then simply load the assets library first and then code the DependentLibraryResolver function to take the assets library, or code the
 	// function to use the path to load the library on demand. You could then store the loaded result as necessary if multiple levels use this assets Library.
 	DependentLibraryResolver func(blendPath string) *Library
+
+	// ImportOptions describes the coordinate system the file was authored in, so that its contents can be converted to Tetra3D's
+	// (which is the same as glTF's). If nil (the default), no conversion is done. See NewImportOptionsBlender(), for example.
+	ImportOptions *ImportOptions
 }
 
 // DefaultGLTFLoadOptions creates an instance of GLTFLoadOptions with some sensible defaults.
@@ -68,13 +74,19 @@ func LoadGLTFFile(path string, loadOptions *GLTFLoadOptions) (*Library, error) {
 // LoadGLTFFile will return a Library, and an error if the process fails.
 func LoadGLTFData(data []byte, gltfLoadOptions *GLTFLoadOptions) (*Library, error) {
 
-	decoder := gltf.NewDecoder(bytes.NewReader(data))
+	doc, err := gltfDecodeDocument(data)
 
-	doc := gltf.NewDocument()
+	if err != nil {
+		return nil, err
+	}
 
-	err := decoder.Decode(doc)
+	for _, ext := range doc.ExtensionsRequired {
+		if ext == "KHR_draco_mesh_compression" {
+			return nil, errors.New("error loading gltf data: Draco mesh compression (KHR_draco_mesh_compression) isn't supported; please use meshopt compression (EXT_meshopt_compression) instead (i.e. by exporting with gltfpack -cc)")
+		}
+	}
 
-	if err != nil {
+	if err := gltfDecodeMeshopt(doc); err != nil {
 		return nil, err
 	}
 
@@ -190,6 +202,15 @@ func LoadGLTFData(data []byte, gltfLoadOptions *GLTFLoadOptions) (*Library, erro
 			} else {
 				newMat.TexturePath = doc.Images[*doc.Textures[texture.Index].Source].URI
 			}
+			// Only the first two UV sets are loaded.
+			if gltfTexCoordSet(texture) == 1 {
+				newMat.TextureUVSet = 1
+			}
+		}
+
+		// Normal maps can only be loaded if the textures are packed into the GLTF file.
+		if texture := gltfMat.NormalTexture; texture != nil && exportedTextures {
+			newMat.NormalMap = images[*doc.Textures[*texture.Index].Source]
 		}
 
 		if gltfMat.Extras != nil {
@@ -254,6 +275,31 @@ func LoadGLTFData(data []byte, gltfLoadOptions *GLTFLoadOptions) (*Library, erro
 
 		newMat.Color.ConvertTosRGB()
 
+		if emissive := gltfMat.EmissiveFactor; emissive[0] > 0 || emissive[1] > 0 || emissive[2] > 0 {
+			newMat.EmissionColor.Set(emissive[0], emissive[1], emissive[2], 1)
+			newMat.EmissionColor.ConvertTosRGB()
+			newMat.EmissionStrength = 1
+		}
+
+		// KHR_materials_emissive_strength scales the emission past 1, which makes the Material bright enough to bloom.
+		if strengthData, exists := gltfMat.Extensions["KHR_materials_emissive_strength"]; exists {
+
+			extension := struct {
+				EmissiveStrength *float64 `json:"emissiveStrength"`
+			}{}
+
+			if raw, isRaw := strengthData.(json.RawMessage); isRaw {
+				if err := json.Unmarshal(raw, &extension); err != nil {
+					return nil, err
+				}
+			}
+
+			if extension.EmissiveStrength != nil && newMat.EmissionStrength > 0 {
+				newMat.EmissionStrength = *extension.EmissiveStrength
+			}
+
+		}
+
 		if gltfMat.AlphaMode == gltf.AlphaOpaque {
 			if gltfLoadOptions.DefaultToAutoTransparency {
 				newMat.TransparencyMode = TransparencyModeAuto
@@ -310,6 +356,11 @@ func LoadGLTFData(data []byte, gltfLoadOptions *GLTFLoadOptions) (*Library, erro
 
 		}
 
+		// Morph target deltas are gathered across all of the mesh's primitives, as they're added to the Mesh as a whole.
+		morphPositions := [][]vector.Vector{}
+		morphNormals := [][]vector.Vector{}
+		morphHasNormals := []bool{}
+
 		for _, v := range mesh.Primitives {
 
 			posBuffer := [][3]float32{}
@@ -332,6 +383,9 @@ func LoadGLTFData(data []byte, gltfLoadOptions *GLTFLoadOptions) (*Library, erro
 
 			}
 
+			// The base color texture's transform is baked into the UV set it uses (see Material.TextureUVSet).
+			texCoordSet, uvTransform := gltfTextureTransform(doc, v)
+
 			if texCoordAccessor, texCoordExists := v.Attributes[gltf.TEXCOORD_0]; texCoordExists {
 
 				uvBuffer := [][2]float32{}
@@ -343,8 +397,34 @@ func LoadGLTFData(data []byte, gltfLoadOptions *GLTFLoadOptions) (*Library, erro
 				}
 
 				for i, v := range texCoords {
-					vertexData[i].U = float64(v[0])
-					vertexData[i].V = -(float64(v[1]) - 1)
+					u, uvV := float64(v[0]), float64(v[1])
+					if texCoordSet == 0 {
+						u, uvV = uvTransform(u, uvV)
+					}
+					vertexData[i].U = u
+					vertexData[i].V = -(uvV - 1)
+				}
+
+			}
+
+			// The second UV set is used for lightmaps, or for textures that use it.
+			if texCoordAccessor, texCoordExists := v.Attributes[gltf.TEXCOORD_1]; texCoordExists {
+
+				uvBuffer := [][2]float32{}
+
+				texCoords, err := modeler.ReadTextureCoord(doc, doc.Accessors[texCoordAccessor], uvBuffer)
+
+				if err != nil {
+					return nil, err
+				}
+
+				for i, v := range texCoords {
+					u, uvV := float64(v[0]), float64(v[1])
+					if texCoordSet == 1 {
+						u, uvV = uvTransform(u, uvV)
+					}
+					vertexData[i].U2 = u
+					vertexData[i].V2 = -(uvV - 1)
 				}
 
 			}
@@ -446,6 +526,56 @@ func LoadGLTFData(data []byte, gltfLoadOptions *GLTFLoadOptions) (*Library, erro
 				newVerts[i] = vertexData[indices[i]]
 			}
 
+			for t, target := range v.Targets {
+
+				for len(morphPositions) <= t {
+					// Primitives before this one didn't have this target, so their vertices don't move.
+					morphPositions = append(morphPositions, make([]vector.Vector, 0, newMesh.VertexCount))
+					morphNormals = append(morphNormals, make([]vector.Vector, 0, newMesh.VertexCount))
+					morphHasNormals = append(morphHasNormals, false)
+					for i := 0; i < newMesh.VertexCount; i++ {
+						morphPositions[len(morphPositions)-1] = append(morphPositions[len(morphPositions)-1], vector.Vector{0, 0, 0})
+						morphNormals[len(morphNormals)-1] = append(morphNormals[len(morphNormals)-1], vector.Vector{0, 0, 0})
+					}
+				}
+
+				var positionDeltas, normalDeltas [][3]float32
+
+				if accessor, exists := target[gltf.POSITION]; exists {
+					positionDeltas, err = modeler.ReadPosition(doc, doc.Accessors[accessor], [][3]float32{})
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				if accessor, exists := target[gltf.NORMAL]; exists {
+					normalDeltas, err = modeler.ReadNormal(doc, doc.Accessors[accessor], [][3]float32{})
+					if err != nil {
+						return nil, err
+					}
+					morphHasNormals[t] = true
+				}
+
+				for _, index := range indices {
+
+					position := vector.Vector{0, 0, 0}
+					if int(index) < len(positionDeltas) {
+						d := positionDeltas[index]
+						position = vector.Vector{float64(d[0]), float64(d[1]), float64(d[2])}
+					}
+					morphPositions[t] = append(morphPositions[t], position)
+
+					normal := vector.Vector{0, 0, 0}
+					if int(index) < len(normalDeltas) {
+						d := normalDeltas[index]
+						normal = vector.Vector{float64(d[0]), float64(d[1]), float64(d[2])}
+					}
+					morphNormals[t] = append(morphNormals[t], normal)
+
+				}
+
+			}
+
 			var mat *Material
 
 			if v.Material != nil {
@@ -459,10 +589,64 @@ func LoadGLTFData(data []byte, gltfLoadOptions *GLTFLoadOptions) (*Library, erro
 
 			newMesh.UpdateBounds()
 
+			// Targets that this primitive didn't have don't move its vertices.
+			for t := len(v.Targets); t < len(morphPositions); t++ {
+				for i := len(morphPositions[t]); i < newMesh.VertexCount; i++ {
+					morphPositions[t] = append(morphPositions[t], vector.Vector{0, 0, 0})
+					morphNormals[t] = append(morphNormals[t], vector.Vector{0, 0, 0})
+				}
+			}
+
+		}
+
+		for _, part := range newMesh.MeshParts {
+			if part.Material != nil && part.Material.NormalMap != nil {
+				newMesh.GenerateTangents()
+				break
+			}
+		}
+
+		targetNames := []interface{}{}
+		if dataMap, isMap := mesh.Extras.(map[string]interface{}); isMap {
+			if names, exists := dataMap["targetNames"].([]interface{}); exists {
+				targetNames = names
+			}
+		}
+
+		for t := range morphPositions {
+
+			name := "Target" + strconv.Itoa(t)
+			if t < len(targetNames) {
+				if n, isString := targetNames[t].(string); isString {
+					name = n
+				}
+			}
+
+			var normals []vector.Vector
+			if morphHasNormals[t] {
+				normals = morphNormals[t]
+			}
+
+			target := newMesh.AddMorphTarget(name, morphPositions[t], normals)
+
+			if t < len(mesh.Weights) {
+				target.DefaultWeight = float64(mesh.Weights[t])
+			}
+
 		}
 
 	}
 
+	converter := gltfLoadOptions.ImportOptions.converter()
+
+	// Meshes are converted before Nodes are created, so that anything generated from their dimensions (like bounding objects)
+	// is already in the correct coordinate system.
+	if converter != nil {
+		for _, mesh := range library.Meshes {
+			converter.mesh(mesh)
+		}
+	}
+
 	for _, gltfAnim := range doc.Animations {
 		anim := NewAnimation(gltfAnim.Name)
 		anim.library = library
@@ -474,6 +658,23 @@ func LoadGLTFData(data []byte, gltfLoadOptions *GLTFLoadOptions) (*Library, erro
 
 			sampler := gltfAnim.Samplers[*channel.Sampler]
 
+			// Channels using KHR_animation_pointer target properties other than nodes' transforms (like materials' colors).
+			if pointerData, exists := channel.Target.Extensions["KHR_animation_pointer"]; exists {
+
+				length, err := gltfLoadAnimationPointer(doc, library, anim, sampler, pointerData)
+
+				if err != nil {
+					return nil, err
+				}
+
+				if length > animLength {
+					animLength = length
+				}
+
+				continue
+
+			}
+
 			channelName := "root"
 			if channel.Target.Node != nil {
 				channelName = doc.Nodes[*channel.Target.Node].Name
@@ -572,6 +773,46 @@ func LoadGLTFData(data []byte, gltfLoadOptions *GLTFLoadOptions) (*Library, erro
 					}
 				}
 
+			} else if channel.Target.Path == gltf.TRSWeights {
+
+				id, err := modeler.ReadAccessor(doc, doc.Accessors[sampler.Input], nil)
+
+				if err != nil {
+					return nil, err
+				}
+
+				inputData := id.([]float32)
+
+				od, err := modeler.ReadAccessor(doc, doc.Accessors[sampler.Output], nil)
+
+				if err != nil {
+					return nil, err
+				}
+
+				// Weights are stored as one flat list, with a weight for each morph target per keyframe.
+				outputData, isFloats := od.([]float32)
+
+				if !isFloats || len(inputData) == 0 {
+					continue
+				}
+
+				targetCount := len(outputData) / len(inputData)
+
+				track := animChannel.AddTrack(TrackTypeMorphWeights)
+				track.Interpolation = int(sampler.Interpolation)
+
+				for i := 0; i < len(inputData); i++ {
+					t := inputData[i]
+					weights := make(vector.Vector, targetCount)
+					for w := range weights {
+						weights[w] = float64(outputData[i*targetCount+w])
+					}
+					track.AddKeyframe(float64(t), weights)
+					if float64(t) > animLength {
+						animLength = float64(t)
+					}
+				}
+
 			}
 
 		}
@@ -631,7 +872,15 @@ func LoadGLTFData(data []byte, gltfLoadOptions *GLTFLoadOptions) (*Library, erro
 		}
 
 		if mesh != nil {
-			obj = NewModel(mesh, node.Name)
+			model := NewModel(mesh, node.Name)
+			if len(node.Weights) > 0 {
+				weights := make([]float64, len(node.Weights))
+				for i, w := range node.Weights {
+					weights[i] = float64(w)
+				}
+				model.SetMorphWeights(weights...)
+			}
+			obj = model
 		} else if node.Camera != nil {
 
 			gltfCam := doc.Cameras[*node.Camera]
@@ -667,6 +916,16 @@ func LoadGLTFData(data []byte, gltfLoadOptions *GLTFLoadOptions) (*Library, erro
 					pointLight.Distance = float64(*lightData.Range)
 				}
 				obj = pointLight
+			} else if lightData.Type == lightspuntual.TypeSpot {
+				spotLight := NewSpotLight(node.Name, lightData.Color[0], lightData.Color[1], lightData.Color[2], *lightData.Intensity/1000)
+				if !math.IsInf(float64(*lightData.Range), 0) {
+					spotLight.Distance = float64(*lightData.Range)
+				}
+				if lightData.Spot != nil {
+					spotLight.InnerConeAngle = ToDegrees(float64(lightData.Spot.InnerConeAngle))
+					spotLight.OuterConeAngle = ToDegrees(float64(lightData.Spot.OuterConeAngleOrDefault()))
+				}
+				obj = spotLight
 			} else {
 				// Any unsupported light type just gets turned into an ambient light
 				pointLight := NewAmbientLight(node.Name, lightData.Color[0], lightData.Color[1], lightData.Color[2], *lightData.Intensity/1000)
@@ -737,6 +996,14 @@ func LoadGLTFData(data []byte, gltfLoadOptions *GLTFLoadOptions) (*Library, erro
 
 			}
 
+		} else if node.Extras != nil && nodeHasProp(node, "t3dPathGraph__") {
+			obj = NewPathGraph(node.Name)
+		} else if node.Extras != nil && nodeHasProp(node, "t3dWaypoint__") {
+			// Waypoints are linked together once all of the objects have been created, below.
+			obj = NewWaypoint(node.Name)
+		} else if node.Extras != nil && nodeHasProp(node, "t3dTriggerVolume__") {
+			// TriggerVolumes use the bounds created for them below to define their regions.
+			obj = NewTriggerVolume(node.Name, nil)
 		} else {
 			obj = NewNode(node.Name)
 		}
@@ -901,6 +1168,49 @@ func LoadGLTFData(data []byte, gltfLoadOptions *GLTFLoadOptions) (*Library, erro
 							obj.AddChildren(triangles)
 						}
 
+					case 5: // OBB
+
+						var obb *BoundingOBB
+
+						if aabbCustomEnabled := getOrDefaultBool("t3dAABBCustomEnabled__", false); aabbCustomEnabled {
+
+							boundsSize := getOrDefaultFloatSlice("t3dAABBCustomSize__", []float64{2, 2, 2})
+							obb = NewBoundingOBB("BoundingOBB", boundsSize[0], boundsSize[1], boundsSize[2])
+
+						} else if obj.Type().Is(NodeTypeModel) && obj.(*Model).Mesh != nil {
+							dim := obj.(*Model).Mesh.Dimensions
+							obb = NewBoundingOBB("BoundingOBB", dim.Width(), dim.Height(), dim.Depth())
+						}
+
+						if obb != nil {
+
+							if obj.Type().Is(NodeTypeModel) && obj.(*Model).Mesh != nil {
+								obb.SetLocalPositionVec(obj.(*Model).Mesh.Dimensions.Center())
+							}
+
+							obj.AddChildren(obb)
+
+						} else {
+							log.Println("Warning: object " + obj.Name() + " has bounds type BoundingOBB with no size and is not a Model")
+						}
+
+					case 6: // Convex
+
+						if obj.Type().Is(NodeTypeModel) && obj.(*Model).Mesh != nil {
+							convex := NewBoundingConvex("BoundingConvex", obj.(*Model).Mesh, 0)
+							obj.AddChildren(convex)
+						} else {
+							log.Println("Warning: object " + obj.Name() + " has bounds type BoundingConvex and is not a Model")
+						}
+
+					}
+				}
+
+				if trigger, isTrigger := obj.(*TriggerVolume); isTrigger {
+					if bounds := trigger.Children().BoundingObjects(); len(bounds) > 0 {
+						trigger.Bounds = bounds[len(bounds)-1]
+					} else {
+						log.Println("Warning: trigger volume " + obj.Name() + " has no bounds, and so won't be triggered")
 					}
 				}
 
@@ -1052,6 +1362,21 @@ func LoadGLTFData(data []byte, gltfLoadOptions *GLTFLoadOptions) (*Library, erro
 					}
 				}
 
+				// Waypoints link to other Waypoints by name.
+				if waypoint, isWaypoint := obj.(*Waypoint); isWaypoint {
+
+					if links, exists := dataMap["t3dWaypointLinkNames__"]; exists {
+
+						for _, linkName := range links.([]interface{}) {
+							if other, isWaypoint := findNode(linkName.(string)).(*Waypoint); isWaypoint {
+								waypoint.Link(other, false)
+							}
+						}
+
+					}
+
+				}
+
 			}
 
 		}
@@ -1277,12 +1602,196 @@ func LoadGLTFData(data []byte, gltfLoadOptions *GLTFLoadOptions) (*Library, erro
 
 	// }
 
+	if converter != nil {
+
+		fixes := map[string]importConversionFix{}
+
+		for _, scene := range library.Scenes {
+			for _, node := range scene.Root.Children() {
+				converter.node(node, library, NewMatrix4(), fixes)
+			}
+		}
+
+		for _, anim := range library.Animations {
+			converter.animation(anim, fixes)
+		}
+
+	}
+
 	library.ExportedScene = library.Scenes[*doc.Scene]
 
 	return library, nil
 
 }
 
+// gltfLoadAnimationPointer loads an animation channel that uses KHR_animation_pointer into the Animation, returning the time of the
+// channel's last keyframe. Material base colors (including alpha) and base color texture offsets are supported; channels targeting
+// other properties are skipped.
+func gltfLoadAnimationPointer(doc *gltf.Document, library *Library, anim *Animation, sampler *gltf.AnimationSampler, pointerData interface{}) (float64, error) {
+
+	extension := struct {
+		Pointer string `json:"pointer"`
+	}{}
+
+	if raw, isRaw := pointerData.(json.RawMessage); isRaw {
+		if err := json.Unmarshal(raw, &extension); err != nil {
+			return 0, err
+		}
+	}
+
+	// Pointers are JSON pointers into the glTF document, like "/materials/0/pbrMetallicRoughness/baseColorFactor".
+	parts := strings.SplitN(extension.Pointer, "/", 4)
+
+	if len(parts) < 4 || parts[1] != "materials" {
+		return 0, nil
+	}
+
+	matIndex, err := strconv.Atoi(parts[2])
+	if err != nil || matIndex < 0 || matIndex >= len(doc.Materials) {
+		return 0, nil
+	}
+
+	gltfMat := doc.Materials[matIndex]
+	mat := library.Materials[gltfMat.Name]
+
+	if mat == nil {
+		return 0, nil
+	}
+
+	id, err := modeler.ReadAccessor(doc, doc.Accessors[sampler.Input], nil)
+
+	if err != nil {
+		return 0, err
+	}
+
+	inputData := id.([]float32)
+
+	od, err := modeler.ReadAccessor(doc, doc.Accessors[sampler.Output], nil)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var keyframes []vector.Vector
+	var trackType string
+
+	switch parts[3] {
+
+	case "pbrMetallicRoughness/baseColorFactor":
+
+		outputData, isVec4 := od.([][4]float32)
+
+		if !isVec4 {
+			return 0, nil
+		}
+
+		trackType = TrackTypeColor
+
+		for _, c := range outputData {
+			// Colors are converted to sRGB, just like Materials' colors are when loading.
+			color := NewColor(c[0], c[1], c[2], c[3])
+			color.ConvertTosRGB()
+			keyframes = append(keyframes, vector.Vector{float64(color.R), float64(color.G), float64(color.B), float64(color.A)})
+		}
+
+	case "pbrMetallicRoughness/baseColorTexture/extensions/KHR_texture_transform/offset":
+
+		outputData, isVec2 := od.([][2]float32)
+
+		if !isVec2 {
+			return 0, nil
+		}
+
+		trackType = TrackTypeUVOffset
+
+		// The texture's static offset has already been baked into the Mesh's UV values (see gltfTextureTransform()), so the UVOffset
+		// is only the difference from it. As the offset is applied last, this works regardless of the texture's scale and rotation.
+		baseU, baseV := 0.0, 0.0
+		if pbr := gltfMat.PBRMetallicRoughness; pbr != nil && pbr.BaseColorTexture != nil {
+			if transform, exists := pbr.BaseColorTexture.Extensions[texturetransform.ExtensionName].(*texturetransform.TextureTranform); exists {
+				baseU, baseV = float64(transform.Offset[0]), float64(transform.Offset[1])
+			}
+		}
+
+		for _, o := range outputData {
+			// glTF's V axis points down, while Tetra3D's points up.
+			keyframes = append(keyframes, vector.Vector{float64(o[0]) - baseU, -(float64(o[1]) - baseV)})
+		}
+
+	default:
+		return 0, nil
+	}
+
+	animChannel := anim.MaterialChannels[mat.Name]
+	if animChannel == nil {
+		animChannel = anim.AddMaterialChannel(mat.Name)
+	}
+
+	track := animChannel.AddTrack(trackType)
+	track.Interpolation = int(sampler.Interpolation)
+
+	length := 0.0
+
+	for i := 0; i < len(inputData) && i < len(keyframes); i++ {
+		track.AddKeyframe(float64(inputData[i]), keyframes[i])
+		if float64(inputData[i]) > length {
+			length = float64(inputData[i])
+		}
+	}
+
+	return length, nil
+
+}
+
+// gltfTextureTransform returns the UV set used by the base color texture of the given primitive's material, along with a function
+// that applies the texture's KHR_texture_transform (which Blender exports from Mapping nodes) to glTF UV values. The transform is
+// baked into the primitive's UV values when loading; animated offsets are applied through the Material's UVOffset instead (see
+// gltfLoadAnimationPointer()).
+func gltfTextureTransform(doc *gltf.Document, primitive *gltf.Primitive) (int, func(u, v float64) (float64, float64)) {
+
+	identity := func(u, v float64) (float64, float64) { return u, v }
+
+	if primitive.Material == nil || int(*primitive.Material) >= len(doc.Materials) {
+		return 0, identity
+	}
+
+	pbr := doc.Materials[*primitive.Material].PBRMetallicRoughness
+
+	if pbr == nil || pbr.BaseColorTexture == nil {
+		return 0, identity
+	}
+
+	texCoordSet := gltfTexCoordSet(pbr.BaseColorTexture)
+
+	transform, exists := pbr.BaseColorTexture.Extensions[texturetransform.ExtensionName].(*texturetransform.TextureTranform)
+
+	if !exists {
+		return texCoordSet, identity
+	}
+
+	scale := transform.ScaleOrDefault()
+	sx, sy := float64(scale[0]), float64(scale[1])
+	ox, oy := float64(transform.Offset[0]), float64(transform.Offset[1])
+	sin, cos := math.Sincos(float64(transform.Rotation))
+
+	// The UV values are scaled, then rotated, and then offset.
+	return texCoordSet, func(u, v float64) (float64, float64) {
+		return cos*sx*u + sin*sy*v + ox, -sin*sx*u + cos*sy*v + oy
+	}
+
+}
+
+// gltfTexCoordSet returns the UV set used by the given texture, taking its KHR_texture_transform into account.
+func gltfTexCoordSet(texture *gltf.TextureInfo) int {
+
+	if transform, exists := texture.Extensions[texturetransform.ExtensionName].(*texturetransform.TextureTranform); exists && transform.TexCoord != nil {
+		return int(*transform.TexCoord)
+	}
+
+	return int(texture.TexCoord)
+
+}
+
 func handleGameProperties(p interface{}) (string, interface{}) {
 
 	getOrDefaultInt := func(propMap map[string]interface{}, key string, defaultValue int) int {
@@ -0,0 +1,607 @@
+package tetra3d
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kvartborg/vector"
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/ext/lightspuntual"
+	"github.com/qmuntal/gltf/modeler"
+)
+
+// GLTFSaveOptions represents options one can use to tweak how Scenes are saved to GLTF files.
+type GLTFSaveOptions struct {
+	// If the data should be saved in the binary GLTF format (.glb), rather than as JSON (.gltf); either way, all binary data (like
+	// vertex data) is embedded in the file. SaveGLTFFile() sets this according to the file extension.
+	Binary bool
+
+	// If Materials' textures should be embedded in the file as PNG images; otherwise, textures are referenced by the Materials'
+	// TexturePaths. Note that embedding textures reads them back from the GPU, which can only be done once the game has started.
+	// Defaults to true.
+	EmbedTextures bool
+
+	// The Scene that should be opened by default when the file is loaded. If nil, the first Scene saved is used.
+	ExportedScene *Scene
+}
+
+// DefaultGLTFSaveOptions creates an instance of GLTFSaveOptions with some sensible defaults.
+func DefaultGLTFSaveOptions() *GLTFSaveOptions {
+	return &GLTFSaveOptions{
+		EmbedTextures: true,
+	}
+}
+
+// SaveGLTFFile saves the given Scenes to a .gltf or .glb file at the filepath given, using a provided GLTFSaveOptions struct to alter
+// how the file is saved. Passing nil for saveOptions will save the file using default save options; whether the file is saved in the
+// binary format is decided by the file extension. See SaveGLTF() for more information.
+func SaveGLTFFile(path string, saveOptions *GLTFSaveOptions, scenes ...*Scene) error {
+
+	if saveOptions == nil {
+		saveOptions = DefaultGLTFSaveOptions()
+	}
+
+	options := *saveOptions
+	options.Binary = strings.ToLower(filepath.Ext(path)) == ".glb"
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := SaveGLTF(file, &options, scenes...); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+
+}
+
+// SaveGLTF writes the given Scenes to the Writer provided in the GLTF format, using a provided GLTFSaveOptions struct to alter how
+// the data is saved. Passing nil for saveOptions will save the data using default save options. This is useful for inspecting or
+// re-editing runtime-generated Scenes in Blender, or for loading them again later with LoadGLTFData().
+//
+// The node hierarchy (including each Node's name, local transform, and game properties) is saved, along with Models' Meshes (vertex
+// positions, normals, UVs, and active vertex colors for each MeshPart), Materials (colors, transparency, emission, backface culling,
+// custom properties, and textures), Cameras, and point and directional Lights. Other kinds of Nodes (like bounding objects, Paths, or
+// ambient Lights) are saved as plain Nodes. Skinned Meshes are saved in their bind pose, without their armature bindings, and
+// Animations aren't saved.
+func SaveGLTF(w io.Writer, saveOptions *GLTFSaveOptions, scenes ...*Scene) error {
+
+	if saveOptions == nil {
+		saveOptions = DefaultGLTFSaveOptions()
+	}
+
+	if len(scenes) == 0 {
+		return errors.New("error saving GLTF data: no Scenes given to save")
+	}
+
+	exporter := &gltfExporter{
+		doc:       gltf.NewDocument(),
+		options:   saveOptions,
+		meshes:    map[*Mesh]*uint32{},
+		materials: map[*Material]*uint32{},
+		lights:    []*lightspuntual.Light{},
+	}
+
+	doc := exporter.doc
+	doc.Asset.Generator = "Tetra3D"
+	doc.Scenes = []*gltf.Scene{}
+
+	for i, scene := range scenes {
+
+		gltfScene := &gltf.Scene{Name: scene.Name}
+
+		for _, child := range scene.Root.Children() {
+			index, err := exporter.node(child)
+			if err != nil {
+				return err
+			}
+			gltfScene.Nodes = append(gltfScene.Nodes, index)
+		}
+
+		extras := gltfPropertiesExtras(scene.Properties())
+
+		// The first Scene's extras hold the global export settings.
+		if i == 0 {
+			packTextures := 0
+			if saveOptions.EmbedTextures {
+				packTextures = 1
+			}
+			extras["t3dPackTextures__"] = packTextures
+		}
+
+		if len(extras) > 0 {
+			gltfScene.Extras = extras
+		}
+
+		doc.Scenes = append(doc.Scenes, gltfScene)
+
+		if scene == saveOptions.ExportedScene {
+			doc.Scene = gltf.Index(uint32(i))
+		}
+
+	}
+
+	if doc.Scene == nil {
+		doc.Scene = gltf.Index(0)
+	}
+
+	if len(exporter.lights) > 0 {
+		exporter.useExtension(lightspuntual.ExtensionName)
+		if doc.Extensions == nil {
+			doc.Extensions = gltf.Extensions{}
+		}
+		doc.Extensions[lightspuntual.ExtensionName] = map[string]interface{}{"lights": exporter.lights}
+	}
+
+	encoder := gltf.NewEncoder(w)
+	encoder.AsBinary = saveOptions.Binary
+
+	return encoder.Encode(doc)
+
+}
+
+// gltfExporter holds the state of a GLTF document being saved, so that Meshes and Materials used by multiple Nodes are only saved once.
+type gltfExporter struct {
+	doc       *gltf.Document
+	options   *GLTFSaveOptions
+	meshes    map[*Mesh]*uint32
+	materials map[*Material]*uint32
+	lights    []*lightspuntual.Light
+}
+
+// useExtension adds the given extension to the document's list of used extensions, if it isn't already in there.
+func (exporter *gltfExporter) useExtension(name string) {
+	for _, used := range exporter.doc.ExtensionsUsed {
+		if used == name {
+			return
+		}
+	}
+	exporter.doc.ExtensionsUsed = append(exporter.doc.ExtensionsUsed, name)
+}
+
+// node adds the given Node (and its children, recursively) to the document, returning its index.
+func (exporter *gltfExporter) node(node INode) (uint32, error) {
+
+	gltfNode := &gltf.Node{
+		Name:     node.Name(),
+		Matrix:   gltf.DefaultMatrix,
+		Rotation: gltf.DefaultRotation,
+		Scale:    gltf.DefaultScale,
+	}
+
+	pos := node.LocalPosition()
+	scale := node.LocalScale()
+	rotation := node.LocalRotation().ToQuaternion()
+
+	gltfNode.Translation = [3]float32{float32(pos[0]), float32(pos[1]), float32(pos[2])}
+	gltfNode.Scale = [3]float32{float32(scale[0]), float32(scale[1]), float32(scale[2])}
+	gltfNode.Rotation = [4]float32{float32(rotation.X), float32(rotation.Y), float32(rotation.Z), float32(rotation.W)}
+
+	switch n := node.(type) {
+
+	case *Model:
+
+		if n.Mesh != nil {
+			index, err := exporter.mesh(n.Mesh)
+			if err != nil {
+				return 0, err
+			}
+			gltfNode.Mesh = index
+		}
+
+	case *Camera:
+
+		camera := &gltf.Camera{Name: n.Name()}
+
+		w, h := n.Size()
+		aspectRatio := float32(w) / float32(h)
+
+		if n.Perspective {
+			camera.Perspective = &gltf.Perspective{
+				AspectRatio: gltf.Float(aspectRatio),
+				Yfov:        float32(n.FieldOfView / 360 * math.Pi * 2),
+				Znear:       float32(n.Near),
+				Zfar:        gltf.Float(float32(n.Far)),
+			}
+		} else {
+			camera.Orthographic = &gltf.Orthographic{
+				Xmag:  float32(n.OrthoScale),
+				Ymag:  float32(n.OrthoScale) / aspectRatio,
+				Znear: float32(n.Near),
+				Zfar:  float32(n.Far),
+			}
+		}
+
+		exporter.doc.Cameras = append(exporter.doc.Cameras, camera)
+		gltfNode.Camera = gltf.Index(uint32(len(exporter.doc.Cameras) - 1))
+
+	case *PointLight:
+
+		light := &lightspuntual.Light{
+			Type:      lightspuntual.TypePoint,
+			Name:      n.Name(),
+			Color:     &[3]float32{n.Color.R, n.Color.G, n.Color.B},
+			Intensity: gltf.Float(n.Energy * 1000),
+		}
+
+		if n.Distance > 0 {
+			light.Range = gltf.Float(float32(n.Distance))
+		}
+
+		gltfNode.Extensions = exporter.light(light)
+
+	case *SpotLight:
+
+		light := &lightspuntual.Light{
+			Type:      lightspuntual.TypeSpot,
+			Name:      n.Name(),
+			Color:     &[3]float32{n.Color.R, n.Color.G, n.Color.B},
+			Intensity: gltf.Float(n.Energy * 1000),
+			Spot: &lightspuntual.Spot{
+				InnerConeAngle: float32(ToRadians(n.InnerConeAngle)),
+				OuterConeAngle: gltf.Float(float32(ToRadians(n.OuterConeAngle))),
+			},
+		}
+
+		if n.Distance > 0 {
+			light.Range = gltf.Float(float32(n.Distance))
+		}
+
+		gltfNode.Extensions = exporter.light(light)
+
+	case *DirectionalLight:
+
+		gltfNode.Extensions = exporter.light(&lightspuntual.Light{
+			Type:      lightspuntual.TypeDirectional,
+			Name:      n.Name(),
+			Color:     &[3]float32{n.Color.R, n.Color.G, n.Color.B},
+			Intensity: gltf.Float(n.Energy),
+		})
+
+	}
+
+	if gameProps := gltfGameProperties(node.Properties()); len(gameProps) > 0 {
+		gltfNode.Extras = map[string]interface{}{"t3dGameProperties__": gameProps}
+	}
+
+	// Children are added before their parents, as LoadGLTFData() expects (and Blender exports) them.
+	for _, child := range node.Children() {
+		childIndex, err := exporter.node(child)
+		if err != nil {
+			return 0, err
+		}
+		gltfNode.Children = append(gltfNode.Children, childIndex)
+	}
+
+	exporter.doc.Nodes = append(exporter.doc.Nodes, gltfNode)
+
+	return uint32(len(exporter.doc.Nodes) - 1), nil
+
+}
+
+// light adds the given Light to the document's KHR_lights_punctual lights, returning the extension data for the Node that uses it.
+func (exporter *gltfExporter) light(light *lightspuntual.Light) gltf.Extensions {
+	exporter.lights = append(exporter.lights, light)
+	return gltf.Extensions{lightspuntual.ExtensionName: map[string]interface{}{"light": len(exporter.lights) - 1}}
+}
+
+// mesh adds the given Mesh to the document (if it hasn't been added already), returning its index.
+func (exporter *gltfExporter) mesh(mesh *Mesh) (*uint32, error) {
+
+	if index, exists := exporter.meshes[mesh]; exists {
+		return index, nil
+	}
+
+	doc := exporter.doc
+
+	gltfMesh := &gltf.Mesh{Name: mesh.Name}
+
+	for _, part := range mesh.MeshParts {
+
+		if part.TriangleCount() == 0 {
+			continue
+		}
+
+		start := part.TriangleStart * 3
+		end := part.TriangleEnd * 3
+
+		positions := make([][3]float32, 0, end-start)
+		normals := make([][3]float32, 0, end-start)
+		uvs := make([][2]float32, 0, end-start)
+		uv2s := make([][2]float32, 0, end-start)
+		indices := make([]uint32, 0, end-start)
+
+		colored := false
+		hasUV2s := false
+
+		for i := start; i < end; i++ {
+
+			pos := mesh.VertexPositions[i]
+			normal := mesh.VertexNormals[i]
+			uv := mesh.VertexUVs[i]
+
+			positions = append(positions, [3]float32{float32(pos[0]), float32(pos[1]), float32(pos[2])})
+			normals = append(normals, [3]float32{float32(normal[0]), float32(normal[1]), float32(normal[2])})
+			// V is flipped when loading GLTF files, so we flip it back here.
+			uvs = append(uvs, [2]float32{float32(uv[0]), float32(1 - uv[1])})
+			uv2 := mesh.VertexUV2s[i]
+			uv2s = append(uv2s, [2]float32{float32(uv2[0]), float32(1 - uv2[1])})
+			if uv2[0] != 0 || uv2[1] != 0 {
+				hasUV2s = true
+			}
+			indices = append(indices, uint32(i-start))
+
+			if len(mesh.VertexColors[i]) > 0 {
+				colored = true
+			}
+
+		}
+
+		primitive := &gltf.Primitive{
+			Mode:    gltf.PrimitiveTriangles,
+			Indices: gltf.Index(modeler.WriteIndices(doc, indices)),
+			Attributes: map[string]uint32{
+				gltf.POSITION:   modeler.WritePosition(doc, positions),
+				gltf.NORMAL:     modeler.WriteNormal(doc, normals),
+				gltf.TEXCOORD_0: modeler.WriteTextureCoord(doc, uvs),
+			},
+		}
+
+		if hasUV2s {
+			primitive.Attributes[gltf.TEXCOORD_1] = modeler.WriteTextureCoord(doc, uv2s)
+		}
+
+		if colored {
+
+			colors := make([][4]uint16, 0, end-start)
+
+			for i := start; i < end; i++ {
+
+				color := NewColor(1, 1, 1, 1)
+				if channel := mesh.VertexActiveColorChannel[i]; channel >= 0 && channel < len(mesh.VertexColors[i]) {
+					color = mesh.VertexColors[i][channel].Clone()
+				}
+
+				// Vertex colors are converted to sRGB when loading GLTF files, so we convert them back to linear here.
+				color.ConvertToLinear()
+				colors = append(colors, [4]uint16{
+					color.capRGBA64(color.R),
+					color.capRGBA64(color.G),
+					color.capRGBA64(color.B),
+					color.capRGBA64(color.A),
+				})
+
+			}
+
+			primitive.Attributes["COLOR_0"] = modeler.WriteColor(doc, colors)
+
+		}
+
+		if part.Material != nil {
+			index, err := exporter.material(part.Material)
+			if err != nil {
+				return nil, err
+			}
+			primitive.Material = index
+		}
+
+		gltfMesh.Primitives = append(gltfMesh.Primitives, primitive)
+
+	}
+
+	// A GLTF Mesh has to have at least one primitive, so Meshes without any triangles aren't saved.
+	if len(gltfMesh.Primitives) == 0 {
+		exporter.meshes[mesh] = nil
+		return nil, nil
+	}
+
+	doc.Meshes = append(doc.Meshes, gltfMesh)
+	index := gltf.Index(uint32(len(doc.Meshes) - 1))
+	exporter.meshes[mesh] = index
+
+	return index, nil
+
+}
+
+// material adds the given Material to the document (if it hasn't been added already), returning its index.
+func (exporter *gltfExporter) material(material *Material) (*uint32, error) {
+
+	if index, exists := exporter.materials[material]; exists {
+		return index, nil
+	}
+
+	doc := exporter.doc
+
+	// Material colors are converted to sRGB when loading GLTF files, so we convert them back to linear here.
+	color := material.Color.Clone()
+	color.ConvertToLinear()
+
+	gltfMat := &gltf.Material{
+		Name:        material.Name,
+		DoubleSided: !material.BackfaceCulling,
+		AlphaMode:   gltf.AlphaOpaque,
+		PBRMetallicRoughness: &gltf.PBRMetallicRoughness{
+			BaseColorFactor: &[4]float32{color.R, color.G, color.B, color.A},
+			MetallicFactor:  gltf.Float(0),
+			RoughnessFactor: gltf.Float(1),
+		},
+	}
+
+	switch material.TransparencyMode {
+	case TransparencyModeTransparent:
+		gltfMat.AlphaMode = gltf.AlphaBlend
+	case TransparencyModeAlphaClip:
+		gltfMat.AlphaMode = gltf.AlphaMask
+	}
+
+	if material.EmissionStrength > 0 {
+
+		emission := material.EmissionColor.Clone()
+		emission.ConvertToLinear()
+
+		strength := float32(math.Min(material.EmissionStrength, 1))
+		gltfMat.EmissiveFactor = [3]float32{emission.R * strength, emission.G * strength, emission.B * strength}
+
+		// Emission brighter than 1 is exported using KHR_materials_emissive_strength, as the emissive factor can't go past 1.
+		if material.EmissionStrength > 1 {
+			gltfMat.Extensions = gltf.Extensions{
+				"KHR_materials_emissive_strength": map[string]interface{}{"emissiveStrength": material.EmissionStrength},
+			}
+			exporter.useExtension("KHR_materials_emissive_strength")
+		}
+
+	}
+
+	extras := gltfPropertiesExtras(material.Properties)
+	extras["t3dMaterialColor__"] = []float32{color.R, color.G, color.B, color.A}
+	extras["t3dBillboardMode__"] = material.BillboardMode
+	if material.Shadeless {
+		extras["t3dMaterialShadeless__"] = 1
+	} else {
+		extras["t3dMaterialShadeless__"] = 0
+	}
+	gltfMat.Extras = extras
+
+	textureSource := -1
+
+	if exporter.options.EmbedTextures {
+
+		if material.Texture != nil {
+
+			buffer := &bytes.Buffer{}
+
+			if err := png.Encode(buffer, material.Texture); err != nil {
+				return nil, err
+			}
+
+			imageIndex, err := modeler.WriteImage(doc, material.Name, "image/png", buffer)
+			if err != nil {
+				return nil, err
+			}
+
+			textureSource = int(imageIndex)
+
+		}
+
+	} else if material.TexturePath != "" {
+
+		doc.Images = append(doc.Images, &gltf.Image{Name: material.Name, URI: material.TexturePath})
+		textureSource = len(doc.Images) - 1
+
+	}
+
+	if textureSource >= 0 {
+		doc.Textures = append(doc.Textures, &gltf.Texture{Source: gltf.Index(uint32(textureSource))})
+		gltfMat.PBRMetallicRoughness.BaseColorTexture = &gltf.TextureInfo{Index: uint32(len(doc.Textures) - 1), TexCoord: uint32(material.TextureUVSet)}
+	}
+
+	doc.Materials = append(doc.Materials, gltfMat)
+	index := gltf.Index(uint32(len(doc.Materials) - 1))
+	exporter.materials[material] = index
+
+	return index, nil
+
+}
+
+// gltfPropertyNames returns the names of the Properties given, sorted so that saved files are deterministic.
+func gltfPropertyNames(props *Properties) []string {
+
+	names := make([]string, 0, len(props.props))
+
+	for name := range props.props {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+
+}
+
+// gltfPropertiesExtras returns the Properties given as a map of GLTF extras, as used for custom Material and Scene properties.
+// Colors and vectors are saved as arrays of numbers.
+func gltfPropertiesExtras(props *Properties) map[string]interface{} {
+
+	extras := map[string]interface{}{}
+
+	for _, name := range gltfPropertyNames(props) {
+
+		switch value := props.props[name].Value.(type) {
+		case *Color:
+			extras[name] = []float32{value.R, value.G, value.B, value.A}
+		case vector.Vector:
+			extras[name] = []float64(value)
+		case nil:
+		default:
+			extras[name] = value
+		}
+
+	}
+
+	return extras
+
+}
+
+// gltfGameProperties returns the Properties given in the format the Tetra3D Blender add-on uses to export game properties for Nodes.
+// Properties with values of unsupported types are skipped.
+func gltfGameProperties(props *Properties) []interface{} {
+
+	gameProps := []interface{}{}
+
+	for _, name := range gltfPropertyNames(props) {
+
+		prop := map[string]interface{}{"name": name}
+
+		switch value := props.props[name].Value.(type) {
+
+		case bool:
+			prop["valueType"] = 0
+			if value {
+				prop["valueBool"] = 1
+			} else {
+				prop["valueBool"] = 0
+			}
+		case int:
+			prop["valueType"] = 1
+			prop["valueInt"] = value
+		case float64:
+			prop["valueType"] = 2
+			prop["valueFloat"] = value
+		case string:
+			prop["valueType"] = 3
+			prop["valueString"] = value
+		case *Color:
+			// Colors are converted to sRGB when loading, so we convert them back to linear here.
+			color := value.Clone()
+			color.ConvertToLinear()
+			prop["valueType"] = 5
+			prop["valueColor"] = []float32{color.R, color.G, color.B, color.A}
+		case vector.Vector:
+			if len(value) < 3 {
+				continue
+			}
+			// Vectors are converted from Blender's Z-up coordinate system when loading, so we convert them back here.
+			prop["valueType"] = 6
+			prop["valueVector3D"] = []float64{value[0], -value[2], value[1]}
+		default:
+			continue
+
+		}
+
+		gameProps = append(gameProps, prop)
+
+	}
+
+	return gameProps
+
+}
@@ -0,0 +1,727 @@
+package tetra3d
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/qmuntal/gltf"
+)
+
+// The EXT_meshopt_compression glTF extension compresses buffer views using meshoptimizer's codecs (as done by gltfpack, or by Blender's
+// glTF exporter with compression enabled). The compressed data lives in a separate buffer, while the buffer views point into a "fallback"
+// buffer that usually has no data at all; we decode each compressed buffer view into the fallback buffer, so that the rest of the loader
+// can read the data as usual.
+
+// gltfDecodeDocument decodes the glTF document in the given .gltf or .glb data, loading its embedded buffers. Documents are decoded using
+// gltf.Decoder, except for documents using EXT_meshopt_compression; the Decoder fails to load their fallback buffers, as they have no URIs,
+// so those documents are decoded here instead, with the fallback buffers left empty for gltfDecodeMeshopt() to fill.
+func gltfDecodeDocument(data []byte) (*gltf.Document, error) {
+
+	doc := gltf.NewDocument()
+
+	if !bytes.Contains(data, []byte("EXT_meshopt_compression")) {
+		if err := gltf.NewDecoder(bytes.NewReader(data)).Decode(doc); err != nil {
+			return nil, err
+		}
+		return doc, nil
+	}
+
+	jsonData := data
+	binaryChunk := []byte{}
+	isBinary := len(data) >= 12 && string(data[:4]) == "glTF"
+
+	if isBinary {
+
+		// A GLB file is a 12-byte header followed by chunks, each with an 8-byte header (its length and type); the JSON chunk comes first,
+		// followed by the optional binary chunk.
+		chunks := data[12:]
+
+		if len(chunks) < 8 || string(chunks[4:8]) != "JSON" || 8+int(binary.LittleEndian.Uint32(chunks)) > len(chunks) {
+			return nil, errors.New("error loading gltf data: invalid GLB JSON chunk")
+		}
+
+		jsonData = chunks[8 : 8+binary.LittleEndian.Uint32(chunks)]
+		chunks = chunks[8+len(jsonData):]
+
+		if len(chunks) >= 8 && string(chunks[4:8]) == "BIN\x00" {
+			if 8+int(binary.LittleEndian.Uint32(chunks)) > len(chunks) {
+				return nil, errors.New("error loading gltf data: invalid GLB BIN chunk")
+			}
+			binaryChunk = chunks[8 : 8+binary.LittleEndian.Uint32(chunks)]
+		}
+
+	}
+
+	if err := json.Unmarshal(jsonData, doc); err != nil {
+		return nil, err
+	}
+
+	for i, buffer := range doc.Buffers {
+
+		errorPrefix := "error loading gltf data: buffer " + strconv.Itoa(i) + " "
+
+		if buffer.URI == "" {
+
+			if isBinary && i == 0 {
+
+				// The first buffer without a URI in a GLB file is the file's binary chunk.
+				if len(binaryChunk) < int(buffer.ByteLength) {
+					return nil, errors.New(errorPrefix + "is larger than the GLB BIN chunk")
+				}
+				buffer.Data = binaryChunk[:buffer.ByteLength]
+
+			} else if _, isFallback := buffer.Extensions["EXT_meshopt_compression"]; isFallback {
+				buffer.Data = make([]byte, buffer.ByteLength)
+			} else {
+				return nil, errors.New(errorPrefix + "has no URI")
+			}
+
+		} else if buffer.IsEmbeddedResource() {
+
+			encoded := buffer.URI[strings.Index(buffer.URI, ",")+1:]
+
+			decoded, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, errors.New(errorPrefix + "has invalid embedded data: " + err.Error())
+			}
+			buffer.Data = decoded
+
+		}
+
+		// Buffers in external files aren't loaded, as with gltf.Decoder without a file system.
+
+	}
+
+	return doc, nil
+
+}
+
+type gltfMeshoptBufferView struct {
+	Buffer     int    `json:"buffer"`
+	ByteOffset int    `json:"byteOffset"`
+	ByteLength int    `json:"byteLength"`
+	ByteStride int    `json:"byteStride"`
+	Count      int    `json:"count"`
+	Mode       string `json:"mode"`
+	Filter     string `json:"filter"`
+}
+
+// gltfDecodeMeshopt decodes the document's buffer views that are compressed using EXT_meshopt_compression into the buffers they point to.
+func gltfDecodeMeshopt(doc *gltf.Document) error {
+
+	for viewIndex, bufferView := range doc.BufferViews {
+
+		rawExtension, exists := bufferView.Extensions["EXT_meshopt_compression"]
+		if !exists {
+			continue
+		}
+
+		extensionData, ok := rawExtension.(json.RawMessage)
+		if !ok {
+			continue
+		}
+
+		view := gltfMeshoptBufferView{}
+		if err := json.Unmarshal(extensionData, &view); err != nil {
+			return err
+		}
+
+		errorPrefix := "error decoding meshopt-compressed buffer view " + strconv.Itoa(viewIndex) + ": "
+
+		if view.Buffer < 0 || view.Buffer >= len(doc.Buffers) || view.ByteOffset < 0 || view.ByteLength < 0 || view.ByteOffset+view.ByteLength > len(doc.Buffers[view.Buffer].Data) {
+			return errors.New(errorPrefix + "compressed data lies outside of its buffer")
+		}
+
+		if view.Count < 0 || view.ByteStride <= 0 {
+			return errors.New(errorPrefix + "invalid count or byte stride")
+		}
+
+		source := doc.Buffers[view.Buffer].Data[view.ByteOffset : view.ByteOffset+view.ByteLength]
+		decoded := make([]byte, view.Count*view.ByteStride)
+
+		var err error
+
+		switch view.Mode {
+		case "ATTRIBUTES":
+			err = meshoptDecodeVertexBuffer(decoded, view.Count, view.ByteStride, source)
+			if err == nil {
+				err = meshoptDecodeFilter(decoded, view.Count, view.ByteStride, view.Filter)
+			}
+		case "TRIANGLES":
+			err = meshoptDecodeIndexBuffer(decoded, view.Count, view.ByteStride, source)
+		case "INDICES":
+			err = meshoptDecodeIndexSequence(decoded, view.Count, view.ByteStride, source)
+		default:
+			err = errors.New("unknown mode " + view.Mode)
+		}
+
+		if err != nil {
+			return errors.New(errorPrefix + err.Error())
+		}
+
+		if int(bufferView.Buffer) >= len(doc.Buffers) {
+			return errors.New(errorPrefix + "buffer view refers to a buffer that doesn't exist")
+		}
+
+		target := doc.Buffers[bufferView.Buffer]
+
+		if len(target.Data) < int(target.ByteLength) {
+			data := make([]byte, target.ByteLength)
+			copy(data, target.Data)
+			target.Data = data
+		}
+
+		if int(bufferView.ByteOffset)+len(decoded) > len(target.Data) {
+			return errors.New(errorPrefix + "decoded data doesn't fit in the buffer view's buffer")
+		}
+
+		copy(target.Data[bufferView.ByteOffset:], decoded)
+
+	}
+
+	return nil
+
+}
+
+// meshoptDecodeVertexBuffer decodes vertex data compressed with meshoptimizer's vertex codec (version 0) into destination.
+func meshoptDecodeVertexBuffer(destination []byte, count, stride int, source []byte) error {
+
+	if stride%4 > 0 || stride > 256 {
+		return errors.New("attribute byte stride must be a multiple of 4 and no more than 256")
+	}
+
+	if len(source) < 1+stride {
+		return errors.New("not enough data")
+	}
+
+	if source[0] != 0xA0 {
+		return errors.New("unsupported vertex codec version")
+	}
+
+	// The vertex before the first one is stored at the very end of the data.
+	lastVertex := make([]byte, stride)
+	copy(lastVertex, source[len(source)-stride:])
+
+	blockSize := (8192 / stride) &^ 15
+	if blockSize > 256 {
+		blockSize = 256
+	}
+
+	data := source[1:]
+	groups := make([]byte, 256)
+
+	for offset := 0; offset < count; offset += blockSize {
+
+		blockCount := blockSize
+		if offset+blockCount > count {
+			blockCount = count - offset
+		}
+
+		alignedCount := (blockCount + 15) &^ 15
+
+		// Each byte of the vertices in the block is encoded separately, as deltas from the same byte of the previous vertex.
+		for k := 0; k < stride; k++ {
+
+			var err error
+			if data, err = meshoptDecodeBytes(data, groups[:alignedCount]); err != nil {
+				return err
+			}
+
+			previous := lastVertex[k]
+
+			for i := 0; i < blockCount; i++ {
+				delta := groups[i]
+				previous += (delta >> 1) ^ -(delta & 1)
+				destination[(offset+i)*stride+k] = previous
+			}
+
+		}
+
+		copy(lastVertex, destination[(offset+blockCount-1)*stride:(offset+blockCount)*stride])
+
+	}
+
+	tailSize := stride
+	if tailSize < 32 {
+		tailSize = 32
+	}
+
+	if len(data) != tailSize {
+		return errors.New("unexpected amount of data after vertices")
+	}
+
+	return nil
+
+}
+
+// meshoptDecodeBytes decodes a run of bytes (with a length that's a multiple of 16) from meshoptimizer's vertex codec, returning the
+// remaining data. Bytes are encoded in groups of 16, where each group uses 0, 2, 4, or 8 bits per byte.
+func meshoptDecodeBytes(data []byte, out []byte) ([]byte, error) {
+
+	groupCount := len(out) / 16
+	headerSize := (groupCount + 3) / 4
+
+	if len(data) < headerSize {
+		return nil, errors.New("not enough data")
+	}
+
+	header := data[:headerSize]
+	data = data[headerSize:]
+
+	for g := 0; g < groupCount; g++ {
+
+		group := out[g*16 : g*16+16]
+		bitsLog2 := (header[g/4] >> ((g % 4) * 2)) & 3
+
+		switch bitsLog2 {
+
+		case 0:
+			for i := range group {
+				group[i] = 0
+			}
+
+		case 3:
+			if len(data) < 16 {
+				return nil, errors.New("not enough data")
+			}
+			copy(group, data[:16])
+			data = data[16:]
+
+		default:
+
+			bits := 1 << bitsLog2
+			packedSize := bits * 2
+			if len(data) < packedSize {
+				return nil, errors.New("not enough data")
+			}
+
+			packed := data[:packedSize]
+			extra := packedSize
+
+			// Values are packed starting from the highest bits; a value with all bits set means the actual byte follows the packed values.
+			sentinel := byte(1<<bits) - 1
+			perByte := 8 / bits
+
+			for i := range group {
+
+				value := (packed[i/perByte] >> (8 - bits*(i%perByte+1))) & sentinel
+
+				if value == sentinel {
+					if extra >= len(data) {
+						return nil, errors.New("not enough data")
+					}
+					value = data[extra]
+					extra++
+				}
+
+				group[i] = value
+
+			}
+
+			data = data[extra:]
+
+		}
+
+	}
+
+	return data, nil
+
+}
+
+// meshoptDecodeFilter reverses a filter applied to vertex data before it was compressed using meshoptimizer's vertex codec.
+func meshoptDecodeFilter(data []byte, count, stride int, filter string) error {
+
+	switch filter {
+
+	case "", "NONE":
+
+	case "OCTAHEDRAL":
+
+		if stride != 4 && stride != 8 {
+			return errors.New("octahedral filter requires a byte stride of 4 or 8")
+		}
+
+		componentSize := stride / 4
+		maxValue := float32(int(1)<<(componentSize*8-1) - 1)
+
+		for i := 0; i < count; i++ {
+
+			vec := meshoptReadSigned(data[i*stride:], componentSize, 3)
+
+			x, y := vec[0], vec[1]
+			z := vec[2] - float32(math.Abs(float64(x))) - float32(math.Abs(float64(y)))
+
+			// Fix up the octahedral coordinates for the lower hemisphere.
+			t := z
+			if t > 0 {
+				t = 0
+			}
+			if x >= 0 {
+				x += t
+			} else {
+				x -= t
+			}
+			if y >= 0 {
+				y += t
+			} else {
+				y -= t
+			}
+
+			scale := maxValue / float32(math.Sqrt(float64(x*x+y*y+z*z)))
+
+			meshoptWriteSigned(data[i*stride:], componentSize, []float32{x * scale, y * scale, z * scale})
+
+		}
+
+	case "QUATERNION":
+
+		if stride != 8 {
+			return errors.New("quaternion filter requires a byte stride of 8")
+		}
+
+		for i := 0; i < count; i++ {
+
+			element := data[i*8:]
+			packed := meshoptReadSigned(element, 2, 4)
+
+			// The highest component is left out, with its index stored in the lowest two bits of the last value.
+			maxComponent := int(int16(binary.LittleEndian.Uint16(element[6:]))) & 3
+			scale := float32(1/math.Sqrt2) / float32(int(packed[3])|3)
+
+			x, y, z := packed[0]*scale, packed[1]*scale, packed[2]*scale
+			ww := 1 - x*x - y*y - z*z
+			if ww < 0 {
+				ww = 0
+			}
+			w := float32(math.Sqrt(float64(ww)))
+
+			values := [4]float32{}
+			values[(maxComponent+1)&3] = x * 32767
+			values[(maxComponent+2)&3] = y * 32767
+			values[(maxComponent+3)&3] = z * 32767
+			values[maxComponent] = w * 32767
+
+			meshoptWriteSigned(element, 2, values[:])
+
+		}
+
+	case "EXPONENTIAL":
+
+		for i := 0; i < count*stride/4; i++ {
+
+			v := binary.LittleEndian.Uint32(data[i*4:])
+
+			// The lowest 24 bits are a signed mantissa, while the highest 8 bits are a signed exponent.
+			mantissa := int32(v<<8) >> 8
+			exponent := int32(v) >> 24
+
+			value := math.Float32frombits(uint32(exponent+127)<<23) * float32(mantissa)
+			binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(value))
+
+		}
+
+	default:
+		return errors.New("unknown filter " + filter)
+
+	}
+
+	return nil
+
+}
+
+func meshoptReadSigned(data []byte, componentSize, count int) []float32 {
+	values := make([]float32, count)
+	for i := range values {
+		if componentSize == 1 {
+			values[i] = float32(int8(data[i]))
+		} else {
+			values[i] = float32(int16(binary.LittleEndian.Uint16(data[i*2:])))
+		}
+	}
+	return values
+}
+
+// meshoptWriteSigned rounds the given values and writes them as signed integers of the given size.
+func meshoptWriteSigned(data []byte, componentSize int, values []float32) {
+	for i, v := range values {
+		if v >= 0 {
+			v += 0.5
+		} else {
+			v -= 0.5
+		}
+		if componentSize == 1 {
+			data[i] = byte(int8(int32(v)))
+		} else {
+			binary.LittleEndian.PutUint16(data[i*2:], uint16(int16(int32(v))))
+		}
+	}
+}
+
+// meshoptDecodeIndexBuffer decodes triangle indices compressed with meshoptimizer's index codec (version 0 or 1) into destination.
+func meshoptDecodeIndexBuffer(destination []byte, count, indexSize int, source []byte) error {
+
+	if indexSize != 2 && indexSize != 4 {
+		return errors.New("triangle byte stride must be 2 or 4")
+	}
+
+	if count%3 > 0 {
+		return errors.New("triangle index count must be a multiple of 3")
+	}
+
+	if len(source) < 1+count/3+16 {
+		return errors.New("not enough data")
+	}
+
+	if source[0]&0xF0 != 0xE0 || source[0]&0x0F > 1 {
+		return errors.New("unsupported index codec version")
+	}
+
+	fecMax := 15
+	if source[0]&0x0F >= 1 {
+		fecMax = 13
+	}
+
+	edgeFifo := [16][2]uint32{}
+	vertexFifo := [16]uint32{}
+	for i := range vertexFifo {
+		vertexFifo[i] = math.MaxUint32
+		edgeFifo[i] = [2]uint32{math.MaxUint32, math.MaxUint32}
+	}
+
+	edgeOffset := 0
+	vertexOffset := 0
+
+	pushEdge := func(a, b uint32) {
+		edgeFifo[edgeOffset] = [2]uint32{a, b}
+		edgeOffset = (edgeOffset + 1) & 15
+	}
+
+	pushVertex := func(v uint32, push bool) {
+		vertexFifo[vertexOffset] = v
+		if push {
+			vertexOffset = (vertexOffset + 1) & 15
+		}
+	}
+
+	codes := source[1 : 1+count/3]
+	data := source[1+count/3:]
+	dataEnd := len(data) - 16
+	codeAuxTable := data[dataEnd:]
+	dataIndex := 0
+
+	var next, last uint32
+
+	// Free indices are stored as zigzag-encoded deltas from the last free index.
+	decodeIndex := func() uint32 {
+		v := meshoptDecodeVByte(data, &dataIndex)
+		last += (v >> 1) ^ -(v & 1)
+		return last
+	}
+
+	for i := 0; i < count; i += 3 {
+
+		// Each triangle reads at most 16 bytes of data, which the code aux table at the end guarantees are there.
+		if dataIndex > dataEnd {
+			return errors.New("not enough data")
+		}
+
+		code := codes[i/3]
+		var a, b, c uint32
+
+		if code < 0xF0 {
+
+			// The triangle shares an edge with a recent triangle.
+			edge := edgeFifo[(edgeOffset-1-int(code>>4))&15]
+			a, b = edge[0], edge[1]
+
+			fec := int(code & 15)
+
+			if fec < fecMax {
+
+				if fec == 0 {
+					c = next
+					next++
+				} else {
+					c = vertexFifo[(vertexOffset-1-fec)&15]
+				}
+
+				pushVertex(c, fec == 0)
+
+			} else {
+
+				if fec != 15 {
+					// 13 and 14 are the last free index minus and plus one.
+					last += uint32(fec - (fec ^ 3))
+					c = last
+				} else {
+					c = decodeIndex()
+				}
+
+				pushVertex(c, true)
+
+			}
+
+			pushEdge(c, b)
+			pushEdge(a, c)
+
+		} else {
+
+			var feb, fec int
+
+			if code < 0xFE {
+
+				codeAux := codeAuxTable[code&15]
+				feb, fec = int(codeAux>>4), int(codeAux&15)
+
+				a = next
+				next++
+
+			} else {
+
+				codeAux := data[dataIndex]
+				dataIndex++
+				feb, fec = int(codeAux>>4), int(codeAux&15)
+
+				if codeAux == 0 {
+					next = 0
+				}
+
+				if code == 0xFE {
+					a = next
+					next++
+				}
+
+			}
+
+			if feb == 0 {
+				b = next
+				next++
+			} else if feb != 15 {
+				b = vertexFifo[(vertexOffset-feb)&15]
+			}
+
+			if fec == 0 {
+				c = next
+				next++
+			} else if fec != 15 {
+				c = vertexFifo[(vertexOffset-fec)&15]
+			}
+
+			if code == 0xFF {
+				a = decodeIndex()
+			}
+			if feb == 15 {
+				b = decodeIndex()
+			}
+			if fec == 15 {
+				c = decodeIndex()
+			}
+
+			pushVertex(a, true)
+			pushVertex(b, feb == 0 || feb == 15)
+			pushVertex(c, fec == 0 || fec == 15)
+
+			pushEdge(b, a)
+			pushEdge(c, b)
+			pushEdge(a, c)
+
+		}
+
+		meshoptWriteIndex(destination, i, indexSize, a)
+		meshoptWriteIndex(destination, i+1, indexSize, b)
+		meshoptWriteIndex(destination, i+2, indexSize, c)
+
+	}
+
+	if dataIndex != dataEnd {
+		return errors.New("unexpected amount of data after triangles")
+	}
+
+	return nil
+
+}
+
+// meshoptDecodeIndexSequence decodes indices compressed with meshoptimizer's index sequence codec into destination.
+func meshoptDecodeIndexSequence(destination []byte, count, indexSize int, source []byte) error {
+
+	if indexSize != 2 && indexSize != 4 {
+		return errors.New("index byte stride must be 2 or 4")
+	}
+
+	if len(source) < 1+count+4 {
+		return errors.New("not enough data")
+	}
+
+	if source[0]&0xF0 != 0xD0 || source[0]&0x0F > 1 {
+		return errors.New("unsupported index sequence codec version")
+	}
+
+	data := source[1:]
+	dataEnd := len(data) - 4
+	dataIndex := 0
+
+	// Each index is a zigzag-encoded delta from one of two previous indices, chosen by the lowest bit.
+	last := [2]uint32{}
+
+	for i := 0; i < count; i++ {
+
+		if dataIndex >= dataEnd {
+			return errors.New("not enough data")
+		}
+
+		v := meshoptDecodeVByte(data, &dataIndex)
+
+		baseline := v & 1
+		v >>= 1
+
+		last[baseline] += (v >> 1) ^ -(v & 1)
+
+		meshoptWriteIndex(destination, i, indexSize, last[baseline])
+
+	}
+
+	if dataIndex != dataEnd {
+		return errors.New("unexpected amount of data after indices")
+	}
+
+	return nil
+
+}
+
+// meshoptDecodeVByte decodes a variable-length integer of up to 5 bytes (7 bits per byte) from data at the given index, advancing it.
+func meshoptDecodeVByte(data []byte, index *int) uint32 {
+
+	var result uint32
+	shift := 0
+
+	for i := 0; i < 5 && *index < len(data); i++ {
+
+		b := data[*index]
+		*index++
+
+		result |= uint32(b&127) << shift
+		shift += 7
+
+		if b < 128 {
+			break
+		}
+
+	}
+
+	return result
+
+}
+
+func meshoptWriteIndex(destination []byte, i, indexSize int, index uint32) {
+	if indexSize == 2 {
+		binary.LittleEndian.PutUint16(destination[i*2:], uint16(index))
+	} else {
+		binary.LittleEndian.PutUint32(destination[i*4:], index)
+	}
+}
@@ -0,0 +1,726 @@
+package tetra3d
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/kvartborg/vector"
+)
+
+// The encoders below follow meshoptimizer's (meshopt_encodeVertexBuffer(), meshopt_encodeIndexBuffer(), meshopt_encodeIndexSequence(),
+// and the meshopt_encodeFilter functions), so that the decoders can be tested against data laid out as gltfpack lays it out. They
+// count which encodings they use, so that the tests can make sure every path through the decoders is covered.
+
+type meshoptEncodingCounts map[string]int
+
+// meshoptEncodeVertexBuffer encodes the given vertex data with meshoptimizer's vertex codec (version 0).
+func meshoptEncodeVertexBuffer(data []byte, count, stride int, counts meshoptEncodingCounts) []byte {
+
+	out := []byte{0xA0}
+
+	blockSize := (8192 / stride) &^ 15
+	if blockSize > 256 {
+		blockSize = 256
+	}
+
+	// The first vertex is the baseline that the first block's deltas are from.
+	lastVertex := append([]byte{}, data[:stride]...)
+	deltas := make([]byte, 256)
+
+	for offset := 0; offset < count; offset += blockSize {
+
+		blockCount := blockSize
+		if offset+blockCount > count {
+			blockCount = count - offset
+		}
+
+		alignedCount := (blockCount + 15) &^ 15
+
+		for k := 0; k < stride; k++ {
+
+			for i := range deltas {
+				deltas[i] = 0
+			}
+
+			previous := lastVertex[k]
+
+			for i := 0; i < blockCount; i++ {
+				b := data[(offset+i)*stride+k]
+				delta := b - previous
+				deltas[i] = (delta << 1) ^ byte(int8(delta)>>7)
+				previous = b
+			}
+
+			out = meshoptEncodeBytes(out, deltas[:alignedCount], counts)
+
+		}
+
+		copy(lastVertex, data[(offset+blockCount-1)*stride:])
+
+	}
+
+	tailSize := stride
+	if tailSize < 32 {
+		tailSize = 32
+	}
+
+	out = append(out, make([]byte, tailSize-stride)...)
+	return append(out, data[:stride]...)
+
+}
+
+// meshoptEncodeBytes encodes the given bytes in groups of 16, using the smallest of the 0, 2, 4, and 8 bits per byte encodings for each.
+func meshoptEncodeBytes(out []byte, values []byte, counts meshoptEncodingCounts) []byte {
+
+	groupCount := len(values) / 16
+	header := make([]byte, (groupCount+3)/4)
+	body := []byte{}
+
+	for g := 0; g < groupCount; g++ {
+
+		group := values[g*16 : g*16+16]
+
+		bestBitsLog2 := 3
+		best := group
+
+		for bitsLog2 := 0; bitsLog2 < 3; bitsLog2++ {
+			if encoded, ok := meshoptEncodeGroup(group, bitsLog2); ok && len(encoded) < len(best) {
+				bestBitsLog2 = bitsLog2
+				best = encoded
+			}
+		}
+
+		header[g/4] |= byte(bestBitsLog2) << ((g % 4) * 2)
+		body = append(body, best...)
+		counts[fmt.Sprintf("%d-bit groups", map[int]int{0: 0, 1: 2, 2: 4, 3: 8}[bestBitsLog2])]++
+
+	}
+
+	return append(append(out, header...), body...)
+
+}
+
+func meshoptEncodeGroup(group []byte, bitsLog2 int) ([]byte, bool) {
+
+	if bitsLog2 == 0 {
+		for _, v := range group {
+			if v != 0 {
+				return nil, false
+			}
+		}
+		return []byte{}, true
+	}
+
+	bits := 1 << bitsLog2
+	sentinel := byte(1<<bits) - 1
+	perByte := 8 / bits
+
+	packed := make([]byte, bits*2)
+	extra := []byte{}
+
+	for i, v := range group {
+		code := v
+		if v >= sentinel {
+			code = sentinel
+			extra = append(extra, v)
+		}
+		packed[i/perByte] |= code << (8 - bits*(i%perByte+1))
+	}
+
+	return append(packed, extra...), true
+
+}
+
+var meshoptCodeAuxTable = []byte{0x00, 0x76, 0x87, 0x56, 0x67, 0x78, 0xa9, 0x86, 0x65, 0x89, 0x68, 0x98, 0x01, 0x69, 0x00, 0x00}
+
+// meshoptEncodeIndexBuffer encodes the given triangle indices with meshoptimizer's index codec (version 1).
+func meshoptEncodeIndexBuffer(indices []uint32, counts meshoptEncodingCounts) []byte {
+
+	const fecMax = 13
+
+	codes := []byte{}
+	data := []byte{}
+
+	edgeFifo := [16][2]uint32{}
+	vertexFifo := [16]uint32{}
+	for i := range vertexFifo {
+		vertexFifo[i] = math.MaxUint32
+		edgeFifo[i] = [2]uint32{math.MaxUint32, math.MaxUint32}
+	}
+
+	edgeOffset := 0
+	vertexOffset := 0
+
+	pushEdge := func(a, b uint32) {
+		edgeFifo[edgeOffset] = [2]uint32{a, b}
+		edgeOffset = (edgeOffset + 1) & 15
+	}
+
+	pushVertex := func(v uint32) {
+		vertexFifo[vertexOffset] = v
+		vertexOffset = (vertexOffset + 1) & 15
+	}
+
+	getVertex := func(v uint32) int {
+		for i := 0; i < 16; i++ {
+			if vertexFifo[(vertexOffset-1-i)&15] == v {
+				return i
+			}
+		}
+		return -1
+	}
+
+	var next, last uint32
+
+	encodeIndex := func(index uint32) {
+		d := index - last
+		data = meshoptEncodeVByte(data, (d<<1)^uint32(int32(d)>>31))
+		last = index
+	}
+
+	for i := 0; i < len(indices); i += 3 {
+
+		tri := [3]uint32{indices[i], indices[i+1], indices[i+2]}
+
+		edge, rotation := -1, 0
+		for e := 0; e < 16 && edge < 0; e++ {
+			fifoEdge := edgeFifo[(edgeOffset-1-e)&15]
+			for r := 0; r < 3; r++ {
+				if fifoEdge[0] == tri[r] && fifoEdge[1] == tri[(r+1)%3] {
+					edge, rotation = e, r
+					break
+				}
+			}
+		}
+
+		if edge >= 0 && edge < 15 {
+
+			a, b, c := tri[rotation], tri[(rotation+1)%3], tri[(rotation+2)%3]
+
+			fc := getVertex(c)
+			fec := 15
+
+			if fc >= 1 && fc < fecMax {
+				fec = fc
+				counts["triangles sharing an edge and a recent vertex"]++
+			} else if c == next {
+				fec = 0
+				next++
+				counts["triangles sharing an edge with a new vertex"]++
+			} else if c+1 == last || c == last+1 {
+				fec = 14
+				if c+1 == last {
+					fec = 13
+				}
+				last = c
+				counts["triangles sharing an edge with the last free vertex plus or minus one"]++
+			} else {
+				counts["triangles sharing an edge with a free vertex"]++
+			}
+
+			codes = append(codes, byte(edge<<4|fec))
+
+			if fec == 15 {
+				encodeIndex(c)
+			}
+
+			if fec == 0 || fec >= fecMax {
+				pushVertex(c)
+			}
+
+			pushEdge(c, b)
+			pushEdge(a, c)
+
+		} else {
+
+			// The triangle is rotated so that the next new vertex comes first, if it has it.
+			if tri[1] == next {
+				tri = [3]uint32{tri[1], tri[2], tri[0]}
+			} else if tri[2] == next {
+				tri = [3]uint32{tri[2], tri[0], tri[1]}
+			}
+
+			a, b, c := tri[0], tri[1], tri[2]
+			fb, fc := getVertex(b), getVertex(c)
+
+			fea := 15
+			if a == next {
+				fea = 0
+				next++
+			}
+
+			feb := 15
+			if fb >= 0 && fb < 14 {
+				feb = fb + 1
+			} else if b == next {
+				feb = 0
+				next++
+			}
+
+			fec := 15
+			if fc >= 0 && fc < 14 {
+				fec = fc + 1
+			} else if c == next {
+				fec = 0
+				next++
+			}
+
+			codeAux := byte(feb<<4 | fec)
+			codeAuxIndex := bytes.IndexByte(meshoptCodeAuxTable[:14], codeAux)
+
+			if fea == 0 && codeAuxIndex >= 0 {
+				codes = append(codes, 0xF0|byte(codeAuxIndex))
+				counts["new triangles with a code from the table"]++
+			} else {
+				codes = append(codes, 0xF0|byte(14+fea/15))
+				data = append(data, codeAux)
+				counts[fmt.Sprintf("new triangles with code %X", 0xF0|byte(14+fea/15))]++
+			}
+
+			if fea == 15 {
+				encodeIndex(a)
+			}
+			if feb == 15 {
+				encodeIndex(b)
+			}
+			if fec == 15 {
+				encodeIndex(c)
+			}
+
+			pushVertex(a)
+			if feb == 0 || feb == 15 {
+				pushVertex(b)
+			}
+			if fec == 0 || fec == 15 {
+				pushVertex(c)
+			}
+
+			pushEdge(b, a)
+			pushEdge(c, b)
+			pushEdge(a, c)
+
+		}
+
+	}
+
+	out := append([]byte{0xE1}, codes...)
+	out = append(out, data...)
+	return append(out, meshoptCodeAuxTable...)
+
+}
+
+// meshoptEncodeIndexSequence encodes the given indices with meshoptimizer's index sequence codec (version 1).
+func meshoptEncodeIndexSequence(indices []uint32) []byte {
+
+	out := []byte{0xD1}
+	last := [2]uint32{}
+	current := uint32(0)
+
+	for _, index := range indices {
+
+		// Each index is encoded as a delta from whichever of the last two baselines is closer.
+		cd := int32(index - last[current])
+		if cd >= 30 || cd <= -30 {
+			current ^= 1
+		}
+
+		d := index - last[current]
+		v := (d << 1) ^ uint32(int32(d)>>31)
+		out = meshoptEncodeVByte(out, v<<1|current)
+		last[current] = index
+
+	}
+
+	return append(out, 0, 0, 0, 0)
+
+}
+
+func meshoptEncodeVByte(out []byte, v uint32) []byte {
+	for v >= 128 {
+		out = append(out, byte(v&127|128))
+		v >>= 7
+	}
+	return append(out, byte(v))
+}
+
+func meshoptQuantizeSnorm(v float64, bits int) int {
+	scale := float64(int(1)<<(bits-1) - 1)
+	v = math.Max(math.Min(v, 1), -1)
+	return int(math.Round(v * scale))
+}
+
+// meshoptEncodeOctahedral encodes the given unit vectors with the octahedral filter, using components of the given size in bytes.
+func meshoptEncodeOctahedral(normals [][3]float64, componentSize int) []byte {
+
+	out := make([]byte, len(normals)*componentSize*4)
+	bits := componentSize * 8
+
+	for i, n := range normals {
+
+		scale := 1 / (math.Abs(n[0]) + math.Abs(n[1]) + math.Abs(n[2]))
+		x, y := n[0]*scale, n[1]*scale
+		u, v := x, y
+
+		if n[2] < 0 {
+			u = (1 - math.Abs(y)) * math.Copysign(1, x)
+			v = (1 - math.Abs(x)) * math.Copysign(1, y)
+		}
+
+		values := []int{meshoptQuantizeSnorm(u, bits), meshoptQuantizeSnorm(v, bits), meshoptQuantizeSnorm(1, bits), 0}
+
+		for c, value := range values {
+			if componentSize == 1 {
+				out[i*4+c] = byte(int8(value))
+			} else {
+				binary.LittleEndian.PutUint16(out[i*8+c*2:], uint16(int16(value)))
+			}
+		}
+
+	}
+
+	return out
+
+}
+
+// meshoptEncodeQuaternion encodes the given unit quaternions with the quaternion filter, using 16 bits per component.
+func meshoptEncodeQuaternion(quaternions [][4]float64) []byte {
+
+	out := make([]byte, len(quaternions)*8)
+
+	for i, q := range quaternions {
+
+		largest := 0
+		for c := range q {
+			if math.Abs(q[c]) > math.Abs(q[largest]) {
+				largest = c
+			}
+		}
+
+		// The largest component is left out (along with its sign, as q and -q are the same rotation).
+		sign := math.Copysign(math.Sqrt2, q[largest])
+
+		values := []int{
+			meshoptQuantizeSnorm(q[(largest+1)&3]*sign, 16),
+			meshoptQuantizeSnorm(q[(largest+2)&3]*sign, 16),
+			meshoptQuantizeSnorm(q[(largest+3)&3]*sign, 16),
+			meshoptQuantizeSnorm(1, 16)&^3 | largest,
+		}
+
+		for c, value := range values {
+			binary.LittleEndian.PutUint16(out[i*8+c*2:], uint16(int16(value)))
+		}
+
+	}
+
+	return out
+
+}
+
+// meshoptEncodeExponential encodes the given vectors with the exponential filter, sharing an exponent across each vector's components and
+// keeping the given number of bits of their mantissas.
+func meshoptEncodeExponential(vectors [][3]float64, bits int) []byte {
+
+	out := make([]byte, len(vectors)*12)
+
+	for i, v := range vectors {
+
+		exponent := math.MinInt32
+		for _, c := range v {
+			if _, e := math.Frexp(c); c != 0 && e > exponent {
+				exponent = e
+			}
+		}
+
+		if exponent == math.MinInt32 {
+			exponent = 0
+		} else {
+			exponent -= bits - 1
+		}
+
+		for c, value := range v {
+			mantissa := int32(math.Round(math.Ldexp(value, -exponent)))
+			binary.LittleEndian.PutUint32(out[i*12+c*4:], uint32(mantissa)&0xFFFFFF|uint32(exponent)<<24)
+		}
+
+	}
+
+	return out
+
+}
+
+func TestMeshoptDecodeIndexBufferReference(t *testing.T) {
+
+	// This is the version 0 index data from meshoptimizer's own tests.
+	source := []byte{
+		0xe0, 0xf0, 0x10, 0xfe, 0xff, 0xf0, 0x0c, 0xff, 0x02, 0x02, 0x02, 0x00, 0x76, 0x87, 0x56, 0x67,
+		0x78, 0xa9, 0x86, 0x65, 0x89, 0x68, 0x98, 0x01, 0x69, 0x00, 0x00,
+	}
+
+	expected := []uint32{0, 1, 2, 2, 1, 3, 4, 6, 5, 7, 8, 9}
+
+	decoded := make([]byte, len(expected)*4)
+	if err := meshoptDecodeIndexBuffer(decoded, len(expected), 4, source); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, index := range expected {
+		if value := binary.LittleEndian.Uint32(decoded[i*4:]); value != index {
+			t.Fatalf("expected index %d to be %d, got %d", i, index, value)
+		}
+	}
+
+}
+
+func TestLoadGLTFMeshoptFilters(t *testing.T) {
+
+	const gridWidth, gridHeight = 20, 15
+	vertexCount := gridWidth * gridHeight
+
+	// Pseudo-random directions and rotations make for larger deltas between vertices than the grid's positions.
+	seed := uint32(1)
+	random := func() float64 {
+		seed = seed*1664525 + 1013904223
+		return float64(seed>>8)/float64(1<<24)*2 - 1
+	}
+
+	positions := make([][3]float64, vertexCount)
+	normals := make([][3]float64, vertexCount)
+	rotations := make([][4]float64, vertexCount)
+
+	for i := range positions {
+
+		positions[i] = [3]float64{float64(i%gridWidth) * 0.25, float64(i/gridWidth) * 0.25, 0}
+
+		n := vector.Vector{random(), random(), random()}.Unit()
+		normals[i] = [3]float64{n[0], n[1], n[2]}
+
+		q := vector.Vector{random(), random(), random(), random()}.Unit()
+		rotations[i] = [4]float64{q[0], q[1], q[2], q[3]}
+
+	}
+
+	// The first half of the grid's triangles are in order (and so mostly share edges with the triangles before them), while the rest are
+	// shuffled (and so mostly don't). A few triangles before the grid's start with three new vertices, and then reuse them.
+	indices := []uint32{0, 1, 2, 2, 1, 3, 1, 0, 2}
+	for y := 0; y < gridHeight-1; y++ {
+		for x := 0; x < gridWidth-1; x++ {
+			a := uint32(y*gridWidth + x)
+			b, c := a+1, a+gridWidth
+			indices = append(indices, a, c, b, b, c, c+1)
+		}
+	}
+
+	triangleCount := len(indices) / 3
+	for i := triangleCount - 1; i > triangleCount/2; i-- {
+		j := triangleCount/2 + int(seed>>8)%(i-triangleCount/2+1)
+		seed = seed*1664525 + 1013904223
+		for k := 0; k < 3; k++ {
+			indices[i*3+k], indices[j*3+k] = indices[j*3+k], indices[i*3+k]
+		}
+	}
+
+	indices16 := make([]byte, len(indices)*2)
+	indices32 := make([]byte, len(indices)*4)
+	for i, index := range indices {
+		binary.LittleEndian.PutUint16(indices16[i*2:], uint16(index))
+		binary.LittleEndian.PutUint32(indices32[i*4:], index)
+	}
+
+	counts := meshoptEncodingCounts{}
+
+	type view struct {
+		Mode, Filter string
+		Count        int
+		Stride       int
+		Data         []byte
+	}
+
+	views := []view{
+		{"ATTRIBUTES", "EXPONENTIAL", vertexCount, 12, meshoptEncodeExponential(positions, 15)},
+		{"ATTRIBUTES", "OCTAHEDRAL", vertexCount, 4, meshoptEncodeOctahedral(normals, 1)},
+		{"ATTRIBUTES", "OCTAHEDRAL", vertexCount, 8, meshoptEncodeOctahedral(normals, 2)},
+		{"ATTRIBUTES", "QUATERNION", vertexCount, 8, meshoptEncodeQuaternion(rotations)},
+		{"TRIANGLES", "", len(indices), 2, indices16},
+		{"INDICES", "", len(indices), 4, indices32},
+	}
+
+	compressed := &bytes.Buffer{}
+	bufferViews := []string{}
+	fallbackOffset := 0
+
+	for _, v := range views {
+
+		var data []byte
+
+		switch v.Mode {
+		case "ATTRIBUTES":
+			data = meshoptEncodeVertexBuffer(v.Data, v.Count, v.Stride, counts)
+		case "TRIANGLES":
+			data = meshoptEncodeIndexBuffer(indices, counts)
+		case "INDICES":
+			data = meshoptEncodeIndexSequence(indices)
+		}
+
+		extension := fmt.Sprintf(`"EXT_meshopt_compression": {"buffer": 0, "byteOffset": %d, "byteLength": %d, "byteStride": %d, "count": %d, "mode": "%s", "filter": "%s"}`,
+			compressed.Len(), len(data), v.Stride, v.Count, v.Mode, v.Filter)
+
+		bufferViews = append(bufferViews, fmt.Sprintf(`{"buffer": 1, "byteOffset": %d, "byteLength": %d, "extensions": {%s}}`, fallbackOffset, len(v.Data), extension))
+
+		compressed.Write(data)
+		for compressed.Len()%4 > 0 {
+			compressed.WriteByte(0)
+		}
+
+		fallbackOffset += (len(v.Data) + 3) &^ 3
+
+	}
+
+	for _, name := range []string{
+		"0-bit groups",
+		"2-bit groups",
+		"4-bit groups",
+		"8-bit groups",
+		"triangles sharing an edge and a recent vertex",
+		"triangles sharing an edge with a new vertex",
+		"triangles sharing an edge with the last free vertex plus or minus one",
+		"triangles sharing an edge with a free vertex",
+		"new triangles with a code from the table",
+		"new triangles with code FE",
+		"new triangles with code FF",
+	} {
+		if counts[name] == 0 {
+			t.Fatalf("expected the test data to be encoded with %s; got %v", name, counts)
+		}
+	}
+
+	document := []byte(fmt.Sprintf(`{
+	"asset": {"version": "2.0"},
+	"extensionsUsed": ["EXT_meshopt_compression"],
+	"extensionsRequired": ["EXT_meshopt_compression"],
+	"buffers": [
+		{"byteLength": %d},
+		{"byteLength": %d, "extensions": {"EXT_meshopt_compression": {"fallback": true}}}
+	],
+	"bufferViews": [%s, %s, %s, %s, %s, %s],
+	"accessors": [
+		{"bufferView": 0, "componentType": 5126, "count": %d, "type": "VEC3"},
+		{"bufferView": 4, "componentType": 5123, "count": %d, "type": "SCALAR"}
+	],
+	"meshes": [{"name": "Grid", "primitives": [{"attributes": {"POSITION": 0}, "indices": 1}]}],
+	"nodes": [{"name": "Grid", "mesh": 0}],
+	"scenes": [{"nodes": [0]}],
+	"scene": 0
+}`, compressed.Len(), fallbackOffset, bufferViews[0], bufferViews[1], bufferViews[2], bufferViews[3], bufferViews[4], bufferViews[5], vertexCount, len(indices)))
+
+	for len(document)%4 > 0 {
+		document = append(document, ' ')
+	}
+
+	glb := &bytes.Buffer{}
+	glb.WriteString("glTF")
+	binary.Write(glb, binary.LittleEndian, []uint32{2, uint32(12 + 8 + len(document) + 8 + compressed.Len())})
+	binary.Write(glb, binary.LittleEndian, uint32(len(document)))
+	glb.WriteString("JSON")
+	glb.Write(document)
+	binary.Write(glb, binary.LittleEndian, uint32(compressed.Len()))
+	glb.WriteString("BIN\x00")
+	glb.Write(compressed.Bytes())
+
+	doc, err := gltfDecodeDocument(glb.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gltfDecodeMeshopt(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	viewData := func(index int) []byte {
+		bufferView := doc.BufferViews[index]
+		return doc.Buffers[1].Data[bufferView.ByteOffset : bufferView.ByteOffset+bufferView.ByteLength]
+	}
+
+	for i, p := range positions {
+		for c := range p {
+			if value := math.Float32frombits(binary.LittleEndian.Uint32(viewData(0)[i*12+c*4:])); math.Abs(float64(value)-p[c]) > 0.001 {
+				t.Fatalf("vertex %d: expected position %v, got %f for component %d", i, p, value, c)
+			}
+		}
+	}
+
+	for i, n := range normals {
+
+		low := vector.Vector{float64(int8(viewData(1)[i*4])), float64(int8(viewData(1)[i*4+1])), float64(int8(viewData(1)[i*4+2]))}
+		high := vector.Vector{}
+		for c := 0; c < 3; c++ {
+			high = append(high, float64(int16(binary.LittleEndian.Uint16(viewData(2)[i*8+c*2:]))))
+		}
+
+		if dot(low.Unit(), n[:]) < 0.99 || math.Abs(low.Magnitude()-127) > 1 {
+			t.Fatalf("vertex %d: expected an 8-bit normal of %v, got %v", i, n, low)
+		}
+
+		if dot(high.Unit(), n[:]) < 0.9999 || math.Abs(high.Magnitude()-32767) > 1 {
+			t.Fatalf("vertex %d: expected a 16-bit normal of %v, got %v", i, n, high)
+		}
+
+	}
+
+	for i, q := range rotations {
+
+		rotation := vector.Vector{}
+		for c := 0; c < 4; c++ {
+			rotation = append(rotation, float64(int16(binary.LittleEndian.Uint16(viewData(3)[i*8+c*2:])))/32767)
+		}
+
+		if math.Abs(rotation.Dot(q[:])) < 0.9999 {
+			t.Fatalf("vertex %d: expected a rotation of %v, got %v", i, q, rotation)
+		}
+
+	}
+
+	// The index codec keeps the order of the triangles, but can rotate the vertices of each one.
+	decodedIndices := make([]uint32, len(indices))
+	for i := range decodedIndices {
+		decodedIndices[i] = uint32(binary.LittleEndian.Uint16(viewData(4)[i*2:]))
+	}
+
+	for i := 0; i < len(indices); i += 3 {
+
+		rotated := false
+		for r := 0; r < 3; r++ {
+			if decodedIndices[i] == indices[i+r] && decodedIndices[i+1] == indices[i+(r+1)%3] && decodedIndices[i+2] == indices[i+(r+2)%3] {
+				rotated = true
+			}
+		}
+
+		if !rotated {
+			t.Fatalf("triangle %d: expected indices %v, got %v", i/3, indices[i:i+3], decodedIndices[i:i+3])
+		}
+
+	}
+
+	if !bytes.Equal(viewData(5), indices32) {
+		t.Fatalf("expected the index sequence to be decoded as it was encoded")
+	}
+
+	library, err := LoadGLTFData(glb.Bytes(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mesh := library.Meshes["Grid"]
+	if mesh == nil || len(mesh.Triangles) != triangleCount {
+		t.Fatalf("expected a Mesh named Grid with %d triangles", triangleCount)
+	}
+
+	for i, index := range decodedIndices {
+		if mesh.VertexPositions[i].Sub(positions[index][:]).Magnitude() > 0.001 {
+			t.Fatalf("vertex %d: expected position %v, got %v", i, positions[index], mesh.VertexPositions[i])
+		}
+	}
+
+}
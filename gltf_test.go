@@ -1,8 +1,15 @@
 package tetra3d
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
 	"os"
 	"testing"
+
+	"github.com/kvartborg/vector"
 )
 
 func BenchmarkLoadGLTFData(b *testing.B) {
@@ -21,3 +28,470 @@ func BenchmarkLoadGLTFData(b *testing.B) {
 		}
 	}
 }
+
+func TestSaveGLTFRoundTrip(t *testing.T) {
+
+	scene := NewScene("Level")
+
+	cube := NewModel(NewCube(), "Cube")
+	cube.Mesh.MeshParts[0].Material.Color.Set(1, 0.5, 0.25, 1)
+	cube.Mesh.MeshParts[0].Material.EmissionColor.Set(0, 1, 0, 1)
+	cube.Mesh.MeshParts[0].Material.EmissionStrength = 4
+	cube.SetLocalPosition(1, 2, 3)
+	cube.SetLocalRotation(NewMatrix4Rotate(0, 1, 0, math.Pi/4))
+	for i := range cube.Mesh.VertexUV2s {
+		cube.Mesh.VertexUV2s[i][0] = float64(i%6) / 6
+		cube.Mesh.VertexUV2s[i][1] = float64(i/6) / 6
+	}
+	scene.Root.AddChildren(cube)
+
+	marker := NewNode("Marker")
+	marker.Properties().Get("health").Set(10)
+	marker.Properties().Get("name").Set("spawn")
+	marker.Properties().Get("offset").Set(vector.Vector{1, 2, 3})
+	cube.AddChildren(marker)
+
+	light := NewPointLight("Light", 1, 0, 0, 2)
+	light.Distance = 5
+	scene.Root.AddChildren(light)
+
+	spot := NewSpotLight("Spot", 0, 1, 0, 3)
+	spot.InnerConeAngle = 15
+	spot.OuterConeAngle = 30
+	scene.Root.AddChildren(spot)
+
+	for _, binary := range []bool{false, true} {
+
+		buffer := &bytes.Buffer{}
+
+		options := DefaultGLTFSaveOptions()
+		options.Binary = binary
+
+		if err := SaveGLTF(buffer, options, scene); err != nil {
+			t.Fatal(err)
+		}
+
+		library, err := LoadGLTFData(buffer.Bytes(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		loadedCube, ok := library.ExportedScene.Root.Get("Cube").(*Model)
+		if !ok {
+			t.Fatalf("expected the cube to be loaded as a Model")
+		}
+
+		if !vectorsNear(loadedCube.LocalPosition(), cube.LocalPosition()) || !vectorsNear(loadedCube.LocalRotation().Forward(), cube.LocalRotation().Forward()) {
+			t.Errorf("expected the cube's transform to be saved")
+		}
+
+		if len(loadedCube.Mesh.Triangles) != len(cube.Mesh.Triangles) {
+			t.Fatalf("expected %d triangles, got %d", len(cube.Mesh.Triangles), len(loadedCube.Mesh.Triangles))
+		}
+
+		for i := 0; i < cube.Mesh.VertexCount; i++ {
+			if !vectorsNear(loadedCube.Mesh.VertexPositions[i], cube.Mesh.VertexPositions[i]) || loadedCube.Mesh.VertexUVs[i].Sub(cube.Mesh.VertexUVs[i]).Magnitude() > 0.0001 ||
+				loadedCube.Mesh.VertexUV2s[i].Sub(cube.Mesh.VertexUV2s[i]).Magnitude() > 0.0001 {
+				t.Fatalf("vertex %d doesn't match the saved cube", i)
+			}
+		}
+
+		if color := loadedCube.Mesh.MeshParts[0].Material.Color; math.Abs(float64(color.G-0.5)) > 0.01 {
+			t.Errorf("expected the material's color to be saved, got %v", color)
+		}
+
+		if strength := loadedCube.Mesh.MeshParts[0].Material.EmissionStrength; math.Abs(strength-4) > 0.0001 {
+			t.Errorf("expected the material's emission strength to be saved, got %f", strength)
+		}
+
+		loadedMarker := loadedCube.Get("Marker")
+		if loadedMarker == nil {
+			t.Fatalf("expected the marker to be loaded as a child of the cube")
+		}
+
+		props := loadedMarker.Properties()
+		if props.Get("health").AsInt() != 10 || props.Get("name").AsString() != "spawn" || !vectorsNear(props.Get("offset").AsVector(), vector.Vector{1, 2, 3}) {
+			t.Errorf("expected the marker's properties to be saved")
+		}
+
+		loadedLight, ok := library.ExportedScene.Root.Get("Light").(*PointLight)
+		if !ok || math.Abs(float64(loadedLight.Energy-2)) > 0.0001 || loadedLight.Distance != 5 {
+			t.Errorf("expected the point light to be saved")
+		}
+
+		loadedSpot, ok := library.ExportedScene.Root.Get("Spot").(*SpotLight)
+		if !ok || math.Abs(loadedSpot.InnerConeAngle-15) > 0.0001 || math.Abs(loadedSpot.OuterConeAngle-30) > 0.0001 {
+			t.Errorf("expected the spot light to be saved")
+		}
+
+	}
+
+}
+
+func TestLoadGLTFMeshopt(t *testing.T) {
+
+	positions := [][3]float32{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}}
+
+	vertexData := bytes.NewBuffer([]byte{0xA0})
+
+	raw := &bytes.Buffer{}
+	binary.Write(raw, binary.LittleEndian, positions)
+
+	// Each byte of the vertices is stored in a single group of 16 uncompressed, zigzag-encoded deltas (as the block only has 4 vertices).
+	for k := 0; k < 12; k++ {
+		vertexData.WriteByte(3)
+		previous := byte(0)
+		group := make([]byte, 16)
+		for i := range positions {
+			b := raw.Bytes()[i*12+k]
+			delta := int8(b - previous)
+			group[i] = byte(delta<<1) ^ byte(delta>>7)
+			previous = b
+		}
+		vertexData.Write(group)
+	}
+
+	// The tail is made up of the vertex before the first one, which is all zeroes.
+	vertexData.Write(make([]byte, 32))
+
+	// The first triangle's vertices are all new (using the first code aux table entry), while the second shares the first's last edge.
+	indexData := []byte{0xE1, 0xF0, 0x00}
+	indexData = append(indexData, make([]byte, 16)...)
+
+	compressed := append(vertexData.Bytes(), indexData...)
+
+	document := fmt.Sprintf(`{
+	"asset": {"version": "2.0"},
+	"extensionsUsed": ["EXT_meshopt_compression"],
+	"extensionsRequired": ["EXT_meshopt_compression"],
+	"buffers": [
+		{"byteLength": %d, "uri": "data:application/octet-stream;base64,%s"},
+		{"byteLength": 60, "extensions": {"EXT_meshopt_compression": {"fallback": true}}}
+	],
+	"bufferViews": [
+		{"buffer": 1, "byteLength": 48, "byteStride": 12, "extensions": {"EXT_meshopt_compression": {"buffer": 0, "byteLength": %d, "byteStride": 12, "count": 4, "mode": "ATTRIBUTES"}}},
+		{"buffer": 1, "byteOffset": 48, "byteLength": 12, "extensions": {"EXT_meshopt_compression": {"buffer": 0, "byteOffset": %d, "byteLength": %d, "byteStride": 2, "count": 6, "mode": "TRIANGLES"}}}
+	],
+	"accessors": [
+		{"bufferView": 0, "componentType": 5126, "count": 4, "type": "VEC3", "min": [0, 0, 0], "max": [1, 1, 0]},
+		{"bufferView": 1, "componentType": 5123, "count": 6, "type": "SCALAR"}
+	],
+	"meshes": [{"name": "Quad", "primitives": [{"attributes": {"POSITION": 0}, "indices": 1}]}],
+	"nodes": [{"name": "Quad", "mesh": 0}],
+	"scenes": [{"nodes": [0]}],
+	"scene": 0
+}`, len(compressed), base64.StdEncoding.EncodeToString(compressed), vertexData.Len(), vertexData.Len(), len(indexData))
+
+	library, err := LoadGLTFData([]byte(document), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mesh := library.Meshes["Quad"]
+	if mesh == nil || len(mesh.Triangles) != 2 {
+		t.Fatalf("expected a Mesh named Quad with 2 triangles")
+	}
+
+	for i, index := range []int{0, 1, 2, 0, 2, 3} {
+		pos := positions[index]
+		if mesh.VertexPositions[i].Sub(vector.Vector{float64(pos[0]), float64(pos[1]), float64(pos[2])}).Magnitude() > 0.0001 {
+			t.Errorf("vertex %d: expected position %v, got %v", i, pos, mesh.VertexPositions[i])
+		}
+	}
+
+}
+
+func TestLoadGLTFTextureTransform(t *testing.T) {
+
+	uvs := [][2]float32{{0, 0}, {1, 0}, {0, 1}}
+
+	buffer := &bytes.Buffer{}
+	binary.Write(buffer, binary.LittleEndian, [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}})
+	binary.Write(buffer, binary.LittleEndian, uvs)
+	binary.Write(buffer, binary.LittleEndian, []uint16{0, 1, 2, 0})
+
+	document := fmt.Sprintf(`{
+	"asset": {"version": "2.0"},
+	"extensionsUsed": ["KHR_texture_transform"],
+	"buffers": [{"byteLength": %d, "uri": "data:application/octet-stream;base64,%s"}],
+	"bufferViews": [{"buffer": 0, "byteLength": 36}, {"buffer": 0, "byteOffset": 36, "byteLength": 24}, {"buffer": 0, "byteOffset": 60, "byteLength": 6}],
+	"accessors": [
+		{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3", "min": [0, 0, 0], "max": [1, 1, 0]},
+		{"bufferView": 1, "componentType": 5126, "count": 3, "type": "VEC2"},
+		{"bufferView": 2, "componentType": 5123, "count": 3, "type": "SCALAR"}
+	],
+	"images": [{"uri": "tiles.png"}],
+	"textures": [{"source": 0}],
+	"materials": [{"name": "Tiles", "pbrMetallicRoughness": {"baseColorTexture": {"index": 0, "extensions": {"KHR_texture_transform": {"offset": [0.5, 0], "rotation": %f, "scale": [2, 3]}}}}}],
+	"meshes": [{"name": "Triangle", "primitives": [{"attributes": {"POSITION": 0, "TEXCOORD_0": 1}, "indices": 2, "material": 0}]}],
+	"nodes": [{"name": "Triangle", "mesh": 0}],
+	"scenes": [{"nodes": [0]}],
+	"scene": 0
+}`, buffer.Len(), base64.StdEncoding.EncodeToString(buffer.Bytes()), math.Pi/2)
+
+	library, err := LoadGLTFData([]byte(document), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mesh := library.Meshes["Triangle"]
+
+	for i, uv := range uvs {
+
+		// Scaled, then rotated by 90 degrees, and then offset; the V value is then flipped, as glTF's V axis points down.
+		u, v := float64(uv[0])*2, float64(uv[1])*3
+		u, v = v+0.5, -u
+		expected := vector.Vector{u, 1 - v}
+
+		if mesh.VertexUVs[i].Sub(expected).Magnitude() > 0.0001 {
+			t.Errorf("vertex %d: expected UV %v, got %v", i, expected, mesh.VertexUVs[i])
+		}
+
+	}
+
+}
+
+func TestLoadGLTFSecondUVSet(t *testing.T) {
+
+	uvs := [][2]float32{{0, 0}, {1, 0}, {0, 1}}
+	uv2s := [][2]float32{{0.25, 0.25}, {0.5, 0.25}, {0.25, 0.5}}
+
+	buffer := &bytes.Buffer{}
+	binary.Write(buffer, binary.LittleEndian, [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}})
+	binary.Write(buffer, binary.LittleEndian, uvs)
+	binary.Write(buffer, binary.LittleEndian, uv2s)
+	binary.Write(buffer, binary.LittleEndian, []uint16{0, 1, 2, 0})
+
+	document := fmt.Sprintf(`{
+	"asset": {"version": "2.0"},
+	"extensionsUsed": ["KHR_texture_transform"],
+	"buffers": [{"byteLength": %d, "uri": "data:application/octet-stream;base64,%s"}],
+	"bufferViews": [
+		{"buffer": 0, "byteLength": 36},
+		{"buffer": 0, "byteOffset": 36, "byteLength": 24},
+		{"buffer": 0, "byteOffset": 60, "byteLength": 24},
+		{"buffer": 0, "byteOffset": 84, "byteLength": 6}
+	],
+	"accessors": [
+		{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3", "min": [0, 0, 0], "max": [1, 1, 0]},
+		{"bufferView": 1, "componentType": 5126, "count": 3, "type": "VEC2"},
+		{"bufferView": 2, "componentType": 5126, "count": 3, "type": "VEC2"},
+		{"bufferView": 3, "componentType": 5123, "count": 3, "type": "SCALAR"}
+	],
+	"images": [{"uri": "detail.png"}],
+	"textures": [{"source": 0}],
+	"materials": [{"name": "Detail", "pbrMetallicRoughness": {"baseColorTexture": {"index": 0, "texCoord": 1, "extensions": {"KHR_texture_transform": {"offset": [0.5, 0]}}}}}],
+	"meshes": [{"name": "Triangle", "primitives": [{"attributes": {"POSITION": 0, "TEXCOORD_0": 1, "TEXCOORD_1": 2}, "indices": 3, "material": 0}]}],
+	"nodes": [{"name": "Triangle", "mesh": 0}],
+	"scenes": [{"nodes": [0]}],
+	"scene": 0
+}`, buffer.Len(), base64.StdEncoding.EncodeToString(buffer.Bytes()))
+
+	library, err := LoadGLTFData([]byte(document), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if set := library.Materials["Detail"].TextureUVSet; set != 1 {
+		t.Errorf("expected the material's texture to use the second UV set, got %d", set)
+	}
+
+	mesh := library.Meshes["Triangle"]
+
+	for i := range uvs {
+
+		// The first set is loaded as-is, while the texture's transform is applied to the second set, which it uses.
+		expected := vector.Vector{float64(uvs[i][0]), 1 - float64(uvs[i][1])}
+		if mesh.VertexUVs[i].Sub(expected).Magnitude() > 0.0001 {
+			t.Errorf("vertex %d: expected UV %v, got %v", i, expected, mesh.VertexUVs[i])
+		}
+
+		expected = vector.Vector{float64(uv2s[i][0]) + 0.5, 1 - float64(uv2s[i][1])}
+		if mesh.VertexUV2s[i].Sub(expected).Magnitude() > 0.0001 {
+			t.Errorf("vertex %d: expected second UV %v, got %v", i, expected, mesh.VertexUV2s[i])
+		}
+
+	}
+
+}
+
+func TestLoadGLTFMorphTargets(t *testing.T) {
+
+	buffer := &bytes.Buffer{}
+	binary.Write(buffer, binary.LittleEndian, [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}})
+	binary.Write(buffer, binary.LittleEndian, [][3]float32{{0, 0, 0}, {0, 0, 0}, {0, 0, 1}})
+	binary.Write(buffer, binary.LittleEndian, []uint16{0, 1, 2, 0})
+	binary.Write(buffer, binary.LittleEndian, []float32{0, 1})
+	binary.Write(buffer, binary.LittleEndian, []float32{0, 1})
+
+	document := fmt.Sprintf(`{
+	"asset": {"version": "2.0"},
+	"buffers": [{"byteLength": %d, "uri": "data:application/octet-stream;base64,%s"}],
+	"bufferViews": [
+		{"buffer": 0, "byteLength": 36},
+		{"buffer": 0, "byteOffset": 36, "byteLength": 36},
+		{"buffer": 0, "byteOffset": 72, "byteLength": 6},
+		{"buffer": 0, "byteOffset": 80, "byteLength": 8},
+		{"buffer": 0, "byteOffset": 88, "byteLength": 8}
+	],
+	"accessors": [
+		{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3", "min": [0, 0, 0], "max": [1, 1, 0]},
+		{"bufferView": 1, "componentType": 5126, "count": 3, "type": "VEC3", "min": [0, 0, 0], "max": [0, 0, 1]},
+		{"bufferView": 2, "componentType": 5123, "count": 3, "type": "SCALAR"},
+		{"bufferView": 3, "componentType": 5126, "count": 2, "type": "SCALAR", "min": [0], "max": [1]},
+		{"bufferView": 4, "componentType": 5126, "count": 2, "type": "SCALAR"}
+	],
+	"meshes": [{"name": "Triangle", "primitives": [{"attributes": {"POSITION": 0}, "indices": 2, "targets": [{"POSITION": 1}]}], "extras": {"targetNames": ["Raise"]}}],
+	"nodes": [{"name": "Triangle", "mesh": 0, "weights": [0.5]}],
+	"animations": [{"name": "RaiseAnim", "channels": [{"sampler": 0, "target": {"node": 0, "path": "weights"}}], "samplers": [{"input": 3, "output": 4}]}],
+	"scenes": [{"nodes": [0]}],
+	"scene": 0
+}`, buffer.Len(), base64.StdEncoding.EncodeToString(buffer.Bytes()))
+
+	library, err := LoadGLTFData([]byte(document), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mesh := library.Meshes["Triangle"]
+
+	if len(mesh.MorphTargets) != 1 || mesh.MorphTargets[0].Name != "Raise" {
+		t.Fatalf("expected a single morph target named Raise, got %v", mesh.MorphTargets)
+	}
+
+	model := library.Scenes[0].Root.Get("Triangle").(*Model)
+
+	if model.MorphWeight("Raise") != 0.5 {
+		t.Errorf("expected the node's morph weight of 0.5, got %f", model.MorphWeight("Raise"))
+	}
+
+	positions, _ := model.morphedVertices()
+	if expected := (vector.Vector{0, 1, 0.5}); positions[2].Sub(expected).Magnitude() > 0.0001 {
+		t.Errorf("expected morphed vertex position %v, got %v", expected, positions[2])
+	}
+
+	if mesh.VertexPositions[2][2] != 0 {
+		t.Errorf("morphing shouldn't change the mesh's own vertex positions, got %v", mesh.VertexPositions[2])
+	}
+
+	player := NewAnimationPlayer(library.Scenes[0].Root)
+	player.Play(library.Animations["RaiseAnim"])
+	player.Playhead = 0.25
+	player.Update(0)
+
+	if weight := model.MorphWeight("Raise"); math.Abs(weight-0.25) > 0.0001 {
+		t.Errorf("expected the animated morph weight to be 0.25, got %f", weight)
+	}
+
+}
+
+func TestLoadGLTFMaterialAnimation(t *testing.T) {
+
+	buffer := &bytes.Buffer{}
+	binary.Write(buffer, binary.LittleEndian, [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}})
+	binary.Write(buffer, binary.LittleEndian, []uint16{0, 1, 2, 0})
+	binary.Write(buffer, binary.LittleEndian, []float32{0, 1})
+	binary.Write(buffer, binary.LittleEndian, [][4]float32{{1, 0, 0, 1}, {0, 0, 1, 0}})
+	binary.Write(buffer, binary.LittleEndian, [][2]float32{{0.5, 0}, {1.5, 0.5}})
+
+	document := fmt.Sprintf(`{
+	"asset": {"version": "2.0"},
+	"extensionsUsed": ["KHR_texture_transform", "KHR_animation_pointer"],
+	"buffers": [{"byteLength": %d, "uri": "data:application/octet-stream;base64,%s"}],
+	"bufferViews": [
+		{"buffer": 0, "byteLength": 36},
+		{"buffer": 0, "byteOffset": 36, "byteLength": 6},
+		{"buffer": 0, "byteOffset": 44, "byteLength": 8},
+		{"buffer": 0, "byteOffset": 52, "byteLength": 32},
+		{"buffer": 0, "byteOffset": 84, "byteLength": 16}
+	],
+	"accessors": [
+		{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3", "min": [0, 0, 0], "max": [1, 1, 0]},
+		{"bufferView": 1, "componentType": 5123, "count": 3, "type": "SCALAR"},
+		{"bufferView": 2, "componentType": 5126, "count": 2, "type": "SCALAR", "min": [0], "max": [1]},
+		{"bufferView": 3, "componentType": 5126, "count": 2, "type": "VEC4"},
+		{"bufferView": 4, "componentType": 5126, "count": 2, "type": "VEC2"}
+	],
+	"images": [{"uri": "tiles.png"}],
+	"textures": [{"source": 0}],
+	"materials": [{"name": "Glow", "pbrMetallicRoughness": {"baseColorTexture": {"index": 0, "extensions": {"KHR_texture_transform": {"offset": [0.5, 0]}}}}}],
+	"meshes": [{"name": "Triangle", "primitives": [{"attributes": {"POSITION": 0}, "indices": 1, "material": 0}]}],
+	"nodes": [{"name": "Triangle", "mesh": 0}],
+	"animations": [{
+		"name": "Pulse",
+		"channels": [
+			{"sampler": 0, "target": {"path": "pointer", "extensions": {"KHR_animation_pointer": {"pointer": "/materials/0/pbrMetallicRoughness/baseColorFactor"}}}},
+			{"sampler": 1, "target": {"path": "pointer", "extensions": {"KHR_animation_pointer": {"pointer": "/materials/0/pbrMetallicRoughness/baseColorTexture/extensions/KHR_texture_transform/offset"}}}}
+		],
+		"samplers": [{"input": 2, "output": 3}, {"input": 2, "output": 4}]
+	}],
+	"scenes": [{"nodes": [0]}],
+	"scene": 0
+}`, buffer.Len(), base64.StdEncoding.EncodeToString(buffer.Bytes()))
+
+	library, err := LoadGLTFData([]byte(document), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	anim := library.Animations["Pulse"]
+
+	if len(anim.Channels) != 0 || anim.MaterialChannels["Glow"] == nil {
+		t.Fatalf("expected a single material channel for Glow, got %d node channels and %d material channels", len(anim.Channels), len(anim.MaterialChannels))
+	}
+
+	player := NewAnimationPlayer(library.Scenes[0].Root)
+	player.Play(anim)
+	player.Playhead = 0.5
+	player.Update(0)
+
+	mat := library.Materials["Glow"]
+
+	if color := mat.Color; math.Abs(float64(color.R)-0.5) > 0.0001 || math.Abs(float64(color.B)-0.5) > 0.0001 || math.Abs(float64(color.A)-0.5) > 0.0001 {
+		t.Errorf("expected the animated color to be halfway between red and transparent blue, got %v", color)
+	}
+
+	// The static offset of 0.5 is already baked into the UV values, so only the difference from it is applied (with V flipped).
+	if expected := (vector.Vector{0.5, -0.25}); mat.UVOffset.Sub(expected).Magnitude() > 0.0001 {
+		t.Errorf("expected the animated UV offset to be %v, got %v", expected, mat.UVOffset)
+	}
+
+}
+
+func TestLoadGLTFTriggerVolume(t *testing.T) {
+
+	document := `{
+	"asset": {"version": "2.0"},
+	"nodes": [
+		{"name": "Door", "translation": [3, 0, 0], "extras": {"t3dTriggerVolume__": 1, "t3dBoundsType__": 1, "t3dAABBCustomEnabled__": 1, "t3dAABBCustomSize__": [2, 4, 6]}},
+		{"name": "Player", "translation": [3, 0, 0], "extras": {"t3dBoundsType__": 3, "t3dSphereCustomEnabled__": 1, "t3dSphereCustomRadius__": 0.5}}
+	],
+	"scenes": [{"nodes": [0, 1]}],
+	"scene": 0
+}`
+
+	library, err := LoadGLTFData([]byte(document), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scene := library.Scenes[0]
+
+	door, ok := scene.Root.Get("Door").(*TriggerVolume)
+	if !ok {
+		t.Fatalf("expected Door to be loaded as a TriggerVolume")
+	}
+
+	if aabb, ok := door.Bounds.(*BoundingAABB); !ok || aabb.Parent() != door || aabb.Dimensions.Width() != 2 {
+		t.Fatalf("expected the TriggerVolume to use the BoundingAABB created for it as its Bounds; got %v", door.Bounds)
+	}
+
+	player := scene.Root.Get("Player")
+
+	door.Update()
+
+	if overlapping := door.Overlapping(); len(overlapping) != 1 || overlapping[0] != player {
+		t.Fatalf("expected the player to be overlapping the loaded TriggerVolume; got %v", overlapping)
+	}
+
+}
@@ -0,0 +1,322 @@
+package tetra3d
+
+import (
+	"github.com/kvartborg/vector"
+)
+
+const (
+	UpAxisY = iota // Y is up; this is Tetra3D's convention, as well as glTF's.
+	UpAxisZ        // Z is up, as in Blender, 3ds Max, and Unreal Engine.
+)
+
+// ImportOptions describe the coordinate system that a file's contents were authored in, so that they can be converted to Tetra3D's
+// coordinate system (right-handed, with +Y up and -Z forward) when loading. The conversion is baked into vertex positions and normals,
+// Node transforms, bone transforms, and animations, so everything lands in Tetra3D's convention without any manual fixups afterwards.
+type ImportOptions struct {
+	UpAxis int // The up axis of the file (UpAxisY or UpAxisZ). Z-up files are rotated so that +Z becomes +Y, and +Y becomes -Z.
+
+	// If the file is left-handed rather than right-handed; if so, the Z axis is flipped (after converting the up axis) and
+	// triangles have their winding order reversed so that they continue to face outwards.
+	HandednessFlip bool
+
+	Scale float64 // A uniform scale applied to positions and sizes (i.e. 0.01 to convert from centimeters to meters). Defaults to 1.
+}
+
+// NewImportOptions returns a new ImportOptions for files that already use Tetra3D's coordinate system (which is the same as glTF's),
+// and so need no conversion.
+func NewImportOptions() *ImportOptions {
+	return &ImportOptions{
+		UpAxis: UpAxisY,
+		Scale:  1,
+	}
+}
+
+// NewImportOptionsBlender returns an ImportOptions for data in Blender's native coordinate system (right-handed, Z-up). Note that
+// Blender's glTF exporter already converts to Y-up, so this is only necessary for files exported without that conversion.
+func NewImportOptionsBlender() *ImportOptions {
+	return &ImportOptions{
+		UpAxis: UpAxisZ,
+		Scale:  1,
+	}
+}
+
+// NewImportOptions3dsMax returns an ImportOptions for data in 3ds Max's coordinate system (right-handed, Z-up).
+func NewImportOptions3dsMax() *ImportOptions {
+	return NewImportOptionsBlender()
+}
+
+// NewImportOptionsUnity returns an ImportOptions for data in Unity's coordinate system (left-handed, Y-up).
+func NewImportOptionsUnity() *ImportOptions {
+	return &ImportOptions{
+		UpAxis:         UpAxisY,
+		HandednessFlip: true,
+		Scale:          1,
+	}
+}
+
+// NewImportOptionsUnreal returns an ImportOptions for data in Unreal Engine's coordinate system (left-handed, Z-up, in centimeters).
+func NewImportOptionsUnreal() *ImportOptions {
+	return &ImportOptions{
+		UpAxis:         UpAxisZ,
+		HandednessFlip: true,
+		Scale:          0.01,
+	}
+}
+
+// converter returns an importConverter to convert data according to the ImportOptions, or nil if no conversion is necessary.
+func (options *ImportOptions) converter() *importConverter {
+
+	if options == nil {
+		return nil
+	}
+
+	scale := options.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+
+	if options.UpAxis == UpAxisY && !options.HandednessFlip && scale == 1 {
+		return nil
+	}
+
+	conv := &importConverter{
+		up:    NewMatrix4(),
+		scale: scale,
+		flip:  options.HandednessFlip,
+	}
+
+	if options.UpAxis == UpAxisZ {
+		// (x, y, z) becomes (x, z, -y)
+		conv.up.SetRow(1, vector.Vector{0, 0, -1, 0})
+		conv.up.SetRow(2, vector.Vector{0, 1, 0, 0})
+	}
+
+	conv.axes = conv.up.Clone()
+
+	if options.HandednessFlip {
+		conv.axes = conv.axes.Mult(NewMatrix4Scale(1, 1, -1))
+	}
+
+	conv.axesInverse = conv.axes.Transposed()
+
+	return conv
+
+}
+
+// importConverter converts data from another coordinate system to Tetra3D's. The conversion matrix C is composed of axes (the axis
+// conversion, which is a rotation followed optionally by a reflection) and a uniform scale. Vertices are transformed by C, while Node
+// transforms are conjugated by it (C^-1 * T * C), so that each Node's world transform ends up as the original world transform followed
+// by C.
+type importConverter struct {
+	axes        Matrix4
+	axesInverse Matrix4
+	up          Matrix4 // The rotational (up axis) part of the conversion
+	scale       float64
+	flip        bool
+}
+
+// position converts a position (or offset) vector.
+func (conv *importConverter) position(pos vector.Vector) vector.Vector {
+	return conv.axes.MultVec(pos).Scale(conv.scale)
+}
+
+// rotation converts a rotation matrix by conjugating it with the axis conversion.
+func (conv *importConverter) rotation(rotation Matrix4) Matrix4 {
+	return conv.axesInverse.Mult(rotation).Mult(conv.axes)
+}
+
+// scaleVector converts a (local) scale vector; as the axis conversion only swaps and flips axes, this swaps the scale's components.
+func (conv *importConverter) scaleVector(scale vector.Vector) vector.Vector {
+
+	out := vector.Vector{0, 0, 0}
+
+	for j := 0; j < 3; j++ {
+		for i := 0; i < 3; i++ {
+			out[j] += conv.axes[i][j] * conv.axes[i][j] * scale[i]
+		}
+	}
+
+	return out
+
+}
+
+// transform converts a full transform matrix (like a bone's inverse bind matrix) by conjugating it with the conversion.
+func (conv *importConverter) transform(transform Matrix4) Matrix4 {
+	c := conv.axes.Mult(NewMatrix4Scale(conv.scale, conv.scale, conv.scale))
+	cInverse := NewMatrix4Scale(1/conv.scale, 1/conv.scale, 1/conv.scale).Mult(conv.axesInverse)
+	return cInverse.Mult(transform).Mult(c)
+}
+
+// mesh converts a Mesh's vertex positions, normals, and tangents (as well as its MorphTargets' deltas), reversing its triangles'
+// winding order if the handedness is flipped.
+func (conv *importConverter) mesh(mesh *Mesh) {
+
+	// Vertex normals may share the same backing vector (i.e. after Mesh.AutoNormal()), so we make sure to only convert each one once.
+	converted := map[*float64]bool{}
+
+	for i := 0; i < mesh.VertexCount; i++ {
+		copy(mesh.VertexPositions[i], conv.position(mesh.VertexPositions[i]))
+		if normal := mesh.VertexNormals[i]; len(normal) >= 3 && !converted[&normal[0]] {
+			converted[&normal[0]] = true
+			copy(normal, conv.axes.MultVec(normal))
+		}
+	}
+
+	// Flipping the handedness mirrors the bitangents along with everything else, so the tangents' handedness is flipped to match.
+	for _, tangent := range mesh.VertexTangents {
+		copy(tangent, conv.axes.MultVec(tangent[:3]))
+		if conv.flip {
+			tangent[3] *= -1
+		}
+	}
+
+	for _, target := range mesh.MorphTargets {
+		for i := range target.PositionDeltas {
+			copy(target.PositionDeltas[i], conv.position(target.PositionDeltas[i]))
+		}
+		for i := range target.NormalDeltas {
+			copy(target.NormalDeltas[i], conv.axes.MultVec(target.NormalDeltas[i]))
+		}
+	}
+
+	if conv.flip {
+
+		for triID := 0; triID < mesh.VertexCount/3; triID++ {
+
+			a, b := triID*3+1, triID*3+2
+
+			for _, target := range mesh.MorphTargets {
+				if len(target.PositionDeltas) > b {
+					target.PositionDeltas[a], target.PositionDeltas[b] = target.PositionDeltas[b], target.PositionDeltas[a]
+				}
+				if len(target.NormalDeltas) > b {
+					target.NormalDeltas[a], target.NormalDeltas[b] = target.NormalDeltas[b], target.NormalDeltas[a]
+				}
+			}
+
+			mesh.VertexPositions[a], mesh.VertexPositions[b] = mesh.VertexPositions[b], mesh.VertexPositions[a]
+			mesh.VertexNormals[a], mesh.VertexNormals[b] = mesh.VertexNormals[b], mesh.VertexNormals[a]
+			if len(mesh.VertexTangents) > b {
+				mesh.VertexTangents[a], mesh.VertexTangents[b] = mesh.VertexTangents[b], mesh.VertexTangents[a]
+			}
+			mesh.VertexUVs[a], mesh.VertexUVs[b] = mesh.VertexUVs[b], mesh.VertexUVs[a]
+			mesh.VertexUV2s[a], mesh.VertexUV2s[b] = mesh.VertexUV2s[b], mesh.VertexUV2s[a]
+			mesh.VertexColors[a], mesh.VertexColors[b] = mesh.VertexColors[b], mesh.VertexColors[a]
+			mesh.VertexActiveColorChannel[a], mesh.VertexActiveColorChannel[b] = mesh.VertexActiveColorChannel[b], mesh.VertexActiveColorChannel[a]
+			mesh.VertexBones[a], mesh.VertexBones[b] = mesh.VertexBones[b], mesh.VertexBones[a]
+			mesh.VertexWeights[a], mesh.VertexWeights[b] = mesh.VertexWeights[b], mesh.VertexWeights[a]
+
+		}
+
+	}
+
+	for _, tri := range mesh.Triangles {
+		tri.RecalculateCenter()
+		tri.RecalculateNormal()
+	}
+
+	mesh.UpdateBounds()
+
+}
+
+// fixedFrame returns if a Node's local axes are defined by Tetra3D rather than by the file it was loaded from (i.e. Cameras always look
+// down their local -Z axis). Such Nodes keep their local axes through the up axis conversion, rather than having them converted.
+func (conv *importConverter) fixedFrame(node INode) bool {
+	switch node.(type) {
+	case *Camera, *DirectionalLight, *SpotLight, *CubeLight:
+		return true
+	}
+	return false
+}
+
+// importConversionFix is the extra rotation applied to a Node to keep its local axes fixed (fix), as well as the inverse of the rotation
+// applied to its parent for the same reason (parentFixInverse), which is undone so that the Node's world transform is unaffected.
+type importConversionFix struct {
+	fix              Matrix4
+	parentFixInverse Matrix4
+}
+
+// node converts a Node's local transform (and that of its children, recursively). Nodes that belong to other Libraries (i.e. instanced
+// from a dependent Library) were already converted when their own Library was loaded, so they're skipped. The rotational fixes applied
+// to each Node are stored in the fixes map by the Node's name, so that the Node's animations can be converted as well.
+func (conv *importConverter) node(node INode, library *Library, parentFix Matrix4, fixes map[string]importConversionFix) {
+
+	if node.Library() != library {
+		return
+	}
+
+	// Bounding objects are generated when loading using the Model's (already converted) Mesh, so they need no further conversion.
+	if node.Type().Is(NodeTypeBoundingObject) {
+		return
+	}
+
+	fix := NewMatrix4()
+	if conv.fixedFrame(node) {
+		fix = conv.up.Clone()
+	}
+
+	parentFixInverse := parentFix.Transposed()
+
+	node.SetLocalPositionVec(parentFixInverse.MultVec(conv.position(node.LocalPosition())))
+	node.SetLocalRotation(fix.Mult(conv.rotation(node.LocalRotation())).Mult(parentFixInverse))
+	node.SetLocalScaleVec(conv.scaleVector(node.LocalScale()))
+
+	fixes[node.Name()] = importConversionFix{fix: fix, parentFixInverse: parentFixInverse}
+
+	switch n := node.(type) {
+
+	case *Node:
+		n.originalLocalPosition = conv.position(n.originalLocalPosition)
+		if n.isBone {
+			n.inverseBindMatrix = conv.transform(n.inverseBindMatrix)
+		}
+	case *Model:
+		n.originalLocalPosition = conv.position(n.originalLocalPosition)
+	case *PointLight:
+		n.Distance *= conv.scale
+	case *SpotLight:
+		n.Distance *= conv.scale
+	case *CubeLight:
+		n.Distance *= conv.scale
+		n.Dimensions[0] = n.Dimensions[0].Scale(conv.scale)
+		n.Dimensions[1] = n.Dimensions[1].Scale(conv.scale)
+
+	}
+
+	for _, child := range node.Children() {
+		conv.node(child, library, fix, fixes)
+	}
+
+}
+
+// animation converts the keyframes of an Animation's channels, using the fixes recorded for the animated Nodes when converting them.
+func (conv *importConverter) animation(animation *Animation, fixes map[string]importConversionFix) {
+
+	for _, channel := range animation.Channels {
+
+		fix, exists := fixes[channel.Name]
+		if !exists {
+			fix = importConversionFix{fix: NewMatrix4(), parentFixInverse: NewMatrix4()}
+		}
+
+		for _, track := range channel.Tracks {
+
+			for _, key := range track.Keyframes {
+
+				switch track.Type {
+				case TrackTypePosition:
+					key.Data.contents = fix.parentFixInverse.MultVec(conv.position(key.Data.AsVector()))
+				case TrackTypeScale:
+					key.Data.contents = conv.scaleVector(key.Data.AsVector())
+				case TrackTypeRotation:
+					rotation := fix.fix.Mult(conv.rotation(key.Data.AsQuaternion().ToMatrix4())).Mult(fix.parentFixInverse)
+					key.Data.contents = rotation.ToQuaternion()
+				}
+
+			}
+
+		}
+
+	}
+
+}
@@ -0,0 +1,81 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kvartborg/vector"
+)
+
+func TestImportOptionsConvertMesh(t *testing.T) {
+
+	mesh := NewCube()
+
+	NewImportOptionsUnreal().converter().mesh(mesh)
+
+	for _, tri := range mesh.Triangles {
+		if dot(tri.Center, tri.Normal) <= 0 {
+			t.Fatalf("triangle %d faces inwards after flipping handedness; its winding order should have been reversed", tri.ID)
+		}
+	}
+
+	if size := mesh.Dimensions.Width(); math.Abs(size-0.02) > 0.00001 {
+		t.Errorf("expected the cube to be scaled down to a width of 0.02, got %f", size)
+	}
+
+	if NewImportOptions().converter() != nil {
+		t.Error("the default ImportOptions shouldn't convert anything")
+	}
+
+}
+
+func TestImportOptionsConvertTangents(t *testing.T) {
+
+	for _, options := range []*ImportOptions{NewImportOptionsBlender(), NewImportOptionsUnreal()} {
+
+		// Tangents are generated when loading normal-mapped Meshes, before they're converted.
+		mesh := NewCube()
+		mesh.GenerateTangents()
+
+		options.converter().mesh(mesh)
+
+		converted := mesh.VertexTangents
+		mesh.GenerateTangents()
+
+		for i, tangent := range mesh.VertexTangents {
+			if !vectorsEqual(converted[i][:3], tangent[:3]) || converted[i][3] != tangent[3] {
+				t.Fatalf("expected converted tangent %d to match the tangent generated after conversion (%v), got %v", i, tangent, converted[i])
+			}
+		}
+
+	}
+
+}
+
+func TestImportOptionsConvertNode(t *testing.T) {
+
+	// A Node one unit up in a Z-up file, rotated 90 degrees around the up axis.
+	node := NewNode("node")
+	node.SetLocalPosition(0, 0, 1)
+	node.SetLocalRotation(NewMatrix4Rotate(0, 0, 1, math.Pi/2))
+
+	conv := NewImportOptionsBlender().converter()
+	conv.node(node, node.Library(), NewMatrix4(), map[string]importConversionFix{})
+
+	if !vectorsEqual(node.LocalPosition(), vector.Vector{0, 1, 0}) {
+		t.Errorf("expected the node to be moved one unit up along +Y, got %v", node.LocalPosition())
+	}
+
+	expected := NewMatrix4Rotate(0, 1, 0, math.Pi/2)
+
+	rotation := node.LocalRotation()
+
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if math.Abs(rotation[i][j]-expected[i][j]) > 0.00001 {
+				t.Fatalf("expected the node to be rotated around +Y, got %v", rotation)
+			}
+		}
+	}
+
+}
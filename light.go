@@ -1,6 +1,7 @@
 package tetra3d
 
 import (
+	"image"
 	"math"
 
 	"github.com/kvartborg/vector"
@@ -17,8 +18,154 @@ type ILight interface {
 	beginModel(model *Model)
 
 	Light(triIndex int, model *Model) [9]float32 // Light returns the R, G, and B colors used to light the vertices of the given triangle.
-	IsOn() bool                                  // isOn is simply used to tell if a "generic" Light is on or not.
-	SetOn(on bool)                               // SetOn sets whether the light is on or not
+
+	// lightFacing returns the R, G, and B colors used to light the vertices of the given triangle, like Light(). facing is 1 to light
+	// the triangle's front side, or -1 to light its back side (i.e. as though its normals were flipped, for Materials with
+	// FlipBackfaceNormals on).
+	lightFacing(triIndex int, model *Model, facing float64) [9]float32
+
+	IsOn() bool               // isOn is simply used to tell if a "generic" Light is on or not.
+	SetOn(on bool)            // SetOn sets whether the light is on or not
+	lightLayers() LightLayers // lightLayers returns the LightLayers the light is on.
+
+	// InfluenceSphere returns the center and radius of a sphere in world space outside of which the light has no effect.
+	// Lights with infinite range (like AmbientLights, DirectionalLights, or PointLights with a Distance of 0) return a radius of +Inf.
+	// This is used to skip lighting Models that are too far away from a light when rendering or baking lighting.
+	InfluenceSphere() (vector.Vector, float64)
+}
+
+// lightInfluencesModel returns if the light's influence sphere touches the Model's BoundingSphere. Note that the Model's transform
+// should be up-to-date when calling this.
+func lightInfluencesModel(light ILight, model *Model) bool {
+
+	center, radius := light.InfluenceSphere()
+
+	if math.IsInf(radius, 1) {
+		return true
+	}
+
+	dist := radius + model.BoundingSphere.WorldRadius()
+	return fastVectorDistanceSquared(center, model.BoundingSphere.WorldPosition()) <= dist*dist
+
+}
+
+// imageSampler holds the pixels of an image (like a light's cookie image, or a Material's ToonRamp), which are read once so that the
+// image can be sampled quickly when lighting vertices.
+type imageSampler struct {
+	source image.Image
+	width  int
+	height int
+	pixels []float32
+}
+
+// update reads the pixels of the given image if it isn't the image that was read previously.
+func (sampler *imageSampler) update(img image.Image) {
+
+	if img == nil || img == sampler.source {
+		return
+	}
+
+	bounds := img.Bounds()
+
+	sampler.source = img
+	sampler.width = bounds.Dx()
+	sampler.height = bounds.Dy()
+	sampler.pixels = make([]float32, sampler.width*sampler.height*3)
+
+	for y := 0; y < sampler.height; y++ {
+		for x := 0; x < sampler.width; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			i := (y*sampler.width + x) * 3
+			sampler.pixels[i] = float32(r) / 0xffff
+			sampler.pixels[i+1] = float32(g) / 0xffff
+			sampler.pixels[i+2] = float32(b) / 0xffff
+		}
+	}
+
+}
+
+// sample returns the color of the image at the given texture coordinates, with (0, 0) being the top-left corner of the image and
+// (1, 1) being the bottom-right. Coordinates outside of the image are clamped to its edges.
+func (sampler *imageSampler) sample(u, v float64) (float32, float32, float32) {
+
+	if sampler.width == 0 || sampler.height == 0 {
+		return 1, 1, 1
+	}
+
+	x := int(math.Max(math.Min(u*float64(sampler.width), float64(sampler.width-1)), 0))
+	y := int(math.Max(math.Min(v*float64(sampler.height), float64(sampler.height-1)), 0))
+
+	i := (y*sampler.width + x) * 3
+
+	return sampler.pixels[i], sampler.pixels[i+1], sampler.pixels[i+2]
+
+}
+
+// LightAttenuation describes how the light of a PointLight, SpotLight, or RectLight falls off over distance, overriding the light's
+// default falloff (which depends on its Distance value). The zero value uses the default falloff.
+type LightAttenuation struct {
+	// Constant, Linear, and Quadratic are the coefficients of the classic attenuation formula; if any of them are non-zero, the
+	// light is multiplied by 1 / (Constant + Linear * distance + Quadratic * distance²).
+	Constant, Linear, Quadratic float64
+
+	// Function, if set, is called with the distance to each lit vertex and returns how much the light is multiplied by; it
+	// takes priority over the coefficients.
+	Function func(distance float64) float64
+
+	// Range is a hard cutoff; if it's above 0, the light has no effect at all on vertices farther away than it. This also limits
+	// the light's InfluenceSphere(), so that Models outside of the Range are skipped without lighting any of their vertices.
+	Range float64
+}
+
+// isDefault returns if the LightAttenuation is unset, meaning that the light's default falloff should be used.
+func (att *LightAttenuation) isDefault() bool {
+	return att.Function == nil && att.Constant == 0 && att.Linear == 0 && att.Quadratic == 0 && att.Range <= 0
+}
+
+// falloff returns how much a light is multiplied by at the given squared distance. lightDistance is the light's Distance value,
+// which is used for the default falloff if no other attenuation is set.
+func (att *LightAttenuation) falloff(distanceSquared, lightDistance float64) float64 {
+
+	if att.Range > 0 && distanceSquared > att.Range*att.Range {
+		return 0
+	}
+
+	if att.Function != nil {
+		return att.Function(math.Sqrt(distanceSquared))
+	}
+
+	if att.Constant != 0 || att.Linear != 0 || att.Quadratic != 0 {
+		denominator := att.Constant + att.Linear*math.Sqrt(distanceSquared) + att.Quadratic*distanceSquared
+		if denominator <= 0 {
+			return 1
+		}
+		return 1 / denominator
+	}
+
+	if lightDistance == 0 {
+		return (1.0 / (1.0 + (0.1 * distanceSquared))) * 2
+	}
+
+	return math.Max(math.Min(1.0-(math.Pow((distanceSquared/(lightDistance*lightDistance)), 4)), 1), 0)
+
+}
+
+// influenceRadius returns the radius outside of which a light with the given Distance has no effect, given its attenuation.
+func (att *LightAttenuation) influenceRadius(lightDistance float64) float64 {
+
+	radius := math.Inf(1)
+
+	// Custom attenuation replaces the default falloff, so the light's Distance no longer limits its range.
+	if lightDistance > 0 && att.Function == nil && att.Constant == 0 && att.Linear == 0 && att.Quadratic == 0 {
+		radius = lightDistance
+	}
+
+	if att.Range > 0 {
+		radius = math.Min(radius, att.Range)
+	}
+
+	return radius
+
 }
 
 //---------------//
@@ -30,7 +177,8 @@ type AmbientLight struct {
 	// Energy is the overall energy of the Light. Internally, technically there's no difference between a brighter color and a
 	// higher energy, but this is here for convenience / adherance to GLTF / 3D modelers.
 	Energy float32
-	On     bool // If the light is on and contributing to the scene.
+	On     bool        // If the light is on and contributing to the scene.
+	Layers LightLayers // The LightLayers the light is on. Defaults to LightLayersAll.
 
 	result [9]float32
 }
@@ -42,6 +190,7 @@ func NewAmbientLight(name string, r, g, b, energy float32) *AmbientLight {
 		Color:  NewColor(r, g, b, 1),
 		Energy: energy,
 		On:     true,
+		Layers: LightLayersAll,
 	}
 }
 
@@ -49,6 +198,7 @@ func (amb *AmbientLight) Clone() INode {
 
 	clone := NewAmbientLight(amb.name, amb.Color.R, amb.Color.G, amb.Color.B, amb.Energy)
 	clone.On = amb.On
+	clone.Layers = amb.Layers
 
 	clone.Node = amb.Node.Clone().(*Node)
 	for _, child := range amb.children {
@@ -70,6 +220,11 @@ func (amb *AmbientLight) beginModel(model *Model) {}
 
 // Light returns the light level for the ambient light. It doesn't use the provided Triangle; it takes it as an argument to simply adhere to the Light interface.
 func (amb *AmbientLight) Light(triIndex int, model *Model) [9]float32 {
+	return amb.lightFacing(triIndex, model, 1)
+}
+
+// lightFacing lights the given side of the Triangle; see ILight.lightFacing().
+func (amb *AmbientLight) lightFacing(triIndex int, model *Model, facing float64) [9]float32 {
 	return amb.result
 }
 
@@ -94,6 +249,15 @@ func (amb *AmbientLight) SetOn(on bool) {
 	amb.On = on
 }
 
+func (amb *AmbientLight) lightLayers() LightLayers {
+	return amb.Layers
+}
+
+// InfluenceSphere returns the AmbientLight's world position and a radius of +Inf, as ambient lights light everything.
+func (amb *AmbientLight) InfluenceSphere() (vector.Vector, float64) {
+	return amb.WorldPosition(), math.Inf(1)
+}
+
 // Type returns the NodeType for this object.
 func (amb *AmbientLight) Type() NodeType {
 	return NodeTypeAmbientLight
@@ -115,9 +279,27 @@ type PointLight struct {
 	Energy float32
 	// If the light is on and contributing to the scene.
 	On bool
-
-	distanceSquared float64
+	// Layers are the LightLayers the light is on; it only lights Models that receive light from at least one of them. Defaults to
+	// LightLayersAll.
+	Layers LightLayers
+	// Attenuation can be used to customize how the PointLight falls off over distance, and to give it a hard range. By default,
+	// it's unset, and the PointLight falls off according to its Distance value.
+	Attenuation LightAttenuation
+
+	// Cookie is an image that the color of the PointLight is multiplied by, projected outwards from the PointLight in all directions
+	// (like a light with a patterned lampshade). The image is wrapped around the PointLight using an equirectangular (latitude /
+	// longitude) projection relative to its rotation, with the center of the image facing the PointLight's local -Z axis and the top
+	// of the image facing its local +Y axis. A *ebiten.Image can be used, though any image.Image works. The image's pixels are read
+	// when the PointLight is first rendered with it, so changes to the image's contents afterwards aren't picked up unless a different
+	// image is assigned. PointLights with a Cookie are always lit per vertex. Defaults to nil.
+	Cookie image.Image
+
+	rangeSquared    float64
 	workingPosition vector.Vector
+	workingRight    vector.Vector
+	workingUp       vector.Vector
+	workingForward  vector.Vector
+	cookie          imageSampler
 	out             [9]float32
 }
 
@@ -128,6 +310,7 @@ func NewPointLight(name string, r, g, b, energy float32) *PointLight {
 		Energy: energy,
 		Color:  NewColor(r, g, b, 1),
 		On:     true,
+		Layers: LightLayersAll,
 		out:    [9]float32{},
 	}
 }
@@ -137,7 +320,10 @@ func (point *PointLight) Clone() INode {
 
 	clone := NewPointLight(point.name, point.Color.R, point.Color.G, point.Color.B, point.Energy)
 	clone.On = point.On
+	clone.Layers = point.Layers
 	clone.Distance = point.Distance
+	clone.Attenuation = point.Attenuation
+	clone.Cookie = point.Cookie
 
 	clone.Node = point.Node.Clone().(*Node)
 	for _, child := range point.children {
@@ -149,7 +335,8 @@ func (point *PointLight) Clone() INode {
 }
 
 func (point *PointLight) beginRender() {
-	point.distanceSquared = point.Distance * point.Distance
+	point.rangeSquared = math.Pow(point.Attenuation.influenceRadius(point.Distance), 2)
+	point.cookie.update(point.Cookie)
 }
 
 func (point *PointLight) beginModel(model *Model) {
@@ -168,10 +355,46 @@ func (point *PointLight) beginModel(model *Model) {
 		point.workingPosition = r.MultVec(point.WorldPosition()).Add(p)
 	}
 
+	if point.Cookie != nil {
+
+		rotation := point.WorldRotation()
+		point.workingRight = rotation.Right()
+		point.workingUp = rotation.Up()
+		point.workingForward = rotation.Forward()
+
+		if !model.Skinned {
+			point.workingRight = r.MultVec(point.workingRight).Unit()
+			point.workingUp = r.MultVec(point.workingUp).Unit()
+			point.workingForward = r.MultVec(point.workingForward).Unit()
+		}
+
+	}
+
+}
+
+// cookieColor returns the color of the PointLight's Cookie in the direction of the given (normalized) vector pointing from the
+// vertex to the PointLight.
+func (point *PointLight) cookieColor(lightVec vector.Vector) (float32, float32, float32) {
+
+	// The direction from the PointLight to the vertex, in the PointLight's local space.
+	x := -dot(lightVec, point.workingRight)
+	y := -dot(lightVec, point.workingUp)
+	z := -dot(lightVec, point.workingForward)
+
+	u := 0.5 + math.Atan2(x, -z)/(2*math.Pi)
+	v := math.Acos(math.Max(math.Min(y, 1), -1)) / math.Pi
+
+	return point.cookie.sample(u, v)
+
 }
 
 // Light returns the R, G, and B values for the PointLight for all vertices of a given Triangle.
 func (point *PointLight) Light(triIndex int, model *Model) [9]float32 {
+	return point.lightFacing(triIndex, model, 1)
+}
+
+// lightFacing lights the given side of the Triangle; see ILight.lightFacing().
+func (point *PointLight) lightFacing(triIndex int, model *Model, facing float64) [9]float32 {
 
 	// TODO: Make lighting faster by returning early if the triangle is too far from the point light position
 
@@ -192,7 +415,7 @@ func (point *PointLight) Light(triIndex int, model *Model) [9]float32 {
 
 	dist := fastVectorDistanceSquared(point.workingPosition, triCenter)
 
-	if point.Distance > 0 && dist > point.distanceSquared+model.Mesh.Triangles[triIndex].MaxSpan {
+	if dist > point.rangeSquared+model.Mesh.Triangles[triIndex].MaxSpan {
 		for i := 0; i < 9; i++ {
 			point.out[i] = 0
 		}
@@ -206,36 +429,41 @@ func (point *PointLight) Light(triIndex int, model *Model) [9]float32 {
 
 	var vertPos, vertNormal vector.Vector
 
+	// Unskinned Models are lit using their morphed vertices (which are just the Mesh's vertices if no MorphTargets apply).
+	positions, normals := model.morphedVertices()
+
 	for i := 0; i < 3; i++ {
 
 		if model.Skinned {
 			vertPos = model.Mesh.vertexSkinnedPositions[triIndex*3+i]
 			vertNormal = model.Mesh.vertexSkinnedNormals[triIndex*3+i]
 		} else {
-			vertPos = model.Mesh.VertexPositions[triIndex*3+i]
-			vertNormal = model.Mesh.VertexNormals[triIndex*3+i]
+			vertPos = positions[triIndex*3+i]
+			vertNormal = normals[triIndex*3+i]
 		}
 
 		lightVec := vector.In(fastVectorSub(point.workingPosition, vertPos)).Unit()
-		diffuse := dot(vertNormal, vector.Vector(lightVec))
+		diffuse := dot(vertNormal, vector.Vector(lightVec)) * facing
 
 		if diffuse < 0 {
 			diffuse = 0
 		}
 
-		var diffuseFactor float64
 		distance := fastVectorDistanceSquared(point.workingPosition, vertPos)
 
-		if point.Distance == 0 {
-			diffuseFactor = diffuse * (1.0 / (1.0 + (0.1 * distance))) * 2
-		} else {
-			diffuseFactor = diffuse * math.Max(math.Min(1.0-(math.Pow((distance/point.distanceSquared), 4)), 1), 0)
-		}
+		diffuseFactor := diffuse * point.Attenuation.falloff(distance, point.Distance)
 
 		point.out[(i * 3)] = point.Color.R * float32(diffuseFactor) * point.Energy
 		point.out[(i*3)+1] = point.Color.G * float32(diffuseFactor) * point.Energy
 		point.out[(i*3)+2] = point.Color.B * float32(diffuseFactor) * point.Energy
 
+		if point.Cookie != nil {
+			r, g, b := point.cookieColor(vector.Vector(lightVec))
+			point.out[(i * 3)] *= r
+			point.out[(i*3)+1] *= g
+			point.out[(i*3)+2] *= b
+		}
+
 	}
 
 	return point.out
@@ -263,6 +491,17 @@ func (point *PointLight) SetOn(on bool) {
 	point.On = on
 }
 
+func (point *PointLight) lightLayers() LightLayers {
+	return point.Layers
+}
+
+// InfluenceSphere returns the PointLight's world position and its range, which is its Distance value (or its Attenuation's Range, if
+// that's set and smaller). If neither is set (or custom attenuation replaces the Distance), the light falls off without a hard
+// limit, so the returned range is +Inf.
+func (point *PointLight) InfluenceSphere() (vector.Vector, float64) {
+	return point.WorldPosition(), point.Attenuation.influenceRadius(point.Distance)
+}
+
 // Type returns the NodeType for this object.
 func (point *PointLight) Type() NodeType {
 	return NodeTypePointLight
@@ -277,7 +516,8 @@ type DirectionalLight struct {
 	// Energy is the overall energy of the Light. Internally, technically there's no difference between a brighter color and a
 	// higher energy, but this is here for convenience / adherance to GLTF / 3D modelers.
 	Energy float32
-	On     bool // If the light is on and contributing to the scene.
+	On     bool        // If the light is on and contributing to the scene.
+	Layers LightLayers // The LightLayers the light is on. Defaults to LightLayersAll.
 
 	workingForward       vector.Vector // Internal forward vector so we don't have to calculate it for every triangle for every model using this light.
 	workingModelRotation Matrix4       // Similarly, this is an internal rotational transform (without the transformation row) for the Model being lit.
@@ -292,6 +532,7 @@ func NewDirectionalLight(name string, r, g, b, energy float32) *DirectionalLight
 		Color:  NewColor(r, g, b, 1),
 		Energy: energy,
 		On:     true,
+		Layers: LightLayersAll,
 		out:    [9]float32{},
 	}
 }
@@ -302,6 +543,7 @@ func (sun *DirectionalLight) Clone() INode {
 	clone := NewDirectionalLight(sun.name, sun.Color.R, sun.Color.G, sun.Color.B, sun.Energy)
 
 	clone.On = sun.On
+	clone.Layers = sun.Layers
 
 	clone.Node = sun.Node.Clone().(*Node)
 	for _, child := range sun.children {
@@ -324,6 +566,13 @@ func (sun *DirectionalLight) beginModel(model *Model) {
 
 // Light returns the R, G, and B values for the DirectionalLight for each vertex of the provided Triangle.
 func (sun *DirectionalLight) Light(triIndex int, model *Model) [9]float32 {
+	return sun.lightFacing(triIndex, model, 1)
+}
+
+// lightFacing lights the given side of the Triangle; see ILight.lightFacing().
+func (sun *DirectionalLight) lightFacing(triIndex int, model *Model, facing float64) [9]float32 {
+
+	_, normals := model.morphedVertices()
 
 	for i := 0; i < 3; i++ {
 
@@ -332,10 +581,10 @@ func (sun *DirectionalLight) Light(triIndex int, model *Model) [9]float32 {
 			// If it's skinned, we don't have to calculate the normal, as that's been pre-calc'd for us
 			normal = model.Mesh.vertexSkinnedNormals[triIndex*3+i]
 		} else {
-			normal = sun.workingModelRotation.MultVec(model.Mesh.VertexNormals[triIndex*3+i])
+			normal = sun.workingModelRotation.MultVec(normals[triIndex*3+i])
 		}
 
-		diffuseFactor := dot(normal, sun.workingForward)
+		diffuseFactor := dot(normal, sun.workingForward) * facing
 		if diffuseFactor < 0 {
 			diffuseFactor = 0
 		}
@@ -371,19 +620,723 @@ func (sun *DirectionalLight) SetOn(on bool) {
 	sun.On = on
 }
 
+func (sun *DirectionalLight) lightLayers() LightLayers {
+	return sun.Layers
+}
+
+// InfluenceSphere returns the DirectionalLight's world position and a radius of +Inf, as directional lights have infinite range.
+func (sun *DirectionalLight) InfluenceSphere() (vector.Vector, float64) {
+	return sun.WorldPosition(), math.Inf(1)
+}
+
 // Type returns the NodeType for this object.
 func (sun *DirectionalLight) Type() NodeType {
 	return NodeTypeDirectionalLight
 }
 
+//---------------//
+
+// SpotLight represents a light that shines from a point in a cone, in the direction of its local -Z axis (like a flashlight or a
+// stage light).
+type SpotLight struct {
+	*Node
+	// Distance represents the distance after which the light fully attenuates. If this is 0 (the default),
+	// it falls off using something akin to the inverse square law, like a PointLight.
+	Distance float64
+	// Color is the color of the SpotLight.
+	Color *Color
+	// Energy is the overall energy of the Light, with 1.0 being full brightness. Internally, technically there's no
+	// difference between a brighter color and a higher energy, but this is here for convenience / adherance to the
+	// GLTF spec and 3D modelers.
+	Energy float32
+	// If the light is on and contributing to the scene.
+	On bool
+	// Layers are the LightLayers the light is on; it only lights Models that receive light from at least one of them. Defaults to
+	// LightLayersAll.
+	Layers LightLayers
+	// Attenuation can be used to customize how the SpotLight falls off over distance, and to give it a hard range. By default,
+	// it's unset, and the SpotLight falls off according to its Distance value.
+	Attenuation LightAttenuation
+
+	// InnerConeAngle and OuterConeAngle are the angles (in degrees) from the center of the SpotLight's cone to its edges. Vertices
+	// within the inner cone are fully lit, while the light fades out between the inner and outer cones; vertices outside of the
+	// outer cone aren't lit at all. InnerConeAngle defaults to 0, and OuterConeAngle defaults to 45.
+	InnerConeAngle float64
+	OuterConeAngle float64
+
+	// Cookie is an image that the color of the SpotLight is multiplied by, projected from the SpotLight along its cone (like a
+	// flashlight's pattern, or light shining through a window). The image is stretched so that its edges meet the edge of the outer
+	// cone, with the top of the image facing the SpotLight's local +Y axis. A *ebiten.Image can be used, though any image.Image works.
+	// The image's pixels are read when the SpotLight is first rendered with it, so changes to the image's contents afterwards aren't
+	// picked up unless a different image is assigned. SpotLights with a Cookie are always lit per vertex. Defaults to nil.
+	Cookie image.Image
+
+	cosInner         float64
+	cosOuter         float64
+	tanOuter         float64
+	workingPosition  vector.Vector
+	workingDirection vector.Vector
+	workingRight     vector.Vector
+	workingUp        vector.Vector
+	cookie           imageSampler
+	out              [9]float32
+}
+
+// NewSpotLight creates a new SpotLight.
+func NewSpotLight(name string, r, g, b, energy float32) *SpotLight {
+	return &SpotLight{
+		Node:           NewNode(name),
+		Energy:         energy,
+		Color:          NewColor(r, g, b, 1),
+		On:             true,
+		Layers:         LightLayersAll,
+		OuterConeAngle: 45,
+		out:            [9]float32{},
+	}
+}
+
+// Clone returns a new clone of the given SpotLight.
+func (spot *SpotLight) Clone() INode {
+
+	clone := NewSpotLight(spot.name, spot.Color.R, spot.Color.G, spot.Color.B, spot.Energy)
+	clone.On = spot.On
+	clone.Layers = spot.Layers
+	clone.Distance = spot.Distance
+	clone.Attenuation = spot.Attenuation
+	clone.InnerConeAngle = spot.InnerConeAngle
+	clone.OuterConeAngle = spot.OuterConeAngle
+	clone.Cookie = spot.Cookie
+
+	clone.Node = spot.Node.Clone().(*Node)
+	for _, child := range spot.children {
+		child.setParent(clone)
+	}
+
+	return clone
+
+}
+
+func (spot *SpotLight) beginRender() {
+	spot.cosInner = math.Cos(ToRadians(math.Min(spot.InnerConeAngle, spot.OuterConeAngle)))
+	spot.cosOuter = math.Cos(ToRadians(spot.OuterConeAngle))
+	spot.tanOuter = math.Tan(ToRadians(spot.OuterConeAngle))
+	spot.cookie.update(spot.Cookie)
+}
+
+func (spot *SpotLight) beginModel(model *Model) {
+
+	// Like PointLights, the SpotLight's position and direction are transformed into the Model's local space rather than transforming
+	// each of the Model's vertices.
+	rotation := spot.WorldRotation()
+	direction := rotation.Forward().Invert()
+
+	if model.Skinned {
+		spot.workingPosition = spot.WorldPosition()
+		spot.workingDirection = direction
+		spot.workingRight = rotation.Right()
+		spot.workingUp = rotation.Up()
+	} else {
+		p, _, r := model.Transform().Inverted().Decompose()
+		spot.workingPosition = r.MultVec(spot.WorldPosition()).Add(p)
+		spot.workingDirection = r.MultVec(direction).Unit()
+		spot.workingRight = r.MultVec(rotation.Right()).Unit()
+		spot.workingUp = r.MultVec(rotation.Up()).Unit()
+	}
+
+}
+
+// cookieColor returns the color of the SpotLight's Cookie in the direction of the given (normalized) vector pointing from the
+// vertex to the SpotLight.
+func (spot *SpotLight) cookieColor(lightVec vector.Vector) (float32, float32, float32) {
+
+	// The direction from the SpotLight to the vertex, in the SpotLight's local space.
+	x := -dot(lightVec, spot.workingRight)
+	y := -dot(lightVec, spot.workingUp)
+	z := -dot(lightVec, spot.workingDirection)
+
+	if z <= 0 || spot.tanOuter <= 0 {
+		return 0, 0, 0
+	}
+
+	u := 0.5 + 0.5*x/(z*spot.tanOuter)
+	v := 0.5 - 0.5*y/(z*spot.tanOuter)
+
+	return spot.cookie.sample(u, v)
+
+}
+
+// cone returns the cone factor for the given cosine of the angle between the SpotLight's direction and the direction from the
+// SpotLight to a vertex; it fades the light from 1 inside of the inner cone to 0 outside of the outer cone.
+func (spot *SpotLight) cone(angle float64) float64 {
+	if angle >= spot.cosInner {
+		return 1
+	} else if angle > spot.cosOuter {
+		return (angle - spot.cosOuter) / (spot.cosInner - spot.cosOuter)
+	}
+	return 0
+}
+
+// Light returns the R, G, and B values for the SpotLight for all vertices of a given Triangle.
+func (spot *SpotLight) Light(triIndex int, model *Model) [9]float32 {
+	return spot.lightFacing(triIndex, model, 1)
+}
+
+// lightFacing lights the given side of the Triangle; see ILight.lightFacing().
+func (spot *SpotLight) lightFacing(triIndex int, model *Model, facing float64) [9]float32 {
+
+	var vertPos, vertNormal vector.Vector
+
+	positions, normals := model.morphedVertices()
+
+	for i := 0; i < 3; i++ {
+
+		if model.Skinned {
+			vertPos = model.Mesh.vertexSkinnedPositions[triIndex*3+i]
+			vertNormal = model.Mesh.vertexSkinnedNormals[triIndex*3+i]
+		} else {
+			vertPos = positions[triIndex*3+i]
+			vertNormal = normals[triIndex*3+i]
+		}
+
+		lightVec := vector.Vector(vector.In(fastVectorSub(spot.workingPosition, vertPos)).Unit())
+		diffuse := dot(vertNormal, lightVec) * facing
+
+		cone := spot.cone(-dot(lightVec, spot.workingDirection))
+
+		if diffuse < 0 || cone <= 0 {
+			spot.out[(i * 3)] = 0
+			spot.out[(i*3)+1] = 0
+			spot.out[(i*3)+2] = 0
+			continue
+		}
+
+		distance := fastVectorDistanceSquared(spot.workingPosition, vertPos)
+
+		diffuseFactor := diffuse * spot.Attenuation.falloff(distance, spot.Distance)
+
+		diffuseFactor *= cone
+
+		spot.out[(i * 3)] = spot.Color.R * float32(diffuseFactor) * spot.Energy
+		spot.out[(i*3)+1] = spot.Color.G * float32(diffuseFactor) * spot.Energy
+		spot.out[(i*3)+2] = spot.Color.B * float32(diffuseFactor) * spot.Energy
+
+		if spot.Cookie != nil {
+			r, g, b := spot.cookieColor(lightVec)
+			spot.out[(i * 3)] *= r
+			spot.out[(i*3)+1] *= g
+			spot.out[(i*3)+2] *= b
+		}
+
+	}
+
+	return spot.out
+
+}
+
+// AddChildren parents the provided children Nodes to the passed parent Node, inheriting its transformations and being under it in the scenegraph
+// hierarchy. If the children are already parented to other Nodes, they are unparented before doing so.
+func (spot *SpotLight) AddChildren(children ...INode) {
+	spot.addChildren(spot, children...)
+}
+
+// Unparent unparents the SpotLight from its parent, removing it from the scenegraph.
+func (spot *SpotLight) Unparent() {
+	if spot.parent != nil {
+		spot.parent.RemoveChildren(spot)
+	}
+}
+
+func (spot *SpotLight) IsOn() bool {
+	return spot.On && spot.Energy > 0
+}
+
+func (spot *SpotLight) SetOn(on bool) {
+	spot.On = on
+}
+
+func (spot *SpotLight) lightLayers() LightLayers {
+	return spot.Layers
+}
+
+// InfluenceSphere returns the SpotLight's world position and its range, which is its Distance value (or its Attenuation's Range, if
+// that's set and smaller). If neither is set (or custom attenuation replaces the Distance), the light falls off without a hard
+// limit, so the returned range is +Inf.
+func (spot *SpotLight) InfluenceSphere() (vector.Vector, float64) {
+	return spot.WorldPosition(), spot.Attenuation.influenceRadius(spot.Distance)
+}
+
+// Type returns the NodeType for this object.
+func (spot *SpotLight) Type() NodeType {
+	return NodeTypeSpotLight
+}
+
+//---------------//
+
+// RectLight represents a rectangular area light, like a window or a ceiling light panel. It's centered on its position, extends
+// along its local X and Y axes, and shines in the direction of its local -Z axis. Rather than lighting vertices from a single
+// point, each vertex is lit from the closest point on the rectangle, which approximates the soft lighting of a real area light.
+// Note that glTF has no area lights, so RectLights have to be created in code.
+type RectLight struct {
+	*Node
+	// Width and Height are the size of the RectLight's rectangle along its local X and Y axes, before the RectLight's scale is
+	// applied. Both default to 1.
+	Width, Height float64
+	// Distance represents the distance from the rectangle after which the light fully attenuates. If this is 0 (the default),
+	// it falls off using something akin to the inverse square law, like a PointLight.
+	Distance float64
+	// Color is the color of the RectLight.
+	Color *Color
+	// Energy is the overall energy of the Light, with 1.0 being full brightness. Internally, technically there's no
+	// difference between a brighter color and a higher energy, but this is here for convenience / adherance to the
+	// GLTF spec and 3D modelers.
+	Energy float32
+	// If the light is on and contributing to the scene.
+	On bool
+	// Layers are the LightLayers the light is on; it only lights Models that receive light from at least one of them. Defaults to
+	// LightLayersAll.
+	Layers LightLayers
+	// Attenuation can be used to customize how the RectLight falls off over distance from its rectangle, and to give it a hard
+	// range. By default, it's unset, and the RectLight falls off according to its Distance value.
+	Attenuation LightAttenuation
+
+	halfWidth        float64
+	halfHeight       float64
+	workingPosition  vector.Vector
+	workingRight     vector.Vector
+	workingUp        vector.Vector
+	workingDirection vector.Vector
+	out              [9]float32
+}
+
+// NewRectLight creates a new RectLight with the given color, energy, and size.
+func NewRectLight(name string, r, g, b, energy float32, width, height float64) *RectLight {
+	return &RectLight{
+		Node:   NewNode(name),
+		Width:  width,
+		Height: height,
+		Energy: energy,
+		Color:  NewColor(r, g, b, 1),
+		On:     true,
+		Layers: LightLayersAll,
+		out:    [9]float32{},
+	}
+}
+
+// Clone returns a new clone of the given RectLight.
+func (rect *RectLight) Clone() INode {
+
+	clone := NewRectLight(rect.name, rect.Color.R, rect.Color.G, rect.Color.B, rect.Energy, rect.Width, rect.Height)
+	clone.On = rect.On
+	clone.Layers = rect.Layers
+	clone.Distance = rect.Distance
+	clone.Attenuation = rect.Attenuation
+
+	clone.Node = rect.Node.Clone().(*Node)
+	for _, child := range rect.children {
+		child.setParent(clone)
+	}
+
+	return clone
+
+}
+
+// TransformedSize returns the width and height of the RectLight's rectangle after applying its world scale.
+func (rect *RectLight) TransformedSize() (float64, float64) {
+	scale := rect.WorldScale()
+	return rect.Width * math.Abs(scale[0]), rect.Height * math.Abs(scale[1])
+}
+
+func (rect *RectLight) beginRender() {
+	w, h := rect.TransformedSize()
+	rect.halfWidth = w / 2
+	rect.halfHeight = h / 2
+}
+
+func (rect *RectLight) beginModel(model *Model) {
+
+	rotation := rect.WorldRotation()
+	right := rotation.Right()
+	up := rotation.Up()
+	direction := rotation.Forward().Invert()
+
+	// Like PointLights, the RectLight is transformed into the Model's local space rather than transforming each of the Model's vertices.
+	if model.Skinned {
+		rect.workingPosition = rect.WorldPosition()
+		rect.workingRight = right
+		rect.workingUp = up
+		rect.workingDirection = direction
+	} else {
+		p, _, r := model.Transform().Inverted().Decompose()
+		rect.workingPosition = r.MultVec(rect.WorldPosition()).Add(p)
+		rect.workingRight = r.MultVec(right).Unit()
+		rect.workingUp = r.MultVec(up).Unit()
+		rect.workingDirection = r.MultVec(direction).Unit()
+	}
+
+}
+
+// closestPoint returns the closest point on the RectLight's rectangle to the given position (in the working space set up by
+// beginModel()).
+func (rect *RectLight) closestPoint(position vector.Vector) vector.Vector {
+	return rect.closestPointOn(position, rect.workingPosition, rect.workingRight, rect.workingUp)
+}
+
+// closestPointOn returns the closest point to the given position on the RectLight's rectangle, placed at the given center and
+// oriented along the given right and up vectors.
+func (rect *RectLight) closestPointOn(position, center, right, up vector.Vector) vector.Vector {
+
+	diff := fastVectorSub(position, center)
+
+	x := math.Max(math.Min(dot(diff, right), rect.halfWidth), -rect.halfWidth)
+	y := math.Max(math.Min(dot(diff, up), rect.halfHeight), -rect.halfHeight)
+
+	return vector.Vector{
+		center[0] + right[0]*x + up[0]*y,
+		center[1] + right[1]*x + up[1]*y,
+		center[2] + right[2]*x + up[2]*y,
+	}
+
+}
+
+// Light returns the R, G, and B values for the RectLight for all vertices of a given Triangle.
+func (rect *RectLight) Light(triIndex int, model *Model) [9]float32 {
+	return rect.lightFacing(triIndex, model, 1)
+}
+
+// lightFacing lights the given side of the Triangle; see ILight.lightFacing().
+func (rect *RectLight) lightFacing(triIndex int, model *Model, facing float64) [9]float32 {
+
+	var vertPos, vertNormal vector.Vector
+
+	positions, normals := model.morphedVertices()
+
+	for i := 0; i < 3; i++ {
+
+		if model.Skinned {
+			vertPos = model.Mesh.vertexSkinnedPositions[triIndex*3+i]
+			vertNormal = model.Mesh.vertexSkinnedNormals[triIndex*3+i]
+		} else {
+			vertPos = positions[triIndex*3+i]
+			vertNormal = normals[triIndex*3+i]
+		}
+
+		rect.out[(i * 3)] = 0
+		rect.out[(i*3)+1] = 0
+		rect.out[(i*3)+2] = 0
+
+		// The rectangle only shines forward, so vertices behind it aren't lit.
+		if dot(fastVectorSub(vertPos, rect.workingPosition), rect.workingDirection) <= 0 {
+			continue
+		}
+
+		closest := rect.closestPoint(vertPos)
+
+		lightVec := vector.Vector(vector.In(fastVectorSub(closest, vertPos)).Unit())
+		diffuse := dot(vertNormal, lightVec) * facing
+
+		if diffuse <= 0 {
+			continue
+		}
+
+		distance := fastVectorDistanceSquared(closest, vertPos)
+
+		diffuseFactor := diffuse * rect.Attenuation.falloff(distance, rect.Distance)
+
+		rect.out[(i * 3)] = rect.Color.R * float32(diffuseFactor) * rect.Energy
+		rect.out[(i*3)+1] = rect.Color.G * float32(diffuseFactor) * rect.Energy
+		rect.out[(i*3)+2] = rect.Color.B * float32(diffuseFactor) * rect.Energy
+
+	}
+
+	return rect.out
+
+}
+
+// AddChildren parents the provided children Nodes to the passed parent Node, inheriting its transformations and being under it in the scenegraph
+// hierarchy. If the children are already parented to other Nodes, they are unparented before doing so.
+func (rect *RectLight) AddChildren(children ...INode) {
+	rect.addChildren(rect, children...)
+}
+
+// Unparent unparents the RectLight from its parent, removing it from the scenegraph.
+func (rect *RectLight) Unparent() {
+	if rect.parent != nil {
+		rect.parent.RemoveChildren(rect)
+	}
+}
+
+func (rect *RectLight) IsOn() bool {
+	return rect.On && rect.Energy > 0
+}
+
+func (rect *RectLight) SetOn(on bool) {
+	rect.On = on
+}
+
+func (rect *RectLight) lightLayers() LightLayers {
+	return rect.Layers
+}
+
+// InfluenceSphere returns the RectLight's world position and its range, which is its Distance value (or its Attenuation's Range,
+// if that's set and smaller) plus the distance from the RectLight's center to its corners. If neither is set (or custom attenuation
+// replaces the Distance), the light falls off without a hard limit, so the returned range is +Inf.
+func (rect *RectLight) InfluenceSphere() (vector.Vector, float64) {
+	radius := rect.Attenuation.influenceRadius(rect.Distance)
+	if math.IsInf(radius, 1) {
+		return rect.WorldPosition(), radius
+	}
+	w, h := rect.TransformedSize()
+	return rect.WorldPosition(), radius + math.Sqrt(w*w+h*h)/2
+}
+
+// Type returns the NodeType for this object.
+func (rect *RectLight) Type() NodeType {
+	return NodeTypeRectLight
+}
+
+//---------------//
+
+// lightProbeDirections are the directions from which the light arriving at a LightProbe is baked, in the order of LightProbe.Colors.
+var lightProbeDirections = [6]vector.Vector{
+	{1, 0, 0},
+	{-1, 0, 0},
+	{0, 1, 0},
+	{0, -1, 0},
+	{0, 0, 1},
+	{0, 0, -1},
+}
+
+// LightProbe represents a point at which the lighting of a Scene has been baked (see LightProbe.Bake()), so that Models moving around
+// a Scene with baked lighting can be lit to match it. LightProbes only light Models that have them in their LightGroup, and aren't used
+// by Models lit by the Scene's lights. A Model with several LightProbes in its LightGroup is lit by a blend of them, weighted by how close
+// the Model is to each LightProbe relative to its Radius; Models that are outside of the Radius of every LightProbe aren't lit by them.
+type LightProbe struct {
+	*Node
+	// Colors are the baked colors of the light arriving at the LightProbe from the +X, -X, +Y, -Y, +Z, and -Z directions (in world space).
+	// Vertices are lit by a blend of these colors according to the directions of their normals. They default to black.
+	Colors [6]*Color
+	// Radius is the distance from the LightProbe within which Models are lit by it. Defaults to 5.
+	Radius float64
+	// Energy is the overall energy of the LightProbe, with 1.0 being the lighting as it was baked.
+	Energy float32
+	// If the light is on and contributing to the scene.
+	On bool
+	// Layers are the LightLayers the light is on; it only lights Models that receive light from at least one of them. Defaults to
+	// LightLayersAll.
+	Layers LightLayers
+
+	workingWeight        float64
+	workingModelRotation Matrix4
+	out                  [9]float32
+}
+
+// NewLightProbe creates a new LightProbe with the given Radius.
+func NewLightProbe(name string, radius float64) *LightProbe {
+
+	probe := &LightProbe{
+		Node:   NewNode(name),
+		Radius: radius,
+		Energy: 1,
+		On:     true,
+		Layers: LightLayersAll,
+		out:    [9]float32{},
+	}
+
+	for i := range probe.Colors {
+		probe.Colors[i] = NewColor(0, 0, 0, 1)
+	}
+
+	return probe
+
+}
+
+// Clone returns a new clone of the given LightProbe.
+func (probe *LightProbe) Clone() INode {
+
+	clone := NewLightProbe(probe.name, probe.Radius)
+	clone.On = probe.On
+	clone.Layers = probe.Layers
+	clone.Energy = probe.Energy
+
+	for i := range probe.Colors {
+		clone.Colors[i] = probe.Colors[i].Clone()
+	}
+
+	clone.Node = probe.Node.Clone().(*Node)
+	for _, child := range probe.children {
+		child.setParent(clone)
+	}
+
+	return clone
+
+}
+
+// Bake bakes the light arriving at the LightProbe's position from each direction from the provided lights into the LightProbe's
+// Colors. If the LightProbe is in a Scene with a World, the World's AmbientLight is baked in as well. Other LightProbes are ignored.
+func (probe *LightProbe) Bake(lights ...ILight) {
+
+	if scene := probe.Scene(); scene != nil && scene.World != nil && scene.World.AmbientLight != nil {
+		lights = append(append(make([]ILight, 0, len(lights)+1), lights...), scene.World.AmbientLight)
+	}
+
+	position := probe.WorldPosition()
+
+	influencing := make([]ILight, 0, len(lights))
+
+	for _, light := range lights {
+
+		if _, isProbe := light.(*LightProbe); isProbe || !light.IsOn() {
+			continue
+		}
+
+		if center, radius := light.InfluenceSphere(); math.IsInf(radius, 1) || fastVectorDistanceSquared(center, position) <= radius*radius {
+			influencing = append(influencing, light)
+		}
+
+	}
+
+	sampler := newLightSampler(NewMatrix4(), influencing)
+
+	for i, direction := range lightProbeDirections {
+		result := sampler.light(position, direction)
+		probe.Colors[i].Set(result[0], result[1], result[2], 1)
+	}
+
+}
+
+func (probe *LightProbe) beginRender() {}
+
+func (probe *LightProbe) beginModel(model *Model) {
+
+	// The weight is normalized against the weights of other LightProbes lighting the Model in normalizeLightProbes().
+	probe.workingWeight = 0
+	if probe.Radius > 0 {
+		probe.workingWeight = math.Max(1-(probe.WorldPosition().Sub(model.WorldPosition()).Magnitude()/probe.Radius), 0)
+	}
+
+	if !model.Skinned {
+		probe.workingModelRotation = model.WorldRotation().Inverted().Transposed()
+	}
+
+}
+
+// normalizeLightProbes normalizes the weights of the LightProbes in the lights given (which have all had beginModel() called for the
+// same Model), so that the Model is lit by a blend of them.
+func normalizeLightProbes(lights []ILight) {
+
+	total := 0.0
+
+	for _, light := range lights {
+		if probe, isProbe := light.(*LightProbe); isProbe {
+			total += probe.workingWeight
+		}
+	}
+
+	if total <= 0 {
+		return
+	}
+
+	for _, light := range lights {
+		if probe, isProbe := light.(*LightProbe); isProbe {
+			probe.workingWeight /= total
+		}
+	}
+
+}
+
+// Light returns the R, G, and B values for the LightProbe for each vertex of the provided Triangle.
+func (probe *LightProbe) Light(triIndex int, model *Model) [9]float32 {
+	return probe.lightFacing(triIndex, model, 1)
+}
+
+// lightFacing lights the given side of the Triangle; see ILight.lightFacing().
+func (probe *LightProbe) lightFacing(triIndex int, model *Model, facing float64) [9]float32 {
+
+	_, normals := model.morphedVertices()
+
+	weight := float32(probe.workingWeight) * probe.Energy
+
+	for i := 0; i < 3; i++ {
+
+		var normal vector.Vector
+		if model.Skinned {
+			normal = model.Mesh.vertexSkinnedNormals[triIndex*3+i]
+		} else {
+			normal = probe.workingModelRotation.MultVec(normals[triIndex*3+i])
+		}
+
+		probe.out[i*3] = 0
+		probe.out[i*3+1] = 0
+		probe.out[i*3+2] = 0
+
+		// Each axis contributes the color baked from the direction the normal faces along it, by the squared component of the normal.
+		for axis := 0; axis < 3; axis++ {
+
+			color := probe.Colors[axis*2]
+			if normal[axis]*facing < 0 {
+				color = probe.Colors[axis*2+1]
+			}
+
+			factor := float32(normal[axis]*normal[axis]) * weight
+
+			probe.out[i*3] += color.R * factor
+			probe.out[i*3+1] += color.G * factor
+			probe.out[i*3+2] += color.B * factor
+
+		}
+
+	}
+
+	return probe.out
+
+}
+
+// AddChildren parents the provided children Nodes to the passed parent Node, inheriting its transformations and being under it in the scenegraph
+// hierarchy. If the children are already parented to other Nodes, they are unparented before doing so.
+func (probe *LightProbe) AddChildren(children ...INode) {
+	probe.addChildren(probe, children...)
+}
+
+// Unparent unparents the LightProbe from its parent, removing it from the scenegraph.
+func (probe *LightProbe) Unparent() {
+	if probe.parent != nil {
+		probe.parent.RemoveChildren(probe)
+	}
+}
+
+func (probe *LightProbe) IsOn() bool {
+	return probe.On && probe.Energy > 0
+}
+
+func (probe *LightProbe) SetOn(on bool) {
+	probe.On = on
+}
+
+func (probe *LightProbe) lightLayers() LightLayers {
+	return probe.Layers
+}
+
+// InfluenceSphere returns the LightProbe's world position and its Radius.
+func (probe *LightProbe) InfluenceSphere() (vector.Vector, float64) {
+	return probe.WorldPosition(), math.Max(probe.Radius, 0)
+}
+
+// Type returns the NodeType for this object.
+func (probe *LightProbe) Type() NodeType {
+	return NodeTypeLightProbe
+}
+
+//---------------//
+
 // CubeLight represents an AABB volume that lights triangles.
 type CubeLight struct {
 	*Node
-	Dimensions Dimensions // The overall dimensions of the CubeLight.
-	Distance   float64    // The distance of the CubeLight - at 0, all triangles within the CubeLight AABB volume are lit evenly. At any other value, the CubeLight lights from the top down to the distance value specified.
-	Energy     float32    // The overall energy of the CubeLight
-	Color      *Color     // The color of the CubeLight
-	On         bool       // If the CubeLight is on or not
+	Dimensions Dimensions  // The overall dimensions of the CubeLight.
+	Distance   float64     // The distance of the CubeLight - at 0, all triangles within the CubeLight AABB volume are lit evenly. At any other value, the CubeLight lights from the top down to the distance value specified.
+	Energy     float32     // The overall energy of the CubeLight
+	Color      *Color      // The color of the CubeLight
+	On         bool        // If the CubeLight is on or not
+	Layers     LightLayers // The LightLayers the CubeLight is on. Defaults to LightLayersAll.
 	// A value between 0 and 1 indicating how much opposite faces are still lit within the volume (i.e. at LightBleed = 0.0,
 	// faces away from the light are dark; at 1.0, faces away from the light are fully illuminated)
 	Bleed                  float64
@@ -406,6 +1359,7 @@ func NewCubeLight(name string, dimensions Dimensions) *CubeLight {
 		Energy:        1,
 		Color:         NewColor(1, 1, 1, 1),
 		On:            true,
+		Layers:        LightLayersAll,
 		LightingAngle: vector.Vector{0, -1, 0},
 		out:           [9]float32{},
 	}
@@ -427,6 +1381,7 @@ func (cube *CubeLight) Clone() INode {
 	newCube.Energy = cube.Energy
 	newCube.Color = cube.Color.Clone()
 	newCube.On = cube.On
+	newCube.Layers = cube.Layers
 	newCube.Bleed = cube.Bleed
 	newCube.LightingAngle = cube.LightingAngle.Clone()
 	newCube.SetWorldTransform(cube.Transform())
@@ -538,6 +1493,11 @@ func (cube *CubeLight) beginModel(model *Model) {
 
 // Light returns the R, G, and B values for the PointLight for all vertices of a given Triangle.
 func (cube *CubeLight) Light(triIndex int, model *Model) [9]float32 {
+	return cube.lightFacing(triIndex, model, 1)
+}
+
+// lightFacing lights the given side of the Triangle; see ILight.lightFacing().
+func (cube *CubeLight) lightFacing(triIndex int, model *Model, facing float64) [9]float32 {
 
 	// TODO: Make lighting faster by returning early if the triangle is too far from the point light position
 
@@ -570,6 +1530,9 @@ func (cube *CubeLight) Light(triIndex int, model *Model) [9]float32 {
 
 	var vertPos, vertNormal vector.Vector
 
+	// Unskinned Models are lit using their morphed vertices (which are just the Mesh's vertices if no MorphTargets apply).
+	positions, normals := model.morphedVertices()
+
 	for i := 0; i < 9; i++ {
 		cube.out[i] = 0
 	}
@@ -580,13 +1543,13 @@ func (cube *CubeLight) Light(triIndex int, model *Model) [9]float32 {
 			vertPos = model.Mesh.vertexSkinnedPositions[triIndex*3+i]
 			vertNormal = model.Mesh.vertexSkinnedNormals[triIndex*3+i]
 		} else {
-			vertPos = model.Mesh.VertexPositions[triIndex*3+i]
-			vertNormal = model.Mesh.VertexNormals[triIndex*3+i]
+			vertPos = positions[triIndex*3+i]
+			vertNormal = normals[triIndex*3+i]
 		}
 
 		var diffuse, diffuseFactor float64
 
-		diffuse = dot(vertNormal, vector.Vector(cube.workingAngle))
+		diffuse = dot(vertNormal, vector.Vector(cube.workingAngle)) * facing
 
 		if cube.Bleed > 0 {
 
@@ -643,6 +1606,16 @@ func (cube *CubeLight) SetOn(on bool) {
 	cube.On = on
 }
 
+func (cube *CubeLight) lightLayers() LightLayers {
+	return cube.Layers
+}
+
+// InfluenceSphere returns a sphere surrounding the CubeLight's transformed AABB volume, as it only lights triangles within the volume.
+func (cube *CubeLight) InfluenceSphere() (vector.Vector, float64) {
+	dim := cube.TransformedDimensions()
+	return dim.Center(), dim.MaxSpan() / 2
+}
+
 // AddChildren parents the provided children Nodes to the passed parent Node, inheriting its transformations and being under it in the scenegraph
 // hierarchy. If the children are already parented to other Nodes, they are unparented before doing so.
 func (cube *CubeLight) AddChildren(children ...INode) {
@@ -1,6 +1,7 @@
 package tetra3d
 
-// LightGroup represents a grouping of lights. This is used on Models to control which lights are used to light them.
+// LightGroup represents a grouping of lights. This is used on Models to control which lights are used to light them. For larger
+// Scenes, it can be simpler to split lights up using LightLayers instead (see Model.LightLayers).
 type LightGroup struct {
 	Lights []ILight // The collection of lights present in the LightGroup.
 	Active bool     // If the LightGroup is active or not; when a Model's LightGroup is inactive, Models will fallback to the lights present under the Scene's root.
@@ -26,3 +27,27 @@ func (lg *LightGroup) Clone() *LightGroup {
 	newLG.Active = lg.Active
 	return newLG
 }
+
+// LightLayers is a bitmask of up to 32 light layers (i.e. one layer for interior lights and another for exterior lights). Lights
+// declare which layers they're on with their Layers field, and Models declare which layers they receive light from with their
+// LightLayers field; a Model is only lit by lights that share at least one layer with it. This applies to lights in a Model's
+// LightGroup as well as the lights in the Scene. By default, both lights and Models are on all layers, so every light lights
+// every Model.
+type LightLayers uint32
+
+// LightLayersAll is a LightLayers value with all layers set.
+const LightLayersAll = ^LightLayers(0)
+
+// NewLightLayers returns a LightLayers bitmask with the layers of the given indices (from 0 to 31) set.
+func NewLightLayers(layers ...int) LightLayers {
+	mask := LightLayers(0)
+	for _, layer := range layers {
+		mask |= 1 << layer
+	}
+	return mask
+}
+
+// Has returns if any of the layers in the other LightLayers are set in the LightLayers.
+func (layers LightLayers) Has(other LightLayers) bool {
+	return layers&other != 0
+}
@@ -0,0 +1,34 @@
+package tetra3d
+
+import (
+	"testing"
+)
+
+func TestLightLayers(t *testing.T) {
+
+	interior, exterior := NewLightLayers(0), NewLightLayers(1)
+
+	bake := func(modelLayers, lightLayers LightLayers) float32 {
+		mesh := NewPlane()
+		quad := NewModel(mesh, "quad")
+		quad.LightLayers = modelLayers
+		light := NewPointLight("light", 1, 1, 1, 1)
+		light.SetLocalPosition(0, 2, 0)
+		light.Layers = lightLayers
+		quad.BakeLighting(0, light)
+		return mesh.VertexColors[0][0].R
+	}
+
+	if lit := bake(interior, interior|exterior); lit <= 0 {
+		t.Errorf("expected a light sharing a layer with the Model to light it, got %f", lit)
+	}
+
+	if lit := bake(interior, exterior); lit != 0 {
+		t.Errorf("expected a light on another layer not to light the Model, got %f", lit)
+	}
+
+	if lit := bake(LightLayersAll, exterior); lit <= 0 {
+		t.Errorf("expected a Model receiving all layers to be lit, got %f", lit)
+	}
+
+}
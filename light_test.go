@@ -0,0 +1,236 @@
+package tetra3d
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestLightProbes(t *testing.T) {
+
+	// A sun shining straight down lights the probe from above, but not from below.
+	sun := NewDirectionalLight("sun", 1, 1, 1, 1)
+	sun.SetLocalRotation(NewMatrix4Rotate(1, 0, 0, -math.Pi/2))
+
+	probe := NewLightProbe("probe", 4)
+	probe.Bake(sun)
+
+	if above, below := probe.Colors[2].R, probe.Colors[3].R; above < 0.99 || below != 0 {
+		t.Errorf("expected the probe to be lit from above only, got %f from above and %f from below", above, below)
+	}
+
+	red := NewLightProbe("red", 4)
+	red.SetLocalPosition(-2, 0, 0)
+	blue := NewLightProbe("blue", 4)
+	blue.SetLocalPosition(2, 0, 0)
+
+	for i := range red.Colors {
+		red.Colors[i].Set(1, 0, 0, 1)
+		blue.Colors[i].Set(0, 0, 1, 1)
+	}
+
+	lightAt := func(x float64) [9]float32 {
+		mesh := NewMesh("triangle")
+		mesh.AddMeshPart(NewMaterial("triangle")).AddTriangles(
+			NewVertex(-0.1, 0, 0.1, 0, 0),
+			NewVertex(0.1, 0, 0.1, 0, 0),
+			NewVertex(0, 0, -0.1, 0, 0),
+		)
+		mesh.AutoNormal()
+		model := NewModel(mesh, "triangle")
+		model.SetLocalPosition(x, 0, 0)
+		model.LightGroup = NewLightGroup(red, blue)
+		lights := []ILight{red, blue}
+		for _, light := range lights {
+			light.beginRender()
+			light.beginModel(model)
+		}
+		normalizeLightProbes(lights)
+		result := [9]float32{}
+		for _, light := range lights {
+			for i, v := range light.Light(0, model) {
+				result[i] += v
+			}
+		}
+		return result
+	}
+
+	// Models are lit by a blend of the probes, weighted by how close they are to each.
+	if lit := lightAt(0); math.Abs(float64(lit[0]-0.5)) > 0.001 || math.Abs(float64(lit[2]-0.5)) > 0.001 {
+		t.Errorf("expected an even blend between the probes, got %v", lit)
+	}
+
+	if lit := lightAt(-1.5); lit[0] <= lit[2] || math.Abs(float64(lit[0]+lit[2]-1)) > 0.001 {
+		t.Errorf("expected mostly the red probe's lighting, got %v", lit)
+	}
+
+	if lit := lightAt(10); lit[0] != 0 || lit[2] != 0 {
+		t.Errorf("expected no lighting outside of the probes' radii, got %v", lit)
+	}
+
+}
+
+func TestSpotLightCone(t *testing.T) {
+
+	// The spot light points straight down from above, so its cone lights triangles right below it, but not ones off to the side.
+	spot := NewSpotLight("spot", 1, 1, 1, 1)
+	spot.SetLocalPosition(0, 2, 0)
+	spot.SetLocalRotation(NewMatrix4Rotate(1, 0, 0, -math.Pi/2))
+	spot.InnerConeAngle = 10
+	spot.OuterConeAngle = 20
+
+	lightAt := func(x float64) float32 {
+		mesh := NewMesh("triangle")
+		mesh.AddMeshPart(NewMaterial("triangle")).AddTriangles(
+			NewVertex(x-0.1, 0, 0.1, 0, 0),
+			NewVertex(x+0.1, 0, 0.1, 0, 0),
+			NewVertex(x, 0, -0.1, 0, 0),
+		)
+		mesh.AutoNormal()
+		model := NewModel(mesh, "triangle")
+		spot.beginRender()
+		spot.beginModel(model)
+		return spot.Light(0, model)[0]
+	}
+
+	if lit := lightAt(0); lit <= 0 {
+		t.Errorf("expected the spot light to light the triangle below it, got %f", lit)
+	}
+
+	if lit := lightAt(5); lit != 0 {
+		t.Errorf("expected the spot light not to light a triangle outside of its cone, got %f", lit)
+	}
+
+}
+
+func TestLightCookies(t *testing.T) {
+
+	// The left half of the cookie is red, and the right half is green.
+	cookie := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	cookie.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	cookie.Set(1, 0, color.RGBA{0, 255, 0, 255})
+
+	lightAt := func(light ILight, x, z float64) [9]float32 {
+		mesh := NewMesh("triangle")
+		mesh.AddMeshPart(NewMaterial("triangle")).AddTriangles(
+			NewVertex(x-0.1, 0, z+0.1, 0, 0),
+			NewVertex(x+0.1, 0, z+0.1, 0, 0),
+			NewVertex(x, 0, z-0.1, 0, 0),
+		)
+		mesh.AutoNormal()
+		model := NewModel(mesh, "triangle")
+		light.beginRender()
+		light.beginModel(model)
+		return light.Light(0, model)
+	}
+
+	spot := NewSpotLight("spot", 1, 1, 1, 1)
+	spot.SetLocalPosition(0, 2, 0)
+	spot.SetLocalRotation(NewMatrix4Rotate(1, 0, 0, -math.Pi/2))
+	spot.OuterConeAngle = 60
+	spot.Cookie = cookie
+
+	if lit := lightAt(spot, -0.5, 0); lit[0] <= 0 || lit[1] != 0 {
+		t.Errorf("expected the spot light's cookie to color the left of its cone red, got %v", lit)
+	}
+
+	if lit := lightAt(spot, 0.5, 0); lit[0] != 0 || lit[1] <= 0 {
+		t.Errorf("expected the spot light's cookie to color the right of its cone green, got %v", lit)
+	}
+
+	// The center of a point light's cookie faces forward (-Z), so the left and right halves light the left and right sides.
+	point := NewPointLight("point", 1, 1, 1, 1)
+	point.SetLocalPosition(0, 0.5, 0)
+	point.Cookie = cookie
+
+	if lit := lightAt(point, -0.5, -1); lit[0] <= 0 || lit[1] != 0 {
+		t.Errorf("expected the point light's cookie to color its front left red, got %v", lit)
+	}
+
+	if lit := lightAt(point, 0.5, -1); lit[0] != 0 || lit[1] <= 0 {
+		t.Errorf("expected the point light's cookie to color its front right green, got %v", lit)
+	}
+
+}
+
+func TestLightAttenuation(t *testing.T) {
+
+	lightAt := func(point *PointLight, x float64) float32 {
+		mesh := NewMesh("triangle")
+		mesh.AddMeshPart(NewMaterial("triangle")).AddTriangles(
+			NewVertex(x-0.1, 0, 0.1, 0, 0),
+			NewVertex(x+0.1, 0, 0.1, 0, 0),
+			NewVertex(x, 0, -0.1, 0, 0),
+		)
+		mesh.AutoNormal()
+		model := NewModel(mesh, "triangle")
+		point.beginRender()
+		point.beginModel(model)
+		return point.Light(0, model)[0]
+	}
+
+	// The light is right above the triangle's first vertex, so it's lit with the attenuation at a distance of 2.
+	point := NewPointLight("point", 1, 1, 1, 1)
+	point.SetLocalPosition(-0.1, 2, 0.1)
+	point.Attenuation.Constant = 1
+	point.Attenuation.Quadratic = 1
+
+	if lit := lightAt(point, 0); math.Abs(float64(lit)-0.2) > 0.001 {
+		t.Errorf("expected the coefficients to attenuate the light to 0.2, got %f", lit)
+	}
+
+	point.Attenuation.Function = func(distance float64) float64 { return 0.5 }
+
+	if lit := lightAt(point, 0); math.Abs(float64(lit)-0.5) > 0.001 {
+		t.Errorf("expected the attenuation function to attenuate the light to 0.5, got %f", lit)
+	}
+
+	point.Attenuation.Range = 1
+
+	if lit := lightAt(point, 0); lit != 0 {
+		t.Errorf("expected no light outside of the attenuation's range, got %f", lit)
+	}
+
+	if _, radius := point.InfluenceSphere(); radius != 1 {
+		t.Errorf("expected the influence sphere to be limited to the attenuation's range, got %f", radius)
+	}
+
+}
+
+func TestRectLightClosestPoint(t *testing.T) {
+
+	// A wide ceiling panel pointing down; vertices below any part of it are lit as though it were right above them.
+	rect := NewRectLight("rect", 1, 1, 1, 1, 10, 1)
+	rect.SetLocalPosition(0, 2, 0)
+	rect.SetLocalRotation(NewMatrix4Rotate(1, 0, 0, -math.Pi/2))
+
+	lightAt := func(x, y float64) float32 {
+		mesh := NewMesh("triangle")
+		mesh.AddMeshPart(NewMaterial("triangle")).AddTriangles(
+			NewVertex(x-0.1, y, 0.1, 0, 0),
+			NewVertex(x+0.1, y, 0.1, 0, 0),
+			NewVertex(x, y, -0.1, 0, 0),
+		)
+		mesh.AutoNormal()
+		model := NewModel(mesh, "triangle")
+		rect.beginRender()
+		rect.beginModel(model)
+		return rect.Light(0, model)[0]
+	}
+
+	center, edge := lightAt(0, 0), lightAt(4, 0)
+
+	if center <= 0 || math.Abs(float64(center-edge)) > 0.01 {
+		t.Errorf("expected vertices under the whole panel to be lit equally, got %f at the center and %f near the edge", center, edge)
+	}
+
+	if beyond := lightAt(10, 0); beyond >= edge {
+		t.Errorf("expected vertices past the end of the panel to be lit less, got %f", beyond)
+	}
+
+	if above := lightAt(0, 3); above != 0 {
+		t.Errorf("expected vertices behind the panel not to be lit, got %f", above)
+	}
+
+}
@@ -0,0 +1,289 @@
+package tetra3d
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/kvartborg/vector"
+)
+
+// lightmapPadding is how many texels the lighting of each triangle is extended past its edges in a baked lightmap, so that texture
+// filtering doesn't blend in the (black) texels that lie outside of the triangles.
+const lightmapPadding = 2
+
+// BakeLightmap bakes the lighting from the provided lights into a new lightmap image of the given size, which can then be applied
+// to the Model's Materials through Material.Lightmap. Unlike Model.BakeLighting(), the lighting is calculated for each texel of the
+// lightmap rather than for each vertex, which gives much better results for large triangles (like floors or walls). The Model's
+// triangles are laid out in the lightmap using their second set of UV values (Mesh.VertexUV2s, which is loaded from the TEXCOORD_1
+// attribute of glTF files), which should not overlap. If the Model is in a Scene with a World, the World's AmbientLight is baked in as
+// well, and Materials' emission colors are also baked in. Note that light levels are clamped to 1.
+func (model *Model) BakeLightmap(width, height int, lights ...ILight) *ebiten.Image {
+	return ebiten.NewImageFromImage(model.bakeLightmap(width, height, lights))
+}
+
+// bakeLightmap bakes the lightmap for BakeLightmap() into an image.RGBA.
+func (model *Model) bakeLightmap(width, height int, lights []ILight) *image.RGBA {
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	if model.Mesh == nil || width <= 0 || height <= 0 {
+		return img
+	}
+
+	if scene := model.Scene(); scene != nil && scene.World != nil && scene.World.AmbientLight != nil {
+		lights = append(append(make([]ILight, 0, len(lights)+1), lights...), scene.World.AmbientLight)
+	}
+
+	transform := model.Transform()
+
+	influencing := make([]ILight, 0, len(lights))
+
+	for _, light := range lights {
+		if light.IsOn() && model.LightLayers.Has(light.lightLayers()) && lightInfluencesModel(light, model) {
+			influencing = append(influencing, light)
+		}
+	}
+
+	sampler := newLightSampler(transform, influencing)
+	lightAt := sampler.light
+
+	texels := make([][3]float32, width*height)
+	covered := make([]bool, width*height)
+
+	positions := model.Mesh.VertexPositions
+	normals := model.Mesh.VertexNormals
+
+	for _, tri := range model.Mesh.Triangles {
+
+		// The corners of the triangle, in texels; V is flipped, as it is for regular UV values.
+		corners := [3][2]float64{}
+
+		for i := 0; i < 3; i++ {
+			uv2 := model.Mesh.VertexUV2s[tri.ID*3+i]
+			corners[i] = [2]float64{uv2[0] * float64(width), (1 - uv2[1]) * float64(height)}
+		}
+
+		area := (corners[1][0]-corners[0][0])*(corners[2][1]-corners[0][1]) - (corners[2][0]-corners[0][0])*(corners[1][1]-corners[0][1])
+
+		if area == 0 {
+			continue
+		}
+
+		minX := math.Min(corners[0][0], math.Min(corners[1][0], corners[2][0]))
+		maxX := math.Max(corners[0][0], math.Max(corners[1][0], corners[2][0]))
+		minY := math.Min(corners[0][1], math.Min(corners[1][1], corners[2][1]))
+		maxY := math.Max(corners[0][1], math.Max(corners[1][1], corners[2][1]))
+
+		startX, endX := int(math.Max(math.Floor(minX), 0)), int(math.Min(math.Ceil(maxX), float64(width-1)))
+		startY, endY := int(math.Max(math.Floor(minY), 0)), int(math.Min(math.Ceil(maxY), float64(height-1)))
+
+		flip := tri.MeshPart.Material != nil && tri.MeshPart.Material.FlipBackfaceNormals
+
+		var emissionR, emissionG, emissionB float32
+		emissive := false
+		if tri.MeshPart.Material != nil {
+			emissionR, emissionG, emissionB, emissive = tri.MeshPart.Material.emission()
+		}
+
+		for y := startY; y <= endY; y++ {
+
+			for x := startX; x <= endX; x++ {
+
+				// The barycentric coordinates of the texel's center within the triangle.
+				px, py := float64(x)+0.5, float64(y)+0.5
+
+				w0 := ((corners[1][0]-px)*(corners[2][1]-py) - (corners[2][0]-px)*(corners[1][1]-py)) / area
+				w1 := ((corners[2][0]-px)*(corners[0][1]-py) - (corners[0][0]-px)*(corners[2][1]-py)) / area
+				w2 := 1 - w0 - w1
+
+				if w0 < 0 || w1 < 0 || w2 < 0 {
+					continue
+				}
+
+				position := vector.Vector{0, 0, 0}
+				normal := vector.Vector{0, 0, 0}
+
+				for i, w := range [3]float64{w0, w1, w2} {
+					vector.In(position).Add(positions[tri.ID*3+i].Scale(w))
+					vector.In(normal).Add(normals[tri.ID*3+i].Scale(w))
+				}
+
+				if normal.Magnitude() > 0 {
+					normal = normal.Unit()
+				}
+
+				result := lightAt(position, normal)
+
+				// For double-sided triangles, each light lights whichever side of the triangle it's on.
+				if flip {
+					backResult := lightAt(position, normal.Invert())
+					for i := range backResult {
+						if backResult[i] > result[i] {
+							result[i] = backResult[i]
+						}
+					}
+				}
+
+				// Emission is unaffected by lights, so it's simply added on top.
+				if emissive {
+					result[0] += emissionR
+					result[1] += emissionG
+					result[2] += emissionB
+				}
+
+				texels[y*width+x] = result
+				covered[y*width+x] = true
+
+			}
+
+		}
+
+	}
+
+	// Texels just outside of the triangles take on the average lighting of their covered neighbors.
+	for pass := 0; pass < lightmapPadding; pass++ {
+
+		newlyCovered := []int{}
+
+		for y := 0; y < height; y++ {
+
+			for x := 0; x < width; x++ {
+
+				if covered[y*width+x] {
+					continue
+				}
+
+				sum := [3]float32{}
+				count := float32(0)
+
+				for ny := y - 1; ny <= y+1; ny++ {
+					for nx := x - 1; nx <= x+1; nx++ {
+						if nx >= 0 && ny >= 0 && nx < width && ny < height && covered[ny*width+nx] {
+							neighbor := texels[ny*width+nx]
+							sum[0] += neighbor[0]
+							sum[1] += neighbor[1]
+							sum[2] += neighbor[2]
+							count++
+						}
+					}
+				}
+
+				if count > 0 {
+					texels[y*width+x] = [3]float32{sum[0] / count, sum[1] / count, sum[2] / count}
+					newlyCovered = append(newlyCovered, y*width+x)
+				}
+
+			}
+
+		}
+
+		for _, index := range newlyCovered {
+			covered[index] = true
+		}
+
+	}
+
+	toByte := func(value float32) uint8 {
+		return uint8(math.Round(math.Max(math.Min(float64(value), 1), 0) * 255))
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			texel := texels[y*width+x]
+			img.SetRGBA(x, y, color.RGBA{toByte(texel[0]), toByte(texel[1]), toByte(texel[2]), 255})
+		}
+	}
+
+	return img
+
+}
+
+// lightSampler lights individual points (positions with normals) as the vertices of a (degenerate) triangle of a sample Model; this
+// way, points are lit in exactly the same way as the vertices of regular Models are, by any kind of light.
+type lightSampler struct {
+	mesh   *Mesh
+	model  *Model
+	lights []ILight
+}
+
+// newLightSampler creates a new lightSampler to light points in the space given by the transform with the given lights.
+func newLightSampler(transform Matrix4, lights []ILight) *lightSampler {
+
+	sampleMesh := NewMesh("light sample")
+	sampleMesh.AddMeshPart(nil).AddTriangles(NewVertex(0, 0, 0, 0, 0), NewVertex(0, 0, 0, 0, 0), NewVertex(0, 0, 0, 0, 0))
+
+	sampler := &lightSampler{
+		mesh:   sampleMesh,
+		model:  NewModel(sampleMesh, "light sample"),
+		lights: lights,
+	}
+
+	sampler.model.SetWorldTransform(transform)
+
+	for _, light := range lights {
+		light.beginRender()
+		light.beginModel(sampler.model)
+	}
+
+	normalizeLightProbes(lights)
+
+	return sampler
+
+}
+
+// light returns the light level at the given position with the given normal.
+func (sampler *lightSampler) light(position, normal vector.Vector) [3]float32 {
+
+	for i := 0; i < 3; i++ {
+		copy(sampler.mesh.VertexPositions[i], position)
+		copy(sampler.mesh.VertexNormals[i], normal)
+	}
+
+	sampler.mesh.Triangles[0].RecalculateCenter()
+
+	result := [3]float32{}
+
+	for _, light := range sampler.lights {
+		lightColors := light.lightFacing(0, sampler.model, 1)
+		result[0] += lightColors[0]
+		result[1] += lightColors[1]
+		result[2] += lightColors[2]
+	}
+
+	return result
+
+}
+
+// setLightmapVertex sets up the given vertex for drawing a lightmap, using the screen position of the source vertex and the given
+// second UV values.
+func setLightmapVertex(dst *ebiten.Vertex, src ebiten.Vertex, uv2 vector.Vector, lightmapW, lightmapH float64) {
+	dst.DstX = src.DstX
+	dst.DstY = src.DstY
+	dst.SrcX = float32(uv2[0] * lightmapW)
+	dst.SrcY = float32((1 - uv2[1]) * lightmapH)
+	dst.ColorR = 1
+	dst.ColorG = 1
+	dst.ColorB = 1
+	dst.ColorA = 1
+}
+
+// drawLightmapped draws the triangles in the vertex lists to the target image, lit by the given lightmap.
+func (camera *Camera) drawLightmapped(target *ebiten.Image, img *ebiten.Image, lightmap *ebiten.Image, options *ebiten.DrawTrianglesOptions, compositeMode ebiten.CompositeMode) {
+
+	intermediateOptions := *options
+	intermediateOptions.CompositeMode = ebiten.CompositeModeSourceOver
+
+	// Nothing is vertex-lit, as lightmapped triangles aren't lit by the Scene's lights.
+	camera.pixelVertexLitIntermediate.Clear()
+
+	camera.pixelUnlitIntermediate.Clear()
+	camera.pixelUnlitIntermediate.DrawTriangles(colorVertexList[:vertexListIndex], indexList[:vertexListIndex], img, &intermediateOptions)
+
+	camera.pixelLightIntermediate.Clear()
+	camera.pixelLightIntermediate.DrawTriangles(pixelLightVertexList[:vertexListIndex], indexList[:vertexListIndex], lightmap, &ebiten.DrawTrianglesOptions{Filter: ebiten.FilterLinear})
+
+	camera.compositePixelLighting(target, compositeMode, 1)
+
+}
@@ -0,0 +1,49 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBakeLightmap(t *testing.T) {
+
+	mesh := NewPlane()
+	for i := range mesh.VertexUV2s {
+		copy(mesh.VertexUV2s[i], mesh.VertexUVs[i])
+	}
+
+	plane := NewModel(mesh, "plane")
+	light := NewPointLight("light", 1, 1, 1, 1)
+	light.SetLocalPosition(1, 0.5, 1)
+
+	const size = 16
+	lightmap := plane.bakeLightmap(size, size, []ILight{light})
+
+	// The texels at the corners of the plane closest to and furthest from the light.
+	texelAt := func(closest bool) uint8 {
+		best := 0
+		for i := range mesh.VertexPositions {
+			dist := fastVectorDistanceSquared(mesh.VertexPositions[i], light.WorldPosition())
+			bestDist := fastVectorDistanceSquared(mesh.VertexPositions[best], light.WorldPosition())
+			if (closest && dist < bestDist) || (!closest && dist > bestDist) {
+				best = i
+			}
+		}
+		uv2 := mesh.VertexUV2s[best]
+		x := int(math.Min(uv2[0]*size, size-1))
+		y := int(math.Min((1-uv2[1])*size, size-1))
+		return lightmap.RGBAAt(x, y).R
+	}
+
+	near, far := texelAt(true), texelAt(false)
+
+	if near <= far || far == 0 {
+		t.Errorf("expected texels to be lit more closer to the light, got %d near and %d far", near, far)
+	}
+
+	// Texels within the same triangle are lit individually, rather than interpolated from the triangle's vertices.
+	if a, b := lightmap.RGBAAt(size/2-1, size/2-1).R, lightmap.RGBAAt(size/2-2, size/2-2).R; a == b {
+		t.Errorf("expected neighboring texels to be lit differently, got %d and %d", a, b)
+	}
+
+}
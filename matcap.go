@@ -0,0 +1,16 @@
+package tetra3d
+
+import "github.com/kvartborg/vector"
+
+// matcapUV returns the UV values at which a matcap is sampled for a vertex with the given normal in view space; the matcap's center
+// faces the Camera, and its edges face away from it.
+func matcapUV(viewNormal vector.Vector) (float64, float64) {
+
+	if viewNormal.Magnitude() > 0 {
+		viewNormal = viewNormal.Unit()
+	}
+
+	// The matcap's edge is slightly inset, as the pixels right at the edge of a matcap's sphere are often blended with its background.
+	return viewNormal[0]*0.495 + 0.5, viewNormal[1]*0.495 + 0.5
+
+}
@@ -0,0 +1,54 @@
+package tetra3d
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/kvartborg/vector"
+)
+
+func TestMatcap(t *testing.T) {
+
+	if u, v := matcapUV(vector.Vector{0, 0, 1}); u != 0.5 || v != 0.5 {
+		t.Errorf("expected normals facing the camera to sample the center of the matcap, got %f, %f", u, v)
+	}
+
+	if u, v := matcapUV(vector.Vector{0, 2, 0}); u != 0.5 || v <= 0.9 {
+		t.Errorf("expected normals facing up to sample the top of the matcap, got %f, %f", u, v)
+	}
+
+	scene := NewScene("matcap")
+	camera := NewCamera(32, 32)
+	scene.Root.AddChildren(camera)
+
+	matcap := ebiten.NewImage(16, 16)
+
+	sphere := NewModel(NewSphere(8, 8), "sphere")
+	sphere.Move(0, 0, -5)
+	scene.Root.AddChildren(sphere)
+
+	part := sphere.Mesh.MeshParts[0]
+	part.Material.Matcap = matcap
+
+	if sphere.materialTexture(part) != matcap {
+		t.Errorf("expected the matcap to be used in place of the Material's texture")
+	}
+
+	inRange := true
+
+	part.OnRender = func(camera *Camera, model *Model, part *MeshPart, stage int, vertices []ebiten.Vertex) {
+		for _, v := range vertices {
+			if v.SrcX < 0 || v.SrcX > 16 || v.SrcY < 0 || v.SrcY > 16 {
+				inRange = false
+			}
+		}
+	}
+
+	camera.Clear()
+	camera.RenderNodes(scene, scene.Root)
+
+	if !inRange {
+		t.Errorf("expected the sphere's vertices to sample the matcap within its bounds")
+	}
+
+}
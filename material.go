@@ -1,7 +1,11 @@
 package tetra3d
 
 import (
+	"image"
+	"math"
+
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/kvartborg/vector"
 )
 
 const (
@@ -22,6 +26,28 @@ const (
 
 	// TransparencyModeTransparent means the triangles are not rendered to the depth buffer, but are rendered in a second pass after opaque and alpha-clip triangles. They are automatically sorted from back-to-front.
 	TransparencyModeTransparent
+
+	// TransparencyModeDithered means the triangles are rendered in the opaque pass to the color and depth buffer, but pixels are discarded
+	// in a Bayer dither pattern according to the alpha of the triangles' vertex colors, the Material's color, and the Model's color. This
+	// allows objects to fade in and out without sorting or depth issues. If the Material and Model's combined alpha is below
+	// DitheredTransparencyMinimumAlpha, the triangles are rendered as though the Material were TransparencyModeTransparent instead, as
+	// the dither pattern would be too sparse to look good.
+	TransparencyModeDithered
+)
+
+// DitheredTransparencyMinimumAlpha is the combined Material and Model alpha under which Materials using TransparencyModeDithered are
+// rendered as regular transparent Materials instead.
+var DitheredTransparencyMinimumAlpha float32 = 0.1
+
+const (
+	// LightingModeVertex means the Material is lit per vertex, with the light levels interpolated across each triangle. This is the default.
+	LightingModeVertex = iota
+
+	// LightingModePixel means the Material is lit per pixel on the GPU, which looks smoother on large triangles at the cost of extra
+	// render passes. Up to MaxPixelLights point, spot, and directional lights (the ones closest to each Model) are applied per pixel,
+	// along with ambient lights; any other lights are still applied per vertex. Materials with custom fragment shaders or using
+	// TransparencyModeDithered are always lit per vertex.
+	LightingModePixel
 )
 
 const (
@@ -45,16 +71,171 @@ type Material struct {
 	CompositeMode     ebiten.CompositeMode // Blend mode to use when rendering the material (i.e. additive, multiplicative, etc)
 	BillboardMode     int                  // Billboard mode
 
+	// SortBias influences the order in which MeshParts using this Material are rendered. MeshParts are rendered in order of
+	// ascending SortBias, regardless of depth, so a MeshPart with a higher SortBias always renders after (and so on top of) MeshParts
+	// with lower SortBias values. MeshParts with equal SortBias values are sorted by depth as usual. This is useful for decals or overlays
+	// that should always draw on top of surfaces they're coplanar with. Defaults to 0.
+	SortBias float64
+
+	// FlipBackfaceNormals allows double-sided geometry to be lit correctly from both sides. When it's enabled, triangles viewed from
+	// behind are lit using their negated normals when rendering, and when baking lighting, each side of each triangle receives light from
+	// lights on its side. This has no effect when rendering with BackfaceCulling enabled, as back faces aren't rendered in the first place.
+	// Defaults to false.
+	FlipBackfaceNormals bool
+
+	// EmissionColor is a color that's added to the Material's lit vertex colors, making it glow regardless of the lights in the Scene.
+	// EmissionStrength is a multiplier for the EmissionColor. Emission is only added when the Material is lit (i.e. it isn't Shadeless
+	// and the World's lighting is on); it's also baked into vertex colors by Model.BakeLighting(), so unlit Materials can glow as well.
+	// Like vertex colors, emission is multiplied by the Material's texture. Emissive surfaces are bright, and so also work well with the
+	// Camera's bloom post-effect (see Camera.BloomEnabled). Emission defaults to black, with a strength of 0.
+	EmissionColor    *Color
+	EmissionStrength float64
+
+	// RimColor and RimPower add a Fresnel-like rim light to the Material, brightening vertices whose normals face away from the camera
+	// (i.e. along the edges of the Material's silhouette), which helps the silhouette stand out against dark backgrounds. The rim light
+	// is RimColor scaled by (1 - facing) ^ RimPower, where facing is how directly the vertex's normal faces the camera, so higher
+	// RimPower values give thinner rims. Like emission, the rim light is added to the Material's lit vertex colors, so it's only added
+	// when the Material is lit, and is multiplied by the Material's texture. RimColor defaults to black (no rim light), and RimPower
+	// defaults to 3.
+	RimColor *Color
+	RimPower float64
+
+	// Shininess and SpecularColor give the Material specular highlights using the Blinn-Phong model, so that it looks glossy rather
+	// than like matte plastic. Shininess is the specular exponent; higher values give smaller, sharper highlights (values between 8
+	// and 128 work well), and 0 disables specular highlights. SpecularColor is the color (and strength) of the highlights; darker
+	// colors give a rougher look. Highlights are calculated per vertex for LightingModeVertex and per pixel for LightingModePixel
+	// (for the lights that are applied per pixel), and ignore light cookies. Like rim lighting, highlights are added to the
+	// Material's lit vertex colors after toon shading, and so are multiplied by the Material's texture, which tints them the way
+	// metallic surfaces are tinted. Shininess defaults to 0, and SpecularColor defaults to white.
+	Shininess     float64
+	SpecularColor *Color
+
+	LightingMode int // How the Material is lit (LightingModeVertex or LightingModePixel); defaults to LightingModeVertex.
+
+	// Lightmap is a texture holding baked lighting (see Model.BakeLightmap()), which is sampled using the second set of UV values of
+	// the triangles using the Material (Mesh.VertexUV2s) and multiplied with the Material's texture and colors. When the Material has
+	// a Lightmap, it's lit by the Lightmap instead of by the Scene's lights. As a lightmap holds the lighting of a specific Model (or
+	// of several Models whose second UV sets don't overlap), Models with different lightmaps should use different Materials. Like
+	// LightingModePixel, the Lightmap isn't used for Materials with custom fragment shaders or using TransparencyModeDithered.
+	// Defaults to nil.
+	Lightmap *ebiten.Image
+
+	// ToonRamp and ToonSteps give the Material a toon (cel-shaded) look by remapping the brightness of the light falling on it into
+	// distinct bands. ToonRamp is an image that's sampled horizontally along its middle row by the light level (from 0 at its left
+	// edge to 1 at its right edge), with the sampled color replacing the light's brightness; a *ebiten.Image can be used, though any
+	// image.Image works, and its pixels are read when it's first rendered, like a light's Cookie. ToonSteps is a simpler alternative:
+	// a list of light levels in ascending order, with the light falling on each vertex rounded down to the nearest step (and light
+	// levels below the first step rounded up to it), so {0.3, 1} gives the Material a shadowed band and a lit band. The light's color
+	// is kept either way. If both are set, ToonRamp is used. Toon shading is applied per vertex for LightingModeVertex, and per pixel
+	// for LightingModePixel (in which case only the first 8 ToonSteps are used, and the ToonRamp is sampled at 16 points). Both
+	// default to nil.
+	ToonRamp  image.Image
+	ToonSteps []float64
+
+	// OutlineThickness and OutlineColor draw an outline (in pixels) of the given color around the triangles using the Material, which
+	// goes well with toon shading. The outline is drawn behind the triangles, so it also shows up along creases where the Material's
+	// triangles overlap one another. OutlineThickness defaults to 0 (no outline), and OutlineColor defaults to opaque black.
+	OutlineThickness float64
+	OutlineColor     *Color
+
+	// NormalMap is a tangent-space normal map texture (with +Y pointing up the texture, as in glTF and Blender) that's used in place of
+	// the vertex normals of the triangles using the Material when lighting them. It's sampled using the Mesh's first set of UV values
+	// (transformed like the Material's Texture, regardless of its TextureUVSet), and uses the tangents of the Mesh's vertices (see Mesh.GenerateTangents()). As normal maps need lighting to
+	// be calculated per pixel, the NormalMap is only used for Materials using LightingModePixel; lights that are lit per vertex (i.e.
+	// beyond the MaxPixelLights closest lights) don't use it. Defaults to nil.
+	NormalMap *ebiten.Image
+
+	// UVOffset is added to the UV values of the triangles using the Material when rendering, which allows for scrolling textures
+	// without changing the Mesh's UV values. It can be animated through an Animation's MaterialChannels. Defaults to {0, 0}.
+	UVOffset vector.Vector
+
+	// UVScale and UVRotation transform the UV values of the triangles using the Material when rendering, before the UVOffset is added;
+	// this is useful for tiling textures, or for rotating textures like skies or spinning portals, without changing the Mesh's UV
+	// values (which would break batching). UVScale multiplies the UV values, and UVRotation rotates them counter-clockwise by the given
+	// angle in radians (so the texture appears to rotate clockwise); both are applied around the center of the texture (0.5, 0.5).
+	// Like UVOffset, they can be animated through an Animation's MaterialChannels. UVScale defaults to {1, 1}, and UVRotation to 0.
+	UVScale    vector.Vector
+	UVRotation float64
+
+	// TextureUVSet is which set of UV values of the Mesh the Material's Texture is sampled with: 0 for the first set (Mesh.VertexUVs),
+	// or 1 for the second set (Mesh.VertexUV2s), which is useful for textures that are laid out separately from the Mesh's main UV
+	// map (like baked lighting or detail textures). Defaults to 0.
+	TextureUVSet int
+
+	// TriplanarScale, if greater than 0, maps the Material's texture onto its triangles by projecting it in world space along
+	// whichever world axis is closest to each triangle's normal (often called box or triplanar mapping), with the texture repeating
+	// every TriplanarScale units, instead of using the Mesh's UV values. This is useful for texturing terrain, rocks, or level geometry
+	// without unwrapping it, as the texture keeps the same scale across Models. Each triangle is projected along a single axis, so
+	// the texture isn't blended across the seams between triangles facing different axes. The Material's UV transform (UVOffset,
+	// UVScale, and UVRotation) is still applied, and the Material's TextureWrapMode should be left as ebiten.AddressRepeat.
+	// Defaults to 0.
+	TriplanarScale float64
+
+	// Matcap is a "material capture" image (a picture of a lit sphere, as seen from the front) that's mapped onto the Material's
+	// triangles according to the direction their vertices' normals face relative to the Camera, which is a cheap way to give the
+	// Material a complex look (like shiny metal, glass, clay, or toon shading) that follows the Camera. The Matcap is used in place of
+	// the Material's Texture (and any textures set through MaterialOverrides), and as it holds the Material's lighting, Materials with a
+	// Matcap aren't lit by the Scene's lights. The Material's colors and vertex colors still tint the Matcap. As the Matcap is mapped
+	// per vertex, Meshes should be reasonably detailed for it to look smooth. Defaults to nil.
+	Matcap *ebiten.Image
+
+	// TextureMapping controls how the Material's texture is mapped onto its triangles: TextureMappingAffine maps it affinely, which
+	// makes the texture warp along triangles that recede into the distance (like on the original PlayStation), while
+	// TextureMappingPerspective subdivides those triangles so the texture appears perspective-correct. TextureMappingCamera leaves the
+	// choice to the Camera rendering the Material (see Camera.PerspectiveCorrectTextures). Defaults to TextureMappingCamera.
+	TextureMapping int
+
+	reflectionPlane *ReflectionPlane // The ReflectionPlane that last fed its reflection to the Material, if any
+
+	// TextureAnimation is a flipbook animation (see NewTextureAnimationGrid()) that's played on the Material's texture by offsetting
+	// the UV values of the triangles using the Material when rendering (on top of the UVOffset), so animated textures like fire, water,
+	// or TV screens work without changing any Mesh's UV values; every Model using the Material animates in sync. The UV values of the
+	// triangles using the Material should cover the animation's first frame. TextureAnimationPlayhead is the current position of the
+	// animation, with its integer portion being the frame being shown; it's advanced by Material.UpdateTextureAnimation(), and loops.
+	// TextureAnimationSpeed is the playback speed and direction of the animation, with 1 being 100%. TextureAnimation defaults to nil,
+	// and TextureAnimationSpeed to 1.
+	TextureAnimation         *TextureAnimation
+	TextureAnimationPlayhead float64
+	TextureAnimationSpeed    float64
+
 	// fragmentShader represents a shader used to render the material with. This shader is activated after rendering
 	// to the depth texture, but before compositing the finished render to the screen after fog.
 	fragmentShader *ebiten.Shader
 	// FragmentShaderOn is an easy boolean toggle to control whether the shader is activated or not (it defaults to on).
 	FragmentShaderOn bool
 	// FragmentShaderOptions allows you to customize the custom fragment shader with uniforms or images. It does NOT take the
-	// CompositeMode property from the Material's CompositeMode. By default, it's an empty DrawTrianglesShaderOptions struct.
+	// CompositeMode property from the Material's CompositeMode. If its first image is nil, the Material's Texture is used in its
+	// place (so imageSrc0At() samples the texture), provided the Material has one. By default, it's an empty
+	// DrawTrianglesShaderOptions struct.
+	// Uniforms can also be set for individual Models rendered with the Material through Model.ShaderUniforms.
 	FragmentShaderOptions *ebiten.DrawTrianglesShaderOptions
 	fragmentSrc           []byte
 
+	// FragmentShaderGBuffer renders the Material's custom fragment shader in screen space with access to the Camera's G-buffer, for
+	// effects like soft particles, foam where water meets the shore, or intersection highlights. When enabled, the Material's
+	// triangles are first rendered as usual to a buffer the size of the Camera, and then rendered with the custom shader, which
+	// receives the following:
+	//
+	// texCoord: the pixel's screen position, for sampling the images below (which are all the size of the Camera).
+	//
+	// imageSrc0: the Material's triangles as they'd be rendered without the custom shader (textured, colored, and lit).
+	//
+	// imageSrc1: the Camera's depth texture (see Camera.DepthTexture()), holding the depth of everything rendered before the
+	// Material's triangles (including themselves, if they're opaque, as opaque triangles are rendered to the depth texture before
+	// their colors); decode it with the decodeDepth() function of the "depth" shader snippet.
+	//
+	// color: the depth of the pixel in its red channel (in the same range as the depth texture), and the pixel's UV values in its
+	// green and blue channels.
+	//
+	// imageSrc2: the Camera's normal texture (see Camera.NormalTexture()), if the Camera's rendering normals and the third image of
+	// the FragmentShaderOptions isn't set.
+	//
+	// Images 2 and 3 of the FragmentShaderOptions are otherwise passed through, and must be the size of the Camera. Depth values are
+	// only available when the Camera's RenderDepth is enabled. Defaults to false.
+	FragmentShaderGBuffer bool
+
+	toonRamp imageSampler // The pixels of the ToonRamp
+
 	// If a material is tagged as transparent, it's rendered in a separate render pass.
 	// Objects with transparent materials don't render to the depth texture and are sorted and rendered back-to-front, AFTER
 	// all non-transparent materials.
@@ -66,6 +247,14 @@ func NewMaterial(name string) *Material {
 	return &Material{
 		Name:                  name,
 		Color:                 NewColor(1, 1, 1, 1),
+		EmissionColor:         NewColor(0, 0, 0, 1),
+		OutlineColor:          NewColor(0, 0, 0, 1),
+		RimColor:              NewColor(0, 0, 0, 1),
+		RimPower:              3,
+		SpecularColor:         NewColor(1, 1, 1, 1),
+		UVOffset:              vector.Vector{0, 0},
+		UVScale:               vector.Vector{1, 1},
+		TextureAnimationSpeed: 1,
 		Properties:            NewProperties(),
 		TextureFilterMode:     ebiten.FilterNearest,
 		TextureWrapMode:       ebiten.AddressRepeat,
@@ -86,6 +275,31 @@ func (material *Material) Clone() *Material {
 	newMat.Texture = material.Texture
 	newMat.Properties = material.Properties.Clone()
 	newMat.BackfaceCulling = material.BackfaceCulling
+	newMat.FlipBackfaceNormals = material.FlipBackfaceNormals
+	newMat.EmissionColor = material.EmissionColor.Clone()
+	newMat.EmissionStrength = material.EmissionStrength
+	newMat.RimColor = material.RimColor.Clone()
+	newMat.RimPower = material.RimPower
+	newMat.Shininess = material.Shininess
+	newMat.SpecularColor = material.SpecularColor.Clone()
+	newMat.UVOffset = material.UVOffset.Clone()
+	newMat.UVScale = material.UVScale.Clone()
+	newMat.UVRotation = material.UVRotation
+	newMat.TextureUVSet = material.TextureUVSet
+	newMat.TriplanarScale = material.TriplanarScale
+	newMat.Matcap = material.Matcap
+	newMat.TextureMapping = material.TextureMapping
+	newMat.reflectionPlane = material.reflectionPlane
+	newMat.TextureAnimation = material.TextureAnimation
+	newMat.TextureAnimationPlayhead = material.TextureAnimationPlayhead
+	newMat.TextureAnimationSpeed = material.TextureAnimationSpeed
+	newMat.LightingMode = material.LightingMode
+	newMat.Lightmap = material.Lightmap
+	newMat.NormalMap = material.NormalMap
+	newMat.ToonRamp = material.ToonRamp
+	newMat.ToonSteps = append([]float64{}, material.ToonSteps...)
+	newMat.OutlineThickness = material.OutlineThickness
+	newMat.OutlineColor = material.OutlineColor.Clone()
 	newMat.TriangleSortMode = material.TriangleSortMode
 	newMat.Shadeless = material.Shadeless
 	newMat.TransparencyMode = material.TransparencyMode
@@ -94,16 +308,21 @@ func (material *Material) Clone() *Material {
 	newMat.CompositeMode = material.CompositeMode
 
 	newMat.BillboardMode = material.BillboardMode
+	newMat.SortBias = material.SortBias
 	newMat.SetShader(material.fragmentSrc)
 	newMat.FragmentShaderOn = material.FragmentShaderOn
+	newMat.FragmentShaderGBuffer = material.FragmentShaderGBuffer
 
 	newMat.FragmentShaderOptions.CompositeMode = material.FragmentShaderOptions.CompositeMode
 	newMat.FragmentShaderOptions.FillRule = material.FragmentShaderOptions.FillRule
 	for i := range material.FragmentShaderOptions.Images {
 		newMat.FragmentShaderOptions.Images[i] = material.FragmentShaderOptions.Images[i]
 	}
-	for k, v := range newMat.FragmentShaderOptions.Uniforms {
-		newMat.FragmentShaderOptions.Uniforms[k] = v
+	if material.FragmentShaderOptions.Uniforms != nil {
+		newMat.FragmentShaderOptions.Uniforms = make(map[string]interface{}, len(material.FragmentShaderOptions.Uniforms))
+		for k, v := range material.FragmentShaderOptions.Uniforms {
+			newMat.FragmentShaderOptions.Uniforms[k] = v
+		}
 	}
 
 	return newMat
@@ -112,7 +331,8 @@ func (material *Material) Clone() *Material {
 // SetShader creates a new custom Kage fragment shader for the Material if provided the shader's source code, provided as a []byte.
 // This custom shader would be used to render the mesh utilizing the material after rendering to the depth texture, but before
 // compositing the finished render to the screen after fog. If the shader is nil, the Material will render using the default Tetra3D
-// render setup (e.g. texture, UV values, vertex colors, and vertex lighting).
+// render setup (e.g. texture, UV values, vertex colors, and vertex lighting). The shader can include shader snippets (like fog or
+// dithering functions) with include directives, which are replaced by the snippets before compiling it; see PreprocessShader().
 // SetShader will return the Shader, and an error if the Shader failed to compile.
 func (material *Material) SetShader(src []byte) (*ebiten.Shader, error) {
 
@@ -122,7 +342,12 @@ func (material *Material) SetShader(src []byte) (*ebiten.Shader, error) {
 		return nil, nil
 	}
 
-	newShader, err := ebiten.NewShader(src)
+	processed, err := PreprocessShader(src)
+	if err != nil {
+		return nil, err
+	}
+
+	newShader, err := ebiten.NewShader(processed)
 	if err != nil {
 		return nil, err
 	}
@@ -148,6 +373,157 @@ func (material *Material) DisposeShader() {
 	material.fragmentShader = nil
 }
 
+// emission returns the Material's emission color multiplied by its strength, and whether it has any emission at all.
+func (material *Material) emission() (float32, float32, float32, bool) {
+
+	if material.EmissionColor == nil || material.EmissionStrength <= 0 {
+		return 0, 0, 0, false
+	}
+
+	strength := float32(material.EmissionStrength)
+	r, g, b := material.EmissionColor.R*strength, material.EmissionColor.G*strength, material.EmissionColor.B*strength
+
+	return r, g, b, r > 0 || g > 0 || b > 0
+
+}
+
+// UpdateTextureAnimation advances the Material's TextureAnimation (if it has one) by the given delta time, in seconds.
+func (material *Material) UpdateTextureAnimation(dt float64) {
+
+	if material.TextureAnimation == nil || len(material.TextureAnimation.Frames) == 0 {
+		return
+	}
+
+	frameCount := float64(len(material.TextureAnimation.Frames))
+
+	material.TextureAnimationPlayhead = math.Mod(material.TextureAnimationPlayhead+dt*material.TextureAnimation.FPS*material.TextureAnimationSpeed, frameCount)
+
+	if material.TextureAnimationPlayhead < 0 {
+		material.TextureAnimationPlayhead += frameCount
+	}
+
+}
+
+// textureAnimationOffset returns the UV offset of the current frame of the Material's TextureAnimation, or 0, 0 if it has none.
+func (material *Material) textureAnimationOffset() (float64, float64) {
+
+	if material.TextureAnimation == nil || len(material.TextureAnimation.Frames) == 0 {
+		return 0, 0
+	}
+
+	frameCount := len(material.TextureAnimation.Frames)
+
+	frame := int(math.Floor(material.TextureAnimationPlayhead)) % frameCount
+	if frame < 0 {
+		frame += frameCount
+	}
+
+	offset := material.TextureAnimation.Frames[frame]
+
+	return offset[0], offset[1]
+
+}
+
+// uvTransform is an affine transform for UV values; the transformed U value is t[0]*u + t[1]*v + t[2], and the transformed V value
+// is t[3]*u + t[4]*v + t[5].
+type uvTransform [6]float64
+
+var identityUVTransform = uvTransform{1, 0, 0, 0, 1, 0}
+
+// apply returns the given UV values, transformed.
+func (t uvTransform) apply(u, v float64) (float64, float64) {
+	return t[0]*u + t[1]*v + t[2], t[3]*u + t[4]*v + t[5]
+}
+
+// uvTransform returns the transform the Material applies to UV values when rendering (see Material.UVOffset, Material.UVScale,
+// Material.UVRotation, and Material.TextureAnimation).
+func (material *Material) uvTransform() uvTransform {
+
+	scaleU, scaleV := 1.0, 1.0
+	if len(material.UVScale) >= 2 {
+		scaleU, scaleV = material.UVScale[0], material.UVScale[1]
+	}
+
+	offsetU, offsetV := material.textureAnimationOffset()
+	if len(material.UVOffset) >= 2 {
+		offsetU += material.UVOffset[0]
+		offsetV += material.UVOffset[1]
+	}
+
+	sin, cos := math.Sincos(material.UVRotation)
+
+	// The UV values are scaled and rotated around the center of the texture, and then offset.
+	t := uvTransform{cos * scaleU, -sin * scaleV, 0, sin * scaleU, cos * scaleV, 0}
+	t[2] = 0.5 - 0.5*t[0] - 0.5*t[1] + offsetU
+	t[5] = 0.5 - 0.5*t[3] - 0.5*t[4] + offsetV
+
+	return t
+
+}
+
+// rim returns the Material's rim light color, and whether it has a rim light at all.
+func (material *Material) rim() (float32, float32, float32, bool) {
+
+	if material.RimColor == nil {
+		return 0, 0, 0, false
+	}
+
+	r, g, b := material.RimColor.R, material.RimColor.G, material.RimColor.B
+
+	return r, g, b, r > 0 || g > 0 || b > 0
+
+}
+
+// specular returns the Material's specular highlight color, and whether it has specular highlights at all.
+func (material *Material) specular() (float32, float32, float32, bool) {
+
+	if material.SpecularColor == nil || material.Shininess <= 0 {
+		return 0, 0, 0, false
+	}
+
+	r, g, b := material.SpecularColor.R, material.SpecularColor.G, material.SpecularColor.B
+
+	return r, g, b, r > 0 || g > 0 || b > 0
+
+}
+
+// rimFactor returns the strength of a rim light with the given power for a vertex with the given normal, seen from the direction of
+// the given view vector (which points from the vertex towards the camera). Neither vector needs to be normalized.
+func rimFactor(normal, view vector.Vector, power float64) float64 {
+
+	normalLength, viewLength := normal.Magnitude(), view.Magnitude()
+
+	if normalLength == 0 || viewLength == 0 {
+		return 0
+	}
+
+	facing := math.Max(math.Min(dot(normal, view)/(normalLength*viewLength), 1), 0)
+
+	return math.Pow(1-facing, power)
+
+}
+
+// pixelLit returns if the Material should be lit per pixel when lighting is on (see LightingModePixel).
+func (material *Material) pixelLit() bool {
+	if material.LightingMode != LightingModePixel || material.TransparencyMode == TransparencyModeDithered {
+		return false
+	}
+	return material.fragmentShader == nil || !material.FragmentShaderOn
+}
+
+// normalMapped returns if the Material should be lit using its NormalMap when lit per pixel.
+func (material *Material) normalMapped() bool {
+	return material.NormalMap != nil && material.pixelLit()
+}
+
+// lightmapped returns if the Material should be lit using its Lightmap when lighting is on.
+func (material *Material) lightmapped() bool {
+	if material.Lightmap == nil || material.TransparencyMode == TransparencyModeDithered {
+		return false
+	}
+	return material.fragmentShader == nil || !material.FragmentShaderOn
+}
+
 // Library returns the Library from which this Material was loaded. If it was created through code, this function will return nil.
 func (material *Material) Library() *Library {
 	return material.library
@@ -0,0 +1,144 @@
+package tetra3d
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// MaterialOverrides replaces some properties of a Material when rendering a specific Model, so individual Models can be tinted,
+// retextured, or given different shader uniforms without cloning the Material they share (which would use more memory, and stop
+// the Models from being batched together). Any property left unset (nil) isn't overridden.
+// MaterialOverrides can be set for a whole Model (Model.MaterialOverrides) or for individual MeshParts of a Model
+// (Model.MeshPartOverrides); properties set for a MeshPart take priority over those set for the whole Model.
+type MaterialOverrides struct {
+	Color   *Color        // The color to use in place of the Material's Color.
+	Texture *ebiten.Image // The texture to use in place of the Material's Texture.
+	// Uniforms are set for the Material's fragment shader on top of the uniforms in the Material's FragmentShaderOptions and the
+	// Model's ShaderUniforms, replacing any of the same name.
+	Uniforms map[string]interface{}
+}
+
+// NewMaterialOverrides creates a new, empty MaterialOverrides struct.
+func NewMaterialOverrides() *MaterialOverrides {
+	return &MaterialOverrides{
+		Uniforms: map[string]interface{}{},
+	}
+}
+
+// Clone creates a clone of the MaterialOverrides.
+func (overrides *MaterialOverrides) Clone() *MaterialOverrides {
+
+	newOverrides := &MaterialOverrides{
+		Texture: overrides.Texture,
+	}
+
+	if overrides.Color != nil {
+		newOverrides.Color = overrides.Color.Clone()
+	}
+
+	if overrides.Uniforms != nil {
+		newOverrides.Uniforms = make(map[string]interface{}, len(overrides.Uniforms))
+		for k, v := range overrides.Uniforms {
+			newOverrides.Uniforms[k] = v
+		}
+	}
+
+	return newOverrides
+
+}
+
+// materialOverrides returns the MaterialOverrides set for the given MeshPart of the Model, followed by the MaterialOverrides set for
+// the whole Model (so they're in order of priority); either can be nil.
+func (model *Model) materialOverrides(meshPart *MeshPart) [2]*MaterialOverrides {
+	return [2]*MaterialOverrides{model.MeshPartOverrides[meshPart], model.MaterialOverrides}
+}
+
+// materialColor returns the color of the given MeshPart's Material when rendered with the Model, or nil if it has no Material.
+func (model *Model) materialColor(meshPart *MeshPart) *Color {
+
+	if meshPart.Material == nil {
+		return nil
+	}
+
+	for _, overrides := range model.materialOverrides(meshPart) {
+		if overrides != nil && overrides.Color != nil {
+			return overrides.Color
+		}
+	}
+
+	return meshPart.Material.Color
+
+}
+
+// materialTexture returns the texture of the given MeshPart's Material when rendered with the Model (or its Matcap, if it has one),
+// or nil if it has none.
+func (model *Model) materialTexture(meshPart *MeshPart) *ebiten.Image {
+
+	if meshPart.Material == nil {
+		return nil
+	}
+
+	if meshPart.Material.Matcap != nil {
+		return meshPart.Material.Matcap
+	}
+
+	for _, overrides := range model.materialOverrides(meshPart) {
+		if overrides != nil && overrides.Texture != nil {
+			return overrides.Texture
+		}
+	}
+
+	return meshPart.Material.Texture
+
+}
+
+// fragmentShaderOptions returns the options used to render the given MeshPart's Material's fragment shader with the Model; if the
+// Model has any ShaderUniforms or overridden uniforms, or the first image of the options is unset and the Material has a texture,
+// this is a copy of the Material's FragmentShaderOptions with those uniforms and the texture applied.
+func (model *Model) fragmentShaderOptions(meshPart *MeshPart) *ebiten.DrawTrianglesShaderOptions {
+
+	options := meshPart.Material.FragmentShaderOptions
+
+	if texture := model.materialTexture(meshPart); options.Images[0] == nil && texture != nil {
+		textured := *options
+		textured.Images[0] = texture
+		options = &textured
+	}
+
+	overrides := model.materialOverrides(meshPart)
+
+	// The sets of uniforms are gathered in increasing order of priority, so later sets replace earlier ones.
+	uniformSets := []map[string]interface{}{}
+	uniformCount := 0
+
+	if len(model.ShaderUniforms) > 0 {
+		uniformSets = append(uniformSets, model.ShaderUniforms)
+		uniformCount += len(model.ShaderUniforms)
+	}
+
+	for i := len(overrides) - 1; i >= 0; i-- {
+		if overrides[i] != nil && len(overrides[i].Uniforms) > 0 {
+			uniformSets = append(uniformSets, overrides[i].Uniforms)
+			uniformCount += len(overrides[i].Uniforms)
+		}
+	}
+
+	if len(uniformSets) == 0 {
+		return options
+	}
+
+	newOptions := *options
+	newOptions.Uniforms = make(map[string]interface{}, len(options.Uniforms)+uniformCount)
+
+	for k, v := range options.Uniforms {
+		newOptions.Uniforms[k] = v
+	}
+
+	for _, uniforms := range uniformSets {
+		for k, v := range uniforms {
+			newOptions.Uniforms[k] = v
+		}
+	}
+
+	return &newOptions
+
+}
@@ -0,0 +1,86 @@
+package tetra3d
+
+import (
+	"testing"
+)
+
+func TestMaterialOverrides(t *testing.T) {
+
+	mat := NewMaterial("shared")
+	mat.FragmentShaderOptions.Uniforms = map[string]interface{}{"Glow": float32(0), "Speed": float32(1)}
+
+	mesh := NewCube()
+	mesh.MeshParts[0].Material = mat
+	part := mesh.MeshParts[0]
+
+	model := NewModel(mesh, "cube")
+
+	model.MaterialOverrides = NewMaterialOverrides()
+	model.MaterialOverrides.Color = NewColor(1, 0, 0, 0.5)
+	model.MaterialOverrides.Uniforms["Glow"] = float32(1)
+
+	// Overrides for the MeshPart take priority over those for the whole Model.
+	model.MeshPartOverrides = map[*MeshPart]*MaterialOverrides{part: {Uniforms: map[string]interface{}{"Glow": float32(2)}}}
+
+	if color := model.materialColor(part); color != model.MaterialOverrides.Color {
+		t.Errorf("expected the overridden color to be used, got %v", color)
+	}
+
+	if !model.isTransparent(part) {
+		t.Errorf("expected the overridden color's alpha to make the model transparent")
+	}
+
+	options := model.fragmentShaderOptions(part)
+
+	if options.Uniforms["Glow"] != float32(2) || options.Uniforms["Speed"] != float32(1) {
+		t.Errorf("expected the uniforms to be merged with the MeshPart's overrides taking priority, got %v", options.Uniforms)
+	}
+
+	if mat.FragmentShaderOptions.Uniforms["Glow"] != float32(0) || NewModel(mesh, "other").isTransparent(part) {
+		t.Errorf("expected the shared Material to be left alone")
+	}
+
+	clone := model.Clone().(*Model)
+	clone.MaterialOverrides.Color.R = 0
+
+	if model.MaterialOverrides.Color.R != 1 || clone.MeshPartOverrides[part] == model.MeshPartOverrides[part] {
+		t.Errorf("expected cloned Models to have their own overrides")
+	}
+
+}
+
+func TestModelShaderUniforms(t *testing.T) {
+
+	mat := NewMaterial("dissolve")
+	mat.FragmentShaderOptions.Uniforms = map[string]interface{}{"Threshold": float32(0), "Edge": float32(0.1)}
+
+	mesh := NewCube()
+	mesh.MeshParts[0].Material = mat
+	part := mesh.MeshParts[0]
+
+	first := NewModel(mesh, "first")
+	second := NewModel(mesh, "second")
+
+	first.ShaderUniforms["Threshold"] = float32(0.5)
+
+	if options := first.fragmentShaderOptions(part); options.Uniforms["Threshold"] != float32(0.5) || options.Uniforms["Edge"] != float32(0.1) {
+		t.Errorf("expected the Model's uniforms to be merged with the Material's, got %v", options.Uniforms)
+	}
+
+	if options := second.fragmentShaderOptions(part); options != mat.FragmentShaderOptions {
+		t.Errorf("expected a Model without uniforms of its own to use the Material's shader options as-is")
+	}
+
+	// Overridden uniforms take priority over the Model's uniforms.
+	first.MaterialOverrides = NewMaterialOverrides()
+	first.MaterialOverrides.Uniforms["Threshold"] = float32(1)
+
+	if options := first.fragmentShaderOptions(part); options.Uniforms["Threshold"] != float32(1) {
+		t.Errorf("expected the overridden uniform to take priority, got %v", options.Uniforms["Threshold"])
+	}
+
+	if clone := mat.Clone(); clone.FragmentShaderOptions.Uniforms["Edge"] != float32(0.1) {
+		t.Errorf("expected cloned Materials to keep their shader uniforms, got %v", clone.FragmentShaderOptions.Uniforms)
+	}
+
+}
@@ -0,0 +1,184 @@
+package tetra3d
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/kvartborg/vector"
+)
+
+func TestRimFactor(t *testing.T) {
+
+	view := vector.Vector{0, 0, 5}
+
+	if rim := rimFactor(vector.Vector{0, 0, 1}, view, 3); rim != 0 {
+		t.Errorf("expected no rim light on a vertex facing the camera, got %f", rim)
+	}
+
+	if rim := rimFactor(vector.Vector{1, 0, 0}, view, 3); rim != 1 {
+		t.Errorf("expected full rim light on a vertex facing sideways, got %f", rim)
+	}
+
+	// Higher powers give thinner rims.
+	normal := vector.Vector{1, 0, 1}
+	if thick, thin := rimFactor(normal, view, 1), rimFactor(normal, view, 4); thick <= thin || thin <= 0 {
+		t.Errorf("expected a higher power to weaken the rim light on a vertex at an angle, got %f and %f", thick, thin)
+	}
+
+}
+
+func TestMaterialUVTransform(t *testing.T) {
+
+	mat := NewMaterial("tiles")
+
+	if u, v := mat.uvTransform().apply(0.25, 0.75); math.Abs(u-0.25) > 0.0001 || math.Abs(v-0.75) > 0.0001 {
+		t.Errorf("expected the default UV transform to leave UV values alone, got %f, %f", u, v)
+	}
+
+	// Scaling and rotating happen around the center of the texture, and the offset is applied afterwards.
+	mat.UVScale = vector.Vector{2, 2}
+	mat.UVRotation = math.Pi / 2
+	mat.UVOffset = vector.Vector{0.1, 0}
+
+	if u, v := mat.uvTransform().apply(0.75, 0.5); math.Abs(u-0.6) > 0.0001 || math.Abs(v-1) > 0.0001 {
+		t.Errorf("expected the UV value to be scaled, rotated, and offset to 0.6, 1, got %f, %f", u, v)
+	}
+
+	// UV rotation can be animated through a material channel.
+	anim := NewAnimation("spin")
+	anim.Length = 1
+	track := anim.AddMaterialChannel("tiles").AddTrack(TrackTypeUVRotation)
+	track.AddKeyframe(0, vector.Vector{0})
+	track.AddKeyframe(1, vector.Vector{math.Pi})
+
+	mesh := NewPlane()
+	mesh.MeshParts[0].Material = mat
+	root := NewNode("root")
+	root.AddChildren(NewModel(mesh, "plane"))
+
+	player := NewAnimationPlayer(root)
+	player.Play(anim)
+	player.Playhead = 0.5
+	player.Update(0)
+
+	if math.Abs(mat.UVRotation-math.Pi/2) > 0.0001 {
+		t.Errorf("expected the animated UV rotation to be halfway to pi, got %f", mat.UVRotation)
+	}
+
+}
+
+func TestDitheredTransparencyTextureOptions(t *testing.T) {
+
+	// The texture's left column is white and its right column is black; it's repeated twice across the quad.
+	texture := ebiten.NewImage(2, 2)
+	texture.SubImage(image.Rect(0, 0, 1, 2)).(*ebiten.Image).Fill(color.White)
+	texture.SubImage(image.Rect(1, 0, 2, 2)).(*ebiten.Image).Fill(color.Black)
+
+	mesh := NewMesh("quad")
+	part := mesh.AddMeshPart(NewMaterial("dithered"))
+	part.AddTriangles(
+		NewVertex(-2, -2, 0, 0, 0), NewVertex(2, -2, 0, 2, 0), NewVertex(2, 2, 0, 2, 1),
+		NewVertex(-2, -2, 0, 0, 0), NewVertex(2, 2, 0, 2, 1), NewVertex(-2, 2, 0, 0, 1),
+	)
+	mesh.UpdateBounds()
+
+	mat := part.Material
+	mat.Texture = texture
+	mat.TransparencyMode = TransparencyModeDithered
+	mat.TextureWrapMode = ebiten.AddressRepeat
+	mat.BackfaceCulling = false
+	mat.Shadeless = true
+
+	quad := NewModel(mesh, "quad")
+
+	// The color blending function removes the red channel.
+	quad.ColorBlendingFunc = func(model *Model, meshPart *MeshPart) ebiten.ColorM {
+		colorM := ebiten.ColorM{}
+		colorM.Scale(0, 1, 1, 1)
+		return colorM
+	}
+
+	camera := NewCamera(64, 64)
+	camera.Move(0, 0, 3)
+	camera.Clear()
+	camera.Render(NewScene("dithered"), quad)
+
+	// The texture's U value goes from 0 at x = -2 to 2 at x = 2.
+	for _, test := range []struct {
+		u        float64
+		expected color.RGBA
+	}{
+		{0.25, color.RGBA{0, 255, 255, 255}},
+		{0.75, color.RGBA{0, 0, 0, 255}},
+		{1.25, color.RGBA{0, 255, 255, 255}},
+		{1.75, color.RGBA{0, 0, 0, 255}},
+	} {
+
+		p := camera.WorldToScreen(vector.Vector{test.u*2 - 2, 0, 0})
+
+		if c := readPixel(t, camera.ColorTexture(), int(p[0]), int(p[1])); c != test.expected {
+			t.Errorf("expected the repeated, color-blended texture to be %v at a U value of %f, got %v", test.expected, test.u, c)
+		}
+
+	}
+
+}
+
+func TestFlipBackfaceNormalsRender(t *testing.T) {
+
+	render := func(flip bool) float32 {
+
+		scene := NewScene("flip backface normals")
+
+		// The plane faces away from the Camera, while the light is on the Camera's side of it.
+		plane := NewModel(NewPlane(), "plane")
+		plane.Rotate(1, 0, 0, -math.Pi/2)
+		plane.Mesh.MeshParts[0].Material.BackfaceCulling = false
+		plane.Mesh.MeshParts[0].Material.FlipBackfaceNormals = flip
+		scene.Root.AddChildren(plane)
+
+		light := NewPointLight("light", 1, 1, 1, 1)
+		light.SetLocalPosition(0, 0, 2)
+		scene.Root.AddChildren(light)
+
+		normals := []vector.Vector{}
+		for _, normal := range plane.Mesh.VertexNormals {
+			normals = append(normals, normal.Clone())
+		}
+
+		brightest := float32(0)
+		plane.Mesh.MeshParts[0].OnRender = func(camera *Camera, model *Model, part *MeshPart, stage int, vertices []ebiten.Vertex) {
+			for _, v := range vertices {
+				if v.ColorR > brightest {
+					brightest = v.ColorR
+				}
+			}
+		}
+
+		camera := NewCamera(32, 32)
+		camera.Move(0, 0, 3)
+		camera.Clear()
+		camera.RenderNodes(scene, scene.Root)
+
+		for i, normal := range plane.Mesh.VertexNormals {
+			if !vectorsEqual(normal, normals[i]) {
+				t.Fatalf("expected rendering not to modify the Mesh's normals")
+			}
+		}
+
+		return brightest
+
+	}
+
+	if lit := render(false); lit > 0 {
+		t.Errorf("expected the back of the plane to be unlit without flipping its normals, got a brightness of %f", lit)
+	}
+
+	if lit := render(true); lit <= 0 {
+		t.Errorf("expected the back of the plane to be lit with its normals flipped")
+	}
+
+}
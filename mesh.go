@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"sort"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/kvartborg/vector"
@@ -162,12 +163,13 @@ type Mesh struct {
 	// Each vertex property (position, normal, UV, colors, weights, bones, etc) is stored
 	// here and indexed in order of triangle ID * 3 + vertex (so the first triangle, 0, has
 	// the vertices 0, 1, and 2, while the 10th triangle would have the vertices 30, 31, and 32).
-	vertexTransforms         []vector.Vector
 	VertexPositions          []vector.Vector
 	VertexNormals            []vector.Vector
 	vertexSkinnedNormals     []vector.Vector
 	vertexSkinnedPositions   []vector.Vector
 	VertexUVs                []vector.Vector
+	VertexUV2s               []vector.Vector // A second set of UV values, used for lightmaps (see Model.BakeLightmap()).
+	VertexTangents           []vector.Vector // Vertex tangents (X, Y, Z, and handedness W), used for normal mapping (see Mesh.GenerateTangents()).
 	VertexColors             [][]*Color
 	VertexActiveColorChannel []int
 	VertexWeights            [][]float32
@@ -179,6 +181,33 @@ type Mesh struct {
 	Dimensions              Dimensions
 	triIndex                int
 	Properties              *Properties
+
+	// Indices is the Mesh's index buffer, built by Mesh.Optimize(). For each vertex, it holds the index of the first vertex in the same
+	// MeshPart that's identical to it (that is, with the same position, normal, UV, vertex colors, active color channel, bones, and bone
+	// weights), which may be the vertex itself. Vertices are still stored per triangle corner, as elsewhere.
+	Indices []int
+
+	// Indexed indicates if the Mesh is rendered in indexed mode, where each group of identical vertices (according to Mesh.Indices)
+	// is only transformed (and skinned, for skinned Models) once per MeshPart render, rather than once per triangle corner.
+	// Indexed mode is turned on by Mesh.Optimize(); it's off by default, and is turned off again whenever triangles are added to
+	// the Mesh, as the index buffer would be out of date.
+	Indexed bool
+
+	indexStamps []uint64 // The pass in which each unique vertex was last processed in indexed mode
+	indexStamp  uint64
+
+	// geometryVersion is incremented whenever the Mesh's vertex buffers are rebuilt (rather than added to), which invalidates
+	// any existing VertexSelections.
+	geometryVersion int
+
+	// MorphTargets are the Mesh's morph targets (also known as shape keys or blend shapes), which Models using the Mesh can blend
+	// their vertices towards; see Mesh.AddMorphTarget() and Model.SetMorphWeight().
+	MorphTargets []*MorphTarget
+
+	// The tight bounding sphere calculated through Mesh.RecalculateBoundsTight(), if it's been called since the last UpdateBounds() call.
+	tightBoundsCenter vector.Vector
+	tightBoundsRadius float64
+	tightBounds       bool
 }
 
 // NewMesh takes a name and a slice of *Vertex instances, and returns a new Mesh. If you provide *Vertex instances, the number must be divisible by 3,
@@ -193,12 +222,12 @@ func NewMesh(name string) *Mesh {
 		triIndex:                0,
 		Properties:              NewProperties(),
 
-		vertexTransforms:         []vector.Vector{},
 		VertexPositions:          []vector.Vector{},
 		VertexNormals:            []vector.Vector{},
 		vertexSkinnedNormals:     []vector.Vector{},
 		vertexSkinnedPositions:   []vector.Vector{},
 		VertexUVs:                []vector.Vector{},
+		VertexUV2s:               []vector.Vector{},
 		VertexColors:             [][]*Color{},
 		VertexActiveColorChannel: []int{},
 		VertexBones:              [][]uint16{},
@@ -218,6 +247,11 @@ func (mesh *Mesh) Clone() *Mesh {
 
 	newMesh.allocateVertexBuffers(mesh.VertexMax)
 
+	if mesh.Indices != nil {
+		newMesh.Indices = append([]int{}, mesh.Indices...)
+		newMesh.Indexed = mesh.Indexed
+	}
+
 	for i := range mesh.VertexPositions {
 		newMesh.VertexPositions[i] = mesh.VertexPositions[i].Clone()
 	}
@@ -230,6 +264,14 @@ func (mesh *Mesh) Clone() *Mesh {
 		newMesh.VertexUVs[i] = mesh.VertexUVs[i].Clone()
 	}
 
+	for i := range mesh.VertexPositions {
+		newMesh.VertexUV2s[i] = mesh.VertexUV2s[i].Clone()
+	}
+
+	for _, tangent := range mesh.VertexTangents {
+		newMesh.VertexTangents = append(newMesh.VertexTangents, tangent.Clone())
+	}
+
 	for i := range mesh.VertexColors {
 		newMesh.VertexColors[i] = make([]*Color, len(mesh.VertexColors[i]))
 		for channelIndex := range mesh.VertexColors[i] {
@@ -253,10 +295,6 @@ func (mesh *Mesh) Clone() *Mesh {
 		}
 	}
 
-	for v := range mesh.vertexTransforms {
-		newMesh.vertexTransforms[v] = mesh.vertexTransforms[v].Clone()
-	}
-
 	for v := range mesh.vertexSkinnedNormals {
 		newMesh.vertexSkinnedNormals[v] = mesh.vertexSkinnedNormals[v].Clone()
 	}
@@ -287,8 +325,18 @@ func (mesh *Mesh) Clone() *Mesh {
 		newMesh.VertexColorChannelNames[channelName] = index
 	}
 
+	for _, target := range mesh.MorphTargets {
+		newMesh.MorphTargets = append(newMesh.MorphTargets, target.Clone())
+	}
+
 	newMesh.Dimensions = mesh.Dimensions.Clone()
 
+	if mesh.tightBounds {
+		newMesh.tightBoundsCenter = mesh.tightBoundsCenter.Clone()
+		newMesh.tightBoundsRadius = mesh.tightBoundsRadius
+		newMesh.tightBounds = true
+	}
+
 	return newMesh
 }
 
@@ -308,6 +356,10 @@ func (mesh *Mesh) allocateVertexBuffers(size int) {
 	copy(newVUVs, mesh.VertexUVs)
 	mesh.VertexUVs = newVUVs
 
+	newVUV2s := make([]vector.Vector, size)
+	copy(newVUV2s, mesh.VertexUV2s)
+	mesh.VertexUV2s = newVUV2s
+
 	newVC := make([][]*Color, size)
 	copy(newVC, mesh.VertexColors)
 	mesh.VertexColors = newVC
@@ -324,10 +376,6 @@ func (mesh *Mesh) allocateVertexBuffers(size int) {
 	copy(newWeights, mesh.VertexWeights)
 	mesh.VertexWeights = newWeights
 
-	newTransforms := make([]vector.Vector, size)
-	copy(newTransforms, mesh.vertexTransforms)
-	mesh.vertexTransforms = newTransforms
-
 	newNormals := make([]vector.Vector, size)
 	copy(newNormals, mesh.vertexSkinnedNormals)
 	mesh.vertexSkinnedNormals = newNormals
@@ -338,6 +386,10 @@ func (mesh *Mesh) allocateVertexBuffers(size int) {
 
 	mesh.VertexMax = size
 
+	// The vertex buffers are changing, so the index buffer is no longer valid.
+	mesh.Indices = nil
+	mesh.Indexed = false
+
 }
 
 func (mesh *Mesh) ensureEnoughVertexColorChannels(channelIndex int) {
@@ -401,6 +453,76 @@ func (mesh *Mesh) SetActiveColorChannel(targetChannel int) {
 	mesh.SelectVertices().SelectAll().SetActiveColorChannel(targetChannel)
 }
 
+// ColorByHeight colors all vertices in the Mesh in the target vertex color channel according to their height, using the provided ColorCurve
+// as a gradient. Vertices at or below minY are given the color at the start of the curve (0), while vertices at or above maxY are given the
+// color at the end of the curve (1); vertices inbetween are interpolated between the curve's points, alpha included. This is useful for coloring
+// terrain procedurally (i.e. grass at the bottom, rock in the middle, snow at the top).
+// A ColorCurve is used as the gradient rather than a dedicated gradient type because it already holds any number of colored points,
+// interpolates them (alpha included) through ColorCurve.Color(), and clamps percentages outside of the 0-1 range.
+func (mesh *Mesh) ColorByHeight(curve *ColorCurve, minY, maxY float64, targetChannel int) {
+
+	if len(curve.Points) == 0 {
+		return
+	}
+
+	mesh.ensureEnoughVertexColorChannels(targetChannel)
+
+	heightRange := maxY - minY
+
+	for i := 0; i < mesh.VertexCount; i++ {
+
+		perc := 0.0
+
+		if heightRange != 0 {
+			perc = (mesh.VertexPositions[i][1] - minY) / heightRange
+		} else if mesh.VertexPositions[i][1] >= maxY {
+			perc = 1
+		}
+
+		mesh.VertexColors[i][targetChannel].Set(curve.Color(perc).ToFloat32s())
+
+	}
+
+}
+
+// PaintVertexColors paints the given color into the target vertex color channel of vertices within radius units of worldCenter, like a
+// brush. transform is the world transform of the Model using the Mesh (i.e. Model.Transform()), used to place the vertices in world space.
+// strength is how strongly the color is blended into vertices at the center of the brush (from 0 to 1); the strength falls off linearly
+// to 0 at the edge of the brush. Alpha is painted as well. The painted colors are visible the next time the Mesh is rendered.
+// PaintVertexColors returns the number of vertices that were painted.
+func (mesh *Mesh) PaintVertexColors(worldCenter vector.Vector, radius float64, color *Color, targetChannel int, strength float64, transform Matrix4) int {
+
+	if radius <= 0 || strength <= 0 {
+		return 0
+	}
+
+	mesh.ensureEnoughVertexColorChannels(targetChannel)
+
+	radiusSquared := radius * radius
+	painted := 0
+
+	for i := 0; i < mesh.VertexCount; i++ {
+
+		x, y, z := fastMatrixMultVec(transform, mesh.VertexPositions[i])
+		dx := x - worldCenter[0]
+		dy := y - worldCenter[1]
+		dz := z - worldCenter[2]
+		distSquared := dx*dx + dy*dy + dz*dz
+
+		if distSquared > radiusSquared {
+			continue
+		}
+
+		falloff := 1 - (math.Sqrt(distSquared) / radius)
+		mesh.VertexColors[i][targetChannel].Mix(color, float32(math.Min(strength*falloff, 1)))
+		painted++
+
+	}
+
+	return painted
+
+}
+
 // Materials returns a slice of the materials present in the Mesh's MeshParts.
 func (mesh *Mesh) Materials() []*Material {
 	mats := []*Material{}
@@ -419,6 +541,99 @@ func (mesh *Mesh) AddMeshPart(material *Material) *MeshPart {
 	return mp
 }
 
+// addSplitMeshParts adds the triangles formed by the given vertices to new MeshParts using the given Material, splitting them up into
+// as many MeshParts as necessary to stay within the maximum renderable triangle count for a single MeshPart.
+func (mesh *Mesh) addSplitMeshParts(material *Material, verts []VertexInfo) {
+
+	for start := 0; start < len(verts); start += maxTriangleCount * 3 {
+
+		end := start + maxTriangleCount*3
+		if end > len(verts) {
+			end = len(verts)
+		}
+
+		mesh.AddMeshPart(material).AddTriangles(verts[start:end]...)
+
+	}
+
+}
+
+// MorphTarget is an alternate shape for a Mesh (also known as a shape key in Blender, or a blend shape), like a smile or a blink for a
+// character's face. Rather than storing the alternate shape's vertices, a MorphTarget stores how far each vertex moves from the Mesh's
+// base shape; Models using the Mesh blend their vertices towards each MorphTarget according to the Model's morph weight for it (see
+// Model.SetMorphWeight()), with a weight of 0 meaning the MorphTarget has no effect and a weight of 1 meaning it's fully applied.
+type MorphTarget struct {
+	Name string
+
+	// PositionDeltas are how far each vertex moves (in the Mesh's local space) when the MorphTarget is fully applied. They're indexed
+	// in the same way as Mesh.VertexPositions.
+	PositionDeltas []vector.Vector
+
+	// NormalDeltas are how much each vertex's normal changes when the MorphTarget is fully applied, indexed in the same way as
+	// Mesh.VertexNormals. If nil, the MorphTarget doesn't affect normals.
+	NormalDeltas []vector.Vector
+
+	DefaultWeight float64 // The morph weight that Models using the Mesh start with for the MorphTarget. Defaults to 0.
+}
+
+// Clone returns a clone of the MorphTarget.
+func (target *MorphTarget) Clone() *MorphTarget {
+
+	newTarget := &MorphTarget{
+		Name:          target.Name,
+		DefaultWeight: target.DefaultWeight,
+	}
+
+	newTarget.PositionDeltas = make([]vector.Vector, 0, len(target.PositionDeltas))
+	for _, delta := range target.PositionDeltas {
+		newTarget.PositionDeltas = append(newTarget.PositionDeltas, delta.Clone())
+	}
+
+	if target.NormalDeltas != nil {
+		newTarget.NormalDeltas = make([]vector.Vector, 0, len(target.NormalDeltas))
+		for _, delta := range target.NormalDeltas {
+			newTarget.NormalDeltas = append(newTarget.NormalDeltas, delta.Clone())
+		}
+	}
+
+	return newTarget
+
+}
+
+// AddMorphTarget adds a new MorphTarget with the given name to the Mesh, using the given position and normal deltas (see MorphTarget
+// for more information), and returns it. normalDeltas can be nil if the MorphTarget shouldn't affect normals. The deltas must have an
+// entry for each of the Mesh's vertices, so the MorphTarget should be added after all of the Mesh's triangles have been; otherwise,
+// AddMorphTarget will panic. Note that MorphTargets only apply while the Mesh has the same number of vertices they do, so functions
+// that change the number of vertices in the Mesh (like Mesh.Subdivide()) effectively disable its MorphTargets.
+func (mesh *Mesh) AddMorphTarget(name string, positionDeltas, normalDeltas []vector.Vector) *MorphTarget {
+
+	if len(positionDeltas) != mesh.VertexCount || (normalDeltas != nil && len(normalDeltas) != mesh.VertexCount) {
+		panic("Error: Morph target [" + name + "] for mesh [" + mesh.Name + "] must have a delta for each of the mesh's " + fmt.Sprintf("%d", mesh.VertexCount) + " vertices.")
+	}
+
+	target := &MorphTarget{
+		Name:           name,
+		PositionDeltas: positionDeltas,
+		NormalDeltas:   normalDeltas,
+	}
+
+	mesh.MorphTargets = append(mesh.MorphTargets, target)
+
+	return target
+
+}
+
+// MorphTargetIndex returns the index of the MorphTarget with the given name in the Mesh's MorphTargets slice, or -1 if there's no
+// MorphTarget with that name.
+func (mesh *Mesh) MorphTargetIndex(name string) int {
+	for i, target := range mesh.MorphTargets {
+		if target.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
 // FindMeshPart allows you to retrieve a MeshPart by its material's name. If no material with the provided name is given, the function returns nil.
 func (mesh *Mesh) FindMeshPart(materialName string) *MeshPart {
 	for _, mp := range mesh.MeshParts {
@@ -437,6 +652,8 @@ func (mesh *Mesh) Library() *Library {
 // UpdateBounds updates the mesh's dimensions; call this after manually changing vertex positions.
 func (mesh *Mesh) UpdateBounds() {
 
+	mesh.tightBounds = false
+
 	mesh.Dimensions[1] = vector.Vector{-math.MaxFloat64, -math.MaxFloat64, -math.MaxFloat64}
 	mesh.Dimensions[0] = vector.Vector{math.MaxFloat64, math.MaxFloat64, math.MaxFloat64}
 
@@ -470,6 +687,190 @@ func (mesh *Mesh) UpdateBounds() {
 
 }
 
+// RecalculateBoundsTight updates the Mesh's Dimensions (like Mesh.UpdateBounds()), and then calculates a tight bounding sphere around
+// the Mesh's vertices using Ritter's algorithm. Models using the Mesh then use this sphere for their BoundingSpheres (which are used for
+// frustum culling, among other things) rather than the sphere enclosing the Mesh's Dimensions, which is generally larger than necessary.
+// As this is more expensive than Mesh.UpdateBounds(), it's opt-in; note that calling UpdateBounds() afterwards (as functions that alter
+// the Mesh's geometry do) discards the tight bounding sphere.
+func (mesh *Mesh) RecalculateBoundsTight() {
+
+	mesh.UpdateBounds()
+
+	if mesh.VertexCount == 0 {
+		return
+	}
+
+	positions := mesh.VertexPositions[:mesh.VertexCount]
+
+	farthestFrom := func(point vector.Vector) vector.Vector {
+		var farthest vector.Vector
+		farthestDist := -1.0
+		for _, pos := range positions {
+			if dist := fastVectorDistanceSquared(point, pos); dist > farthestDist {
+				farthestDist = dist
+				farthest = pos
+			}
+		}
+		return farthest
+	}
+
+	// Start with the sphere spanning two points that are far apart from each other...
+	a := farthestFrom(positions[0])
+	b := farthestFrom(a)
+
+	center := vector.Vector{(a[0] + b[0]) / 2, (a[1] + b[1]) / 2, (a[2] + b[2]) / 2}
+	radius := math.Sqrt(fastVectorDistanceSquared(a, b)) / 2
+
+	// ...And then grow it to encompass any points outside of it.
+	for _, pos := range positions {
+
+		dist := math.Sqrt(fastVectorDistanceSquared(center, pos))
+
+		if dist > radius {
+
+			newRadius := (radius + dist) / 2
+			shift := (newRadius - radius) / dist
+
+			center[0] += (pos[0] - center[0]) * shift
+			center[1] += (pos[1] - center[1]) * shift
+			center[2] += (pos[2] - center[2]) * shift
+			radius = newRadius
+
+		}
+
+	}
+
+	mesh.tightBoundsCenter = center
+	mesh.tightBoundsRadius = radius
+	mesh.tightBounds = true
+
+}
+
+// boundingSphere returns the local center and radius of a sphere encompassing the Mesh; this is the tight bounding sphere if
+// Mesh.RecalculateBoundsTight() was called, or the sphere encompassing the Mesh's Dimensions otherwise.
+func (mesh *Mesh) boundingSphere() (vector.Vector, float64) {
+	if mesh.tightBounds {
+		return mesh.tightBoundsCenter.Clone(), mesh.tightBoundsRadius
+	}
+	return mesh.Dimensions.Center(), mesh.Dimensions.MaxSpan() / 2
+}
+
+// Optimize builds the Mesh's index buffer (Mesh.Indices) by finding identical vertices (vertices with the same position, normal,
+// UV, vertex colors, active color channel, bones, and bone weights) in each MeshPart, and then turns on indexed mode (Mesh.Indexed).
+// In indexed mode, each group of identical vertices is only transformed (and skinned) once when rendering, which can save a good
+// amount of work for Meshes with many shared vertices (like merged or smooth-shaded Meshes). The result is deterministic, as each
+// vertex is mapped to the first identical vertex in its MeshPart in triangle order. Optimize returns the number of unique vertices.
+// Note that if you alter the Mesh's vertices afterwards (for example, through a VertexSelection) so that previously identical
+// vertices differ, you should call Optimize() again.
+func (mesh *Mesh) Optimize() int {
+
+	mesh.Indices = make([]int, mesh.VertexCount)
+	uniqueCount := 0
+
+	key := make([]byte, 0, 256)
+
+	for _, part := range mesh.MeshParts {
+
+		unique := map[string]int{}
+
+		for triID := part.TriangleStart; triID < part.TriangleEnd; triID++ {
+
+			for v := triID * 3; v < triID*3+3; v++ {
+
+				key = mesh.appendVertexKey(key[:0], v)
+
+				if existing, ok := unique[string(key)]; ok {
+					mesh.Indices[v] = existing
+				} else {
+					unique[string(key)] = v
+					mesh.Indices[v] = v
+					uniqueCount++
+				}
+
+			}
+
+		}
+
+	}
+
+	mesh.Indexed = true
+
+	return uniqueCount
+
+}
+
+// appendVertexKey appends the binary representation of all of the properties of the specified vertex to the key byte slice given,
+// so that identical vertices have identical keys.
+func (mesh *Mesh) appendVertexKey(key []byte, index int) []byte {
+
+	appendFloat := func(f float64) {
+		bits := math.Float64bits(f)
+		for i := 0; i < 8; i++ {
+			key = append(key, byte(bits>>(i*8)))
+		}
+	}
+
+	for _, vec := range []vector.Vector{mesh.VertexPositions[index], mesh.VertexNormals[index], mesh.VertexUVs[index], mesh.VertexUV2s[index]} {
+		for _, f := range vec {
+			appendFloat(f)
+		}
+	}
+
+	appendFloat(float64(mesh.VertexActiveColorChannel[index]))
+
+	appendFloat(float64(len(mesh.VertexColors[index])))
+	for _, color := range mesh.VertexColors[index] {
+		appendFloat(float64(color.R))
+		appendFloat(float64(color.G))
+		appendFloat(float64(color.B))
+		appendFloat(float64(color.A))
+	}
+
+	appendFloat(float64(len(mesh.VertexBones[index])))
+	for _, bone := range mesh.VertexBones[index] {
+		appendFloat(float64(bone))
+	}
+
+	appendFloat(float64(len(mesh.VertexWeights[index])))
+	for _, weight := range mesh.VertexWeights[index] {
+		appendFloat(float64(weight))
+	}
+
+	return key
+
+}
+
+// beginIndexedPass starts a new vertex processing pass for indexed mode, returning if the Mesh should be processed in indexed mode at all.
+func (mesh *Mesh) beginIndexedPass() bool {
+
+	if !mesh.Indexed || len(mesh.Indices) != mesh.VertexCount {
+		return false
+	}
+
+	if len(mesh.indexStamps) != mesh.VertexCount {
+		mesh.indexStamps = make([]uint64, mesh.VertexCount)
+	}
+
+	mesh.indexStamp++
+
+	return true
+
+}
+
+// markIndexedVertex marks the unique vertex with the index given as processed in the current indexed pass, returning if it had already
+// been processed.
+func (mesh *Mesh) markIndexedVertex(index int) bool {
+
+	if mesh.indexStamps[index] == mesh.indexStamp {
+		return true
+	}
+
+	mesh.indexStamps[index] = mesh.indexStamp
+
+	return false
+
+}
+
 // GetVertexInfo returns a VertexInfo struct containing the vertex information for the vertex with the provided index.
 func (mesh *Mesh) GetVertexInfo(vertexIndex int) VertexInfo {
 
@@ -480,6 +881,8 @@ func (mesh *Mesh) GetVertexInfo(vertexIndex int) VertexInfo {
 		Z:                  mesh.VertexPositions[vertexIndex][2],
 		U:                  mesh.VertexUVs[vertexIndex][0],
 		V:                  mesh.VertexUVs[vertexIndex][1],
+		U2:                 mesh.VertexUV2s[vertexIndex][0],
+		V2:                 mesh.VertexUV2s[vertexIndex][1],
 		NormalX:            mesh.VertexNormals[vertexIndex][0],
 		NormalY:            mesh.VertexNormals[vertexIndex][1],
 		NormalZ:            mesh.VertexNormals[vertexIndex][2],
@@ -506,20 +909,512 @@ func (mesh *Mesh) AutoNormal() {
 
 }
 
+// GenerateTangents calculates the tangents of the Mesh's vertices from their positions, normals, and UV values, storing them in
+// Mesh.VertexTangents; tangents are needed for normal mapping (see Material.NormalMap). Each tangent points along the direction
+// in which the U value increases across the surface, and its W component is 1 or -1 depending on whether the V value increases
+// along the bitangent (the cross product of the normal and the tangent) or against it (i.e. for mirrored UVs). Tangents are
+// averaged between vertices that share the same position and normal. They're generated automatically when loading a Mesh with a
+// normal-mapped Material and when rendering a normal-mapped Mesh whose tangents are missing, so calling this is only necessary
+// after changing the Mesh's vertices or UV values.
+func (mesh *Mesh) GenerateTangents() {
+
+	type tangentKey struct {
+		position, normal [3]float64
+		mirrored         bool
+	}
+
+	type tangentSum struct {
+		tangent, bitangent vector.Vector
+	}
+
+	sums := map[tangentKey]*tangentSum{}
+	keys := make([]tangentKey, mesh.VertexCount)
+
+	for _, tri := range mesh.Triangles {
+
+		i := tri.ID * 3
+
+		p0, p1, p2 := mesh.VertexPositions[i], mesh.VertexPositions[i+1], mesh.VertexPositions[i+2]
+		uv0, uv1, uv2 := mesh.VertexUVs[i], mesh.VertexUVs[i+1], mesh.VertexUVs[i+2]
+
+		edge1, edge2 := p1.Sub(p0), p2.Sub(p0)
+		du1, dv1 := uv1[0]-uv0[0], uv1[1]-uv0[1]
+		du2, dv2 := uv2[0]-uv0[0], uv2[1]-uv0[1]
+
+		tangent := vector.Vector{0, 0, 0}
+		bitangent := vector.Vector{0, 0, 0}
+
+		if det := du1*dv2 - du2*dv1; det != 0 {
+			r := 1 / det
+			tangent = edge1.Scale(dv2 * r).Sub(edge2.Scale(dv1 * r))
+			bitangent = edge2.Scale(du1 * r).Sub(edge1.Scale(du2 * r))
+		}
+
+		for v := i; v < i+3; v++ {
+
+			normal := mesh.VertexNormals[v]
+			cross, _ := normal[:3].Cross(tangent)
+			key := tangentKey{
+				position: [3]float64{mesh.VertexPositions[v][0], mesh.VertexPositions[v][1], mesh.VertexPositions[v][2]},
+				normal:   [3]float64{normal[0], normal[1], normal[2]},
+				mirrored: dot(cross, bitangent) < 0,
+			}
+
+			sum, exists := sums[key]
+			if !exists {
+				sum = &tangentSum{tangent: vector.Vector{0, 0, 0}, bitangent: vector.Vector{0, 0, 0}}
+				sums[key] = sum
+			}
+
+			vector.In(sum.tangent).Add(tangent)
+			vector.In(sum.bitangent).Add(bitangent)
+			keys[v] = key
+
+		}
+
+	}
+
+	mesh.VertexTangents = make([]vector.Vector, mesh.VertexCount)
+
+	for v := 0; v < mesh.VertexCount; v++ {
+
+		normal := mesh.VertexNormals[v]
+		sum := sums[keys[v]]
+
+		tangent := vector.Vector{1, 0, 0}
+		bitangent := vector.Vector{0, 1, 0}
+
+		if sum != nil {
+			tangent, bitangent = sum.tangent, sum.bitangent
+		}
+
+		// The tangent is made perpendicular to the normal; if that's not possible (i.e. because the triangles have no UV values), any
+		// perpendicular direction is used.
+		tangent = tangent.Sub(normal.Scale(dot(normal, tangent)))
+
+		if tangent.Magnitude() < 0.0001 {
+			axis := vector.Vector{1, 0, 0}
+			if math.Abs(normal[0]) > 0.9 {
+				axis = vector.Vector{0, 1, 0}
+			}
+			tangent = axis.Sub(normal.Scale(dot(normal, axis)))
+		}
+
+		tangent = tangent.Unit()
+
+		handedness := 1.0
+		if cross, _ := normal[:3].Cross(tangent); dot(cross, bitangent) < 0 {
+			handedness = -1
+		}
+
+		mesh.VertexTangents[v] = vector.Vector{tangent[0], tangent[1], tangent[2], handedness}
+
+	}
+
+}
+
+// Subdivide subdivides the Mesh the number of times specified by iterations. Each iteration splits every triangle into four
+// triangles using the midpoints of its edges, interpolating UVs and vertex colors for the new vertices; vertex normals are then recalculated
+// from the new triangles, keeping smooth-shaded areas smooth and hard edges hard. Note that the
+// triangle count grows exponentially - each iteration multiplies it by 4, so subdividing a 1000-triangle Mesh three times
+// results in 64000 triangles. Be careful! If a MeshPart would exceed the maximum renderable triangle count for a single
+// MeshPart (21845 triangles), the extra triangles are split off into new MeshParts using the same Material.
+// Subdivide doesn't move any vertices on its own; see Mesh.Smooth() for that. Note that skinned Models size their internal
+// buffers according to their Mesh's vertex count on creation, so you should subdivide a skinned Mesh before creating Models with it.
+func (mesh *Mesh) Subdivide(iterations int) {
+
+	type subdividedPart struct {
+		Part     *MeshPart
+		Vertices []VertexInfo
+	}
+
+	for iter := 0; iter < iterations; iter++ {
+
+		parts := make([]subdividedPart, 0, len(mesh.MeshParts))
+		totalVertexCount := 0
+
+		for _, part := range mesh.MeshParts {
+
+			if part.TriangleStart < 0 {
+				parts = append(parts, subdividedPart{Part: part})
+				continue
+			}
+
+			verts := make([]VertexInfo, 0, (part.TriangleEnd-part.TriangleStart)*12)
+
+			for triIndex := part.TriangleStart; triIndex < part.TriangleEnd; triIndex++ {
+
+				a := mesh.GetVertexInfo(triIndex * 3)
+				b := mesh.GetVertexInfo(triIndex*3 + 1)
+				c := mesh.GetVertexInfo(triIndex*3 + 2)
+
+				ab := vertexInfoMidpoint(a, b)
+				bc := vertexInfoMidpoint(b, c)
+				ca := vertexInfoMidpoint(c, a)
+
+				verts = append(verts,
+					a.clone(), ab, ca.clone(),
+					b.clone(), bc, ab.clone(),
+					c.clone(), ca, bc.clone(),
+					ab.clone(), bc.clone(), ca.clone(),
+				)
+
+			}
+
+			totalVertexCount += len(verts)
+
+			parts = append(parts, subdividedPart{Part: part, Vertices: verts})
+
+		}
+
+		mesh.clearGeometry()
+		mesh.allocateVertexBuffers(totalVertexCount)
+
+		mesh.MeshParts = make([]*MeshPart, 0, len(parts))
+
+		for _, p := range parts {
+
+			p.Part.TriangleStart = -1
+			p.Part.TriangleEnd = -1
+			p.Part.sortingTriangles = []sortingTriangle{}
+			mesh.MeshParts = append(mesh.MeshParts, p.Part)
+
+			target := p.Part
+
+			for start := 0; start < len(p.Vertices); start += maxTriangleCount * 3 {
+
+				end := start + maxTriangleCount*3
+				if end > len(p.Vertices) {
+					end = len(p.Vertices)
+				}
+
+				if start > 0 {
+					target = mesh.AddMeshPart(p.Part.Material)
+				}
+
+				target.AddTriangles(p.Vertices[start:end]...)
+
+			}
+
+		}
+
+	}
+
+	mesh.recalculateVertexNormals()
+
+	mesh.UpdateBounds()
+
+}
+
+// Smooth smooths the Mesh by moving each vertex towards the average position of its neighboring vertices (vertices that share a triangle
+// edge with it). Vertices are identified by position, so vertices that are in the same place (i.e. on the seams of separate triangles)
+// move together. factor controls how much the vertices move towards the average each iteration (with 0 being not at all and 1 being
+// fully). Smooth recalculates the physical triangle normals as well as the vertex normals, keeping smooth-shaded areas smooth and hard
+// edges hard; call Mesh.AutoNormal() afterwards if you want the Mesh to be flat-shaded instead. Smooth pairs well with Mesh.Subdivide().
+func (mesh *Mesh) Smooth(iterations int, factor float64) {
+
+	type positionKey [3]int64
+
+	toKey := func(v vector.Vector) positionKey {
+		return positionKey{int64(math.Round(v[0] * 10000)), int64(math.Round(v[1] * 10000)), int64(math.Round(v[2] * 10000))}
+	}
+
+	for iter := 0; iter < iterations; iter++ {
+
+		vertices := map[positionKey][]int{}
+		neighbors := map[positionKey]map[positionKey]vector.Vector{}
+
+		for _, tri := range mesh.Triangles {
+
+			indices := tri.VertexIndices()
+
+			for i, index := range indices {
+
+				key := toKey(mesh.VertexPositions[index])
+				vertices[key] = append(vertices[key], index)
+
+				if _, exists := neighbors[key]; !exists {
+					neighbors[key] = map[positionKey]vector.Vector{}
+				}
+
+				for j, otherIndex := range indices {
+					if i != j {
+						neighbors[key][toKey(mesh.VertexPositions[otherIndex])] = mesh.VertexPositions[otherIndex]
+					}
+				}
+
+			}
+
+		}
+
+		newPositions := make(map[positionKey]vector.Vector, len(vertices))
+
+		for key, indices := range vertices {
+
+			if len(neighbors[key]) == 0 {
+				continue
+			}
+
+			avg := vector.Vector{0, 0, 0}
+			for _, n := range neighbors[key] {
+				avg[0] += n[0]
+				avg[1] += n[1]
+				avg[2] += n[2]
+			}
+
+			count := float64(len(neighbors[key]))
+			pos := mesh.VertexPositions[indices[0]]
+
+			newPositions[key] = vector.Vector{
+				pos[0] + (avg[0]/count-pos[0])*factor,
+				pos[1] + (avg[1]/count-pos[1])*factor,
+				pos[2] + (avg[2]/count-pos[2])*factor,
+			}
+
+		}
+
+		for key, newPos := range newPositions {
+			for _, index := range vertices[key] {
+				mesh.VertexPositions[index][0] = newPos[0]
+				mesh.VertexPositions[index][1] = newPos[1]
+				mesh.VertexPositions[index][2] = newPos[2]
+			}
+		}
+
+	}
+
+	for _, tri := range mesh.Triangles {
+		tri.RecalculateCenter()
+		tri.RecalculateNormal()
+	}
+
+	mesh.recalculateVertexNormals()
+
+	mesh.UpdateBounds()
+
+}
+
+// recalculateVertexNormals recalculates the Mesh's vertex normals from the surface normals of its triangles. Each vertex's normal becomes
+// the average of the normals of the triangles (weighted by their areas) that share both its position and, roughly, its existing normal;
+// this way, smooth-shaded areas of the Mesh stay smooth and hard edges stay hard.
+func (mesh *Mesh) recalculateVertexNormals() {
+
+	type positionKey [3]int64
+
+	toKey := func(v vector.Vector) positionKey {
+		return positionKey{int64(math.Round(v[0] * 10000)), int64(math.Round(v[1] * 10000)), int64(math.Round(v[2] * 10000))}
+	}
+
+	// A group of vertices in the same position with roughly the same normal, and the sum of their triangles' area-weighted normals.
+	type normalGroup struct {
+		normal  vector.Vector
+		sum     vector.Vector
+		indices []int
+	}
+
+	groups := map[positionKey][]*normalGroup{}
+
+	for _, tri := range mesh.Triangles {
+
+		indices := tri.VertexIndices()
+		p0, p1, p2 := mesh.VertexPositions[indices[0]], mesh.VertexPositions[indices[1]], mesh.VertexPositions[indices[2]]
+
+		// The cross product's length is twice the triangle's area, weighting larger triangles more heavily.
+		weighted, _ := p1.Sub(p0).Cross(p2.Sub(p1))
+
+		for _, index := range indices {
+
+			key := toKey(mesh.VertexPositions[index])
+			normal := mesh.VertexNormals[index]
+
+			var group *normalGroup
+
+			for _, g := range groups[key] {
+				if dot(g.normal, normal) >= 0.99 {
+					group = g
+					break
+				}
+			}
+
+			if group == nil {
+				group = &normalGroup{normal: normal.Clone(), sum: vector.Vector{0, 0, 0}}
+				groups[key] = append(groups[key], group)
+			}
+
+			group.sum[0] += weighted[0]
+			group.sum[1] += weighted[1]
+			group.sum[2] += weighted[2]
+			group.indices = append(group.indices, index)
+
+		}
+
+	}
+
+	for _, positionGroups := range groups {
+		for _, group := range positionGroups {
+
+			if group.sum.Magnitude() == 0 {
+				continue
+			}
+
+			normal := group.sum.Unit()
+
+			for _, index := range group.indices {
+				mesh.VertexNormals[index][0] = normal[0]
+				mesh.VertexNormals[index][1] = normal[1]
+				mesh.VertexNormals[index][2] = normal[2]
+			}
+
+		}
+	}
+
+}
+
+// insertTriangles rebuilds the Mesh's vertex buffers with the triangles formed by the given vertices added to the end of their MeshParts.
+// sources holds the index of the existing vertex that each new vertex was made from, so that the per-vertex data that VertexInfo doesn't
+// cover (tangents and morph target deltas) can be carried over to it. insertTriangles returns the new indices of the Mesh's existing vertices.
+func (mesh *Mesh) insertTriangles(verts map[*MeshPart][]VertexInfo, sources map[*MeshPart][]int) []int {
+
+	type rebuiltPart struct {
+		Part          *MeshPart
+		Vertices      []VertexInfo
+		Sources       []int
+		ExistingCount int
+	}
+
+	parts := make([]rebuiltPart, 0, len(mesh.MeshParts))
+	totalVertexCount := 0
+
+	for _, part := range mesh.MeshParts {
+
+		p := rebuiltPart{Part: part}
+
+		if part.TriangleStart >= 0 {
+			for i := part.TriangleStart * 3; i < part.TriangleEnd*3; i++ {
+				p.Vertices = append(p.Vertices, mesh.GetVertexInfo(i))
+				p.Sources = append(p.Sources, i)
+			}
+		}
+
+		p.ExistingCount = len(p.Vertices)
+		p.Vertices = append(p.Vertices, verts[part]...)
+		p.Sources = append(p.Sources, sources[part]...)
+
+		totalVertexCount += len(p.Vertices)
+		parts = append(parts, p)
+
+	}
+
+	remapped := make([]int, mesh.VertexCount)
+	newSources := make([]int, 0, totalVertexCount)
+	tangents := mesh.VertexTangents
+
+	mesh.clearGeometry()
+	mesh.allocateVertexBuffers(totalVertexCount)
+
+	for _, p := range parts {
+
+		p.Part.TriangleStart = -1
+		p.Part.TriangleEnd = -1
+		p.Part.sortingTriangles = []sortingTriangle{}
+
+		if len(p.Vertices) == 0 {
+			continue
+		}
+
+		for i := 0; i < p.ExistingCount; i++ {
+			remapped[p.Sources[i]] = mesh.VertexCount + i
+		}
+
+		p.Part.AddTriangles(p.Vertices...)
+		newSources = append(newSources, p.Sources...)
+
+	}
+
+	if tangents != nil {
+		mesh.VertexTangents = make([]vector.Vector, len(newSources))
+		for i, source := range newSources {
+			mesh.VertexTangents[i] = tangents[source].Clone()
+		}
+	}
+
+	for _, target := range mesh.MorphTargets {
+
+		positionDeltas := make([]vector.Vector, len(newSources))
+		for i, source := range newSources {
+			positionDeltas[i] = target.PositionDeltas[source].Clone()
+		}
+		target.PositionDeltas = positionDeltas
+
+		if target.NormalDeltas != nil {
+			normalDeltas := make([]vector.Vector, len(newSources))
+			for i, source := range newSources {
+				normalDeltas[i] = target.NormalDeltas[source].Clone()
+			}
+			target.NormalDeltas = normalDeltas
+		}
+
+	}
+
+	return remapped
+
+}
+
+// clearGeometry clears the Mesh's vertex buffers and triangles, leaving the MeshParts in place.
+func (mesh *Mesh) clearGeometry() {
+
+	mesh.VertexPositions = []vector.Vector{}
+	mesh.VertexNormals = []vector.Vector{}
+	mesh.vertexSkinnedNormals = []vector.Vector{}
+	mesh.vertexSkinnedPositions = []vector.Vector{}
+	mesh.VertexUVs = []vector.Vector{}
+	mesh.VertexUV2s = []vector.Vector{}
+	mesh.VertexColors = [][]*Color{}
+	mesh.VertexActiveColorChannel = []int{}
+	mesh.VertexBones = [][]uint16{}
+	mesh.VertexWeights = [][]float32{}
+	mesh.VertexCount = 0
+	mesh.VertexMax = 0
+	mesh.Triangles = []*Triangle{}
+	mesh.triIndex = 0
+	mesh.Indices = nil
+	mesh.Indexed = false
+	mesh.geometryVersion++
+
+}
+
 // SelectVertices generates a new vertex selection for the current Mesh.
 func (mesh *Mesh) SelectVertices() *VertexSelection {
 	return NewVertexSelection(mesh)
 }
 
-// VertexSelection represents a selection of vertices on a Mesh.
+// VertexSelection represents a selection of vertices on a Mesh. Selecting functions add to the selection and return the VertexSelection,
+// so they can be chained (i.e. mesh.SelectVertices().SelectMaterial("Grass").SelectInBox(min, max)). Functions that modify the vertices update
+// the Mesh's bounds and triangle normals as necessary.
+// Adding triangles to a Mesh keeps existing VertexSelections valid, but rebuilding the Mesh's vertex buffers (i.e. through Mesh.Subdivide()
+// or another VertexSelection's Extrude()) invalidates them; using an invalidated VertexSelection will panic. You can check if a VertexSelection
+// is still valid with VertexSelection.Valid().
 type VertexSelection struct {
 	Indices map[int]bool
 	Mesh    *Mesh
+
+	geometryVersion int
 }
 
 // NewVertexSelection creates a new VertexSelection instance for the specified Mesh.
 func NewVertexSelection(mesh *Mesh) *VertexSelection {
-	return &VertexSelection{Indices: map[int]bool{}, Mesh: mesh}
+	return &VertexSelection{Indices: map[int]bool{}, Mesh: mesh, geometryVersion: mesh.geometryVersion}
+}
+
+// Valid returns if the VertexSelection is still valid (i.e. the Mesh's vertex buffers haven't been rebuilt since the VertexSelection's creation).
+func (vs *VertexSelection) Valid() bool {
+	return vs.geometryVersion == vs.Mesh.geometryVersion
+}
+
+func (vs *VertexSelection) checkValid() {
+	if !vs.Valid() {
+		panic("Error: VertexSelection on mesh [" + vs.Mesh.Name + "] is no longer valid, as the mesh's vertices have been rebuilt since the selection was made.")
+	}
 }
 
 // SelectInChannel selects all vertices in the Mesh that have a non-pure black color in the color channel
@@ -527,9 +1422,11 @@ func NewVertexSelection(mesh *Mesh) *VertexSelection {
 // the color channel will be created.
 func (vs *VertexSelection) SelectInChannel(channelIndex int) *VertexSelection {
 
+	vs.checkValid()
+
 	vs.Mesh.ensureEnoughVertexColorChannels(channelIndex)
 
-	for vertexIndex := range vs.Mesh.VertexColors {
+	for vertexIndex := 0; vertexIndex < vs.Mesh.VertexCount; vertexIndex++ {
 
 		color := vs.Mesh.VertexColors[vertexIndex][channelIndex]
 
@@ -546,7 +1443,9 @@ func (vs *VertexSelection) SelectInChannel(channelIndex int) *VertexSelection {
 // SelectAll selects all vertices on the source Mesh.
 func (vs *VertexSelection) SelectAll() *VertexSelection {
 
-	for i := 0; i < vs.Mesh.VertexMax; i++ {
+	vs.checkValid()
+
+	for i := 0; i < vs.Mesh.VertexCount; i++ {
 		vs.Indices[i] = true
 	}
 
@@ -556,6 +1455,12 @@ func (vs *VertexSelection) SelectAll() *VertexSelection {
 // SelectMeshPart selects all vertices in the Mesh belonging to the specified MeshPart.
 func (vs *VertexSelection) SelectMeshPart(meshPart *MeshPart) *VertexSelection {
 
+	vs.checkValid()
+
+	if meshPart.TriangleStart < 0 {
+		return vs
+	}
+
 	for i := meshPart.TriangleStart * 3; i < meshPart.TriangleEnd*3; i++ {
 		vs.Indices[i] = true
 	}
@@ -564,9 +1469,94 @@ func (vs *VertexSelection) SelectMeshPart(meshPart *MeshPart) *VertexSelection {
 
 }
 
+// SelectMaterial selects all vertices in the Mesh belonging to MeshParts that use a Material with the specified name.
+func (vs *VertexSelection) SelectMaterial(materialName string) *VertexSelection {
+
+	for _, mp := range vs.Mesh.MeshParts {
+		if mp.Material != nil && mp.Material.Name == materialName {
+			vs.SelectMeshPart(mp)
+		}
+	}
+
+	return vs
+
+}
+
+// SelectTriangles selects all vertices of the specified Triangles.
+func (vs *VertexSelection) SelectTriangles(triangles ...*Triangle) *VertexSelection {
+
+	vs.checkValid()
+
+	for _, tri := range triangles {
+		vs.Indices[tri.ID*3] = true
+		vs.Indices[tri.ID*3+1] = true
+		vs.Indices[tri.ID*3+2] = true
+	}
+
+	return vs
+
+}
+
+// SelectNormalDirection selects all vertices of triangles that face in the specified direction. tolerance is the maximum
+// angle (in radians) between a triangle's normal and the direction for the triangle to be selected.
+func (vs *VertexSelection) SelectNormalDirection(direction vector.Vector, tolerance float64) *VertexSelection {
+
+	vs.checkValid()
+
+	dir := direction.Unit()
+	minDot := math.Cos(tolerance)
+
+	for _, tri := range vs.Mesh.Triangles {
+		if dot(tri.Normal, dir) >= minDot {
+			vs.Indices[tri.ID*3] = true
+			vs.Indices[tri.ID*3+1] = true
+			vs.Indices[tri.ID*3+2] = true
+		}
+	}
+
+	return vs
+
+}
+
+// SelectInBox selects all vertices that lie within the box formed by the min and max corners provided (in the Mesh's local space).
+func (vs *VertexSelection) SelectInBox(min, max vector.Vector) *VertexSelection {
+
+	vs.checkValid()
+
+	box := Dimensions{min, max}
+
+	for i := 0; i < vs.Mesh.VertexCount; i++ {
+		if box.Inside(vs.Mesh.VertexPositions[i]) {
+			vs.Indices[i] = true
+		}
+	}
+
+	return vs
+
+}
+
+// Triangles returns the Triangles that are fully selected (i.e. all three of their vertices are in the VertexSelection).
+func (vs *VertexSelection) Triangles() []*Triangle {
+
+	vs.checkValid()
+
+	triangles := []*Triangle{}
+
+	for _, tri := range vs.Mesh.Triangles {
+		if vs.Indices[tri.ID*3] && vs.Indices[tri.ID*3+1] && vs.Indices[tri.ID*3+2] {
+			triangles = append(triangles, tri)
+		}
+	}
+
+	return triangles
+
+}
+
 // SetColor sets the color of the specified channel in all vertices contained within the VertexSelection to the provided Color.
 func (vs *VertexSelection) SetColor(channelIndex int, color *Color) {
 
+	vs.checkValid()
+
 	vs.Mesh.ensureEnoughVertexColorChannels(channelIndex)
 
 	for i := range vs.Indices {
@@ -578,6 +1568,8 @@ func (vs *VertexSelection) SetColor(channelIndex int, color *Color) {
 // SetNormal sets the normal of all vertices contained within the VertexSelection to the provided normal vector.
 func (vs *VertexSelection) SetNormal(normal vector.Vector) {
 
+	vs.checkValid()
+
 	for i := range vs.Indices {
 		vs.Mesh.VertexNormals[i][0] = normal[0]
 		vs.Mesh.VertexNormals[i][1] = normal[1]
@@ -590,6 +1582,8 @@ func (vs *VertexSelection) SetNormal(normal vector.Vector) {
 // specified index.
 func (vs *VertexSelection) SetActiveColorChannel(channelIndex int) {
 
+	vs.checkValid()
+
 	vs.Mesh.ensureEnoughVertexColorChannels(channelIndex)
 
 	for i := range vs.Indices {
@@ -601,41 +1595,180 @@ func (vs *VertexSelection) SetActiveColorChannel(channelIndex int) {
 // ApplyMatrix applies a Matrix4 to the position of all vertices contained within the VertexSelection.
 func (vs *VertexSelection) ApplyMatrix(matrix Matrix4) {
 
-	for index := range vs.Indices {
+	vs.checkValid()
+
+	for index := range vs.Indices {
+
+		x, y, z := fastMatrixMultVec(matrix, vs.Mesh.VertexPositions[index])
+		vs.Mesh.VertexPositions[index][0] = x
+		vs.Mesh.VertexPositions[index][1] = y
+		vs.Mesh.VertexPositions[index][2] = z
+
+	}
+
+	vs.updateGeometry()
+
+}
+
+// Move moves all vertices contained within the VertexSelection by the provided x, y, and z values.
+func (vs *VertexSelection) Move(x, y, z float64) {
+
+	vs.checkValid()
+
+	for index := range vs.Indices {
+
+		vs.Mesh.VertexPositions[index][0] += x
+		vs.Mesh.VertexPositions[index][1] += y
+		vs.Mesh.VertexPositions[index][2] += z
+
+	}
+
+	vs.updateGeometry()
+
+}
+
+// Move moves all vertices contained within the VertexSelection by the provided 3D vector.
+func (vs *VertexSelection) MoveVec(vec vector.Vector) {
+	vs.Move(vec[0], vec[1], vec[2])
+}
+
+// Extrude extrudes the fully selected triangles of the VertexSelection (see VertexSelection.Triangles()) outwards along their normals
+// by the distance given, creating new triangles to form walls along the outer edges of the extruded area. The VertexSelection continues
+// to select the extruded triangles afterwards. Each wall is added to the MeshPart of the triangle whose edge it's built from. As this
+// rebuilds the Mesh's vertex buffers, any other VertexSelections on the Mesh are invalidated (though this one is kept up to date).
+func (vs *VertexSelection) Extrude(distance float64) {
+
+	triangles := vs.Triangles()
+
+	if len(triangles) == 0 {
+		return
+	}
+
+	mesh := vs.Mesh
+
+	type positionKey [3]int64
+
+	toKey := func(v vector.Vector) positionKey {
+		return positionKey{int64(math.Round(v[0] * 10000)), int64(math.Round(v[1] * 10000)), int64(math.Round(v[2] * 10000))}
+	}
+
+	type edgeKey [2]positionKey
+
+	// Count the edges of the selected triangles; edges that only belong to one selected triangle are on the boundary
+	// of the selection, and so need walls.
+	edgeCounts := map[edgeKey]int{}
+
+	for _, tri := range triangles {
+		for i := 0; i < 3; i++ {
+			a := toKey(mesh.VertexPositions[tri.ID*3+i])
+			b := toKey(mesh.VertexPositions[tri.ID*3+(i+1)%3])
+			edgeCounts[edgeKey{a, b}]++
+			edgeCounts[edgeKey{b, a}]++
+		}
+	}
+
+	// Each vertex position moves along the average normal of the selected triangles it's a part of.
+	offsets := map[positionKey]vector.Vector{}
+
+	for _, tri := range triangles {
+		for i := 0; i < 3; i++ {
+			key := toKey(mesh.VertexPositions[tri.ID*3+i])
+			if _, exists := offsets[key]; !exists {
+				offsets[key] = vector.Vector{0, 0, 0}
+			}
+			vector.In(offsets[key]).Add(tri.Normal)
+		}
+	}
+
+	for key, offset := range offsets {
+		if offset.Magnitude() > 0 {
+			offsets[key] = offset.Unit().Scale(distance)
+		}
+	}
+
+	walls := map[*MeshPart][]VertexInfo{}
+	wallSources := map[*MeshPart][]int{}
+
+	for _, tri := range triangles {
+
+		for i := 0; i < 3; i++ {
+
+			aIndex := tri.ID*3 + i
+			bIndex := tri.ID*3 + (i+1)%3
+
+			aKey := toKey(mesh.VertexPositions[aIndex])
+			bKey := toKey(mesh.VertexPositions[bIndex])
+
+			if edgeCounts[edgeKey{aKey, bKey}] > 1 {
+				continue
+			}
+
+			a := mesh.GetVertexInfo(aIndex).clone()
+			b := mesh.GetVertexInfo(bIndex).clone()
+
+			extrudedA := a.clone()
+			extrudedA.X += offsets[aKey][0]
+			extrudedA.Y += offsets[aKey][1]
+			extrudedA.Z += offsets[aKey][2]
+
+			extrudedB := b.clone()
+			extrudedB.X += offsets[bKey][0]
+			extrudedB.Y += offsets[bKey][1]
+			extrudedB.Z += offsets[bKey][2]
+
+			walls[tri.MeshPart] = append(walls[tri.MeshPart], a, b, extrudedB, a.clone(), extrudedB.clone(), extrudedA)
+			wallSources[tri.MeshPart] = append(wallSources[tri.MeshPart], aIndex, bIndex, bIndex, aIndex, bIndex, aIndex)
 
-		x, y, z := fastMatrixMultVec(matrix, vs.Mesh.VertexPositions[index])
-		vs.Mesh.VertexPositions[index][0] = x
-		vs.Mesh.VertexPositions[index][1] = y
-		vs.Mesh.VertexPositions[index][2] = z
+		}
 
 	}
 
-}
+	for index := range vs.Indices {
+		if offset, exists := offsets[toKey(mesh.VertexPositions[index])]; exists {
+			vector.In(mesh.VertexPositions[index]).Add(offset)
+		}
+	}
 
-// Move moves all vertices contained within the VertexSelection by the provided x, y, and z values.
-func (vs *VertexSelection) Move(x, y, z float64) {
+	if len(walls) > 0 {
 
-	for index := range vs.Indices {
+		remapped := mesh.insertTriangles(walls, wallSources)
 
-		vs.Mesh.VertexPositions[index][0] += x
-		vs.Mesh.VertexPositions[index][1] += y
-		vs.Mesh.VertexPositions[index][2] += z
+		indices := make(map[int]bool, len(vs.Indices))
+		for index := range vs.Indices {
+			indices[remapped[index]] = true
+		}
 
-	}
+		vs.Indices = indices
+		vs.geometryVersion = mesh.geometryVersion
 
-}
+		// The walls are flat-shaded.
+		for part, partWalls := range walls {
+			for _, tri := range mesh.Triangles[part.TriangleEnd-len(partWalls)/3 : part.TriangleEnd] {
+				for i := 0; i < 3; i++ {
+					normal := mesh.VertexNormals[tri.ID*3+i]
+					normal[0], normal[1], normal[2] = tri.Normal[0], tri.Normal[1], tri.Normal[2]
+				}
+			}
+		}
 
-// Move moves all vertices contained within the VertexSelection by the provided 3D vector.
-func (vs *VertexSelection) MoveVec(vec vector.Vector) {
+	}
 
-	for index := range vs.Indices {
+	vs.updateGeometry()
+
+}
 
-		vs.Mesh.VertexPositions[index][0] += vec[0]
-		vs.Mesh.VertexPositions[index][1] += vec[1]
-		vs.Mesh.VertexPositions[index][2] += vec[2]
+// updateGeometry recalculates the centers and normals of triangles with selected vertices, as well as the Mesh's bounds.
+func (vs *VertexSelection) updateGeometry() {
 
+	for _, tri := range vs.Mesh.Triangles {
+		if vs.Indices[tri.ID*3] || vs.Indices[tri.ID*3+1] || vs.Indices[tri.ID*3+2] {
+			tri.RecalculateCenter()
+			tri.RecalculateNormal()
+		}
 	}
 
+	vs.Mesh.UpdateBounds()
+
 }
 
 // NewCube creates a new Cube Mesh and gives it a new material (suitably named "Cube").
@@ -712,7 +1845,9 @@ func NewCube() *Mesh {
 
 }
 
-// NewIcosphere creates a new icosphere Mesh of the specified detail level. Note that the UVs are left at {0,0} because I'm lazy.
+// NewIcosphere creates a new icosphere Mesh of the specified detail level, with a radius of 1. A detail level of 0 gives an icosahedron
+// (20 triangles), while each level above that subdivides it further (a detail level of n > 0 gives 20 * 4^(n+1) triangles). The
+// vertices have smooth normals and spherically mapped UVs (like NewSphere()'s).
 func NewIcosphere(detailLevel int) *Mesh {
 
 	// Code cribbed from http://blog.andreaskahler.com/2009/06/creating-icosphere-mesh-in-code.html, thank you very much Andreas!
@@ -807,23 +1942,364 @@ func NewIcosphere(detailLevel int) *Mesh {
 		triangles[i].Y = v[1]
 		triangles[i].Z = v[2]
 
-		triangles[i].U = 0
-		triangles[i].V = 0
-		if i%3 == 1 {
-			triangles[i].U = 1
-		} else if i%3 == 2 {
-			triangles[i].V = 1
-		}
+		// On a sphere, the normals point straight out from the center
+		triangles[i].NormalX = v[0]
+		triangles[i].NormalY = v[1]
+		triangles[i].NormalZ = v[2]
+
+		triangles[i].U, triangles[i].V = sphereUV(v)
+
+	}
 
+	for triIndex := 0; triIndex < len(triangles); triIndex += 3 {
+		fixSphereTriangleUVs(triangles[triIndex : triIndex+3])
 	}
 
 	part.AddTriangles(triangles...)
 
-	mesh.AutoNormal()
+	mesh.UpdateBounds()
+
+	return mesh
+}
+
+// NewSphere creates a new UV sphere Mesh with a radius of 1, composed of the given number of rings (horizontal bands from pole to pole,
+// minimum 2) and segments (vertical slices around the Y axis, minimum 3). The vertices have smooth normals, and the UVs wrap around the
+// sphere horizontally once, with V = 0 at the top (+Y) pole and V = 1 at the bottom.
+func NewSphere(rings, segments int) *Mesh {
+
+	if rings < 2 {
+		rings = 2
+	}
+
+	if segments < 3 {
+		segments = 3
+	}
+
+	mesh := NewMesh("Sphere")
+	part := mesh.AddMeshPart(NewMaterial("Sphere"))
+
+	vertex := func(ring, segment int) VertexInfo {
+
+		theta := math.Pi * float64(ring) / float64(rings)
+		phi := math.Pi * 2 * float64(segment) / float64(segments)
+
+		x := math.Sin(theta) * math.Cos(phi)
+		y := math.Cos(theta)
+		z := math.Sin(theta) * math.Sin(phi)
+
+		u := float64(segment) / float64(segments)
+
+		// The poles are shared by every segment, so we center their UVs horizontally within the segment to reduce distortion.
+		if ring == 0 || ring == rings {
+			u += 0.5 / float64(segments)
+		}
+
+		v := NewVertex(x, y, z, u, float64(ring)/float64(rings))
+		v.NormalX, v.NormalY, v.NormalZ = x, y, z
+		return v
+
+	}
+
+	verts := make([]VertexInfo, 0, rings*segments*6)
+
+	for ring := 0; ring < rings; ring++ {
+
+		for segment := 0; segment < segments; segment++ {
+
+			a := vertex(ring, segment)
+			b := vertex(ring, segment+1)
+			c := vertex(ring+1, segment)
+			d := vertex(ring+1, segment+1)
+
+			// The top and bottom rings are made of triangles rather than quads, as one side of each quad is collapsed into a pole.
+			if ring == 0 {
+				verts = append(verts, a, d, c)
+			} else if ring == rings-1 {
+				verts = append(verts, a, b, c)
+			} else {
+				verts = append(verts, a, b, c, b, d, c)
+			}
+
+		}
+
+	}
+
+	part.AddTriangles(verts...)
+
+	mesh.UpdateBounds()
+
+	return mesh
+
+}
+
+// sphereUV returns the spherically mapped UV values for the given point on a unit sphere; U wraps around the Y axis, and V goes from 0 at
+// the top pole to 1 at the bottom.
+func sphereUV(point vector.Vector) (float64, float64) {
+
+	u := math.Atan2(point[2], point[0]) / (math.Pi * 2)
+	if u < 0 {
+		u++
+	}
+
+	v := math.Acos(math.Max(-1, math.Min(1, point[1]))) / math.Pi
+
+	return u, v
+
+}
+
+// fixSphereTriangleUVs fixes the spherically mapped U values of a triangle's vertices (see sphereUV()), so that triangles that cross the
+// seam where U wraps from 1 back to 0 don't stretch across the entire texture, and so that vertices at the poles (where U is meaningless)
+// use the average U of the triangle's other vertices.
+func fixSphereTriangleUVs(tri []VertexInfo) {
+
+	minU, maxU := math.MaxFloat64, -math.MaxFloat64
+
+	for _, v := range tri {
+		minU = math.Min(minU, v.U)
+		maxU = math.Max(maxU, v.U)
+	}
+
+	if maxU-minU > 0.5 {
+		for i := range tri {
+			if tri[i].U < 0.5 {
+				tri[i].U++
+			}
+		}
+	}
+
+	for i := range tri {
+
+		if math.Abs(tri[i].Y) > 0.9999 {
+
+			other1 := tri[(i+1)%3]
+			other2 := tri[(i+2)%3]
+			tri[i].U = (other1.U + other2.U) / 2
+
+		}
+
+	}
+
+}
+
+// latheRing is a ring of vertices around the Y axis; a list of latheRings traces out the profile of a rotationally symmetric
+// Mesh (see addLatheStrip()).
+type latheRing struct {
+	Radius float64 // The radius of the ring; a radius of 0 collapses the ring into a single point (i.e. the tip of a cone).
+	Y      float64 // The vertical position of the ring.
+
+	// The normal of the ring's vertices, in terms of its outward and upward components; it's rotated around the Y axis
+	// for each vertex and then normalized.
+	NormalOut, NormalUp float64
+
+	V        float64 // The V value for the ring's vertices; U wraps around the Y axis from 0 to 1.
+	PlanarUV bool    // If the ring's UVs should be projected from above instead (which is useful for flat caps).
+}
+
+// addLatheStrip rotates the given profile of rings around the Y axis with the given number of segments, appending the resulting
+// triangles' vertices to verts. The rings should be ordered from the top of the profile to the bottom, tracing along the outside of
+// the shape, so that the triangles face outwards.
+func addLatheStrip(verts []VertexInfo, segments int, rings []latheRing) []VertexInfo {
+
+	vertex := func(ring latheRing, segment int) VertexInfo {
+
+		perc := float64(segment) / float64(segments)
+
+		// Collapsed rings are shared by every segment, so we center their normals and UVs within the segment.
+		if ring.Radius == 0 {
+			perc += 0.5 / float64(segments)
+		}
+
+		phi := math.Pi * 2 * perc
+
+		x := math.Cos(phi) * ring.Radius
+		z := math.Sin(phi) * ring.Radius
+
+		u, v := perc, ring.V
+		if ring.PlanarUV {
+			u, v = 0.5+x/2, 0.5+z/2
+		}
+
+		normal := vector.Vector{math.Cos(phi) * ring.NormalOut, ring.NormalUp, math.Sin(phi) * ring.NormalOut}.Unit()
+
+		vert := NewVertex(x, ring.Y, z, u, v)
+		vert.NormalX, vert.NormalY, vert.NormalZ = normal[0], normal[1], normal[2]
+		return vert
+
+	}
+
+	for i := 0; i < len(rings)-1; i++ {
+
+		top := rings[i]
+		bottom := rings[i+1]
+
+		for segment := 0; segment < segments; segment++ {
+
+			a := vertex(top, segment)
+			b := vertex(top, segment+1)
+			c := vertex(bottom, segment)
+			d := vertex(bottom, segment+1)
+
+			if top.Radius == 0 {
+				verts = append(verts, a, d, c)
+			} else if bottom.Radius == 0 {
+				verts = append(verts, a, b, c)
+			} else {
+				verts = append(verts, a, b, c, b, d, c)
+			}
+
+		}
+
+	}
+
+	return verts
+
+}
+
+// NewCylinder creates a new cylinder Mesh with a radius of 1 and the given height, standing along the Y axis and centered on the
+// origin, and gives it a new material (suitably named "Cylinder"). segments is how many sides the cylinder has around its
+// circumference (and is at least 3), and capped is whether the top and bottom are closed off with flat caps. The sides have smooth
+// normals, while the caps have flat ones.
+func NewCylinder(segments int, height float64, capped bool) *Mesh {
+
+	if segments < 3 {
+		segments = 3
+	}
+
+	mesh := NewMesh("Cylinder")
+	part := mesh.AddMeshPart(NewMaterial("Cylinder"))
+
+	top := height / 2
+	bottom := -height / 2
+
+	verts := []VertexInfo{}
+
+	if capped {
+		verts = addLatheStrip(verts, segments, []latheRing{
+			{Radius: 0, Y: top, NormalUp: 1, PlanarUV: true},
+			{Radius: 1, Y: top, NormalUp: 1, PlanarUV: true},
+		})
+	}
+
+	verts = addLatheStrip(verts, segments, []latheRing{
+		{Radius: 1, Y: top, NormalOut: 1, V: 0},
+		{Radius: 1, Y: bottom, NormalOut: 1, V: 1},
+	})
+
+	if capped {
+		verts = addLatheStrip(verts, segments, []latheRing{
+			{Radius: 1, Y: bottom, NormalUp: -1, PlanarUV: true},
+			{Radius: 0, Y: bottom, NormalUp: -1, PlanarUV: true},
+		})
+	}
+
+	part.AddTriangles(verts...)
+
+	mesh.UpdateBounds()
+
+	return mesh
+
+}
+
+// NewCone creates a new cone Mesh with a radius of 1 and a height of 2, pointing up along the Y axis and centered on the origin (so
+// it fits in the same space as NewCube()), and gives it a new material (suitably named "Cone"). segments is how many sides the cone
+// has around its base (and is at least 3). The sides have smooth normals, while the base has flat ones.
+func NewCone(segments int) *Mesh {
+
+	if segments < 3 {
+		segments = 3
+	}
+
+	mesh := NewMesh("Cone")
+	part := mesh.AddMeshPart(NewMaterial("Cone"))
+
+	verts := []VertexInfo{}
+
+	// The sides slope down 2 units for every unit outwards, so the normals point 2 units outwards for every unit up.
+	verts = addLatheStrip(verts, segments, []latheRing{
+		{Radius: 0, Y: 1, NormalOut: 2, NormalUp: 1, V: 0},
+		{Radius: 1, Y: -1, NormalOut: 2, NormalUp: 1, V: 1},
+	})
+
+	verts = addLatheStrip(verts, segments, []latheRing{
+		{Radius: 1, Y: -1, NormalUp: -1, PlanarUV: true},
+		{Radius: 0, Y: -1, NormalUp: -1, PlanarUV: true},
+	})
+
+	part.AddTriangles(verts...)
+
+	mesh.UpdateBounds()
+
+	return mesh
+
+}
+
+// NewCapsule creates a new capsule Mesh with a radius of 1, standing along the Y axis and centered on the origin, and gives it a new
+// material (suitably named "Capsule"). segments is how many sides the capsule has around its circumference (and is at least 4); the
+// hemispheres on either end have a quarter as many rings. height is the total height of the capsule, including the hemispheres, just
+// like a BoundingCapsule's Height, so a capsule Mesh and a BoundingCapsule with a radius of 1 created with the same height line up.
+// As with BoundingCapsules, the height can't be less than the diameter (2). The capsule has smooth normals.
+func NewCapsule(segments int, height float64) *Mesh {
+
+	if segments < 4 {
+		segments = 4
+	}
+
+	height = math.Max(height, 2)
+
+	mesh := NewMesh("Capsule")
+	part := mesh.AddMeshPart(NewMaterial("Capsule"))
+
+	hemisphereRings := segments / 4
+	if hemisphereRings < 2 {
+		hemisphereRings = 2
+	}
+
+	// The distance from the center of the capsule to the center of each hemisphere
+	offset := height/2 - 1
+
+	// V goes from 0 to 1 along the length of the profile, from the top to the bottom.
+	profileLength := math.Pi + offset*2
+
+	rings := make([]latheRing, 0, hemisphereRings*2+2)
+
+	for i := 0; i <= hemisphereRings; i++ {
+		theta := math.Pi / 2 * float64(i) / float64(hemisphereRings)
+		rings = append(rings, latheRing{
+			Radius:    math.Sin(theta),
+			Y:         math.Cos(theta) + offset,
+			NormalOut: math.Sin(theta),
+			NormalUp:  math.Cos(theta),
+			V:         theta / profileLength,
+		})
+	}
+
+	// If there's no cylindrical section between the hemispheres, they share their equator ring.
+	start := 0
+	if offset == 0 {
+		start = 1
+	}
+
+	for i := start; i <= hemisphereRings; i++ {
+		theta := math.Pi/2 + math.Pi/2*float64(i)/float64(hemisphereRings)
+		rings = append(rings, latheRing{
+			Radius:    math.Sin(theta),
+			Y:         math.Cos(theta) - offset,
+			NormalOut: math.Sin(theta),
+			NormalUp:  math.Cos(theta),
+			V:         (theta + offset*2) / profileLength,
+		})
+	}
+
+	// Make sure the poles are collapsed into points exactly.
+	rings[0].Radius = 0
+	rings[len(rings)-1].Radius = 0
+
+	part.AddTriangles(addLatheStrip([]VertexInfo{}, segments, rings)...)
 
 	mesh.UpdateBounds()
 
 	return mesh
+
 }
 
 // NewPlane creates a new plane Mesh and gives it a new material (suitably named "Plane").
@@ -848,6 +2324,59 @@ func NewPlane() *Mesh {
 
 }
 
+// NewSubdividedPlane creates a new flat plane Mesh with the given width (along the X axis) and depth (along the Z axis), centered on
+// the origin and facing up, and gives it a new material (suitably named "Plane"). Unlike NewPlane(), the plane is subdivided into a
+// grid of subdivisionsX by subdivisionsZ cells (each at least 1), so that effects that move vertices around (like waves or terrain
+// deformation through a Model's VertexTransformFunction) have enough vertices to work with. The UVs stretch across the entire plane.
+func NewSubdividedPlane(width, depth float64, subdivisionsX, subdivisionsZ int) *Mesh {
+
+	if subdivisionsX < 1 {
+		subdivisionsX = 1
+	}
+
+	if subdivisionsZ < 1 {
+		subdivisionsZ = 1
+	}
+
+	mesh := NewMesh("Plane")
+	part := mesh.AddMeshPart(NewMaterial("Plane"))
+
+	vertex := func(x, z int) VertexInfo {
+		u := float64(x) / float64(subdivisionsX)
+		v := float64(z) / float64(subdivisionsZ)
+		vert := NewVertex((u-0.5)*width, 0, (v-0.5)*depth, u, v)
+		vert.NormalY = 1
+		return vert
+	}
+
+	verts := make([]VertexInfo, 0, subdivisionsX*subdivisionsZ*6)
+
+	for z := 0; z < subdivisionsZ; z++ {
+
+		for x := 0; x < subdivisionsX; x++ {
+
+			verts = append(verts,
+				vertex(x+1, z),
+				vertex(x, z),
+				vertex(x+1, z+1),
+
+				vertex(x, z),
+				vertex(x, z+1),
+				vertex(x+1, z+1),
+			)
+
+		}
+
+	}
+
+	part.AddTriangles(verts...)
+
+	mesh.UpdateBounds()
+
+	return mesh
+
+}
+
 func NewWeirdDebuggingStatueThing() *Mesh {
 
 	mesh := NewMesh("Weird Statue")
@@ -882,9 +2411,11 @@ func NewWeirdDebuggingStatueThing() *Mesh {
 // sortingTriangle is used specifically for sorting triangles when rendering. Less data means more data fits in cache,
 // which means sorting is faster.
 type sortingTriangle struct {
-	ID       int
-	depth    float32
-	rendered bool
+	ID         int
+	depth      float32
+	rendered   bool
+	backfacing bool
+	clipIndex  int // Index of the triangle's near-plane clipping results in Camera.nearClipTriangles; -1 if it wasn't clipped
 }
 
 // A Triangle represents the smallest renderable object in Tetra3D. A triangle contains very little data, and is mainly used to help identify triads of vertices.
@@ -1019,8 +2550,21 @@ type MeshPart struct {
 	TriangleStart    int
 	TriangleEnd      int
 	sortingTriangles []sortingTriangle
+
+	// OnRender is an optional callback that is called just before (with stage being RenderStageBefore) and just after (with stage being
+	// RenderStageAfter) the MeshPart's triangles are drawn by a Camera, in render order. model is the Model being rendered, and vertices
+	// contains the screen-space vertices of the triangles to be drawn (three per triangle); these can be modified in the
+	// RenderStageBefore call to alter the draw. If the Model is the owner of a dynamic batch, vertices contains the triangles of all
+	// of the batched Models as well. OnRender isn't called if none of the MeshPart's triangles are to be drawn. If the triangles don't
+	// all fit in one draw (for example, because clipping them against the near plane created more), OnRender is called for each draw.
+	OnRender func(camera *Camera, model *Model, part *MeshPart, stage int, vertices []ebiten.Vertex)
 }
 
+const (
+	RenderStageBefore = iota // The MeshPart's triangles are about to be drawn
+	RenderStageAfter         // The MeshPart's triangles have just been drawn
+)
+
 // NewMeshPart creates a new MeshPart that renders using the specified Material.
 func NewMeshPart(mesh *Mesh, material *Material) *MeshPart {
 	return &MeshPart{
@@ -1039,6 +2583,7 @@ func (part *MeshPart) Clone() *MeshPart {
 		Material:      part.Material,
 		TriangleStart: part.TriangleStart,
 		TriangleEnd:   part.TriangleEnd,
+		OnRender:      part.OnRender,
 	}
 
 	for i := 0; i < len(part.sortingTriangles); i++ {
@@ -1076,22 +2621,46 @@ func (part *MeshPart) AddTriangles(verts ...VertexInfo) {
 		part.Mesh.allocateVertexBuffers(part.Mesh.VertexMax + len(verts))
 	}
 
+	// Rather than allocating each vertex's vectors individually, we allocate one backing buffer for all of them and slice
+	// it up (capping each vector's capacity so appending to one can't overwrite another).
+	vectorBuffer := make([]float64, len(verts)*16)
+	bufferIndex := 0
+
+	nextVector := func(size int) vector.Vector {
+		vec := vectorBuffer[bufferIndex : bufferIndex+size : bufferIndex+size]
+		bufferIndex += size
+		return vec
+	}
+
 	for i := 0; i < len(verts); i += 3 {
 
 		for j := 0; j < 3; j++ {
 			vertInfo := verts[i+j]
 			index := (mesh.triIndex * 3) + j
-			mesh.VertexPositions[index] = vector.Vector{vertInfo.X, vertInfo.Y, vertInfo.Z}
-			mesh.VertexNormals[index] = vector.Vector{vertInfo.NormalX, vertInfo.NormalY, vertInfo.NormalZ}
-			mesh.VertexUVs[index] = vector.Vector{vertInfo.U, vertInfo.V}
+
+			position := nextVector(3)
+			position[0], position[1], position[2] = vertInfo.X, vertInfo.Y, vertInfo.Z
+			mesh.VertexPositions[index] = position
+
+			normal := nextVector(3)
+			normal[0], normal[1], normal[2] = vertInfo.NormalX, vertInfo.NormalY, vertInfo.NormalZ
+			mesh.VertexNormals[index] = normal
+
+			uv := nextVector(2)
+			uv[0], uv[1] = vertInfo.U, vertInfo.V
+			mesh.VertexUVs[index] = uv
+
+			uv2 := nextVector(2)
+			uv2[0], uv2[1] = vertInfo.U2, vertInfo.V2
+			mesh.VertexUV2s[index] = uv2
+
 			mesh.VertexColors[index] = vertInfo.Colors
 			mesh.VertexActiveColorChannel[index] = vertInfo.ActiveColorChannel
 			mesh.VertexBones[index] = vertInfo.Bones
 			mesh.VertexWeights[index] = vertInfo.Weights
 
-			mesh.vertexTransforms[index] = vector.Vector{0, 0, 0, 0}
-			mesh.vertexSkinnedNormals[index] = vector.Vector{0, 0, 0}
-			mesh.vertexSkinnedPositions[index] = vector.Vector{0, 0, 0}
+			mesh.vertexSkinnedNormals[index] = nextVector(3)
+			mesh.vertexSkinnedPositions[index] = nextVector(3)
 		}
 
 		newTri := NewTriangle(part, mesh.triIndex)
@@ -1118,9 +2687,52 @@ func (part *MeshPart) AddTriangles(verts ...VertexInfo) {
 
 }
 
+// triangleSortEpsilon is the depth range within which triangles are considered to be equally distant from the camera when sorting;
+// such triangles are ordered by their IDs instead so that they don't flicker as they're rendered from frame to frame.
+const triangleSortEpsilon = 0.001
+
+// sortTriangles sorts the given triangles of the MeshPart for rendering according to the Material's TriangleSortMode.
+func (part *MeshPart) sortTriangles(triangles []sortingTriangle) {
+
+	sortMode := TriangleSortModeBackToFront
+
+	if part.Material != nil {
+		sortMode = part.Material.TriangleSortMode
+	}
+
+	if sortMode == TriangleSortModeNone {
+		return
+	}
+
+	// Depths are compared in buckets of triangleSortEpsilon rather than with a simple epsilon check so that the comparison stays
+	// transitive; triangles in the same bucket are then sorted by their ID so that their order is always the same, regardless of their
+	// previous order.
+	// Preliminary tests indicate sort.SliceStable is faster than sort.Slice for our purposes
+	sort.SliceStable(triangles, func(i, j int) bool {
+
+		depthI := math.Round(float64(triangles[i].depth) / triangleSortEpsilon)
+		depthJ := math.Round(float64(triangles[j].depth) / triangleSortEpsilon)
+
+		if depthI == depthJ {
+			return triangles[i].ID < triangles[j].ID
+		}
+
+		if sortMode == TriangleSortModeFrontToBack {
+			return depthI < depthJ
+		}
+
+		return depthI > depthJ
+
+	})
+
+}
+
 // TriangleCount returns the total number of triangles in the MeshPart, specifically.
 func (part *MeshPart) TriangleCount() int {
-	return part.TriangleEnd - part.TriangleStart + 1
+	if part.TriangleStart < 0 {
+		return 0
+	}
+	return part.TriangleEnd - part.TriangleStart
 }
 
 // ApplyMatrix applies a transformation matrix to the vertices referenced by the MeshPart.
@@ -1141,6 +2753,7 @@ type VertexInfo struct {
 	ID                        int
 	X, Y, Z                   float64
 	U, V                      float64
+	U2, V2                    float64 // The vertex's second set of UV values, used for lightmaps
 	NormalX, NormalY, NormalZ float64
 	Weights                   []float32
 	Colors                    []*Color
@@ -1164,3 +2777,54 @@ func NewVertex(x, y, z, u, v float64) VertexInfo {
 		Bones:              []uint16{},
 	}
 }
+
+// clone returns a deep copy of the VertexInfo, so that its colors, bones, and weights aren't shared with the original.
+func (vi VertexInfo) clone() VertexInfo {
+
+	newVert := vi
+
+	newVert.Colors = make([]*Color, 0, len(vi.Colors))
+	for _, c := range vi.Colors {
+		newVert.Colors = append(newVert.Colors, c.Clone())
+	}
+
+	newVert.Weights = append([]float32{}, vi.Weights...)
+	newVert.Bones = append([]uint16{}, vi.Bones...)
+
+	return newVert
+
+}
+
+// vertexInfoMidpoint returns a new VertexInfo that lies halfway between the a and b VertexInfos. Positions, UVs, normals, and
+// vertex colors are interpolated, while bones and weights (which can't really be blended) are taken from a.
+func vertexInfoMidpoint(a, b VertexInfo) VertexInfo {
+
+	// We add and then halve (rather than use a + (b - a) / 2) so that the midpoint of an edge is the same regardless of the order
+	// of its vertices, which helps Mesh.Smooth() recognize the vertices as being identical.
+	mid := NewVertex((a.X+b.X)/2, (a.Y+b.Y)/2, (a.Z+b.Z)/2, (a.U+b.U)/2, (a.V+b.V)/2)
+	mid.U2 = (a.U2 + b.U2) / 2
+	mid.V2 = (a.V2 + b.V2) / 2
+
+	normal := vector.Vector{(a.NormalX + b.NormalX) / 2, (a.NormalY + b.NormalY) / 2, (a.NormalZ + b.NormalZ) / 2}
+	if normal.Magnitude() > 0 {
+		normal = normal.Unit()
+	}
+	mid.NormalX = normal[0]
+	mid.NormalY = normal[1]
+	mid.NormalZ = normal[2]
+
+	for i, c := range a.Colors {
+		newColor := c.Clone()
+		if i < len(b.Colors) {
+			newColor.Mix(b.Colors[i], 0.5)
+		}
+		mid.Colors = append(mid.Colors, newColor)
+	}
+
+	mid.ActiveColorChannel = a.ActiveColorChannel
+	mid.Weights = append(mid.Weights, a.Weights...)
+	mid.Bones = append(mid.Bones, a.Bones...)
+
+	return mid
+
+}
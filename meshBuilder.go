@@ -0,0 +1,253 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/kvartborg/vector"
+)
+
+// MeshBuilder is a helper to procedurally build Meshes out of triangles, quads, fans, and extruded outlines (i.e. for runtime-generated
+// dungeons, roads, or debris). It works a bit like immediate-mode rendering - the MeshBuilder has a current Material, UV, and color
+// (set with SetMaterial(), SetUV(), and SetColor()), and every vertex added uses whatever the current state is at the time. Triangles
+// are grouped into MeshParts by Material. Once you're done, call Build() to create the finished Mesh.
+//
+// Shapes should be wound counter-clockwise when viewed from the side they should face.
+type MeshBuilder struct {
+	Name string
+
+	// If the finished Mesh should have smooth normals, where vertices sharing the same position have their normals averaged together.
+	// Otherwise, each vertex uses the normal of the triangle it's a part of. Defaults to false.
+	SmoothNormals bool
+
+	uv       [2]float64
+	color    *Color
+	material *Material
+
+	parts   []*meshBuilderPart
+	pending []VertexInfo
+}
+
+type meshBuilderPart struct {
+	material *Material
+	vertices []VertexInfo
+}
+
+// NewMeshBuilder creates a new MeshBuilder, which builds a Mesh with the given name. The MeshBuilder starts off with a new Material
+// (also named after the Mesh), a UV of (0, 0), and no vertex color.
+func NewMeshBuilder(name string) *MeshBuilder {
+	return &MeshBuilder{
+		Name:     name,
+		material: NewMaterial(name),
+		parts:    []*meshBuilderPart{},
+		pending:  []VertexInfo{},
+	}
+}
+
+// SetMaterial sets the Material used for triangles added afterwards. Triangles using the same Material end up in the same MeshPart.
+func (mb *MeshBuilder) SetMaterial(material *Material) *MeshBuilder {
+	mb.material = material
+	return mb
+}
+
+// SetUV sets the UV value used for vertices added afterwards.
+func (mb *MeshBuilder) SetUV(u, v float64) *MeshBuilder {
+	mb.uv = [2]float64{u, v}
+	return mb
+}
+
+// SetColor sets the vertex color used for vertices added afterwards; the color is set in the first vertex color channel, which is
+// made active. Passing nil means vertices added afterwards have no vertex color.
+func (mb *MeshBuilder) SetColor(color *Color) *MeshBuilder {
+	if color != nil {
+		color = color.Clone()
+	}
+	mb.color = color
+	return mb
+}
+
+// AddVertex adds a single vertex at the given position, using the current UV and color. Every three vertices added this way form a
+// triangle; this is useful for when you need control over each vertex's UV or color.
+func (mb *MeshBuilder) AddVertex(position vector.Vector) *MeshBuilder {
+
+	vert := NewVertex(position[0], position[1], position[2], mb.uv[0], mb.uv[1])
+
+	if mb.color != nil {
+		vert.Colors = append(vert.Colors, mb.color.Clone())
+		vert.ActiveColorChannel = 0
+	}
+
+	mb.pending = append(mb.pending, vert)
+
+	if len(mb.pending) == 3 {
+		mb.currentPart().vertices = append(mb.currentPart().vertices, mb.pending...)
+		mb.pending = mb.pending[:0]
+	}
+
+	return mb
+
+}
+
+// currentPart returns the meshBuilderPart for the current Material, creating it if necessary.
+func (mb *MeshBuilder) currentPart() *meshBuilderPart {
+
+	for _, part := range mb.parts {
+		if part.material == mb.material {
+			return part
+		}
+	}
+
+	part := &meshBuilderPart{material: mb.material, vertices: []VertexInfo{}}
+	mb.parts = append(mb.parts, part)
+	return part
+
+}
+
+// AddTriangle adds a triangle with the given corners.
+func (mb *MeshBuilder) AddTriangle(a, b, c vector.Vector) *MeshBuilder {
+	return mb.AddVertex(a).AddVertex(b).AddVertex(c)
+}
+
+// AddQuad adds a quad with the given corners (in order around the quad), made of two triangles.
+func (mb *MeshBuilder) AddQuad(a, b, c, d vector.Vector) *MeshBuilder {
+	return mb.AddTriangle(a, b, c).AddTriangle(a, c, d)
+}
+
+// AddTriangleFan adds a fan of triangles, where the first point is the hub of the fan, and every pair of consecutive points afterwards
+// forms a triangle with the hub (so a fan of N points makes N-2 triangles). To close a fan all the way around the hub, pass the second
+// point again at the end. This is useful for convex polygons and circles.
+func (mb *MeshBuilder) AddTriangleFan(points ...vector.Vector) *MeshBuilder {
+
+	for i := 1; i < len(points)-1; i++ {
+		mb.AddTriangle(points[0], points[i], points[i+1])
+	}
+
+	return mb
+
+}
+
+// Extrude adds walls by sweeping the closed outline given along the offset vector, connecting each pair of consecutive points (as well
+// as the last point back to the first) with a quad. The outline should wind counter-clockwise when viewed from the end that the offset
+// points towards, so the walls face outwards. If capped is true, both ends of the extrusion are closed off with triangle fans, so the
+// outline should also be convex in that case.
+func (mb *MeshBuilder) Extrude(offset vector.Vector, capped bool, outline ...vector.Vector) *MeshBuilder {
+
+	if len(outline) < 2 {
+		return mb
+	}
+
+	top := make([]vector.Vector, 0, len(outline))
+	for _, point := range outline {
+		top = append(top, point.Add(offset))
+	}
+
+	for i := range outline {
+		next := (i + 1) % len(outline)
+		mb.AddQuad(outline[i], outline[next], top[next], top[i])
+	}
+
+	if capped && len(outline) > 2 {
+
+		mb.AddTriangleFan(top...)
+
+		bottom := make([]vector.Vector, 0, len(outline))
+		for i := len(outline) - 1; i >= 0; i-- {
+			bottom = append(bottom, outline[i])
+		}
+
+		mb.AddTriangleFan(bottom...)
+
+	}
+
+	return mb
+
+}
+
+// TriangleCount returns the number of complete triangles added to the MeshBuilder so far.
+func (mb *MeshBuilder) TriangleCount() int {
+	count := 0
+	for _, part := range mb.parts {
+		count += len(part.vertices) / 3
+	}
+	return count
+}
+
+// Clear clears all triangles added to the MeshBuilder so far, allowing it to be reused. The current Material, UV, and color are kept.
+func (mb *MeshBuilder) Clear() {
+	mb.parts = mb.parts[:0]
+	mb.pending = mb.pending[:0]
+}
+
+// Build creates a new Mesh out of the triangles added to the MeshBuilder, with a MeshPart for each Material used. MeshParts that would
+// exceed the maximum renderable triangle count for a single MeshPart (21845 triangles) are split up into multiple MeshParts using the
+// same Material. Vertices added that don't form a complete triangle are ignored. The MeshBuilder can keep being used afterwards without
+// affecting the built Mesh.
+func (mb *MeshBuilder) Build() *Mesh {
+
+	mesh := NewMesh(mb.Name)
+
+	for _, part := range mb.parts {
+
+		verts := make([]VertexInfo, 0, len(part.vertices))
+		for _, v := range part.vertices {
+			verts = append(verts, v.clone())
+		}
+
+		mesh.addSplitMeshParts(part.material, verts)
+
+	}
+
+	mesh.AutoNormal()
+
+	if mb.SmoothNormals {
+		mesh.smoothNormals()
+	}
+
+	mesh.UpdateBounds()
+
+	return mesh
+
+}
+
+// smoothNormals sets the normals of vertices that share the same position to the average of the normals of the triangles they belong to.
+// Each triangle's normal is weighted by the angle of its corner at the vertex, so that how a face is split up into triangles doesn't
+// affect the result.
+func (mesh *Mesh) smoothNormals() {
+
+	normals := map[[3]float64]vector.Vector{}
+
+	key := func(index int) [3]float64 {
+		pos := mesh.VertexPositions[index]
+		return [3]float64{pos[0], pos[1], pos[2]}
+	}
+
+	for _, tri := range mesh.Triangles {
+		indices := tri.VertexIndices()
+		for i, index := range indices {
+
+			pos := mesh.VertexPositions[index]
+			edgeA := mesh.VertexPositions[indices[(i+1)%3]].Sub(pos).Unit()
+			edgeB := mesh.VertexPositions[indices[(i+2)%3]].Sub(pos).Unit()
+			weighted := tri.Normal.Scale(math.Acos(math.Max(-1, math.Min(1, dot(edgeA, edgeB)))))
+
+			k := key(index)
+			if normal, exists := normals[k]; exists {
+				normals[k] = normal.Add(weighted)
+			} else {
+				normals[k] = weighted
+			}
+
+		}
+	}
+
+	for _, tri := range mesh.Triangles {
+		for _, index := range tri.VertexIndices() {
+			// Normals of opposing triangles can cancel each other out, in which case we stick with the triangle's normal.
+			if normal := normals[key(index)]; normal.Magnitude() > 0.0001 {
+				mesh.VertexNormals[index] = normal.Unit()
+			} else {
+				mesh.VertexNormals[index] = tri.Normal.Clone()
+			}
+		}
+	}
+
+}
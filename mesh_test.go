@@ -0,0 +1,751 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/kvartborg/vector"
+)
+
+func TestTriangleSortingIsStableForEqualDepths(t *testing.T) {
+
+	// The second quad is either exactly on the first, or just barely below it (and so, very slightly farther from the Camera).
+	for _, offset := range []float64{0, -0.00002} {
+
+		mesh := NewMesh("overlapping quads")
+		mat := NewMaterial("quads")
+		mat.Shadeless = true
+		mat.TransparencyMode = TransparencyModeTransparent
+		part := mesh.AddMeshPart(mat)
+
+		// Two transparent quads, one on top of the other; the first is red and the second blue, so that they can be told apart when drawn.
+		for q, c := range []*Color{NewColor(1, 0, 0, 0.5), NewColor(0, 0, 1, 0.5)} {
+
+			y := float64(q) * offset
+
+			verts := []VertexInfo{
+				NewVertex(1, y, -1, 1, 0),
+				NewVertex(-1, y, -1, 0, 0),
+				NewVertex(1, y, 1, 1, 1),
+
+				NewVertex(-1, y, -1, 0, 0),
+				NewVertex(-1, y, 1, 0, 1),
+				NewVertex(1, y, 1, 1, 1),
+			}
+
+			for i := range verts {
+				verts[i].Colors = []*Color{c.Clone()}
+				verts[i].ActiveColorChannel = 0
+			}
+
+			part.AddTriangles(verts...)
+
+		}
+
+		mesh.UpdateBounds()
+
+		// Turning the quads so that a corner faces the Camera puts each quad's two triangles at different depths.
+		model := NewModel(mesh, "quads")
+		model.Rotate(0, 1, 0, math.Pi/4)
+
+		scene := NewScene("overlapping quads")
+		scene.Root.AddChildren(model)
+
+		// Whether each triangle drawn was red, in the order they were drawn, and the depths of the red and blue triangles.
+		drawnRed := []bool{}
+		depths := map[int]float32{}
+
+		part.OnRender = func(camera *Camera, model *Model, part *MeshPart, stage int, vertices []ebiten.Vertex) {
+			if stage == RenderStageBefore {
+				drawnRed = drawnRed[:0]
+				for i := 0; i < len(vertices); i += 3 {
+					drawnRed = append(drawnRed, vertices[i].ColorR > vertices[i].ColorB)
+				}
+				for _, tri := range camera.sortingTriangles {
+					depths[tri.ID] = tri.depth
+				}
+			}
+		}
+
+		camera := NewCamera(64, 64)
+		camera.Move(0.5, 3, 3)
+		camera.Rotate(1, 0, 0, -math.Pi/4)
+
+		for frame := 0; frame < 10; frame++ {
+
+			// Moving the Camera changes the triangles' depths, but each of the first quad's triangles stays at (or very nearly at) the
+			// same depth as the matching triangle of the second quad.
+			camera.Move(0.01, 0, float64(frame%3)*0.001)
+
+			camera.Clear()
+			camera.RenderNodes(scene, scene.Root)
+
+			// Each pair of triangles at the same depth is drawn together; in each pair, the first quad's triangle has the lower ID, and
+			// so is always drawn first, even when the second quad's triangle is a hair farther away.
+			if len(drawnRed) != 4 || !drawnRed[0] || drawnRed[1] || !drawnRed[2] || drawnRed[3] {
+				t.Fatalf("offset %f, frame %d: expected the quads' triangles to be drawn red, then blue, for each pair of triangles at the same depth; got %v (true being red)", offset, frame, drawnRed)
+			}
+
+			if offset != 0 && (depths[0] == depths[2] || depths[1] == depths[3]) {
+				t.Fatalf("offset %f, frame %d: the quads' triangles should be at slightly different depths; got %v", offset, frame, depths)
+			}
+
+		}
+
+	}
+
+}
+
+func TestRecalculateBoundsTight(t *testing.T) {
+
+	for _, mesh := range []*Mesh{NewCube(), NewIcosphere(1)} {
+
+		_, extentRadius := mesh.boundingSphere()
+
+		mesh.RecalculateBoundsTight()
+
+		center, tightRadius := mesh.boundingSphere()
+
+		if tightRadius > extentRadius+0.0001 {
+			t.Errorf("%s: tight radius %f is larger than the extent-based radius %f", mesh.Name, tightRadius, extentRadius)
+		}
+
+		for i := 0; i < mesh.VertexCount; i++ {
+			if dist := Distance(center, mesh.VertexPositions[i]); dist > tightRadius+0.0001 {
+				t.Fatalf("%s: vertex %d lies outside of the tight bounding sphere (distance %f, radius %f)", mesh.Name, i, dist, tightRadius)
+			}
+		}
+
+		t.Logf("%s: extent-based radius: %f, tight radius: %f", mesh.Name, extentRadius, tightRadius)
+
+	}
+
+	// An icosphere's vertices all lie on a unit sphere, so the tight radius should be much smaller than half of its bounding box's diagonal.
+	ico := NewIcosphere(1)
+	_, extentRadius := ico.boundingSphere()
+	ico.RecalculateBoundsTight()
+
+	if _, tightRadius := ico.boundingSphere(); tightRadius > extentRadius*0.75 {
+		t.Errorf("expected the icosphere's tight radius (%f) to be much smaller than its extent-based radius (%f)", tightRadius, extentRadius)
+	}
+
+	ico.UpdateBounds()
+
+	if _, radius := ico.boundingSphere(); radius != extentRadius {
+		t.Errorf("UpdateBounds() should discard the tight bounding sphere")
+	}
+
+}
+
+func TestMeshOptimize(t *testing.T) {
+
+	mesh := NewCube()
+
+	unique := mesh.Optimize()
+
+	if !mesh.Indexed || len(mesh.Indices) != mesh.VertexCount {
+		t.Fatalf("Optimize() should build an index buffer for every vertex and turn on indexed mode")
+	}
+
+	if unique <= 0 || unique >= mesh.VertexCount {
+		t.Fatalf("expected a cube's %d vertices to be reduced to fewer unique vertices, got %d", mesh.VertexCount, unique)
+	}
+
+	for v, index := range mesh.Indices {
+
+		if index > v || mesh.Indices[index] != index {
+			t.Fatalf("vertex %d should map to the first identical vertex, but maps to %d (which maps to %d)", v, index, mesh.Indices[index])
+		}
+
+		if string(mesh.appendVertexKey(nil, v)) != string(mesh.appendVertexKey(nil, index)) {
+			t.Fatalf("vertex %d was mapped to vertex %d, but they aren't identical", v, index)
+		}
+
+	}
+
+	first := append([]int{}, mesh.Indices...)
+
+	if mesh.Optimize() != unique {
+		t.Fatal("optimizing again should give the same number of unique vertices")
+	}
+
+	for i := range first {
+		if first[i] != mesh.Indices[i] {
+			t.Fatal("optimizing again should give the same index buffer")
+		}
+	}
+
+	if clone := mesh.Clone(); !clone.Indexed || len(clone.Indices) != len(mesh.Indices) {
+		t.Fatal("cloning an indexed Mesh should clone its index buffer")
+	}
+
+	mesh.MeshParts[0].AddTriangles(NewVertex(0, 0, 0, 0, 0), NewVertex(1, 0, 0, 0, 0), NewVertex(0, 1, 0, 0, 0))
+
+	if mesh.Indexed || mesh.Indices != nil {
+		t.Fatal("adding triangles should turn indexed mode off, as the index buffer is out of date")
+	}
+
+}
+
+func TestSpherePrimitives(t *testing.T) {
+
+	for _, mesh := range []*Mesh{NewSphere(8, 16), NewIcosphere(1)} {
+
+		for _, tri := range mesh.Triangles {
+
+			if dot(tri.Center, tri.Normal) <= 0 {
+				t.Fatalf("%s: triangle %d faces inwards", mesh.Name, tri.ID)
+			}
+
+			indices := tri.VertexIndices()
+
+			minU, maxU := 10.0, -10.0
+
+			for _, i := range indices {
+
+				position := mesh.VertexPositions[i]
+
+				if !vectorsEqual(mesh.VertexNormals[i], position) || math.Abs(position.Magnitude()-1) > 0.0001 {
+					t.Fatalf("%s: vertex %d should lie on the unit sphere with a smooth normal pointing outwards", mesh.Name, i)
+				}
+
+				uv := mesh.VertexUVs[i]
+				if uv[1] < 0 || uv[1] > 1 {
+					t.Fatalf("%s: vertex %d has a V value outside of 0 - 1: %f", mesh.Name, i, uv[1])
+				}
+
+				minU = math.Min(minU, uv[0])
+				maxU = math.Max(maxU, uv[0])
+
+			}
+
+			// Triangles crossing the UV seam shouldn't stretch across the whole texture.
+			if maxU-minU > 0.5 {
+				t.Fatalf("%s: triangle %d's UVs span %f horizontally", mesh.Name, tri.ID, maxU-minU)
+			}
+
+		}
+
+	}
+
+	if count := len(NewSphere(8, 16).Triangles); count != 16*2+16*6*2 {
+		t.Errorf("expected a sphere with 8 rings and 16 segments to have %d triangles, got %d", 16*2+16*6*2, count)
+	}
+
+}
+
+func TestLathePrimitives(t *testing.T) {
+
+	tests := []struct {
+		mesh   *Mesh
+		width  float64
+		height float64
+	}{
+		{NewCylinder(12, 3, true), 2, 3},
+		{NewCylinder(12, 3, false), 2, 3},
+		{NewCone(12), 2, 2},
+		{NewCapsule(16, 5), 2, 5},
+		{NewCapsule(16, 1), 2, 2},
+	}
+
+	for _, test := range tests {
+
+		mesh := test.mesh
+
+		for _, tri := range mesh.Triangles {
+
+			if math.IsNaN(tri.Normal[0]) || dot(tri.Center, tri.Normal) <= 0 {
+				t.Fatalf("%s: triangle %d faces inwards or is degenerate", mesh.Name, tri.ID)
+			}
+
+			for _, i := range tri.VertexIndices() {
+				if dot(mesh.VertexNormals[i], tri.Normal) <= 0 {
+					t.Fatalf("%s: vertex %d's normal faces away from its triangle", mesh.Name, i)
+				}
+			}
+
+		}
+
+		dim := mesh.Dimensions
+
+		if math.Abs(dim.Width()-test.width) > 0.0001 || math.Abs(dim.Height()-test.height) > 0.0001 {
+			t.Errorf("%s: expected dimensions of %f x %f, got %f x %f", mesh.Name, test.width, test.height, dim.Width(), dim.Height())
+		}
+
+	}
+
+}
+
+func TestSubdividedPlane(t *testing.T) {
+
+	plane := NewSubdividedPlane(4, 2, 8, 3)
+
+	if count := len(plane.Triangles); count != 8*3*2 {
+		t.Errorf("expected %d triangles, got %d", 8*3*2, count)
+	}
+
+	for _, tri := range plane.Triangles {
+		if !vectorsEqual(tri.Normal, vector.Vector{0, 1, 0}) {
+			t.Fatalf("triangle %d doesn't face up", tri.ID)
+		}
+	}
+
+	if dim := plane.Dimensions; dim.Width() != 4 || dim.Depth() != 2 || dim.Height() != 0 {
+		t.Errorf("expected dimensions of 4 x 0 x 2, got %f x %f x %f", dim.Width(), dim.Height(), dim.Depth())
+	}
+
+}
+
+func TestPaintVertexColors(t *testing.T) {
+
+	// A 4 x 4 plane with a vertex every unit, painted through a Model moved 10 units along X.
+	model := NewModel(NewSubdividedPlane(4, 4, 4, 4), "canvas")
+	model.Move(10, 0, 0)
+
+	mesh := model.Mesh
+	red := NewColor(1, 0, 0, 1)
+	center := vector.Vector{10, 0, 0}
+
+	if painted := mesh.PaintVertexColors(center, 1.5, red, 1, 1, NewMatrix4()); painted != 0 {
+		t.Fatalf("the brush shouldn't reach the Mesh's vertices without the Model's transform; %d vertices were painted", painted)
+	}
+
+	// Vertices are stored per triangle corner, so each grid point within reach is painted once per triangle it's a part of.
+	expected := 0
+	for i := 0; i < mesh.VertexCount; i++ {
+		if mesh.VertexPositions[i].Magnitude() <= 1.5 {
+			expected++
+		}
+	}
+
+	if painted := mesh.PaintVertexColors(center, 1.5, red, 1, 1, model.Transform()); painted != expected {
+		t.Fatalf("expected %d vertices to be painted, got %d", expected, painted)
+	}
+
+	for i := 0; i < mesh.VertexCount; i++ {
+
+		c := mesh.VertexColors[i][1]
+		distance := mesh.VertexPositions[i].Magnitude()
+
+		// The brush's strength falls off linearly from the center, mixing the existing white towards red.
+		strength := float32(math.Max(1-distance/1.5, 0))
+
+		if math.Abs(float64(c.R-1)) > 1e-5 || math.Abs(float64(c.G-(1-strength))) > 1e-5 || math.Abs(float64(c.B-(1-strength))) > 1e-5 {
+			t.Fatalf("vertex %d, %f units from the brush's center, should have been painted with a strength of %f; got %v", i, distance, strength, c)
+		}
+
+	}
+
+	if len(mesh.VertexColors[0]) != 2 {
+		t.Fatalf("painting into channel 1 should have created it (and channel 0) for every vertex")
+	}
+
+	if painted := mesh.PaintVertexColors(center, 1.5, red, 1, 0, model.Transform()); painted != 0 {
+		t.Fatalf("painting with no strength shouldn't paint anything; %d vertices were painted", painted)
+	}
+
+}
+
+func TestColorByHeight(t *testing.T) {
+
+	// Vertices below, within, and above the 0 - 2 height range.
+	heights := []float64{-3, 0, 1, 1.5, 2, 5}
+
+	mesh := NewMesh("heights")
+	verts := []VertexInfo{}
+	for i, y := range heights {
+		verts = append(verts, NewVertex(float64(i), y, 0, 0, 0))
+	}
+	mesh.AddMeshPart(NewMaterial("heights")).AddTriangles(verts...)
+
+	// A transparent red at the bottom, fading to an opaque blue at the top.
+	curve := NewColorCurve()
+	curve.AddRGBA(1, 0, 0, 0, 0)
+	curve.AddRGBA(0, 0, 1, 1, 1)
+
+	mesh.ColorByHeight(curve, 0, 2, 1)
+
+	if len(mesh.VertexColors[0]) != 2 {
+		t.Fatalf("coloring channel 1 should have created it (and channel 0) for every vertex")
+	}
+
+	for i, y := range heights {
+
+		perc := float32(math.Min(math.Max(y/2, 0), 1))
+		expected := NewColor(1-perc, 0, perc, perc)
+		c := mesh.VertexColors[i][1]
+
+		if math.Abs(float64(c.R-expected.R)) > 1e-5 || math.Abs(float64(c.G-expected.G)) > 1e-5 || math.Abs(float64(c.B-expected.B)) > 1e-5 || math.Abs(float64(c.A-expected.A)) > 1e-5 {
+			t.Fatalf("vertex %d at a height of %f should have been colored %v, got %v", i, y, expected, c)
+		}
+
+		if white := mesh.VertexColors[i][0]; white.R != 1 || white.G != 1 || white.B != 1 || white.A != 1 {
+			t.Fatalf("coloring channel 1 shouldn't alter channel 0 of vertex %d; got %v", i, white)
+		}
+
+	}
+
+	// With no height range, vertices at or above it take the end of the curve, and vertices below it the start.
+	mesh.ColorByHeight(curve, 1, 1, 0)
+
+	for i, y := range heights {
+
+		expected := NewColor(1, 0, 0, 0)
+		if y >= 1 {
+			expected = NewColor(0, 0, 1, 1)
+		}
+
+		if c := mesh.VertexColors[i][0]; c.R != expected.R || c.G != expected.G || c.B != expected.B || c.A != expected.A {
+			t.Fatalf("vertex %d at a height of %f should have been colored %v with no height range, got %v", i, y, expected, c)
+		}
+
+	}
+
+}
+
+func TestVertexSelection(t *testing.T) {
+
+	cube := NewCube()
+
+	if top := cube.SelectVertices().SelectNormalDirection(vector.Vector{0, 1, 0}, 0.1).Triangles(); len(top) != 2 {
+		t.Fatalf("expected the top 2 triangles of the cube to be selected, got %d", len(top))
+	}
+
+	// The vertices in the box are those of the cube's +X face, but only the triangles on that face have all three of them.
+	selection := cube.SelectVertices().SelectInBox(vector.Vector{0.5, -2, -2}, vector.Vector{2, 2, 2})
+
+	if len(selection.Triangles()) != 2 {
+		t.Fatalf("expected the 2 triangles of the +X face to be fully selected, got %d", len(selection.Triangles()))
+	}
+
+	for _, tri := range selection.Triangles() {
+		if !vectorsEqual(tri.Normal, vector.Vector{1, 0, 0}) {
+			t.Fatalf("expected triangle %d to face +X, got %v", tri.ID, tri.Normal)
+		}
+	}
+
+	if all := cube.SelectVertices().SelectMaterial("Cube"); len(all.Indices) != cube.VertexCount || len(all.Triangles()) != 12 {
+		t.Fatalf("selecting the cube's material should select all of its vertices")
+	}
+
+	selection.Move(1, 0, 0)
+
+	if cube.Dimensions[1][0] != 2 || selection.Triangles()[0].Center[0] != 2 {
+		t.Fatalf("moving the selection should have updated the cube's bounds and triangle centers")
+	}
+
+	cube.Subdivide(1)
+
+	if selection.Valid() {
+		t.Fatalf("subdividing the cube should have invalidated the selection")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("using an invalidated selection should panic")
+		}
+	}()
+
+	selection.SelectAll()
+
+}
+
+// newTentMesh creates a square pyramid without a base - four triangles fanning out from a peak at (0, 1, 0) to the corners of a 2x2 square
+// on the ground. All of its vertex normals point straight up, as though it were smooth-shaded.
+func newTentMesh() *Mesh {
+
+	mesh := NewMesh("tent")
+	part := mesh.AddMeshPart(NewMaterial("tent"))
+
+	ring := []vector.Vector{{1, 0, 1}, {1, 0, -1}, {-1, 0, -1}, {-1, 0, 1}}
+
+	for i := range ring {
+		a, b := ring[i], ring[(i+1)%len(ring)]
+		part.AddTriangles(NewVertex(0, 1, 0, 0, 0), NewVertex(a[0], a[1], a[2], 0, 0), NewVertex(b[0], b[1], b[2], 0, 0))
+	}
+
+	for _, normal := range mesh.VertexNormals {
+		normal[0], normal[1], normal[2] = 0, 1, 0
+	}
+
+	mesh.UpdateBounds()
+
+	return mesh
+
+}
+
+func TestSubdivide(t *testing.T) {
+
+	mesh := newTentMesh()
+	peak := vector.Vector{0, 1, 0}
+
+	mesh.Subdivide(1)
+
+	if len(mesh.Triangles) != 16 || mesh.VertexCount != 48 {
+		t.Fatalf("subdividing 4 triangles once should result in 16 triangles and 48 vertices, got %d and %d", len(mesh.Triangles), mesh.VertexCount)
+	}
+
+	// The 5 original positions are kept, and the 8 edges each gain a midpoint.
+	positions := map[[3]float64]bool{}
+	for _, p := range mesh.VertexPositions {
+		positions[[3]float64{p[0], p[1], p[2]}] = true
+	}
+
+	for _, p := range [][3]float64{
+		{0, 1, 0}, {1, 0, 1}, {1, 0, -1}, {-1, 0, -1}, {-1, 0, 1},
+		{0.5, 0.5, 0.5}, {0.5, 0.5, -0.5}, {-0.5, 0.5, -0.5}, {-0.5, 0.5, 0.5},
+		{1, 0, 0}, {0, 0, -1}, {-1, 0, 0}, {0, 0, 1},
+	} {
+		if !positions[p] {
+			t.Fatalf("expected a vertex at %v after subdividing", p)
+		}
+	}
+
+	if len(positions) != 13 {
+		t.Fatalf("expected 13 unique vertex positions after subdividing, got %d", len(positions))
+	}
+
+	if !vectorsEqual(mesh.Dimensions[0], vector.Vector{-1, 0, -1}) || !vectorsEqual(mesh.Dimensions[1], vector.Vector{1, 1, 1}) {
+		t.Fatalf("subdividing shouldn't change the shape of the Mesh, but its dimensions changed to %v", mesh.Dimensions)
+	}
+
+	for i, normal := range mesh.VertexNormals {
+
+		position := mesh.VertexPositions[i]
+
+		// The midpoints of the ground edges are only part of one side of the tent, so they face the way that side does
+		// rather than straight up, as interpolating their normals would have them.
+		if position[1] == 0 && (position[0] == 0 || position[2] == 0) {
+			expected := vector.Vector{position[0], 1, position[2]}.Unit()
+			if normal.Sub(expected).Magnitude() > 1e-6 {
+				t.Fatalf("expected the vertex at %v to face %v after subdividing, got %v", position, expected, normal)
+			}
+		}
+
+		// The peak is shared equally by all four sides, so it faces straight up.
+		if vectorsEqual(position, peak) && normal.Sub(vector.Vector{0, 1, 0}).Magnitude() > 1e-6 {
+			t.Fatalf("expected the peak to face straight up after subdividing, got %v", normal)
+		}
+
+	}
+
+	mesh.Subdivide(2)
+
+	if len(mesh.Triangles) != 256 || mesh.VertexCount != 768 {
+		t.Fatalf("subdividing 16 triangles twice should result in 256 triangles and 768 vertices, got %d and %d", len(mesh.Triangles), mesh.VertexCount)
+	}
+
+}
+
+func TestSmooth(t *testing.T) {
+
+	mesh := newTentMesh()
+	mesh.Smooth(1, 0.5)
+
+	// The peak's neighbors average out to the origin, while each corner's neighbors (the peak and the two adjacent corners) average
+	// out to (0, 1/3, 0); with a factor of 0.5, each vertex moves halfway there.
+	for i, position := range mesh.VertexPositions {
+
+		var expected vector.Vector
+		if i%3 == 0 {
+			expected = vector.Vector{0, 0.5, 0}
+		} else {
+			expected = vector.Vector{position[0], 1.0 / 6, position[2]}
+			if math.Abs(position[0]) != 0.5 || math.Abs(position[2]) != 0.5 {
+				t.Fatalf("expected the corner at vertex %d to move halfway towards the middle of the tent, got %v", i, position)
+			}
+		}
+
+		if position.Sub(expected).Magnitude() > 1e-6 {
+			t.Fatalf("expected vertex %d to be smoothed to %v, got %v", i, expected, position)
+		}
+
+	}
+
+	if !vectorsEqual(mesh.Dimensions[0], vector.Vector{-0.5, 1.0 / 6, -0.5}) || !vectorsEqual(mesh.Dimensions[1], vector.Vector{0.5, 0.5, 0.5}) {
+		t.Fatalf("smoothing should have updated the Mesh's dimensions, got %v", mesh.Dimensions)
+	}
+
+	for i, normal := range mesh.VertexNormals {
+
+		tri := mesh.Triangles[i/3]
+
+		if !vectorsEqual(tri.Normal, calculateNormal(mesh.VertexPositions[i-i%3], mesh.VertexPositions[i-i%3+1], mesh.VertexPositions[i-i%3+2])) {
+			t.Fatalf("smoothing should have recalculated triangle %d's normal", tri.ID)
+		}
+
+		// The smooth peak faces straight up, while each corner faces halfway between the two sides it's a part of.
+		var expected vector.Vector
+		if i%3 == 0 {
+			expected = vector.Vector{0, 1, 0}
+		} else {
+			// The sides now slope by 2/3 units down for every unit out, so they face along (2/3, 1, 0) and the like.
+			expected = vector.Vector{mesh.VertexPositions[i][0] * 4 / 3, 2, mesh.VertexPositions[i][2] * 4 / 3}.Unit()
+		}
+
+		if normal.Sub(expected).Magnitude() > 1e-6 {
+			t.Fatalf("expected vertex %d to face %v after smoothing, got %v", i, expected, normal)
+		}
+
+	}
+
+	// A flat-shaded Mesh stays flat-shaded, with each vertex facing the way its triangle does.
+	mesh = newTentMesh()
+	mesh.AutoNormal()
+	mesh.Smooth(1, 0.5)
+
+	for i, normal := range mesh.VertexNormals {
+		if tri := mesh.Triangles[i/3]; normal.Sub(tri.Normal).Magnitude() > 1e-6 {
+			t.Fatalf("expected vertex %d to keep facing along its triangle's normal %v after smoothing, got %v", i, tri.Normal, normal)
+		}
+	}
+
+}
+
+func TestVertexSelectionExtrude(t *testing.T) {
+
+	mesh := NewMesh("Floors")
+
+	floor := mesh.AddMeshPart(NewMaterial("Floor"))
+	floor.AddTriangles(
+		NewVertex(1, 0, -1, 1, 0), NewVertex(-1, 0, -1, 0, 0), NewVertex(1, 0, 1, 1, 1),
+		NewVertex(-1, 0, -1, 0, 0), NewVertex(-1, 0, 1, 0, 1), NewVertex(1, 0, 1, 1, 1),
+	)
+
+	other := mesh.AddMeshPart(NewMaterial("Other"))
+	other.AddTriangles(
+		NewVertex(6, 0, -1, 1, 0), NewVertex(4, 0, -1, 0, 0), NewVertex(6, 0, 1, 1, 1),
+		NewVertex(4, 0, -1, 0, 0), NewVertex(4, 0, 1, 0, 1), NewVertex(6, 0, 1, 1, 1),
+	)
+
+	otherSelection := mesh.SelectVertices().SelectMeshPart(other)
+
+	selection := mesh.SelectVertices().SelectMeshPart(floor)
+	selection.Extrude(1)
+
+	// The square's 4 outer edges each get a wall of 2 triangles, in the floor's MeshPart rather than the one after it.
+	if len(mesh.MeshParts) != 2 || floor.TriangleCount() != 10 || other.TriangleCount() != 2 || len(mesh.Triangles) != 12 {
+		t.Fatalf("expected the walls to be added to the floor's MeshPart; the floor has %d triangles, and the other MeshPart has %d", floor.TriangleCount(), other.TriangleCount())
+	}
+
+	for _, tri := range mesh.Triangles[floor.TriangleStart:floor.TriangleEnd] {
+		if tri.MeshPart != floor {
+			t.Fatalf("triangle %d should belong to the floor's MeshPart", tri.ID)
+		}
+	}
+
+	for _, tri := range mesh.Triangles[other.TriangleStart:other.TriangleEnd] {
+		if tri.MeshPart != other || tri.Center[1] != 0 || tri.Center[0] < 4 {
+			t.Fatalf("the other MeshPart's triangles shouldn't have moved")
+		}
+	}
+
+	if !selection.Valid() || otherSelection.Valid() {
+		t.Fatalf("extruding should keep the extruding selection valid, but invalidate others")
+	}
+
+	extruded := selection.Triangles()
+
+	if len(extruded) != 2 {
+		t.Fatalf("the selection should still select the 2 extruded triangles, got %d", len(extruded))
+	}
+
+	for _, tri := range extruded {
+		if tri.MeshPart != floor || tri.Center[1] != 1 || !vectorsEqual(tri.Normal, vector.Vector{0, 1, 0}) {
+			t.Fatalf("the extruded triangles should have moved up by 1 and still face up")
+		}
+	}
+
+	center := vector.Vector{0, 0.5, 0}
+
+	for _, tri := range mesh.Triangles[floor.TriangleStart+2 : floor.TriangleEnd] {
+
+		if math.Abs(tri.Normal[1]) > 1e-6 || dot(tri.Center.Sub(center), tri.Normal) <= 0 {
+			t.Fatalf("wall triangle %d should face outwards, but has a normal of %v", tri.ID, tri.Normal)
+		}
+
+		for _, index := range tri.VertexIndices() {
+			if !vectorsEqual(mesh.VertexNormals[index], tri.Normal) {
+				t.Fatalf("wall triangle %d should be flat-shaded", tri.ID)
+			}
+		}
+
+	}
+
+	if mesh.Dimensions.Height() != 1 {
+		t.Fatalf("the mesh's bounds should include the extruded triangles; got a height of %f", mesh.Dimensions.Height())
+	}
+
+}
+
+func TestMeshBuilder(t *testing.T) {
+
+	builder := NewMeshBuilder("Box")
+
+	// A unit square around the origin, counter-clockwise when viewed from above.
+	square := []vector.Vector{{-0.5, 0, 0.5}, {0.5, 0, 0.5}, {0.5, 0, -0.5}, {-0.5, 0, -0.5}}
+	builder.Extrude(vector.Vector{0, 1, 0}, true, square...)
+
+	roof := NewMaterial("Roof")
+	builder.SetMaterial(roof).SetColor(NewColor(1, 0, 0, 1))
+	builder.AddTriangleFan(vector.Vector{0, 2, 0}, vector.Vector{-0.5, 1, 0.5}, vector.Vector{0.5, 1, 0.5}, vector.Vector{0.5, 1, -0.5})
+
+	mesh := builder.Build()
+
+	if count := len(mesh.Triangles); count != 14 {
+		t.Fatalf("expected 14 triangles, got %d", count)
+	}
+
+	if len(mesh.MeshParts) != 2 || mesh.MeshParts[1].Material != roof || mesh.MeshParts[1].TriangleCount() != 2 {
+		t.Fatalf("expected the roof triangles to be in their own MeshPart")
+	}
+
+	center := vector.Vector{0, 0.5, 0}
+
+	for _, tri := range mesh.Triangles {
+		if tri.MeshPart == mesh.MeshParts[0] && dot(tri.Center.Sub(center), tri.Normal) <= 0 {
+			t.Fatalf("triangle %d of the extruded box faces inwards", tri.ID)
+		}
+	}
+
+	if len(mesh.VertexColors[mesh.VertexCount-1]) != 1 || len(mesh.VertexColors[0]) != 0 {
+		t.Errorf("expected only the roof's vertices to have vertex colors")
+	}
+
+	builder.Clear()
+	builder.SmoothNormals = true
+	builder.Extrude(vector.Vector{0, 1, 0}, false, square...)
+
+	mesh = builder.Build()
+
+	// The walls' bottom corners are shared by two walls facing different directions, so their smooth normals point diagonally.
+	for i := 0; i < mesh.VertexCount; i++ {
+		normal := mesh.VertexNormals[i]
+		if math.Abs(math.Abs(normal[0])-math.Sqrt2/2) > 0.0001 || math.Abs(math.Abs(normal[2])-math.Sqrt2/2) > 0.0001 {
+			t.Fatalf("expected vertex %d to have a diagonal smoothed normal, got %v", i, normal)
+		}
+	}
+
+}
+
+func TestGenerateTangents(t *testing.T) {
+
+	mesh := NewPlane()
+	mesh.GenerateTangents()
+
+	if len(mesh.VertexTangents) != mesh.VertexCount {
+		t.Fatalf("expected %d tangents, got %d", mesh.VertexCount, len(mesh.VertexTangents))
+	}
+
+	// The plane's U coordinate increases along +X.
+	for i, tangent := range mesh.VertexTangents {
+		if !vectorsEqual(tangent[:3], vector.Vector{1, 0, 0}) {
+			t.Errorf("tangent %d = %v, expected it to point along +X", i, tangent)
+		}
+		if tangent[3] != 1 && tangent[3] != -1 {
+			t.Errorf("tangent %d has a handedness of %f, expected 1 or -1", i, tangent[3])
+		}
+	}
+
+}
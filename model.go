@@ -1,9 +1,8 @@
 package tetra3d
 
 import (
-	"errors"
+	"fmt"
 	"math"
-	"sort"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
@@ -20,8 +19,22 @@ type Model struct {
 	ColorBlendingFunc func(model *Model, meshPart *MeshPart) ebiten.ColorM // A user-customizeable blending function used to color the Model.
 	BoundingSphere    *BoundingSphere
 
-	DynamicBatchModels map[*MeshPart][]*Model // Models that are dynamically merged into this one.
-	DynamicBatchOwner  *Model
+	// MaterialOverrides overrides properties of the Materials of all of the Model's MeshParts when rendering the Model, without
+	// changing the Materials themselves. MeshPartOverrides does the same for individual MeshParts, taking priority over
+	// MaterialOverrides. Note that when the Model is dynamically batched, the texture and shader uniforms used are taken from the
+	// batching Model, while colors are still applied to each batched Model. Both default to nil.
+	MaterialOverrides *MaterialOverrides
+	MeshPartOverrides map[*MeshPart]*MaterialOverrides
+
+	// ShaderUniforms are uniforms passed to the fragment shaders of the Model's Materials (see Material.SetShader()) when rendering
+	// the Model, on top of the uniforms in the Materials' FragmentShaderOptions (replacing any of the same name). This allows each
+	// Model to customize a shared shader, like with a dissolve threshold or a damage flash amount. Uniforms set through
+	// MaterialOverrides take priority over these. Defaults to an empty map.
+	ShaderUniforms map[string]interface{}
+
+	DynamicBatchModels   map[*MeshPart][]*Model // Models that are dynamically merged into this one.
+	DynamicBatchOwner    *Model
+	dynamicBatchMeshPart *MeshPart // The MeshPart of the DynamicBatchOwner this Model is batched under.
 
 	Skinned        bool  // If the model is skinned and this is enabled, the model will tranform its vertices to match the skinning armature (Model.SkinRoot).
 	SkinRoot       INode // The root node of the armature skinning this Model.
@@ -29,10 +42,31 @@ type Model struct {
 	bones          [][]*Node // The bones (nodes) of the Model, assuming it has been skinned. A Mesh's bones slice will point to indices indicating bones in the Model.
 	skinVectorPool *VectorPool
 
+	// SkinningLOD allows skinned Models to update their skinning less often as they get further away from the Camera rendering them,
+	// which can save a lot of CPU time when rendering many skinned Models. If SkinningLOD is nil (the default), skinning is updated
+	// every time the Model is rendered.
+	SkinningLOD *SkinningLOD
+
+	armatureDetached bool // If the Model was detached from its armature when removed from its tree, and so should be reskinned when reassigned
+
+	skinnedPositions []vector.Vector // The last skinned vertex positions for this Model, reused when skinning is skipped
+	skinnedNormals   []vector.Vector // The last skinned vertex normals for this Model, reused when skinning is skipped
+	skinningStates   map[skinningStateKey]*skinningState
+
+	morphMesh        *Mesh           // The Mesh that the Model's morph weights are for
+	morphWeights     []float64       // The Model's weight for each of its Mesh's MorphTargets
+	morphedPositions []vector.Vector // The Mesh's vertex positions after applying the Model's morph weights
+	morphedNormals   []vector.Vector // The Mesh's vertex normals after applying the Model's morph weights
+	morphDirty       bool            // If the morphed vertex positions and normals need to be recalculated
+
 	// A LightGroup indicates if a Model should be lit by a specific group of Lights. This allows you to control the overall lighting of scenes more accurately.
 	// If a Model has no LightGroup, the Model is lit by the lights present in the Scene.
 	LightGroup *LightGroup
 
+	// LightLayers are the light layers the Model receives light from; it's only lit by lights on at least one of these layers, whether
+	// they're in the Scene or in the Model's LightGroup. Defaults to LightLayersAll.
+	LightLayers LightLayers
+
 	// VertexTransformFunction is a function that runs on the world position of each vertex position rendered with the material.
 	// It accepts the vertex position as an argument, along with the index of the vertex in the mesh.
 	// One can use this to simply transform vertices of the mesh on CPU (note that this is, of course, not as performant as
@@ -56,8 +90,10 @@ func NewModel(mesh *Mesh, name string) *Model {
 		Mesh:               mesh,
 		FrustumCulling:     true,
 		Color:              NewColor(1, 1, 1, 1),
+		LightLayers:        LightLayersAll,
 		skinMatrix:         NewMatrix4(),
 		DynamicBatchModels: map[*MeshPart][]*Model{},
+		ShaderUniforms:     map[string]interface{}{},
 	}
 
 	model.Node.onTransformUpdate = model.TransformUpdate
@@ -68,7 +104,7 @@ func NewModel(mesh *Mesh, name string) *Model {
 
 	radius := 0.0
 	if mesh != nil {
-		radius = mesh.Dimensions.MaxSpan() / 2
+		_, radius = mesh.boundingSphere()
 	}
 	model.BoundingSphere = NewBoundingSphere("bounding sphere", radius)
 
@@ -84,14 +120,36 @@ func (model *Model) Clone() INode {
 	newModel.visible = model.visible
 	newModel.Color = model.Color.Clone()
 
+	if model.MaterialOverrides != nil {
+		newModel.MaterialOverrides = model.MaterialOverrides.Clone()
+	}
+
+	for k, v := range model.ShaderUniforms {
+		newModel.ShaderUniforms[k] = v
+	}
+
+	if model.MeshPartOverrides != nil {
+		newModel.MeshPartOverrides = make(map[*MeshPart]*MaterialOverrides, len(model.MeshPartOverrides))
+		for part, overrides := range model.MeshPartOverrides {
+			if overrides != nil {
+				newModel.MeshPartOverrides[part] = overrides.Clone()
+			}
+		}
+	}
+
 	for k := range model.DynamicBatchModels {
 		newModel.DynamicBatchModels[k] = append([]*Model{}, model.DynamicBatchModels[k]...)
 	}
 
 	newModel.DynamicBatchOwner = model.DynamicBatchOwner
+	newModel.dynamicBatchMeshPart = model.dynamicBatchMeshPart
 
 	newModel.Skinned = model.Skinned
 	newModel.SkinRoot = model.SkinRoot
+	if model.SkinningLOD != nil {
+		lod := *model.SkinningLOD
+		newModel.SkinningLOD = &lod
+	}
 	for i := range model.bones {
 		newModel.bones = append(newModel.bones, append([]*Node{}, model.bones[i]...))
 	}
@@ -108,9 +166,15 @@ func (model *Model) Clone() INode {
 		newModel.LightGroup = model.LightGroup.Clone()
 	}
 
+	newModel.LightLayers = model.LightLayers
+
 	newModel.VertexClipFunction = model.VertexClipFunction
 	newModel.VertexTransformFunction = model.VertexTransformFunction
 
+	newModel.morphMesh = model.morphMesh
+	newModel.morphWeights = append([]float64{}, model.morphWeights...)
+	newModel.morphDirty = true
+
 	return newModel
 
 }
@@ -127,15 +191,13 @@ func (model *Model) TransformUpdate() {
 	// To combat this, we save the original local positions of the mesh on export to position the bounding sphere in the
 	// correct location.
 
-	var center vector.Vector
+	center, radius := model.Mesh.boundingSphere()
 
 	// We do this because if a model is skinned and we've parented the model to the armature, then the center is
 	// now from origin relative to the base of the armature on scene export.
 	if model.SkinRoot != nil && model.Skinned && model.parent == model.SkinRoot {
 		parent := model.parent.(*Node)
-		center = model.Mesh.Dimensions.Center().Sub(parent.originalLocalPosition)
-	} else {
-		center = model.Mesh.Dimensions.Center()
+		center = center.Sub(parent.originalLocalPosition)
 	}
 
 	center = rotation.MultVec(center)
@@ -145,16 +207,23 @@ func (model *Model) TransformUpdate() {
 	position[2] += center[2] * scale[2]
 	model.BoundingSphere.SetLocalPositionVec(position)
 
-	dim := model.Mesh.Dimensions.Clone()
-	dim[0][0] *= scale[0]
-	dim[0][1] *= scale[1]
-	dim[0][2] *= scale[2]
+	if model.Mesh.tightBounds {
+		// A sphere scaled non-uniformly becomes an ellipsoid, so we use the largest scale to encompass it.
+		model.BoundingSphere.Radius = radius * math.Max(math.Abs(scale[0]), math.Max(math.Abs(scale[1]), math.Abs(scale[2])))
+	} else {
+
+		dim := model.Mesh.Dimensions.Clone()
+		dim[0][0] *= scale[0]
+		dim[0][1] *= scale[1]
+		dim[0][2] *= scale[2]
 
-	dim[1][0] *= scale[0]
-	dim[1][1] *= scale[1]
-	dim[1][2] *= scale[2]
+		dim[1][0] *= scale[0]
+		dim[1][1] *= scale[1]
+		dim[1][2] *= scale[2]
 
-	model.BoundingSphere.Radius = dim.MaxSpan() / 2
+		model.BoundingSphere.Radius = dim.MaxSpan() / 2
+
+	}
 
 }
 
@@ -179,19 +248,22 @@ func (model *Model) modelAlreadyDynamicallyBatched(batchedModel *Model) bool {
 // of course). Note that unlike StaticMerge(), DynamicBatchAdd works by simply rendering the batched models using the calling Model's first MeshPart's material. By
 // dynamically batching models together, this allows us to not flush between rendering multiple Models, saving a lot of render time, particularly if rendering many
 // low-poly, individual models that have very little variance (i.e. if they all share a single texture).
+// As all MeshParts of the batched Models are rendered using the specified MeshPart, each MeshPart can only batch up to 21845 triangles in total
+// (i.e. all triangles of all Models batched under that MeshPart). If adding a Model would exceed this limit, DynamicBatchAdd returns an error indicating
+// which Model overflowed the batch; Models prior to that one in the batchedModels slice are still added.
 // For more information, see this Wiki page on batching / merging: https://github.com/SolarLune/Tetra3d/wiki/Merging-and-Batching-Draw-Calls
 func (model *Model) DynamicBatchAdd(meshPart *MeshPart, batchedModels ...*Model) error {
 
+	triCount := model.DynamicBatchMeshPartTriangleCount(meshPart)
+
 	for _, other := range batchedModels {
 
 		if model == other || model.modelAlreadyDynamicallyBatched(other) {
 			continue
 		}
 
-		triCount := model.DynamicBatchTriangleCount()
-
 		if triCount+len(other.Mesh.Triangles) > maxTriangleCount {
-			return errors.New("too many triangles in dynamic merge")
+			return fmt.Errorf("error dynamically batching model [%s] into model [%s]: its %d triangles would bring the batch's triangle count to %d, exceeding the maximum of %d triangles for one MeshPart", other.name, model.name, len(other.Mesh.Triangles), triCount+len(other.Mesh.Triangles), maxTriangleCount)
 		}
 
 		if _, exists := model.DynamicBatchModels[meshPart]; !exists {
@@ -200,6 +272,8 @@ func (model *Model) DynamicBatchAdd(meshPart *MeshPart, batchedModels ...*Model)
 
 		model.DynamicBatchModels[meshPart] = append(model.DynamicBatchModels[meshPart], other)
 		other.DynamicBatchOwner = model
+		other.dynamicBatchMeshPart = meshPart
+		triCount += len(other.Mesh.Triangles)
 
 	}
 
@@ -208,34 +282,70 @@ func (model *Model) DynamicBatchAdd(meshPart *MeshPart, batchedModels ...*Model)
 }
 
 // DynamicBatchRemove removes the specified batched Models from the calling Model's dynamic batch slice.
+// Note that this doesn't preserve the order of the remaining batched Models (which doesn't matter, as they're sorted by depth when rendering).
 func (model *Model) DynamicBatchRemove(batched ...*Model) {
+
 	for _, m := range batched {
-		for meshPartName, modelSlice := range model.DynamicBatchModels {
-			for i, existing := range modelSlice {
-				if existing == m {
-					model.DynamicBatchModels[meshPartName][i] = nil
-					model.DynamicBatchModels[meshPartName] = append(model.DynamicBatchModels[meshPartName][:i], model.DynamicBatchModels[meshPartName][i+1:]...)
-					m.DynamicBatchOwner = nil
-					break
-				}
+
+		if m.DynamicBatchOwner != model {
+			continue
+		}
+
+		meshPart := m.dynamicBatchMeshPart
+		modelSlice := model.DynamicBatchModels[meshPart]
+
+		for i, existing := range modelSlice {
+			if existing == m {
+				last := len(modelSlice) - 1
+				modelSlice[i] = modelSlice[last]
+				modelSlice[last] = nil
+				modelSlice = modelSlice[:last]
+				break
 			}
 		}
+
+		if len(modelSlice) == 0 {
+			delete(model.DynamicBatchModels, meshPart)
+		} else {
+			model.DynamicBatchModels[meshPart] = modelSlice
+		}
+
+		m.DynamicBatchOwner = nil
+		m.dynamicBatchMeshPart = nil
+
 	}
 
-	for mp := range model.DynamicBatchModels {
-		if len(model.DynamicBatchModels[mp]) == 0 {
-			delete(model.DynamicBatchModels, mp)
+}
+
+// DynamicBatchClear removes all Models from the calling Model's dynamic batch.
+func (model *Model) DynamicBatchClear() {
+
+	for _, modelSlice := range model.DynamicBatchModels {
+		for _, m := range modelSlice {
+			m.DynamicBatchOwner = nil
+			m.dynamicBatchMeshPart = nil
 		}
 	}
+
+	model.DynamicBatchModels = map[*MeshPart][]*Model{}
+
 }
 
-// DynamicBatchTriangleCount returns the total number of triangles of Models in the calling Model's dynamic batch.
+// DynamicBatchTriangleCount returns the total number of triangles of Models in the calling Model's dynamic batch, across all MeshParts.
 func (model *Model) DynamicBatchTriangleCount() int {
 	count := 0
-	for _, models := range model.DynamicBatchModels {
-		for _, child := range models {
-			count += len(child.Mesh.Triangles)
-		}
+	for meshPart := range model.DynamicBatchModels {
+		count += model.DynamicBatchMeshPartTriangleCount(meshPart)
+	}
+	return count
+}
+
+// DynamicBatchMeshPartTriangleCount returns the total number of triangles of Models dynamically batched under the specified MeshPart of the
+// calling Model. This is the number that must stay under the maximum triangle count for a single MeshPart (21845).
+func (model *Model) DynamicBatchMeshPartTriangleCount(meshPart *MeshPart) int {
+	count := 0
+	for _, child := range model.DynamicBatchModels[meshPart] {
+		count += len(child.Mesh.Triangles)
 	}
 	return count
 }
@@ -244,16 +354,23 @@ func (model *Model) DynamicBatchTriangleCount() int {
 // You can use this to merge several objects initially dynamically placed into the calling Model's mesh, thereby pulling back to a single draw call. Note that models are merged into MeshParts
 // (saving draw calls) based on maximum vertex count and shared materials (so to get any benefit from merging, ensure the merged models share materials; if they all have unique
 // materials, they will be turned into individual MeshParts, thereby forcing multiple draw calls). Also note that as the name suggests, this is static merging, which means that
-// after merging, the new vertices are static - part of the merging Model.
+// after merging, the new vertices are static - part of the merging Model. Merging turns off the Mesh's indexed mode; call Mesh.Optimize()
+// afterwards to rebuild its index buffer if you want shared vertices to only be transformed once.
 // For more information, see this Wiki page on batching / merging: https://github.com/SolarLune/Tetra3d/wiki/Merging-and-Batching-Draw-Calls
 func (model *Model) Merge(models ...*Model) {
 
 	totalSize := 0
+	largestPartSize := 0
 	for _, other := range models {
 		if model == other {
 			continue
 		}
 		totalSize += len(other.Mesh.VertexPositions)
+		for _, otherPart := range other.Mesh.MeshParts {
+			if size := otherPart.TriangleCount() * 3; size > largestPartSize {
+				largestPartSize = size
+			}
+		}
 	}
 
 	if totalSize == 0 {
@@ -264,25 +381,40 @@ func (model *Model) Merge(models ...*Model) {
 		model.Mesh.allocateVertexBuffers(model.Mesh.VertexMax + totalSize)
 	}
 
+	if cap(model.Mesh.Triangles) < len(model.Mesh.Triangles)+totalSize/3 {
+		newTris := make([]*Triangle, len(model.Mesh.Triangles), len(model.Mesh.Triangles)+totalSize/3)
+		copy(newTris, model.Mesh.Triangles)
+		model.Mesh.Triangles = newTris
+	}
+
+	// We reuse the same VertexInfo buffer for all merged MeshParts to avoid allocating for each one.
+	verts := make([]VertexInfo, 0, largestPartSize)
+
+	p, s, r := model.Transform().Decompose()
+	scaleMatrix := NewMatrix4Scale(s[0], s[1], s[2])
+	rTransposed := r.Transposed()
+
 	for _, other := range models {
 
 		if model == other {
 			continue
 		}
 
-		p, s, r := model.Transform().Decompose()
 		op, os, or := other.Transform().Decompose()
 
 		inverted := NewMatrix4Scale(os[0], os[1], os[2])
-		scaleMatrix := NewMatrix4Scale(s[0], s[1], s[2])
 		inverted = inverted.Mult(scaleMatrix)
 
-		inverted = inverted.Mult(r.Transposed().Mult(or))
+		inverted = inverted.Mult(rTransposed.Mult(or))
 
 		inverted = inverted.Mult(NewMatrix4Translate(op[0]-p[0], op[1]-p[1], op[2]-p[2]))
 
 		for _, otherPart := range other.Mesh.MeshParts {
 
+			if otherPart.TriangleCount() == 0 {
+				continue
+			}
+
 			// Here, we'll merge models into the calling Model, using its existing mesh parts if the materials match and if adding the vertices wouldn't exceed the maximum triangle count (21845 in a single draw call).
 
 			var targetPart *MeshPart
@@ -298,20 +430,22 @@ func (model *Model) Merge(models ...*Model) {
 				targetPart = model.Mesh.AddMeshPart(otherPart.Material)
 			}
 
-			verts := []VertexInfo{}
+			verts = verts[:0]
 
 			for triIndex := otherPart.TriangleStart; triIndex < otherPart.TriangleEnd; triIndex++ {
 				for i := 0; i < 3; i++ {
-					vertInfo := otherPart.Mesh.GetVertexInfo(triIndex*3 + i)
-					vec := vector.Vector{vertInfo.X, vertInfo.Y, vertInfo.Z}
-					x, y, z := fastMatrixMultVec(inverted, vec)
-					vertInfo.X = x
-					vertInfo.Y = y
-					vertInfo.Z = z
-					verts = append(verts, vertInfo)
+					verts = append(verts, otherPart.Mesh.GetVertexInfo(triIndex*3+i))
+					vertInfo := &verts[len(verts)-1]
+					vertInfo.X, vertInfo.Y, vertInfo.Z = fastMatrixMultVec(inverted, otherPart.Mesh.VertexPositions[triIndex*3+i])
 				}
 			}
 
+			if cap(targetPart.sortingTriangles) < len(targetPart.sortingTriangles)+len(verts)/3 {
+				newSorting := make([]sortingTriangle, len(targetPart.sortingTriangles), len(targetPart.sortingTriangles)+len(verts)/3)
+				copy(newSorting, targetPart.sortingTriangles)
+				targetPart.sortingTriangles = newSorting
+			}
+
 			targetPart.AddTriangles(verts...)
 
 		}
@@ -320,15 +454,17 @@ func (model *Model) Merge(models ...*Model) {
 
 	model.Mesh.UpdateBounds()
 
-	model.BoundingSphere.SetLocalPositionVec(model.Mesh.Dimensions.Center())
-	model.BoundingSphere.Radius = model.Mesh.Dimensions.MaxSpan() / 2
+	center, radius := model.Mesh.boundingSphere()
+	model.BoundingSphere.SetLocalPositionVec(center)
+	model.BoundingSphere.Radius = radius
 
 	model.skinVectorPool = NewVectorPool(len(model.Mesh.VertexPositions)*2, true)
 
 }
 
 // ReassignBones reassigns the model to point to a different armature. armatureNode should be a pointer to the starting object Node of the
-// armature (not any of its bones).
+// armature (not any of its bones). If the Model was detached from its previous armature by being removed from its tree (see
+// Node.RemoveChildren()), this also turns skinning back on.
 func (model *Model) ReassignBones(armatureRoot INode) {
 
 	if len(model.bones) == 0 {
@@ -359,9 +495,74 @@ func (model *Model) ReassignBones(armatureRoot INode) {
 
 	}
 
+	if model.armatureDetached {
+		model.Skinned = true
+		model.armatureDetached = false
+	}
+
+	model.skinningStates = nil
+
+}
+
+// detachArmature detaches the Model from the armature skinning it, so that the Model doesn't keep the armature from being garbage
+// collected. The Model's bones are replaced with placeholders that only hold the bones' names, so that it can be attached to an
+// armature again using ReassignBones().
+func (model *Model) detachArmature() {
+
+	placeholders := map[string]*Node{}
+
+	for vertexIndex := range model.bones {
+
+		for i, bone := range model.bones[vertexIndex] {
+
+			if bone == nil {
+				continue
+			}
+
+			placeholder, exists := placeholders[bone.name]
+			if !exists {
+				placeholder = &Node{name: bone.name}
+				placeholders[bone.name] = placeholder
+			}
+
+			model.bones[vertexIndex][i] = placeholder
+
+		}
+
+	}
+
+	model.SkinRoot = nil
+	model.armatureDetached = model.armatureDetached || model.Skinned
+	model.Skinned = false
+	model.skinningStates = nil
+
+}
+
+// Dispose removes the Model from its parent and clears all of its references to other Models and Nodes - the Models dynamically
+// batched under it, the Model it's dynamically batched under, and the armature skinning it - so that it doesn't keep any of them from
+// being garbage collected (or vice-versa). The Model shouldn't be rendered after being disposed.
+func (model *Model) Dispose() {
+
+	model.Unparent()
+
+	if model.DynamicBatchOwner != nil {
+		model.DynamicBatchOwner.DynamicBatchRemove(model)
+	}
+
+	model.DynamicBatchClear()
+
+	model.SkinRoot = nil
+	model.bones = nil
+	model.Skinned = false
+	model.armatureDetached = false
+	model.skinningStates = nil
+	model.skinnedPositions = nil
+	model.skinnedNormals = nil
+
 }
 
-func (model *Model) skinVertex(vertID int, transformNormal bool) (vector.Vector, vector.Vector) {
+// skinVertex skins the vertex with the given ID, using the given (possibly morphed) vertex positions and normals.
+func (model *Model) skinVertex(vertID int, positions, normals []vector.Vector, transformNormal bool) (vector.Vector, vector.Vector) {
 
 	// Avoid reallocating a new matrix for every vertex; that's wasteful
 	model.skinMatrix.Clear()
@@ -389,7 +590,7 @@ func (model *Model) skinVertex(vertID int, transformNormal bool) (vector.Vector,
 
 	}
 
-	vertOut := model.skinVectorPool.MultVecW(model.skinMatrix, model.Mesh.VertexPositions[vertID])
+	vertOut := model.skinVectorPool.MultVecW(model.skinMatrix, positions[vertID])
 
 	if transformNormal {
 		model.skinMatrix[3][0] = 0
@@ -397,13 +598,293 @@ func (model *Model) skinVertex(vertID int, transformNormal bool) (vector.Vector,
 		model.skinMatrix[3][2] = 0
 		model.skinMatrix[3][3] = 1
 
-		normal = model.skinVectorPool.MultVecW(model.skinMatrix, model.Mesh.VertexNormals[vertID])
+		normal = model.skinVectorPool.MultVecW(model.skinMatrix, normals[vertID])
 	}
 
 	return vertOut, normal
 
 }
 
+// SetMorphWeight sets the Model's weight for the MorphTarget with the given name in the Model's Mesh. A weight of 0 means the MorphTarget
+// has no effect, while a weight of 1 means it's fully applied (though weights outside of that range work as well, exaggerating or
+// inverting the MorphTarget). The Model's vertex positions and normals are blended towards its MorphTargets according to their weights
+// when rendering, before skinning for skinned Models. If the Mesh has no MorphTarget with the given name, SetMorphWeight does nothing.
+func (model *Model) SetMorphWeight(name string, weight float64) {
+
+	index := model.Mesh.MorphTargetIndex(name)
+
+	if index < 0 {
+		return
+	}
+
+	model.syncMorphWeights()
+
+	if model.morphWeights[index] != weight {
+		model.morphWeights[index] = weight
+		model.morphDirty = true
+	}
+
+}
+
+// MorphWeight returns the Model's weight for the MorphTarget with the given name in the Model's Mesh, or 0 if the Mesh has no
+// MorphTarget with the given name.
+func (model *Model) MorphWeight(name string) float64 {
+
+	index := model.Mesh.MorphTargetIndex(name)
+
+	if index < 0 {
+		return 0
+	}
+
+	model.syncMorphWeights()
+
+	return model.morphWeights[index]
+
+}
+
+// SetMorphWeights sets the Model's weights for its Mesh's MorphTargets in order (so the first weight is for the first MorphTarget in
+// Mesh.MorphTargets, and so on); weights beyond the number of MorphTargets are ignored. This is used to apply animated morph weights.
+func (model *Model) SetMorphWeights(weights ...float64) {
+
+	model.syncMorphWeights()
+
+	for i := 0; i < len(weights) && i < len(model.morphWeights); i++ {
+		if model.morphWeights[i] != weights[i] {
+			model.morphWeights[i] = weights[i]
+			model.morphDirty = true
+		}
+	}
+
+}
+
+// syncMorphWeights resets the Model's morph weights to the MorphTargets' default weights if they don't belong to the Model's current
+// Mesh (i.e. before they're first used, or after the Model's Mesh has been changed or has had MorphTargets added).
+func (model *Model) syncMorphWeights() {
+
+	if model.morphMesh == model.Mesh && len(model.morphWeights) == len(model.Mesh.MorphTargets) {
+		return
+	}
+
+	model.morphMesh = model.Mesh
+	model.morphWeights = make([]float64, len(model.Mesh.MorphTargets))
+
+	for i, target := range model.Mesh.MorphTargets {
+		model.morphWeights[i] = target.DefaultWeight
+	}
+
+	model.morphDirty = true
+
+}
+
+// morphedVertices returns the vertex positions and normals of the Model's Mesh after blending them towards the Mesh's MorphTargets
+// according to the Model's morph weights, recalculating them if necessary. If no MorphTargets apply, the Mesh's own vertex positions
+// and normals are returned.
+func (model *Model) morphedVertices() ([]vector.Vector, []vector.Vector) {
+
+	mesh := model.Mesh
+
+	if len(mesh.MorphTargets) == 0 {
+		return mesh.VertexPositions, mesh.VertexNormals
+	}
+
+	model.syncMorphWeights()
+
+	// MorphTargets only apply if they have deltas for each of the Mesh's vertices (i.e. the Mesh hasn't been subdivided since).
+	applies := func(i int) bool {
+		return model.morphWeights[i] != 0 && len(mesh.MorphTargets[i].PositionDeltas) == mesh.VertexCount
+	}
+
+	applied := false
+	for i := range mesh.MorphTargets {
+		if applies(i) {
+			applied = true
+			break
+		}
+	}
+
+	if !applied {
+		return mesh.VertexPositions, mesh.VertexNormals
+	}
+
+	if len(model.morphedPositions) != mesh.VertexCount {
+		model.morphedPositions = make([]vector.Vector, mesh.VertexCount)
+		model.morphedNormals = make([]vector.Vector, mesh.VertexCount)
+		buffer := make([]float64, mesh.VertexCount*6)
+		for i := range model.morphedPositions {
+			model.morphedPositions[i] = buffer[i*6 : i*6+3 : i*6+3]
+			model.morphedNormals[i] = buffer[i*6+3 : i*6+6 : i*6+6]
+		}
+		model.morphDirty = true
+	}
+
+	if model.morphDirty {
+
+		for v := 0; v < mesh.VertexCount; v++ {
+
+			position := model.morphedPositions[v]
+			normal := model.morphedNormals[v]
+			copy(position, mesh.VertexPositions[v])
+			copy(normal, mesh.VertexNormals[v])
+
+			normalChanged := false
+
+			for i, target := range mesh.MorphTargets {
+
+				if !applies(i) {
+					continue
+				}
+
+				weight := model.morphWeights[i]
+
+				delta := target.PositionDeltas[v]
+				position[0] += delta[0] * weight
+				position[1] += delta[1] * weight
+				position[2] += delta[2] * weight
+
+				if len(target.NormalDeltas) == mesh.VertexCount {
+					delta = target.NormalDeltas[v]
+					normal[0] += delta[0] * weight
+					normal[1] += delta[1] * weight
+					normal[2] += delta[2] * weight
+					normalChanged = true
+				}
+
+			}
+
+			if normalChanged {
+				if length := normal.Magnitude(); length > 0 {
+					normal[0] /= length
+					normal[1] /= length
+					normal[2] /= length
+				}
+			}
+
+		}
+
+		model.morphDirty = false
+
+	}
+
+	return model.morphedPositions, model.morphedNormals
+
+}
+
+// SkinningLOD controls how often a skinned Model updates its skinning depending on its distance from the Camera rendering it.
+// Within NearDistance, skinning is updated every UpdateInterval frames (every frame by default); from there, the number of frames
+// between skinning updates grows linearly until it reaches MaxInterval frames at FarDistance and beyond. Models that appear smaller
+// on screen than MinScreenSize are updated every MaxInterval frames regardless of their distance. In between updates, the Model is
+// rendered using its last skinned vertex positions. Skinning is always updated when a Model is rendered for the first time after not
+// being rendered (i.e. after being outside of the Camera's frustum; skinning is skipped entirely while that's the case), so the Model
+// doesn't render in an outdated pose.
+type SkinningLOD struct {
+	NearDistance float64 // The distance within which skinning is updated every UpdateInterval frames.
+	FarDistance  float64 // The distance at and beyond which skinning is updated every MaxInterval frames.
+	MaxInterval  int     // The maximum number of frames between skinning updates.
+
+	// UpdateInterval is the number of frames between skinning updates within NearDistance, and so the fewest frames between updates
+	// overall. Values below 1 are treated as 1 (updating every frame), which is the default.
+	UpdateInterval int
+
+	// MinScreenSize is the fraction of the Camera's height (from 0 to 1) that a Model's bounding sphere has to span on screen for its
+	// distance to be taken into account; Models that appear smaller than this are updated every MaxInterval frames, however close they
+	// are. This is useful for orthographic Cameras (where distance doesn't affect size) and Cameras with wide fields of view. Defaults
+	// to 0, which turns it off.
+	MinScreenSize float64
+}
+
+// NewSkinningLOD creates a new SkinningLOD struct with the provided distances and maximum number of frames between skinning updates.
+func NewSkinningLOD(nearDistance, farDistance float64, maxInterval int) *SkinningLOD {
+	return &SkinningLOD{
+		NearDistance:   nearDistance,
+		FarDistance:    farDistance,
+		MaxInterval:    maxInterval,
+		UpdateInterval: 1,
+	}
+}
+
+// Interval returns the number of frames between skinning updates for a Model at the given distance from the Camera.
+func (lod *SkinningLOD) Interval(distance float64) int {
+
+	minInterval := lod.UpdateInterval
+	if minInterval < 1 {
+		minInterval = 1
+	}
+
+	maxInterval := lod.MaxInterval
+	if maxInterval < minInterval {
+		maxInterval = minInterval
+	}
+
+	if distance <= lod.NearDistance {
+		return minInterval
+	}
+
+	if distance >= lod.FarDistance || lod.FarDistance <= lod.NearDistance {
+		return maxInterval
+	}
+
+	perc := (distance - lod.NearDistance) / (lod.FarDistance - lod.NearDistance)
+	return minInterval + int(math.Round(perc*float64(maxInterval-minInterval)))
+
+}
+
+// modelInterval returns the number of frames between skinning updates for the given Model when rendered through the given Camera.
+func (lod *SkinningLOD) modelInterval(model *Model, camera *Camera) int {
+
+	if lod.MinScreenSize > 0 && camera.screenSize(model.BoundingSphere) < lod.MinScreenSize {
+		return lod.Interval(math.Inf(1))
+	}
+
+	return lod.Interval(Distance(model.WorldPosition(), camera.WorldPosition()))
+
+}
+
+// skinningState tracks when a MeshPart of a skinned Model was last rendered and skinned by a Camera. This is tracked per
+// Camera because each Camera has its own frame count and distance to the Model.
+type skinningState struct {
+	lastRendered uint64
+	lastSkinned  uint64
+	hasNormals   bool
+}
+
+type skinningStateKey struct {
+	camera   *Camera
+	meshPart *MeshPart
+}
+
+// shouldUpdateSkinning returns if the skinning of the MeshPart provided should be updated when rendering it through the Camera given.
+func (model *Model) shouldUpdateSkinning(camera *Camera, meshPart *MeshPart, lightingOn bool) bool {
+
+	if model.skinningStates == nil {
+		model.skinningStates = map[skinningStateKey]*skinningState{}
+	}
+
+	key := skinningStateKey{camera: camera, meshPart: meshPart}
+	state, exists := model.skinningStates[key]
+
+	if !exists {
+		state = &skinningState{}
+		model.skinningStates[key] = state
+	}
+
+	frame := camera.renderFrame
+
+	update := model.SkinningLOD == nil || !exists ||
+		frame-state.lastRendered > 1 || // The Model wasn't rendered last frame, so its skinning is outdated
+		(lightingOn && !state.hasNormals) ||
+		int(frame-state.lastSkinned) >= model.SkinningLOD.modelInterval(model, camera)
+
+	state.lastRendered = frame
+
+	if update {
+		state.lastSkinned = frame
+		state.hasNormals = lightingOn
+	}
+
+	return update
+
+}
+
 // ProcessVertices processes the vertices a Model has in preparation for rendering, given a view-projection
 // matrix, a camera, and the MeshPart being rendered.
 func (model *Model) ProcessVertices(vpMatrix Matrix4, camera *Camera, meshPart *MeshPart, scene *Scene) {
@@ -431,6 +912,17 @@ func (model *Model) ProcessVertices(vpMatrix Matrix4, camera *Camera, meshPart *
 
 	zeroVec := vector.Vector{0, 0, 0}
 
+	mesh := model.Mesh
+
+	// The results are stored in the Camera's buffers, so that other Cameras rendering the Mesh don't overwrite them.
+	camera.prepareRenderBuffers(meshPart)
+	triangles := camera.sortingTriangles
+
+	positions, normals := model.morphedVertices()
+
+	// In indexed mode, only the first of each group of identical vertices is transformed (and skinned); the others copy its results.
+	indexed := mesh.beginIndexedPass()
+
 	if model.Skinned {
 
 		lightingOn := false
@@ -444,12 +936,25 @@ func (model *Model) ProcessVertices(vpMatrix Matrix4, camera *Camera, meshPart *
 
 		model.skinVectorPool.Reset()
 
+		if len(model.skinnedPositions) != model.Mesh.VertexCount {
+			model.skinnedPositions = make([]vector.Vector, model.Mesh.VertexCount)
+			model.skinnedNormals = make([]vector.Vector, model.Mesh.VertexCount)
+			buffer := make([]float64, model.Mesh.VertexCount*6)
+			for i := range model.skinnedPositions {
+				model.skinnedPositions[i] = buffer[i*6 : i*6+3 : i*6+3]
+				model.skinnedNormals[i] = buffer[i*6+3 : i*6+6 : i*6+6]
+			}
+			model.skinningStates = nil
+		}
+
+		updateSkinning := model.shouldUpdateSkinning(camera, meshPart, lightingOn)
+
 		t := time.Now()
 
 		// If we're skinning a model, it will automatically copy the armature's position, scale, and rotation by copying its bones
-		for i := 0; i < len(meshPart.sortingTriangles); i++ {
+		for i := 0; i < len(triangles); i++ {
 
-			tri := meshPart.sortingTriangles[i]
+			tri := triangles[i]
 
 			depth := math.MaxFloat32
 
@@ -457,20 +962,48 @@ func (model *Model) ProcessVertices(vpMatrix Matrix4, camera *Camera, meshPart *
 
 			for v := 0; v < 3; v++ {
 
-				vertPos, vertNormal := model.skinVertex(tri.ID*3+v, lightingOn)
-				if transformFunc != nil {
-					vertPos = transformFunc(vertPos, tri.ID*3+v)
+				vertID := tri.ID*3 + v
+
+				srcID := vertID
+				processed := false
+
+				if indexed {
+					srcID = mesh.Indices[vertID]
+					processed = mesh.markIndexedVertex(srcID)
 				}
-				if vertNormal != nil {
-					model.Mesh.vertexSkinnedNormals[tri.ID*3+v] = vertNormal
-					model.Mesh.vertexSkinnedPositions[tri.ID*3+v] = vertPos
+
+				// When skinning isn't updated, the Model's previously skinned vertices are used instead.
+				if updateSkinning && !processed {
+					camera.DebugInfo.skinnedVertices++
+					skinnedPos, skinnedNormal := model.skinVertex(srcID, positions, normals, lightingOn)
+					if transformFunc != nil {
+						skinnedPos = transformFunc(skinnedPos, srcID)
+					}
+					copy(model.skinnedPositions[srcID], skinnedPos)
+					if skinnedNormal != nil {
+						copy(model.skinnedNormals[srcID], skinnedNormal)
+					}
+				}
+
+				vertPos := model.skinnedPositions[srcID]
+
+				if lightingOn {
+					mesh.vertexSkinnedNormals[vertID] = model.skinnedNormals[srcID]
+					mesh.vertexSkinnedPositions[vertID] = vertPos
+				}
+
+				transformed := camera.vertexTransforms[vertID]
+
+				if !processed {
+					src := camera.vertexTransforms[srcID]
+					src[0], src[1], src[2], src[3] = fastMatrixMultVecW(vpMatrix, vertPos)
 				}
-				transformed := model.Mesh.vertexTransforms[tri.ID*3+v]
-				x, y, z, w := fastMatrixMultVecW(vpMatrix, vertPos)
-				transformed[0] = x
-				transformed[1] = y
-				transformed[2] = z
-				transformed[3] = w
+
+				if srcID != vertID {
+					copy(transformed, camera.vertexTransforms[srcID])
+				}
+
+				z, w := transformed[2], transformed[3]
 
 				if w >= 0 && z < far {
 					outOfBounds = false
@@ -483,15 +1016,17 @@ func (model *Model) ProcessVertices(vpMatrix Matrix4, camera *Camera, meshPart *
 			}
 
 			if outOfBounds {
-				meshPart.sortingTriangles[i].rendered = false
+				triangles[i].rendered = false
 				continue
 			}
 
-			meshPart.sortingTriangles[i].depth = float32(depth)
+			triangles[i].depth = float32(depth)
 
 		}
 
-		camera.DebugInfo.animationTime += time.Since(t)
+		animationTime := time.Since(t)
+		camera.DebugInfo.animationTime += animationTime
+		camera.DebugInfo.renderAnimationTime += animationTime
 
 	} else {
 
@@ -528,32 +1063,50 @@ func (model *Model) ProcessVertices(vpMatrix Matrix4, camera *Camera, meshPart *
 
 		mvp := fastMatrixMult(base, vpMatrix)
 
-		for i := 0; i < len(meshPart.sortingTriangles); i++ {
+		for i := 0; i < len(triangles); i++ {
 
-			triID := meshPart.sortingTriangles[i].ID
+			triID := triangles[i].ID
 			depth := math.MaxFloat64
 
 			// triRef := model.Mesh.Triangles[triID]
 			// TODO: Replace this distance check with a broadphase check; we could also use it to easily reject
 			// triangles that lie outside of the camera frustum.
 			// if fastVectorDistanceSquared(camPos, triRef.Center) > (camFarSquared)+triRef.MaxSpan {
-			// 	meshPart.sortingTriangles[i].rendered = false
+			// 	triangles[i].rendered = false
 			// 	continue
 			// }
 
-			meshPart.sortingTriangles[i].rendered = true
+			triangles[i].rendered = true
 
 			outOfBounds := true
 
 			for i := 0; i < 3; i++ {
-				v0 := model.Mesh.VertexPositions[triID*3+i]
 
-				if transformFunc != nil {
-					v0 = transformFunc(v0.Clone(), triID*3+i)
+				vertID := triID*3 + i
+				srcID := vertID
+
+				if indexed {
+					srcID = mesh.Indices[vertID]
+				}
+
+				if !indexed || !mesh.markIndexedVertex(srcID) {
+
+					v0 := positions[srcID]
+
+					if transformFunc != nil {
+						v0 = transformFunc(v0.Clone(), srcID)
+					}
+
+					src := camera.vertexTransforms[srcID]
+					src[0], src[1], src[2], src[3] = fastMatrixMultVecW(mvp, v0)
+
 				}
 
-				transformed := model.Mesh.vertexTransforms[triID*3+i]
-				transformed[0], transformed[1], transformed[2], transformed[3] = fastMatrixMultVecW(mvp, v0)
+				transformed := camera.vertexTransforms[vertID]
+
+				if srcID != vertID {
+					copy(transformed, camera.vertexTransforms[srcID])
+				}
 
 				if transformed[3] < depth {
 					depth = transformed[3]
@@ -566,36 +1119,30 @@ func (model *Model) ProcessVertices(vpMatrix Matrix4, camera *Camera, meshPart *
 			}
 
 			if outOfBounds {
-				meshPart.sortingTriangles[i].rendered = false
+				triangles[i].rendered = false
 				continue
 			}
 
-			meshPart.sortingTriangles[i].depth = float32(depth)
+			triangles[i].depth = float32(depth)
 
 		}
 
 	}
 
-	sortMode := TriangleSortModeBackToFront
-
-	if meshPart.Material != nil {
-		sortMode = meshPart.Material.TriangleSortMode
-	}
-
-	// Preliminary tests indicate sort.SliceStable is faster than sort.Slice for our purposes
-
-	if sortMode == TriangleSortModeBackToFront {
-		sort.SliceStable(meshPart.sortingTriangles, func(i, j int) bool {
-			return meshPart.sortingTriangles[i].depth > meshPart.sortingTriangles[j].depth
-		})
-	} else if sortMode == TriangleSortModeFrontToBack {
-		sort.SliceStable(meshPart.sortingTriangles, func(i, j int) bool {
-			return meshPart.sortingTriangles[i].depth < meshPart.sortingTriangles[j].depth
-		})
-	}
+	meshPart.sortTriangles(triangles)
 
 }
 
+const (
+	// AOBakeModeAdjacency bakes ambient occlusion by checking the angles between adjacent triangles (and the distances to the
+	// triangles of other Models). It's fast, but approximate.
+	AOBakeModeAdjacency = iota
+	// AOBakeModeRaycast bakes ambient occlusion by casting rays across the hemisphere above each vertex against the triangles of the
+	// Model and the other Models in AOBakeOptions.OtherModels; the more rays hit something, the more occluded the vertex is. It's
+	// much more accurate than AOBakeModeAdjacency, but also much slower.
+	AOBakeModeRaycast
+)
+
 type AOBakeOptions struct {
 	TargetChannel  int     // The target vertex color channel to bake the ambient occlusion to.
 	OcclusionAngle float64 // How severe the angle must be (in radians) for the occlusion effect to show up.
@@ -606,6 +1153,10 @@ type AOBakeOptions struct {
 	// are too close to the baking Model.
 	OtherModels        []*Model
 	InterModelDistance float64 // How far the other models in OtherModels must be to influence the baking AO.
+
+	Mode        int     // How the ambient occlusion is baked (AOBakeModeAdjacency or AOBakeModeRaycast).
+	RayCount    int     // The number of rays cast from each vertex when baking with AOBakeModeRaycast.
+	RayDistance float64 // How far rays are cast when baking with AOBakeModeRaycast; anything further away doesn't occlude the vertex.
 }
 
 // NewDefaultAOBakeOptions creates a new AOBakeOptions struct with default settings.
@@ -617,6 +1168,9 @@ func NewDefaultAOBakeOptions() *AOBakeOptions {
 		Color:              NewColor(0.4, 0.4, 0.4, 1),
 		InterModelDistance: 1,
 		OtherModels:        []*Model{},
+		Mode:               AOBakeModeAdjacency,
+		RayCount:           32,
+		RayDistance:        1,
 	}
 
 }
@@ -625,145 +1179,23 @@ func NewDefaultAOBakeOptions() *AOBakeOptions {
 // struct. If a slice of models is passed in the OtherModels slice, then inter-object AO will also be baked.
 // If nil is passed instead of bake options, a default AOBakeOptions struct will be created and used.
 // The resulting vertex color will be mixed between whatever was originally there in that channel and the AO color where the color
-// takes effect.
+// takes effect. To bake AO in the background without blocking, see Model.BakeAOAsync().
 func (model *Model) BakeAO(bakeOptions *AOBakeOptions) {
 
-	if bakeOptions == nil {
-		bakeOptions = NewDefaultAOBakeOptions()
-	}
+	bake := model.newAOBake(bakeOptions)
 
-	if model.Mesh == nil || bakeOptions.TargetChannel < 0 {
+	if bake == nil {
 		return
 	}
 
-	model.Mesh.ensureEnoughVertexColorChannels(bakeOptions.TargetChannel)
-
-	// Same model AO first
-
-	for _, tri := range model.Mesh.Triangles {
-
-		ao := [3]float32{0, 0, 0}
-
-		verts := tri.VertexIndices()
-
-		for _, other := range model.Mesh.Triangles {
-
-			if tri == other || vectorsEqual(tri.Normal, other.Normal) {
-				continue
-			}
-
-			span := tri.MaxSpan
-			if other.MaxSpan > span {
-				span = other.MaxSpan
-			}
-
-			span *= 0.66
-
-			if fastVectorDistanceSquared(tri.Center, other.Center) > span*span {
-				continue
-			}
-
-			angle := tri.Normal.Angle(other.Normal)
-			if angle < bakeOptions.OcclusionAngle {
-				continue
-			}
-
-			if shared := tri.SharesVertexPositions(other); shared != nil {
-
-				if shared[0] >= 0 {
-					ao[0] = 1
-				}
-				if shared[1] >= 0 {
-					ao[1] = 1
-				}
-				if shared[2] >= 0 {
-					ao[2] = 1
-				}
-
-			}
-
-		}
-
-		for i := 0; i < 3; i++ {
-			model.Mesh.VertexColors[verts[i]][bakeOptions.TargetChannel].Mix(bakeOptions.Color, ao[i])
-		}
-
-	}
-
-	// Inter-object AO next; this is kinda slow and janky, but it does work OK, I think
-
-	transform := model.Transform()
-
-	distanceSquared := bakeOptions.InterModelDistance * bakeOptions.InterModelDistance
-
-	for _, other := range bakeOptions.OtherModels {
-
-		rad := model.BoundingSphere.WorldRadius()
-		if or := other.BoundingSphere.WorldRadius(); or > rad {
-			rad = or
-		}
-		if model == other || fastVectorDistanceSquared(model.WorldPosition(), other.WorldPosition()) > rad*rad {
-			continue
-		}
-
-		otherTransform := other.Transform()
-
-		for _, tri := range model.Mesh.Triangles {
-
-			ao := [3]float32{0, 0, 0}
-
-			verts := tri.VertexIndices()
-
-			transformedTriVerts := [3]vector.Vector{
-				transform.MultVec(model.Mesh.VertexPositions[verts[0]]),
-				transform.MultVec(model.Mesh.VertexPositions[verts[1]]),
-				transform.MultVec(model.Mesh.VertexPositions[verts[2]]),
-			}
-
-			for _, otherTri := range other.Mesh.Triangles {
-
-				otherVerts := otherTri.VertexIndices()
-
-				span := tri.MaxSpan
-				if otherTri.MaxSpan > span {
-					span = otherTri.MaxSpan
-				}
-
-				span *= 0.66
-
-				if fastVectorDistanceSquared(transform.MultVec(tri.Center), otherTransform.MultVec(otherTri.Center)) > span {
-					continue
-				}
-
-				transformedOtherVerts := [3]vector.Vector{
-					otherTransform.MultVec(other.Mesh.VertexPositions[otherVerts[0]]),
-					otherTransform.MultVec(other.Mesh.VertexPositions[otherVerts[1]]),
-					otherTransform.MultVec(other.Mesh.VertexPositions[otherVerts[2]]),
-				}
-
-				for i := 0; i < 3; i++ {
-					for j := 0; j < 3; j++ {
-						if fastVectorDistanceSquared(transformedTriVerts[i], transformedOtherVerts[j]) <= distanceSquared {
-							ao[i] = 1
-							break
-						}
-					}
-				}
-
-			}
-
-			for i := 0; i < 3; i++ {
-				model.Mesh.VertexColors[verts[i]][bakeOptions.TargetChannel].Mix(bakeOptions.Color, ao[i])
-			}
-
-		}
-
-	}
+	bake.run(nil)
+	bake.apply()
 
 }
 
 // BakeLighting bakes the colors for the provided lights into a Model's Mesh's vertex colors. Note that the baked lighting overwrites whatever vertex colors
 // previously existed in the target channel (as otherwise, the colors could only get brighter with additive mixing, or only get darker with multiplicative mixing).
+// Materials' emission colors are baked in as well.
 func (model *Model) BakeLighting(targetChannel int, lights ...ILight) {
 
 	if model.Mesh == nil || targetChannel < 0 {
@@ -772,37 +1204,64 @@ func (model *Model) BakeLighting(targetChannel int, lights ...ILight) {
 
 	model.Mesh.ensureEnoughVertexColorChannels(targetChannel)
 
-	allLights := append([]ILight{}, lights...)
-
-	if model.Scene() != nil {
-		allLights = append(allLights, model.Scene().World.AmbientLight)
+	if scene := model.Scene(); scene != nil && scene.World != nil && scene.World.AmbientLight != nil {
+		lights = append(append(make([]ILight, 0, len(lights)+1), lights...), scene.World.AmbientLight)
 	}
 
-	for _, light := range allLights {
+	model.Transform()
+
+	// Lights that are off or out of range of the Model are skipped entirely.
+	allLights := make([]ILight, 0, len(lights))
 
-		if light.IsOn() {
+	for _, light := range lights {
+
+		if light.IsOn() && model.LightLayers.Has(light.lightLayers()) && lightInfluencesModel(light, model) {
 
 			light.beginRender()
 			light.beginModel(model)
+			allLights = append(allLights, light)
 
 		}
 
 	}
 
+	normalizeLightProbes(allLights)
+
 	for _, tri := range model.Mesh.Triangles {
 
 		lightResults := [9]float32{}
 
+		flip := tri.MeshPart.Material != nil && tri.MeshPart.Material.FlipBackfaceNormals
+
 		for _, light := range allLights {
 
-			if light.IsOn() {
-				lightColors := light.Light(tri.ID, model)
+			lightColors := light.lightFacing(tri.ID, model, 1)
 
-				for i := range lightColors {
-					lightResults[i] += lightColors[i]
+			// For double-sided triangles, each light lights whichever side of the triangle it's on.
+			if flip {
+				backColors := light.lightFacing(tri.ID, model, -1)
+				for i := range backColors {
+					if backColors[i] > lightColors[i] {
+						lightColors[i] = backColors[i]
+					}
 				}
 			}
 
+			for i := range lightColors {
+				lightResults[i] += lightColors[i]
+			}
+
+		}
+
+		// Emission is unaffected by lights, so it's simply added on top.
+		if tri.MeshPart.Material != nil {
+			if r, g, b, emissive := tri.MeshPart.Material.emission(); emissive {
+				for i := 0; i < 3; i++ {
+					lightResults[i*3] += r
+					lightResults[i*3+1] += g
+					lightResults[i*3+2] += b
+				}
+			}
 		}
 
 		for i := 0; i < 3; i++ {
@@ -818,12 +1277,18 @@ func (model *Model) BakeLighting(targetChannel int, lights ...ILight) {
 
 }
 
-// isTransparent returns true if the provided MeshPart has a Material with TransparencyModeTransparent, or if it's
-// TransparencyModeAuto with the model or material alpha color being under 0.99. This is a helper function for sorting
+// isTransparent returns true if the provided MeshPart has a Material with TransparencyModeTransparent, if it's
+// TransparencyModeAuto with the model or material alpha color being under 0.99, or if it's TransparencyModeDithered with the
+// combined model and material alpha being under DitheredTransparencyMinimumAlpha. This is a helper function for sorting
 // MeshParts into either transparent or opaque buckets for rendering.
 func (model *Model) isTransparent(meshPart *MeshPart) bool {
 	mat := meshPart.Material
-	return mat != nil && (mat.TransparencyMode == TransparencyModeTransparent || mat.CompositeMode != ebiten.CompositeModeSourceOver || (mat.TransparencyMode == TransparencyModeAuto && (mat.Color.A < 0.99 || model.Color.A < 0.99)))
+	if mat == nil {
+		return false
+	}
+	color := model.materialColor(meshPart)
+	return mat.TransparencyMode == TransparencyModeTransparent || mat.CompositeMode != ebiten.CompositeModeSourceOver || (mat.TransparencyMode == TransparencyModeAuto && (color.A < 0.99 || model.Color.A < 0.99)) ||
+		(mat.TransparencyMode == TransparencyModeDithered && color.A*model.Color.A < DitheredTransparencyMinimumAlpha)
 }
 
 ////////
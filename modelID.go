@@ -0,0 +1,156 @@
+package tetra3d
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// maxModelIDs is how many Models can be told apart in a Camera's model ID texture each frame; IDs are stored in 24 bits, and 0 is
+// reserved for empty pixels.
+const maxModelIDs = 1<<24 - 1
+
+// The model ID shader writes a MeshPart's Model's ID (encoded as a color) to the Camera's model ID texture where the MeshPart passed
+// the depth test (i.e. where it was rendered to the depth intermediate texture).
+var modelIDShaderText = []byte(
+	`package main
+
+	var ID vec3
+
+	func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+
+		if imageSrc0UnsafeAt(texCoord).a > 0 {
+			return vec4(ID, 1)
+		}
+
+		discard()
+
+	}
+	`,
+)
+
+// prepareModelIDRendering creates the shader and texture used to render model IDs if they haven't been created yet.
+func (camera *Camera) prepareModelIDRendering() {
+
+	if camera.modelIDShader == nil {
+
+		var err error
+
+		camera.modelIDShader, err = ebiten.NewShader(modelIDShaderText)
+
+		if err != nil {
+			panic(err)
+		}
+
+	}
+
+	if camera.resultModelIDTexture == nil {
+		camera.resultModelIDTexture = ebiten.NewImage(camera.resultColorTexture.Size())
+	}
+
+	if camera.modelIDs == nil {
+		camera.modelIDs = map[*Model]int{}
+	}
+
+}
+
+// clearModelIDs clears the Camera's model ID texture and forgets the IDs handed out since it was last cleared.
+func (camera *Camera) clearModelIDs() {
+
+	camera.prepareModelIDRendering()
+	camera.resultModelIDTexture.Clear()
+
+	for model := range camera.modelIDs {
+		delete(camera.modelIDs, model)
+	}
+
+	camera.modelsByID = camera.modelsByID[:0]
+
+}
+
+// modelID returns the ID of the given Model in the Camera's model ID texture, handing out a new one if it doesn't have one yet this
+// frame, or 0 if the Camera's run out of IDs.
+func (camera *Camera) modelID(model *Model) int {
+
+	if id, ok := camera.modelIDs[model]; ok {
+		return id
+	}
+
+	if len(camera.modelsByID) >= maxModelIDs {
+		return 0
+	}
+
+	camera.modelsByID = append(camera.modelsByID, model)
+	id := len(camera.modelsByID)
+	camera.modelIDs[model] = id
+	return id
+
+}
+
+// modelIDColor returns the color that the given model ID is encoded as in the model ID texture.
+func modelIDColor(id int) []float32 {
+	return []float32{
+		float32(id&0xff) / 255,
+		float32(id>>8&0xff) / 255,
+		float32(id>>16&0xff) / 255,
+	}
+}
+
+// modelIDFromColor returns the model ID encoded in the given color from the model ID texture.
+func modelIDFromColor(c color.RGBA) int {
+	if c.A == 0 {
+		return 0
+	}
+	return int(c.R) | int(c.G)<<8 | int(c.B)<<16
+}
+
+// drawModelID draws the given Model's ID to the Camera's model ID texture where the MeshPart just rendered to the depth intermediate
+// texture passed the depth test.
+func (camera *Camera) drawModelID(model *Model) {
+
+	id := camera.modelID(model)
+
+	if id == 0 {
+		return
+	}
+
+	w, h := camera.resultModelIDTexture.Size()
+
+	camera.resultModelIDTexture.DrawRectShader(w, h, camera.modelIDShader, &ebiten.DrawRectShaderOptions{
+		Images:   [4]*ebiten.Image{camera.depthIntermediate},
+		Uniforms: map[string]interface{}{"ID": modelIDColor(id)},
+	})
+
+}
+
+// ModelIDTexture returns the Camera's model ID texture from any previous Render() or RenderNodes() calls since it was last cleared,
+// which holds an ID for the front-most Model rendered at each pixel (see Camera.RenderModelIDs), encoded in the texture's RGB
+// channels (with red holding the lowest 8 bits). Pixels that nothing was rendered to are transparent. If Camera.RenderModelIDs
+// is set to false, the function will return nil instead.
+func (camera *Camera) ModelIDTexture() *ebiten.Image {
+	if !camera.RenderModelIDs {
+		return nil
+	}
+	camera.prepareModelIDRendering()
+	return camera.resultModelIDTexture
+}
+
+// ModelAtScreenPosition returns the front-most Model rendered at the given screen position (in the Camera's texture's pixels) since
+// the Camera was last cleared, according to its model ID texture (see Camera.RenderModelIDs), or nil if there's no Model there or
+// the Camera isn't rendering model IDs. Note that this reads the model ID texture back from the GPU, which is slow the first time
+// it's done after rendering; it's best to only call it once or twice each frame, after rendering.
+func (camera *Camera) ModelAtScreenPosition(x, y int) *Model {
+
+	if !camera.RenderModelIDs || camera.resultModelIDTexture == nil {
+		return nil
+	}
+
+	id := modelIDFromColor(color.RGBAModel.Convert(camera.resultModelIDTexture.At(x, y)).(color.RGBA))
+
+	if id <= 0 || id > len(camera.modelsByID) {
+		return nil
+	}
+
+	return camera.modelsByID[id-1]
+
+}
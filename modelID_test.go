@@ -0,0 +1,57 @@
+package tetra3d
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestCameraModelIDs(t *testing.T) {
+
+	scene := NewScene("model ID test")
+
+	a := NewModel(NewCube(), "a")
+	b := NewModel(NewCube(), "b")
+	a.Move(-2, 0, -6)
+	b.Move(2, 0, -6)
+	scene.Root.AddChildren(a, b)
+
+	camera := NewCamera(64, 48)
+	camera.RenderModelIDs = true
+
+	camera.Clear()
+	camera.RenderNodes(scene, scene.Root)
+
+	if camera.ModelIDTexture() == nil {
+		t.Fatalf("camera should have a model ID texture")
+	}
+
+	if len(camera.modelsByID) != 2 || camera.modelIDs[a] == camera.modelIDs[b] || camera.modelIDs[a] == 0 || camera.modelIDs[b] == 0 {
+		t.Fatalf("both cubes should have been given their own nonzero IDs, but the IDs are %v", camera.modelIDs)
+	}
+
+	for _, id := range []int{1, 255, 256, 65793, maxModelIDs} {
+		c := modelIDColor(id)
+		encoded := color.RGBA{uint8(math.Round(float64(c[0]) * 255)), uint8(math.Round(float64(c[1]) * 255)), uint8(math.Round(float64(c[2]) * 255)), 255}
+		if decoded := modelIDFromColor(encoded); decoded != id {
+			t.Fatalf("model ID %d was decoded as %d", id, decoded)
+		}
+	}
+
+	if modelIDFromColor(color.RGBA{}) != 0 {
+		t.Fatalf("empty pixels should decode to no model ID")
+	}
+
+	camera.Clear()
+
+	if len(camera.modelsByID) != 0 {
+		t.Fatalf("clearing the camera should clear its model IDs")
+	}
+
+	camera.RenderModelIDs = false
+
+	if camera.ModelIDTexture() != nil || camera.ModelAtScreenPosition(32, 24) != nil {
+		t.Fatalf("camera shouldn't return model IDs when it isn't rendering them")
+	}
+
+}
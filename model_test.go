@@ -0,0 +1,241 @@
+package tetra3d
+
+import (
+	"testing"
+)
+
+// newTestMesh creates a Mesh with a single MeshPart composed of the specified number of (degenerate) triangles.
+func newTestMesh(triangleCount int) *Mesh {
+	mesh := NewMesh("test")
+	part := mesh.AddMeshPart(NewMaterial("test"))
+	verts := make([]VertexInfo, 0, triangleCount*3)
+	for i := 0; i < triangleCount*3; i++ {
+		verts = append(verts, NewVertex(0, 0, 0, 0, 0))
+	}
+	part.AddTriangles(verts...)
+	return mesh
+}
+
+func TestDynamicBatchTriangleLimit(t *testing.T) {
+
+	owner := NewModel(NewCube(), "owner")
+	owner.Mesh.AddMeshPart(NewMaterial("second"))
+
+	partA := owner.Mesh.MeshParts[0]
+	partB := owner.Mesh.MeshParts[1]
+
+	big := NewModel(newTestMesh(maxTriangleCount-1), "big")
+	single := NewModel(newTestMesh(1), "single")
+	overflow := NewModel(newTestMesh(1), "overflow")
+
+	if err := owner.DynamicBatchAdd(partA, big, single); err != nil {
+		t.Fatalf("batching exactly %d triangles should succeed, but returned error: %s", maxTriangleCount, err)
+	}
+
+	if count := owner.DynamicBatchMeshPartTriangleCount(partA); count != maxTriangleCount {
+		t.Fatalf("expected %d batched triangles, got %d", maxTriangleCount, count)
+	}
+
+	if err := owner.DynamicBatchAdd(partA, overflow); err == nil {
+		t.Fatal("batching past the maximum triangle count should return an error")
+	}
+
+	if overflow.DynamicBatchOwner != nil {
+		t.Fatal("model that overflowed the batch shouldn't be batched")
+	}
+
+	// The limit applies to each MeshPart individually.
+	if err := owner.DynamicBatchAdd(partB, overflow); err != nil {
+		t.Fatalf("batching into a different MeshPart should succeed, but returned error: %s", err)
+	}
+
+	if count := owner.DynamicBatchTriangleCount(); count != maxTriangleCount+1 {
+		t.Fatalf("expected %d batched triangles in total, got %d", maxTriangleCount+1, count)
+	}
+
+	owner.DynamicBatchRemove(single)
+
+	if single.DynamicBatchOwner != nil || owner.DynamicBatchMeshPartTriangleCount(partA) != maxTriangleCount-1 {
+		t.Fatal("removing a model from the batch didn't update the batch properly")
+	}
+
+	owner.DynamicBatchClear()
+
+	if len(owner.DynamicBatchModels) != 0 || big.DynamicBatchOwner != nil || overflow.DynamicBatchOwner != nil {
+		t.Fatal("clearing the batch didn't remove all batched models")
+	}
+
+}
+
+func BenchmarkMerge500Cubes(b *testing.B) {
+
+	cubeMesh := NewCube()
+	cubes := make([]*Model, 0, 500)
+	for i := 0; i < 500; i++ {
+		cube := NewModel(cubeMesh, "cube")
+		cube.SetLocalPosition(float64(i%20)*2, 0, float64(i/20)*2)
+		cubes = append(cubes, cube)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		merged := NewModel(NewMesh("merged"), "merged")
+		merged.Merge(cubes...)
+	}
+
+}
+
+func TestBakeLightingFlipBackfaceNormals(t *testing.T) {
+
+	bake := func(flip bool, lightHeight float64) float32 {
+		mesh := NewPlane()
+		mesh.MeshParts[0].Material.BackfaceCulling = false
+		mesh.MeshParts[0].Material.FlipBackfaceNormals = flip
+		quad := NewModel(mesh, "quad")
+		light := NewPointLight("light", 1, 1, 1, 1)
+		light.SetLocalPosition(0, lightHeight, 0)
+		quad.BakeLighting(0, light)
+		return mesh.VertexColors[0][0].R
+	}
+
+	above := bake(false, 2)
+	below := bake(false, -2)
+
+	if (above > 0) == (below > 0) {
+		t.Fatalf("without flipping normals, the quad should only be lit from one side; lit from above: %f, lit from below: %f", above, below)
+	}
+
+	above = bake(true, 2)
+	below = bake(true, -2)
+
+	if above <= 0 || below <= 0 || above != below {
+		t.Fatalf("with flipped normals, the quad should be lit equally from both sides; lit from above: %f, lit from below: %f", above, below)
+	}
+
+}
+
+func TestSkinningLODInterval(t *testing.T) {
+
+	lod := NewSkinningLOD(10, 50, 5)
+
+	tests := []struct {
+		distance float64
+		interval int
+	}{
+		{0, 1},
+		{10, 1},
+		{20, 2},
+		{30, 3},
+		{50, 5},
+		{1000, 5},
+	}
+
+	for _, test := range tests {
+		if interval := lod.Interval(test.distance); interval != test.interval {
+			t.Errorf("at a distance of %f, expected an interval of %d frames, got %d", test.distance, test.interval, interval)
+		}
+	}
+
+	// With an UpdateInterval, nearby Models are only updated every few frames as well.
+	lod.UpdateInterval = 3
+
+	for distance, expected := range map[float64]int{0: 3, 10: 3, 30: 4, 50: 5} {
+		if interval := lod.Interval(distance); interval != expected {
+			t.Errorf("with an UpdateInterval of 3, at a distance of %f, expected an interval of %d frames, got %d", distance, expected, interval)
+		}
+	}
+
+}
+
+func TestSkinningLODScreenSize(t *testing.T) {
+
+	lod := NewSkinningLOD(10, 50, 5)
+	lod.MinScreenSize = 0.1
+
+	camera := NewCamera(64, 64)
+
+	model := NewModel(NewCube(), "cube")
+	model.Move(0, 0, -5)
+
+	if interval := lod.modelInterval(model, camera); interval != 1 {
+		t.Fatalf("a nearby cube taking up much of the screen should be updated every frame; got an interval of %d", interval)
+	}
+
+	// The cube's just as close, but too small on screen to be worth updating every frame.
+	model.SetLocalScale(0.05, 0.05, 0.05)
+
+	if interval := lod.modelInterval(model, camera); interval != 5 {
+		t.Fatalf("a nearby cube too small on screen should be updated every MaxInterval frames; got an interval of %d", interval)
+	}
+
+	// Distance doesn't affect size with an orthographic projection, so a wide view makes even a close cube small on screen.
+	model.SetLocalScale(1, 1, 1)
+	camera.SetOrthographic(10)
+
+	if interval := lod.modelInterval(model, camera); interval != 1 {
+		t.Fatalf("a cube taking up much of an orthographic view should be updated every frame; got an interval of %d", interval)
+	}
+
+	camera.SetOrthographic(100)
+
+	if interval := lod.modelInterval(model, camera); interval != 5 {
+		t.Fatalf("a cube too small in a wide orthographic view should be updated every MaxInterval frames; got an interval of %d", interval)
+	}
+
+	lod.MinScreenSize = 0
+
+	if interval := lod.modelInterval(model, camera); interval != 1 {
+		t.Fatalf("with MinScreenSize off, only the cube's distance should matter; got an interval of %d", interval)
+	}
+
+}
+
+func TestRemoveChildrenReleasesOwnersAndArmatures(t *testing.T) {
+
+	scene := NewScene("pooling")
+
+	owner := NewModel(NewCube(), "owner")
+
+	armature := NewNode("armature")
+	bone := NewNode("bone")
+	bone.isBone = true
+	armature.AddChildren(bone)
+
+	mesh := NewCube()
+
+	batched := NewModel(mesh, "batched")
+	skinned := NewModel(mesh, "skinned")
+	skinned.Skinned = true
+	skinned.SkinRoot = armature
+	skinned.bones = [][]*Node{{bone}}
+
+	scene.Root.AddChildren(owner, armature, batched, skinned)
+
+	if err := owner.DynamicBatchAdd(owner.Mesh.MeshParts[0], batched); err != nil {
+		t.Fatal(err)
+	}
+
+	// Removing the batch owner unbatches the Models left in the scene.
+	owner.Unparent()
+
+	if batched.DynamicBatchOwner != nil || len(owner.DynamicBatchModels) != 0 {
+		t.Fatal("removing a batch owner should clear its batch and the batched Models' references to it")
+	}
+
+	// Removing the armature detaches the Models it skinned that are left in the scene.
+	armature.Unparent()
+
+	if skinned.SkinRoot != nil || skinned.Skinned || skinned.bones[0][0] == bone {
+		t.Fatal("removing an armature should detach the Models it skins from it")
+	}
+
+	scene.Root.AddChildren(armature)
+	skinned.ReassignBones(armature)
+
+	if !skinned.Skinned || skinned.SkinRoot != armature || skinned.bones[0][0] != bone {
+		t.Fatal("ReassignBones() should reattach a Model to the armature after it was re-added")
+	}
+
+}
@@ -0,0 +1,189 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/kvartborg/vector"
+)
+
+// NavAgent moves a Node along paths across a NavMesh towards a target, like an AI character walking around a level. If the NavAgent
+// has a CharacterController, it walks using it (and so collides with things and falls with gravity); in this case, if something
+// blocks the NavAgent's way for long enough (like a closed door, a crate, or another character), it finds a new path around it.
+// Otherwise, the NavAgent moves its Node directly along the path.
+type NavAgent struct {
+	NavMesh   *NavMesh             // The NavMesh the NavAgent finds paths across.
+	Node      INode                // The Node moved by the NavAgent.
+	Character *CharacterController // The CharacterController the NavAgent walks with; if nil, the NavAgent moves the Node directly.
+
+	Speed          float64 // How far the NavAgent moves each time it's updated, in world units. Defaults to 0.05.
+	ArriveDistance float64 // How close the NavAgent needs to get to each point on its path to reach it, in world units. Defaults to 0.1.
+	// BlockedTime is how many updates in a row the NavAgent can fail to make progress along its path before it considers itself
+	// blocked and finds a new path. Defaults to 30.
+	BlockedTime int
+
+	OnArrive func() // OnArrive is called when the NavAgent reaches its target.
+
+	target     vector.Vector
+	path       *NavPath
+	index      int
+	blockedFor int
+	avoid      map[*NavPolygon]bool
+}
+
+// NewNavAgent returns a new NavAgent that moves the given Node across the given NavMesh. character can be nil, in which case the
+// Node is moved directly; otherwise, it should be a CharacterController that moves the Node.
+func NewNavAgent(navMesh *NavMesh, node INode, character *CharacterController) *NavAgent {
+	return &NavAgent{
+		NavMesh:        navMesh,
+		Node:           node,
+		Character:      character,
+		Speed:          0.05,
+		ArriveDistance: 0.1,
+		BlockedTime:    30,
+		avoid:          map[*NavPolygon]bool{},
+	}
+}
+
+// SetTarget has the NavAgent find a path to the given world position, and then start moving along it each time it's updated.
+// SetTarget returns false if there's no path to the target (in which case the NavAgent stops).
+func (agent *NavAgent) SetTarget(target vector.Vector) bool {
+	agent.target = target.Clone()
+	agent.avoid = map[*NavPolygon]bool{}
+	return agent.Repath()
+}
+
+// Target returns the world position the NavAgent is moving towards, or nil if it isn't moving anywhere.
+func (agent *NavAgent) Target() vector.Vector {
+	if agent.path == nil {
+		return nil
+	}
+	return agent.target.Clone()
+}
+
+// Path returns the NavPath the NavAgent is currently following, or nil if it isn't moving anywhere.
+func (agent *NavAgent) Path() *NavPath {
+	return agent.path
+}
+
+// Stop stops the NavAgent from moving towards its target.
+func (agent *NavAgent) Stop() {
+	agent.path = nil
+}
+
+// Moving returns if the NavAgent is moving towards a target.
+func (agent *NavAgent) Moving() bool {
+	return agent.path != nil
+}
+
+// Repath has the NavAgent find a new path from where it is to its target. Repath returns false if there's no path to the target
+// (in which case the NavAgent stops).
+func (agent *NavAgent) Repath() bool {
+
+	agent.blockedFor = 0
+	agent.index = 1
+
+	if agent.target == nil {
+		agent.path = nil
+		return false
+	}
+
+	agent.path = agent.NavMesh.findPath(agent.Node.WorldPosition(), agent.target, agent.avoid)
+
+	// If avoiding the places the NavAgent was blocked leaves no way to get to the target, it might as well try them again.
+	if agent.path == nil && len(agent.avoid) > 0 {
+		agent.avoid = map[*NavPolygon]bool{}
+		agent.path = agent.NavMesh.findPath(agent.Node.WorldPosition(), agent.target, nil)
+	}
+
+	return agent.path != nil
+
+}
+
+// Update moves the NavAgent along its path by its Speed, colliding with the BoundingObjects in the trees of the INodes provided in
+// others if it has a CharacterController. You should call Update() once per game frame, even when the NavAgent isn't moving
+// anywhere, so that its CharacterController keeps falling and stays grounded.
+func (agent *NavAgent) Update(others ...INode) {
+
+	movement := vector.Vector{0, 0, 0}
+	position := agent.Node.WorldPosition()
+
+	if agent.path != nil {
+
+		// Points that the NavAgent's close enough to (ignoring height if it's walking, since its origin's likely above the ground)
+		// count as reached.
+		for agent.index < len(agent.path.Points) && agent.distanceTo(position, agent.path.Points[agent.index]) <= agent.ArriveDistance {
+			agent.index++
+		}
+
+		if agent.index >= len(agent.path.Points) {
+
+			agent.path = nil
+
+			if agent.OnArrive != nil {
+				agent.OnArrive()
+			}
+
+		} else {
+
+			toNext := agent.path.Points[agent.index].Sub(position)
+
+			if agent.Character != nil {
+				toNext = toNext.Sub(agent.Character.Up.Unit().Scale(dot(toNext, agent.Character.Up.Unit())))
+			}
+
+			if distance := toNext.Magnitude(); distance > 0 {
+				movement = toNext.Scale(math.Min(agent.Speed, distance) / distance)
+			}
+
+		}
+
+	}
+
+	if agent.Character == nil {
+		agent.Node.SetWorldPositionVec(position.Add(movement))
+		return
+	}
+
+	moved := agent.Character.Move(movement, others...)
+
+	expected := movement.Magnitude()
+	if expected == 0 {
+		return
+	}
+
+	// If the NavAgent hardly moved where it was trying to go, something's in its way.
+	if dot(moved, movement)/expected < expected/4 {
+		agent.blockedFor++
+	} else {
+		agent.blockedFor = 0
+	}
+
+	if agent.blockedFor >= agent.BlockedTime {
+
+		// The NavPolygon just ahead of the NavAgent is avoided when finding a new path (unless it's the one the NavAgent is on).
+		ahead := position.Add(movement.Scale(agent.Character.Capsule.WorldRadius() * 2 / expected))
+		current, _ := agent.NavMesh.NearestPolygon(position)
+
+		if blocked, _ := agent.NavMesh.NearestPolygon(ahead); blocked != nil && blocked != current {
+			agent.avoid[blocked] = true
+		}
+
+		agent.Repath()
+
+	}
+
+}
+
+// distanceTo returns the distance from the given position to the given point on the NavAgent's path.
+func (agent *NavAgent) distanceTo(position, point vector.Vector) float64 {
+
+	diff := point.Sub(position)
+
+	if agent.Character != nil {
+		up := agent.Character.Up.Unit()
+		diff = diff.Sub(up.Scale(dot(diff, up)))
+	}
+
+	return diff.Magnitude()
+
+}
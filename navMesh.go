@@ -0,0 +1,719 @@
+package tetra3d
+
+import (
+	"math"
+	"sort"
+
+	"github.com/kvartborg/vector"
+)
+
+// NavMesh is a navigation mesh - a set of convex polygons covering the places in a level where an AI character can walk, along
+// with how those polygons connect to each other. A NavMesh can be generated from a level's Models using a NavMeshBuilder, or made
+// from a Model that's shaped like one using NewNavMeshFromModel(). NavMeshes are in world space, so they don't move along with the
+// Models they were made from.
+type NavMesh struct {
+	Polygons []*NavPolygon // The NavPolygons making up the NavMesh.
+}
+
+// NavPolygon is a convex polygon in a NavMesh; an agent can walk in a straight line between any two points within it.
+type NavPolygon struct {
+	Vertices []vector.Vector // The world positions of the NavPolygon's vertices, ordered counter-clockwise when viewed from above.
+	Center   vector.Vector   // The world position of the center of the NavPolygon.
+	Portals  []*NavPortal    // The edges (or parts of edges) that the NavPolygon shares with its neighbors.
+}
+
+// NavPortal is an edge (or part of one) shared between two neighboring NavPolygons, which an agent can walk through to go from one
+// to the other.
+type NavPortal struct {
+	Polygon    *NavPolygon   // The NavPolygon on the other side of the NavPortal.
+	Start, End vector.Vector // The ends of the NavPortal in world space, in the same (counter-clockwise) order as its NavPolygon's vertices.
+}
+
+// newNavPolygon returns a new NavPolygon with the given vertices.
+func newNavPolygon(vertices []vector.Vector) *NavPolygon {
+
+	center := vector.Vector{0, 0, 0}
+	for _, v := range vertices {
+		center = center.Add(v)
+	}
+
+	return &NavPolygon{
+		Vertices: vertices,
+		Center:   center.Scale(1 / float64(len(vertices))),
+	}
+
+}
+
+// NewNavMeshFromModel returns a new NavMesh made from the triangles of the given Model (in world space), with each triangle
+// becoming a NavPolygon. Triangles that share an edge are connected; triangles that are vertical (like walls) are skipped. This is
+// useful for using a NavMesh made by hand (or by another tool) rather than one generated with a NavMeshBuilder.
+func NewNavMeshFromModel(model *Model) *NavMesh {
+
+	navMesh := &NavMesh{Polygons: []*NavPolygon{}}
+
+	if model.Mesh == nil {
+		return navMesh
+	}
+
+	for _, tri := range navModelTriangles(model) {
+
+		normal := calculateNormal(tri[0], tri[1], tri[2])
+
+		if math.Abs(normal[1]) < 1e-6 {
+			continue
+		}
+
+		// NavPolygons always wind counter-clockwise when viewed from above, whichever way their triangles faced.
+		if normal[1] < 0 {
+			tri[1], tri[2] = tri[2], tri[1]
+		}
+
+		navMesh.Polygons = append(navMesh.Polygons, newNavPolygon([]vector.Vector{tri[0], tri[1], tri[2]}))
+
+	}
+
+	navMesh.linkSharedEdges()
+
+	return navMesh
+
+}
+
+// linkSharedEdges connects the NavMesh's NavPolygons that share edges with NavPortals.
+func (navMesh *NavMesh) linkSharedEdges() {
+
+	type edgeKey [2][3]float64
+
+	key := func(v vector.Vector) [3]float64 {
+		return [3]float64{math.Round(v[0] * 1e4), math.Round(v[1] * 1e4), math.Round(v[2] * 1e4)}
+	}
+
+	type edgeOwner struct {
+		polygon    *NavPolygon
+		start, end vector.Vector
+	}
+
+	edges := map[edgeKey][]edgeOwner{}
+
+	for _, polygon := range navMesh.Polygons {
+
+		for i, start := range polygon.Vertices {
+
+			end := polygon.Vertices[(i+1)%len(polygon.Vertices)]
+			a, b := key(start), key(end)
+
+			// The edge is keyed the same way whichever direction it runs.
+			if a[0] > b[0] || (a[0] == b[0] && (a[1] > b[1] || (a[1] == b[1] && a[2] > b[2]))) {
+				a, b = b, a
+			}
+
+			edges[edgeKey{a, b}] = append(edges[edgeKey{a, b}], edgeOwner{polygon, start, end})
+
+		}
+
+	}
+
+	for _, owners := range edges {
+		for _, owner := range owners {
+			for _, other := range owners {
+				if other.polygon != owner.polygon {
+					owner.polygon.Portals = append(owner.polygon.Portals, &NavPortal{Polygon: other.polygon, Start: owner.start, End: owner.end})
+				}
+			}
+		}
+	}
+
+}
+
+// navModelTriangles returns the triangles of the given Model's Mesh in world space.
+func navModelTriangles(model *Model) [][3]vector.Vector {
+
+	transform := model.Transform()
+	triangles := make([][3]vector.Vector, 0, len(model.Mesh.Triangles))
+
+	for _, tri := range model.Mesh.Triangles {
+		var points [3]vector.Vector
+		for i, index := range tri.VertexIndices() {
+			points[i] = transform.MultVec(model.Mesh.VertexPositions[index])
+		}
+		triangles = append(triangles, points)
+	}
+
+	return triangles
+
+}
+
+// NavMeshBuilder generates NavMeshes from level geometry. It works by voxelizing the triangles of the level's Models into columns of
+// cells, keeping the cells on top of surfaces that are flat enough to walk on and that have enough room above them for an agent to
+// stand in, and then merging neighboring cells together into polygons. An agent's radius is kept clear of walls and ledges, so agents
+// can follow paths on the generated NavMesh without catching on corners.
+type NavMeshBuilder struct {
+	// CellSize is the width and depth of the cells (in world units) that level geometry is voxelized into. Smaller cells make for
+	// a more accurate NavMesh, but take longer to build and make for more NavPolygons. Defaults to 0.25.
+	CellSize float64
+
+	AgentRadius   float64 // How far agents need to stay from walls and ledges, in world units. Defaults to 0.5.
+	AgentHeight   float64 // How much room agents need above the ground to fit, in world units. Defaults to 2.
+	MaxStepHeight float64 // How tall a step or ledge agents can walk up (or down) onto, in world units. Defaults to 0.3.
+	MaxSlopeAngle float64 // The steepest slope (in radians) that agents can walk on. Defaults to 45 degrees (math.Pi / 4).
+}
+
+// NewNavMeshBuilder returns a new NavMeshBuilder with default settings.
+func NewNavMeshBuilder() *NavMeshBuilder {
+	return &NavMeshBuilder{
+		CellSize:      0.25,
+		AgentRadius:   0.5,
+		AgentHeight:   2,
+		MaxStepHeight: 0.3,
+		MaxSlopeAngle: math.Pi / 4,
+	}
+}
+
+// Build generates a NavMesh from the triangles of the given Models (and the Models in their trees); these would usually be the
+// parts of the level that agents walk on and around.
+func (builder *NavMeshBuilder) Build(nodes ...INode) *NavMesh {
+
+	triangles := [][3]vector.Vector{}
+
+	for _, node := range nodes {
+		for _, model := range append(NodeFilter{node}, node.ChildrenRecursive()...).Models() {
+			if model.Mesh != nil {
+				triangles = append(triangles, navModelTriangles(model)...)
+			}
+		}
+	}
+
+	navMesh := &NavMesh{Polygons: []*NavPolygon{}}
+
+	if len(triangles) == 0 {
+		return navMesh
+	}
+
+	field := builder.voxelize(triangles)
+	field.findCells(builder)
+	field.erode(int(math.Ceil(builder.AgentRadius/builder.CellSize - 1e-9)))
+
+	for _, rect := range field.mergeCells(builder.MaxStepHeight / 4) {
+		navMesh.Polygons = append(navMesh.Polygons, rect.polygon)
+	}
+
+	return navMesh
+
+}
+
+// navSpan is a solid vertical span of level geometry in a column of a navHeightfield.
+type navSpan struct {
+	min, max float64
+	walkable bool
+	normal   vector.Vector // The normal of the surface on top of the span.
+}
+
+// navCell is an open cell on top of a navSpan - a place where an agent can stand.
+type navCell struct {
+	x, z           int
+	floor          float64 // The height of the top of the span that the cell's on.
+	ceiling        float64 // The height of the bottom of the span above the cell.
+	height         float64 // The height of the floor at the center of the cell.
+	slopeX, slopeZ float64 // How much the floor's height changes across the cell along the X and Z axes.
+	neighbors      [4]int  // The connected cells in the -X, +Z, +X, and -Z directions, or -1 if there isn't one.
+	distance       int     // The distance (in cells) to the nearest edge of the walkable area.
+	rect           int     // The index of the navRect the cell's been merged into, or -1 if it hasn't been.
+}
+
+// navDirections are the offsets to the neighboring cells in the -X, +Z, +X, and -Z directions; these are ordered so that a
+// rectangle's sides come in counter-clockwise order when viewed from above.
+var navDirections = [4][2]int{{-1, 0}, {0, 1}, {1, 0}, {0, -1}}
+
+// navHeightfield is the voxelized form of level geometry that a NavMeshBuilder builds NavMeshes from: a grid of columns, each
+// containing the solid spans of geometry in it and the open cells on top of them.
+type navHeightfield struct {
+	minX, minZ   float64
+	cellSize     float64
+	width, depth int
+	columns      [][]navSpan
+	cells        []*navCell
+	columnCells  [][]int // The indices of the cells in each column.
+}
+
+// voxelize rasterizes the given world-space triangles into a new navHeightfield.
+func (builder *NavMeshBuilder) voxelize(triangles [][3]vector.Vector) *navHeightfield {
+
+	minX, minZ := math.MaxFloat64, math.MaxFloat64
+	maxX, maxZ := -math.MaxFloat64, -math.MaxFloat64
+
+	for _, tri := range triangles {
+		for _, p := range tri {
+			minX, maxX = math.Min(minX, p[0]), math.Max(maxX, p[0])
+			minZ, maxZ = math.Min(minZ, p[2]), math.Max(maxZ, p[2])
+		}
+	}
+
+	cellSize := builder.CellSize
+
+	field := &navHeightfield{
+		minX:     minX,
+		minZ:     minZ,
+		cellSize: cellSize,
+		width:    int(math.Max(1, math.Ceil((maxX-minX)/cellSize))),
+		depth:    int(math.Max(1, math.Ceil((maxZ-minZ)/cellSize))),
+	}
+
+	field.columns = make([][]navSpan, field.width*field.depth)
+
+	cellIndex := func(value, min float64, count int) int {
+		return int(math.Min(math.Max(math.Floor((value-min)/cellSize), 0), float64(count-1)))
+	}
+
+	walkableY := math.Cos(builder.MaxSlopeAngle) - 1e-9
+
+	for _, tri := range triangles {
+
+		normal := calculateNormal(tri[0], tri[1], tri[2])
+		if normal.Magnitude() < 0.5 {
+			continue // Degenerate triangles have no normal
+		}
+
+		triMinX, triMaxX := math.Min(tri[0][0], math.Min(tri[1][0], tri[2][0])), math.Max(tri[0][0], math.Max(tri[1][0], tri[2][0]))
+		triMinZ, triMaxZ := math.Min(tri[0][2], math.Min(tri[1][2], tri[2][2])), math.Max(tri[0][2], math.Max(tri[1][2], tri[2][2]))
+
+		// The triangle is clipped to each cell it covers; the part inside of the cell makes up a span. Cells include their -X and -Z
+		// edges but not their +X and +Z ones, so triangles that end right on the edge of a cell don't spill over into the next one.
+		lastX := int(math.Max(float64(cellIndex(triMinX, minX, field.width)), float64(cellIndex(triMaxX-1e-9, minX, field.width))))
+		lastZ := int(math.Max(float64(cellIndex(triMinZ, minZ, field.depth)), float64(cellIndex(triMaxZ-1e-9, minZ, field.depth))))
+
+		for z := cellIndex(triMinZ, minZ, field.depth); z <= lastZ; z++ {
+
+			cellMinZ := minZ + float64(z)*cellSize
+			row := navClipPolygon(navClipPolygon(tri[:], 2, cellMinZ, false), 2, cellMinZ+cellSize, true)
+
+			if len(row) == 0 {
+				continue
+			}
+
+			for x := cellIndex(triMinX, minX, field.width); x <= lastX; x++ {
+
+				cellMinX := minX + float64(x)*cellSize
+				cell := navClipPolygon(navClipPolygon(row, 0, cellMinX, false), 0, cellMinX+cellSize, true)
+
+				if len(cell) == 0 {
+					continue
+				}
+
+				span := navSpan{min: math.MaxFloat64, max: -math.MaxFloat64, walkable: normal[1] >= walkableY, normal: normal}
+				for _, p := range cell {
+					span.min = math.Min(span.min, p[1])
+					span.max = math.Max(span.max, p[1])
+				}
+
+				field.columns[x+z*field.width] = append(field.columns[x+z*field.width], span)
+
+			}
+
+		}
+
+	}
+
+	// Overlapping spans are merged together; the merged span is walkable if the surface on top of it is.
+	for i, spans := range field.columns {
+
+		if len(spans) == 0 {
+			continue
+		}
+
+		sort.Slice(spans, func(a, b int) bool { return spans[a].min < spans[b].min })
+
+		merged := []navSpan{spans[0]}
+
+		for _, span := range spans[1:] {
+
+			top := &merged[len(merged)-1]
+
+			if span.min > top.max+1e-6 {
+				merged = append(merged, span)
+				continue
+			}
+
+			// Surfaces that are close enough together to step between count as the same surface.
+			if math.Abs(span.max-top.max) <= builder.MaxStepHeight {
+				if span.walkable && (!top.walkable || span.max > top.max) {
+					top.normal = span.normal
+				}
+				top.walkable = top.walkable || span.walkable
+			} else if span.max > top.max {
+				top.walkable = span.walkable
+				top.normal = span.normal
+			}
+
+			top.max = math.Max(top.max, span.max)
+
+		}
+
+		field.columns[i] = merged
+
+	}
+
+	return field
+
+}
+
+// findCells finds the open cells in the navHeightfield that agents can stand in, and connects each cell to the cells beside it
+// that agents can step onto.
+func (field *navHeightfield) findCells(builder *NavMeshBuilder) {
+
+	field.cells = []*navCell{}
+	field.columnCells = make([][]int, field.width*field.depth)
+
+	for z := 0; z < field.depth; z++ {
+
+		for x := 0; x < field.width; x++ {
+
+			spans := field.columns[x+z*field.width]
+
+			for i, span := range spans {
+
+				if !span.walkable {
+					continue
+				}
+
+				ceiling := math.MaxFloat64
+				if i < len(spans)-1 {
+					ceiling = spans[i+1].min
+				}
+
+				if ceiling-span.max < builder.AgentHeight {
+					continue
+				}
+
+				// The top of the span is the highest point of the floor in the cell; on a slope, the floor at the cell's center
+				// is lower than that by half of the slope across the cell.
+				slopeX := -span.normal[0] / span.normal[1] * field.cellSize
+				slopeZ := -span.normal[2] / span.normal[1] * field.cellSize
+
+				field.columnCells[x+z*field.width] = append(field.columnCells[x+z*field.width], len(field.cells))
+				field.cells = append(field.cells, &navCell{
+					x:         x,
+					z:         z,
+					floor:     span.max,
+					ceiling:   ceiling,
+					height:    span.max - (math.Abs(slopeX)+math.Abs(slopeZ))/2,
+					slopeX:    slopeX,
+					slopeZ:    slopeZ,
+					neighbors: [4]int{-1, -1, -1, -1},
+					rect:      -1,
+				})
+
+			}
+
+		}
+
+	}
+
+	for _, cell := range field.cells {
+
+		for dir, offset := range navDirections {
+
+			x, z := cell.x+offset[0], cell.z+offset[1]
+			if x < 0 || z < 0 || x >= field.width || z >= field.depth {
+				continue
+			}
+
+			best := math.MaxFloat64
+
+			for _, index := range field.columnCells[x+z*field.width] {
+
+				other := field.cells[index]
+				step := math.Abs(other.floor - cell.floor)
+				room := math.Min(other.ceiling, cell.ceiling) - math.Max(other.floor, cell.floor)
+
+				if step <= builder.MaxStepHeight && room >= builder.AgentHeight && step < best {
+					cell.neighbors[dir] = index
+					best = step
+				}
+
+			}
+
+		}
+
+	}
+
+}
+
+// erode removes the cells that are closer than the given number of cells to an edge of the walkable area (like a wall or a
+// ledge), disconnecting them from the cells around them.
+func (field *navHeightfield) erode(clearance int) {
+
+	queue := []int{}
+
+	for i, cell := range field.cells {
+
+		cell.distance = math.MaxInt32
+
+		for _, neighbor := range cell.neighbors {
+			if neighbor < 0 {
+				cell.distance = 0
+				queue = append(queue, i)
+				break
+			}
+		}
+
+	}
+
+	for len(queue) > 0 {
+
+		cell := field.cells[queue[0]]
+		queue = queue[1:]
+
+		for _, neighbor := range cell.neighbors {
+			if neighbor >= 0 && field.cells[neighbor].distance > cell.distance+1 {
+				field.cells[neighbor].distance = cell.distance + 1
+				queue = append(queue, neighbor)
+			}
+		}
+
+	}
+
+	for _, cell := range field.cells {
+		for dir, neighbor := range cell.neighbors {
+			if cell.distance < clearance || (neighbor >= 0 && field.cells[neighbor].distance < clearance) {
+				cell.neighbors[dir] = -1
+			}
+		}
+	}
+
+	for i, cell := range field.cells {
+		if cell.distance < clearance {
+			field.cells[i] = nil
+		}
+	}
+
+}
+
+// navRect is a rectangle of navCells, all lying on the same plane, that becomes a NavPolygon.
+type navRect struct {
+	x, z, width, depth int
+	height             float64 // The height of the floor at the center of the cell in the rectangle's -X, -Z corner.
+	slopeX, slopeZ     float64 // How much the floor's height changes from one cell to the next along the X and Z axes.
+	polygon            *NavPolygon
+}
+
+// heightAt returns the height of the rectangle's floor at the given position in cell units.
+func (rect *navRect) heightAt(x, z float64) float64 {
+	return rect.height + (x-float64(rect.x)-0.5)*rect.slopeX + (z-float64(rect.z)-0.5)*rect.slopeZ
+}
+
+// mergeCells greedily merges the cells of the navHeightfield into rectangles, as long as the cells' floors stay within the given
+// tolerance of the plane of the rectangle, and returns the rectangles with their NavPolygons connected.
+func (field *navHeightfield) mergeCells(tolerance float64) []*navRect {
+
+	rects := []*navRect{}
+
+	// open returns if the cell at the given index exists and hasn't been merged into a rectangle yet.
+	open := func(index int) bool {
+		return index >= 0 && field.cells[index] != nil && field.cells[index].rect < 0
+	}
+
+	for start, startCell := range field.cells {
+
+		if !open(start) {
+			continue
+		}
+
+		rect := &navRect{x: startCell.x, z: startCell.z, width: 1, depth: 1, height: startCell.height, slopeX: startCell.slopeX, slopeZ: startCell.slopeZ}
+
+		onPlane := func(index int) bool {
+			cell := field.cells[index]
+			return math.Abs(cell.height-rect.heightAt(float64(cell.x)+0.5, float64(cell.z)+0.5)) <= tolerance
+		}
+
+		// The rectangle grows along the X axis first...
+		row := []int{start}
+
+		for {
+			next := field.cells[row[len(row)-1]].neighbors[2]
+			if !open(next) || !onPlane(next) {
+				break
+			}
+			row = append(row, next)
+		}
+
+		rect.width = len(row)
+
+		// ...and then along the Z axis, a row at a time, for as long as each row is complete.
+		for _, index := range row {
+			field.cells[index].rect = len(rects)
+		}
+
+		for {
+
+			next := make([]int, 0, rect.width)
+
+			for i, index := range row {
+
+				above := field.cells[index].neighbors[1]
+
+				if !open(above) || !onPlane(above) || (i > 0 && field.cells[next[i-1]].neighbors[2] != above) {
+					break
+				}
+
+				next = append(next, above)
+
+			}
+
+			if len(next) < rect.width {
+				break
+			}
+
+			for _, index := range next {
+				field.cells[index].rect = len(rects)
+			}
+
+			rect.depth++
+			row = next
+
+		}
+
+		x0, z0 := float64(rect.x), float64(rect.z)
+		x1, z1 := float64(rect.x+rect.width), float64(rect.z+rect.depth)
+
+		rect.polygon = newNavPolygon([]vector.Vector{
+			field.worldPosition(rect, x0, z0),
+			field.worldPosition(rect, x0, z1),
+			field.worldPosition(rect, x1, z1),
+			field.worldPosition(rect, x1, z0),
+		})
+
+		rects = append(rects, rect)
+
+	}
+
+	for index := range rects {
+		field.connectRect(rects, index)
+	}
+
+	return rects
+
+}
+
+// worldPosition returns the world position of the point on the given rectangle's floor at the given position in cell units.
+func (field *navHeightfield) worldPosition(rect *navRect, x, z float64) vector.Vector {
+	return vector.Vector{field.minX + x*field.cellSize, rect.heightAt(x, z), field.minZ + z*field.cellSize}
+}
+
+// connectRect adds NavPortals to the NavPolygon of the rectangle at the given index leading to the NavPolygons of each neighboring
+// rectangle, along the parts of their sides that touch.
+func (field *navHeightfield) connectRect(rects []*navRect, index int) {
+
+	rect := rects[index]
+
+	for dir := range navDirections {
+
+		length := rect.width
+		if dir%2 == 0 {
+			length = rect.depth
+		}
+
+		// The range of cells along the side that touch each neighboring rectangle.
+		touching := map[int][2]int{}
+		order := []int{}
+
+		for i := 0; i < length; i++ {
+
+			var x, z int
+
+			switch dir {
+			case 0:
+				x, z = rect.x, rect.z+i
+			case 1:
+				x, z = rect.x+i, rect.z+rect.depth-1
+			case 2:
+				x, z = rect.x+rect.width-1, rect.z+i
+			case 3:
+				x, z = rect.x+i, rect.z
+			}
+
+			for _, c := range field.columnCells[x+z*field.width] {
+
+				cell := field.cells[c]
+				if cell == nil || cell.rect != index || cell.neighbors[dir] < 0 {
+					continue
+				}
+
+				other := field.cells[cell.neighbors[dir]].rect
+
+				if r, exists := touching[other]; exists {
+					touching[other] = [2]int{r[0], i + 1}
+				} else {
+					touching[other] = [2]int{i, i + 1}
+					order = append(order, other)
+				}
+
+			}
+
+		}
+
+		for _, other := range order {
+
+			otherRect := rects[other]
+
+			// Where the two rectangles' floors don't quite meet (like at a step), the NavPortal is halfway between them.
+			point := func(x, z float64) vector.Vector {
+				p := field.worldPosition(rect, x, z)
+				p[1] = (p[1] + otherRect.heightAt(x, z)) / 2
+				return p
+			}
+
+			from, to := float64(touching[other][0]), float64(touching[other][1])
+			x0, z0 := float64(rect.x), float64(rect.z)
+			x1, z1 := float64(rect.x+rect.width), float64(rect.z+rect.depth)
+
+			var start, end vector.Vector
+
+			switch dir {
+			case 0:
+				start, end = point(x0, z0+from), point(x0, z0+to)
+			case 1:
+				start, end = point(x0+from, z1), point(x0+to, z1)
+			case 2:
+				start, end = point(x1, z0+to), point(x1, z0+from)
+			case 3:
+				start, end = point(x0+to, z0), point(x0+from, z0)
+			}
+
+			rect.polygon.Portals = append(rect.polygon.Portals, &NavPortal{Polygon: otherRect.polygon, Start: start, End: end})
+
+		}
+
+	}
+
+}
+
+// navClipPolygon clips the given convex polygon against a plane perpendicular to the given axis at the given value, keeping the part
+// of the polygon below the plane if below is true, or above it otherwise.
+func navClipPolygon(points []vector.Vector, axis int, value float64, below bool) []vector.Vector {
+
+	inside := func(p vector.Vector) bool {
+		if below {
+			return p[axis] <= value
+		}
+		return p[axis] >= value
+	}
+
+	clipped := make([]vector.Vector, 0, len(points)+1)
+
+	for i, current := range points {
+
+		previous := points[(i+len(points)-1)%len(points)]
+
+		if inside(current) != inside(previous) {
+			t := (value - previous[axis]) / (current[axis] - previous[axis])
+			clipped = append(clipped, previous.Add(current.Sub(previous).Scale(t)))
+		}
+
+		if inside(current) {
+			clipped = append(clipped, current)
+		}
+
+	}
+
+	return clipped
+
+}
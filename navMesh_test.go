@@ -0,0 +1,200 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kvartborg/vector"
+)
+
+// navPolygonAt returns the NavPolygon in the NavMesh that's above or below the given position (on the X and Z axes), closest
+// to it vertically, or nil if there isn't one.
+func navPolygonAt(navMesh *NavMesh, position vector.Vector) *NavPolygon {
+
+	var closest *NavPolygon
+
+	for _, polygon := range navMesh.Polygons {
+
+		inside := true
+		for i, start := range polygon.Vertices {
+			end := polygon.Vertices[(i+1)%len(polygon.Vertices)]
+			// Counter-clockwise when viewed from above means points inside are on the left of each edge.
+			if (end[0]-start[0])*(position[2]-start[2])-(end[2]-start[2])*(position[0]-start[0]) > 1e-9 {
+				inside = false
+				break
+			}
+		}
+
+		if inside && (closest == nil || math.Abs(polygon.Center[1]-position[1]) < math.Abs(closest.Center[1]-position[1])) {
+			closest = polygon
+		}
+
+	}
+
+	return closest
+
+}
+
+// navReachable returns the NavPolygons that can be reached from the given NavPolygon through NavPortals.
+func navReachable(from *NavPolygon) map[*NavPolygon]bool {
+
+	reached := map[*NavPolygon]bool{from: true}
+	queue := []*NavPolygon{from}
+
+	for len(queue) > 0 {
+		polygon := queue[0]
+		queue = queue[1:]
+		for _, portal := range polygon.Portals {
+			if !reached[portal.Polygon] {
+				reached[portal.Polygon] = true
+				queue = append(queue, portal.Polygon)
+			}
+		}
+	}
+
+	return reached
+
+}
+
+func TestNavMeshBuilder(t *testing.T) {
+
+	floor := NewModel(NewPlane(), "floor")
+	floor.SetLocalScale(5, 1, 5)
+
+	builder := NewNavMeshBuilder()
+
+	// An empty floor becomes a single polygon, inset from its edges by the agent's radius.
+	navMesh := builder.Build(floor)
+
+	if len(navMesh.Polygons) != 1 {
+		t.Fatalf("an empty floor should become a single NavPolygon; got %d", len(navMesh.Polygons))
+	}
+
+	dim := NewDimensionsFromPoints(navMesh.Polygons[0].Vertices...)
+	if dim[0].Sub(vector.Vector{-4.5, 0, -4.5}).Magnitude() > 1e-6 || dim[1].Sub(vector.Vector{4.5, 0, 4.5}).Magnitude() > 1e-6 {
+		t.Fatalf("the floor's NavPolygon should be inset from its edges by the agent's radius; got %v", dim)
+	}
+
+	// A box in the middle of the floor is too tall to step onto, so agents have to walk around it.
+	box := NewModel(NewCube(), "box")
+	box.SetLocalPosition(0, 0.5, 0)
+
+	level := NewNode("level")
+	level.AddChildren(floor, box)
+
+	navMesh = builder.Build(level)
+
+	if polygon := navPolygonAt(navMesh, vector.Vector{1.25, 0, 0}); polygon != nil && polygon.Center[1] < 1 {
+		t.Fatalf("the floor beside the box should be kept clear by the agent's radius")
+	}
+
+	start := navPolygonAt(navMesh, vector.Vector{-3, 0, -3})
+	end := navPolygonAt(navMesh, vector.Vector{3, 0, 3})
+
+	if start == nil || end == nil || !navReachable(start)[end] {
+		t.Fatalf("the floor should be walkable all the way around the box")
+	}
+
+	for _, polygon := range navMesh.Polygons {
+		for _, portal := range polygon.Portals {
+			if portal.Start[1] > 0.1 || portal.End[1] > 0.1 {
+				t.Fatalf("no NavPortals should lead up onto the top of the box")
+			}
+		}
+	}
+
+	// A low shelf leaves too little room for agents underneath it.
+	shelf := NewModel(NewPlane(), "shelf")
+	shelf.SetLocalPosition(-3.5, 1.5, 0)
+	shelf.SetLocalScale(1.5, 1, 5)
+	level.AddChildren(shelf)
+
+	navMesh = builder.Build(level)
+
+	if polygon := navPolygonAt(navMesh, vector.Vector{-4, 0, 0}); polygon != nil && polygon.Center[1] < 1 {
+		t.Fatalf("agents shouldn't be able to stand under the shelf")
+	}
+
+	// A low step can be walked up onto.
+	step := NewModel(NewCube(), "step")
+	step.SetLocalPosition(3, -0.8, 0)
+	step.SetLocalScale(1.5, 1, 5)
+	shelf.Unparent()
+	level.AddChildren(step)
+
+	navMesh = builder.Build(level)
+
+	start = navPolygonAt(navMesh, vector.Vector{-3, 0, -3})
+	onStep := navPolygonAt(navMesh, vector.Vector{3.5, 0.2, 0})
+	if onStep == nil || math.Abs(onStep.Center[1]-0.2) > 1e-6 || !navReachable(start)[onStep] {
+		t.Fatalf("the step should be walkable from the rest of the floor")
+	}
+
+}
+
+func TestNavMeshBuilderSlope(t *testing.T) {
+
+	builder := NewNavMeshBuilder()
+
+	ramp := NewModel(NewPlane(), "ramp")
+	ramp.SetLocalScale(5, 1, 5)
+
+	for _, test := range []struct {
+		angle    float64
+		walkable bool
+	}{
+		{math.Pi / 6, true},
+		{math.Pi / 3, false},
+	} {
+
+		ramp.SetLocalRotation(NewMatrix4Rotate(0, 0, 1, test.angle))
+		navMesh := builder.Build(ramp)
+
+		if walkable := len(navMesh.Polygons) > 0; walkable != test.walkable {
+			t.Fatalf("a ramp at %f degrees should be walkable: %t", test.angle*180/math.Pi, test.walkable)
+		}
+
+		if test.walkable {
+
+			// The ramp's flat, so its cells should all merge into a single polygon that follows its slope.
+			if len(navMesh.Polygons) != 1 {
+				t.Fatalf("a flat ramp should become a single NavPolygon; got %d", len(navMesh.Polygons))
+			}
+
+			for _, v := range navMesh.Polygons[0].Vertices {
+				if height := v[0] * math.Tan(test.angle); math.Abs(v[1]-height) > 1e-6 {
+					t.Fatalf("the ramp's NavPolygon should follow its slope; expected a vertex at %v to be at a height of %f", v, height)
+				}
+			}
+
+		}
+
+	}
+
+}
+
+func TestNewNavMeshFromModel(t *testing.T) {
+
+	model := NewModel(NewPlane(), "navmesh")
+	model.SetLocalPosition(0, 2, 0)
+
+	navMesh := NewNavMeshFromModel(model)
+
+	if len(navMesh.Polygons) != 2 {
+		t.Fatalf("a plane should become two NavPolygons; got %d", len(navMesh.Polygons))
+	}
+
+	for _, polygon := range navMesh.Polygons {
+
+		if len(polygon.Portals) != 1 {
+			t.Fatalf("each of the plane's triangles should be connected to the other")
+		}
+
+		normal := calculateNormal(polygon.Vertices[0], polygon.Vertices[1], polygon.Vertices[2])
+		if normal.Sub(vector.Vector{0, 1, 0}).Magnitude() > 1e-6 || polygon.Center[1] != 2 {
+			t.Fatalf("NavPolygons should be in world space, and wind counter-clockwise when viewed from above")
+		}
+
+	}
+
+}
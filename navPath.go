@@ -0,0 +1,323 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/kvartborg/vector"
+)
+
+// NavPath is a path across a NavMesh, found using NavMesh.FindPath(), or along the Waypoints of a PathGraph, found using
+// PathGraph.FindPath() or Waypoint.PathTo(). A NavPath fulfills the IPath interface, so it can be followed using a Navigator (or a
+// NavAgent, which also finds new paths across a NavMesh when it's blocked).
+type NavPath struct {
+	Points []vector.Vector // The world positions making up the NavPath, starting at its start and ending at its end.
+}
+
+// Distance returns the total distance covered by the NavPath.
+func (path *NavPath) Distance() float64 {
+
+	dist := 0.0
+
+	for i := 1; i < len(path.Points); i++ {
+		dist += path.Points[i].Sub(path.Points[i-1]).Magnitude()
+	}
+
+	return dist
+
+}
+
+func (path *NavPath) points() []vector.Vector {
+	points := make([]vector.Vector, 0, len(path.Points))
+	for _, p := range path.Points {
+		points = append(points, p.Clone())
+	}
+	return points
+}
+
+func (path *NavPath) isClosed() bool {
+	return false
+}
+
+// HeightAt returns the height of the NavPolygon at the given X and Z world coordinates (whether or not they're within it).
+func (polygon *NavPolygon) HeightAt(x, z float64) float64 {
+
+	v := polygon.Vertices[0]
+	normal := calculateNormal(v, polygon.Vertices[1], polygon.Vertices[2])
+
+	if math.Abs(normal[1]) < 1e-9 {
+		return v[1]
+	}
+
+	return v[1] - (normal[0]*(x-v[0])+normal[2]*(z-v[2]))/normal[1]
+
+}
+
+// Contains returns if the given world position is within the NavPolygon when viewed from above (so its height isn't taken into
+// account).
+func (polygon *NavPolygon) Contains(position vector.Vector) bool {
+
+	for i, start := range polygon.Vertices {
+		if navCross(start, polygon.Vertices[(i+1)%len(polygon.Vertices)], position) < -1e-9 {
+			return false
+		}
+	}
+
+	return true
+
+}
+
+// ClosestPoint returns the point on the NavPolygon closest to the given world position when viewed from above.
+func (polygon *NavPolygon) ClosestPoint(position vector.Vector) vector.Vector {
+
+	if polygon.Contains(position) {
+		return vector.Vector{position[0], polygon.HeightAt(position[0], position[2]), position[2]}
+	}
+
+	var closest vector.Vector
+	closestDistance := math.MaxFloat64
+
+	for i, start := range polygon.Vertices {
+
+		point := navClosestPointOnSegment(start, polygon.Vertices[(i+1)%len(polygon.Vertices)], position)
+
+		if distance := math.Hypot(point[0]-position[0], point[2]-position[2]); distance < closestDistance {
+			closest = point
+			closestDistance = distance
+		}
+
+	}
+
+	return closest
+
+}
+
+// NearestPolygon returns the NavPolygon in the NavMesh that's closest to the given world position, along with the point on it
+// closest to that position. If the NavMesh has no NavPolygons, NearestPolygon returns nil for both.
+func (navMesh *NavMesh) NearestPolygon(position vector.Vector) (*NavPolygon, vector.Vector) {
+
+	var nearest *NavPolygon
+	var nearestPoint vector.Vector
+	nearestDistance := math.MaxFloat64
+
+	for _, polygon := range navMesh.Polygons {
+
+		point := polygon.ClosestPoint(position)
+
+		if distance := point.Sub(position).Magnitude(); distance < nearestDistance {
+			nearest = polygon
+			nearestPoint = point
+			nearestDistance = distance
+		}
+
+	}
+
+	return nearest, nearestPoint
+
+}
+
+// FindPath returns the shortest path across the NavMesh from the given start position to the given end position, or nil if there's
+// no way to get from one to the other. The positions are moved onto the NavMesh if they aren't on it already. The path is found
+// with A* across the NavMesh's NavPolygons, and is then smoothed (string-pulled) so that it only turns at corners, like a string
+// pulled tight between its ends.
+func (navMesh *NavMesh) FindPath(start, end vector.Vector) *NavPath {
+	return navMesh.findPath(start, end, nil)
+}
+
+// navSearchNode is a NavPolygon being searched through by NavMesh.findPath().
+type navSearchNode struct {
+	polygon  *NavPolygon
+	point    vector.Vector // The point the NavPolygon was entered at.
+	cost     float64       // The distance travelled to get to the point.
+	estimate float64       // The estimated total distance of a path going through the point.
+	previous *navSearchNode
+	portal   *NavPortal // The NavPortal the previous NavPolygon was left through.
+	closed   bool
+}
+
+// findPath finds a path from the start to the end like FindPath(), avoiding the NavPolygons in the given set (other than the ones
+// that the start and end are on).
+func (navMesh *NavMesh) findPath(start, end vector.Vector, avoid map[*NavPolygon]bool) *NavPath {
+
+	startPolygon, start := navMesh.NearestPolygon(start)
+	endPolygon, end := navMesh.NearestPolygon(end)
+
+	if startPolygon == nil {
+		return nil
+	}
+
+	nodes := map[*NavPolygon]*navSearchNode{
+		startPolygon: {polygon: startPolygon, point: start, estimate: start.Sub(end).Magnitude()},
+	}
+	open := []*navSearchNode{nodes[startPolygon]}
+
+	var found *navSearchNode
+
+	for len(open) > 0 {
+
+		best := 0
+		for i, node := range open {
+			if node.estimate < open[best].estimate {
+				best = i
+			}
+		}
+
+		current := open[best]
+		open[best] = open[len(open)-1]
+		open = open[:len(open)-1]
+		current.closed = true
+
+		if current.polygon == endPolygon {
+			found = current
+			break
+		}
+
+		for _, portal := range current.polygon.Portals {
+
+			next := portal.Polygon
+
+			if avoid[next] && next != endPolygon {
+				continue
+			}
+
+			// The NavPolygon is entered at the point on the NavPortal closest to where the current one was entered.
+			point := navClosestPointOnSegment(portal.Start, portal.End, current.point)
+			cost := current.cost + point.Sub(current.point).Magnitude()
+
+			if next == endPolygon {
+				cost += end.Sub(point).Magnitude()
+				point = end
+			}
+
+			node, exists := nodes[next]
+
+			if exists && (node.closed || node.cost <= cost) {
+				continue
+			}
+
+			if !exists {
+				node = &navSearchNode{polygon: next}
+				nodes[next] = node
+				open = append(open, node)
+			}
+
+			node.point = point
+			node.cost = cost
+			node.estimate = cost + point.Sub(end).Magnitude()
+			node.previous = current
+			node.portal = portal
+
+		}
+
+	}
+
+	if found == nil {
+		return nil
+	}
+
+	portals := []*NavPortal{}
+	for node := found; node.previous != nil; node = node.previous {
+		portals = append([]*NavPortal{node.portal}, portals...)
+	}
+
+	return &NavPath{Points: navPullString(start, end, portals)}
+
+}
+
+// navPullString returns the shortest path from the start to the end that passes through each of the given NavPortals in turn, using
+// the "simple stupid funnel algorithm": a funnel reaching out from the last corner of the path is narrowed down through each
+// NavPortal, and when one side of the funnel would cross over the other, the path turns a corner there.
+func navPullString(start, end vector.Vector, portals []*NavPortal) []vector.Vector {
+
+	// A NavPortal's End is on the left when walking out through it, since its NavPolygon winds counter-clockwise.
+	lefts := []vector.Vector{start}
+	rights := []vector.Vector{start}
+
+	for _, portal := range portals {
+		lefts = append(lefts, portal.End)
+		rights = append(rights, portal.Start)
+	}
+
+	lefts = append(lefts, end)
+	rights = append(rights, end)
+
+	points := []vector.Vector{start.Clone()}
+
+	apex, left, right := start, start, start
+	apexIndex, leftIndex, rightIndex := 0, 0, 0
+
+	same := func(a, b vector.Vector) bool {
+		return math.Abs(a[0]-b[0]) < 1e-9 && math.Abs(a[2]-b[2]) < 1e-9
+	}
+
+	for i := 1; i < len(lefts); i++ {
+
+		nextLeft, nextRight := lefts[i], rights[i]
+
+		// The right side of the funnel moves in if it can...
+		if navCross(apex, right, nextRight) >= 0 {
+
+			if same(apex, right) || navCross(apex, left, nextRight) < 0 {
+				right, rightIndex = nextRight, i
+			} else {
+
+				// ...but if it would cross over the left side, the path turns around the left side's corner instead, and the funnel
+				// starts over from there.
+				points = append(points, left.Clone())
+				apex, apexIndex = left, leftIndex
+				right, rightIndex = apex, apexIndex
+				i = apexIndex
+				continue
+
+			}
+
+		}
+
+		// The same goes for the left side.
+		if navCross(apex, left, nextLeft) <= 0 {
+
+			if same(apex, left) || navCross(apex, right, nextLeft) > 0 {
+				left, leftIndex = nextLeft, i
+			} else {
+
+				points = append(points, right.Clone())
+				apex, apexIndex = right, rightIndex
+				left, leftIndex = apex, apexIndex
+				i = apexIndex
+				continue
+
+			}
+
+		}
+
+	}
+
+	if last := points[len(points)-1]; !same(last, end) || math.Abs(last[1]-end[1]) > 1e-9 {
+		points = append(points, end.Clone())
+	}
+
+	return points
+
+}
+
+// navCross returns twice the signed area of the triangle made of the given points when viewed from above; it's positive if the
+// point c is to the left of the line running from a to b (in the same sense that NavPolygons wind counter-clockwise).
+func navCross(a, b, c vector.Vector) float64 {
+	return (b[2]-a[2])*(c[0]-a[0]) - (b[0]-a[0])*(c[2]-a[2])
+}
+
+// navClosestPointOnSegment returns the point on the line segment from start to end that's closest to the given position when
+// viewed from above.
+func navClosestPointOnSegment(start, end, position vector.Vector) vector.Vector {
+
+	dx, dz := end[0]-start[0], end[2]-start[2]
+	length := dx*dx + dz*dz
+
+	if length == 0 {
+		return start.Clone()
+	}
+
+	t := math.Max(0, math.Min(1, ((position[0]-start[0])*dx+(position[2]-start[2])*dz)/length))
+
+	return start.Add(end.Sub(start).Scale(t))
+
+}
@@ -0,0 +1,128 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kvartborg/vector"
+)
+
+// newNavTestLevel returns a level made of a floor with a box in the middle of it, with BoundingObjects for each, along with a
+// NavMesh built from it.
+func newNavTestLevel() (*Node, *NavMesh) {
+
+	floor := NewModel(NewPlane(), "floor")
+	floor.SetLocalScale(5, 1, 5)
+	floor.AddChildren(NewBoundingTriangles("floor bounds", floor.Mesh, 0))
+
+	box := NewModel(NewCube(), "box")
+	box.SetLocalPosition(0, 1, 0)
+	box.AddChildren(NewBoundingAABB("box bounds", 2, 2, 2))
+
+	level := NewNode("level")
+	level.AddChildren(floor, box)
+
+	return level, NewNavMeshBuilder().Build(level)
+
+}
+
+func TestNavMeshFindPath(t *testing.T) {
+
+	_, navMesh := newNavTestLevel()
+
+	start := vector.Vector{-3, 0, 0}
+	end := vector.Vector{3, 0, 0}
+
+	path := navMesh.FindPath(start, end)
+
+	if path == nil || path.Points[0].Sub(start).Magnitude() > 1e-6 || path.Points[len(path.Points)-1].Sub(end).Magnitude() > 1e-6 {
+		t.Fatalf("the path should go from the start to the end; got %v", path)
+	}
+
+	// The shortest way around the box turns at two of its corners (or rather, at the corners of the NavMesh around them).
+	if len(path.Points) != 4 || path.Distance() > 7.5 {
+		t.Fatalf("the path should be pulled tight around the box; got %v", path.Points)
+	}
+
+	for i := 1; i < len(path.Points); i++ {
+
+		for step := 0.0; step <= 1; step += 0.05 {
+			point := path.Points[i-1].Add(path.Points[i].Sub(path.Points[i-1]).Scale(step))
+			if polygon := navPolygonAt(navMesh, point); polygon == nil || polygon.Center[1] > 0.1 {
+				t.Fatalf("the path should stay on the floor's NavMesh; %v isn't on it", point)
+			}
+		}
+
+	}
+
+	// A path that doesn't have to go around anything is a straight line.
+	if path = navMesh.FindPath(vector.Vector{-3, 0, -3}, vector.Vector{3, 0, -3}); path == nil || len(path.Points) != 2 {
+		t.Fatalf("a path with nothing in the way should be a straight line; got %v", path)
+	}
+
+	// Positions off of the NavMesh are moved onto it.
+	if path = navMesh.FindPath(vector.Vector{-10, 0, -3}, vector.Vector{3, 0, -10}); path == nil || path.Points[0].Sub(vector.Vector{-4.5, 0, -3}).Magnitude() > 1e-6 || path.Points[1].Sub(vector.Vector{3, 0, -4.5}).Magnitude() > 1e-6 {
+		t.Fatalf("the ends of the path should be moved onto the NavMesh; got %v", path)
+	}
+
+	// The top of the box can't be reached from the floor.
+	if path = navMesh.FindPath(start, vector.Vector{0, 2, 0}); path != nil {
+		t.Fatalf("there shouldn't be a path up onto the box; got %v", path.Points)
+	}
+
+	if navigator := NewNavigator(navMesh.FindPath(start, end)); math.Abs(navigator.Path.Distance()-navMesh.FindPath(start, end).Distance()) > 1e-9 {
+		t.Fatalf("a Navigator should be able to follow a NavPath")
+	}
+
+}
+
+func TestNavAgent(t *testing.T) {
+
+	level, navMesh := newNavTestLevel()
+
+	// Without a CharacterController, the NavAgent moves its Node straight along the path.
+	node := NewNode("agent")
+	node.SetLocalPosition(-3, 0, 0)
+
+	arrived := false
+	agent := NewNavAgent(navMesh, node, nil)
+	agent.OnArrive = func() { arrived = true }
+
+	if !agent.SetTarget(vector.Vector{3, 0, 0}) {
+		t.Fatalf("the NavAgent should find a path to its target")
+	}
+
+	for i := 0; i < 300 && agent.Moving(); i++ {
+		agent.Update()
+	}
+
+	if !arrived || agent.Moving() || node.WorldPosition().Sub(vector.Vector{3, 0, 0}).Magnitude() > agent.ArriveDistance {
+		t.Fatalf("the NavAgent should have reached its target; position: %v", node.WorldPosition())
+	}
+
+	// With a CharacterController, the NavAgent walks, and finds another way around if something gets in its way.
+	player := NewNode("player")
+	capsule := NewBoundingCapsule("player capsule", 2, 0.5)
+	capsule.SetLocalPosition(0, 1, 0)
+	player.AddChildren(capsule)
+	player.SetLocalPosition(-3, 0, 0)
+
+	agent = NewNavAgent(navMesh, player, NewCharacterController(player, capsule))
+	agent.SetTarget(vector.Vector{3, 0, 0})
+
+	side := math.Copysign(1, agent.Path().Points[1][2])
+
+	// The wall blocks the path the NavAgent was going to take, between the box and the edge of the floor.
+	wall := NewBoundingAABB("wall", 1, 2, 4)
+	wall.SetLocalPosition(0, 1, side*3)
+	level.AddChildren(wall)
+
+	for i := 0; i < 1000 && agent.Moving(); i++ {
+		agent.Update(level)
+	}
+
+	if position := player.WorldPosition(); agent.Moving() || math.Hypot(position[0]-3, position[2]) > agent.ArriveDistance+1e-3 {
+		t.Fatalf("the NavAgent should have found its way around the wall to its target; position: %v", position)
+	}
+
+}
@@ -20,10 +20,14 @@ const (
 	NodeTypeGrid   NodeType = "NodeGrid"   // NodeTypeGrid represents specifically a Grid
 
 	NodeTypeGridPoint NodeType = "Node_GridPoint" // NodeTypeGrid represents specifically a GridPoint (note the extra underscore to ensure !NodeTypeGridPoint.Is(NodeTypeGrid))
+	NodeTypePathGraph NodeType = "Node_PathGraph" // NodeTypePathGraph represents specifically a PathGraph (note the extra underscore to ensure !NodeTypePathGraph.Is(NodeTypePath))
+	NodeTypeWaypoint  NodeType = "Node_Waypoint"  // NodeTypeWaypoint represents specifically a Waypoint
 
 	NodeTypeBoundingObject    NodeType = "NodeBounding"          // NodeTypeBoundingObject represents any generic bounding object
 	NodeTypeBoundingAABB      NodeType = "NodeBoundingAABB"      // NodeTypeBoundingAABB represents specifically a BoundingAABB
 	NodeTypeBoundingCapsule   NodeType = "NodeBoundingCapsule"   // NodeTypeBoundingCapsule represents specifically a BoundingCapsule
+	NodeTypeBoundingConvex    NodeType = "NodeBoundingConvex"    // NodeTypeBoundingConvex represents specifically a BoundingConvex
+	NodeTypeBoundingOBB       NodeType = "NodeBoundingOBB"       // NodeTypeBoundingOBB represents specifically a BoundingOBB
 	NodeTypeBoundingTriangles NodeType = "NodeBoundingTriangles" // NodeTypeBoundingTriangles represents specifically a BoundingTriangles object
 	NodeTypeBoundingSphere    NodeType = "NodeBoundingSphere"    // NodeTypeBoundingSphere represents specifically a BoundingSphere BoundingObject
 
@@ -32,6 +36,13 @@ const (
 	NodeTypePointLight       NodeType = "NodeLightPoint"       // NodeTypePointLight represents specifically a point light
 	NodeTypeDirectionalLight NodeType = "NodeLightDirectional" // NodeTypeDirectionalLight represents specifically a directional (sun) light
 	NodeTypeCubeLight        NodeType = "NodeLightCube"        // NodeTypeCubeLight represents, specifically, a cube light
+	NodeTypeSpotLight        NodeType = "NodeLightSpot"        // NodeTypeSpotLight represents specifically a spot light
+	NodeTypeRectLight        NodeType = "NodeLightRect"        // NodeTypeRectLight represents specifically a rectangular area light
+	NodeTypeLightProbe       NodeType = "NodeLightProbe"       // NodeTypeLightProbe represents specifically a light probe
+
+	NodeTypeFogVolume       NodeType = "NodeFogVolume"       // NodeTypeFogVolume represents specifically a FogVolume
+	NodeTypeReflectionPlane NodeType = "NodeReflectionPlane" // NodeTypeReflectionPlane represents specifically a ReflectionPlane
+	NodeTypeTriggerVolume   NodeType = "NodeTriggerVolume"   // NodeTypeTriggerVolume represents specifically a TriggerVolume
 )
 
 // Is returns true if a NodeType satisfies another NodeType category. A specific node type can be said to
@@ -88,8 +99,10 @@ type INode interface {
 	// AddChildren parents the provided children Nodes to the passed parent Node, inheriting its transformations and being under it in the scenegraph
 	// hierarchy. If the children are already parented to other Nodes, they are unparented before doing so.
 	AddChildren(...INode)
-	// RemoveChildren removes the provided children from this object.
+	// RemoveChildren removes the provided children from this object, clearing any references between Models in the removed hierarchies
+	// and Nodes outside of them (like dynamic batches and skinning armatures).
 	RemoveChildren(...INode)
+	removeChild(child INode) bool
 
 	// updateLocalTransform(newParent INode)
 	dirtyTransform()
@@ -139,6 +152,8 @@ type INode interface {
 	WorldScale() vector.Vector
 	// SetWorldScaleVec sets the object's absolute world scale. scale should be a 3D vector (i.e. X, Y, and Z components).
 	SetWorldScaleVec(scale vector.Vector)
+	// SetWorldScale sets the object's absolute world scale.
+	SetWorldScale(w, h, d float64)
 
 	// Move moves a Node in local space by the x, y, and z values provided.
 	Move(x, y, z float64)
@@ -321,6 +336,10 @@ func (props *Properties) Get(tagName string) *Property {
 // Node represents a minimal struct that fully implements the Node interface. Model and Camera embed Node
 // into their structs to automatically easily implement Node.
 type Node struct {
+	// Layers are the render layers the Node is on; Cameras only render Models on at least one of the layers in their LayerMask.
+	// Each Model's own Layers are used, so children aren't affected by the Layers of their parents. Defaults to RenderLayersDefault.
+	Layers RenderLayers
+
 	name                  string
 	position              vector.Vector
 	scale                 vector.Vector
@@ -346,6 +365,7 @@ type Node struct {
 func NewNode(name string) *Node {
 
 	nb := &Node{
+		Layers:           RenderLayersDefault,
 		name:             name,
 		position:         vector.Vector{0, 0, 0},
 		scale:            vector.Vector{1, 1, 1},
@@ -399,6 +419,7 @@ func (node *Node) Clone() INode {
 	newNode.scale = node.scale.Clone()
 	newNode.rotation = node.rotation.Clone()
 	newNode.visible = node.visible
+	newNode.Layers = node.Layers
 	newNode.data = node.data
 
 	newNode.props = node.props.Clone()
@@ -588,21 +609,12 @@ func (node *Node) SetLocalPositionVec(position vector.Vector) {
 }
 
 // SetWorldPositionVec sets the object's world position (position relative to the world origin point of {0, 0, 0}).
-// position needs to be a 3D vector (i.e. X, Y, and Z components).
+// position needs to be a 3D vector (i.e. X, Y, and Z components). The local position is calculated using the inverse of the parent's
+// full transform, so this works properly even if the parent (or any of its parents) is rotated and non-uniformly scaled.
 func (node *Node) SetWorldPositionVec(position vector.Vector) {
 
 	if node.parent != nil {
-
-		parentTransform := node.parent.Transform()
-		parentPos, parentScale, parentRot := parentTransform.Decompose()
-
-		pr := parentRot.Transposed().MultVec(position.Sub(parentPos))
-		pr[0] /= parentScale[0]
-		pr[1] /= parentScale[1]
-		pr[2] /= parentScale[2]
-
-		node.position = pr
-
+		node.position = node.parent.Transform().Inverted().MultVec(position)
 	} else {
 		node.position[0] = position[0]
 		node.position[1] = position[1]
@@ -670,18 +682,18 @@ func (node *Node) SetWorldScaleVec(scale vector.Vector) {
 	node.SetWorldScale(scale[0], scale[1], scale[2])
 }
 
-// SetWorldScale sets the object's absolute world scale.
+// SetWorldScale sets the object's absolute world scale. The object's world rotation is unaffected, and the parent's scale is taken
+// into account along the object's own (rotated) axes, so this works properly even if the parent is non-uniformly scaled.
 func (node *Node) SetWorldScale(w, h, d float64) {
 
 	if node.parent != nil {
 
-		parentTransform := node.parent.Transform()
-		_, parentScale, _ := parentTransform.Decompose()
+		axes := node.parentScaledAxes(node.rotation)
 
 		node.scale = vector.Vector{
-			w / parentScale[0],
-			h / parentScale[1],
-			d / parentScale[2],
+			w / axes[0].Magnitude(),
+			h / axes[1].Magnitude(),
+			d / axes[2].Magnitude(),
 		}
 
 	} else {
@@ -694,6 +706,20 @@ func (node *Node) SetWorldScale(w, h, d float64) {
 
 }
 
+// parentScaledAxes returns the rows of the given local rotation after being transformed by the Node's parent's transform (but not
+// the Node's own scale); their directions are the Node's world-space axes, while their lengths are how much the parent scales each axis.
+func (node *Node) parentScaledAxes(rotation Matrix4) [3]vector.Vector {
+
+	rows := rotation.Mult(node.parent.Transform())
+
+	return [3]vector.Vector{
+		rows.Row(0)[:3],
+		rows.Row(1)[:3],
+		rows.Row(2)[:3],
+	}
+
+}
+
 // LocalRotation returns the object's local rotation Matrix4.
 func (node *Node) LocalRotation() Matrix4 {
 	return node.rotation.Clone()
@@ -712,20 +738,51 @@ func (node *Node) WorldRotation() Matrix4 {
 	return rotation
 }
 
-// SetWorldRotation sets an object's rotation to the provided rotation Matrix4.
+// SetWorldRotation sets an object's rotation to the provided rotation Matrix4. The object's world scale is unaffected. Note that if
+// the parent is non-uniformly scaled, not every rotation can be represented without shearing the object; in that case, the object is
+// rotated as closely to the provided rotation as possible.
 func (node *Node) SetWorldRotation(rotation Matrix4) {
 
 	if node.parent != nil {
 
-		parentTransform := node.parent.Transform()
-		_, _, parentRot := parentTransform.Decompose()
-		node.rotation.Set(parentRot.Transposed().Mult(rotation))
+		// The world scale is calculated from the local scale rather than decomposed from the world transform, so that it survives
+		// being zero'd out.
+		axes := node.parentScaledAxes(node.rotation)
+		worldScale := vector.Vector{
+			node.scale[0] * axes[0].Magnitude(),
+			node.scale[1] * axes[1].Magnitude(),
+			node.scale[2] * axes[2].Magnitude(),
+		}
+
+		// Bring the rotation's axes into the parent's space, and then re-orthonormalize them (which only does anything if the parent's
+		// transform would shear the object).
+		local := rotation.Mult(node.parent.Transform().Inverted())
+
+		x := local.Row(0)[:3].Unit()
+		y := local.Row(1)[:3]
+		y = y.Sub(x.Scale(dot(x, y))).Unit()
+		z := local.Row(2)[:3]
+		z = z.Sub(x.Scale(dot(x, z))).Sub(y.Scale(dot(y, z))).Unit()
+
+		node.rotation = NewMatrix4()
+		node.rotation.SetRow(0, vector.Vector{x[0], x[1], x[2], 0})
+		node.rotation.SetRow(1, vector.Vector{y[0], y[1], y[2], 0})
+		node.rotation.SetRow(2, vector.Vector{z[0], z[1], z[2], 0})
+
+		axes = node.parentScaledAxes(node.rotation)
+
+		node.scale = vector.Vector{
+			worldScale[0] / axes[0].Magnitude(),
+			worldScale[1] / axes[1].Magnitude(),
+			worldScale[2] / axes[2].Magnitude(),
+		}
 
 	} else {
 		node.rotation.Set(rotation)
 	}
 
 	node.dirtyTransform()
+
 }
 
 // Move moves a Node in local space by the x, y, and z values provided.
@@ -805,7 +862,8 @@ func (node *Node) addChildren(parent INode, children ...INode) {
 	for _, child := range children {
 		// child.updateLocalTransform(parent)
 		if child.Parent() != nil {
-			child.Parent().RemoveChildren(child)
+			// The child is still in use, so its references to other Nodes are kept, unlike with RemoveChildren().
+			child.Parent().removeChild(child)
 		}
 		child.setParent(parent)
 		child.dirtyTransform()
@@ -819,22 +877,97 @@ func (node *Node) AddChildren(children ...INode) {
 	node.addChildren(node, children...)
 }
 
-// RemoveChildren removes the provided children from this object.
+// RemoveChildren removes the provided children from this object. Any references between Models in the removed hierarchies and Nodes
+// outside of them are cleared, so that removed Nodes can be garbage collected (or pooled and re-added later) without anything keeping
+// them alive or pointing to them. That is to say, removed Models that are dynamically batched under a Model outside of the removed
+// hierarchy are removed from that batch (and removed Models lose the Models batched under them from outside of the removed hierarchy),
+// while skinned Models whose armatures lie on the other side of the removal (i.e. removed Models skinned by an armature left in the
+// tree, or Models left in the tree skinned by a removed armature) are detached from those armatures. A detached Model renders
+// unskinned, and can be attached to an armature again using Model.ReassignBones().
 func (node *Node) RemoveChildren(children ...INode) {
 
 	for _, child := range children {
-		for i, c := range node.children {
-			if c == child {
-				// child.updateLocalTransform(nil)
-				child.setParent(nil)
-				child.dirtyTransform()
-				node.children[i] = nil
-				node.children = append(node.children[:i], node.children[i+1:]...)
-				break
+		if node.removeChild(child) {
+			releaseReferences(child, node)
+		}
+	}
+
+}
+
+// removeChild removes the child from the Node's children, returning if it was found.
+func (node *Node) removeChild(child INode) bool {
+
+	for i, c := range node.children {
+		if c == child {
+			// child.updateLocalTransform(nil)
+			child.setParent(nil)
+			child.dirtyTransform()
+			last := len(node.children) - 1
+			copy(node.children[i:], node.children[i+1:])
+			node.children[last] = nil // So the backing array doesn't keep the last child alive
+			node.children = node.children[:last]
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// releaseReferences clears references between the Models in the hierarchy starting at the Node provided and Nodes in other trees,
+// after the Node was removed from the given former parent.
+func releaseReferences(node, formerParent INode) {
+
+	top := topNode(node)
+	removed := append(NodeFilter{node}, node.ChildrenRecursive()...)
+	hasBones := false
+
+	for _, n := range removed {
+
+		hasBones = hasBones || n.IsBone()
+
+		model, ok := n.(*Model)
+		if !ok {
+			continue
+		}
+
+		if model.DynamicBatchOwner != nil && topNode(model.DynamicBatchOwner) != top {
+			model.DynamicBatchOwner.DynamicBatchRemove(model)
+		}
+
+		for _, batched := range model.DynamicBatchModels {
+			for _, other := range append([]*Model{}, batched...) {
+				if topNode(other) != top {
+					model.DynamicBatchRemove(other)
+				}
 			}
 		}
+
+		if model.SkinRoot != nil && topNode(model.SkinRoot) != top {
+			model.detachArmature()
+		}
+
 	}
 
+	// Models left in the former tree can't be skinned by a removed armature either. Bones don't point back to the Models they
+	// skin, so this has to search the tree, but only if an armature was removed.
+	if hasBones {
+		for _, n := range topNode(formerParent).ChildrenRecursive() {
+			if model, ok := n.(*Model); ok && model.SkinRoot != nil && topNode(model.SkinRoot) == top {
+				model.detachArmature()
+			}
+		}
+	}
+
+}
+
+// topNode returns the top-most parent of the Node provided, or the Node itself if it has no parent. Unlike Node.Root(), this works
+// for trees that aren't part of a Scene.
+func topNode(node INode) INode {
+	for node.Parent() != nil {
+		node = node.Parent()
+	}
+	return node
 }
 
 // Unparent unparents the Node from its parent, removing it from the scenegraph. Note that this needs to be overridden for objects that embed Node.
@@ -850,11 +983,14 @@ func (node *Node) Children() NodeFilter {
 }
 
 // ChildrenRecursive() returns the Node's recursive children (i.e. children, grandchildren, etc)
-// as a NodeFilter.
+// as a NodeFilter. The Nodes are returned in depth-first order (so each Node is followed by its own
+// children before its next sibling), with siblings in the order they were added as children; this order
+// is stable as long as the hierarchy doesn't change.
 func (node *Node) ChildrenRecursive() NodeFilter {
-	out := node.Children()
+	out := NodeFilter{}
 
 	for _, child := range node.children {
+		out = append(out, child)
 		out = append(out, child.ChildrenRecursive()...)
 	}
 	return out
@@ -913,6 +1049,12 @@ func (node *Node) HierarchyAsString() string {
 				prefix = "GRID"
 			} else if nodeType.Is(NodeTypeGridPoint) {
 				prefix = "GPOINT"
+			} else if nodeType.Is(NodeTypePathGraph) {
+				prefix = "PGRAPH"
+			} else if nodeType.Is(NodeTypeWaypoint) {
+				prefix = "WPOINT"
+			} else if nodeType.Is(NodeTypeTriggerVolume) {
+				prefix = "TRIG"
 			} else if nodeType.Is(NodeTypeAmbientLight) {
 				prefix = "AMB"
 			} else if nodeType.Is(NodeTypeDirectionalLight) {
@@ -921,12 +1063,22 @@ func (node *Node) HierarchyAsString() string {
 				prefix = "POINT"
 			} else if nodeType.Is(NodeTypeCubeLight) {
 				prefix = "CUBE"
+			} else if nodeType.Is(NodeTypeSpotLight) {
+				prefix = "SPOT"
+			} else if nodeType.Is(NodeTypeRectLight) {
+				prefix = "RECT"
+			} else if nodeType.Is(NodeTypeLightProbe) {
+				prefix = "PROBE"
 			} else if nodeType.Is(NodeTypeBoundingSphere) {
 				prefix = "BS"
 			} else if nodeType.Is(NodeTypeBoundingAABB) {
 				prefix = "AABB"
 			} else if nodeType.Is(NodeTypeBoundingCapsule) {
 				prefix = "CAP"
+			} else if nodeType.Is(NodeTypeBoundingOBB) {
+				prefix = "OBB"
+			} else if nodeType.Is(NodeTypeBoundingConvex) {
+				prefix = "CVX"
 			} else if nodeType.Is(NodeTypeBoundingTriangles) {
 				prefix = "TRI"
 			} else {
@@ -0,0 +1,97 @@
+package tetra3d
+
+import (
+	"testing"
+)
+
+func TestRemoveChildrenReleasesReferences(t *testing.T) {
+
+	scene := NewScene("pooling")
+	root := scene.Root.(*Node)
+
+	owner := NewModel(NewCube(), "owner")
+
+	armature := NewNode("armature")
+	bone := NewNode("bone")
+	bone.isBone = true
+	armature.AddChildren(bone)
+
+	scene.Root.AddChildren(owner, armature)
+
+	mesh := NewCube()
+
+	// A batched Model underneath its owner should stay batched when the owner is removed along with it.
+	child := NewModel(mesh, "child")
+	owner.AddChildren(child)
+	owner.DynamicBatchAdd(owner.Mesh.MeshParts[0], child)
+
+	for i := 0; i < 1000; i++ {
+
+		model := NewModel(mesh, "pooled")
+		model.Skinned = true
+		model.SkinRoot = armature
+		model.bones = make([][]*Node, mesh.VertexCount)
+		for v := range model.bones {
+			model.bones[v] = []*Node{bone}
+		}
+
+		scene.Root.AddChildren(model)
+
+		if err := owner.DynamicBatchAdd(owner.Mesh.MeshParts[0], model); err != nil {
+			t.Fatal(err)
+		}
+
+		if i%2 == 0 {
+			model.Unparent()
+		} else {
+			model.Dispose()
+		}
+
+		if model.DynamicBatchOwner != nil || model.SkinRoot != nil || model.Skinned {
+			t.Fatalf("removed Model %d is still batched or skinned", i)
+		}
+
+		for _, bones := range model.bones {
+			for _, b := range bones {
+				if b == bone {
+					t.Fatalf("removed Model %d still references the armature's bones", i)
+				}
+			}
+		}
+
+	}
+
+	if count := len(owner.DynamicBatchModels[owner.Mesh.MeshParts[0]]); count != 1 {
+		t.Fatalf("expected only the owner's child to remain batched, but %d Models are batched", count)
+	}
+
+	if len(root.children) != 2 {
+		t.Fatalf("expected the scene root to have 2 children, but it has %d", len(root.children))
+	}
+
+	for _, c := range root.children[len(root.children):cap(root.children)] {
+		if c != nil {
+			t.Fatal("the scene root's children slice retains a reference to a removed child")
+		}
+	}
+
+	owner.Unparent()
+
+	if child.DynamicBatchOwner != owner {
+		t.Fatal("removing a batch owner along with the Models batched under it shouldn't unbatch them")
+	}
+
+	// Models detached from their armatures (rather than disposed) can be reattached.
+	detached := NewModel(mesh, "reattached")
+	detached.Skinned = true
+	detached.SkinRoot = armature
+	detached.bones = [][]*Node{{bone}}
+	scene.Root.AddChildren(detached)
+	detached.Unparent()
+	detached.ReassignBones(armature)
+
+	if !detached.Skinned || detached.SkinRoot != armature || detached.bones[0][0] != bone {
+		t.Fatal("ReassignBones() should reattach a detached Model to the armature")
+	}
+
+}
@@ -170,3 +170,25 @@ func (nc NodeFilter) Grids() []*Grid {
 	}
 	return grids
 }
+
+// PathGraphs returns a slice of the PathGraphs contained within the NodeFilter.
+func (nc NodeFilter) PathGraphs() []*PathGraph {
+	graphs := make([]*PathGraph, 0, len(nc))
+	for _, n := range nc {
+		if m, ok := n.(*PathGraph); ok {
+			graphs = append(graphs, m)
+		}
+	}
+	return graphs
+}
+
+// TriggerVolumes returns a slice of the TriggerVolumes contained within the NodeFilter.
+func (nc NodeFilter) TriggerVolumes() []*TriggerVolume {
+	triggers := make([]*TriggerVolume, 0, len(nc))
+	for _, n := range nc {
+		if m, ok := n.(*TriggerVolume); ok {
+			triggers = append(triggers, m)
+		}
+	}
+	return triggers
+}
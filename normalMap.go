@@ -0,0 +1,113 @@
+package tetra3d
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/kvartborg/vector"
+)
+
+// The normal map shader renders one component of the world normals of normal-mapped triangles to a normal buffer. As Ebitengine vertices
+// have no custom attributes, each vertex holds the matching component of its world tangent, bitangent, and normal in its color, which
+// transforms the tangent-space normal read from the normal map into world space. The component is stored in the red channel, mapped
+// from -1 to 1 to 0 to 1.
+var normalMapShaderText = []byte(
+	`package main
+
+	func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+
+		tangentNormal := imageSrc0At(texCoord).rgb*2 - 1
+		component := dot(color.rgb, tangentNormal)
+
+		return vec4(component*0.5+0.5, 0, 0, 1)
+
+	}
+	`,
+)
+
+// These vertex lists are only allocated once a normal-mapped Material is rendered; there's one list for each component of the normals.
+var normalMapVertexLists [3][]ebiten.Vertex
+
+// prepareNormalMapping creates the shader, buffers, and vertex lists used for normal mapping if they haven't been created yet.
+func (camera *Camera) prepareNormalMapping() {
+
+	camera.preparePixelLighting()
+
+	if camera.normalMapShader == nil {
+
+		var err error
+
+		camera.normalMapShader, err = ebiten.NewShader(normalMapShaderText)
+
+		if err != nil {
+			panic(err)
+		}
+
+	}
+
+	if camera.normalMapIntermediates[0] == nil {
+		w, h := camera.resultColorTexture.Size()
+		for i := range camera.normalMapIntermediates {
+			camera.normalMapIntermediates[i] = ebiten.NewImage(w, h)
+		}
+	}
+
+	if normalMapVertexLists[0] == nil {
+		for i := range normalMapVertexLists {
+			normalMapVertexLists[i] = make([]ebiten.Vertex, ebiten.MaxIndicesNum)
+		}
+	}
+
+}
+
+// setNormalMapVertices sets up the vertices at the given index of the normal map vertex lists and the pixel light vertex list for
+// rendering a normal-mapped vertex, using the screen position of the source vertex, the given position in the normal map (in pixels),
+// and the given world position, normal, and tangent (with its handedness in its W component).
+func setNormalMapVertices(index int, src ebiten.Vertex, srcX, srcY float32, position, normal, tangent vector.Vector) {
+
+	normal = normal.Unit()
+
+	// The tangent is made perpendicular to the normal, as the normal may have been changed by skinning or morphing.
+	worldTangent := tangent[:3].Sub(normal.Scale(dot(normal, tangent)))
+	if worldTangent.Magnitude() > 0 {
+		worldTangent = worldTangent.Unit()
+	}
+
+	bitangent, _ := normal.Cross(worldTangent)
+	bitangent = bitangent.Scale(tangent[3])
+
+	for i := range normalMapVertexLists {
+		dst := &normalMapVertexLists[i][index]
+		dst.DstX = src.DstX
+		dst.DstY = src.DstY
+		dst.SrcX = srcX
+		dst.SrcY = srcY
+		dst.ColorR = float32(worldTangent[i])
+		dst.ColorG = float32(bitangent[i])
+		dst.ColorB = float32(normal[i])
+		dst.ColorA = 1
+	}
+
+	// The pixel light shader reads normals from the normal buffers (at the same screen position as the vertex), so the world position
+	// is passed through the vertex's color instead.
+	dst := &pixelLightVertexList[index]
+	dst.DstX = src.DstX
+	dst.DstY = src.DstY
+	dst.SrcX = src.DstX
+	dst.SrcY = src.DstY
+	dst.ColorR = float32(position[0])
+	dst.ColorG = float32(position[1])
+	dst.ColorB = float32(position[2])
+	dst.ColorA = 1
+
+}
+
+// drawNormalMap renders the world normals of the triangles in the normal map vertex lists to the normal buffers.
+func (camera *Camera) drawNormalMap(normalMap *ebiten.Image) {
+
+	for i, img := range camera.normalMapIntermediates {
+		img.Clear()
+		img.DrawTrianglesShader(normalMapVertexLists[i][:vertexListIndex], indexList[:vertexListIndex], camera.normalMapShader, &ebiten.DrawTrianglesShaderOptions{
+			Images: [4]*ebiten.Image{normalMap},
+		})
+	}
+
+}
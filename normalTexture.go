@@ -0,0 +1,115 @@
+package tetra3d
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/kvartborg/vector"
+)
+
+const (
+	NormalSpaceWorld = iota // Normals are rendered in world space
+	NormalSpaceView         // Normals are rendered in view space (relative to the Camera, with +Z pointing towards it)
+)
+
+// The normal composite shader draws the normals rendered for a MeshPart to the Camera's normal texture where the MeshPart passed the
+// depth test (i.e. where it was rendered to the depth buffer).
+var normalCompositeShaderText = []byte(
+	`package main
+
+	func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+
+		if imageSrc1UnsafeAt(texCoord).a > 0 {
+			return imageSrc0UnsafeAt(texCoord)
+		}
+
+		discard()
+
+	}
+	`,
+)
+
+// This vertex list is only allocated once a Camera renders normals; each vertex holds its normal in its color, mapped from -1 to 1
+// to 0 to 1.
+var normalVertexList []ebiten.Vertex
+
+// prepareNormalRendering creates the shader, textures, and vertex list used to render normals if they haven't been created yet.
+func (camera *Camera) prepareNormalRendering() {
+
+	if camera.normalCompositeShader == nil {
+
+		var err error
+
+		camera.normalCompositeShader, err = ebiten.NewShader(normalCompositeShaderText)
+
+		if err != nil {
+			panic(err)
+		}
+
+	}
+
+	if camera.resultNormalTexture == nil {
+		w, h := camera.resultColorTexture.Size()
+		camera.resultNormalTexture = ebiten.NewImage(w, h)
+		camera.normalIntermediate = ebiten.NewImage(w, h)
+	}
+
+	if normalVertexList == nil {
+		normalVertexList = make([]ebiten.Vertex, ebiten.MaxIndicesNum)
+	}
+
+}
+
+// setNormalVertex sets up the vertex at the given index of the normal vertex list, using the screen position of the source vertex
+// and the given world normal, which is converted to the Camera's NormalSpace.
+func (camera *Camera) setNormalVertex(index int, src ebiten.Vertex, normal vector.Vector, viewRotation Matrix4) {
+
+	if camera.NormalSpace == NormalSpaceView {
+		normal = viewRotation.MultVec(normal)
+	}
+
+	if normal.Magnitude() > 0 {
+		normal = normal.Unit()
+	}
+
+	dst := &normalVertexList[index]
+	dst.DstX = src.DstX
+	dst.DstY = src.DstY
+	dst.SrcX = 0
+	dst.SrcY = 0
+	dst.ColorR = float32(normal[0]*0.5 + 0.5)
+	dst.ColorG = float32(normal[1]*0.5 + 0.5)
+	dst.ColorB = float32(normal[2]*0.5 + 0.5)
+	dst.ColorA = 1
+
+}
+
+// drawNormals renders the triangles in the normal vertex list to the Camera's normal texture; if the Camera's rendering depth, only
+// the parts of the triangles that were rendered to the depth intermediate texture are drawn.
+func (camera *Camera) drawNormals() {
+
+	if !camera.RenderDepth {
+		camera.resultNormalTexture.DrawTriangles(normalVertexList[:vertexListIndex], indexList[:vertexListIndex], defaultImg, nil)
+		return
+	}
+
+	camera.normalIntermediate.Clear()
+	camera.normalIntermediate.DrawTriangles(normalVertexList[:vertexListIndex], indexList[:vertexListIndex], defaultImg, nil)
+
+	w, h := camera.resultNormalTexture.Size()
+
+	camera.resultNormalTexture.DrawRectShader(w, h, camera.normalCompositeShader, &ebiten.DrawRectShaderOptions{
+		Images: [4]*ebiten.Image{camera.normalIntermediate, camera.depthIntermediate},
+	})
+
+}
+
+// NormalTexture returns the Camera's final result normal texture from any previous Render() or RenderNodes() calls, holding the
+// normals of the opaque triangles rendered in the Camera's NormalSpace, with each component mapped from -1 to 1 to 0 to 1 in the
+// texture's RGB channels (so a pixel's normal is its color * 2 - 1); pixels that nothing was rendered to are transparent. If
+// Camera.RenderNormals is set to false, the function will return nil instead.
+func (camera *Camera) NormalTexture() *ebiten.Image {
+	if !camera.RenderNormals {
+		return nil
+	}
+	camera.prepareNormalRendering()
+	return camera.resultNormalTexture
+}
@@ -0,0 +1,54 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCameraNormalTexture(t *testing.T) {
+
+	scene := NewScene("normals")
+
+	camera := NewCamera(32, 32)
+	camera.RenderNormals = true
+	camera.Rotate(0, 1, 0, math.Pi/2)
+	scene.Root.AddChildren(camera)
+
+	// The plane is parented to the camera, facing it.
+	plane := NewModel(NewPlane(), "plane")
+	plane.Move(0, 0, -5)
+	plane.Rotate(1, 0, 0, math.Pi/2)
+	camera.AddChildren(plane)
+
+	camera.Clear()
+	camera.RenderNodes(scene, scene.Root)
+
+	if camera.NormalTexture() == nil {
+		t.Fatalf("expected the camera to have a normal texture")
+	}
+
+	// In world space, the plane faces along the X axis.
+	for i, v := range normalVertexList[:6] {
+		if math.Abs(float64(v.ColorR)-0.5) < 0.4999 || math.Abs(float64(v.ColorG)-0.5) > 0.0001 || math.Abs(float64(v.ColorB)-0.5) > 0.0001 {
+			t.Errorf("vertex %d: expected a world normal along the X axis, got %f, %f, %f", i, v.ColorR, v.ColorG, v.ColorB)
+		}
+	}
+
+	// In view space, the plane faces the camera.
+	camera.NormalSpace = NormalSpaceView
+	camera.Clear()
+	camera.RenderNodes(scene, scene.Root)
+
+	for i, v := range normalVertexList[:6] {
+		if math.Abs(float64(v.ColorR)-0.5) > 0.0001 || math.Abs(float64(v.ColorG)-0.5) > 0.0001 || math.Abs(float64(v.ColorB)-1) > 0.0001 {
+			t.Errorf("vertex %d: expected a view normal pointing towards the camera, got %f, %f, %f", i, v.ColorR, v.ColorG, v.ColorB)
+		}
+	}
+
+	camera.RenderNormals = false
+
+	if camera.NormalTexture() != nil {
+		t.Errorf("expected no normal texture when the camera isn't rendering normals")
+	}
+
+}
@@ -0,0 +1,695 @@
+package tetra3d
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/kvartborg/vector"
+)
+
+// objDefaultMaterialName is the material name used when exporting MeshParts that have no Material.
+const objDefaultMaterialName = "Default"
+
+// objName returns the name given in a form that's safe to use in OBJ and MTL files (which don't support whitespace in names).
+func objName(name string) string {
+	name = strings.Join(strings.Fields(name), "_")
+	if name == "" {
+		return "Unnamed"
+	}
+	return name
+}
+
+// objMaterialName returns the name to use for the Material in OBJ and MTL files.
+func objMaterialName(material *Material) string {
+	if material == nil {
+		return objDefaultMaterialName
+	}
+	return objName(material.Name)
+}
+
+func objFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// ExportOBJ writes the Mesh to the Writer provided in the Wavefront OBJ format. This is mainly useful for debugging, as it allows you
+// to inspect generated, merged, or baked geometry in a 3D modeler like Blender. Vertex positions, UVs, and normals are written,
+// along with a group of faces for each MeshPart that uses its Material (by name). If the Mesh has vertex colors, the colors of
+// each vertex's active color channel are written as well, using the (non-standard, but widely supported) extension of appending
+// RGB values to vertex positions. The OBJ file references a material library named after the Mesh (i.e. "[Mesh name].mtl"); use
+// Mesh.ExportMTL() to write it.
+func (mesh *Mesh) ExportOBJ(w io.Writer) error {
+
+	out := bufio.NewWriter(w)
+
+	out.WriteString("# Tetra3D OBJ export\n")
+	out.WriteString("mtllib " + objName(mesh.Name) + ".mtl\n")
+	out.WriteString("o " + objName(mesh.Name) + "\n")
+
+	for i := 0; i < mesh.VertexCount; i++ {
+
+		pos := mesh.VertexPositions[i]
+		out.WriteString("v " + objFloat(pos[0]) + " " + objFloat(pos[1]) + " " + objFloat(pos[2]))
+
+		if len(mesh.VertexColors[i]) > 0 {
+			color := NewColor(1, 1, 1, 1)
+			if channel := mesh.VertexActiveColorChannel[i]; channel >= 0 && channel < len(mesh.VertexColors[i]) {
+				color = mesh.VertexColors[i][channel]
+			}
+			out.WriteString(" " + objFloat(float64(color.R)) + " " + objFloat(float64(color.G)) + " " + objFloat(float64(color.B)))
+		}
+
+		out.WriteString("\n")
+
+	}
+
+	for i := 0; i < mesh.VertexCount; i++ {
+		uv := mesh.VertexUVs[i]
+		out.WriteString("vt " + objFloat(uv[0]) + " " + objFloat(uv[1]) + "\n")
+	}
+
+	for i := 0; i < mesh.VertexCount; i++ {
+		normal := mesh.VertexNormals[i]
+		out.WriteString("vn " + objFloat(normal[0]) + " " + objFloat(normal[1]) + " " + objFloat(normal[2]) + "\n")
+	}
+
+	for _, part := range mesh.MeshParts {
+
+		if part.TriangleCount() == 0 {
+			continue
+		}
+
+		out.WriteString("usemtl " + objMaterialName(part.Material) + "\n")
+
+		for triID := part.TriangleStart; triID < part.TriangleEnd; triID++ {
+
+			out.WriteString("f")
+
+			for i := 0; i < 3; i++ {
+				index := strconv.Itoa(triID*3 + i + 1) // OBJ indices start at 1
+				out.WriteString(" " + index + "/" + index + "/" + index)
+			}
+
+			out.WriteString("\n")
+
+		}
+
+	}
+
+	return out.Flush()
+
+}
+
+// ExportMTL writes the Materials used by the Mesh's MeshParts to the Writer provided in the Wavefront MTL format, to be used alongside
+// an OBJ file written with Mesh.ExportOBJ(). The Materials' colors and opacities are written, as well as their texture paths (if they
+// were loaded with one).
+func (mesh *Mesh) ExportMTL(w io.Writer) error {
+
+	out := bufio.NewWriter(w)
+
+	out.WriteString("# Tetra3D MTL export\n")
+
+	written := map[string]bool{}
+
+	for _, part := range mesh.MeshParts {
+
+		name := objMaterialName(part.Material)
+
+		if written[name] {
+			continue
+		}
+
+		written[name] = true
+
+		color := NewColor(1, 1, 1, 1)
+		if part.Material != nil {
+			color = part.Material.Color
+		}
+
+		out.WriteString("\nnewmtl " + name + "\n")
+		out.WriteString("Kd " + objFloat(float64(color.R)) + " " + objFloat(float64(color.G)) + " " + objFloat(float64(color.B)) + "\n")
+		out.WriteString("d " + objFloat(float64(color.A)) + "\n")
+
+		if part.Material != nil && part.Material.TexturePath != "" {
+			out.WriteString("map_Kd " + part.Material.TexturePath + "\n")
+		}
+
+	}
+
+	return out.Flush()
+
+}
+
+// OBJLoadOptions represents options one can use to tweak how .obj files are loaded into Tetra3D.
+type OBJLoadOptions struct {
+	// FileResolver is a function that takes the path (relative to the OBJ file) of a file that the OBJ file refers to (i.e. an MTL
+	// material library, or a texture used by a material) and returns its contents. If FileResolver is nil or returns an error, material
+	// libraries are skipped (so materials are created with just their names), and textures aren't loaded (so materials just have their
+	// TexturePath set). LoadOBJFile() automatically loads files relative to the OBJ file if FileResolver is nil.
+	FileResolver func(path string) ([]byte, error)
+
+	LoadTextures              bool // If textures used by materials should be loaded using the FileResolver. Defaults to true.
+	DefaultToAutoTransparency bool // If DefaultToAutoTransparency is true, then opaque materials become Auto transparent materials in Tetra3D.
+
+	// ImportOptions describes the coordinate system the file was authored in, so that its contents can be converted to Tetra3D's.
+	// If nil (the default), no conversion is done. See NewImportOptionsBlender(), for example.
+	ImportOptions *ImportOptions
+}
+
+// DefaultOBJLoadOptions creates an instance of OBJLoadOptions with some sensible defaults.
+func DefaultOBJLoadOptions() *OBJLoadOptions {
+	return &OBJLoadOptions{
+		LoadTextures:              true,
+		DefaultToAutoTransparency: true,
+	}
+}
+
+// LoadOBJFile loads a Wavefront .obj file from the filepath given, using a provided OBJLoadOptions struct to alter how the file is loaded.
+// Passing nil for loadOptions will load the file using default load options. Material libraries (.mtl files) and textures that the file
+// refers to are loaded from paths relative to the OBJ file, unless loadOptions has a FileResolver set. See LoadOBJData() for more
+// information. LoadOBJFile will return a Library, and an error if the process fails.
+func LoadOBJFile(path string, loadOptions *OBJLoadOptions) (*Library, error) {
+
+	fileData, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if loadOptions == nil {
+		loadOptions = DefaultOBJLoadOptions()
+	}
+
+	if loadOptions.FileResolver == nil {
+		options := *loadOptions
+		options.FileResolver = func(relativePath string) ([]byte, error) {
+			return os.ReadFile(filepath.Join(filepath.Dir(path), filepath.FromSlash(relativePath)))
+		}
+		loadOptions = &options
+	}
+
+	return LoadOBJData(fileData, loadOptions)
+
+}
+
+// LoadOBJData loads Wavefront OBJ data, using a provided OBJLoadOptions struct to alter how the data is loaded. Passing nil for
+// loadOptions will load the data using default load options. The returned Library has a single Scene (which is also the ExportedScene),
+// containing a Model for each object (or group) in the OBJ data; each Model has its own Mesh, with a MeshPart for each material it
+// uses. Vertex positions, UVs, normals, and vertex colors are loaded; faces with more than three vertices are triangulated, and faces
+// without normals get flat normals. Materials are loaded from any MTL material libraries the data refers to (using the loadOptions'
+// FileResolver), including their diffuse colors, opacities, emission colors, and diffuse textures. LoadOBJData will return a Library,
+// and an error if the process fails.
+func LoadOBJData(data []byte, loadOptions *OBJLoadOptions) (*Library, error) {
+
+	if loadOptions == nil {
+		loadOptions = DefaultOBJLoadOptions()
+	}
+
+	library := NewLibrary()
+
+	scene := library.AddScene("Scene")
+	scene.library = library
+	library.ExportedScene = scene
+
+	positions := []vector.Vector{}
+	colors := []*Color{}
+	uvs := []vector.Vector{}
+	normals := []vector.Vector{}
+
+	objects := []*objObject{}
+
+	var object *objObject
+	var material *Material
+
+	// currentObject returns the object faces are being added to, creating a default one if the data doesn't name its objects.
+	currentObject := func() *objObject {
+		if object == nil {
+			object = newOBJObject("Object")
+			objects = append(objects, object)
+		}
+		return object
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	lineNumber := 0
+
+	for scanner.Scan() {
+
+		lineNumber++
+
+		fields := strings.Fields(scanner.Text())
+
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		lineError := func(err error) error {
+			return fmt.Errorf("error parsing OBJ data on line %d: %w", lineNumber, err)
+		}
+
+		switch fields[0] {
+
+		case "v":
+
+			values, err := objParseFloats(fields[1:], 3)
+			if err != nil {
+				return nil, lineError(err)
+			}
+
+			positions = append(positions, vector.Vector{values[0], values[1], values[2]})
+
+			if len(values) >= 6 {
+				colors = append(colors, NewColor(float32(values[3]), float32(values[4]), float32(values[5]), 1))
+			} else {
+				colors = append(colors, nil)
+			}
+
+		case "vt":
+
+			values, err := objParseFloats(fields[1:], 1)
+			if err != nil {
+				return nil, lineError(err)
+			}
+
+			uv := vector.Vector{values[0], 0}
+			if len(values) > 1 {
+				uv[1] = values[1]
+			}
+
+			uvs = append(uvs, uv)
+
+		case "vn":
+
+			values, err := objParseFloats(fields[1:], 3)
+			if err != nil {
+				return nil, lineError(err)
+			}
+
+			normals = append(normals, vector.Vector{values[0], values[1], values[2]})
+
+		case "f":
+
+			if len(fields) < 4 {
+				return nil, lineError(errors.New("face has fewer than three vertices"))
+			}
+
+			face := make([]VertexInfo, 0, len(fields)-1)
+			hasNormals := true
+
+			for _, field := range fields[1:] {
+
+				indices := strings.Split(field, "/")
+
+				posIndex, err := objParseIndex(indices[0], len(positions))
+				if err != nil {
+					return nil, lineError(err)
+				}
+
+				pos := positions[posIndex]
+				vert := NewVertex(pos[0], pos[1], pos[2], 0, 0)
+
+				if color := colors[posIndex]; color != nil {
+					vert.Colors = append(vert.Colors, color.Clone())
+					vert.ActiveColorChannel = 0
+				}
+
+				if len(indices) > 1 && indices[1] != "" {
+					uvIndex, err := objParseIndex(indices[1], len(uvs))
+					if err != nil {
+						return nil, lineError(err)
+					}
+					vert.U, vert.V = uvs[uvIndex][0], uvs[uvIndex][1]
+				}
+
+				if len(indices) > 2 && indices[2] != "" {
+					normalIndex, err := objParseIndex(indices[2], len(normals))
+					if err != nil {
+						return nil, lineError(err)
+					}
+					normal := normals[normalIndex]
+					vert.NormalX, vert.NormalY, vert.NormalZ = normal[0], normal[1], normal[2]
+				} else {
+					hasNormals = false
+				}
+
+				face = append(face, vert)
+
+			}
+
+			obj := currentObject()
+			part := obj.part(material)
+
+			// Faces are triangulated as fans around their first vertex, which works for the convex faces OBJ files generally have.
+			for i := 1; i < len(face)-1; i++ {
+
+				tri := []VertexInfo{face[0].clone(), face[i].clone(), face[i+1].clone()}
+
+				if !hasNormals {
+					normal := calculateNormal(
+						vector.Vector{tri[0].X, tri[0].Y, tri[0].Z},
+						vector.Vector{tri[1].X, tri[1].Y, tri[1].Z},
+						vector.Vector{tri[2].X, tri[2].Y, tri[2].Z},
+					)
+					for j := range tri {
+						tri[j].NormalX, tri[j].NormalY, tri[j].NormalZ = normal[0], normal[1], normal[2]
+					}
+				}
+
+				part.vertices = append(part.vertices, tri...)
+
+			}
+
+		case "o", "g":
+
+			name := "Object"
+			if len(fields) > 1 {
+				name = strings.Join(fields[1:], " ")
+			}
+
+			// Objects and groups that haven't had any faces added yet are just renamed, rather than creating empty Models.
+			if object != nil && !object.hasFaces() {
+				object.name = name
+			} else {
+				object = newOBJObject(name)
+				objects = append(objects, object)
+			}
+
+		case "usemtl":
+
+			name := strings.Join(fields[1:], " ")
+
+			mat, exists := library.Materials[name]
+			if !exists {
+				mat = NewMaterial(name)
+				mat.library = library
+				library.Materials[name] = mat
+			}
+
+			material = mat
+
+		case "mtllib":
+
+			if loadOptions.FileResolver == nil {
+				continue
+			}
+
+			for _, mtlPath := range fields[1:] {
+
+				mtlData, err := loadOptions.FileResolver(mtlPath)
+				if err != nil {
+					continue
+				}
+
+				if err := loadMTLData(mtlData, library, loadOptions); err != nil {
+					return nil, err
+				}
+
+			}
+
+		}
+
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	converter := loadOptions.ImportOptions.converter()
+
+	for _, obj := range objects {
+
+		if !obj.hasFaces() {
+			continue
+		}
+
+		name := obj.name
+		for i := 1; library.Meshes[name] != nil; i++ {
+			name = fmt.Sprintf("%s.%03d", obj.name, i)
+		}
+
+		mesh := NewMesh(name)
+		mesh.library = library
+		library.Meshes[name] = mesh
+
+		colored := false
+
+		for _, part := range obj.parts {
+			for _, v := range part.vertices {
+				if len(v.Colors) > 0 {
+					colored = true
+				}
+			}
+		}
+
+		for _, part := range obj.parts {
+
+			if len(part.vertices) == 0 {
+				continue
+			}
+
+			// If any of the object's vertices are colored, the rest default to white, so that every vertex has the same color channels.
+			if colored {
+				for i := range part.vertices {
+					if len(part.vertices[i].Colors) == 0 {
+						part.vertices[i].Colors = append(part.vertices[i].Colors, NewColor(1, 1, 1, 1))
+						part.vertices[i].ActiveColorChannel = 0
+					}
+				}
+			}
+
+			mesh.AddMeshPart(part.material).AddTriangles(part.vertices...)
+
+		}
+
+		mesh.UpdateBounds()
+
+		if converter != nil {
+			converter.mesh(mesh)
+		}
+
+		model := NewModel(mesh, obj.name)
+		model.setLibrary(library)
+		scene.Root.AddChildren(model)
+
+	}
+
+	return library, nil
+
+}
+
+// objObject is an object (or group) being loaded from OBJ data, with its triangles' vertices sorted by material.
+type objObject struct {
+	name  string
+	parts []*objPart
+}
+
+type objPart struct {
+	material *Material
+	vertices []VertexInfo
+}
+
+func newOBJObject(name string) *objObject {
+	return &objObject{name: name, parts: []*objPart{}}
+}
+
+// part returns the objPart for the given Material, creating it if necessary.
+func (obj *objObject) part(material *Material) *objPart {
+
+	for _, part := range obj.parts {
+		if part.material == material {
+			return part
+		}
+	}
+
+	part := &objPart{material: material, vertices: []VertexInfo{}}
+	obj.parts = append(obj.parts, part)
+	return part
+
+}
+
+func (obj *objObject) hasFaces() bool {
+	for _, part := range obj.parts {
+		if len(part.vertices) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// objParseFloats parses the given fields as floats, returning an error if there are fewer than minimum of them.
+func objParseFloats(fields []string, minimum int) ([]float64, error) {
+
+	if len(fields) < minimum {
+		return nil, fmt.Errorf("expected at least %d values, got %d", minimum, len(fields))
+	}
+
+	values := make([]float64, 0, len(fields))
+
+	for _, field := range fields {
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
+
+}
+
+// objParseIndex parses an OBJ index (which starts at 1, or is negative to count backwards from the end) into a regular index into
+// a slice of the given length.
+func objParseIndex(field string, length int) (int, error) {
+
+	index, err := strconv.Atoi(field)
+	if err != nil {
+		return 0, err
+	}
+
+	if index < 0 {
+		index += length
+	} else {
+		index--
+	}
+
+	if index < 0 || index >= length {
+		return 0, fmt.Errorf("index %s is out of range", field)
+	}
+
+	return index, nil
+
+}
+
+// loadMTLData loads the Materials defined in MTL data into the Library given.
+func loadMTLData(data []byte, library *Library, loadOptions *OBJLoadOptions) error {
+
+	var material *Material
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	lineNumber := 0
+
+	for scanner.Scan() {
+
+		lineNumber++
+
+		fields := strings.Fields(scanner.Text())
+
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		if fields[0] == "newmtl" {
+
+			name := strings.Join(fields[1:], " ")
+
+			material = library.Materials[name]
+			if material == nil {
+				material = NewMaterial(name)
+				material.library = library
+				library.Materials[name] = material
+			}
+
+			if loadOptions.DefaultToAutoTransparency {
+				material.TransparencyMode = TransparencyModeAuto
+			} else {
+				material.TransparencyMode = TransparencyModeOpaque
+			}
+
+			continue
+
+		}
+
+		if material == nil {
+			continue
+		}
+
+		lineError := func(err error) error {
+			return fmt.Errorf("error parsing MTL data on line %d: %w", lineNumber, err)
+		}
+
+		switch fields[0] {
+
+		case "Kd":
+
+			values, err := objParseFloats(fields[1:], 3)
+			if err != nil {
+				return lineError(err)
+			}
+
+			material.Color.R = float32(values[0])
+			material.Color.G = float32(values[1])
+			material.Color.B = float32(values[2])
+
+		case "d", "Tr":
+
+			values, err := objParseFloats(fields[1:], 1)
+			if err != nil {
+				return lineError(err)
+			}
+
+			// Tr is transparency, rather than opacity.
+			if fields[0] == "Tr" {
+				values[0] = 1 - values[0]
+			}
+
+			material.Color.A = float32(values[0])
+
+			if values[0] < 1 {
+				material.TransparencyMode = TransparencyModeTransparent
+			}
+
+		case "Ke":
+
+			values, err := objParseFloats(fields[1:], 3)
+			if err != nil {
+				return lineError(err)
+			}
+
+			if values[0] > 0 || values[1] > 0 || values[2] > 0 {
+				material.EmissionColor.Set(float32(values[0]), float32(values[1]), float32(values[2]), 1)
+				material.EmissionStrength = 1
+			}
+
+		case "map_Kd":
+
+			if len(fields) < 2 {
+				continue
+			}
+
+			// Texture options (like "-s 1 1 1") can come before the path, so we just use the last field.
+			material.TexturePath = strings.ReplaceAll(fields[len(fields)-1], "\\", "/")
+
+			if !loadOptions.LoadTextures || loadOptions.FileResolver == nil {
+				continue
+			}
+
+			imageData, err := loadOptions.FileResolver(material.TexturePath)
+			if err != nil {
+				continue
+			}
+
+			img, _, err := image.Decode(bytes.NewReader(imageData))
+			if err != nil {
+				return err
+			}
+
+			material.Texture = ebiten.NewImageFromImage(img)
+
+		}
+
+	}
+
+	return scanner.Err()
+
+}
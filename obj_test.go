@@ -0,0 +1,211 @@
+package tetra3d
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kvartborg/vector"
+)
+
+func TestExportOBJ(t *testing.T) {
+
+	cube := NewCube()
+
+	buffer := &bytes.Buffer{}
+
+	if err := cube.ExportOBJ(buffer); err != nil {
+		t.Fatal(err)
+	}
+
+	// Re-import the positions and faces to make sure the indices point to the right vertices.
+
+	positions := []vector.Vector{}
+	faces := [][3]int{}
+	usedMaterials := 0
+
+	for _, line := range strings.Split(buffer.String(), "\n") {
+
+		fields := strings.Fields(line)
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+
+		case "v":
+			pos := vector.Vector{0, 0, 0}
+			for i := 0; i < 3; i++ {
+				value, err := strconv.ParseFloat(fields[i+1], 64)
+				if err != nil {
+					t.Fatal(err)
+				}
+				pos[i] = value
+			}
+			positions = append(positions, pos)
+
+		case "f":
+			if len(fields) != 4 {
+				t.Fatalf("expected triangular faces, got %q", line)
+			}
+			face := [3]int{}
+			for i := 0; i < 3; i++ {
+				index, err := strconv.Atoi(strings.Split(fields[i+1], "/")[0])
+				if err != nil {
+					t.Fatal(err)
+				}
+				face[i] = index - 1
+			}
+			faces = append(faces, face)
+
+		case "usemtl":
+			usedMaterials++
+
+		}
+
+	}
+
+	if len(positions) != cube.VertexCount {
+		t.Fatalf("expected %d vertices, got %d", cube.VertexCount, len(positions))
+	}
+
+	if len(faces) != len(cube.Triangles) {
+		t.Fatalf("expected %d faces, got %d", len(cube.Triangles), len(faces))
+	}
+
+	if usedMaterials != len(cube.MeshParts) {
+		t.Fatalf("expected %d material groups, got %d", len(cube.MeshParts), usedMaterials)
+	}
+
+	for triID, face := range faces {
+		for i, index := range face {
+			if !vectorsNear(positions[index], cube.VertexPositions[triID*3+i]) {
+				t.Fatalf("face %d, vertex %d: expected position %v, got %v", triID, i, cube.VertexPositions[triID*3+i], positions[index])
+			}
+		}
+	}
+
+	mtl := &bytes.Buffer{}
+
+	if err := cube.ExportMTL(mtl); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(mtl.String(), "newmtl "+objMaterialName(cube.MeshParts[0].Material)) {
+		t.Fatalf("expected the MTL file to define the cube's material, got:\n%s", mtl.String())
+	}
+
+}
+
+func TestLoadOBJRoundTrip(t *testing.T) {
+
+	cube := NewCube()
+	cube.MeshParts[0].Material.Color.Set(1, 0.5, 0, 0.5)
+
+	objData := &bytes.Buffer{}
+	mtlData := &bytes.Buffer{}
+
+	if err := cube.ExportOBJ(objData); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cube.ExportMTL(mtlData); err != nil {
+		t.Fatal(err)
+	}
+
+	options := DefaultOBJLoadOptions()
+	options.FileResolver = func(path string) ([]byte, error) {
+		if path != objName(cube.Name)+".mtl" {
+			t.Fatalf("unexpected file requested: %s", path)
+		}
+		return mtlData.Bytes(), nil
+	}
+
+	library, err := LoadOBJData(objData.Bytes(), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mesh := library.Meshes[objName(cube.Name)]
+	if mesh == nil {
+		t.Fatalf("expected a mesh named %s to be loaded", objName(cube.Name))
+	}
+
+	if len(mesh.Triangles) != len(cube.Triangles) {
+		t.Fatalf("expected %d triangles, got %d", len(cube.Triangles), len(mesh.Triangles))
+	}
+
+	for i := 0; i < mesh.VertexCount; i++ {
+		if !vectorsNear(mesh.VertexPositions[i], cube.VertexPositions[i]) || mesh.VertexUVs[i].Sub(cube.VertexUVs[i]).Magnitude() > 0.0001 {
+			t.Fatalf("vertex %d doesn't match the exported cube", i)
+		}
+	}
+
+	material := mesh.MeshParts[0].Material
+	if material == nil || material.Color.G != 0.5 || material.Color.A != 0.5 || material.TransparencyMode != TransparencyModeTransparent {
+		t.Fatalf("expected the cube's material to be loaded from the MTL data")
+	}
+
+	if len(library.ExportedScene.Root.Children()) != 1 {
+		t.Fatalf("expected the loaded scene to have a single Model")
+	}
+
+}
+
+func TestLoadOBJPolygons(t *testing.T) {
+
+	data := `
+# A quad and a triangle in two groups, using negative indices and no normals
+g Quad
+v -1 0 1
+v 1 0 1
+v 1 0 -1
+v -1 0 -1
+f -4 -3 -2 -1
+o Triangle
+v 0 0 0 1 0 0
+v 1 0 0 1 0 0
+v 0 1 0 1 0 0
+vt 0 0
+vt 1 0
+vt 0 1
+f 5/1 6/2 7/3
+`
+
+	library, err := LoadOBJData([]byte(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	quad := library.Meshes["Quad"]
+	triangle := library.Meshes["Triangle"]
+
+	if quad == nil || len(quad.Triangles) != 2 {
+		t.Fatalf("expected the quad to be triangulated into two triangles")
+	}
+
+	for i := 0; i < quad.VertexCount; i++ {
+		if !vectorsNear(quad.VertexNormals[i], vector.Vector{0, 1, 0}) {
+			t.Fatalf("expected the quad's vertex %d to have a flat normal facing up, got %v", i, quad.VertexNormals[i])
+		}
+	}
+
+	if triangle == nil || len(triangle.Triangles) != 1 {
+		t.Fatalf("expected a single triangle")
+	}
+
+	if uv := triangle.VertexUVs[2]; uv[0] != 0 || uv[1] != 1 {
+		t.Errorf("expected the triangle's last vertex to have a UV of (0, 1), got %v", uv)
+	}
+
+	if colors := triangle.VertexColors[0]; len(colors) != 1 || colors[0].R != 1 || colors[0].G != 0 {
+		t.Errorf("expected the triangle's vertices to be red")
+	}
+
+	if _, err := LoadOBJData([]byte("v 0 0 0\nf 1 2 3\n"), nil); err == nil {
+		t.Errorf("expected an error for out of range indices")
+	}
+
+}
@@ -0,0 +1,64 @@
+package tetra3d
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// RenderOverlay renders the given Models on top of everything the Camera has rendered so far, for first-person "viewmodels" (like
+// hands or weapons) and other 3D elements that should never clip into the world. The Models are rendered with their own depth
+// texture (so they're depth-tested against each other, but not against the world) and using the Camera's OverlayNear, OverlayFar,
+// OverlayFieldOfView, and OverlayLayerMask settings in place of its usual ones; the Scene's World is used for lighting and fog as
+// usual, and the result is composited into the Camera's color texture, so post-processing applies to it as well. The Camera's depth
+// texture is left holding the world's depth. Each call renders with a freshly cleared depth texture.
+//
+// Generally, viewmodels are put on their own render layer (see Node.Layers) that's excluded from the Camera's LayerMask, and then
+// rendered after the world each frame with this function.
+func (camera *Camera) RenderOverlay(scene *Scene, models ...*Model) {
+
+	near, far, fov, layerMask := camera.Near, camera.Far, camera.FieldOfView, camera.LayerMask
+	depthTexture := camera.resultDepthTexture
+
+	camera.Near = camera.OverlayNear
+	camera.Far = camera.OverlayFar
+	if camera.OverlayFieldOfView > 0 {
+		camera.FieldOfView = camera.OverlayFieldOfView
+	}
+	camera.LayerMask = camera.OverlayLayerMask
+	camera.sphereFactorCalculated = false
+
+	if camera.RenderDepth {
+		if camera.overlayDepthTexture == nil {
+			camera.overlayDepthTexture = ebiten.NewImage(camera.resultColorTexture.Size())
+		}
+		camera.overlayDepthTexture.Clear()
+		camera.resultDepthTexture = camera.overlayDepthTexture
+	}
+
+	camera.renderingOverlay = true
+
+	camera.Render(scene, models...)
+
+	camera.renderingOverlay = false
+
+	camera.resultDepthTexture = depthTexture
+	camera.Near, camera.Far, camera.FieldOfView, camera.LayerMask = near, far, fov, layerMask
+	camera.sphereFactorCalculated = false
+
+}
+
+// RenderOverlayNodes renders all Models starting with the provided rootNode as an overlay (see Camera.RenderOverlay()).
+func (camera *Camera) RenderOverlayNodes(scene *Scene, rootNode INode) {
+
+	models := []*Model{}
+
+	if model, isModel := rootNode.(*Model); isModel {
+		models = append(models, model)
+	}
+
+	for _, node := range rootNode.ChildrenRecursive() {
+		if model, ok := node.(*Model); ok && model.DynamicBatchOwner == nil {
+			models = append(models, model)
+		}
+	}
+
+	camera.RenderOverlay(scene, models...)
+
+}
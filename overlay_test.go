@@ -0,0 +1,52 @@
+package tetra3d
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestCameraOverlay(t *testing.T) {
+
+	scene := NewScene("overlay")
+
+	wall := NewModel(NewCube(), "wall")
+	wall.Move(0, 0, -1)
+	scene.Root.AddChildren(wall)
+
+	weapon := NewModel(NewCube(), "weapon")
+	weapon.SetLocalScale(0.2, 0.2, 0.5)
+	weapon.Move(0.3, -0.3, -0.8)
+	weapon.Layers = NewRenderLayers(1)
+	scene.Root.AddChildren(weapon)
+
+	camera := NewCamera(32, 32)
+	camera.LayerMask = RenderLayersAll.Without(1)
+	camera.OverlayLayerMask = NewRenderLayers(1)
+
+	overlayFar := 0.0
+
+	weapon.Mesh.MeshParts[0].OnRender = func(camera *Camera, model *Model, part *MeshPart, stage int, vertices []ebiten.Vertex) {
+		overlayFar = camera.Far
+	}
+
+	depthTexture := camera.DepthTexture()
+
+	camera.Clear()
+	camera.RenderNodes(scene, scene.Root)
+
+	if overlayFar != 0 {
+		t.Errorf("expected the weapon not to be rendered with the world")
+	}
+
+	camera.RenderOverlayNodes(scene, scene.Root)
+
+	if overlayFar != camera.OverlayFar {
+		t.Errorf("expected the weapon to be rendered with the overlay's depth range, got a far plane of %f", overlayFar)
+	}
+
+	if camera.Far != 100 || camera.LayerMask.Has(NewRenderLayers(1)) || camera.DepthTexture() != depthTexture {
+		t.Errorf("expected the Camera's settings and depth texture to be restored after rendering an overlay")
+	}
+
+}
@@ -0,0 +1,295 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/kvartborg/vector"
+)
+
+// Waypoint represents a point in a PathGraph, used for pathfinding along hand-placed routes (like patrol routes or the paths
+// between rooms in a level). Waypoints are parented to a PathGraph, and each Waypoint links to the Waypoints that can be
+// travelled to directly from it. Links only go one way, so a Waypoint that can be travelled to and from another Waypoint links to
+// it, and is linked to by it in turn. As with GridPoints, links are separate from positions, so Waypoints can be moved freely
+// after creation.
+type Waypoint struct {
+	*Node
+	Links []*Waypoint // The Waypoints that can be travelled to directly from this Waypoint.
+}
+
+// NewWaypoint creates a new Waypoint.
+func NewWaypoint(name string) *Waypoint {
+	return &Waypoint{
+		Node:  NewNode(name),
+		Links: []*Waypoint{},
+	}
+}
+
+// Clone clones the given Waypoint. The clone links to the same Waypoints as the original (though if it's cloned as part of a
+// PathGraph, the PathGraph relinks it to the cloned Waypoints).
+func (waypoint *Waypoint) Clone() INode {
+	newWaypoint := &Waypoint{
+		Node:  waypoint.Node.Clone().(*Node),
+		Links: append([]*Waypoint{}, waypoint.Links...),
+	}
+	for _, child := range newWaypoint.children {
+		child.setParent(newWaypoint)
+	}
+	return newWaypoint
+}
+
+// IsLinked returns if the Waypoint links to the given other Waypoint (i.e. if the other Waypoint can be travelled to directly
+// from this one).
+func (waypoint *Waypoint) IsLinked(other *Waypoint) bool {
+
+	for _, link := range waypoint.Links {
+		if link == other {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// Link links the Waypoint to the given other Waypoint, so that it can be travelled to directly from this one. If twoWay is true,
+// the other Waypoint is linked back to this one as well.
+func (waypoint *Waypoint) Link(other *Waypoint, twoWay bool) {
+
+	if waypoint == other {
+		return
+	}
+
+	if !waypoint.IsLinked(other) {
+		waypoint.Links = append(waypoint.Links, other)
+	}
+
+	if twoWay {
+		other.Link(waypoint, false)
+	}
+
+}
+
+// Unlink removes the link from the Waypoint to the given other Waypoint. If twoWay is true, the link from the other Waypoint
+// back to this one is removed as well.
+func (waypoint *Waypoint) Unlink(other *Waypoint, twoWay bool) {
+
+	for i, link := range waypoint.Links {
+		if link == other {
+			waypoint.Links[i] = nil
+			waypoint.Links = append(waypoint.Links[:i], waypoint.Links[i+1:]...)
+			break
+		}
+	}
+
+	if twoWay {
+		other.Unlink(waypoint, false)
+	}
+
+}
+
+// waypointSearchNode is a Waypoint being searched through by Waypoint.PathTo().
+type waypointSearchNode struct {
+	waypoint *Waypoint
+	position vector.Vector
+	cost     float64 // The distance travelled to get to the Waypoint.
+	estimate float64 // The estimated total distance of a path going through the Waypoint.
+	previous *waypointSearchNode
+	closed   bool
+}
+
+// PathTo returns the shortest path along the Waypoints' links going from this Waypoint to the given other Waypoint, or nil
+// if there's no way to get from one to the other. Unlike GridPoint.PathTo(), the path is found using A*, so it's the shortest
+// path by distance, rather than by the number of Waypoints it passes through.
+func (waypoint *Waypoint) PathTo(other *Waypoint) *NavPath {
+
+	end := other.WorldPosition()
+	start := waypoint.WorldPosition()
+
+	nodes := map[*Waypoint]*waypointSearchNode{
+		waypoint: {waypoint: waypoint, position: start, estimate: start.Sub(end).Magnitude()},
+	}
+	open := []*waypointSearchNode{nodes[waypoint]}
+
+	for len(open) > 0 {
+
+		best := 0
+		for i, node := range open {
+			if node.estimate < open[best].estimate {
+				best = i
+			}
+		}
+
+		current := open[best]
+		open[best] = open[len(open)-1]
+		open = open[:len(open)-1]
+		current.closed = true
+
+		if current.waypoint == other {
+
+			points := []vector.Vector{}
+			for node := current; node != nil; node = node.previous {
+				points = append([]vector.Vector{node.position}, points...)
+			}
+
+			return &NavPath{Points: points}
+
+		}
+
+		for _, link := range current.waypoint.Links {
+
+			node, exists := nodes[link]
+
+			if !exists {
+				node = &waypointSearchNode{waypoint: link, position: link.WorldPosition(), cost: math.MaxFloat64}
+				nodes[link] = node
+				open = append(open, node)
+			}
+
+			cost := current.cost + node.position.Sub(current.position).Magnitude()
+
+			if node.closed || node.cost <= cost {
+				continue
+			}
+
+			node.cost = cost
+			node.estimate = cost + node.position.Sub(end).Magnitude()
+			node.previous = current
+
+		}
+
+	}
+
+	return nil
+
+}
+
+////////////
+
+// AddChildren parents the provided children Nodes to the passed parent Node, inheriting its transformations and being under it in the scenegraph
+// hierarchy. If the children are already parented to other Nodes, they are unparented before doing so.
+func (waypoint *Waypoint) AddChildren(children ...INode) {
+	// We do this manually so that addChildren() parents the children to the Waypoint, rather than to the Waypoint.Node.
+	waypoint.addChildren(waypoint, children...)
+}
+
+// Unparent unparents the Waypoint from its parent, removing it from the scenegraph.
+func (waypoint *Waypoint) Unparent() {
+	if waypoint.parent != nil {
+		waypoint.parent.RemoveChildren(waypoint)
+	}
+}
+
+// Type returns the NodeType for this object.
+func (waypoint *Waypoint) Type() NodeType {
+	return NodeTypeWaypoint
+}
+
+// PathGraph represents a graph of Waypoints and the links between them, used for pathfinding along hand-placed routes. A
+// PathGraph's Waypoints are its direct children. PathGraphs can be created in Blender by setting an Empty's type to Path Graph
+// and parenting Empties with their type set to Waypoint to it.
+type PathGraph struct {
+	*Node
+}
+
+// NewPathGraph creates a new PathGraph.
+func NewPathGraph(name string) *PathGraph {
+	return &PathGraph{Node: NewNode(name)}
+}
+
+// Clone creates a clone of this PathGraph. The cloned Waypoints link to each other in the same way as the original Waypoints.
+func (graph *PathGraph) Clone() INode {
+
+	newGraph := &PathGraph{}
+	newGraph.Node = graph.Node.Clone().(*Node)
+
+	for _, child := range newGraph.children {
+		child.setParent(newGraph)
+	}
+
+	// Children are cloned in order, so the cloned Waypoints are in the same places in the list as the originals.
+	oldWaypoints := graph.Waypoints()
+	newWaypoints := newGraph.Waypoints()
+
+	clones := map[*Waypoint]*Waypoint{}
+	for i, waypoint := range oldWaypoints {
+		clones[waypoint] = newWaypoints[i]
+	}
+
+	for _, waypoint := range newWaypoints {
+		for i, link := range waypoint.Links {
+			if clone, exists := clones[link]; exists {
+				waypoint.Links[i] = clone
+			}
+		}
+	}
+
+	return newGraph
+
+}
+
+// Waypoints returns a slice of the children nodes that constitute this PathGraph's Waypoints.
+func (graph *PathGraph) Waypoints() []*Waypoint {
+	waypoints := make([]*Waypoint, 0, len(graph.children))
+	for _, n := range graph.children {
+		if waypoint, ok := n.(*Waypoint); ok {
+			waypoints = append(waypoints, waypoint)
+		}
+	}
+	return waypoints
+}
+
+// NearestWaypoint returns the PathGraph's Waypoint nearest to the given world position. If the PathGraph has no Waypoints,
+// NearestWaypoint returns nil.
+func (graph *PathGraph) NearestWaypoint(position vector.Vector) *Waypoint {
+
+	var nearest *Waypoint
+	nearestDistance := math.MaxFloat64
+
+	for _, waypoint := range graph.Waypoints() {
+
+		if distance := fastVectorDistanceSquared(waypoint.WorldPosition(), position); distance < nearestDistance {
+			nearest = waypoint
+			nearestDistance = distance
+		}
+
+	}
+
+	return nearest
+
+}
+
+// FindPath returns the shortest path along the PathGraph's links from the Waypoint nearest to the given start position to the
+// Waypoint nearest to the given end position, or nil if there's no way to get from one to the other.
+func (graph *PathGraph) FindPath(start, end vector.Vector) *NavPath {
+
+	startWaypoint := graph.NearestWaypoint(start)
+	endWaypoint := graph.NearestWaypoint(end)
+
+	if startWaypoint == nil {
+		return nil
+	}
+
+	return startWaypoint.PathTo(endWaypoint)
+
+}
+
+////////
+
+// AddChildren parents the provided children Nodes to the passed parent Node, inheriting its transformations and being under it in the scenegraph
+// hierarchy. If the children are already parented to other Nodes, they are unparented before doing so.
+func (graph *PathGraph) AddChildren(children ...INode) {
+	// We do this manually so that addChildren() parents the children to the PathGraph, rather than to the PathGraph.Node.
+	graph.addChildren(graph, children...)
+}
+
+// Unparent unparents the PathGraph from its parent, removing it from the scenegraph.
+func (graph *PathGraph) Unparent() {
+	if graph.parent != nil {
+		graph.parent.RemoveChildren(graph)
+	}
+}
+
+// Type returns the NodeType for this object.
+func (graph *PathGraph) Type() NodeType {
+	return NodeTypePathGraph
+}
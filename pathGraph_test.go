@@ -0,0 +1,116 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kvartborg/vector"
+)
+
+// newTestPathGraph returns a PathGraph with Waypoints a, b, c, and d in a line, linked to each other in turn. Waypoint e is off to
+// the side, linked to and from d, and linked to from a (but not back).
+func newTestPathGraph() *PathGraph {
+
+	graph := NewPathGraph("graph")
+
+	positions := map[string]vector.Vector{
+		"a": {0, 0, 0},
+		"b": {1, 0, 0},
+		"c": {2, 0, 0},
+		"d": {3, 0, 0},
+		"e": {1.5, 0, 3},
+	}
+
+	waypoints := map[string]*Waypoint{}
+
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		waypoints[name] = NewWaypoint(name)
+		waypoints[name].SetLocalPositionVec(positions[name])
+		graph.AddChildren(waypoints[name])
+	}
+
+	waypoints["a"].Link(waypoints["b"], true)
+	waypoints["b"].Link(waypoints["c"], true)
+	waypoints["c"].Link(waypoints["d"], true)
+	waypoints["d"].Link(waypoints["e"], true)
+	waypoints["a"].Link(waypoints["e"], false)
+
+	return graph
+
+}
+
+func TestPathGraphFindPath(t *testing.T) {
+
+	graph := newTestPathGraph()
+	a := graph.Get("a").(*Waypoint)
+	d := graph.Get("d").(*Waypoint)
+	e := graph.Get("e").(*Waypoint)
+
+	// Going through e passes through fewer Waypoints, but going along the line is shorter.
+	if path := a.PathTo(d); path == nil || len(path.Points) != 4 || math.Abs(path.Distance()-3) > 1e-6 {
+		t.Fatalf("the path from a to d should go along the line; got %v", path)
+	}
+
+	// Moving b out of the way and e closer makes going through e the shortest path.
+	graph.Get("b").SetLocalPosition(1, 0, -2)
+	e.SetLocalPosition(1.5, 0, 0.5)
+
+	if path := a.PathTo(d); path == nil || len(path.Points) != 3 || path.Points[1].Sub(e.WorldPosition()).Magnitude() > 1e-6 {
+		t.Fatalf("the path from a to d should go through e; got %v", path)
+	}
+
+	// The link from a to e only goes one way, so the path back from e goes through d.
+	if path := e.PathTo(a); path == nil || len(path.Points) != 5 {
+		t.Fatalf("the path from e to a should go back through d; got %v", path)
+	}
+
+	e.Unlink(d, true)
+
+	if d.IsLinked(e) || e.IsLinked(d) {
+		t.Fatalf("unlinking both ways should remove the links in both directions")
+	}
+
+	if path := e.PathTo(a); path != nil {
+		t.Fatalf("e shouldn't link to any other Waypoints; got %v", path.Points)
+	}
+
+	// FindPath() goes between the Waypoints nearest to the given positions.
+	path := graph.FindPath(vector.Vector{0.1, 5, 0}, vector.Vector{2.9, 0, -1})
+	if path == nil || path.Points[0].Magnitude() > 1e-6 || path.Points[len(path.Points)-1].Sub(d.WorldPosition()).Magnitude() > 1e-6 {
+		t.Fatalf("the path should go from a to d; got %v", path)
+	}
+
+	if nearest := NewPathGraph("empty").NearestWaypoint(vector.Vector{0, 0, 0}); nearest != nil {
+		t.Fatalf("a PathGraph with no Waypoints shouldn't have a nearest Waypoint")
+	}
+
+}
+
+func TestPathGraphClone(t *testing.T) {
+
+	graph := newTestPathGraph()
+	clone := graph.Clone().(*PathGraph)
+
+	if clone.Type() != NodeTypePathGraph || clone.Type().Is(NodeTypePath) || len(clone.Waypoints()) != 5 {
+		t.Fatalf("the clone should be a PathGraph with 5 Waypoints")
+	}
+
+	for _, waypoint := range clone.Waypoints() {
+		for _, link := range waypoint.Links {
+			if link.Parent() != clone {
+				t.Fatalf("the cloned Waypoints should link to each other, not to the original Waypoints")
+			}
+		}
+	}
+
+	if a, e := clone.Get("a").(*Waypoint), clone.Get("e").(*Waypoint); !a.IsLinked(e) || e.IsLinked(a) {
+		t.Fatalf("the cloned Waypoints should keep the directions of their links")
+	}
+
+	clone.SetLocalPosition(10, 0, 0)
+
+	if path := clone.FindPath(vector.Vector{10, 0, 0}, vector.Vector{13, 0, 0}); path == nil || path.Points[len(path.Points)-1].Sub(vector.Vector{13, 0, 0}).Magnitude() > 1e-6 {
+		t.Fatalf("the path across the moved clone should end at its d Waypoint; got %v", path)
+	}
+
+}
@@ -0,0 +1,193 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	TextureMappingCamera      = iota // Textures are mapped according to the Camera rendering them (see Camera.PerspectiveCorrectTextures)
+	TextureMappingAffine             // Textures are mapped affinely, warping along triangles that recede into the distance (like the PlayStation)
+	TextureMappingPerspective        // Textures are mapped with perspective correction, by subdividing triangles that recede into the distance
+)
+
+const (
+	// perspectiveCorrectionTolerance is roughly how far off (in pixels) affine mapping can be before a triangle's subdivided.
+	perspectiveCorrectionTolerance = 1.0
+	// perspectiveCorrectionMaxLevels is how many times a triangle can be subdivided (with each level splitting it into 4 triangles).
+	perspectiveCorrectionMaxLevels = 4
+)
+
+// perspectiveVertex is a vertex of a triangle being subdivided for perspective correction; it holds the vertex in each of the
+// vertex lists being subdivided (in the same order as Camera.perspectiveLists), along with the vertex's clip-space W value.
+type perspectiveVertex struct {
+	W        float64
+	Vertices [9]ebiten.Vertex
+}
+
+// This list is only allocated once a Camera renders with perspective correction; it holds the clip-space W value of each vertex in
+// the vertex lists.
+var perspectiveWList []float64
+
+// perspectiveCorrected returns if the given Material's textures should be mapped with perspective correction when rendered by the
+// Camera.
+func (camera *Camera) perspectiveCorrected(mat *Material) bool {
+
+	if !camera.Perspective {
+		return false
+	}
+
+	if mat != nil {
+		if mat.TextureMapping == TextureMappingAffine {
+			return false
+		} else if mat.TextureMapping == TextureMappingPerspective {
+			return true
+		}
+	}
+
+	return camera.PerspectiveCorrectTextures
+
+}
+
+// preparePerspectiveCorrection creates the list used to hold the W values of vertices if it hasn't been created yet.
+func (camera *Camera) preparePerspectiveCorrection() {
+	if perspectiveWList == nil {
+		perspectiveWList = make([]float64, ebiten.MaxIndicesNum)
+	}
+}
+
+// correctPerspective subdivides the triangles in the vertex lists from the start index to the end index where affine texture mapping
+// would be noticeably off, and returns the new end index. Screen positions of the vertices created are placed where they'd be in 3D,
+// while the rest of their data is interpolated linearly, so that the subdivided triangles approximate perspective-correct mapping.
+// The vertex lists used for pixel lighting, normal rendering, normal mapping, and local fog are subdivided as well if the flags given
+// are set.
+func (camera *Camera) correctPerspective(start, end int, pixelLit, renderNormals, normalMapped, localFogged bool) int {
+
+	camera.perspectiveLists = append(camera.perspectiveLists[:0], colorVertexList, depthVertexList)
+
+	// The pixel light vertex list holds screen positions in its source positions when normal mapping.
+	screenSrcList := -1
+
+	if pixelLit {
+		if normalMapped {
+			screenSrcList = len(camera.perspectiveLists) + 1
+		}
+		camera.perspectiveLists = append(camera.perspectiveLists, pixelUnlitVertexList, pixelLightVertexList)
+	}
+
+	if renderNormals {
+		camera.perspectiveLists = append(camera.perspectiveLists, normalVertexList)
+	}
+
+	if normalMapped {
+		camera.perspectiveLists = append(camera.perspectiveLists, normalMapVertexLists[:]...)
+	}
+
+	if localFogged {
+		camera.perspectiveLists = append(camera.perspectiveLists, fogVertexList)
+	}
+
+	camera.perspectiveInput = camera.perspectiveInput[:0]
+
+	for i := start; i < end; i++ {
+		v := perspectiveVertex{W: perspectiveWList[i]}
+		for l, list := range camera.perspectiveLists {
+			v.Vertices[l] = list[i]
+		}
+		camera.perspectiveInput = append(camera.perspectiveInput, v)
+	}
+
+	out := start
+
+	// Reserved is how many vertices have yet to be written; triangles are only subdivided if there's room for the result.
+	reserved := end - start
+
+	var subdivide func(a, b, c *perspectiveVertex, level int)
+
+	subdivide = func(a, b, c *perspectiveVertex, level int) {
+
+		if level < perspectiveCorrectionMaxLevels && out+reserved+9 <= ebiten.MaxIndicesNum && perspectiveError(a, b, c) > perspectiveCorrectionTolerance {
+
+			ab := camera.perspectiveMidpoint(a, b, screenSrcList)
+			bc := camera.perspectiveMidpoint(b, c, screenSrcList)
+			ca := camera.perspectiveMidpoint(c, a, screenSrcList)
+
+			reserved += 9
+
+			subdivide(a, &ab, &ca, level+1)
+			subdivide(&ab, b, &bc, level+1)
+			subdivide(&ca, &bc, c, level+1)
+			subdivide(&ab, &bc, &ca, level+1)
+			return
+
+		}
+
+		for _, v := range [3]*perspectiveVertex{a, b, c} {
+			for l, list := range camera.perspectiveLists {
+				list[out] = v.Vertices[l]
+			}
+			out++
+		}
+
+		reserved -= 3
+
+	}
+
+	for i := 0; i+2 < len(camera.perspectiveInput); i += 3 {
+		subdivide(&camera.perspectiveInput[i], &camera.perspectiveInput[i+1], &camera.perspectiveInput[i+2], 0)
+	}
+
+	return out
+
+}
+
+// perspectiveError returns roughly how far off (in pixels) affine texture mapping would be for the given triangle.
+func perspectiveError(a, b, c *perspectiveVertex) float64 {
+
+	// Vertices behind the Camera can't be corrected.
+	if a.W <= 0 || b.W <= 0 || c.W <= 0 {
+		return 0
+	}
+
+	minW := math.Min(a.W, math.Min(b.W, c.W))
+	maxW := math.Max(a.W, math.Max(b.W, c.W))
+
+	length := 0.0
+
+	for _, edge := range [3][2]*perspectiveVertex{{a, b}, {b, c}, {c, a}} {
+		dx := float64(edge[0].Vertices[0].DstX - edge[1].Vertices[0].DstX)
+		dy := float64(edge[0].Vertices[0].DstY - edge[1].Vertices[0].DstY)
+		length = math.Max(length, math.Sqrt(dx*dx+dy*dy))
+	}
+
+	// The midpoint of an edge is off by about this much; the error grows with the edge's length and how much it recedes.
+	return length * (maxW - minW) / (maxW + minW) / 2
+
+}
+
+// perspectiveMidpoint returns the vertex halfway between the given vertices in 3D. If screenSrcList isn't negative, it's the index
+// of the vertex list whose source positions are screen positions.
+func (camera *Camera) perspectiveMidpoint(a, b *perspectiveVertex, screenSrcList int) perspectiveVertex {
+
+	mid := perspectiveVertex{W: (a.W + b.W) / 2}
+
+	// Screen positions are divided by W, so the point halfway between the vertices in 3D lies closer to the further vertex on screen.
+	t := float32(a.W / (a.W + b.W))
+	dstX := a.Vertices[0].DstX + (b.Vertices[0].DstX-a.Vertices[0].DstX)*(1-t)
+	dstY := a.Vertices[0].DstY + (b.Vertices[0].DstY-a.Vertices[0].DstY)*(1-t)
+
+	for l := range camera.perspectiveLists {
+		lerpVertexAttributes(&mid.Vertices[l], a.Vertices[l], b.Vertices[l], 0.5)
+		mid.Vertices[l].DstX = dstX
+		mid.Vertices[l].DstY = dstY
+	}
+
+	if screenSrcList >= 0 {
+		mid.Vertices[screenSrcList].SrcX = dstX
+		mid.Vertices[screenSrcList].SrcY = dstY
+	}
+
+	return mid
+
+}
@@ -0,0 +1,80 @@
+package tetra3d
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestPerspectiveCorrection(t *testing.T) {
+
+	camera := NewCamera(64, 64)
+
+	mat := NewMaterial("floor")
+
+	if camera.perspectiveCorrected(mat) {
+		t.Errorf("expected textures to be mapped affinely by default")
+	}
+
+	camera.PerspectiveCorrectTextures = true
+
+	if !camera.perspectiveCorrected(mat) {
+		t.Errorf("expected Materials to follow the camera's texture mapping by default")
+	}
+
+	mat.TextureMapping = TextureMappingAffine
+
+	if camera.perspectiveCorrected(mat) {
+		t.Errorf("expected Materials to be able to override the camera's texture mapping")
+	}
+
+	camera.preparePerspectiveCorrection()
+
+	// A triangle receding into the distance, with its far vertex 10 times further away than its near vertices.
+	positions := [3][2]float32{{0, 60}, {60, 60}, {30, 10}}
+	ws := [3]float64{1, 1, 10}
+	uvs := [3][2]float32{{0, 0}, {16, 0}, {8, 16}}
+
+	for i := range positions {
+		colorVertexList[i] = ebiten.Vertex{DstX: positions[i][0], DstY: positions[i][1], SrcX: uvs[i][0], SrcY: uvs[i][1], ColorA: 1}
+		depthVertexList[i] = colorVertexList[i]
+		perspectiveWList[i] = ws[i]
+	}
+
+	end := camera.correctPerspective(0, 3, false, false, false, false)
+
+	if end <= 3 || end%3 != 0 {
+		t.Fatalf("expected the receding triangle to be subdivided, got %d vertices", end)
+	}
+
+	// The vertex halfway up the triangle in 3D (in the middle of its texture) should be much closer to the far vertex on screen.
+	found := false
+
+	for i := 0; i < end; i++ {
+		v := colorVertexList[i]
+		if v.SrcX == 4 && v.SrcY == 8 {
+			found = true
+			if v.DstY > 20 {
+				t.Errorf("expected the midpoint of the left edge to be placed near the far vertex, got a screen Y of %f", v.DstY)
+			}
+		}
+		if depthVertexList[i].DstX != v.DstX || depthVertexList[i].DstY != v.DstY {
+			t.Errorf("expected the depth vertices to be subdivided along with the color vertices")
+			break
+		}
+	}
+
+	if !found {
+		t.Errorf("expected a vertex to be created at the midpoint of the left edge")
+	}
+
+	// Triangles that face the camera don't need to be subdivided.
+	for i := range perspectiveWList[:3] {
+		perspectiveWList[i] = 5
+	}
+
+	if end := camera.correctPerspective(0, 3, false, false, false, false); end != 3 {
+		t.Errorf("expected a triangle facing the camera not to be subdivided, got %d vertices", end)
+	}
+
+}
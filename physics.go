@@ -0,0 +1,719 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/kvartborg/vector"
+)
+
+const (
+	// physicsSlop is how far RigidBodies can sink into each other before they're pushed apart; allowing a little overlap keeps
+	// resting contacts from jittering.
+	physicsSlop = 0.005
+	// physicsCorrection is how much of the overlap between RigidBodies (past the slop) is corrected each Update().
+	physicsCorrection = 0.4
+	// physicsBounceThreshold is the slowest (in world units per second) that RigidBodies can hit each other and still bounce.
+	physicsBounceThreshold = 1
+)
+
+// RigidBody is a physically simulated object in a PhysicsWorld. It's moved and rotated by gravity, forces, impulses, and collisions
+// with other RigidBodies, and it updates its Node's transform to match each time the PhysicsWorld is updated. RigidBodies collide using
+// a BoundingObject; BoundingSpheres, BoundingCapsules, and BoundingOBBs make good boxes, balls, and rolling props, and BoundingConvexes
+// fit other props. BoundingTriangles can be used for static level geometry. BoundingAABBs can't rotate, so RigidBodies using them don't, either.
+// RigidBodies rotate around their Node's origin, so their BoundingObject should be centered on it.
+type RigidBody struct {
+	Node   INode           // The Node moved and rotated by the RigidBody; this can be the Bounds itself, or a Node that the Bounds is a child of.
+	Bounds IBoundingObject // The BoundingObject the RigidBody collides with.
+
+	Velocity        vector.Vector // The RigidBody's linear velocity, in world units per second.
+	AngularVelocity vector.Vector // The RigidBody's angular velocity, as a world-space axis scaled by its speed in radians per second.
+
+	Restitution    float64 // How bouncy the RigidBody is, from 0 (not at all) to 1 (perfectly bouncy). Defaults to 0.2.
+	Friction       float64 // How much the RigidBody resists sliding against other RigidBodies. Defaults to 0.5.
+	LinearDamping  float64 // How quickly the RigidBody's Velocity slows down on its own, per second. Defaults to 0.05.
+	AngularDamping float64 // How quickly the RigidBody's AngularVelocity slows down on its own, per second. Defaults to 0.1.
+	GravityScale   float64 // How strongly the PhysicsWorld's Gravity affects the RigidBody. Defaults to 1.
+
+	// ContinuousCollision makes the RigidBody sweep from where it was to where it's going each time the PhysicsWorld is updated,
+	// rather than just being tested where it ends up, so that it can't tunnel through thin walls or other RigidBodies when moving
+	// quickly (like a projectile). This is slower, so it defaults to false.
+	ContinuousCollision bool
+
+	mass       float64
+	invMass    float64
+	invInertia vector.Vector // The inverse of the RigidBody's moment of inertia around each of its local axes.
+	force      vector.Vector
+	torque     vector.Vector
+	sleeping   bool
+	sleepTime  float64
+}
+
+// NewRigidBody returns a new RigidBody that moves the given Node and collides using the given BoundingObject (which should be the
+// Node itself or one of its children). If node is nil, the BoundingObject itself is moved. If mass is 0 or less, the RigidBody is
+// static, and doesn't move at all (which is good for level geometry).
+func NewRigidBody(node INode, bounds IBoundingObject, mass float64) *RigidBody {
+
+	if node == nil {
+		node = bounds.(INode)
+	}
+
+	body := &RigidBody{
+		Node:            node,
+		Bounds:          bounds,
+		Velocity:        vector.Vector{0, 0, 0},
+		AngularVelocity: vector.Vector{0, 0, 0},
+		Restitution:     0.2,
+		Friction:        0.5,
+		LinearDamping:   0.05,
+		AngularDamping:  0.1,
+		GravityScale:    1,
+		force:           vector.Vector{0, 0, 0},
+		torque:          vector.Vector{0, 0, 0},
+	}
+
+	body.SetMass(mass)
+
+	return body
+
+}
+
+// Mass returns the RigidBody's mass; static RigidBodies have a mass of 0.
+func (body *RigidBody) Mass() float64 {
+	return body.mass
+}
+
+// SetMass sets the RigidBody's mass, which also sets how hard it is to spin from the size of its Bounds. If mass is 0 or less, the
+// RigidBody becomes static. If you resize the RigidBody's Bounds, call SetMass() again to update how hard it is to spin.
+func (body *RigidBody) SetMass(mass float64) {
+
+	body.invInertia = vector.Vector{0, 0, 0}
+
+	if mass <= 0 {
+		body.mass = 0
+		body.invMass = 0
+		body.Velocity = vector.Vector{0, 0, 0}
+		body.AngularVelocity = vector.Vector{0, 0, 0}
+		return
+	}
+
+	body.mass = mass
+	body.invMass = 1 / mass
+
+	// Moments of inertia are approximated as those of a solid sphere or box of the same size.
+	var inertia vector.Vector
+
+	switch bounds := body.Bounds.(type) {
+
+	case *BoundingSphere:
+		r := bounds.WorldRadius()
+		i := 0.4 * mass * r * r
+		inertia = vector.Vector{i, i, i}
+
+	case *BoundingCapsule:
+		r := bounds.WorldRadius()
+		inertia = boxInertia(mass, r*2, bounds.Height*bounds.WorldScale()[1], r*2)
+
+	case *BoundingOBB:
+		size := bounds.HalfExtents().Scale(2)
+		inertia = boxInertia(mass, size[0], size[1], size[2])
+
+	case *BoundingTriangles:
+		size := bounds.Mesh.Dimensions.Size()
+		scale := bounds.WorldScale()
+		inertia = boxInertia(mass, size[0]*scale[0], size[1]*scale[1], size[2]*scale[2])
+
+	case *BoundingConvex:
+		size := NewDimensionsFromPoints(bounds.points...).Size()
+		scale := bounds.WorldScale()
+		inertia = boxInertia(mass, size[0]*scale[0], size[1]*scale[1], size[2]*scale[2])
+
+	}
+
+	// BoundingAABBs can't rotate, so their RigidBodies don't spin (which is the same as having infinite inertia).
+	for i := range inertia {
+		if inertia[i] > 0 {
+			body.invInertia[i] = 1 / inertia[i]
+		}
+	}
+
+}
+
+// boxInertia returns the moments of inertia of a solid box with the given mass and size around its local axes.
+func boxInertia(mass, width, height, depth float64) vector.Vector {
+	return vector.Vector{
+		mass / 12 * (height*height + depth*depth),
+		mass / 12 * (width*width + depth*depth),
+		mass / 12 * (width*width + height*height),
+	}
+}
+
+// IsStatic returns if the RigidBody is static (has no mass), and so doesn't move.
+func (body *RigidBody) IsStatic() bool {
+	return body.invMass == 0
+}
+
+// inverseMass returns the inverse of the RigidBody's mass, or 0 if it's static or asleep (as then it can't be moved by collisions).
+func (body *RigidBody) inverseMass() float64 {
+	if body.sleeping {
+		return 0
+	}
+	return body.invMass
+}
+
+// Sleeping returns if the RigidBody is asleep. RigidBodies fall asleep once they've come to rest, and aren't simulated until
+// something wakes them up (like another RigidBody hitting them, or a force or impulse being applied to them).
+func (body *RigidBody) Sleeping() bool {
+	return body.sleeping
+}
+
+// Wake wakes the RigidBody up if it was asleep.
+func (body *RigidBody) Wake() {
+	body.sleeping = false
+	body.sleepTime = 0
+}
+
+// ApplyForce applies a force (in world space) to the RigidBody at the given world position over the next PhysicsWorld.Update().
+// If position is nil, the force is applied at the RigidBody's center, and so doesn't spin it.
+func (body *RigidBody) ApplyForce(force, position vector.Vector) {
+
+	if body.IsStatic() {
+		return
+	}
+
+	body.force = body.force.Add(force)
+
+	if position != nil {
+		torque, _ := position.Sub(body.Node.WorldPosition()).Cross(force)
+		body.torque = body.torque.Add(torque)
+	}
+
+	body.Wake()
+
+}
+
+// ApplyTorque applies a torque (a world-space axis scaled by its strength) to the RigidBody over the next PhysicsWorld.Update().
+func (body *RigidBody) ApplyTorque(torque vector.Vector) {
+
+	if body.IsStatic() {
+		return
+	}
+
+	body.torque = body.torque.Add(torque)
+	body.Wake()
+
+}
+
+// ApplyImpulse instantly changes the RigidBody's velocity by applying an impulse (in world space) at the given world position. If
+// position is nil, the impulse is applied at the RigidBody's center, and so doesn't spin it.
+func (body *RigidBody) ApplyImpulse(impulse, position vector.Vector) {
+
+	if body.IsStatic() {
+		return
+	}
+
+	body.Wake()
+
+	if position == nil {
+		body.applyImpulse(impulse, vector.Vector{0, 0, 0})
+	} else {
+		body.applyImpulse(impulse, position.Sub(body.Node.WorldPosition()))
+	}
+
+}
+
+// applyImpulse applies an impulse at the given offset from the RigidBody's center.
+func (body *RigidBody) applyImpulse(impulse, offset vector.Vector) {
+	if body.inverseMass() == 0 {
+		return
+	}
+	body.Velocity = body.Velocity.Add(impulse.Scale(body.invMass))
+	angular, _ := offset.Cross(impulse)
+	body.AngularVelocity = body.AngularVelocity.Add(body.applyInvInertia(angular))
+}
+
+// applyInvInertia multiplies the given world-space vector by the RigidBody's inverse inertia tensor in world space.
+func (body *RigidBody) applyInvInertia(vec vector.Vector) vector.Vector {
+
+	out := vector.Vector{0, 0, 0}
+
+	if body.inverseMass() == 0 {
+		return out
+	}
+
+	transform := body.Node.Transform()
+
+	for i := 0; i < 3; i++ {
+		axis := vector.Vector{transform[i][0], transform[i][1], transform[i][2]}.Unit()
+		out = out.Add(axis.Scale(body.invInertia[i] * dot(axis, vec)))
+	}
+
+	return out
+
+}
+
+// velocityAt returns the velocity of the point at the given offset from the RigidBody's center.
+func (body *RigidBody) velocityAt(offset vector.Vector) vector.Vector {
+	spin, _ := body.AngularVelocity.Cross(offset)
+	return body.Velocity.Add(spin)
+}
+
+// physicsContact is a single point of contact between two RigidBodies, with the normal pointing from B towards A.
+type physicsContact struct {
+	A, B           *RigidBody
+	Normal         vector.Vector
+	Depth          float64
+	offsetA        vector.Vector
+	offsetB        vector.Vector
+	tangents       [2]vector.Vector
+	bounce         float64
+	normalImpulse  float64
+	frictionLimit  float64
+	tangentImpulse [2]float64
+}
+
+// PhysicsWorld simulates a set of RigidBodies, moving them with gravity and making them collide with and bounce off of each other.
+// It's not as accurate as a dedicated physics engine, but it's stable enough for crates, props, and rolling objects. Like with
+// CollisionWorlds, RigidBodies only collide if at least one of their Bounds collides with the other's according to their
+// CollisionFilters.
+type PhysicsWorld struct {
+	Gravity    vector.Vector // The acceleration due to gravity, in world units per second squared. Defaults to {0, -9.8, 0}.
+	Iterations int           // How many times collisions are resolved each Update(); more is more accurate, but slower. Defaults to 10.
+	// SleepThreshold is the speed (in world units or radians per second) under which RigidBodies are considered to be resting; once
+	// they've rested for SleepTime seconds, they fall asleep. Defaults to 0.1.
+	SleepThreshold float64
+	SleepTime      float64 // How long RigidBodies must rest before falling asleep, in seconds. Defaults to 0.5. If 0 or less, they never sleep.
+
+	bodies []*RigidBody
+}
+
+// NewPhysicsWorld returns a new, empty PhysicsWorld.
+func NewPhysicsWorld() *PhysicsWorld {
+	return &PhysicsWorld{
+		Gravity:        vector.Vector{0, -9.8, 0},
+		Iterations:     10,
+		SleepThreshold: 0.1,
+		SleepTime:      0.5,
+		bodies:         []*RigidBody{},
+	}
+}
+
+// Add adds the given RigidBodies to the PhysicsWorld. RigidBodies that are already in it are skipped.
+func (world *PhysicsWorld) Add(bodies ...*RigidBody) {
+
+	for _, body := range bodies {
+
+		if world.Contains(body) {
+			continue
+		}
+
+		world.bodies = append(world.bodies, body)
+
+	}
+
+}
+
+// Remove removes the given RigidBodies from the PhysicsWorld.
+func (world *PhysicsWorld) Remove(bodies ...*RigidBody) {
+
+	for _, body := range bodies {
+
+		for i, b := range world.bodies {
+			if b == body {
+				world.bodies = append(world.bodies[:i], world.bodies[i+1:]...)
+				break
+			}
+		}
+
+	}
+
+}
+
+// Contains returns if the given RigidBody is in the PhysicsWorld.
+func (world *PhysicsWorld) Contains(body *RigidBody) bool {
+	for _, b := range world.bodies {
+		if b == body {
+			return true
+		}
+	}
+	return false
+}
+
+// Bodies returns the RigidBodies in the PhysicsWorld, in the order they were added.
+func (world *PhysicsWorld) Bodies() []*RigidBody {
+	return append([]*RigidBody{}, world.bodies...)
+}
+
+// Update steps the PhysicsWorld's simulation forward by dt seconds, updating the transforms of its RigidBodies' Nodes. You should
+// call Update() once per game frame with a fixed dt (like 1.0 / 60) for the most stable results.
+func (world *PhysicsWorld) Update(dt float64) {
+
+	if dt <= 0 {
+		return
+	}
+
+	// Applying gravity and forces
+
+	for _, body := range world.bodies {
+
+		if body.IsStatic() || body.sleeping {
+			continue
+		}
+
+		acceleration := world.Gravity.Scale(body.GravityScale).Add(body.force.Scale(body.invMass))
+		body.Velocity = body.Velocity.Add(acceleration.Scale(dt))
+		body.AngularVelocity = body.AngularVelocity.Add(body.applyInvInertia(body.torque).Scale(dt))
+
+		body.Velocity = body.Velocity.Scale(1 / (1 + dt*body.LinearDamping))
+		body.AngularVelocity = body.AngularVelocity.Scale(1 / (1 + dt*body.AngularDamping))
+
+	}
+
+	for _, body := range world.bodies {
+		body.force = vector.Vector{0, 0, 0}
+		body.torque = vector.Vector{0, 0, 0}
+	}
+
+	// Resolving collisions
+
+	contacts := world.findContacts()
+
+	for _, contact := range contacts {
+		contact.prepare()
+	}
+
+	for i := 0; i < world.Iterations; i++ {
+		for _, contact := range contacts {
+			contact.solve()
+		}
+	}
+
+	// Moving
+
+	for _, body := range world.bodies {
+
+		if body.IsStatic() || body.sleeping {
+			continue
+		}
+
+		movement := body.Velocity.Scale(dt)
+
+		if body.ContinuousCollision {
+			movement = world.sweepBody(body, movement, contacts)
+		}
+
+		body.Node.SetWorldPositionVec(body.Node.WorldPosition().Add(movement))
+
+		if speed := body.AngularVelocity.Magnitude(); speed > 0 {
+			axis := body.AngularVelocity
+			body.Node.SetWorldRotation(body.Node.WorldRotation().Mult(NewMatrix4Rotate(axis[0], axis[1], axis[2], speed*dt)))
+		}
+
+	}
+
+	// Pushing overlapping RigidBodies apart directly, as impulses alone would let them slowly sink into each other.
+
+	for _, contact := range contacts {
+
+		invMassA, invMassB := contact.A.inverseMass(), contact.B.inverseMass()
+
+		if invMassA+invMassB == 0 {
+			continue
+		}
+
+		correction := math.Max(contact.Depth-physicsSlop, 0) * physicsCorrection / (invMassA + invMassB)
+
+		if correction <= 0 {
+			continue
+		}
+
+		push := contact.Normal.Scale(correction)
+
+		if invMassA > 0 {
+			contact.A.Node.SetWorldPositionVec(contact.A.Node.WorldPosition().Add(push.Scale(invMassA)))
+		}
+
+		if invMassB > 0 {
+			contact.B.Node.SetWorldPositionVec(contact.B.Node.WorldPosition().Sub(push.Scale(invMassB)))
+		}
+
+	}
+
+	// Falling asleep
+
+	for _, body := range world.bodies {
+
+		if body.IsStatic() || body.sleeping {
+			continue
+		}
+
+		if world.SleepTime > 0 && body.Velocity.Magnitude() < world.SleepThreshold && body.AngularVelocity.Magnitude() < world.SleepThreshold {
+
+			body.sleepTime += dt
+
+			if body.sleepTime >= world.SleepTime {
+				body.sleeping = true
+				body.Velocity = vector.Vector{0, 0, 0}
+				body.AngularVelocity = vector.Vector{0, 0, 0}
+			}
+
+		} else {
+			body.sleepTime = 0
+		}
+
+	}
+
+}
+
+// findContacts returns the points of contact between the PhysicsWorld's colliding RigidBodies, waking sleeping RigidBodies that
+// moving ones run into.
+func (world *PhysicsWorld) findContacts() []*physicsContact {
+
+	contacts := []*physicsContact{}
+
+	for i, a := range world.bodies {
+
+		for _, b := range world.bodies[i+1:] {
+
+			aResting := a.IsStatic() || a.sleeping
+			bResting := b.IsStatic() || b.sleeping
+
+			if (aResting && bResting) || (!a.Bounds.CollidesWith(b.Bounds) && !b.Bounds.CollidesWith(a.Bounds)) {
+				continue
+			}
+
+			collision := a.Bounds.Collision(b.Bounds)
+
+			if collision == nil {
+				continue
+			}
+
+			// Sleeping RigidBodies are only woken up by RigidBodies that are actually moving, so that stacks of RigidBodies can all
+			// fall asleep.
+			if a.sleeping && b.Velocity.Magnitude() >= world.SleepThreshold {
+				a.Wake()
+			} else if b.sleeping && a.Velocity.Magnitude() >= world.SleepThreshold {
+				b.Wake()
+			}
+
+			normals := []vector.Vector{}
+
+			for _, intersection := range collision.Intersections {
+
+				depth := intersection.MTV.Magnitude()
+
+				if depth == 0 {
+					continue
+				}
+
+				normal := intersection.MTV.Scale(1 / depth)
+
+				// Touching several triangles of a flat surface gives the same normal several times over, so we only use each
+				// normal once.
+				duplicate := false
+				for _, n := range normals {
+					if dot(n, normal) > 0.999 {
+						duplicate = true
+						break
+					}
+				}
+
+				if duplicate {
+					continue
+				}
+
+				normals = append(normals, normal)
+
+				for _, point := range contactPoints(a, b, normal, depth, intersection.ContactPoint) {
+					contacts = append(contacts, &physicsContact{A: a, B: b, Normal: normal, Depth: depth, offsetA: point})
+				}
+
+			}
+
+		}
+
+	}
+
+	return contacts
+
+}
+
+// sweepBody sweeps the given RigidBody along its movement against the other RigidBodies that it isn't already touching, so that it
+// can't tunnel through them. If it hits one, the RigidBody bounces off of it, and the movement up to the hit is returned.
+func (world *PhysicsWorld) sweepBody(body *RigidBody, movement vector.Vector, contacts []*physicsContact) vector.Vector {
+
+	// RigidBodies that are already being touched are left out, as they'd always be hit immediately (and are already handled by
+	// their contacts, anyway).
+	touching := map[*RigidBody]bool{body: true}
+
+	for _, contact := range contacts {
+		if contact.A == body {
+			touching[contact.B] = true
+		} else if contact.B == body {
+			touching[contact.A] = true
+		}
+	}
+
+	others := []INode{}
+
+	for _, other := range world.bodies {
+		if !touching[other] {
+			others = append(others, other.Bounds.(INode))
+		}
+	}
+
+	if len(others) == 0 || movement.Magnitude() == 0 {
+		return movement
+	}
+
+	hit := body.Bounds.Sweep(movement, others...)
+
+	if hit == nil {
+		return movement
+	}
+
+	restitution := body.Restitution
+
+	for _, other := range world.bodies {
+		if other.Bounds.(INode) == hit.BoundingObject {
+			restitution = math.Max(restitution, other.Restitution)
+			break
+		}
+	}
+
+	if approach := dot(body.Velocity, hit.Normal); approach < 0 {
+		body.Velocity = body.Velocity.Sub(hit.Normal.Scale((1 + restitution) * approach))
+	}
+
+	return movement.Scale(hit.Time)
+
+}
+
+// contactPoints returns the world-space points of contact for a collision between the given RigidBodies along the given normal
+// (pointing from b towards a). Boxes (and convex hulls) touch along their faces and edges, so rather than a single point, they use
+// each of their corners that's sunk into the other RigidBody; this lets boxes rest flat on the ground and tip over off of edges.
+func contactPoints(a, b *RigidBody, normal vector.Vector, depth float64, contactPoint vector.Vector) []vector.Vector {
+
+	corners := func(bounds IBoundingObject) []vector.Vector {
+		switch shape := bounds.(type) {
+		case *BoundingOBB:
+			return shape.Corners()
+		case *BoundingConvex:
+			return shape.Points()
+		}
+		return nil
+	}
+
+	direction := normal.Invert()
+	points := corners(a.Bounds)
+
+	if points == nil {
+		points = corners(b.Bounds)
+		direction = normal
+	}
+
+	if points == nil {
+		return []vector.Vector{contactPoint}
+	}
+
+	deepest := -math.MaxFloat64
+	for _, corner := range points {
+		deepest = math.Max(deepest, dot(corner, direction))
+	}
+
+	sunk := []vector.Vector{}
+	for _, corner := range points {
+		if dot(corner, direction) >= deepest-depth-physicsSlop {
+			sunk = append(sunk, corner)
+		}
+	}
+
+	return sunk
+
+}
+
+// prepare calculates the values needed to resolve the contact that stay the same across iterations.
+func (contact *physicsContact) prepare() {
+
+	point := contact.offsetA
+	contact.offsetA = point.Sub(contact.A.Node.WorldPosition())
+	contact.offsetB = point.Sub(contact.B.Node.WorldPosition())
+
+	// Two tangents perpendicular to the normal are used for friction.
+	axis := vector.Vector{1, 0, 0}
+	if math.Abs(contact.Normal[0]) > 0.9 {
+		axis = vector.Vector{0, 1, 0}
+	}
+	first, _ := contact.Normal.Cross(axis)
+	first = first.Unit()
+	second, _ := contact.Normal.Cross(first)
+	contact.tangents = [2]vector.Vector{first, second}
+
+	contact.frictionLimit = math.Sqrt(contact.A.Friction * contact.B.Friction)
+
+	approach := dot(contact.relativeVelocity(), contact.Normal)
+	if approach < -physicsBounceThreshold {
+		contact.bounce = -approach * math.Max(contact.A.Restitution, contact.B.Restitution)
+	}
+
+}
+
+// relativeVelocity returns the velocity of RigidBody A relative to RigidBody B at the contact.
+func (contact *physicsContact) relativeVelocity() vector.Vector {
+	return contact.A.velocityAt(contact.offsetA).Sub(contact.B.velocityAt(contact.offsetB))
+}
+
+// effectiveMass returns the inverse of how much the RigidBodies' relative velocity along the given direction changes from a unit
+// impulse applied along it at the contact.
+func (contact *physicsContact) effectiveMass(direction vector.Vector) float64 {
+
+	k := contact.A.inverseMass() + contact.B.inverseMass()
+
+	crossA, _ := contact.offsetA.Cross(direction)
+	crossA, _ = contact.A.applyInvInertia(crossA).Cross(contact.offsetA)
+	crossB, _ := contact.offsetB.Cross(direction)
+	crossB, _ = contact.B.applyInvInertia(crossB).Cross(contact.offsetB)
+
+	k += dot(crossA.Add(crossB), direction)
+
+	if k <= 0 {
+		return 0
+	}
+
+	return 1 / k
+
+}
+
+// applyImpulse applies an impulse along the given direction to RigidBody A, and the opposite impulse to RigidBody B.
+func (contact *physicsContact) applyImpulse(direction vector.Vector, impulse float64) {
+	contact.A.applyImpulse(direction.Scale(impulse), contact.offsetA)
+	contact.B.applyImpulse(direction.Scale(-impulse), contact.offsetB)
+}
+
+// solve applies the impulses needed to stop the RigidBodies from moving into each other at the contact, and to slow them from
+// sliding against each other. The impulses are accumulated across iterations and clamped, so that contacts don't pull the
+// RigidBodies together.
+func (contact *physicsContact) solve() {
+
+	approach := dot(contact.relativeVelocity(), contact.Normal)
+	impulse := (contact.bounce - approach) * contact.effectiveMass(contact.Normal)
+
+	total := math.Max(contact.normalImpulse+impulse, 0)
+	impulse = total - contact.normalImpulse
+	contact.normalImpulse = total
+	contact.applyImpulse(contact.Normal, impulse)
+
+	limit := contact.frictionLimit * contact.normalImpulse
+
+	for i, tangent := range contact.tangents {
+
+		slide := dot(contact.relativeVelocity(), tangent)
+		impulse := -slide * contact.effectiveMass(tangent)
+
+		total := math.Max(-limit, math.Min(contact.tangentImpulse[i]+impulse, limit))
+		impulse = total - contact.tangentImpulse[i]
+		contact.tangentImpulse[i] = total
+		contact.applyImpulse(tangent, impulse)
+
+	}
+
+}
@@ -0,0 +1,182 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kvartborg/vector"
+)
+
+func newPhysicsTestWorld() (*PhysicsWorld, *Scene) {
+
+	scene := NewScene("physics test")
+
+	floor := NewBoundingTriangles("floor", NewPlane(), 0)
+	floor.SetLocalScale(20, 1, 20)
+	scene.Root.AddChildren(floor)
+
+	world := NewPhysicsWorld()
+	world.Add(NewRigidBody(nil, floor, 0))
+
+	return world, scene
+
+}
+
+func TestPhysicsWorld(t *testing.T) {
+
+	world, scene := newPhysicsTestWorld()
+
+	ball := NewBoundingSphere("ball", 0.5)
+	ball.SetLocalPosition(0, 3, 0)
+	scene.Root.AddChildren(ball)
+
+	ballBody := NewRigidBody(nil, ball, 1)
+	world.Add(ballBody)
+
+	for i := 0; i < 300; i++ {
+		world.Update(1.0 / 60)
+	}
+
+	if y := ball.WorldPosition()[1]; math.Abs(y-0.5) > 0.02 || !ballBody.Sleeping() {
+		t.Fatalf("the ball should have come to rest on the floor; position: %v, sleeping: %t", ball.WorldPosition(), ballBody.Sleeping())
+	}
+
+	// Pushing the ball wakes it up, and friction makes it roll along the floor.
+	ballBody.ApplyImpulse(vector.Vector{2, 0, 0}, nil)
+
+	for i := 0; i < 30; i++ {
+		world.Update(1.0 / 60)
+	}
+
+	if ballBody.Sleeping() || ball.WorldPosition()[0] < 0.5 || ballBody.AngularVelocity[2] > -0.5 {
+		t.Fatalf("the ball should be rolling along the floor; position: %v, spin: %v", ball.WorldPosition(), ballBody.AngularVelocity)
+	}
+
+}
+
+func TestPhysicsWorldBounce(t *testing.T) {
+
+	world, scene := newPhysicsTestWorld()
+
+	ball := NewBoundingSphere("ball", 0.5)
+	ball.SetLocalPosition(0, 5.5, 0)
+	scene.Root.AddChildren(ball)
+
+	ballBody := NewRigidBody(nil, ball, 1)
+	ballBody.Restitution = 0.8
+	world.Add(ballBody)
+
+	bounced := false
+	highest := 0.0
+
+	for i := 0; i < 180; i++ {
+
+		falling := ballBody.Velocity[1] < 0
+		world.Update(1.0 / 60)
+
+		if falling && ballBody.Velocity[1] > 0 {
+			bounced = true
+		}
+
+		if bounced {
+			highest = math.Max(highest, ball.WorldPosition()[1])
+		}
+
+	}
+
+	// Dropped from a height of 5, a ball with a restitution of 0.8 should bounce back up to about 5 * 0.8 * 0.8 = 3.2.
+	if !bounced || highest < 0.5+2.5 || highest > 0.5+4 {
+		t.Fatalf("the ball should have bounced back up to about 3.2 units high; bounced: %t, highest: %f", bounced, highest)
+	}
+
+}
+
+func TestPhysicsWorldBoxes(t *testing.T) {
+
+	world, scene := newPhysicsTestWorld()
+
+	// A crate dropped on its corner should tip over and land flat...
+	crate := NewBoundingOBB("crate", 1, 1, 1)
+	crate.SetLocalPosition(-4, 2, 0)
+	crate.SetLocalRotation(NewMatrix4Rotate(1, 0, 1, ToRadians(35)))
+	scene.Root.AddChildren(crate)
+	world.Add(NewRigidBody(nil, crate, 1))
+
+	// ...and a stack of crates should stay stacked.
+	stack := []*BoundingOBB{}
+
+	for i := 0; i < 3; i++ {
+		box := NewBoundingOBB("stacked crate", 1, 1, 1)
+		box.SetLocalPosition(4, 0.5+float64(i)*1.01, 0)
+		scene.Root.AddChildren(box)
+		world.Add(NewRigidBody(nil, box, 1))
+		stack = append(stack, box)
+	}
+
+	for i := 0; i < 600; i++ {
+		world.Update(1.0 / 60)
+	}
+
+	flat := false
+	for _, axis := range crate.Axes() {
+		if math.Abs(dot(axis, vector.Vector{0, 1, 0})) > 0.99 {
+			flat = true
+		}
+	}
+
+	if !flat || math.Abs(crate.WorldPosition()[1]-0.5) > 0.05 {
+		t.Fatalf("the crate should have landed flat on the floor; position: %v, axes: %v", crate.WorldPosition(), crate.Axes())
+	}
+
+	for i, box := range stack {
+		pos := box.WorldPosition()
+		if math.Abs(pos[0]-4) > 0.1 || math.Abs(pos[2]) > 0.1 || math.Abs(pos[1]-(0.5+float64(i))) > 0.05 {
+			t.Fatalf("the stack of crates should have stayed stacked; crate %d's position: %v", i, pos)
+		}
+	}
+
+}
+
+func TestPhysicsWorldContinuousCollision(t *testing.T) {
+
+	scene := NewScene("continuous collision test")
+
+	wall := NewBoundingAABB("wall", 0.05, 4, 4)
+	wall.SetLocalPosition(5, 0, 0)
+	scene.Root.AddChildren(wall)
+
+	world := NewPhysicsWorld()
+	world.Gravity = vector.Vector{0, 0, 0}
+	world.Add(NewRigidBody(nil, wall, 0))
+
+	bullet := NewBoundingSphere("bullet", 0.1)
+	scene.Root.AddChildren(bullet)
+
+	bulletBody := NewRigidBody(nil, bullet, 0.1)
+	world.Add(bulletBody)
+
+	// Moving 10 units each frame, the bullet passes right through the wall without continuous collision...
+	bulletBody.Velocity = vector.Vector{600, 0, 0}
+
+	for i := 0; i < 3; i++ {
+		world.Update(1.0 / 60)
+	}
+
+	if bullet.WorldPosition()[0] < 5 {
+		t.Fatalf("without continuous collision, the bullet should tunnel through the wall; position: %v", bullet.WorldPosition())
+	}
+
+	// ...but not with it.
+	bullet.SetLocalPosition(0, 0, 0)
+	bulletBody.Velocity = vector.Vector{600, 0, 0}
+	bulletBody.ContinuousCollision = true
+
+	for i := 0; i < 3; i++ {
+		world.Update(1.0 / 60)
+	}
+
+	if bullet.WorldPosition()[0] > 5 || bulletBody.Velocity[0] >= 0 {
+		t.Fatalf("with continuous collision, the bullet should bounce off of the wall; position: %v, velocity: %v", bullet.WorldPosition(), bulletBody.Velocity)
+	}
+
+}
@@ -0,0 +1,206 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/kvartborg/vector"
+)
+
+// PickResult is the result of picking a Model's triangle with a ray (see Camera.MouseRayCast()).
+type PickResult struct {
+	Model    *Model    // The Model that was hit.
+	Triangle *Triangle // The Triangle that was hit; its ID is the triangle's index in the Model's Mesh.
+
+	// Barycentric holds the barycentric coordinates of the hit on the Triangle; that is, the weights of the Triangle's three vertices
+	// (in order) that, blended together, give the hit position. They can be used to blend any of the vertices' properties (like their
+	// UV values or colors) to get the value at the hit position.
+	Barycentric vector.Vector
+
+	Position vector.Vector // The world position of the hit.
+	Normal   vector.Vector // The world-space normal of the Triangle that was hit (the side facing the ray).
+	Distance float64       // The distance from the ray's origin to the hit, in world units.
+}
+
+// MouseRayCast casts a ray from the Camera through the given screen position (see Camera.ScreenToWorldRay()) and returns the closest
+// hit on the triangles of the given Nodes and their recursive children, or nil if nothing was hit, for picking objects with the
+// mouse. Only the part of the ray between the Camera's near and far planes is tested. Only Models that the Camera would render
+// (visible ones on its LayerMask) are hit, and faces turned away from the Camera are ignored for Materials with BackfaceCulling on.
+// Skinned Models are tested in their current pose, and Models with morph targets with their current morph weights applied; billboarded
+// Materials are tested as though they weren't billboarded.
+func (camera *Camera) MouseRayCast(screenX, screenY float64, nodes ...INode) *PickResult {
+
+	from, to := camera.screenToNearFar(screenX, screenY)
+
+	var closest *PickResult
+
+	// test tests the given Node, keeping the result if it's the closest hit so far.
+	test := func(node INode) {
+
+		model, ok := node.(*Model)
+
+		if !ok || !model.visible || !camera.LayerMask.Has(model.Layers) || model.Mesh == nil {
+			return
+		}
+
+		if result := model.rayCast(from, to); result != nil && (closest == nil || result.Distance < closest.Distance) {
+			closest = result
+		}
+
+	}
+
+	for _, node := range nodes {
+		test(node)
+		for _, child := range node.ChildrenRecursive() {
+			test(child)
+		}
+	}
+
+	return closest
+
+}
+
+// rayCast returns the closest hit on the Model's triangles of the line segment between the given world positions, or nil if there
+// isn't one.
+func (model *Model) rayCast(from, to vector.Vector) *PickResult {
+
+	mesh := model.Mesh
+	transform := model.Transform()
+	positions, _ := model.morphedVertices()
+
+	if model.Skinned {
+
+		// Skinned vertex positions are already in world space.
+		skinned := make([]vector.Vector, mesh.VertexCount)
+		model.skinVectorPool.Reset()
+		for i := range skinned {
+			pos, _ := model.skinVertex(i, positions, nil, false)
+			if model.VertexTransformFunction != nil {
+				pos = model.VertexTransformFunction(pos, i)
+			}
+			skinned[i] = vector.Vector{pos[0], pos[1], pos[2]}
+		}
+		positions = skinned
+		transform = NewMatrix4()
+
+	} else if model.VertexTransformFunction != nil {
+
+		transformed := make([]vector.Vector, mesh.VertexCount)
+		for i := range transformed {
+			transformed[i] = model.VertexTransformFunction(positions[i].Clone(), i)
+		}
+		positions = transformed
+
+	} else if !segmentHitsSphere(from, to, model.BoundingSphere.WorldPosition(), model.BoundingSphere.WorldRadius()) {
+		// The bounding sphere only fits the Mesh's own vertex positions, so it can't be used to reject Models whose vertices move.
+		return nil
+	}
+
+	// The segment is tested in the Model's local space; as the transform is affine, hits are at the same fractions of the segment.
+	inverse := transform.Inverted()
+	localFrom := inverse.MultVec(from)
+	localTo := inverse.MultVec(to)
+	dir := localTo.Sub(localFrom)
+
+	// The segment always runs away from the Camera, so faces the segment hits the front of are the ones facing the Camera.
+	hitT := math.MaxFloat64
+	var hitTri *Triangle
+	var hitU, hitV float64
+
+	for _, tri := range mesh.Triangles {
+
+		a, b, c := positions[tri.ID*3], positions[tri.ID*3+1], positions[tri.ID*3+2]
+
+		t, u, v, frontFacing, hit := rayTriangleBarycentric(localFrom, dir, a, b, c)
+
+		if !hit || t < 0 || t > 1 || t >= hitT {
+			continue
+		}
+
+		if !frontFacing && tri.MeshPart.Material != nil && tri.MeshPart.Material.BackfaceCulling {
+			continue
+		}
+
+		hitT, hitTri, hitU, hitV = t, tri, u, v
+
+	}
+
+	if hitTri == nil {
+		return nil
+	}
+
+	a := transform.MultVec(positions[hitTri.ID*3])
+	b := transform.MultVec(positions[hitTri.ID*3+1])
+	c := transform.MultVec(positions[hitTri.ID*3+2])
+
+	normal := calculateNormal(a, b, c)
+	segment := to.Sub(from)
+	if dot(normal, segment) > 0 {
+		normal = normal.Invert()
+	}
+
+	return &PickResult{
+		Model:       model,
+		Triangle:    hitTri,
+		Barycentric: vector.Vector{1 - hitU - hitV, hitU, hitV},
+		Position:    from.Add(segment.Scale(hitT)),
+		Normal:      normal,
+		Distance:    segment.Magnitude() * hitT,
+	}
+
+}
+
+// rayTriangleBarycentric intersects the ray from the origin along the direction with the triangle composed of the vertices v0, v1,
+// and v2, using the Möller–Trumbore intersection algorithm. It returns the distance (as a multiple of the direction vector) along the
+// ray to the triangle (which is negative if the triangle's behind the origin), the barycentric coordinates of the hit (as the weights
+// of v1 and v2), whether the ray hit the triangle's front (the side its normal faces; see calculateNormal()), and whether the ray's
+// line intersects the triangle at all.
+func rayTriangleBarycentric(origin, direction, v0, v1, v2 vector.Vector) (t, u, v float64, front, hit bool) {
+
+	edge1 := v1.Sub(v0)
+	edge2 := v2.Sub(v0)
+
+	h, _ := direction.Cross(edge2)
+	a := dot(edge1, h)
+
+	if math.Abs(a) < 0.0000001 {
+		return 0, 0, 0, false, false // Parallel to the triangle
+	}
+
+	f := 1.0 / a
+	s := origin.Sub(v0)
+	u = f * dot(s, h)
+
+	if u < 0 || u > 1 {
+		return 0, 0, 0, false, false
+	}
+
+	q, _ := s.Cross(edge1)
+	v = f * dot(direction, q)
+
+	if v < 0 || u+v > 1 {
+		return 0, 0, 0, false, false
+	}
+
+	t = f * dot(edge2, q)
+
+	// The determinant (a) is positive when the ray runs against the triangle's normal.
+	return t, u, v, a > 0, true
+
+}
+
+// segmentHitsSphere returns if the line segment between the given positions passes within the given radius of the given center.
+func segmentHitsSphere(from, to, center vector.Vector, radius float64) bool {
+
+	segment := to.Sub(from)
+	toCenter := center.Sub(from)
+
+	t := 0.0
+	if lengthSquared := dot(segment, segment); lengthSquared > 0 {
+		t = math.Max(0, math.Min(1, dot(toCenter, segment)/lengthSquared))
+	}
+
+	closest := toCenter.Sub(segment.Scale(t))
+
+	return dot(closest, closest) <= radius*radius
+
+}
@@ -0,0 +1,84 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kvartborg/vector"
+)
+
+func TestCameraMouseRayCast(t *testing.T) {
+
+	camera := NewCamera(160, 90)
+	camera.Move(0, 0, 5)
+
+	root := NewNode("root")
+
+	near := NewModel(NewCube(), "near")
+	far := NewModel(NewCube(), "far")
+	far.Move(0, 0, -4)
+	root.AddChildren(near, far)
+
+	result := camera.MouseRayCast(80, 45, root)
+
+	if result == nil || result.Model != near {
+		t.Fatalf("the ray through the center of the screen should hit the nearer cube, but hit %v", result)
+	}
+
+	if result.Position.Sub(vector.Vector{0, 0, 1}).Magnitude() > 1e-6 || result.Normal.Sub(vector.Vector{0, 0, 1}).Magnitude() > 1e-6 {
+		t.Fatalf("the ray should hit the front face of the cube at 0, 0, 1 facing +Z, but hit %v facing %v", result.Position, result.Normal)
+	}
+
+	if math.Abs(result.Distance-(4-camera.Near)) > 1e-6 {
+		t.Fatalf("the hit should be %f units from the near plane, but is %f", 4-camera.Near, result.Distance)
+	}
+
+	blended := vector.Vector{0, 0, 0}
+	for i, weight := range result.Barycentric {
+		blended = blended.Add(near.Mesh.VertexPositions[result.Triangle.ID*3+i].Scale(weight))
+	}
+
+	if blended.Sub(vector.Vector{0, 0, 1}).Magnitude() > 1e-6 {
+		t.Fatalf("blending the triangle's vertices by the barycentric coordinates should give the hit position, but gave %v", blended)
+	}
+
+	if result := camera.MouseRayCast(0, 0, root); result != nil {
+		t.Fatalf("the ray through the corner of the screen should miss, but hit %s", result.Model.Name())
+	}
+
+	near.SetVisible(false, false)
+
+	if result := camera.MouseRayCast(80, 45, root); result == nil || result.Model != far {
+		t.Fatalf("with the nearer cube hidden, the ray should hit the further cube")
+	}
+
+	near.SetVisible(true, false)
+
+	// Vertex transformations are taken into account.
+	near.VertexTransformFunction = func(vertexPosition vector.Vector, vertexIndex int) vector.Vector {
+		vertexPosition[1] += 10
+		return vertexPosition
+	}
+
+	if result := camera.MouseRayCast(80, 45, near); result != nil {
+		t.Fatalf("the cube's vertices were moved out of the ray's way, but it was still hit")
+	}
+
+	near.VertexTransformFunction = nil
+
+	// From inside the cube, only its back faces can be hit.
+	camera.SetLocalPositionVec(vector.Vector{0, 0, 0})
+
+	if result := camera.MouseRayCast(80, 45, near); result != nil {
+		t.Fatalf("the cube's back faces should be ignored with backface culling on")
+	}
+
+	for _, mat := range near.Mesh.Materials() {
+		mat.BackfaceCulling = false
+	}
+
+	if result := camera.MouseRayCast(80, 45, near); result == nil || result.Normal.Sub(vector.Vector{0, 0, 1}).Magnitude() > 1e-6 {
+		t.Fatalf("the cube's far face should be hit (facing the ray) with backface culling off, but got %v", result)
+	}
+
+}
@@ -0,0 +1,427 @@
+package tetra3d
+
+import (
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/kvartborg/vector"
+)
+
+// MaxPixelLights is the maximum number of point, spot, and directional lights that can light a Material using LightingModePixel per
+// pixel; any further lights are applied per vertex instead.
+const MaxPixelLights = 8
+
+// pixelLightScale is how much light levels are divided by when rendered to the Camera's light buffer (and multiplied by when reading
+// them back), so that light brighter than 1 isn't lost.
+const pixelLightScale = 2
+
+// Light types, as given to the pixel lighting shader
+const (
+	pixelLightTypeDirectional = iota
+	pixelLightTypePoint
+	pixelLightTypeSpot
+)
+
+// The pixel lighting shader renders the light levels of each pixel to the light buffer. As Ebitengine vertices have no custom
+// attributes, each vertex's world normal is passed through its color, and its world position through its color's alpha channel and
+// texture coordinates. For normal-mapped triangles, the normals are read from the normal buffers instead (see drawNormalMap()), and
+// the world position is passed through the vertex's color.
+var pixelLightShaderText = []byte(
+	`package main
+
+	var LightCount float
+	var Ambient vec3
+	var LightPosition [8]vec3
+	var LightDirection [8]vec3
+	var LightColor [8]vec3
+	var LightParams [8]vec4 // Type, distance, cosine of inner cone angle, cosine of outer cone angle
+	var LightScale float
+	var ToonStepCount float
+	var ToonSteps [8]float
+	var ToonRampSize float
+	var ToonRamp [16]vec3
+	var NormalMapped float
+	var Shininess float
+	var SpecularColor vec3
+	var CameraPosition vec3
+	var ViewDirection vec3
+	var Perspective float
+
+	func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+
+		normal := normalize(color.xyz)
+		pos := vec3(color.w, texCoord.x, texCoord.y)
+
+		if NormalMapped > 0 {
+			normal = normalize(vec3(imageSrc0UnsafeAt(texCoord).r, imageSrc1UnsafeAt(texCoord).r, imageSrc2UnsafeAt(texCoord).r)*2 - 1)
+			pos = color.xyz
+		}
+
+		light := Ambient
+		specular := vec3(0)
+
+		view := ViewDirection
+		if Perspective > 0 {
+			view = normalize(CameraPosition - pos)
+		}
+
+		for i := 0; i < 8; i++ {
+
+			if float(i) >= LightCount {
+				break
+			}
+
+			params := LightParams[i]
+
+			lightDir := LightDirection[i]
+			attenuation := 1.0
+
+			if params.x != 0 {
+
+				toLight := LightPosition[i] - pos
+				distSquared := dot(toLight, toLight)
+				lightDir = normalize(toLight)
+
+				if params.y == 0 {
+					attenuation = 2 / (1 + 0.1*distSquared)
+				} else {
+					attenuation = clamp(1-pow(distSquared/(params.y*params.y), 4), 0, 1)
+				}
+
+				if params.x == 2 {
+					attenuation *= clamp((dot(-lightDir, LightDirection[i])-params.w)/max(params.z-params.w, 0.0001), 0, 1)
+				}
+
+			}
+
+			diffuse := dot(normal, lightDir)
+
+			light += LightColor[i] * max(diffuse, 0) * attenuation
+
+			// Blinn-Phong specular highlights
+			if Shininess > 0 && diffuse > 0 {
+				halfway := normalize(lightDir + view)
+				specular += LightColor[i] * pow(max(dot(normal, halfway), 0), Shininess) * attenuation
+			}
+
+		}
+
+		// Toon shading remaps the light's brightness, keeping its hue.
+		if ToonStepCount > 0 || ToonRampSize > 0 {
+
+			level := max(light.r, max(light.g, light.b))
+			hue := vec3(1)
+			if level > 0 {
+				hue = light / level
+			}
+
+			if ToonRampSize > 0 {
+				index := clamp(floor(level*ToonRampSize), 0, ToonRampSize-1)
+				ramp := ToonRamp[0]
+				for i := 1; i < 16; i++ {
+					if float(i) == index {
+						ramp = ToonRamp[i]
+					}
+				}
+				light = ramp * hue
+			} else {
+				stepped := ToonSteps[0]
+				for i := 1; i < 8; i++ {
+					if float(i) >= ToonStepCount {
+						break
+					}
+					if level >= ToonSteps[i] {
+						stepped = ToonSteps[i]
+					}
+				}
+				light = stepped * hue
+			}
+
+		}
+
+		light += specular * SpecularColor
+
+		return vec4(light/LightScale, 1)
+
+	}
+	`,
+)
+
+// The pixel lighting composite shader combines the vertex-lit render (for lights that aren't applied per pixel, and emission) with
+// the unlit render multiplied by the light buffer.
+var pixelLightCompositeShaderText = []byte(
+	`package main
+
+	var LightScale float
+
+	func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+
+		vertexLit := imageSrc0UnsafeAt(texCoord)
+		unlit := imageSrc1UnsafeAt(texCoord)
+		light := imageSrc2UnsafeAt(texCoord)
+
+		return vec4(vertexLit.rgb+unlit.rgb*light.rgb*LightScale, unlit.a)
+
+	}
+	`,
+)
+
+// These vertex lists are only allocated once a Material using LightingModePixel is rendered.
+var pixelUnlitVertexList []ebiten.Vertex
+var pixelLightVertexList []ebiten.Vertex
+
+// pixelLightSet is the set of lights applied per pixel to the MeshPart being rendered.
+type pixelLightSet struct {
+	count     int
+	ambient   [3]float32
+	position  [MaxPixelLights * 3]float32
+	direction [MaxPixelLights * 3]float32
+	color     [MaxPixelLights * 3]float32
+	params    [MaxPixelLights * 4]float32
+	lights    map[ILight]bool
+
+	toonStepCount int
+	toonSteps     [toonShaderSteps]float32
+	toonRampSize  int
+	toonRamp      [toonShaderRampSamples * 3]float32
+
+	shininess      float32
+	specularColor  [3]float32
+	cameraPosition [3]float32
+	viewDirection  [3]float32
+	perspective    bool
+
+	candidates []ILight
+}
+
+// assign fills the set with the ambient lights and the (up to MaxPixelLights) point, spot, and directional lights from the candidates
+// given that are on the given light layers and are closest to the given position.
+func (set *pixelLightSet) assign(candidates []ILight, position vector.Vector, layers LightLayers) {
+
+	set.count = 0
+	set.ambient = [3]float32{}
+
+	if set.lights == nil {
+		set.lights = map[ILight]bool{}
+	}
+
+	for light := range set.lights {
+		delete(set.lights, light)
+	}
+
+	set.candidates = set.candidates[:0]
+
+	for _, light := range candidates {
+
+		if !light.IsOn() || !layers.Has(light.lightLayers()) {
+			continue
+		}
+
+		switch l := light.(type) {
+		case *AmbientLight:
+			set.ambient[0] += l.Color.R * l.Energy
+			set.ambient[1] += l.Color.G * l.Energy
+			set.ambient[2] += l.Color.B * l.Energy
+			set.lights[light] = true
+		case *PointLight:
+			// Lights with cookies or custom attenuation are lit per vertex, as the pixel lighting shader only supports the default
+			// falloff and doesn't sample cookies.
+			if l.Cookie == nil && l.Attenuation.isDefault() {
+				set.candidates = append(set.candidates, light)
+			}
+		case *SpotLight:
+			if l.Cookie == nil && l.Attenuation.isDefault() {
+				set.candidates = append(set.candidates, light)
+			}
+		case *DirectionalLight:
+			set.candidates = append(set.candidates, light)
+		}
+
+	}
+
+	// Directional lights are infinitely far away, but affect everything equally, so they're prioritized.
+	distance := func(light ILight) float64 {
+		if _, isDirectional := light.(*DirectionalLight); isDirectional {
+			return -1
+		}
+		return fastVectorDistanceSquared(light.(INode).WorldPosition(), position)
+	}
+
+	sort.SliceStable(set.candidates, func(i, j int) bool {
+		return distance(set.candidates[i]) < distance(set.candidates[j])
+	})
+
+	for _, light := range set.candidates {
+
+		if set.count >= MaxPixelLights {
+			break
+		}
+
+		i := set.count
+
+		var color *Color
+		var energy float32
+		var pos, dir vector.Vector
+
+		switch l := light.(type) {
+
+		case *DirectionalLight:
+			color, energy = l.Color, l.Energy
+			pos = l.WorldPosition()
+			dir = l.WorldRotation().Forward()
+			set.params[i*4] = pixelLightTypeDirectional
+
+		case *PointLight:
+			color, energy = l.Color, l.Energy
+			pos = l.WorldPosition()
+			dir = vector.Vector{0, 0, 0}
+			set.params[i*4] = pixelLightTypePoint
+			set.params[i*4+1] = float32(l.Distance)
+
+		case *SpotLight:
+			color, energy = l.Color, l.Energy
+			pos = l.WorldPosition()
+			dir = l.WorldRotation().Forward().Invert()
+			l.beginRender()
+			set.params[i*4] = pixelLightTypeSpot
+			set.params[i*4+1] = float32(l.Distance)
+			set.params[i*4+2] = float32(l.cosInner)
+			set.params[i*4+3] = float32(l.cosOuter)
+
+		}
+
+		for c := 0; c < 3; c++ {
+			set.position[i*3+c] = float32(pos[c])
+			set.direction[i*3+c] = float32(dir[c])
+		}
+
+		set.color[i*3] = color.R * energy
+		set.color[i*3+1] = color.G * energy
+		set.color[i*3+2] = color.B * energy
+
+		set.lights[light] = true
+		set.count++
+
+	}
+
+}
+
+// uniforms returns the uniforms for the pixel lighting shader.
+func (set *pixelLightSet) uniforms() map[string]interface{} {
+
+	perspective := float32(0)
+	if set.perspective {
+		perspective = 1
+	}
+
+	return map[string]interface{}{
+		"LightCount":     float32(set.count),
+		"Ambient":        set.ambient[:],
+		"LightPosition":  set.position[:],
+		"LightDirection": set.direction[:],
+		"LightColor":     set.color[:],
+		"LightParams":    set.params[:],
+		"LightScale":     float32(pixelLightScale),
+		"ToonStepCount":  float32(set.toonStepCount),
+		"ToonSteps":      set.toonSteps[:],
+		"ToonRampSize":   float32(set.toonRampSize),
+		"ToonRamp":       set.toonRamp[:],
+		"Shininess":      set.shininess,
+		"SpecularColor":  set.specularColor[:],
+		"CameraPosition": set.cameraPosition[:],
+		"ViewDirection":  set.viewDirection[:],
+		"Perspective":    perspective,
+	}
+
+}
+
+// preparePixelLighting creates the shaders, buffers, and vertex lists used for per-pixel lighting if they haven't been created yet.
+func (camera *Camera) preparePixelLighting() {
+
+	if camera.pixelLightShader == nil {
+
+		var err error
+
+		camera.pixelLightShader, err = ebiten.NewShader(pixelLightShaderText)
+
+		if err != nil {
+			panic(err)
+		}
+
+		camera.pixelLightCompositeShader, err = ebiten.NewShader(pixelLightCompositeShaderText)
+
+		if err != nil {
+			panic(err)
+		}
+
+	}
+
+	if camera.pixelVertexLitIntermediate == nil {
+		w, h := camera.resultColorTexture.Size()
+		camera.pixelVertexLitIntermediate = ebiten.NewImage(w, h)
+		camera.pixelUnlitIntermediate = ebiten.NewImage(w, h)
+		camera.pixelLightIntermediate = ebiten.NewImage(w, h)
+	}
+
+	if pixelUnlitVertexList == nil {
+		pixelUnlitVertexList = make([]ebiten.Vertex, ebiten.MaxIndicesNum)
+		pixelLightVertexList = make([]ebiten.Vertex, ebiten.MaxIndicesNum)
+	}
+
+}
+
+// setPixelLightVertex sets up the given vertex for the pixel lighting shader, using the screen position of the source vertex and the
+// given world position and normal.
+func setPixelLightVertex(dst *ebiten.Vertex, src ebiten.Vertex, position, normal vector.Vector) {
+	dst.DstX = src.DstX
+	dst.DstY = src.DstY
+	dst.ColorR = float32(normal[0])
+	dst.ColorG = float32(normal[1])
+	dst.ColorB = float32(normal[2])
+	dst.ColorA = float32(position[0])
+	dst.SrcX = float32(position[1])
+	dst.SrcY = float32(position[2])
+}
+
+// drawPixelLit draws the triangles in the vertex lists to the target image, lit per pixel. If the normal map given isn't nil, the
+// triangles are normal-mapped.
+func (camera *Camera) drawPixelLit(target *ebiten.Image, img *ebiten.Image, normalMap *ebiten.Image, options *ebiten.DrawTrianglesOptions, compositeMode ebiten.CompositeMode) {
+
+	intermediateOptions := *options
+	intermediateOptions.CompositeMode = ebiten.CompositeModeSourceOver
+
+	camera.pixelVertexLitIntermediate.Clear()
+	camera.pixelVertexLitIntermediate.DrawTriangles(colorVertexList[:vertexListIndex], indexList[:vertexListIndex], img, &intermediateOptions)
+
+	camera.pixelUnlitIntermediate.Clear()
+	camera.pixelUnlitIntermediate.DrawTriangles(pixelUnlitVertexList[:vertexListIndex], indexList[:vertexListIndex], img, &intermediateOptions)
+
+	lightOptions := &ebiten.DrawTrianglesShaderOptions{
+		Uniforms: camera.pixelLights.uniforms(),
+	}
+
+	if normalMap != nil {
+		camera.drawNormalMap(normalMap)
+		lightOptions.Images = [4]*ebiten.Image{camera.normalMapIntermediates[0], camera.normalMapIntermediates[1], camera.normalMapIntermediates[2]}
+		lightOptions.Uniforms["NormalMapped"] = float32(1)
+	}
+
+	camera.pixelLightIntermediate.Clear()
+	camera.pixelLightIntermediate.DrawTrianglesShader(pixelLightVertexList[:vertexListIndex], indexList[:vertexListIndex], camera.pixelLightShader, lightOptions)
+
+	camera.compositePixelLighting(target, compositeMode, pixelLightScale)
+
+}
+
+// compositePixelLighting draws the vertex-lit, unlit, and light buffers to the target image, combined.
+func (camera *Camera) compositePixelLighting(target *ebiten.Image, compositeMode ebiten.CompositeMode, lightScale float32) {
+
+	w, h := target.Size()
+
+	target.DrawRectShader(w, h, camera.pixelLightCompositeShader, &ebiten.DrawRectShaderOptions{
+		CompositeMode: compositeMode,
+		Images:        [4]*ebiten.Image{camera.pixelVertexLitIntermediate, camera.pixelUnlitIntermediate, camera.pixelLightIntermediate},
+		Uniforms:      map[string]interface{}{"LightScale": lightScale},
+	})
+
+}
@@ -0,0 +1,47 @@
+package tetra3d
+
+import (
+	"testing"
+
+	"github.com/kvartborg/vector"
+)
+
+func TestPixelLightSetAssign(t *testing.T) {
+
+	lights := []ILight{
+		NewAmbientLight("ambient", 1, 1, 1, 0.25),
+		NewAmbientLight("ambient 2", 1, 1, 1, 0.25),
+	}
+
+	// More point lights than can be lit per pixel, each further away from the origin than the last.
+	points := []*PointLight{}
+	for i := 0; i < MaxPixelLights+2; i++ {
+		point := NewPointLight("point", 1, 1, 1, 1)
+		point.SetLocalPosition(float64(MaxPixelLights+2-i), 0, 0)
+		points = append(points, point)
+		lights = append(lights, point)
+	}
+
+	sun := NewDirectionalLight("sun", 1, 1, 1, 1)
+	lights = append(lights, sun)
+
+	set := pixelLightSet{}
+	set.assign(lights, vector.Vector{0, 0, 0}, LightLayersAll)
+
+	if set.count != MaxPixelLights {
+		t.Fatalf("expected %d lights to be lit per pixel, got %d", MaxPixelLights, set.count)
+	}
+
+	if set.ambient[0] != 0.5 {
+		t.Errorf("expected ambient lights to be summed to 0.5, got %f", set.ambient[0])
+	}
+
+	if set.params[0] != pixelLightTypeDirectional {
+		t.Errorf("expected the directional light to be prioritized, got light type %f", set.params[0])
+	}
+
+	if !set.lights[points[len(points)-1]] || set.lights[points[0]] || set.lights[points[1]] {
+		t.Errorf("expected the closest point lights to be lit per pixel, and the furthest to be left to vertex lighting")
+	}
+
+}
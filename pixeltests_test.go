@@ -0,0 +1,54 @@
+//go:build pixeltests
+// +build pixeltests
+
+package tetra3d
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Building the tests with the pixeltests tag runs them while Ebiten's game loop is running, so that tests can read back the pixels
+// they render (see readPixel()). This needs a display (or a virtual one, like Xvfb) to run.
+
+var errTestsDone = errors.New("tests done")
+
+type testGame struct {
+	done chan struct{}
+}
+
+func (g *testGame) Update() error {
+	select {
+	case <-g.done:
+		return errTestsDone
+	default:
+		return nil
+	}
+}
+
+func (g *testGame) Draw(screen *ebiten.Image) {}
+
+func (g *testGame) Layout(w, h int) (int, int) {
+	return 1, 1
+}
+
+func TestMain(m *testing.M) {
+
+	game := &testGame{done: make(chan struct{})}
+	code := 0
+
+	go func() {
+		code = m.Run()
+		close(game.done)
+	}()
+
+	if err := ebiten.RunGame(game); err != nil && err != errTestsDone {
+		panic(err)
+	}
+
+	os.Exit(code)
+
+}
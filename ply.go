@@ -0,0 +1,389 @@
+package tetra3d
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LoadPLYFile loads a .ply file from the filepath given, returning a Mesh named after the file (without its extension). See
+// LoadPLYData() for more information. LoadPLYFile will return a Mesh, and an error if the process fails.
+func LoadPLYFile(path string, importOptions *ImportOptions) (*Mesh, error) {
+
+	fileData, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	mesh, err := LoadPLYData(fileData, importOptions)
+
+	if err != nil {
+		return nil, err
+	}
+
+	mesh.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	return mesh, nil
+
+}
+
+// LoadPLYData loads PLY (Stanford Polygon) data, in either the ASCII or binary (little or big endian) formats, returning a Mesh named
+// "PLY". importOptions describes the coordinate system the data was authored in, so that it can be converted to Tetra3D's; if nil, no
+// conversion is done.
+//
+// Faces with more than three vertices are triangulated as fans. Vertex normals (nx, ny, nz), colors (red, green, blue, and
+// optionally alpha), and UV values (s and t, u and v, or texture_u and texture_v) are loaded if the vertices have them; vertices
+// without normals use the normals of the triangles they belong to. Vertex colors are placed in the first vertex color channel, which
+// is made active. Elements other than vertices and faces are skipped. Note that PLY data without faces (i.e. point clouds) can't be
+// loaded, as the Mesh would have no triangles. LoadPLYData will return a Mesh, and an error if the process fails.
+func LoadPLYData(data []byte, importOptions *ImportOptions) (*Mesh, error) {
+
+	headerEnd := bytes.Index(data, []byte("end_header"))
+
+	if !bytes.HasPrefix(data, []byte("ply")) || headerEnd < 0 {
+		return nil, errors.New("error loading ply data: data is not PLY data")
+	}
+
+	bodyStart := bytes.IndexByte(data[headerEnd:], '\n')
+	if bodyStart < 0 {
+		return nil, errors.New("error loading ply data: data has no body")
+	}
+
+	reader := &plyReader{}
+	elements := []*plyElement{}
+
+	for _, line := range strings.Split(string(data[:headerEnd]), "\n") {
+
+		fields := strings.Fields(line)
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+
+		case "format":
+
+			if len(fields) < 2 {
+				return nil, errors.New("error loading ply data: format not specified")
+			}
+
+			switch fields[1] {
+			case "ascii":
+				reader.fields = strings.Fields(string(data[headerEnd+bodyStart+1:]))
+			case "binary_little_endian":
+				reader.order = binary.LittleEndian
+			case "binary_big_endian":
+				reader.order = binary.BigEndian
+			default:
+				return nil, errors.New("error loading ply data: unknown format " + fields[1])
+			}
+
+			reader.data = data[headerEnd+bodyStart+1:]
+
+		case "element":
+
+			if len(fields) < 3 {
+				return nil, errors.New("error loading ply data: element is missing its name or count")
+			}
+
+			count, err := strconv.Atoi(fields[2])
+			if err != nil || count < 0 {
+				return nil, errors.New("error loading ply data: invalid count for element " + fields[1])
+			}
+
+			elements = append(elements, &plyElement{Name: fields[1], Count: count})
+
+		case "property":
+
+			if len(elements) == 0 {
+				return nil, errors.New("error loading ply data: property defined before any element")
+			}
+
+			element := elements[len(elements)-1]
+
+			if len(fields) >= 5 && fields[1] == "list" {
+				element.Properties = append(element.Properties, plyProperty{Name: fields[4], CountType: fields[2], Type: fields[3]})
+			} else if len(fields) >= 3 {
+				element.Properties = append(element.Properties, plyProperty{Name: fields[2], Type: fields[1]})
+			} else {
+				return nil, errors.New("error loading ply data: property is missing its type or name")
+			}
+
+		}
+
+	}
+
+	if reader.data == nil {
+		return nil, errors.New("error loading ply data: format not specified")
+	}
+
+	verts := []VertexInfo{}
+	hasNormals := false
+	triangles := []VertexInfo{}
+
+	for _, element := range elements {
+
+		for _, property := range element.Properties {
+			if plyTypeSize(property.Type) == 0 || (property.CountType != "" && plyTypeSize(property.CountType) == 0) {
+				return nil, errors.New("error loading ply data: property " + property.Name + " has an unknown type")
+			}
+		}
+
+		for i := 0; i < element.Count && reader.err == nil; i++ {
+
+			switch element.Name {
+
+			case "vertex":
+
+				vert := NewVertex(0, 0, 0, 0, 0)
+				var color *Color
+
+				for _, property := range element.Properties {
+
+					if property.CountType != "" {
+						reader.list(property)
+						continue
+					}
+
+					value := reader.read(property.Type)
+
+					switch property.Name {
+					case "x":
+						vert.X = value
+					case "y":
+						vert.Y = value
+					case "z":
+						vert.Z = value
+					case "nx":
+						vert.NormalX = value
+						hasNormals = true
+					case "ny":
+						vert.NormalY = value
+					case "nz":
+						vert.NormalZ = value
+					case "s", "u", "texture_u":
+						vert.U = value
+					case "t", "v", "texture_v":
+						vert.V = value
+					case "red", "green", "blue", "alpha":
+						if color == nil {
+							color = NewColor(1, 1, 1, 1)
+						}
+						channel := float32(value * plyColorScale(property.Type))
+						switch property.Name {
+						case "red":
+							color.R = channel
+						case "green":
+							color.G = channel
+						case "blue":
+							color.B = channel
+						case "alpha":
+							color.A = channel
+						}
+					}
+
+				}
+
+				if color != nil {
+					vert.Colors = append(vert.Colors, color)
+					vert.ActiveColorChannel = 0
+				}
+
+				verts = append(verts, vert)
+
+			case "face":
+
+				for _, property := range element.Properties {
+
+					if property.CountType == "" {
+						reader.read(property.Type)
+						continue
+					}
+
+					indices := reader.list(property)
+
+					if property.Name != "vertex_indices" && property.Name != "vertex_index" {
+						continue
+					}
+
+					for _, index := range indices {
+						if index < 0 || int(index) >= len(verts) {
+							return nil, errors.New("error loading ply data: face refers to vertex " + strconv.Itoa(int(index)) + ", which doesn't exist")
+						}
+					}
+
+					for v := 1; v < len(indices)-1; v++ {
+						triangles = append(triangles, verts[int(indices[0])].clone(), verts[int(indices[v])].clone(), verts[int(indices[v+1])].clone())
+					}
+
+				}
+
+			default:
+
+				for _, property := range element.Properties {
+					if property.CountType != "" {
+						reader.list(property)
+					} else {
+						reader.read(property.Type)
+					}
+				}
+
+			}
+
+		}
+
+	}
+
+	if reader.err != nil {
+		return nil, reader.err
+	}
+
+	if len(triangles) == 0 {
+		return nil, errors.New("error loading ply data: data has no faces")
+	}
+
+	mesh := NewMesh("PLY")
+	mesh.addSplitMeshParts(NewMaterial("PLY"), triangles)
+
+	if !hasNormals {
+		mesh.AutoNormal()
+	}
+
+	mesh.UpdateBounds()
+
+	if converter := importOptions.converter(); converter != nil {
+		converter.mesh(mesh)
+	}
+
+	return mesh, nil
+
+}
+
+type plyElement struct {
+	Name       string
+	Count      int
+	Properties []plyProperty
+}
+
+type plyProperty struct {
+	Name      string
+	Type      string
+	CountType string // The type of the property's item count, if the property is a list.
+}
+
+// plyReader reads values from the body of PLY data, either from whitespace-separated fields (for ASCII data) or from bytes in the
+// given byte order (for binary data). Once an error occurs, it's stored and reading stops.
+type plyReader struct {
+	fields []string
+	data   []byte
+	order  binary.ByteOrder
+	index  int
+	err    error
+}
+
+func (reader *plyReader) read(valueType string) float64 {
+
+	if reader.err != nil {
+		return 0
+	}
+
+	if reader.order == nil {
+
+		if reader.index >= len(reader.fields) {
+			reader.err = errors.New("error loading ply data: unexpected end of data")
+			return 0
+		}
+
+		value, err := strconv.ParseFloat(reader.fields[reader.index], 64)
+		reader.index++
+
+		if err != nil {
+			reader.err = errors.New("error loading ply data: " + err.Error())
+		}
+
+		return value
+
+	}
+
+	size := plyTypeSize(valueType)
+
+	if reader.index+size > len(reader.data) {
+		reader.err = errors.New("error loading ply data: unexpected end of data")
+		return 0
+	}
+
+	b := reader.data[reader.index : reader.index+size]
+	reader.index += size
+
+	switch valueType {
+	case "char", "int8":
+		return float64(int8(b[0]))
+	case "uchar", "uint8":
+		return float64(b[0])
+	case "short", "int16":
+		return float64(int16(reader.order.Uint16(b)))
+	case "ushort", "uint16":
+		return float64(reader.order.Uint16(b))
+	case "int", "int32":
+		return float64(int32(reader.order.Uint32(b)))
+	case "uint", "uint32":
+		return float64(reader.order.Uint32(b))
+	case "float", "float32":
+		return float64(math.Float32frombits(reader.order.Uint32(b)))
+	default:
+		return math.Float64frombits(reader.order.Uint64(b))
+	}
+
+}
+
+// list reads a list property's values.
+func (reader *plyReader) list(property plyProperty) []float64 {
+
+	count := int(reader.read(property.CountType))
+
+	if count < 0 {
+		reader.err = errors.New("error loading ply data: list property " + property.Name + " has a negative count")
+		return nil
+	}
+
+	values := []float64{}
+	for i := 0; i < count && reader.err == nil; i++ {
+		values = append(values, reader.read(property.Type))
+	}
+
+	return values
+
+}
+
+// plyTypeSize returns the size in bytes of the given PLY property type, or 0 if the type is unknown.
+func plyTypeSize(valueType string) int {
+	switch valueType {
+	case "char", "int8", "uchar", "uint8":
+		return 1
+	case "short", "int16", "ushort", "uint16":
+		return 2
+	case "int", "int32", "uint", "uint32", "float", "float32":
+		return 4
+	case "double", "float64":
+		return 8
+	}
+	return 0
+}
+
+// plyColorScale returns what a color channel of the given PLY property type should be multiplied by to be in the range of 0 to 1.
+func plyColorScale(valueType string) float64 {
+	switch valueType {
+	case "uchar", "uint8":
+		return 1.0 / 255
+	case "ushort", "uint16":
+		return 1.0 / 65535
+	}
+	return 1
+}
@@ -0,0 +1,76 @@
+package tetra3d
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestLoadPLYData(t *testing.T) {
+
+	header := `ply
+format %s 1.0
+comment A single colored quad
+element vertex 4
+property float x
+property float y
+property float z
+property uchar red
+property uchar green
+property uchar blue
+element face 1
+property list uchar int vertex_indices
+end_header
+`
+
+	ascii := bytes.ReplaceAll([]byte(header), []byte("%s"), []byte("ascii"))
+	ascii = append(ascii, []byte(`0 0 0 255 0 0
+1 0 0 255 0 0
+1 1 0 255 0 0
+0 1 0 255 0 0
+4 0 1 2 3
+`)...)
+
+	binaryData := &bytes.Buffer{}
+	binaryData.Write(bytes.ReplaceAll([]byte(header), []byte("%s"), []byte("binary_big_endian")))
+	for _, pos := range [][3]float32{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}} {
+		binary.Write(binaryData, binary.BigEndian, pos)
+		binaryData.Write([]byte{255, 0, 0})
+	}
+	binaryData.WriteByte(4)
+	binary.Write(binaryData, binary.BigEndian, []int32{0, 1, 2, 3})
+
+	for _, data := range [][]byte{ascii, binaryData.Bytes()} {
+
+		mesh, err := LoadPLYData(data, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(mesh.Triangles) != 2 {
+			t.Fatalf("expected the quad to be split into 2 triangles, got %d", len(mesh.Triangles))
+		}
+
+		for _, tri := range mesh.Triangles {
+			if tri.Normal[2] < 0.9999 {
+				t.Errorf("expected triangle %d to face +Z, got %v", tri.ID, tri.Normal)
+			}
+		}
+
+		for i, colors := range mesh.VertexColors {
+			if len(colors) == 0 || colors[0].R != 1 || colors[0].G != 0 || colors[0].A != 1 {
+				t.Fatalf("vertex %d: expected a red vertex color, got %v", i, colors)
+			}
+		}
+
+		if mesh.Dimensions.Width() != 1 || mesh.Dimensions.Height() != 1 {
+			t.Errorf("expected a 1x1 quad, got %fx%f", mesh.Dimensions.Width(), mesh.Dimensions.Height())
+		}
+
+	}
+
+	if _, err := LoadPLYData([]byte("ply\nformat ascii 1.0\nelement vertex 1\nproperty float x\nend_header\n0\n"), nil); err == nil {
+		t.Errorf("expected an error loading PLY data without faces")
+	}
+
+}
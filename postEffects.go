@@ -0,0 +1,126 @@
+package tetra3d
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// PostEffect is a full-screen post-processing pass that a Camera applies to its color texture (see Camera.AddPostEffect()). The
+// PostEffect's Shader is a Kage shader that's drawn over the whole screen, with texCoord being the pixel's position, and the following
+// images (all the size of the Camera):
+//
+// imageSrc0: the color texture with any previous PostEffects (and bloom and FXAA) applied.
+//
+// imageSrc1: the Camera's depth texture, if the Camera's rendering depth (see Camera.DepthTexture() and the "depth" shader snippet).
+//
+// imageSrc2: the Camera's normal texture, if the Camera's rendering normals (see Camera.NormalTexture()).
+//
+// imageSrc3: the Camera's color texture before any PostEffects were applied.
+//
+// Shaders can include shader snippets by being preprocessed with PreprocessShader() before being created with ebiten.NewShader().
+type PostEffect struct {
+	Shader   *ebiten.Shader
+	Uniforms map[string]interface{} // The uniforms passed to the Shader; they can be changed at any time (e.g. to animate the effect).
+	Active   bool                   // Whether the PostEffect is applied; defaults to true.
+}
+
+// AddPostEffect adds a PostEffect using the given Kage shader and uniforms to the end of the Camera's PostEffects, and returns it. The
+// uniforms map can be nil. The Camera's PostEffects are applied in order when retrieving the Camera's color texture through
+// Camera.ColorTexture(), after rendering, bloom, and FXAA, with each PostEffect working on the result of the previous one.
+func (camera *Camera) AddPostEffect(shader *ebiten.Shader, uniforms map[string]interface{}) *PostEffect {
+
+	if uniforms == nil {
+		uniforms = map[string]interface{}{}
+	}
+
+	effect := &PostEffect{
+		Shader:   shader,
+		Uniforms: uniforms,
+		Active:   true,
+	}
+
+	camera.PostEffects = append(camera.PostEffects, effect)
+	camera.postProcessDirty = true
+
+	return effect
+
+}
+
+// RemovePostEffect removes the given PostEffect from the Camera's PostEffects.
+func (camera *Camera) RemovePostEffect(effect *PostEffect) {
+
+	for i, e := range camera.PostEffects {
+		if e == effect {
+			camera.PostEffects[i] = nil
+			camera.PostEffects = append(camera.PostEffects[:i], camera.PostEffects[i+1:]...)
+			camera.postProcessDirty = true
+			return
+		}
+	}
+
+}
+
+// clonedUniforms returns a copy of the PostEffect's Uniforms.
+func (effect *PostEffect) clonedUniforms() map[string]interface{} {
+	uniforms := make(map[string]interface{}, len(effect.Uniforms))
+	for k, v := range effect.Uniforms {
+		uniforms[k] = v
+	}
+	return uniforms
+}
+
+// hasActivePostEffects returns if the Camera has any active PostEffects to apply.
+func (camera *Camera) hasActivePostEffects() bool {
+	for _, effect := range camera.PostEffects {
+		if effect.Active && effect.Shader != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// applyPostEffects applies the Camera's active PostEffects to the given texture one after another, alternating between the Camera's
+// post effect buffers, and returns the result (which is the given texture if no PostEffects are active).
+func (camera *Camera) applyPostEffects(texture *ebiten.Image) *ebiten.Image {
+
+	if !camera.hasActivePostEffects() {
+		return texture
+	}
+
+	w, h := camera.resultColorTexture.Size()
+
+	if camera.postEffectBuffers[0] == nil {
+		for i := range camera.postEffectBuffers {
+			camera.postEffectBuffers[i] = ebiten.NewImage(w, h)
+		}
+	}
+
+	options := &ebiten.DrawRectShaderOptions{}
+	options.Images[1] = camera.DepthTexture()
+	options.Images[2] = camera.NormalTexture()
+	options.Images[3] = texture
+
+	source := texture
+	buffer := 0
+
+	for _, effect := range camera.PostEffects {
+
+		if !effect.Active || effect.Shader == nil {
+			continue
+		}
+
+		target := camera.postEffectBuffers[buffer]
+		buffer = 1 - buffer
+
+		options.Images[0] = source
+		options.Uniforms = effect.Uniforms
+
+		target.Clear()
+		target.DrawRectShader(w, h, effect.Shader, options)
+
+		source = target
+
+	}
+
+	return source
+
+}
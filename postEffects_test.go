@@ -0,0 +1,85 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestCameraPostEffects(t *testing.T) {
+
+	src, err := PreprocessShader([]byte(`package main
+
+	//tetra3d:include depth
+
+	var Strength float
+
+	func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+		c := imageSrc0At(texCoord)
+		return vec4(mix(c.rgb, vec3(decodeDepth(imageSrc1At(texCoord))), Strength), c.a)
+	}`))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shader, err := ebiten.NewShader(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scene := NewScene("post effects")
+	camera := NewCamera(32, 32)
+	scene.Root.AddChildren(camera)
+
+	cube := NewModel(NewCube(), "cube")
+	cube.Mesh.MeshParts[0].Material.Shadeless = true
+	cube.Move(0, 0, -5)
+	scene.Root.AddChildren(cube)
+
+	first := camera.AddPostEffect(shader, map[string]interface{}{"Strength": float32(0.5)})
+	second := camera.AddPostEffect(shader, nil)
+
+	camera.Clear()
+	camera.RenderNodes(scene, scene.Root)
+
+	// Each effect works on the result of the previous one.
+	if result := camera.ColorTexture(); result != camera.postEffectBuffers[1] {
+		t.Errorf("expected the color texture to be the result of the second post effect")
+	}
+
+	second.Active = false
+	camera.Clear()
+
+	if result := camera.ColorTexture(); result != camera.postEffectBuffers[0] {
+		t.Errorf("expected inactive post effects to be skipped")
+	}
+
+	camera.RemovePostEffect(first)
+
+	if len(camera.PostEffects) != 1 || camera.ColorTexture() != camera.resultColorTexture {
+		t.Errorf("expected the color texture to be unprocessed with no active post effects")
+	}
+
+	if clone := camera.Clone().(*Camera); len(clone.PostEffects) != 1 || clone.PostEffects[0] == second || clone.PostEffects[0].Active {
+		t.Errorf("expected cloned cameras to have copies of the post effects")
+	}
+
+	// The effect mixes the cube's color halfway towards its depth.
+	camera.AddPostEffect(shader, map[string]interface{}{"Strength": float32(0.5)})
+	camera.Clear()
+	camera.RenderNodes(scene, scene.Root)
+
+	result := readPixel(t, camera.ColorTexture(), 16, 16)
+	original := readPixel(t, camera.resultColorTexture, 16, 16)
+	depthColor := readPixel(t, camera.DepthTexture(), 16, 16)
+
+	depth := float64(depthColor.R)/255 + float64(depthColor.G)/255/255 + float64(depthColor.B)/255/65025
+	expected := (float64(original.R) + depth*255) / 2
+
+	if original.A != 255 || math.Abs(float64(result.R)-expected) > 3 || result.A != original.A {
+		t.Errorf("expected the post effect to turn the cube's color %v into a red of %f, got %v", original, expected, result)
+	}
+
+}
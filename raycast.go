@@ -0,0 +1,347 @@
+package tetra3d
+
+import (
+	"math"
+	"sort"
+
+	"github.com/kvartborg/vector"
+)
+
+// RayHit represents a ray hitting a BoundingObject in a ray test (see RayTest() and Scene.RayTest()).
+type RayHit struct {
+	BoundingObject INode // The BoundingObject that was hit.
+	// The root object of the tree containing the BoundingObject that was hit; like with Collisions, this can be the same or different
+	// from RayHit.BoundingObject, depending on which objects were tested against.
+	Root INode
+
+	Position vector.Vector // The world position of the hit.
+	Normal   vector.Vector // The normal of the surface that was hit, in world space; it always faces back along the ray.
+	Distance float64       // The distance from the start of the ray to the hit, in world units.
+	Triangle *Triangle     // The Triangle that was hit, for BoundingTriangles; otherwise, this will be nil.
+}
+
+// RayTest casts a ray from the from position to the to position (both in world space) against the BoundingObjects in the trees of
+// the INodes provided in others, returning a RayHit for each BoundingObject the ray hits, sorted by distance (closest first). Each
+// BoundingObject is only hit once, where the ray first hits it; BoundingObjects that the ray starts inside of (other than
+// BoundingTriangles, which have no inside) aren't hit. If nothing was hit, RayTest returns an empty slice.
+func RayTest(from, to vector.Vector, others ...INode) []*RayHit {
+	return rayTest(from, to, others, nil)
+}
+
+// RayTest casts a ray from the from position to the to position (both in world space) against all of the BoundingObjects in the
+// Scene, returning a RayHit for each BoundingObject the ray hits, sorted by distance (closest first); see RayTest() for more
+// information. If any filters are given, only BoundingObjects that pass all of them (i.e. that they return true for) are tested.
+func (scene *Scene) RayTest(from, to vector.Vector, filters ...func(boundingObject INode) bool) []*RayHit {
+	return rayTest(from, to, scene.Root.Children(), filters)
+}
+
+// rayTest casts a ray between the given positions against the BoundingObjects in the given trees that pass the given filters.
+func rayTest(from, to vector.Vector, others []INode, filters []func(boundingObject INode) bool) []*RayHit {
+
+	hits := []*RayHit{}
+
+	segment := to.Sub(from)
+	length := segment.Magnitude()
+
+	if length == 0 {
+		return hits
+	}
+
+	dir := segment.Scale(1 / length)
+
+	var test func(checking, root INode)
+
+	test = func(checking, root INode) {
+
+		if _, ok := checking.(IBoundingObject); ok {
+
+			passes := true
+
+			for _, filter := range filters {
+				if !filter(checking) {
+					passes = false
+					break
+				}
+			}
+
+			if passes {
+				if hit := rayHitBoundingObject(from, dir, length, checking); hit != nil {
+					hit.Root = root
+					hits = append(hits, hit)
+				}
+			}
+
+		}
+
+		for _, child := range checking.Children() {
+			test(child, root)
+		}
+
+	}
+
+	for _, o := range others {
+		test(o, o)
+	}
+
+	sort.Slice(hits, func(i, j int) bool { return hits[i].Distance < hits[j].Distance })
+
+	return hits
+
+}
+
+// rayHitBoundingObject returns where the ray from the given origin, along the given normalized direction for the given length, first
+// hits the given BoundingObject, or nil if it doesn't.
+func rayHitBoundingObject(origin, dir vector.Vector, length float64, boundingObject INode) *RayHit {
+
+	hit := &RayHit{BoundingObject: boundingObject}
+
+	switch bounds := boundingObject.(type) {
+
+	case *BoundingSphere:
+
+		center := bounds.WorldPosition()
+		t, ok := raySphereIntersection(origin, dir, center, bounds.WorldRadius())
+		if !ok || t > length {
+			return nil
+		}
+		hit.Distance = t
+		hit.Position = origin.Add(dir.Scale(t))
+		hit.Normal = hit.Position.Sub(center).Unit()
+
+	case *BoundingAABB:
+
+		position := bounds.WorldPosition()
+		t, normal, ok := rayAABBIntersection(origin, dir, position.Add(bounds.Dimensions[0]), position.Add(bounds.Dimensions[1]))
+		if !ok || t > length {
+			return nil
+		}
+		hit.Distance = t
+		hit.Position = origin.Add(dir.Scale(t))
+		hit.Normal = normal
+
+	case *BoundingOBB:
+
+		// The ray's tested in the box's own space, where it's axis-aligned.
+		shape := bounds.shape()
+		delta := origin.Sub(shape.center)
+		localOrigin := vector.Vector{dot(delta, shape.axes[0]), dot(delta, shape.axes[1]), dot(delta, shape.axes[2])}
+		localDir := vector.Vector{dot(dir, shape.axes[0]), dot(dir, shape.axes[1]), dot(dir, shape.axes[2])}
+		half := vector.Vector{shape.half[0], shape.half[1], shape.half[2]}
+		t, localNormal, ok := rayAABBIntersection(localOrigin, localDir, half.Invert(), half)
+		if !ok || t > length {
+			return nil
+		}
+		hit.Distance = t
+		hit.Position = origin.Add(dir.Scale(t))
+		hit.Normal = shape.axes[0].Scale(localNormal[0]).Add(shape.axes[1].Scale(localNormal[1])).Add(shape.axes[2].Scale(localNormal[2]))
+
+	case *BoundingConvex:
+
+		t, normal, ok := bounds.rayIntersection(origin, dir)
+		if !ok || t > length {
+			return nil
+		}
+		hit.Distance = t
+		hit.Position = origin.Add(dir.Scale(t))
+		hit.Normal = normal
+
+	case *BoundingCapsule:
+
+		bottom, top := bounds.lineBottom(), bounds.lineTop()
+		t, ok := rayCapsuleIntersection(origin, dir, bottom, top, bounds.WorldRadius())
+		if !ok || t > length {
+			return nil
+		}
+		hit.Distance = t
+		hit.Position = origin.Add(dir.Scale(t))
+		hit.Normal = hit.Position.Sub(closestPointOnSegment(hit.Position, bottom, top)).Unit()
+
+	case *BoundingTriangles:
+
+		// The ray has to pass through the triangles' bounding box (or start inside it) to hit any of them.
+		box := bounds.BoundingAABB
+		boxPosition := box.WorldPosition()
+		boxMin, boxMax := boxPosition.Add(box.Dimensions[0]), boxPosition.Add(box.Dimensions[1])
+		if t, _, ok := rayAABBIntersection(origin, dir, boxMin, boxMax); (!ok || t > length) && !pointInBox(origin, boxMin, boxMax) {
+			return nil
+		}
+
+		// Rather than transforming all of the triangles, we transform the ray into the BoundingTriangles' local space; as the
+		// transform is affine, hits are at the same fractions of the ray.
+		transform := bounds.Transform()
+		inverted := transform.Inverted()
+		localOrigin := inverted.MultVec(origin)
+		localDir := inverted.MultVec(origin.Add(dir.Scale(length))).Sub(localOrigin)
+
+		closest := math.MaxFloat64
+		positions := bounds.Mesh.VertexPositions
+
+		for _, tri := range bounds.Mesh.Triangles {
+			t, _, _, _, ok := rayTriangleBarycentric(localOrigin, localDir, positions[tri.ID*3], positions[tri.ID*3+1], positions[tri.ID*3+2])
+			if ok && t >= 0 && t <= 1 && t < closest {
+				closest = t
+				hit.Triangle = tri
+			}
+		}
+
+		if hit.Triangle == nil {
+			return nil
+		}
+
+		id := hit.Triangle.ID
+		hit.Distance = closest * length
+		hit.Position = origin.Add(dir.Scale(hit.Distance))
+		hit.Normal = calculateNormal(transform.MultVec(positions[id*3]), transform.MultVec(positions[id*3+1]), transform.MultVec(positions[id*3+2]))
+
+	default:
+		return nil
+
+	}
+
+	if dot(hit.Normal, dir) > 0 {
+		hit.Normal = hit.Normal.Invert()
+	}
+
+	return hit
+
+}
+
+// raySphereIntersection returns the distance along the ray from the origin along the normalized direction to where it enters the
+// sphere with the given center and radius, and whether it does at all; rays starting inside the sphere don't enter it.
+func raySphereIntersection(origin, dir, center vector.Vector, radius float64) (float64, bool) {
+
+	m := origin.Sub(center)
+	b := dot(m, dir)
+	c := dot(m, m) - radius*radius
+
+	// Starting inside, or outside and pointing away
+	if c <= 0 || b > 0 {
+		return 0, false
+	}
+
+	discriminant := b*b - c
+	if discriminant < 0 {
+		return 0, false
+	}
+
+	return -b - math.Sqrt(discriminant), true
+
+}
+
+// rayAABBIntersection returns the distance along the ray from the origin along the normalized direction to where it enters the box
+// with the given minimum and maximum corners, the normal of the side it enters through, and whether it enters the box at all; rays
+// starting inside the box don't enter it.
+func rayAABBIntersection(origin, dir, min, max vector.Vector) (float64, vector.Vector, bool) {
+
+	enter := -math.MaxFloat64
+	exit := math.MaxFloat64
+	enterAxis := -1
+
+	for axis := 0; axis < 3; axis++ {
+
+		if math.Abs(dir[axis]) < 1e-12 {
+			if origin[axis] < min[axis] || origin[axis] > max[axis] {
+				return 0, nil, false
+			}
+			continue
+		}
+
+		t0 := (min[axis] - origin[axis]) / dir[axis]
+		t1 := (max[axis] - origin[axis]) / dir[axis]
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+
+		if t0 > enter {
+			enter = t0
+			enterAxis = axis
+		}
+
+		exit = math.Min(exit, t1)
+
+	}
+
+	if enterAxis < 0 || enter > exit || enter < 0 {
+		return 0, nil, false
+	}
+
+	normal := vector.Vector{0, 0, 0}
+	normal[enterAxis] = -math.Copysign(1, dir[enterAxis])
+
+	return enter, normal, true
+
+}
+
+// rayCapsuleIntersection returns the distance along the ray from the origin along the normalized direction to where it enters the
+// capsule around the line between the given bottom and top points with the given radius, and whether it does at all; rays
+// starting inside the capsule don't enter it.
+func rayCapsuleIntersection(origin, dir, bottom, top vector.Vector, radius float64) (float64, bool) {
+
+	if p := closestPointOnSegment(origin, bottom, top); fastVectorDistanceSquared(origin, p) <= radius*radius {
+		return 0, false
+	}
+
+	axis := top.Sub(bottom)
+	toOrigin := origin.Sub(bottom)
+
+	axisLengthSquared := dot(axis, axis)
+	axisDir := dot(axis, dir)
+	axisOrigin := dot(axis, toOrigin)
+
+	// The cylindrical part of the capsule first; rays running along the capsule's axis can only hit its caps.
+	a := axisLengthSquared - axisDir*axisDir
+	if a > 1e-12 {
+
+		b := axisLengthSquared*dot(dir, toOrigin) - axisOrigin*axisDir
+		c := axisLengthSquared*dot(toOrigin, toOrigin) - axisOrigin*axisOrigin - radius*radius*axisLengthSquared
+		h := b*b - a*c
+
+		if h < 0 {
+			return 0, false
+		}
+
+		t := (-b - math.Sqrt(h)) / a
+		y := axisOrigin + t*axisDir
+
+		if y > 0 && y < axisLengthSquared {
+			if t < 0 {
+				return 0, false
+			}
+			return t, true
+		}
+
+	}
+
+	// Then the caps; whichever end of the capsule the ray's closest to the cylinder at is the one it can hit.
+	bestT := math.MaxFloat64
+	for _, end := range []vector.Vector{bottom, top} {
+		if t, ok := raySphereIntersection(origin, dir, end, radius); ok && t < bestT {
+			bestT = t
+		}
+	}
+
+	return bestT, bestT < math.MaxFloat64
+
+}
+
+// closestPointOnSegment returns the closest point to the given point on the line segment between the given start and end points.
+func closestPointOnSegment(point, start, end vector.Vector) vector.Vector {
+
+	segment := end.Sub(start)
+	lengthSquared := dot(segment, segment)
+
+	if lengthSquared == 0 {
+		return start.Clone()
+	}
+
+	t := math.Max(0, math.Min(1, dot(point.Sub(start), segment)/lengthSquared))
+	return start.Add(segment.Scale(t))
+
+}
+
+// pointInBox returns if the given point is inside the box with the given minimum and maximum corners.
+func pointInBox(point, min, max vector.Vector) bool {
+	return point[0] >= min[0] && point[0] <= max[0] &&
+		point[1] >= min[1] && point[1] <= max[1] &&
+		point[2] >= min[2] && point[2] <= max[2]
+}
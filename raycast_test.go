@@ -0,0 +1,82 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kvartborg/vector"
+)
+
+func TestRayTest(t *testing.T) {
+
+	scene := NewScene("ray test")
+
+	sphere := NewBoundingSphere("sphere", 1)
+	sphere.SetLocalPosition(0, 0, -5)
+
+	box := NewBoundingAABB("box", 2, 2, 2)
+	box.SetLocalPosition(3, 0, -5)
+
+	capsule := NewBoundingCapsule("capsule", 2, 0.5)
+	capsule.SetLocalPosition(-3, 0, -5)
+
+	ground := NewBoundingTriangles("ground", NewPlane(), 0)
+	ground.SetLocalPosition(0, -2, -5)
+	ground.SetLocalScale(10, 1, 10)
+
+	scene.Root.AddChildren(sphere, box, capsule, ground)
+
+	// expectHit checks that the first hit of a ray test is on the given object, with the given distance and normal.
+	expectHit := func(hits []*RayHit, object INode, distance float64, normal vector.Vector) {
+		t.Helper()
+		if len(hits) == 0 {
+			t.Fatalf("expected the ray to hit %s, but it didn't hit anything", object.Name())
+		}
+		hit := hits[0]
+		if hit.BoundingObject != object {
+			t.Fatalf("expected the ray to hit %s first, but it hit %s", object.Name(), hit.BoundingObject.Name())
+		}
+		if math.Abs(hit.Distance-distance) > 1e-6 || hit.Normal.Sub(normal).Magnitude() > 1e-6 {
+			t.Fatalf("expected the ray to hit %s at a distance of %f with a normal of %v, but got %f and %v", object.Name(), distance, normal, hit.Distance, hit.Normal)
+		}
+	}
+
+	expectHit(scene.RayTest(vector.Vector{0, 0, 0}, vector.Vector{0, 0, -10}), sphere, 4, vector.Vector{0, 0, 1})
+	expectHit(scene.RayTest(vector.Vector{3, 0, 0}, vector.Vector{3, 0, -10}), box, 4, vector.Vector{0, 0, 1})
+	expectHit(scene.RayTest(vector.Vector{-3, 5, -5}, vector.Vector{-3, -1, -5}), capsule, 4, vector.Vector{0, 1, 0})
+	expectHit(scene.RayTest(vector.Vector{-1, 0, -5}, vector.Vector{-5, 0, -5}), capsule, 1.5, vector.Vector{1, 0, 0})
+
+	// Rays stop at their end points.
+	if hits := scene.RayTest(vector.Vector{0, 0, 0}, vector.Vector{0, 0, -3.9}); len(hits) != 0 {
+		t.Fatalf("a ray ending short of the sphere shouldn't hit it")
+	}
+
+	// Hits are sorted from closest to furthest.
+	hits := scene.RayTest(vector.Vector{0, 5, -5}, vector.Vector{0, -10, -5})
+
+	if len(hits) != 2 || hits[0].BoundingObject != sphere || hits[1].BoundingObject != ground {
+		t.Fatalf("a ray cast down through the sphere should hit it, and then the ground; got %d hits", len(hits))
+	}
+
+	if math.Abs(hits[1].Distance-7) > 1e-6 || hits[1].Normal.Sub(vector.Vector{0, 1, 0}).Magnitude() > 1e-6 || hits[1].Triangle == nil {
+		t.Fatalf("the ray should hit a triangle of the ground 7 units down, facing up; got %f, %v", hits[1].Distance, hits[1].Normal)
+	}
+
+	if hits[0].Root != sphere || hits[1].Root != ground {
+		t.Fatalf("the hits' roots should be the top-level nodes tested")
+	}
+
+	// Filters skip BoundingObjects.
+	hits = scene.RayTest(vector.Vector{0, 5, -5}, vector.Vector{0, -10, -5}, func(boundingObject INode) bool { return boundingObject != sphere })
+	expectHit(hits, ground, 7, vector.Vector{0, 1, 0})
+
+	// Rays starting inside objects don't hit them.
+	if hits := RayTest(vector.Vector{0, 0, -5}, vector.Vector{0, 0, -20}, sphere, box, capsule); len(hits) != 0 {
+		t.Fatalf("a ray starting inside the sphere shouldn't hit it")
+	}
+
+	if hits := RayTest(vector.Vector{3, 0, -5}, vector.Vector{3, 0, -20}, box); len(hits) != 0 {
+		t.Fatalf("a ray starting inside the box shouldn't hit it")
+	}
+
+}
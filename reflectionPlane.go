@@ -0,0 +1,266 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/kvartborg/vector"
+)
+
+// ReflectionPlane is a Node that renders planar reflections, for mirrors and calm water. When rendered, it re-renders the Scene from
+// a Camera mirrored about the plane running through the ReflectionPlane's position and facing along its local +Y axis, only keeping
+// what's in front of the plane, and feeds the result to its Material, whose texture then shows the reflection when it's rendered by
+// the Camera that the reflection was rendered for. The reflection is mapped onto the Material's triangles by their screen positions
+// (so their UV values are ignored), which means the Material should be used for flat surfaces lying on the plane. As they hold the
+// reflected Scene's lighting, Materials showing reflections aren't lit by the Scene's lights; their colors still tint the reflection.
+//
+// A ReflectionPlane has to be rendered (see ReflectionPlane.Render()) each frame before the Camera that views it renders the Scene,
+// and renders the reflection for that Camera only.
+type ReflectionPlane struct {
+	*Node
+
+	// Camera is the Camera used to render the reflection; its position and orientation are set when rendering. Its size should have
+	// the same aspect ratio as the Camera the reflection is rendered for (though it can be smaller, as reflections are usually blurry
+	// or distorted anyway), and its other settings (like RenderDepth or post-processing) can be changed as desired.
+	Camera *Camera
+
+	// Material is the Material the reflection is fed to; its Texture is set to the reflection when rendering. Models using the
+	// Material aren't rendered in the reflection. Defaults to nil.
+	Material *Material
+
+	// ClipOffset is how far in front of the plane things have to be to show up in the reflection, which keeps the surface that the
+	// reflection is shown on (and things just touching it) out of the reflection. Defaults to 0.01.
+	ClipOffset float64
+
+	// Distortion is how far the reflection is distorted by ripples, as a fraction of the view; 0.01 to 0.05 or so works well for
+	// water. DistortionScale is the size of the ripples in world units, and DistortionSpeed is how quickly they move as the
+	// ReflectionPlane's distortion is updated (see ReflectionPlane.UpdateDistortion()). The distortion is calculated for each vertex,
+	// so the surface showing the reflection should be subdivided for the ripples to be visible. Distortion defaults to 0,
+	// DistortionScale to 1, and DistortionSpeed to 1.
+	Distortion      float64
+	DistortionScale float64
+	DistortionSpeed float64
+
+	On bool // Whether the ReflectionPlane renders its reflection; defaults to true.
+
+	texture        *ebiten.Image    // The texture last fed to the Material
+	distortionTime float64          // How far the distortion's ripples have moved
+	distortionAxes [2]vector.Vector // The world-space axes of the plane, used to place the ripples
+}
+
+// NewReflectionPlane creates a new ReflectionPlane, rendering its reflection at the given size.
+func NewReflectionPlane(name string, width, height int) *ReflectionPlane {
+	return &ReflectionPlane{
+		Node:            NewNode(name),
+		Camera:          NewCamera(width, height),
+		ClipOffset:      0.01,
+		DistortionScale: 1,
+		DistortionSpeed: 1,
+		On:              true,
+	}
+}
+
+// Clone returns a new clone of the given ReflectionPlane; the clone has its own Camera, but shares the original's Material.
+func (plane *ReflectionPlane) Clone() INode {
+
+	w, h := plane.Camera.Size()
+	clone := NewReflectionPlane(plane.name, w, h)
+	clone.Camera = plane.Camera.Clone().(*Camera)
+	clone.Material = plane.Material
+	clone.ClipOffset = plane.ClipOffset
+	clone.Distortion = plane.Distortion
+	clone.DistortionScale = plane.DistortionScale
+	clone.DistortionSpeed = plane.DistortionSpeed
+	clone.On = plane.On
+	clone.distortionTime = plane.distortionTime
+
+	clone.Node = plane.Node.Clone().(*Node)
+	for _, child := range plane.children {
+		child.setParent(clone)
+	}
+
+	return clone
+
+}
+
+// Texture returns the texture holding the ReflectionPlane's reflection, as it was last rendered.
+func (plane *ReflectionPlane) Texture() *ebiten.Image {
+	return plane.Camera.ColorTexture()
+}
+
+// UpdateDistortion moves the ripples that distort the ReflectionPlane's reflection (see ReflectionPlane.Distortion) by the given
+// delta time, in seconds.
+func (plane *ReflectionPlane) UpdateDistortion(dt float64) {
+	plane.distortionTime += dt * plane.DistortionSpeed
+}
+
+// RenderNodes renders the ReflectionPlane's reflection of all nodes starting with the provided rootNode, as seen by the given Camera.
+func (plane *ReflectionPlane) RenderNodes(camera *Camera, scene *Scene, rootNode INode) {
+
+	models := []*Model{}
+
+	if model, isModel := rootNode.(*Model); isModel {
+		models = append(models, model)
+	}
+
+	for _, node := range rootNode.ChildrenRecursive() {
+		if model, ok := node.(*Model); ok && model.DynamicBatchOwner == nil {
+			models = append(models, model)
+		}
+	}
+
+	plane.Render(camera, scene, models...)
+
+}
+
+// Render renders the ReflectionPlane's reflection of the given Models as seen by the given Camera (using the Scene's properties, like
+// Camera.Render()), and feeds it to the ReflectionPlane's Material. The ReflectionPlane's Camera is cleared beforehand.
+func (plane *ReflectionPlane) Render(camera *Camera, scene *Scene, models ...*Model) {
+
+	if !plane.On {
+		return
+	}
+
+	mirror := plane.Camera
+
+	normal := plane.WorldRotation().Up()
+	origin := plane.WorldPosition()
+
+	// reflect mirrors the given direction about the plane.
+	reflect := func(dir vector.Vector) vector.Vector {
+		return dir.Sub(normal.Scale(2 * dot(dir, normal)))
+	}
+
+	position := camera.WorldPosition()
+	if camera.shakeOffset != nil {
+		position = position.Add(camera.shakeOffset)
+	}
+
+	mirror.SetWorldPositionVec(position.Sub(normal.Scale(2 * dot(position.Sub(origin), normal))))
+
+	// Mirroring the Camera's axes would turn it inside-out (making it left-handed), so its right axis is flipped back; this renders
+	// the reflection flipped horizontally, which is undone when the reflection is mapped onto the Material.
+	rotation := camera.WorldRotation()
+	right := reflect(rotation.Right()).Invert()
+	up := reflect(rotation.Up())
+	forward := reflect(rotation.Forward())
+
+	mirror.SetWorldRotation(Matrix4{
+		{right[0], right[1], right[2], 0},
+		{up[0], up[1], up[2], 0},
+		{forward[0], forward[1], forward[2], 0},
+		{0, 0, 0, 1},
+	})
+
+	mirror.Perspective = camera.Perspective
+	mirror.FieldOfView = camera.FieldOfView
+	mirror.OrthoScale = camera.OrthoScale
+	mirror.Near = camera.Near
+	mirror.Far = camera.Far
+	mirror.sphereFactorCalculated = false
+
+	mirror.clipPlane = vector.Vector{normal[0], normal[1], normal[2], -dot(normal, origin) - plane.ClipOffset}
+
+	reflected := make([]*Model, 0, len(models))
+
+	for _, model := range models {
+		if !plane.showsReflection(model) {
+			reflected = append(reflected, model)
+		}
+	}
+
+	mirror.Clear()
+	mirror.Render(scene, reflected...)
+
+	planeRotation := plane.WorldRotation()
+	plane.distortionAxes = [2]vector.Vector{planeRotation.Right(), planeRotation.Forward()}
+
+	plane.texture = mirror.ColorTexture()
+
+	if plane.Material != nil {
+		plane.Material.Texture = plane.texture
+		plane.Material.reflectionPlane = plane
+	}
+
+}
+
+// showsReflection returns if the given Model uses the ReflectionPlane's Material.
+func (plane *ReflectionPlane) showsReflection(model *Model) bool {
+
+	if plane.Material == nil || model.Mesh == nil {
+		return false
+	}
+
+	for _, mp := range model.Mesh.MeshParts {
+		if mp.Material == plane.Material {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// distortionOffset returns the offset (as a fraction of the reflection's size) of the reflection at the given world position.
+func (plane *ReflectionPlane) distortionOffset(position vector.Vector) (float64, float64) {
+
+	if plane.Distortion == 0 || plane.DistortionScale == 0 {
+		return 0, 0
+	}
+
+	a := dot(position, plane.distortionAxes[0]) / plane.DistortionScale
+	b := dot(position, plane.distortionAxes[1]) / plane.DistortionScale
+	t := plane.distortionTime
+
+	// Two sets of waves running across each other look less regular than one.
+	u := math.Sin((a+t)*2*math.Pi)*0.6 + math.Sin((a*0.7-b*1.3-t*0.8)*2*math.Pi)*0.4
+	v := math.Sin((b+t*0.9)*2*math.Pi)*0.6 + math.Sin((b*0.8+a*1.1+t*0.7)*2*math.Pi)*0.4
+
+	return u * plane.Distortion, v * plane.Distortion
+
+}
+
+// AddChildren parents the provided children Nodes to the passed parent Node, inheriting its transformations and being under it in the scenegraph
+// hierarchy. If the children are already parented to other Nodes, they are unparented before doing so.
+func (plane *ReflectionPlane) AddChildren(children ...INode) {
+	plane.addChildren(plane, children...)
+}
+
+// Unparent unparents the ReflectionPlane from its parent, removing it from the scenegraph.
+func (plane *ReflectionPlane) Unparent() {
+	if plane.parent != nil {
+		plane.parent.RemoveChildren(plane)
+	}
+}
+
+// Type returns the NodeType for this object.
+func (plane *ReflectionPlane) Type() NodeType {
+	return NodeTypeReflectionPlane
+}
+
+// reflection returns the ReflectionPlane whose reflection the Material's texture holds, or nil if it doesn't hold one.
+func (material *Material) reflection() *ReflectionPlane {
+	if material == nil || material.reflectionPlane == nil || material.Texture == nil || material.Texture != material.reflectionPlane.texture {
+		return nil
+	}
+	return material.reflectionPlane
+}
+
+// mapReflection maps a reflection onto the vertices in the color and depth vertex lists from the start index to the end index by
+// their screen positions (flipping it horizontally, as reflections are rendered flipped), adding the offsets that the vertices'
+// source positions hold. srcW and srcH are the size of the reflection's texture.
+func (camera *Camera) mapReflection(start, end int, srcW, srcH float64) {
+
+	w, h := camera.resultColorTexture.Size()
+	scaleX := float32(srcW / float64(w))
+	scaleY := float32(srcH / float64(h))
+
+	for i := start; i < end; i++ {
+		v := &colorVertexList[i]
+		v.SrcX += (float32(w) - v.DstX) * scaleX
+		v.SrcY += v.DstY * scaleY
+		depthVertexList[i].SrcX = v.SrcX
+		depthVertexList[i].SrcY = v.SrcY
+	}
+
+}
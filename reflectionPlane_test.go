@@ -0,0 +1,220 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/kvartborg/vector"
+)
+
+func TestReflectionPlane(t *testing.T) {
+
+	scene := NewScene("reflection")
+	camera := NewCamera(32, 32)
+	camera.Move(1, 2, 6)
+	camera.Rotate(1, 0, 0, -0.3)
+	scene.Root.AddChildren(camera)
+
+	plane := NewReflectionPlane("mirror", 32, 32)
+	scene.Root.AddChildren(plane)
+
+	floor := NewModel(NewPlane(), "floor")
+	floor.SetLocalScale(4, 1, 4)
+	scene.Root.AddChildren(floor)
+
+	floorPart := floor.Mesh.MeshParts[0]
+	plane.Material = floorPart.Material
+
+	cube := NewModel(NewCube(), "cube")
+	cube.Move(0, 1.5, 0)
+	scene.Root.AddChildren(cube)
+
+	// The wall stands halfway below the plane, so it should be clipped in the reflection.
+	wallMesh := NewMesh("wall")
+	wallPart := wallMesh.AddMeshPart(NewMaterial("wall"))
+	wallPart.Material.BackfaceCulling = false
+	wallPart.AddTriangles(
+		NewVertex(-1, -1, 0, 0, 0), NewVertex(1, -1, 0, 1, 0), NewVertex(1, 1, 0, 1, 1),
+		NewVertex(-1, -1, 0, 0, 0), NewVertex(1, 1, 0, 1, 1), NewVertex(-1, 1, 0, 0, 1),
+	)
+	wallMesh.UpdateBounds()
+
+	wall := NewModel(wallMesh, "wall")
+	wall.Move(0, 0, -3)
+	scene.Root.AddChildren(wall)
+
+	var reflectedCube, reflectedWall []ebiten.Vertex
+
+	cube.Mesh.MeshParts[0].OnRender = func(renderer *Camera, model *Model, part *MeshPart, stage int, vertices []ebiten.Vertex) {
+		if renderer == plane.Camera && stage == RenderStageBefore {
+			reflectedCube = append(reflectedCube, vertices...)
+		}
+	}
+
+	wallPart.OnRender = func(renderer *Camera, model *Model, part *MeshPart, stage int, vertices []ebiten.Vertex) {
+		if renderer == plane.Camera && stage == RenderStageBefore {
+			reflectedWall = append(reflectedWall, vertices...)
+		}
+	}
+
+	floorInReflection := false
+	flipped := true
+
+	floorPart.OnRender = func(renderer *Camera, model *Model, part *MeshPart, stage int, vertices []ebiten.Vertex) {
+		if renderer == plane.Camera {
+			floorInReflection = true
+			return
+		}
+		for _, v := range vertices {
+			if math.Abs(float64(v.SrcX+v.DstX-32)) > 0.01 || math.Abs(float64(v.SrcY-v.DstY)) > 0.01 {
+				flipped = false
+			}
+		}
+	}
+
+	plane.RenderNodes(camera, scene, scene.Root)
+
+	if floorInReflection {
+		t.Errorf("expected Models showing the reflection not to be rendered in it")
+	}
+
+	if plane.Material.Texture != plane.Texture() || plane.Material.reflection() != plane {
+		t.Errorf("expected the reflection to be fed to the ReflectionPlane's Material")
+	}
+
+	// The cube's corners should be drawn in the reflection where the Camera would see the corners of a cube mirrored beneath the
+	// plane, flipped horizontally.
+	if len(reflectedCube) == 0 {
+		t.Fatalf("expected the cube to be rendered in the reflection")
+	}
+
+	for _, v := range reflectedCube {
+
+		found := false
+
+		for _, corner := range cube.Mesh.VertexPositions {
+			world := cube.Transform().MultVec(corner)
+			seen := camera.WorldToScreen(vector.Vector{world[0], -world[1], world[2]})
+			if math.Abs(float64(v.DstX)-(32-seen[0])) < 0.01 && math.Abs(float64(v.DstY)-seen[1]) < 0.01 {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			t.Fatalf("expected the cube to be drawn mirrored in the reflection, but found a vertex at %f, %f", v.DstX, v.DstY)
+		}
+
+	}
+
+	// Only the top half of the wall should be drawn in the reflection, with its bottom edge at the plane's surface.
+	edge := plane.Camera.WorldToScreen(vector.Vector{0, plane.ClipOffset, -3})[1]
+	top := plane.Camera.WorldToScreen(vector.Vector{0, 1, -3})[1]
+	onEdge := false
+
+	for _, v := range reflectedWall {
+		if (float64(v.DstY)-edge)*(top-edge) < -0.01 {
+			t.Fatalf("expected the wall to be clipped against the plane in the reflection, but found a vertex beyond it at a height of %f", v.DstY)
+		}
+		if math.Abs(float64(v.DstY)-edge) < 0.01 {
+			onEdge = true
+		}
+	}
+
+	if len(reflectedWall) == 0 || !onEdge {
+		t.Errorf("expected the wall to be drawn in the reflection, cut off at the plane")
+	}
+
+	// Points seen in the reflection should line up with where their mirror images would be seen by the Camera, flipped horizontally.
+	point := vector.Vector{0.5, 1, -1}
+	seen := camera.WorldToScreen(vector.Vector{0.5, -1, -1})
+	reflected := plane.Camera.WorldToScreen(point)
+
+	if math.Abs(seen[0]+reflected[0]-32) > 0.0001 || math.Abs(seen[1]-reflected[1]) > 0.0001 {
+		t.Errorf("expected the reflection to mirror the Camera's view, got %v and %v", seen, reflected)
+	}
+
+	camera.Clear()
+	camera.RenderNodes(scene, scene.Root)
+
+	if !flipped {
+		t.Errorf("expected the reflection to be mapped onto the floor by screen position, flipped horizontally")
+	}
+
+	// Triangles are clipped against the plane, keeping only what's in front of it.
+	mirror := plane.Camera
+	mirror.updateClipPlane(mirror.ViewMatrix().Mult(mirror.Projection()))
+
+	corners := [3]vector.Vector{{0, 1, -1}, {1, -1, -1}, {-1, 1, -1}}
+	var clipped nearClipTriangle
+
+	count := mirror.clipTriangle(mirror.WorldToClip(corners[0]), mirror.WorldToClip(corners[1]), mirror.WorldToClip(corners[2]), false, 0, &clipped)
+
+	if count != 4 {
+		t.Fatalf("expected a triangle with one corner behind the plane to be clipped into a quad, got %d corners", count)
+	}
+
+	for i := 0; i < count; i++ {
+		y := 0.0
+		for c, weight := range clipped.Vertices[i].Weights {
+			y += corners[c][1] * weight
+		}
+		if y < plane.ClipOffset-0.0001 {
+			t.Errorf("expected clipped corners to lie in front of the plane, got a corner at a height of %f", y)
+		}
+	}
+
+	below := [3]vector.Vector{{0, -1, -1}, {1, -1, -1}, {-1, -2, -1}}
+	if count := mirror.clipTriangle(mirror.WorldToClip(below[0]), mirror.WorldToClip(below[1]), mirror.WorldToClip(below[2]), false, 0, &clipped); count >= 3 {
+		t.Errorf("expected a triangle behind the plane to be clipped away, got %d corners", count)
+	}
+
+}
+
+func TestClipPlaneWithNearClipping(t *testing.T) {
+
+	// Each triangle reaches from behind the Camera to in front of it, and past the clip plane, so clipping it against both turns it into
+	// a pentagon drawn as three triangles. There are few enough triangles to render in one draw unclipped, but too many once they're
+	// clipped.
+	triangleCount := 8000
+
+	mesh := NewMesh("crossing")
+	part := mesh.AddMeshPart(NewMaterial("crossing"))
+	part.Material.BackfaceCulling = false
+
+	verts := make([]VertexInfo, 0, triangleCount*3)
+	for i := 0; i < triangleCount; i++ {
+		verts = append(verts, NewVertex(0, -1, 1, 0, 0), NewVertex(-1, -1, -10, 0, 0), NewVertex(1, -1, -10, 0, 0))
+	}
+	part.AddTriangles(verts...)
+	mesh.UpdateBounds()
+
+	model := NewModel(mesh, "crossing")
+
+	draws := []int{}
+	part.OnRender = func(camera *Camera, model *Model, part *MeshPart, stage int, vertices []ebiten.Vertex) {
+		if stage == RenderStageBefore {
+			draws = append(draws, len(vertices))
+		}
+	}
+
+	// The clip plane cuts off everything to the right of x = 0.5.
+	camera := NewCamera(64, 64)
+	camera.clipPlane = vector.Vector{-1, 0, 0, 0.5}
+	camera.Clear()
+	camera.Render(NewScene("clip plane"), model)
+
+	total := 0
+	for _, count := range draws {
+		if count > ebiten.MaxIndicesNum || count%maxClippedVertices != 0 {
+			t.Fatalf("expected each draw to fit in the vertex lists without splitting up clipped triangles, but one had %d vertices", count)
+		}
+		total += count
+	}
+
+	if len(draws) < 2 || total != triangleCount*maxClippedVertices {
+		t.Fatalf("expected the clipped triangles to be drawn across multiple draws with %d vertices each; got draws of %v vertices", maxClippedVertices, draws)
+	}
+
+}
@@ -0,0 +1,37 @@
+package tetra3d
+
+// RenderLayers is a bitmask of up to 32 render layers (i.e. one layer for the game world, another for a first-person weapon, and
+// another for 3D UI elements). Nodes declare which layers they're on with their Layers field, and Cameras declare which layers they
+// render with their LayerMask field; a Camera only renders Models that share at least one layer with it. By default, Nodes are on
+// layer 0 (RenderLayersDefault), and Cameras render all layers.
+type RenderLayers uint32
+
+const (
+	RenderLayersDefault = RenderLayers(1)  // RenderLayersDefault is a RenderLayers value with only the first layer (layer 0) set.
+	RenderLayersAll     = ^RenderLayers(0) // RenderLayersAll is a RenderLayers value with all layers set.
+	RenderLayersNone    = RenderLayers(0)  // RenderLayersNone is a RenderLayers value with no layers set.
+)
+
+// NewRenderLayers returns a RenderLayers bitmask with the layers of the given indices (from 0 to 31) set.
+func NewRenderLayers(layers ...int) RenderLayers {
+	mask := RenderLayers(0)
+	for _, layer := range layers {
+		mask |= 1 << layer
+	}
+	return mask
+}
+
+// Has returns if any of the layers in the other RenderLayers are set in the RenderLayers.
+func (layers RenderLayers) Has(other RenderLayers) bool {
+	return layers&other != 0
+}
+
+// With returns a copy of the RenderLayers with the layers of the given indices (from 0 to 31) set.
+func (layers RenderLayers) With(indices ...int) RenderLayers {
+	return layers | NewRenderLayers(indices...)
+}
+
+// Without returns a copy of the RenderLayers with the layers of the given indices (from 0 to 31) unset.
+func (layers RenderLayers) Without(indices ...int) RenderLayers {
+	return layers &^ NewRenderLayers(indices...)
+}
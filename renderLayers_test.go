@@ -0,0 +1,87 @@
+package tetra3d
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestRenderLayers(t *testing.T) {
+
+	if layers := NewRenderLayers(0, 3); layers != 9 || !layers.Has(RenderLayersDefault) || layers.Without(0).Has(RenderLayersDefault) {
+		t.Errorf("expected render layers 0 and 3 to be set, got %b", layers)
+	}
+
+	scene := NewScene("render layers")
+
+	world := NewModel(NewCube(), "world")
+	world.Move(0, 0, -5)
+	scene.Root.AddChildren(world)
+
+	weapon := NewModel(NewCube(), "weapon")
+	weapon.Move(0, 0, -5)
+	weapon.Layers = NewRenderLayers(1)
+	scene.Root.AddChildren(weapon)
+
+	rendered := map[*Model]bool{}
+
+	for _, model := range []*Model{world, weapon} {
+		model.Mesh.MeshParts[0].OnRender = func(camera *Camera, model *Model, part *MeshPart, stage int, vertices []ebiten.Vertex) {
+			rendered[model] = true
+		}
+	}
+
+	camera := NewCamera(32, 32)
+	camera.LayerMask = RenderLayersAll.Without(1)
+	camera.Clear()
+	camera.RenderNodes(scene, scene.Root)
+
+	if !rendered[world] || rendered[weapon] {
+		t.Errorf("expected only the world to be rendered by the world Camera, got %v", rendered)
+	}
+
+	rendered = map[*Model]bool{}
+
+	camera.LayerMask = NewRenderLayers(1)
+	camera.Clear()
+	camera.RenderNodes(scene, scene.Root)
+
+	if rendered[world] || !rendered[weapon] {
+		t.Errorf("expected only the weapon to be rendered by the weapon Camera, got %v", rendered)
+	}
+
+	// Dynamically batched Models are rendered by their batch owner, but should still be left out if they're on layers the Camera
+	// doesn't render.
+	owner := NewModel(NewCube(), "owner")
+	scene.Root.AddChildren(owner)
+
+	batchedWorld := NewModel(NewCube(), "batched world")
+	batchedWorld.Move(0, 0, -5)
+	batchedWeapon := batchedWorld.Clone().(*Model)
+	batchedWeapon.Layers = NewRenderLayers(1)
+
+	if err := owner.DynamicBatchAdd(owner.Mesh.MeshParts[0], batchedWorld, batchedWeapon); err != nil {
+		t.Fatal(err)
+	}
+
+	batchedVertices := 0
+	owner.Mesh.MeshParts[0].OnRender = func(camera *Camera, model *Model, part *MeshPart, stage int, vertices []ebiten.Vertex) {
+		if stage == RenderStageBefore {
+			batchedVertices = len(vertices)
+		}
+	}
+
+	camera.LayerMask = RenderLayersAll
+	camera.Clear()
+	camera.Render(scene, owner)
+	allVertices := batchedVertices
+
+	camera.LayerMask = RenderLayersAll.Without(1)
+	camera.Clear()
+	camera.Render(scene, owner)
+
+	if allVertices == 0 || batchedVertices*2 != allVertices {
+		t.Errorf("expected only one of the two batched cubes to be rendered by the world Camera; got %d vertices out of %d", batchedVertices, allVertices)
+	}
+
+}
@@ -1,5 +1,10 @@
 package tetra3d
 
+import (
+	"path"
+	"strings"
+)
+
 // Scene represents a world of sorts, and can contain a variety of Meshes and Nodes, which organize the scene into a
 // graph of parents and children. Models (visual instances of Meshes), Cameras, and "empty" NodeBases all are kinds of Nodes.
 type Scene struct {
@@ -54,3 +59,82 @@ func (scene *Scene) Library() *Library {
 func (scene *Scene) Properties() *Properties {
 	return scene.props
 }
+
+// FindByPath returns the Nodes underneath the Scene's Root whose paths (see Node.Path()) match the pattern given. The pattern is
+// composed of Node names separated by forward slashes ('/'), just like a path; each name can contain "*" wildcards, which match any
+// sequence of characters within a name (so "Enemy*" matches "Enemy", "Enemy.001", and "EnemyBoss"), while a name that is just "**"
+// matches any number of levels of the hierarchy, including none. As an example, "Level/**/Enemy*" would find all Nodes whose names
+// start with "Enemy" anywhere underneath the "Level" Node. The Nodes are returned in the same depth-first order as
+// Node.ChildrenRecursive(); if no Nodes match, an empty NodeFilter is returned.
+func (scene *Scene) FindByPath(pattern string) NodeFilter {
+
+	patternSegments := []string{}
+
+	for _, s := range strings.Split(pattern, `/`) {
+		if s = strings.TrimSpace(s); len(s) > 0 {
+			patternSegments = append(patternSegments, s)
+		}
+	}
+
+	out := NodeFilter{}
+
+	if len(patternSegments) == 0 {
+		return out
+	}
+
+	pathSegments := []string{}
+
+	var search func(node INode)
+
+	search = func(node INode) {
+
+		for _, child := range node.Children() {
+
+			pathSegments = append(pathSegments, child.Name())
+
+			if matchPathSegments(patternSegments, pathSegments) {
+				out = append(out, child)
+			}
+
+			search(child)
+
+			pathSegments = pathSegments[:len(pathSegments)-1]
+
+		}
+
+	}
+
+	search(scene.Root)
+
+	return out
+
+}
+
+// matchPathSegments returns if the path segments given match the pattern segments, where "**" matches any number of path segments,
+// and other pattern segments are matched against single path segments using path.Match().
+func matchPathSegments(pattern, segments []string) bool {
+
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchPathSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	if matched, err := path.Match(pattern[0], segments[0]); err != nil || !matched {
+		return false
+	}
+
+	return matchPathSegments(pattern[1:], segments[1:])
+
+}
@@ -0,0 +1,171 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kvartborg/vector"
+)
+
+// newTestScene creates a Scene with the following hierarchy:
+//
+//	Level
+//		Enemies
+//			Enemy.001
+//			Group
+//				Enemy.002
+//				Crate
+//		Enemy.003
+//	Player
+func newTestScene() *Scene {
+
+	scene := NewScene("test")
+
+	level := NewNode("Level")
+	enemies := NewNode("Enemies")
+	group := NewNode("Group")
+
+	scene.Root.AddChildren(level, NewNode("Player"))
+	level.AddChildren(enemies, NewNode("Enemy.003"))
+	enemies.AddChildren(NewNode("Enemy.001"), group)
+	group.AddChildren(NewNode("Enemy.002"), NewNode("Crate"))
+
+	return scene
+
+}
+
+func nodeNames(nodes NodeFilter) []string {
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.Name())
+	}
+	return names
+}
+
+func TestChildrenRecursiveIsDepthFirst(t *testing.T) {
+
+	scene := newTestScene()
+
+	expected := []string{"Level", "Enemies", "Enemy.001", "Group", "Enemy.002", "Crate", "Enemy.003", "Player"}
+	names := nodeNames(scene.Root.ChildrenRecursive())
+
+	if len(names) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, names)
+		}
+	}
+
+}
+
+func TestFindByPath(t *testing.T) {
+
+	scene := newTestScene()
+
+	tests := []struct {
+		pattern  string
+		expected []string
+	}{
+		{"Level", []string{"Level"}},
+		{"Level/Enemies/Enemy.001", []string{"Enemy.001"}},
+		{"Level/*", []string{"Enemies", "Enemy.003"}},
+		{"Level/Enemies/*", []string{"Enemy.001", "Group"}},
+		{"Level/**/Enemy*", []string{"Enemy.001", "Enemy.002", "Enemy.003"}},
+		{"Level/**/Enem*", []string{"Enemies", "Enemy.001", "Enemy.002", "Enemy.003"}},
+		{"**/Enemy.*", []string{"Enemy.001", "Enemy.002", "Enemy.003"}},
+		{"**/Group/**", []string{"Group", "Enemy.002", "Crate"}},
+		{"*/*/Group/Crate", []string{"Crate"}},
+		{"**", []string{"Level", "Enemies", "Enemy.001", "Group", "Enemy.002", "Crate", "Enemy.003", "Player"}},
+		{"Player/*", []string{}},
+		{"Missing/**", []string{}},
+		{"", []string{}},
+	}
+
+	for _, test := range tests {
+
+		found := scene.FindByPath(test.pattern)
+
+		if found == nil {
+			t.Fatalf("FindByPath(%q) returned nil rather than an empty NodeFilter", test.pattern)
+		}
+
+		names := nodeNames(found)
+
+		if len(names) != len(test.expected) {
+			t.Fatalf("FindByPath(%q): expected %v, got %v", test.pattern, test.expected, names)
+		}
+
+		for i := range test.expected {
+			if names[i] != test.expected[i] {
+				t.Fatalf("FindByPath(%q): expected %v, got %v", test.pattern, test.expected, names)
+			}
+		}
+
+	}
+
+}
+
+func TestSetWorldTransformProperties(t *testing.T) {
+
+	near := func(a, b vector.Vector) bool {
+		return math.Abs(a[0]-b[0]) < 0.00001 && math.Abs(a[1]-b[1]) < 0.00001 && math.Abs(a[2]-b[2]) < 0.00001
+	}
+
+	grandparent := NewNode("grandparent")
+	grandparent.SetLocalPosition(1, 2, 3)
+	grandparent.SetLocalRotation(NewMatrix4Rotate(1, 1, 0, 0.7))
+
+	parent := NewNode("parent")
+	parent.SetLocalPosition(-4, 0, 2)
+	parent.SetLocalScale(3, 1, 0.5) // Non-uniform
+	parent.SetLocalRotation(NewMatrix4Rotate(0, 1, 0, 1.2))
+
+	child := NewNode("child")
+
+	grandparent.AddChildren(parent)
+	parent.AddChildren(child)
+
+	// The grandparent has no parent, while the child's parent is rotated and non-uniformly scaled. The child goes first, as otherwise
+	// the grandparent's non-uniform world scale would shear the parent.
+	for _, node := range []INode{child, grandparent} {
+
+		position := vector.Vector{5, -2, 8}
+		rotation := NewMatrix4Rotate(0, 0, 1, 0.3)
+
+		if node == child {
+			// The parent's world rotation is representable regardless of its non-uniform scale.
+			_, _, rotation = parent.Transform().Decompose()
+		}
+
+		node.SetWorldRotation(rotation)
+		node.SetWorldScale(2, 3, 4)
+		node.SetWorldPositionVec(position)
+
+		for i := 0; i < 10; i++ {
+
+			if !near(node.WorldPosition(), position) {
+				t.Fatalf("%s: expected world position %v, got %v", node.Name(), position, node.WorldPosition())
+			}
+
+			if scale := node.WorldScale(); !near(scale, vector.Vector{2, 3, 4}) {
+				t.Fatalf("%s: expected world scale {2, 3, 4}, got %v", node.Name(), scale)
+			}
+
+			worldRotation := node.WorldRotation()
+			for row := 0; row < 3; row++ {
+				if !near(worldRotation.Row(row), rotation.Row(row)) {
+					t.Fatalf("%s: expected world rotation %v, got %v", node.Name(), rotation, worldRotation)
+				}
+			}
+
+			// Setting the world properties to their current values shouldn't cause them to drift.
+			node.SetWorldTransform(node.Transform())
+
+		}
+
+	}
+
+}
@@ -0,0 +1,157 @@
+package tetra3d
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// The shaders below are ready-made custom fragment shaders for Materials, set through the Material.Set...Shader() functions. Each
+// samples the Material's texture (which is used as the first image of the Material's FragmentShaderOptions if that's unset) and
+// multiplies it by the vertex color (which includes the Material's, Model's, and Mesh's colors, and vertex lighting), like the
+// default renderer does. Their uniforms are set to defaults in the Material's FragmentShaderOptions, and can be changed there or for
+// individual Models through Model.ShaderUniforms (for example, to dissolve one enemy without affecting others sharing its Material).
+// Note that Ebitengine requires all images passed to a shader to be the same size, so any extra textures they use must be the same
+// size as the Material's texture.
+// As custom fragment shaders don't have access to the normals or world positions of the surfaces they render, effects that need
+// them are built into Materials instead: for fresnel glow, see Material.RimColor, and for triplanar mapping, see
+// Material.TriplanarScale.
+
+// The dissolve shader hides the pixels whose noise value (from the red channel of the second image) is at or below the Threshold
+// uniform, drawing the pixels within EdgeWidth above the Threshold in the EdgeColor.
+var dissolveShaderText = []byte(
+	`package main
+
+	var Threshold float
+	var EdgeWidth float
+	var EdgeColor vec4
+
+	func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+
+		texel := imageSrc0At(texCoord) * vec4(color.rgb*color.a, color.a)
+
+		if Threshold <= 0 {
+			return texel
+		}
+
+		noise := imageSrc1At(texCoord).r
+
+		if noise <= Threshold {
+			return vec4(0)
+		}
+
+		if noise <= Threshold+EdgeWidth {
+			return vec4(EdgeColor.rgb*EdgeColor.a, EdgeColor.a) * texel.a
+		}
+
+		return texel
+
+	}
+	`,
+)
+
+// The scanline shader darkens horizontal lines across the screen; each line is Thickness pixels tall and repeats every Spacing
+// pixels, darkening the Material by Intensity (from 0 to 1). Offset scrolls the lines vertically (in pixels).
+var scanlineShaderText = []byte(
+	`package main
+
+	var Spacing float
+	var Thickness float
+	var Intensity float
+	var Offset float
+
+	func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+
+		texel := imageSrc0At(texCoord) * vec4(color.rgb*color.a, color.a)
+
+		if Spacing > 0 && mod(position.y+Offset, Spacing) < Thickness {
+			return vec4(texel.rgb*(1-Intensity), texel.a)
+		}
+
+		return texel
+
+	}
+	`,
+)
+
+// The vertex color blend shader blends between the first and second images using the vertex colors' alpha channel as a mask (0
+// being fully the first image, and 1 being fully the second); the vertex colors' RGB channels tint the result as usual.
+var vertexColorBlendShaderText = []byte(
+	`package main
+
+	func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+
+		texel := mix(imageSrc0At(texCoord), imageSrc1At(texCoord), color.a)
+
+		return texel * vec4(color.rgb, 1)
+
+	}
+	`,
+)
+
+// setPresetShader sets the Material's fragment shader to the given built-in shader, clearing its FragmentShaderOptions' images and
+// setting its uniforms to the ones given.
+func (material *Material) setPresetShader(src []byte, uniforms map[string]interface{}) {
+
+	if _, err := material.SetShader(src); err != nil {
+		panic(err)
+	}
+
+	material.FragmentShaderOn = true
+	material.FragmentShaderOptions.Images = [4]*ebiten.Image{}
+	material.FragmentShaderOptions.Uniforms = uniforms
+
+}
+
+// SetDissolveShader sets the Material to use a dissolve shader, which makes the Material disappear in a pattern defined by the noise
+// texture given (which must be the same size as the Material's texture); the darkest parts of the noise texture (based on its red
+// channel) disappear first. The shader's uniforms are:
+//
+// Threshold (float32): How far the Material has dissolved, from 0 (not at all) to 1 (completely). Defaults to 0.
+//
+// EdgeWidth (float32): How far above the Threshold noise values are drawn in the EdgeColor, for a burning edge. Defaults to 0.05.
+//
+// EdgeColor ([]float32{r, g, b, a}): The color of the edge. Defaults to orange ({1, 0.5, 0, 1}).
+func (material *Material) SetDissolveShader(noise *ebiten.Image) {
+
+	material.setPresetShader(dissolveShaderText, map[string]interface{}{
+		"Threshold": float32(0),
+		"EdgeWidth": float32(0.05),
+		"EdgeColor": []float32{1, 0.5, 0, 1},
+	})
+
+	material.FragmentShaderOptions.Images[1] = noise
+
+}
+
+// SetScanlineShader sets the Material to use a scanline shader, which darkens horizontal lines across the screen where the Material
+// is drawn, like an old CRT monitor or a hologram. The shader's uniforms are:
+//
+// Spacing (float32): The distance between the lines, in pixels. Defaults to 4.
+//
+// Thickness (float32): The thickness of the lines, in pixels. Defaults to 2.
+//
+// Intensity (float32): How much the lines darken the Material, from 0 (not at all) to 1 (completely black). Defaults to 0.5.
+//
+// Offset (float32): The vertical offset of the lines, in pixels; increase it over time to scroll the lines. Defaults to 0.
+func (material *Material) SetScanlineShader() {
+
+	material.setPresetShader(scanlineShaderText, map[string]interface{}{
+		"Spacing":   float32(4),
+		"Thickness": float32(2),
+		"Intensity": float32(0.5),
+		"Offset":    float32(0),
+	})
+
+}
+
+// SetVertexColorBlendShader sets the Material to use a shader that blends its texture with the second texture given (which must be
+// the same size as the Material's texture), using the alpha channel of the Mesh's vertex colors as a mask: where the alpha is 0, the
+// Material's texture shows, and where it's 1, the second texture shows. This is useful for painting details like grass or dirt
+// onto terrain with vertex colors. Note that the alpha of the Model's and Material's colors also affects the mask, as it's multiplied
+// into the vertex colors. The shader has no uniforms.
+func (material *Material) SetVertexColorBlendShader(texture *ebiten.Image) {
+
+	material.setPresetShader(vertexColorBlendShaderText, map[string]interface{}{})
+
+	material.FragmentShaderOptions.Images[1] = texture
+
+}
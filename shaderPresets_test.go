@@ -0,0 +1,46 @@
+package tetra3d
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestShaderPresets(t *testing.T) {
+
+	texture := ebiten.NewImage(16, 16)
+	noise := ebiten.NewImage(16, 16)
+
+	mat := NewMaterial("dissolving")
+	mat.Texture = texture
+	mat.SetDissolveShader(noise)
+
+	if mat.Shader() == nil || mat.FragmentShaderOptions.Images[1] != noise {
+		t.Fatalf("expected the dissolve shader to be set up with the noise texture")
+	}
+
+	mesh := NewCube()
+	mesh.MeshParts[0].Material = mat
+	model := NewModel(mesh, "enemy")
+	model.ShaderUniforms["Threshold"] = float32(0.5)
+
+	// The Material's texture fills in the shader's first image.
+	options := model.fragmentShaderOptions(mesh.MeshParts[0])
+
+	if options.Images[0] != texture || options.Images[1] != noise || options.Uniforms["Threshold"] != float32(0.5) || options.Uniforms["EdgeWidth"] != float32(0.05) {
+		t.Errorf("expected the dissolve shader's options to use the texture, noise, and the Model's threshold, got %v", options)
+	}
+
+	mat.SetScanlineShader()
+
+	if mat.FragmentShaderOptions.Images[1] != nil || mat.FragmentShaderOptions.Uniforms["Spacing"] != float32(4) {
+		t.Errorf("expected setting a new preset to replace the previous preset's images and uniforms")
+	}
+
+	mat.SetVertexColorBlendShader(ebiten.NewImage(16, 16))
+
+	if mat.Shader() == nil {
+		t.Errorf("expected the vertex color blend shader to be set")
+	}
+
+}
@@ -0,0 +1,178 @@
+package tetra3d
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// shaderIncludeDirective is the comment that includes a shader snippet in a Kage shader, followed by the snippet's name.
+const shaderIncludeDirective = "//tetra3d:include"
+
+// shaderSnippets holds the Kage shader snippets that can be included in shaders, by name.
+var shaderSnippets = map[string]string{
+
+	// depth decodes the depth values that Tetra3D encodes into the RGB channels of its depth textures (like Camera.DepthTexture()),
+	// returning a depth from 0 (at the Camera's near plane) to 1 (at its far plane).
+	"depth": `
+	func decodeDepth(rgba vec4) float {
+		return rgba.r + (rgba.g / 255) + (rgba.b / 65025)
+	}
+	`,
+
+	// texture converts between the texel coordinates given to Kage shaders and UV values from 0 to 1 across the first source image.
+	"texture": `
+	func texCoordToUV(texCoord vec2) vec2 {
+		origin, size := imageSrcRegionOnTexture()
+		return (texCoord - origin) / size
+	}
+
+	func uvToTexCoord(uv vec2) vec2 {
+		origin, size := imageSrcRegionOnTexture()
+		return origin + uv*size
+	}
+	`,
+
+	// dither returns the threshold of an ordered (4x4 Bayer) dithering pattern at the given pixel position, with each cell of the
+	// pattern being size pixels across; it's between 0 and 1, matching the pattern used by Tetra3D's dithered transparency and fog.
+	"dither": `
+	func bayer2(x, y float) float {
+		return mod(2*x+3*y, 4)
+	}
+
+	func ditherThreshold(position vec2, size float) float {
+		cell := floor(position / max(size, 1))
+		return (4*bayer2(mod(cell.x, 2), mod(cell.y, 2)) + bayer2(mod(floor(cell.x/2), 2), mod(floor(cell.y/2), 2)) + 1) / 17
+	}
+	`,
+
+	// fog applies fog to the premultiplied color given at the given depth (from 0 to 1, see the depth snippet), using the fog color
+	// and mode (matching the FogMode constants: 1 for FogAdd, 2 for FogMultiply, 3 for FogOverwrite, and 4 for FogTransparent, with 0
+	// being no fog) and the range in which the fog fades in, like World.FogColor, World.FogMode, and World.FogRange.
+	"fog": `
+	func applyFog(color vec4, depth float, fogColor vec3, fogMode float, fogRange vec2) vec4 {
+
+		d := smoothstep(fogRange.x, fogRange.y, depth)
+
+		if fogMode == 1 {
+			return vec4(color.rgb+fogColor*d*color.a, color.a)
+		} else if fogMode == 2 {
+			return vec4(color.rgb-(1-fogColor)*d*color.a, color.a)
+		} else if fogMode == 3 {
+			return vec4(mix(color.rgb, fogColor, d)*color.a, color.a)
+		} else if fogMode == 4 {
+			return color * abs(1-d)
+		}
+
+		return color
+
+	}
+	`,
+
+	// lighting holds common lighting functions: the diffuse (Lambertian) and specular (Blinn-Phong) factors of a light shining in
+	// the given direction (pointing from the surface towards the light), the strength of rim lighting, and toon shading that rounds
+	// the given light level down to the given number of evenly-spaced bands. Directions and normals should be normalized.
+	"lighting": `
+	func luminance(rgb vec3) float {
+		return dot(rgb, vec3(0.2126, 0.7152, 0.0722))
+	}
+
+	func diffuseFactor(normal vec3, lightDir vec3) float {
+		return max(dot(normal, lightDir), 0)
+	}
+
+	func specularFactor(normal vec3, lightDir vec3, viewDir vec3, shininess float) float {
+		if dot(normal, lightDir) <= 0 {
+			return 0
+		}
+		return pow(max(dot(normal, normalize(lightDir+viewDir)), 0), shininess)
+	}
+
+	func rimFactor(normal vec3, viewDir vec3, power float) float {
+		return pow(1-clamp(dot(normal, viewDir), 0, 1), power)
+	}
+
+	func toonBands(light float, bands float) float {
+		return floor(light*bands) / bands
+	}
+	`,
+}
+
+// RegisterShaderSnippet registers a Kage shader snippet (typically a set of helper functions, though it can also declare uniforms)
+// under the given name, so shaders can include it with a "//tetra3d:include name" line (see PreprocessShader()). Snippets can include
+// other snippets. Registering a snippet with the name of an existing snippet (including one of the built-in snippets) replaces it;
+// shaders that were already set up with the snippet are unaffected until they're set again.
+func RegisterShaderSnippet(name string, src string) {
+	shaderSnippets[name] = src
+}
+
+// PreprocessShader returns the Kage shader source given with its include directives replaced by the shader snippets they name.
+// An include directive is a line consisting of "//tetra3d:include" followed by the name of a snippet, like "//tetra3d:include fog";
+// they should be placed outside of functions. Each snippet is only included once, no matter how many times it's named.
+// Material.SetShader() preprocesses shaders automatically.
+// The built-in snippets are:
+//
+// depth: decodeDepth(rgba vec4) float, which decodes Tetra3D's depth textures.
+//
+// texture: texCoordToUV(texCoord vec2) vec2 and uvToTexCoord(uv vec2) vec2, which convert between texel coordinates and UV values.
+//
+// dither: ditherThreshold(position vec2, size float) float, which returns the threshold of a 4x4 ordered dithering pattern.
+//
+// fog: applyFog(color vec4, depth float, fogColor vec3, fogMode float, fogRange vec2) vec4, which applies fog like Tetra3D does.
+//
+// lighting: luminance(), diffuseFactor(), specularFactor(), rimFactor(), and toonBands(), for custom lighting.
+//
+// An error is returned if a snippet doesn't exist, or if snippets include each other in a loop.
+func PreprocessShader(src []byte) ([]byte, error) {
+	out := &bytes.Buffer{}
+	if err := preprocessShader(src, out, map[string]bool{}, map[string]bool{}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// preprocessShader writes the shader source given to out with its include directives replaced by the shader snippets they name,
+// skipping snippets that have already been included, and erroring if a snippet that's still being included is included again.
+func preprocessShader(src []byte, out *bytes.Buffer, included, including map[string]bool) error {
+
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+
+	for scanner.Scan() {
+
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if !strings.HasPrefix(trimmed, shaderIncludeDirective) {
+			out.WriteString(line)
+			out.WriteByte('\n')
+			continue
+		}
+
+		name := strings.TrimSpace(strings.TrimPrefix(trimmed, shaderIncludeDirective))
+
+		if including[name] {
+			return fmt.Errorf("shader snippet %q includes itself", name)
+		}
+
+		if included[name] {
+			continue
+		}
+
+		snippet, ok := shaderSnippets[name]
+		if !ok {
+			return fmt.Errorf("shader snippet %q doesn't exist", name)
+		}
+
+		including[name] = true
+		if err := preprocessShader([]byte(snippet), out, included, including); err != nil {
+			return err
+		}
+		including[name] = false
+		included[name] = true
+
+	}
+
+	return scanner.Err()
+
+}
@@ -0,0 +1,50 @@
+package tetra3d
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPreprocessShader(t *testing.T) {
+
+	src := []byte(`package main
+
+	//tetra3d:include depth
+	//tetra3d:include texture
+	//tetra3d:include dither
+	//tetra3d:include fog
+	//tetra3d:include lighting
+	//tetra3d:include depth
+
+	func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+		c := imageSrc0At(uvToTexCoord(texCoordToUV(texCoord))) * diffuseFactor(vec3(0, 1, 0), vec3(0, 1, 0))
+		c = applyFog(c, decodeDepth(color), vec3(1), 3, vec2(0, 1))
+		return c * step(ditherThreshold(position.xy, 1), color.a)
+	}`)
+
+	processed, err := PreprocessShader(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count := bytes.Count(processed, []byte("func decodeDepth")); count != 1 {
+		t.Errorf("expected snippets to be included once, but the depth snippet was included %d times", count)
+	}
+
+	// The built-in snippets should all compile.
+	if _, err := NewMaterial("snippets").SetShader(src); err != nil {
+		t.Errorf("expected the shader using the built-in snippets to compile: %s", err)
+	}
+
+	RegisterShaderSnippet("test loop", "//tetra3d:include test loop")
+	defer delete(shaderSnippets, "test loop")
+
+	if _, err := PreprocessShader([]byte("//tetra3d:include test loop")); err == nil {
+		t.Errorf("expected an error for a snippet that includes itself")
+	}
+
+	if _, err := PreprocessShader([]byte("//tetra3d:include missing")); err == nil {
+		t.Errorf("expected an error for a snippet that doesn't exist")
+	}
+
+}
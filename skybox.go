@@ -0,0 +1,258 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/kvartborg/vector"
+)
+
+const (
+	SkyboxModePanorama = iota // The Skybox's texture is a panorama (an equirectangular projection, like a map of the world)
+	SkyboxModeCubemap         // The Skybox's texture is a cubemap, with its six faces laid out in a horizontal strip
+)
+
+// The skybox shader samples the Skybox's texture in the direction of each pixel's view ray, which is passed through the vertex colors
+// (mapped from -1 to 1 to 0 to 1), as the view rays of a perspective camera can be interpolated linearly across the screen.
+var skyboxShaderText = []byte(
+	`package main
+
+	//tetra3d:include texture
+
+	var Mode float
+	var Tint vec4
+	var Fog vec4
+	var FogRange [2]float
+	var FogAmount float
+
+	func cubemapUV(dir vec3) vec2 {
+
+		a := abs(dir)
+
+		face := 0.0
+		sc := 0.0
+		tc := 0.0
+		ma := 0.0
+
+		if a.x >= a.y && a.x >= a.z {
+			ma = a.x
+			tc = -dir.y
+			if dir.x > 0 {
+				face = 0
+				sc = -dir.z
+			} else {
+				face = 1
+				sc = dir.z
+			}
+		} else if a.y >= a.z {
+			ma = a.y
+			sc = dir.x
+			if dir.y > 0 {
+				face = 2
+				tc = dir.z
+			} else {
+				face = 3
+				tc = -dir.z
+			}
+		} else {
+			ma = a.z
+			tc = -dir.y
+			if dir.z > 0 {
+				face = 4
+				sc = dir.x
+			} else {
+				face = 5
+				sc = -dir.x
+			}
+		}
+
+		// The position on the face is kept half a texel from its edges, so that neighboring faces don't bleed into it.
+		_, size := imageSrcRegionOnTexture()
+		margin := 0.5 / (size * imageSrcTextureSize())
+
+		u := clamp((sc / ma + 1) / 2, margin.x * 6, 1 - margin.x * 6)
+		v := clamp((tc / ma + 1) / 2, margin.y, 1 - margin.y)
+
+		return vec2((face + u) / 6, v)
+
+	}
+
+	func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+
+		dir := normalize(color.rgb * 2 - 1)
+
+		uv := vec2(0)
+
+		if Mode == 1 {
+			uv = cubemapUV(dir)
+		} else {
+			uv = vec2(0.5 + atan2(dir.x, -dir.z) / (2 * 3.1415926), 0.5 - asin(clamp(dir.y, -1, 1)) / 3.1415926)
+		}
+
+		sky := imageSrc0At(uvToTexCoord(uv)) * Tint
+
+		// The sky is fogged as though it were at the far plane.
+		d := smoothstep(FogRange[0], FogRange[1], 1) * FogAmount
+
+		if Fog.a == 1 {
+			sky.rgb += Fog.rgb * d * sky.a
+		} else if Fog.a == 2 {
+			sky.rgb -= Fog.rgb * d * sky.a
+		} else if Fog.a == 3 {
+			sky.rgb = mix(sky.rgb, Fog.rgb * sky.a, d)
+		} else if Fog.a == 4 {
+			sky *= 1 - d
+		}
+
+		return sky
+
+	}
+	`,
+)
+
+// Skybox is a texture that's rendered behind everything else in a Scene, as though it were infinitely far away, for skies and
+// distant scenery (see World.Skybox). It rotates with the Camera, but doesn't move with it. The Skybox is fogged by the World's fog
+// as though it were at the Camera's far plane.
+type Skybox struct {
+	// Texture is the Skybox's texture; for SkyboxModePanorama, it's an equirectangular panorama (with its horizontal center facing
+	// -Z), and for SkyboxModeCubemap, it's the six faces of a cubemap (facing +X, -X, +Y, -Y, +Z, and -Z, oriented like OpenGL cubemap
+	// faces) laid out from left to right (see NewSkyboxCubemap()). It's sampled without filtering.
+	Texture *ebiten.Image
+	Mode    int    // How the Texture is mapped onto the sky (SkyboxModePanorama or SkyboxModeCubemap).
+	Color   *Color // A color that the Skybox's texture is multiplied by; defaults to opaque white.
+
+	// FogAmount is how much the World's fog affects the Skybox, from 0 (not at all) to 1 (as much as something at the Camera's far
+	// plane); defaults to 1.
+	FogAmount float64
+}
+
+// NewSkyboxPanorama creates a new Skybox using the given equirectangular panorama texture.
+func NewSkyboxPanorama(texture *ebiten.Image) *Skybox {
+	return &Skybox{
+		Texture:   texture,
+		Mode:      SkyboxModePanorama,
+		Color:     NewColor(1, 1, 1, 1),
+		FogAmount: 1,
+	}
+}
+
+// NewSkyboxCubemap creates a new Skybox using the given cubemap faces (facing +X, -X, +Y, -Y, +Z, and -Z, in that order), which should
+// all be square and of the same size. The faces are copied into a single texture as a horizontal strip.
+func NewSkyboxCubemap(faces [6]*ebiten.Image) *Skybox {
+
+	size := faces[0].Bounds().Dx()
+
+	texture := ebiten.NewImage(size*6, size)
+
+	for i, face := range faces {
+		opt := &ebiten.DrawImageOptions{}
+		opt.GeoM.Translate(float64(i*size), 0)
+		texture.DrawImage(face, opt)
+	}
+
+	skybox := NewSkyboxPanorama(texture)
+	skybox.Mode = SkyboxModeCubemap
+	return skybox
+
+}
+
+// Clone returns a new Skybox with the same properties as the existing Skybox; the texture is shared.
+func (skybox *Skybox) Clone() *Skybox {
+	return &Skybox{
+		Texture:   skybox.Texture,
+		Mode:      skybox.Mode,
+		Color:     skybox.Color.Clone(),
+		FogAmount: skybox.FogAmount,
+	}
+}
+
+// skyboxViewRays returns the directions (in world space) of the view rays at the top-left, top-right, bottom-left, and bottom-right
+// corners of the Camera's view. Orthographic Cameras use the view rays of a perspective Camera with the same field of view.
+func (camera *Camera) skyboxViewRays() [4]vector.Vector {
+
+	y := math.Tan(camera.FieldOfView * math.Pi / 360)
+	x := y * camera.AspectRatio()
+
+	rotation := camera.WorldRotation()
+
+	return [4]vector.Vector{
+		rotation.MultVec(vector.Vector{-x, y, -1}),
+		rotation.MultVec(vector.Vector{x, y, -1}),
+		rotation.MultVec(vector.Vector{-x, -y, -1}),
+		rotation.MultVec(vector.Vector{x, -y, -1}),
+	}
+
+}
+
+var skyboxIndices = []uint16{0, 1, 2, 1, 3, 2}
+
+// drawSkybox draws the World's Skybox to the Camera's color texture, behind anything that's already been rendered to it.
+func (camera *Camera) drawSkybox(world *World) {
+
+	skybox := world.Skybox
+
+	if skybox == nil || skybox.Texture == nil {
+		return
+	}
+
+	if camera.skyboxShader == nil {
+
+		src, err := PreprocessShader(skyboxShaderText)
+		if err != nil {
+			panic(err)
+		}
+
+		camera.skyboxShader, err = ebiten.NewShader(src)
+		if err != nil {
+			panic(err)
+		}
+
+	}
+
+	rays := camera.skyboxViewRays()
+
+	// The rays are scaled to fit within the range of a color (as they're interpolated linearly, scaling them doesn't change their
+	// direction anywhere on the screen).
+	scale := 0.0
+	for _, ray := range rays {
+		for _, v := range ray {
+			scale = math.Max(scale, math.Abs(v))
+		}
+	}
+
+	w, h := camera.resultColorTexture.Size()
+	bounds := skybox.Texture.Bounds()
+
+	vertices := [4]ebiten.Vertex{}
+
+	for i, ray := range rays {
+		corner := &vertices[i]
+		corner.DstX = float32(i%2) * float32(w)
+		corner.DstY = float32(i/2) * float32(h)
+		corner.SrcX = float32(bounds.Min.X + (i%2)*bounds.Dx())
+		corner.SrcY = float32(bounds.Min.Y + (i/2)*bounds.Dy())
+		corner.ColorR = float32(ray[0]/scale*0.5 + 0.5)
+		corner.ColorG = float32(ray[1]/scale*0.5 + 0.5)
+		corner.ColorB = float32(ray[2]/scale*0.5 + 0.5)
+		corner.ColorA = 1
+	}
+
+	color := skybox.Color
+	if color == nil {
+		color = NewColor(1, 1, 1, 1)
+	}
+
+	camera.resultColorTexture.DrawTrianglesShader(vertices[:], skyboxIndices, camera.skyboxShader, &ebiten.DrawTrianglesShaderOptions{
+		Images: [4]*ebiten.Image{skybox.Texture},
+		Uniforms: map[string]interface{}{
+			"Mode":      float32(skybox.Mode),
+			"Tint":      []float32{color.R * color.A, color.G * color.A, color.B * color.A, color.A},
+			"Fog":       world.fogAsFloatSlice(),
+			"FogRange":  world.FogRange,
+			"FogAmount": float32(skybox.FogAmount),
+		},
+		// The Skybox is drawn behind anything that's already been rendered (e.g. by previous calls to Camera.Render() this frame).
+		CompositeMode: ebiten.CompositeModeDestinationOver,
+	})
+
+}
@@ -0,0 +1,48 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestSkybox(t *testing.T) {
+
+	scene := NewScene("skybox")
+	camera := NewCamera(64, 32)
+	camera.Rotate(0, 1, 0, 0.5)
+	scene.Root.AddChildren(camera)
+
+	// The view rays at the corners of the view should project to the corners of the screen.
+	rays := camera.skyboxViewRays()
+	corners := [4][2]float64{{0, 0}, {64, 0}, {0, 32}, {64, 32}}
+
+	for i, ray := range rays {
+		screen := camera.WorldToScreen(camera.WorldPosition().Add(ray.Scale(10)))
+		if math.Abs(screen[0]-corners[i][0]) > 0.5 || math.Abs(screen[1]-corners[i][1]) > 0.5 {
+			t.Errorf("expected view ray %d to project to %v, got %v", i, corners[i], screen)
+		}
+	}
+
+	face := ebiten.NewImage(4, 4)
+	scene.World.Skybox = NewSkyboxCubemap([6]*ebiten.Image{face, face, face, face, face, face})
+
+	if w, h := scene.World.Skybox.Texture.Size(); w != 24 || h != 4 {
+		t.Errorf("expected the cubemap faces to be laid out in a strip, got a %d x %d texture", w, h)
+	}
+
+	camera.Clear()
+	camera.RenderNodes(scene, scene.Root)
+
+	scene.World.Skybox = NewSkyboxPanorama(ebiten.NewImage(8, 4))
+	scene.World.FogMode = FogOverwrite
+
+	camera.Clear()
+	camera.RenderNodes(scene, scene.Root)
+
+	if clone := scene.World.Clone(); clone.Skybox == nil || clone.Skybox.Texture != scene.World.Skybox.Texture || clone.Skybox == scene.World.Skybox {
+		t.Errorf("expected cloned Worlds to have copies of the Skybox")
+	}
+
+}
@@ -0,0 +1,141 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/kvartborg/vector"
+)
+
+// specularSource holds the world-space position and orientation of a light, gathered once for each MeshPart that's rendered with
+// specular highlights, as the lights' working vectors are in the lit Model's local space.
+type specularSource struct {
+	light     ILight
+	position  vector.Vector
+	direction vector.Vector // The direction towards DirectionalLights, or the direction SpotLights and RectLights shine in
+	right, up vector.Vector
+}
+
+// newSpecularSource returns the specularSource for the given light, and whether the light can give specular highlights at all (as
+// ambient lights and light probes can't).
+func newSpecularSource(light ILight) (specularSource, bool) {
+
+	source := specularSource{light: light}
+
+	switch l := light.(type) {
+
+	case *DirectionalLight:
+		source.direction = l.WorldRotation().Forward() // Already reversed
+
+	case *PointLight:
+		source.position = l.WorldPosition()
+
+	case *SpotLight:
+		source.position = l.WorldPosition()
+		source.direction = l.WorldRotation().Forward().Invert()
+
+	case *RectLight:
+		rotation := l.WorldRotation()
+		source.position = l.WorldPosition()
+		source.direction = rotation.Forward().Invert()
+		source.right = rotation.Right()
+		source.up = rotation.Up()
+
+	default:
+		return source, false
+
+	}
+
+	return source, true
+
+}
+
+// highlight returns the color of the specular highlight the light gives a vertex with the given world position and normal, seen from
+// the direction of the given view vector (which points from the vertex towards the camera), using the given shininess.
+func (source *specularSource) highlight(position, normal, view vector.Vector, shininess float64) (float32, float32, float32) {
+
+	var toLight vector.Vector
+	var color *Color
+	var energy float32
+	factor := 1.0
+
+	switch l := source.light.(type) {
+
+	case *DirectionalLight:
+		toLight = source.direction
+		color, energy = l.Color, l.Energy
+
+	case *PointLight:
+		toLight = source.position.Sub(position)
+		factor = l.Attenuation.falloff(dot(toLight, toLight), l.Distance)
+		color, energy = l.Color, l.Energy
+
+	case *SpotLight:
+		toLight = source.position.Sub(position)
+		factor = l.Attenuation.falloff(dot(toLight, toLight), l.Distance)
+		factor *= l.cone(-dot(toLight.Unit(), source.direction))
+		color, energy = l.Color, l.Energy
+
+	case *RectLight:
+		// The rectangle only shines forward, so vertices behind it aren't lit.
+		if dot(fastVectorSub(position, source.position), source.direction) <= 0 {
+			return 0, 0, 0
+		}
+		toLight = l.closestPointOn(position, source.position, source.right, source.up).Sub(position)
+		factor = l.Attenuation.falloff(dot(toLight, toLight), l.Distance)
+		color, energy = l.Color, l.Energy
+
+	}
+
+	if factor <= 0 || toLight.Magnitude() == 0 || view.Magnitude() == 0 || normal.Magnitude() == 0 {
+		return 0, 0, 0
+	}
+
+	toLight = toLight.Unit()
+	normal = normal.Unit()
+
+	// Surfaces facing away from the light get no highlights.
+	if dot(normal, toLight) <= 0 {
+		return 0, 0, 0
+	}
+
+	halfway := toLight.Add(view.Unit())
+	if halfway.Magnitude() == 0 {
+		return 0, 0, 0
+	}
+
+	factor *= math.Pow(math.Max(dot(normal, halfway.Unit()), 0), shininess)
+
+	return color.R * energy * float32(factor), color.G * energy * float32(factor), color.B * energy * float32(factor)
+
+}
+
+// assignSpecular sets up the specular highlights of the given Material, as seen from the given Camera, for the pixel lighting shader.
+func (set *pixelLightSet) assignSpecular(material *Material, camera *Camera) {
+
+	set.shininess = 0
+
+	if material == nil {
+		return
+	}
+
+	r, g, b, specular := material.specular()
+
+	if !specular {
+		return
+	}
+
+	set.shininess = float32(material.Shininess)
+	set.specularColor = [3]float32{r, g, b}
+
+	// For orthographic cameras, the view vector is the same for every pixel.
+	position := camera.WorldPosition()
+	view := camera.cameraForward.Invert()
+
+	for i := 0; i < 3; i++ {
+		set.cameraPosition[i] = float32(position[i])
+		set.viewDirection[i] = float32(view[i])
+	}
+
+	set.perspective = camera.Perspective
+
+}
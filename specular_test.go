@@ -0,0 +1,39 @@
+package tetra3d
+
+import (
+	"testing"
+
+	"github.com/kvartborg/vector"
+)
+
+func TestSpecularHighlight(t *testing.T) {
+
+	light := NewPointLight("light", 1, 1, 1, 1)
+	light.SetLocalPosition(0, 0, 5)
+	light.beginRender()
+
+	source, ok := newSpecularSource(light)
+	if !ok {
+		t.Fatalf("expected a PointLight to give specular highlights")
+	}
+
+	if _, ok := newSpecularSource(NewAmbientLight("ambient", 1, 1, 1, 1)); ok {
+		t.Errorf("expected an AmbientLight to give no specular highlights")
+	}
+
+	position := vector.Vector{0, 0, 0}
+	normal := vector.Vector{0, 0, 1}
+
+	// With the camera and the light both straight in front of the surface, the highlight is at its brightest.
+	facing, _, _ := source.highlight(position, normal, vector.Vector{0, 0, 1}, 32)
+	angled, _, _ := source.highlight(position, normal, vector.Vector{1, 0, 1}, 32)
+
+	if facing <= 0 || angled >= facing {
+		t.Errorf("expected the highlight to fade as the camera moves away from the reflected light, got %f and %f", facing, angled)
+	}
+
+	if behind, _, _ := source.highlight(position, normal.Invert(), vector.Vector{0, 0, -1}, 32); behind != 0 {
+		t.Errorf("expected no highlight on a surface facing away from the light, got %f", behind)
+	}
+
+}
@@ -0,0 +1,143 @@
+package tetra3d
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LoadSTLFile loads a .stl file from the filepath given, returning a Mesh named after the file (without its extension). See
+// LoadSTLData() for more information. LoadSTLFile will return a Mesh, and an error if the process fails.
+func LoadSTLFile(path string, importOptions *ImportOptions) (*Mesh, error) {
+
+	fileData, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	mesh, err := LoadSTLData(fileData, importOptions)
+
+	if err != nil {
+		return nil, err
+	}
+
+	mesh.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	return mesh, nil
+
+}
+
+// LoadSTLData loads STL data (either binary or ASCII), as is commonly exported from CAD software and 3D scanners, returning a Mesh.
+// importOptions describes the coordinate system the data was authored in, so that it can be converted to Tetra3D's; STL files are
+// frequently Z-up (see NewImportOptionsBlender()), and often measured in millimeters (see ImportOptions.Scale). If importOptions is
+// nil, no conversion is done.
+//
+// STL files only contain triangles, so the Mesh has no UV values or vertex colors, and a single Material. The Mesh is named after
+// the solid's name in ASCII STL data, or "STL" otherwise. The normals stored in STL files are frequently missing or wrong, so the
+// Mesh's normals are calculated from its triangles' winding order instead. LoadSTLData will return a Mesh, and an error if the
+// process fails.
+func LoadSTLData(data []byte, importOptions *ImportOptions) (*Mesh, error) {
+
+	name := "STL"
+	var verts []VertexInfo
+
+	// Binary STL files can also start with "solid", so the size of the data is the best way to tell them apart.
+	if len(data) >= 84 && len(data) == 84+int(binary.LittleEndian.Uint32(data[80:84]))*50 {
+
+		count := int(binary.LittleEndian.Uint32(data[80:84]))
+
+		verts = make([]VertexInfo, 0, count*3)
+
+		float := func(offset int) float64 {
+			return float64(math.Float32frombits(binary.LittleEndian.Uint32(data[offset : offset+4])))
+		}
+
+		for i := 0; i < count; i++ {
+
+			// Each triangle is a normal, three vertices, and a two-byte attribute.
+			offset := 84 + i*50 + 12
+
+			for v := 0; v < 3; v++ {
+				verts = append(verts, NewVertex(float(offset), float(offset+4), float(offset+8), 0, 0))
+				offset += 12
+			}
+
+		}
+
+	} else if bytes.HasPrefix(bytes.TrimSpace(data), []byte("solid")) {
+
+		verts = []VertexInfo{}
+
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+
+		for scanner.Scan() {
+
+			fields := strings.Fields(scanner.Text())
+
+			if len(fields) == 0 {
+				continue
+			}
+
+			switch fields[0] {
+
+			case "solid":
+				if len(fields) > 1 {
+					name = strings.Join(fields[1:], " ")
+				}
+
+			case "vertex":
+
+				if len(fields) < 4 {
+					return nil, errors.New("error loading stl data: vertex has fewer than three coordinates")
+				}
+
+				pos := [3]float64{}
+				for i := range pos {
+					value, err := strconv.ParseFloat(fields[i+1], 64)
+					if err != nil {
+						return nil, errors.New("error loading stl data: " + err.Error())
+					}
+					pos[i] = value
+				}
+
+				verts = append(verts, NewVertex(pos[0], pos[1], pos[2], 0, 0))
+
+			}
+
+		}
+
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+
+		if len(verts)%3 > 0 {
+			return nil, errors.New("error loading stl data: facet doesn't have three vertices")
+		}
+
+	} else {
+		return nil, errors.New("error loading stl data: data is neither binary nor ASCII STL")
+	}
+
+	if len(verts) == 0 {
+		return nil, errors.New("error loading stl data: data has no triangles")
+	}
+
+	mesh := NewMesh(name)
+	mesh.addSplitMeshParts(NewMaterial(name), verts)
+	mesh.AutoNormal()
+	mesh.UpdateBounds()
+
+	if converter := importOptions.converter(); converter != nil {
+		converter.mesh(mesh)
+	}
+
+	return mesh, nil
+
+}
@@ -0,0 +1,70 @@
+package tetra3d
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func TestLoadSTLData(t *testing.T) {
+
+	cube := NewCube()
+
+	// Binary STL data can start with "solid", too.
+	header := make([]byte, 80)
+	copy(header, "solid cube")
+
+	buffer := &bytes.Buffer{}
+	buffer.Write(header)
+	binary.Write(buffer, binary.LittleEndian, uint32(len(cube.Triangles)))
+
+	for _, tri := range cube.Triangles {
+		binary.Write(buffer, binary.LittleEndian, [3]float32{})
+		for _, index := range tri.VertexIndices() {
+			pos := cube.VertexPositions[index]
+			binary.Write(buffer, binary.LittleEndian, [3]float32{float32(pos[0]), float32(pos[1]), float32(pos[2])})
+		}
+		binary.Write(buffer, binary.LittleEndian, uint16(0))
+	}
+
+	mesh, err := LoadSTLData(buffer.Bytes(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mesh.Triangles) != len(cube.Triangles) {
+		t.Fatalf("expected %d triangles, got %d", len(cube.Triangles), len(mesh.Triangles))
+	}
+
+	for i, tri := range mesh.Triangles {
+		if tri.Normal.Sub(cube.Triangles[i].Normal).Magnitude() > 0.0001 {
+			t.Errorf("triangle %d: expected normal %v, got %v", i, cube.Triangles[i].Normal, tri.Normal)
+		}
+	}
+
+	ascii := `solid triangle
+  facet normal 0 0 0
+    outer loop
+      vertex 0 0 0
+      vertex 1 0 0
+      vertex 0 1 0
+    endloop
+  endfacet
+endsolid triangle`
+
+	mesh, err = LoadSTLData([]byte(ascii), NewImportOptionsBlender())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mesh.Name != "triangle" || len(mesh.Triangles) != 1 {
+		t.Fatalf("expected a single triangle in a Mesh named triangle, got %d in %s", len(mesh.Triangles), mesh.Name)
+	}
+
+	// +Z in Blender's coordinate system is +Y in Tetra3D's.
+	if normal := mesh.Triangles[0].Normal; math.Abs(normal[1]-1) > 0.0001 {
+		t.Errorf("expected the triangle to face +Y, got %v", normal)
+	}
+
+}
@@ -0,0 +1,627 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/kvartborg/vector"
+)
+
+// sweepSkin is how far short of the time of impact (in world units) a sweep stops, so that an object moved to a SweepHit's Position
+// is left just shy of what it hit, rather than touching (and so overlapping) it.
+const sweepSkin = 0.0001
+
+// SweepHit is the result of sweeping a BoundingObject along a movement vector (see IBoundingObject.Sweep(), MoveWithCollision(), and
+// SphereCast()); it describes the first thing the swept object would hit along the way.
+type SweepHit struct {
+	BoundingObject INode // The BoundingObject that was hit.
+	// The root object of the tree containing the BoundingObject that was hit; like with Collisions, this can be the same or different
+	// from SweepHit.BoundingObject, depending on which objects were tested against.
+	Root INode
+
+	// Time is how far along the movement the swept object can go before it hits the BoundingObject, from 0 (not at all, which
+	// includes when it's overlapping the BoundingObject to begin with) to 1 (all the way).
+	Time float64
+
+	Position     vector.Vector // The world position of the swept object at the time of impact.
+	ContactPoint vector.Vector // The point of contact with the BoundingObject at the time of impact, in world space.
+	Normal       vector.Vector // The normal of the contact at the time of impact, in world space, pointing back towards the swept object.
+}
+
+// sweepShape is a convex polytope in world space (a point, line segment, polygon, or polyhedron), expanded outwards in all directions
+// by a radius; every kind of BoundingObject can be described by one or more of these, which sweeps are solved with.
+type sweepShape struct {
+	vertices []vector.Vector
+	edges    [][2]int
+	faces    [][]int // The vertex indices of each face, in order around the face.
+	radius   float64
+
+	min, max vector.Vector // The corners of the shape's bounding box, including its radius.
+}
+
+// newSweepShape returns a sweepShape made of the given vertices, edges, faces, and radius, with its bounding box calculated.
+func newSweepShape(vertices []vector.Vector, edges [][2]int, faces [][]int, radius float64) sweepShape {
+
+	shape := sweepShape{
+		vertices: vertices,
+		edges:    edges,
+		faces:    faces,
+		radius:   radius,
+		min:      vector.Vector{math.Inf(1), math.Inf(1), math.Inf(1)},
+		max:      vector.Vector{math.Inf(-1), math.Inf(-1), math.Inf(-1)},
+	}
+
+	for _, v := range vertices {
+		for i := 0; i < 3; i++ {
+			shape.min[i] = math.Min(shape.min[i], v[i]-radius)
+			shape.max[i] = math.Max(shape.max[i], v[i]+radius)
+		}
+	}
+
+	return shape
+
+}
+
+var boxSweepEdges, boxSweepFaces = func() ([][2]int, [][]int) {
+
+	// The corners of a boxShape are ordered by their X, Y, and Z signs as bits (i.e. corner 0b101 is at +X, -Y, +Z).
+	edges := [][2]int{}
+	faces := [][]int{}
+
+	for axis := 0; axis < 3; axis++ {
+
+		bit := 4 >> axis
+		a, b := 4>>((axis+1)%3), 4>>((axis+2)%3)
+
+		for corner := 0; corner < 8; corner++ {
+			if corner&bit == 0 {
+				edges = append(edges, [2]int{corner, corner | bit})
+			}
+		}
+
+		for _, side := range []int{0, bit} {
+			faces = append(faces, []int{side, side | a, side | a | b, side | b})
+		}
+
+	}
+
+	return edges, faces
+
+}()
+
+// sweepShapes returns the sweepShapes that make up the given BoundingObject, in world space, or nil if it isn't a BoundingObject.
+func sweepShapes(node INode) []sweepShape {
+
+	switch bounds := node.(type) {
+
+	case *BoundingSphere:
+		return []sweepShape{newSweepShape([]vector.Vector{bounds.WorldPosition()}, [][2]int{{0, 0}}, nil, bounds.WorldRadius())}
+
+	case *BoundingCapsule:
+		return []sweepShape{newSweepShape([]vector.Vector{bounds.lineTop(), bounds.lineBottom()}, [][2]int{{0, 1}}, nil, bounds.WorldRadius())}
+
+	case *BoundingAABB:
+		return []sweepShape{newSweepShape(aabbShape(bounds).corners(), boxSweepEdges, boxSweepFaces, 0)}
+
+	case *BoundingOBB:
+		return []sweepShape{newSweepShape(bounds.shape().corners(), boxSweepEdges, boxSweepFaces, 0)}
+
+	case *BoundingTriangles:
+
+		transform := bounds.Transform()
+		shapes := make([]sweepShape, 0, len(bounds.Mesh.Triangles))
+
+		for _, tri := range bounds.Mesh.Triangles {
+			verts := []vector.Vector{
+				transform.MultVec(bounds.Mesh.VertexPositions[tri.ID*3]),
+				transform.MultVec(bounds.Mesh.VertexPositions[tri.ID*3+1]),
+				transform.MultVec(bounds.Mesh.VertexPositions[tri.ID*3+2]),
+			}
+			shapes = append(shapes, newSweepShape(verts, [][2]int{{0, 1}, {1, 2}, {2, 0}}, [][]int{{0, 1, 2}}, 0))
+		}
+
+		return shapes
+
+	case *BoundingConvex:
+
+		edges := [][2]int{}
+		faces := make([][]int, 0, len(bounds.faces))
+		added := map[[2]int]bool{}
+
+		for _, face := range bounds.faces {
+			faces = append(faces, []int{face[0], face[1], face[2]})
+			for i := range face {
+				edge := [2]int{face[i], face[(i+1)%3]}
+				if edge[0] > edge[1] {
+					edge[0], edge[1] = edge[1], edge[0]
+				}
+				if !added[edge] {
+					added[edge] = true
+					edges = append(edges, edge)
+				}
+			}
+		}
+
+		return []sweepShape{newSweepShape(bounds.Points(), edges, faces, 0)}
+
+	}
+
+	return nil
+
+}
+
+// sweepContact is a point of contact found while sweeping one sweepShape against another.
+type sweepContact struct {
+	time   float64       // How far along the movement the contact happens, from 0 to 1.
+	normal vector.Vector // The normal of the contact, pointing from the target towards the swept shape.
+	point  vector.Vector // The point of contact on the target's polytope (i.e. not including its radius).
+	found  bool
+
+	// What kind of features touched; 0 for a vertex against a face, 1 for an edge against an edge, and 2 for a vertex or edge against
+	// another vertex or edge's rounded surface.
+	kind int
+}
+
+// consider replaces the sweepContact with the other one if the other one happens first, returning if it did. On a tie, faces win
+// over edges (so that a shape already touching both a face and one of its edges at the start of the sweep gets the face's normal),
+// and then the contact that faces the movement most directly wins.
+func (contact *sweepContact) consider(other sweepContact, movement vector.Vector) bool {
+
+	if !other.found {
+		return false
+	}
+
+	replace := !contact.found || other.time < contact.time-1e-9
+
+	if !replace && other.time < contact.time+1e-9 {
+		replace = other.kind < contact.kind || (other.kind == contact.kind && dot(other.normal, movement) < dot(contact.normal, movement))
+	}
+
+	if replace {
+		*contact = other
+	}
+
+	return replace
+
+}
+
+// sweepPointSphere returns when the point moving from origin by movement (over a time of 0 to 1) first comes within the radius of the
+// center.
+func sweepPointSphere(origin, movement, center vector.Vector, radius float64) (float64, bool) {
+
+	m := origin.Sub(center)
+	a := dot(movement, movement)
+	b := dot(m, movement)
+	c := dot(m, m) - radius*radius
+
+	// A point that's already inside of the sphere only hits it if it's moving further in.
+	if a == 0 || b >= 0 {
+		return 0, false
+	} else if c <= 0 {
+		return 0, true
+	}
+
+	disc := b*b - a*c
+	if disc < 0 {
+		return 0, false
+	}
+
+	if t := (-b - math.Sqrt(disc)) / a; t <= 1 {
+		return t, true
+	}
+
+	return 0, false
+
+}
+
+// sweepPointCylinder returns when the point moving from origin by movement (over a time of 0 to 1) first comes within the radius of
+// the line segment from start to end, not counting the segment's ends (which sweepPointCapsule() tests as spheres).
+func sweepPointCylinder(origin, movement, start, end vector.Vector, radius float64) (float64, bool) {
+
+	axis := end.Sub(start)
+	lengthSquared := dot(axis, axis)
+
+	if lengthSquared == 0 {
+		return 0, false
+	}
+
+	// Only what's perpendicular to the axis matters for hitting the cylinder's side.
+	m := origin.Sub(start)
+	mAlong := dot(m, axis) / lengthSquared
+	moveAlong := dot(movement, axis) / lengthSquared
+	mPerp := m.Sub(axis.Scale(mAlong))
+	movePerp := movement.Sub(axis.Scale(moveAlong))
+
+	a := dot(movePerp, movePerp)
+	b := dot(mPerp, movePerp)
+	c := dot(mPerp, mPerp) - radius*radius
+
+	// As with spheres, a point that's already inside of the cylinder only hits it if it's moving further in.
+	if b >= 0 || (c > 0 && a < 1e-12) {
+		return 0, false
+	}
+
+	t := 0.0
+
+	if c > 0 {
+
+		disc := b*b - a*c
+		if disc < 0 {
+			return 0, false
+		}
+
+		t = (-b - math.Sqrt(disc)) / a
+		if t > 1 {
+			return 0, false
+		}
+
+	}
+
+	if along := mAlong + moveAlong*t; along < 0 || along > 1 {
+		return 0, false
+	}
+
+	return t, true
+
+}
+
+// sweepPointCapsule returns the contact of the point moving from origin by movement (over a time of 0 to 1) with the capsule of the
+// given radius around the line segment from start to end.
+func sweepPointCapsule(origin, movement, start, end vector.Vector, radius float64) sweepContact {
+
+	contact := sweepContact{time: math.Inf(1), kind: 2}
+
+	if t, ok := sweepPointCylinder(origin, movement, start, end, radius); ok {
+		contact.time, contact.found = t, true
+	}
+
+	for _, tip := range []vector.Vector{start, end} {
+		if t, ok := sweepPointSphere(origin, movement, tip, radius); ok && t < contact.time {
+			contact.time, contact.found = t, true
+		}
+	}
+
+	if contact.found {
+		position := origin.Add(movement.Scale(contact.time))
+		contact.point = closestPointOnSegment(position, start, end)
+		contact.normal = position.Sub(contact.point).Unit()
+	}
+
+	return contact
+
+}
+
+// sweepPointPolygon returns the contact of the point moving from origin by movement (over a time of 0 to 1) with the flat, convex
+// polygon with the given vertices, expanded outwards by radius along the polygon's normal. Only the polygon's faces are tested;
+// its edges and corners are tested as capsules. The polygon can face (and be wound) either way.
+func sweepPointPolygon(origin, movement vector.Vector, polygon []vector.Vector, radius float64) sweepContact {
+
+	normal := gjkCross(polygon[1].Sub(polygon[0]), polygon[2].Sub(polygon[0]))
+	if mag := normal.Magnitude(); mag > 1e-12 {
+		normal = normal.Scale(1 / mag)
+	} else {
+		return sweepContact{}
+	}
+
+	// The point has to move towards the polygon to hit its face.
+	distance := dot(normal, origin.Sub(polygon[0]))
+
+	side := 1.0
+	if distance < 0 {
+		side = -1
+	}
+
+	approach := dot(normal, movement)
+	if side*approach >= 0 {
+		return sweepContact{}
+	}
+
+	// A point that starts out within the expanded polygon's slab already (which the collision test done before sweeping can miss
+	// by a hair) hits it right away.
+	t := math.Max(0, (side*radius-distance)/approach)
+	if t > 1 {
+		return sweepContact{}
+	}
+
+	position := origin.Add(movement.Scale(t))
+	point := position.Sub(normal.Scale(dot(normal, position.Sub(polygon[0]))))
+
+	for i := range polygon {
+		next := polygon[(i+1)%len(polygon)]
+		if dot(gjkCross(next.Sub(polygon[i]), point.Sub(polygon[i])), normal) < -1e-9 {
+			return sweepContact{}
+		}
+	}
+
+	return sweepContact{time: t, normal: normal.Scale(side), point: point, found: true}
+
+}
+
+// sweepEdgeEdge returns the first contact of the edge from a to b moving by movement with the edge from start to end, when the edges
+// come within radius of each other. Relative to each other, this is the same as a point moving from the origin hitting the
+// parallelogram of the differences between points on the two edges (expanded by radius), which is how it's solved.
+func sweepEdgeEdge(a, b, movement, start, end vector.Vector, radius float64) sweepContact {
+
+	origin := vector.Vector{0, 0, 0}
+	corner := start.Sub(a)
+	edge := end.Sub(start)
+	swept := a.Sub(b)
+
+	contact := sweepContact{}
+
+	// The parallelogram's face; a point at corner + edge * v + swept * u corresponds to the point at start + edge * v on the target.
+	if face := gjkCross(edge, swept); dot(face, face) > 1e-12*dot(edge, edge)*dot(swept, swept) {
+
+		polygon := []vector.Vector{corner, corner.Add(edge), corner.Add(edge).Add(swept), corner.Add(swept)}
+
+		if c := sweepPointPolygon(origin, movement, polygon, radius); c.found {
+
+			// Solve for how far along the target edge the contact is.
+			offset := c.point.Sub(corner)
+			ee, es, ss := dot(edge, edge), dot(edge, swept), dot(swept, swept)
+			oe, os := dot(offset, edge), dot(offset, swept)
+			v := (oe*ss - os*es) / (ee*ss - es*es)
+
+			c.point = start.Add(edge.Scale(v))
+			c.kind = 1
+			contact.consider(c, movement)
+
+		}
+
+	}
+
+	// The parallelogram's sides: the ends of the swept edge against the target edge, and the ends of the target edge against the
+	// swept edge.
+	sides := [][2]vector.Vector{{corner, corner.Add(edge)}}
+	if dot(swept, swept) > 0 {
+		sides = append(sides, [2]vector.Vector{corner.Add(swept), corner.Add(swept).Add(edge)})
+	}
+
+	for _, side := range sides {
+		if c := sweepPointCapsule(origin, movement, side[0], side[1], radius); c.found {
+			c.point = c.point.Sub(side[0]).Add(start)
+			contact.consider(c, movement)
+		}
+	}
+
+	if dot(swept, swept) > 0 {
+		for _, target := range []vector.Vector{start, end} {
+			side := target.Sub(a)
+			if c := sweepPointCapsule(origin, movement, side, side.Add(swept), radius); c.found {
+				c.point = target.Clone()
+				contact.consider(c, movement)
+			}
+		}
+	}
+
+	return contact
+
+}
+
+// sweepShapeShape returns the first contact of the swept sweepShape moving by movement with the target sweepShape. The first contact
+// between two convex shapes is always either a vertex of one against a face of the other, or an edge of one against an edge of the
+// other (which includes vertices against edges or other vertices, as the edges' ends).
+func sweepShapeShape(swept sweepShape, movement vector.Vector, target sweepShape) sweepContact {
+
+	radius := swept.radius + target.radius
+	contact := sweepContact{}
+
+	polygon := func(shape sweepShape, face []int) []vector.Vector {
+		verts := make([]vector.Vector, len(face))
+		for i, index := range face {
+			verts[i] = shape.vertices[index]
+		}
+		return verts
+	}
+
+	for _, face := range target.faces {
+		verts := polygon(target, face)
+		for _, vertex := range swept.vertices {
+			contact.consider(sweepPointPolygon(vertex, movement, verts, radius), movement)
+		}
+	}
+
+	// The target's vertices hitting the swept shape's faces is the same as them moving the other way into a still swept shape.
+	reverse := movement.Invert()
+
+	for _, face := range swept.faces {
+		verts := polygon(swept, face)
+		for _, vertex := range target.vertices {
+			if c := sweepPointPolygon(vertex, reverse, verts, radius); c.found {
+				c.normal = c.normal.Invert()
+				c.point = vertex.Clone()
+				contact.consider(c, movement)
+			}
+		}
+	}
+
+	for _, sweptEdge := range swept.edges {
+		for _, targetEdge := range target.edges {
+			contact.consider(sweepEdgeEdge(
+				swept.vertices[sweptEdge[0]], swept.vertices[sweptEdge[1]], movement,
+				target.vertices[targetEdge[0]], target.vertices[targetEdge[1]], radius,
+			), movement)
+		}
+	}
+
+	if contact.found {
+		// The contact point is moved from the target's polytope out to its surface.
+		contact.point = contact.point.Add(contact.normal.Scale(target.radius))
+	}
+
+	return contact
+
+}
+
+// sweep moves the given BoundingObject along the given movement vector in world space, returning the first hit against the
+// BoundingObjects in the trees of the given INodes, or nil if there isn't one. The time of impact is solved for exactly rather than
+// by stepping along the movement, so nothing can be tunneled through, no matter how thin or fast. The BoundingObject itself isn't moved.
+func sweep(node INode, moveVec vector.Vector, others ...INode) *SweepHit {
+
+	start := node.WorldPosition()
+
+	// Anything the BoundingObject's already overlapping is hit right away.
+	if collisions := commonCollisionTest(node, 0, 0, 0, others...); len(collisions) > 0 {
+
+		// The collision that's deepest is the one that's most in the way.
+		deepest := collisions[0]
+		for _, collision := range collisions[1:] {
+			if collision.AverageMTV().Magnitude() > deepest.AverageMTV().Magnitude() {
+				deepest = collision
+			}
+		}
+
+		// The MTV can be tiny when just touching (shorter than vector.Unit() will normalize), so it's scaled by hand.
+		normal := deepest.AverageMTV()
+		if mag := normal.Magnitude(); mag > 0 {
+			normal = normal.Scale(1 / mag)
+		} else {
+			normal = deepest.AverageNormal().Unit()
+		}
+
+		return &SweepHit{
+			BoundingObject: deepest.BoundingObject,
+			Root:           deepest.Root,
+			Time:           0,
+			Position:       start,
+			ContactPoint:   deepest.AverageContactPoint(),
+			Normal:         normal,
+		}
+
+	}
+
+	distance := moveVec.Magnitude()
+	if distance == 0 {
+		return nil
+	}
+
+	swept := sweepShapes(node)
+	bounds := node.(IBoundingObject)
+
+	var hit *SweepHit
+	first := sweepContact{}
+
+	var test func(checking, root INode)
+
+	test = func(checking, root INode) {
+
+		if c, ok := checking.(IBoundingObject); ok && checking != node && bounds.CollidesWith(c) {
+
+			for _, target := range sweepShapes(checking) {
+
+				for _, shape := range swept {
+
+					// Skip the shapes whose bounding boxes the swept shape's bounding box never touches along the way.
+					if !sweepBoundsOverlap(shape, moveVec, target) {
+						continue
+					}
+
+					if contact := sweepShapeShape(shape, moveVec, target); first.consider(contact, moveVec) {
+						hit = &SweepHit{BoundingObject: checking, Root: root, ContactPoint: contact.point, Normal: contact.normal, Time: contact.time}
+					}
+
+				}
+
+			}
+
+		}
+
+		for _, child := range checking.Children() {
+			test(child, root)
+		}
+
+	}
+
+	for _, o := range others {
+		test(o, o)
+	}
+
+	if hit != nil {
+		hit.Time = math.Max(0, hit.Time-sweepSkin/distance)
+		hit.Position = start.Add(moveVec.Scale(hit.Time))
+	}
+
+	return hit
+
+}
+
+// sweepBoundsOverlap returns if the bounding box of the swept sweepShape moving by movement overlaps the target sweepShape's bounding box.
+func sweepBoundsOverlap(swept sweepShape, movement vector.Vector, target sweepShape) bool {
+
+	for i := 0; i < 3; i++ {
+		if math.Min(swept.min[i], swept.min[i]+movement[i]) > target.max[i] || math.Max(swept.max[i], swept.max[i]+movement[i]) < target.min[i] {
+			return false
+		}
+	}
+
+	return true
+
+}
+
+// Sweep moves the BoundingSphere along the given movement vector (in world space), returning where it first hits any of the
+// BoundingObjects in the trees of the INodes provided in others, or nil if it doesn't hit anything; this is useful for fast-moving
+// projectiles and for moving characters without tunneling through thin walls. The BoundingSphere isn't actually moved. If it's
+// already overlapping something, the returned SweepHit's Time is 0.
+func (sphere *BoundingSphere) Sweep(moveVec vector.Vector, others ...INode) *SweepHit {
+	return sweep(sphere, moveVec, others...)
+}
+
+// Sweep moves the BoundingCapsule along the given movement vector (in world space), returning where it first hits any of the
+// BoundingObjects in the trees of the INodes provided in others, or nil if it doesn't hit anything; this is useful for moving
+// characters without tunneling through thin walls. The BoundingCapsule isn't actually moved. If it's already overlapping something,
+// the returned SweepHit's Time is 0.
+func (capsule *BoundingCapsule) Sweep(moveVec vector.Vector, others ...INode) *SweepHit {
+	return sweep(capsule, moveVec, others...)
+}
+
+// Sweep moves the BoundingAABB along the given movement vector (in world space), returning where it first hits any of the
+// BoundingObjects in the trees of the INodes provided in others, or nil if it doesn't hit anything. The BoundingAABB isn't actually
+// moved. If it's already overlapping something, the returned SweepHit's Time is 0.
+func (box *BoundingAABB) Sweep(moveVec vector.Vector, others ...INode) *SweepHit {
+	return sweep(box, moveVec, others...)
+}
+
+// Sweep moves the BoundingOBB along the given movement vector (in world space), returning where it first hits any of the
+// BoundingObjects in the trees of the INodes provided in others, or nil if it doesn't hit anything. The BoundingOBB isn't actually
+// moved. If it's already overlapping something, the returned SweepHit's Time is 0.
+func (box *BoundingOBB) Sweep(moveVec vector.Vector, others ...INode) *SweepHit {
+	return sweep(box, moveVec, others...)
+}
+
+// Sweep moves the BoundingTriangles along the given movement vector (in world space), returning where it first hits any of the
+// BoundingObjects in the trees of the INodes provided in others, or nil if it doesn't hit anything. The BoundingTriangles isn't
+// actually moved. If it's already overlapping something, the returned SweepHit's Time is 0. Note that every triangle of the Mesh is
+// swept, so this is much slower than sweeping the simpler BoundingObjects.
+func (bt *BoundingTriangles) Sweep(moveVec vector.Vector, others ...INode) *SweepHit {
+	return sweep(bt, moveVec, others...)
+}
+
+// MoveWithCollision moves the given BoundingObject along the given movement vector (in world space), stopping it where it first
+// hits any of the BoundingObjects in the trees of the INodes provided in others. Unlike moving the BoundingObject and then testing for
+// collisions, this sweeps it from where it was to where it's going (continuous collision detection), so fast-moving objects like
+// projectiles can't tunnel through thin walls. MoveWithCollision returns what the BoundingObject hit, or nil if it moved all the
+// way without hitting anything.
+func MoveWithCollision(boundingObject IBoundingObject, moveVec vector.Vector, others ...INode) *SweepHit {
+
+	node := boundingObject.(INode)
+	hit := boundingObject.Sweep(moveVec, others...)
+
+	if hit != nil {
+		node.SetWorldPositionVec(hit.Position)
+	} else {
+		node.SetWorldPositionVec(node.WorldPosition().Add(moveVec))
+	}
+
+	return hit
+
+}
+
+// SphereCast sweeps a sphere with the given radius from the from position to the to position (in world space), returning where it
+// first hits any of the BoundingObjects in the trees of the INodes provided in others, or nil if it doesn't hit anything (see
+// BoundingSphere.Sweep()). The time of impact is solved for exactly rather than by testing the sphere at steps along the way, so
+// the cast can't pass through anything, however thin or far away it is; it stops just short of (sweepSkin, or 0.0001 units
+// from) what it hits. A sphere that starts out overlapping something hits it at a Time of 0.
+func SphereCast(from, to vector.Vector, radius float64, others ...INode) *SweepHit {
+	sphere := NewBoundingSphere("sphere cast", radius)
+	sphere.SetLocalPositionVec(from)
+	return sphere.Sweep(to.Sub(from), others...)
+}
@@ -0,0 +1,227 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kvartborg/vector"
+)
+
+func TestSweep(t *testing.T) {
+
+	scene := NewScene("sweep test")
+
+	wall := NewBoundingAABB("wall", 2, 2, 0.1)
+	wall.SetLocalPosition(0, 0, -5)
+
+	ground := NewBoundingTriangles("ground", NewPlane(), 0)
+	ground.SetLocalPosition(0, -2, 0)
+	ground.SetLocalScale(10, 1, 10)
+
+	scene.Root.AddChildren(wall, ground)
+
+	sphere := NewBoundingSphere("sphere", 0.5)
+
+	hit := sphere.Sweep(vector.Vector{0, 0, -10}, scene.Root)
+
+	if hit == nil || hit.BoundingObject != wall {
+		t.Fatalf("the sphere should hit the wall")
+	}
+
+	if math.Abs(hit.Time-0.445) > 1e-4 || hit.Normal.Sub(vector.Vector{0, 0, 1}).Magnitude() > 1e-6 {
+		t.Fatalf("the sphere should hit the wall 44.5%% of the way along, facing +Z; got %f, %v", hit.Time, hit.Normal)
+	}
+
+	if hit.Position.Sub(vector.Vector{0, 0, -4.45}).Magnitude() > 1e-3 {
+		t.Fatalf("the sphere should stop just short of the wall, but stopped at %v", hit.Position)
+	}
+
+	if pos := sphere.WorldPosition(); pos.Magnitude() != 0 {
+		t.Fatalf("sweeping shouldn't move the sphere, but it's at %v", pos)
+	}
+
+	// Even very fast movement doesn't tunnel through a thin wall.
+	wall.SetDimensions(2, 2, 0.001)
+
+	if hit := sphere.Sweep(vector.Vector{0, 0, -500}, scene.Root); hit == nil || hit.BoundingObject != wall {
+		t.Fatalf("the sphere shouldn't tunnel through the thin wall")
+	}
+
+	if hit := sphere.Sweep(vector.Vector{0, 0, 10}, scene.Root); hit != nil {
+		t.Fatalf("the sphere shouldn't hit anything moving away from the wall")
+	}
+
+	capsule := NewBoundingCapsule("capsule", 2, 0.5)
+	capsule.SetLocalPosition(5, 0, 0)
+
+	hit = capsule.Sweep(vector.Vector{0, -10, 0}, scene.Root)
+
+	if hit == nil || hit.BoundingObject != ground || math.Abs(hit.Time-0.1) > 1e-4 || hit.Normal.Sub(vector.Vector{0, 1, 0}).Magnitude() > 1e-3 {
+		t.Fatalf("the capsule should land on the ground 10%% of the way along, facing up; got %v", hit)
+	}
+
+	// Objects already overlapping something are stuck from the start.
+	capsule.SetLocalPosition(5, -1.5, 0)
+
+	if hit := capsule.Sweep(vector.Vector{0, -10, 0}, scene.Root); hit == nil || hit.Time != 0 {
+		t.Fatalf("the capsule should already be touching the ground")
+	}
+
+	if hit := SphereCast(vector.Vector{0, 0, 0}, vector.Vector{0, -10, 0}, 1, scene.Root); hit == nil || hit.BoundingObject != ground || math.Abs(hit.Time-0.1) > 1e-4 {
+		t.Fatalf("the sphere cast should hit the ground 10%% of the way along; got %v", hit)
+	}
+
+}
+
+func TestSweepLeavesNodeInPlace(t *testing.T) {
+
+	scene := NewScene("sweep in place test")
+
+	wall := NewBoundingAABB("wall", 4, 4, 0.1)
+	wall.SetLocalPosition(0, 0, -5)
+
+	// The sphere is swept from under a moved and scaled parent, and is in the tree that it's swept against.
+	player := NewNode("player")
+	player.SetLocalPosition(1, 0, 0)
+	player.SetLocalScale(2, 2, 2)
+
+	sphere := NewBoundingSphere("sphere", 0.25)
+	player.AddChildren(sphere)
+
+	scene.Root.AddChildren(wall, player)
+
+	transform := sphere.Transform()
+
+	hit := sphere.Sweep(vector.Vector{0, 0, -10}, scene.Root)
+
+	if hit == nil || hit.BoundingObject != wall {
+		t.Fatalf("the sphere should hit the wall, rather than itself; got %v", hit)
+	}
+
+	// The sweep starts from the sphere's world position, and its world radius is 0.5.
+	if hit.Position.Sub(vector.Vector{1, 0, -4.45}).Magnitude() > 1e-3 {
+		t.Fatalf("the sphere should stop just short of the wall, but stopped at %v", hit.Position)
+	}
+
+	// The sphere's Node is never moved, so its transform isn't even rebuilt.
+	if sphere.isTransformDirty || !sphere.Transform().Equals(transform) || sphere.Parent() != player {
+		t.Fatalf("sweeping shouldn't touch the sphere's transform")
+	}
+
+	if hit := SphereCast(vector.Vector{1, 0, 0}, vector.Vector{1, 0, -10}, 0.5, scene.Root); hit == nil || hit.BoundingObject != sphere || hit.Time != 0 {
+		t.Fatalf("a sphere cast from the sphere's position should start off overlapping it; got %v", hit)
+	}
+
+}
+
+func TestSweepTimeOfImpact(t *testing.T) {
+
+	ground := NewBoundingTriangles("ground", NewPlane(), 0)
+	ground.SetLocalScale(10, 1, 10)
+
+	rail := NewBoundingAABB("rail", 0.1, 0.1, 4)
+
+	ball := NewBoundingSphere("ball", 0.5)
+	ball.SetLocalPosition(5, 0, 0)
+
+	wall := NewBoundingAABB("wall", 4, 4, 0.001)
+	wall.SetLocalPosition(0, 0, -5)
+
+	// A capsule lying on its side, so that the middle of it lands across the rail.
+	lying := NewBoundingCapsule("lying capsule", 2, 0.5)
+	lying.SetLocalRotation(NewMatrix4Rotate(0, 0, 1, math.Pi/2))
+
+	turned := NewBoundingOBB("turned box", 1, 1, 1)
+	turned.SetLocalRotation(NewMatrix4Rotate(0, 1, 0, math.Pi/4))
+
+	tests := []struct {
+		mover    IBoundingObject
+		start    vector.Vector
+		movement vector.Vector
+		target   INode
+		time     float64
+		normal   vector.Vector
+	}{
+		// A sphere falling very fast onto a flat plane.
+		{NewBoundingSphere("sphere", 0.5), vector.Vector{0, 5, 0}, vector.Vector{0, -1000, 0}, ground, 4.5 / 1000, vector.Vector{0, 1, 0}},
+		// The capsule's side (rather than either of its ends) landing on the rail's top edges.
+		{lying, vector.Vector{0, 3, 0}, vector.Vector{0, -10, 0}, rail, (3 - 0.55) / 10, vector.Vector{0, 1, 0}},
+		// The box's corner hitting the sphere.
+		{turned, vector.Vector{0, 0, 0}, vector.Vector{10, 0, 0}, ball, (5 - 0.5 - math.Sqrt(0.5)) / 10, vector.Vector{-1, 0, 0}},
+		// A convex cube hitting a very thin wall.
+		{NewBoundingConvex("convex", NewCube(), 0), vector.Vector{0, 0, 0}, vector.Vector{0, 0, -10}, wall, (5 - 0.0005 - 1) / 10, vector.Vector{0, 0, 1}},
+		// A flat-bottomed triangle mesh landing on a flat plane.
+		{NewBoundingTriangles("cube", NewCube(), 0), vector.Vector{0, 5, 0}, vector.Vector{0, -10, 0}, ground, 0.4, vector.Vector{0, 1, 0}},
+	}
+
+	for _, test := range tests {
+
+		node := test.mover.(INode)
+		node.SetLocalPositionVec(test.start)
+
+		hit := test.mover.Sweep(test.movement, test.target)
+
+		if hit == nil || hit.BoundingObject != test.target {
+			t.Fatalf("%s should hit %s", node.Name(), test.target.Name())
+		}
+
+		// Sweeps stop just short of the time of impact.
+		if expected := test.time - sweepSkin/test.movement.Magnitude(); math.Abs(hit.Time-expected) > 1e-9 {
+			t.Fatalf("%s should hit %s at a time of %f, but hit it at %f", node.Name(), test.target.Name(), expected, hit.Time)
+		}
+
+		if hit.Normal.Sub(test.normal).Magnitude() > 1e-9 {
+			t.Fatalf("%s should hit %s with a normal of %v, but got %v", node.Name(), test.target.Name(), test.normal, hit.Normal)
+		}
+
+		if hit.Position.Sub(test.start.Add(test.movement.Scale(hit.Time))).Magnitude() > 1e-9 {
+			t.Fatalf("%s's hit position should be where it'd be at the time of impact, but it's %v", node.Name(), hit.Position)
+		}
+
+		// At the hit's position, the mover is just shy of touching the target.
+		node.SetLocalPositionVec(hit.Position)
+
+		if test.mover.Colliding(test.target.(IBoundingObject)) {
+			t.Fatalf("%s shouldn't be overlapping %s at the hit's position", node.Name(), test.target.Name())
+		}
+
+	}
+
+}
+
+func TestMoveWithCollision(t *testing.T) {
+
+	scene := NewScene("move with collision test")
+
+	wall := NewBoundingAABB("wall", 4, 4, 0.001)
+	wall.SetLocalPosition(0, 0, -5)
+	scene.Root.AddChildren(wall)
+
+	movers := []IBoundingObject{
+		NewBoundingSphere("sphere", 0.5),
+		NewBoundingAABB("aabb", 1, 1, 1),
+		NewBoundingOBB("obb", 1, 1, 1),
+		NewBoundingTriangles("cube", NewCube(), 0),
+	}
+
+	movers[3].(INode).SetLocalScale(0.5, 0.5, 0.5)
+
+	for _, mover := range movers {
+
+		node := mover.(INode)
+
+		if hit := MoveWithCollision(mover, vector.Vector{0, 0, -500}, scene.Root); hit == nil || hit.BoundingObject != wall {
+			t.Fatalf("%s shouldn't tunnel through the thin wall", node.Name())
+		}
+
+		if pos := node.WorldPosition(); math.Abs(pos[2]+4.5) > 1e-3 {
+			t.Fatalf("%s should have stopped just short of the wall, but stopped at %v", node.Name(), pos)
+		}
+
+		if hit := MoveWithCollision(mover, vector.Vector{0, 0, 2}, scene.Root); hit != nil || math.Abs(node.WorldPosition()[2]+2.5) > 1e-3 {
+			t.Fatalf("%s should move all the way when it doesn't hit anything", node.Name())
+		}
+
+	}
+
+}
@@ -20,12 +20,21 @@ objectTypes = [
     ("GRID", "Grid", "A grid object; not visualized or 'physically present'. The vertices in Blender become grid points in Tetra3D; the edges become their connections", 0, 1),
 ]
 
+emptyTypes = [
+    ("NODE", "Node", "A standard, empty node", 0, 0),
+    ("PATHGRAPH", "Path Graph", "A path graph, used for pathfinding along hand-placed routes. Empties parented to it with a type of Waypoint become its waypoints in Tetra3D", 0, 1),
+    ("WAYPOINT", "Waypoint", "A waypoint in a path graph; this should be parented to a Path Graph empty. Add links to other waypoints to set where can be travelled to from this waypoint", 0, 2),
+    ("TRIGGER", "Trigger Volume", "A trigger volume, which calls functions when nodes enter or exit it. Its bounds define its region", 0, 3),
+]
+
 boundsTypes = [
     ("NONE", "No Bounds", "No collision will be created for this object.", 0, 0),
     ("AABB", "AABB", "An AABB (axis-aligned bounding box). If the size isn't customized, it will be big enough to fully contain the mesh of the current object. Currently buggy when resolving intersections between AABB or other Triangle Nodes", 0, 1),
     ("CAPSULE", "Capsule", "A capsule, which can rotate. If the radius and height are not set, it will have a radius and height to fully contain the current object", 0, 2),
     ("SPHERE", "Sphere", "A sphere. If the radius is not custom set, it will have a large enough radius to fully contain the provided object", 0, 3),
     ("TRIANGLES", "Triangle Mesh", "A triangle mesh bounds type. Only works on mesh-type objects (i.e. an Empty won't generate a BoundingTriangles). Accurate, but slow. Currently buggy when resolving intersections between AABB or other Triangle Nodes", 0, 4),
+    ("OBB", "OBB", "An OBB (oriented bounding box), which rotates with the object. If the size isn't customized, it will be big enough to fully contain the mesh of the current object", 0, 5),
+    ("CONVEX", "Convex Hull", "A convex hull, shrink-wrapped around the mesh of the current object. Only works on mesh-type objects. Faster than a Triangle Mesh and can tell when something is fully inside of it, but concave parts of the mesh are filled in", 0, 6),
 ]
 
 gltfExportTypes = [
@@ -81,6 +90,12 @@ class t3dGamePropertyItem__(bpy.types.PropertyGroup):
     # valueVector4D: bpy.props.FloatVectorProperty(name = "", description="The 4D vector value of the property")
     
 
+class t3dWaypointLinkItem__(bpy.types.PropertyGroup):
+
+    target: bpy.props.PointerProperty(name = "", type=bpy.types.Object, description="The waypoint to link to")
+    twoWay: bpy.props.BoolProperty(name = "Two-Way", description="Whether the linked waypoint also links back to this one", default=True)
+
+
 class OBJECT_OT_tetra3dAddProp(bpy.types.Operator):
     bl_idname = "object.tetra3daddprop"
     bl_label = "Add Game Property"
@@ -110,6 +125,28 @@ class OBJECT_OT_tetra3dDeleteProp(bpy.types.Operator):
         target.t3dGameProperties__.remove(self.index)
         return {'FINISHED'}
 
+class OBJECT_OT_tetra3dAddWaypointLink(bpy.types.Operator):
+    bl_idname = "object.tetra3daddwaypointlink"
+    bl_label = "Add Waypoint Link"
+    bl_description= "Adds a link from the currently selected waypoint to another waypoint"
+    bl_options = {'REGISTER', 'UNDO'}
+
+    def execute(self, context):
+        context.object.t3dWaypointLinks__.add()
+        return {'FINISHED'}
+
+class OBJECT_OT_tetra3dDeleteWaypointLink(bpy.types.Operator):
+    bl_idname = "object.tetra3ddeletewaypointlink"
+    bl_label = "Delete Waypoint Link"
+    bl_description= "Deletes a link from the currently selected waypoint"
+    bl_options = {'REGISTER', 'UNDO'}
+
+    index : bpy.props.IntProperty()
+
+    def execute(self, context):
+        context.object.t3dWaypointLinks__.remove(self.index)
+        return {'FINISHED'}
+
 class OBJECT_OT_tetra3dReorderProps(bpy.types.Operator):
     bl_idname = "object.tetra3dreorderprops"
     bl_label = "Re-order Game Property"
@@ -281,10 +318,27 @@ class OBJECT_PT_tetra3d(bpy.types.Panel):
             row.label(text="Object Type: ")
             row.prop(context.object, "t3dObjectType__", expand=True)
 
+        if context.object.type == "EMPTY":
+            box = self.layout.box()
+            row = box.row()
+            row.label(text="Empty Type: ")
+            row.prop(context.object, "t3dEmptyType__", expand=True)
+
+            if context.object.t3dEmptyType__ == 'WAYPOINT':
+                row = box.row()
+                row.operator(OBJECT_OT_tetra3dAddWaypointLink.bl_idname, text="Add Waypoint Link", icon="PLUS")
+
+                for index, link in enumerate(context.object.t3dWaypointLinks__):
+                    row = box.row()
+                    row.prop(link, "target")
+                    row.prop(link, "twoWay")
+                    deleteOptions = row.operator(OBJECT_OT_tetra3dDeleteWaypointLink.bl_idname, text="", icon="TRASH")
+                    deleteOptions.index = index
+
         row = self.layout.row()
 
         
-        if context.object.t3dBoundsType__ == 'AABB':
+        if context.object.t3dBoundsType__ == 'AABB' or context.object.t3dBoundsType__ == 'OBB':
             row.prop(context.object, "t3dAABBCustomEnabled__")
             if context.object.t3dAABBCustomEnabled__:
                 row = self.layout.row()
@@ -550,6 +604,7 @@ def export():
     ogCollections = {} # What collection an object was originally pointing to
     collections = {} # What collections exist in the Blend file
     ogGrids = {}
+    waypointLinks = {}
 
     for collection in bpy.data.collections:
         if len(collection.objects) == 0:
@@ -663,6 +718,27 @@ def export():
                         obj["t3dPathPoints__"] = points
                         obj["t3dPathCyclic__"] = spline.use_cyclic_u or spline.use_cyclic_v
 
+                    # Record path graphs and the links between their waypoints
+                    if obj.type == "EMPTY":
+                        if obj.t3dEmptyType__ == 'PATHGRAPH':
+                            obj["t3dPathGraph__"] = True
+                        elif obj.t3dEmptyType__ == 'WAYPOINT':
+                            obj["t3dWaypoint__"] = True
+                            for link in obj.t3dWaypointLinks__:
+                                if link.target is None:
+                                    continue
+                                if obj.name not in waypointLinks:
+                                    waypointLinks[obj.name] = []
+                                if link.target.name not in waypointLinks[obj.name]:
+                                    waypointLinks[obj.name].append(link.target.name)
+                                if link.twoWay:
+                                    if link.target.name not in waypointLinks:
+                                        waypointLinks[link.target.name] = []
+                                    if obj.name not in waypointLinks[link.target.name]:
+                                        waypointLinks[link.target.name].append(obj.name)
+                        elif obj.t3dEmptyType__ == 'TRIGGER':
+                            obj["t3dTriggerVolume__"] = True
+
                     if obj.instance_type == "COLLECTION":
                         obj["t3dInstanceCollection__"] = obj.instance_collection.name
                         ogCollections[obj] = obj.instance_collection
@@ -670,6 +746,11 @@ def export():
                         # 2) will apply the collection's offset to the object's position for some reason (which is annoying because we use OpenGL's axes for positioning compared to Blender)
                         obj.instance_collection = None
 
+    # Links are gathered from all waypoints first, as two-way links add links to the waypoints they link to as well.
+    for obj in bpy.data.objects:
+        if obj.name in waypointLinks:
+            obj["t3dWaypointLinkNames__"] = waypointLinks[obj.name]
+
     # Gather marker information and put them into the actions.
     for action in bpy.data.actions:
         markers = []
@@ -721,6 +802,14 @@ def export():
                         del(obj["t3dPathPoints__"])
                     if "t3dPathCyclic__" in obj:
                         del(obj["t3dPathCyclic__"])
+                    if "t3dPathGraph__" in obj:
+                        del(obj["t3dPathGraph__"])
+                    if "t3dWaypoint__" in obj:
+                        del(obj["t3dWaypoint__"])
+                    if "t3dWaypointLinkNames__" in obj:
+                        del(obj["t3dWaypointLinkNames__"])
+                    if "t3dTriggerVolume__" in obj:
+                        del(obj["t3dTriggerVolume__"])
                     if obj.type == "MESH":
                         if "t3dVertexColorNames__" in obj.data:
                             del(obj.data["t3dVertexColorNames__"])
@@ -774,6 +863,8 @@ objectProps = {
     "t3dSphereCustomRadius__" : bpy.props.FloatProperty(name="Radius", description="Radius of the BoundingSphere node that will be created", min=0.0, default=1),
     "t3dGameProperties__" : bpy.props.CollectionProperty(type=t3dGamePropertyItem__),
     "t3dObjectType__" : bpy.props.EnumProperty(items=objectTypes, name="Object Type", description="The type of object this is"),
+    "t3dEmptyType__" : bpy.props.EnumProperty(items=emptyTypes, name="Empty Type", description="The type of node this empty becomes"),
+    "t3dWaypointLinks__" : bpy.props.CollectionProperty(type=t3dWaypointLinkItem__),
     "t3dShareProperties__" : bpy.props.BoolProperty(name="Copy Game Properties to Top-Level Instanced Nodes", description="If enabled, properties set on the collection instance are cloned to its instanced top-level nodes", default=False)
 }
 
@@ -872,6 +963,8 @@ def register():
     bpy.utils.register_class(OBJECT_OT_tetra3dAddProp)
     bpy.utils.register_class(OBJECT_OT_tetra3dDeleteProp)
     bpy.utils.register_class(OBJECT_OT_tetra3dReorderProps)
+    bpy.utils.register_class(OBJECT_OT_tetra3dAddWaypointLink)
+    bpy.utils.register_class(OBJECT_OT_tetra3dDeleteWaypointLink)
     bpy.utils.register_class(OBJECT_OT_tetra3dCopyProps)
     bpy.utils.register_class(OBJECT_OT_tetra3dCopyOneProperty)
     bpy.utils.register_class(OBJECT_OT_tetra3dClearProps)
@@ -882,6 +975,7 @@ def register():
     bpy.utils.register_class(EXPORT_OT_tetra3d)
     
     bpy.utils.register_class(t3dGamePropertyItem__)
+    bpy.utils.register_class(t3dWaypointLinkItem__)
 
     for propName, prop in objectProps.items():
         setattr(bpy.types.Object, propName, prop)
@@ -932,6 +1026,8 @@ def unregister():
     bpy.utils.unregister_class(OBJECT_OT_tetra3dAddProp)
     bpy.utils.unregister_class(OBJECT_OT_tetra3dDeleteProp)
     bpy.utils.unregister_class(OBJECT_OT_tetra3dReorderProps)
+    bpy.utils.unregister_class(OBJECT_OT_tetra3dAddWaypointLink)
+    bpy.utils.unregister_class(OBJECT_OT_tetra3dDeleteWaypointLink)
     bpy.utils.unregister_class(OBJECT_OT_tetra3dCopyProps)
     bpy.utils.unregister_class(OBJECT_OT_tetra3dCopyOneProperty)
     bpy.utils.unregister_class(OBJECT_OT_tetra3dClearProps)
@@ -942,6 +1038,7 @@ def unregister():
     bpy.utils.unregister_class(EXPORT_OT_tetra3d)
     
     bpy.utils.unregister_class(t3dGamePropertyItem__)
+    bpy.utils.unregister_class(t3dWaypointLinkItem__)
     
     for propName in objectProps.keys():
         delattr(bpy.types.Object, propName)
@@ -42,6 +42,38 @@ func NewTextureAnimationPixels(fps float64, image *ebiten.Image, framePositions
 
 }
 
+// NewTextureAnimationGrid creates a new TextureAnimation for a spritesheet laid out as a grid of equally-sized frames, with the given
+// number of columns and rows. fps is the frames per second for the animation. The frames are played from left to right, and then top
+// to bottom, starting with the top-left frame, which the animated vertices' UV values should cover. frameCount is the number of
+// frames to play, which is useful if the last row of the spritesheet isn't full; if it's 0 or less, all of the grid's frames are
+// played. NewTextureAnimationGrid will panic if given less than 1 column or row.
+func NewTextureAnimationGrid(fps float64, columns, rows, frameCount int) *TextureAnimation {
+
+	if columns < 1 || rows < 1 {
+		panic("Error: NewTextureAnimationGrid must take at least 1 column and 1 row.")
+	}
+
+	if frameCount <= 0 || frameCount > columns*rows {
+		frameCount = columns * rows
+	}
+
+	frames := make([]vector.Vector, 0, frameCount)
+
+	for i := 0; i < frameCount; i++ {
+		// V values increase upwards, so lower rows have negative offsets.
+		frames = append(frames, vector.Vector{
+			float64(i%columns) / float64(columns),
+			-float64(i/columns) / float64(rows),
+		})
+	}
+
+	return &TextureAnimation{
+		FPS:    fps,
+		Frames: frames,
+	}
+
+}
+
 // TexturePlayer is a struct that allows you to animate a collection of vertices' UV values using a TextureAnimation.
 type TexturePlayer struct {
 	OriginalOffsets map[int]vector.Vector // OriginalOffsets is a map of vertex indices to their base UV offsets. All animating happens relative to these values.
@@ -0,0 +1,40 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMaterialTextureAnimation(t *testing.T) {
+
+	mat := NewMaterial("flipbook")
+	mat.TextureAnimation = NewTextureAnimationGrid(10, 2, 2, 3)
+
+	if count := len(mat.TextureAnimation.Frames); count != 3 {
+		t.Fatalf("expected 3 frames, got %d", count)
+	}
+
+	tests := []struct {
+		dt   float64
+		u, v float64
+	}{
+		{0, 0, 0},
+		{0.1, 0.5, 0},
+		{0.1, 0, -0.5},
+		{0.1, 0, 0}, // Loops back around to the first frame
+	}
+
+	for i, test := range tests {
+		mat.UpdateTextureAnimation(test.dt)
+		if u, v := mat.textureAnimationOffset(); math.Abs(u-test.u) > 0.0001 || math.Abs(v-test.v) > 0.0001 {
+			t.Errorf("step %d: expected a UV offset of %f, %f, got %f, %f", i, test.u, test.v, u, v)
+		}
+	}
+
+	mat.TextureAnimationSpeed = -1
+	mat.UpdateTextureAnimation(0.1)
+	if u, v := mat.textureAnimationOffset(); u != 0 || v != -0.5 {
+		t.Errorf("expected playing backwards to wrap around to the last frame, got %f, %f", u, v)
+	}
+
+}
@@ -0,0 +1,138 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// The maximum number of ToonSteps, and the number of points the ToonRamp is sampled at, for Materials lit per pixel.
+const (
+	toonShaderSteps       = 8
+	toonShaderRampSamples = 16
+)
+
+// outlineDirections is the number of directions an outline is drawn in around the triangles it outlines.
+const outlineDirections = 8
+
+// This vertex list is only allocated once a Material with an outline is rendered.
+var outlineVertexList []ebiten.Vertex
+
+// toonShaded returns if the Material is toon shaded (see Material.ToonRamp and Material.ToonSteps).
+func (material *Material) toonShaded() bool {
+	return material.ToonRamp != nil || len(material.ToonSteps) > 0
+}
+
+// toonShade returns the given light level remapped according to the Material's ToonRamp or ToonSteps.
+func (material *Material) toonShade(r, g, b float32) (float32, float32, float32) {
+
+	level := float64(r)
+	if float64(g) > level {
+		level = float64(g)
+	}
+	if float64(b) > level {
+		level = float64(b)
+	}
+
+	// The hue of the light is kept, and only its brightness is remapped.
+	hueR, hueG, hueB := float32(1), float32(1), float32(1)
+	if level > 0 {
+		hueR, hueG, hueB = r/float32(level), g/float32(level), b/float32(level)
+	}
+
+	if material.ToonRamp != nil {
+		material.toonRamp.update(material.ToonRamp)
+		rampR, rampG, rampB := material.toonRamp.sample(math.Min(level, 1), 0.5)
+		return rampR * hueR, rampG * hueG, rampB * hueB
+	}
+
+	// The light level is rounded down to the nearest step, or up to the first step if it's below all of them.
+	stepped := material.ToonSteps[0]
+
+	for _, step := range material.ToonSteps[1:] {
+		if level >= step {
+			stepped = step
+		}
+	}
+
+	return float32(stepped) * hueR, float32(stepped) * hueG, float32(stepped) * hueB
+
+}
+
+// assignToon sets up the toon shading of the given Material for the pixel lighting shader.
+func (set *pixelLightSet) assignToon(material *Material) {
+
+	set.toonStepCount = 0
+	set.toonRampSize = 0
+
+	if material == nil || !material.toonShaded() {
+		return
+	}
+
+	if material.ToonRamp != nil {
+
+		material.toonRamp.update(material.ToonRamp)
+
+		for i := 0; i < toonShaderRampSamples; i++ {
+			r, g, b := material.toonRamp.sample((float64(i)+0.5)/toonShaderRampSamples, 0.5)
+			set.toonRamp[i*3] = r
+			set.toonRamp[i*3+1] = g
+			set.toonRamp[i*3+2] = b
+		}
+
+		set.toonRampSize = toonShaderRampSamples
+
+		return
+
+	}
+
+	for i, step := range material.ToonSteps {
+		if i >= toonShaderSteps {
+			break
+		}
+		set.toonSteps[i] = float32(step)
+		set.toonStepCount++
+	}
+
+}
+
+// drawOutline draws the given triangles several times using the draw function, offset in a ring of the given thickness (in pixels)
+// around their original screen positions. If the color given isn't nil, the triangles are drawn flat in that color (so they should
+// be drawn using defaultImg); otherwise, their vertex colors are left alone (as is necessary when drawing the outline's depth).
+func (camera *Camera) drawOutline(vertices []ebiten.Vertex, thickness float64, color *Color, draw func(vertices []ebiten.Vertex)) {
+
+	if outlineVertexList == nil {
+		outlineVertexList = make([]ebiten.Vertex, ebiten.MaxIndicesNum)
+	}
+
+	outline := outlineVertexList[:len(vertices)]
+
+	for d := 0; d < outlineDirections; d++ {
+
+		angle := float64(d) / outlineDirections * 2 * math.Pi
+		dx := float32(math.Cos(angle) * thickness)
+		dy := float32(math.Sin(angle) * thickness)
+
+		copy(outline, vertices)
+
+		for i := range outline {
+
+			outline[i].DstX += dx
+			outline[i].DstY += dy
+
+			if color != nil {
+				outline[i].SrcX = 0.5
+				outline[i].SrcY = 0.5
+				outline[i].ColorR = color.R
+				outline[i].ColorG = color.G
+				outline[i].ColorB = color.B
+				outline[i].ColorA = color.A
+			}
+
+		}
+
+		draw(outline)
+
+	}
+
+}
@@ -0,0 +1,46 @@
+package tetra3d
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestToonShading(t *testing.T) {
+
+	mat := NewMaterial("toon")
+	mat.ToonSteps = []float64{0.25, 0.5, 1}
+
+	for _, test := range [][2]float32{{0, 0.25}, {0.4, 0.25}, {0.75, 0.5}, {2, 1}} {
+		if r, _, _ := mat.toonShade(test[0], test[0], test[0]); r != test[1] {
+			t.Errorf("expected a light level of %f to be stepped to %f, got %f", test[0], test[1], r)
+		}
+	}
+
+	// The light's hue is kept.
+	if r, g, b := mat.toonShade(0.8, 0.4, 0); r != 0.5 || g != 0.25 || b != 0 {
+		t.Errorf("expected the light's hue to be kept, got %f, %f, %f", r, g, b)
+	}
+
+	// The left half of the ramp is dark blue, and the right half is white.
+	ramp := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	ramp.Set(0, 0, color.RGBA{0, 0, 51, 255})
+	ramp.Set(1, 0, color.RGBA{255, 255, 255, 255})
+	mat.ToonRamp = ramp
+
+	if r, _, b := mat.toonShade(0.2, 0.2, 0.2); r != 0 || b != 0.2 {
+		t.Errorf("expected dim light to take on the ramp's dark blue, got %f, %f", r, b)
+	}
+
+	if r, _, b := mat.toonShade(0.9, 0.9, 0.9); r != 1 || b != 1 {
+		t.Errorf("expected bright light to take on the ramp's white, got %f, %f", r, b)
+	}
+
+	set := pixelLightSet{}
+	set.assignToon(mat)
+
+	if set.toonRampSize != toonShaderRampSamples || set.toonRamp[2] != 0.2 || set.toonRamp[len(set.toonRamp)-1] != 1 {
+		t.Errorf("expected the ramp to be sampled for the pixel lighting shader, got %v", set.toonRamp)
+	}
+
+}
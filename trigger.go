@@ -0,0 +1,237 @@
+package tetra3d
+
+// TriggerVolume is a Node representing a region of space that calls functions when Nodes enter or exit it, for doors, checkpoints,
+// cutscene triggers, and so on. The region is defined by a BoundingObject (i.e. a BoundingSphere or BoundingAABB) parented to the
+// TriggerVolume, so it moves along with it. TriggerVolumes work by testing for collisions against the Nodes passed to
+// TriggerVolume.Update() and comparing the overlapping Nodes against the ones from the previous call; because of this, you should call
+// Update() once per game frame. As with collision tests, the Bounds' CollisionMask controls which BoundingObjects can trigger it.
+// TriggerVolumes can be created in Blender by setting an Empty's type to Trigger Volume and giving it bounds.
+type TriggerVolume struct {
+	*Node
+	Bounds  IBoundingObject   // The BoundingObject that defines the TriggerVolume's region.
+	OnEnter func(other INode) // OnEnter is called when a Node starts overlapping the TriggerVolume.
+	OnExit  func(other INode) // OnExit is called when a Node stops overlapping the TriggerVolume.
+
+	// Tags, if set, limits the TriggerVolume to Nodes that have all of the given tags (see Node.Properties()); either the Node
+	// itself or the BoundingObject overlapping the TriggerVolume can have them. For example, a checkpoint with Tags set to
+	// []string{"player"} ignores enemies and props passing through it.
+	Tags []string
+
+	overlapping []triggerOverlap
+}
+
+type triggerOverlap struct {
+	Node   INode
+	inTree bool // If the Node was in a scene's hierarchy when it entered the TriggerVolume
+}
+
+// NewTriggerVolume creates a new TriggerVolume with the given name, using the provided BoundingObject to define its region. The
+// BoundingObject is parented to the TriggerVolume; if it's nil, the TriggerVolume doesn't trigger until its Bounds are set.
+func NewTriggerVolume(name string, bounds IBoundingObject) *TriggerVolume {
+
+	trigger := &TriggerVolume{
+		Node:        NewNode(name),
+		Bounds:      bounds,
+		overlapping: []triggerOverlap{},
+	}
+
+	if bounds != nil {
+		trigger.AddChildren(bounds.(INode))
+	}
+
+	return trigger
+
+}
+
+// NewTriggerVolumeBox creates a new TriggerVolume with the given name whose region is a box (a BoundingAABB) of the given size.
+func NewTriggerVolumeBox(name string, width, height, depth float64) *TriggerVolume {
+	return NewTriggerVolume(name, NewBoundingAABB("BoundingAABB", width, height, depth))
+}
+
+// NewTriggerVolumeSphere creates a new TriggerVolume with the given name whose region is a sphere (a BoundingSphere) of the given radius.
+func NewTriggerVolumeSphere(name string, radius float64) *TriggerVolume {
+	return NewTriggerVolume(name, NewBoundingSphere("BoundingSphere", radius))
+}
+
+// NewTriggerVolumeMesh creates a new TriggerVolume with the given name whose region is the surface of the given Mesh (a
+// BoundingTriangles object). Note that Nodes entirely inside of the Mesh don't overlap its triangles, and so don't count as being inside.
+func NewTriggerVolumeMesh(name string, mesh *Mesh) *TriggerVolume {
+	return NewTriggerVolume(name, NewBoundingTriangles("BoundingTriangles", mesh, 0))
+}
+
+// Clone creates a clone of this TriggerVolume. The clone uses the clone of the original's Bounds, and has the same callbacks and Tags,
+// but doesn't start out overlapping anything.
+func (trigger *TriggerVolume) Clone() INode {
+
+	clone := NewTriggerVolume(trigger.name, nil)
+	clone.Node = trigger.Node.Clone().(*Node)
+	clone.OnEnter = trigger.OnEnter
+	clone.OnExit = trigger.OnExit
+	clone.Tags = append([]string{}, trigger.Tags...)
+
+	for i, child := range clone.children {
+		child.setParent(clone)
+		// Children are cloned in order, so the cloned Bounds are in the same place in the list as the original.
+		if bounds, isBounds := trigger.children[i].(IBoundingObject); isBounds && bounds == trigger.Bounds {
+			clone.Bounds = child.(IBoundingObject)
+		}
+	}
+
+	return clone
+
+}
+
+// Update tests the TriggerVolume's Bounds against the Nodes provided (and their hierarchies, in the same way as
+// IBoundingObject.CollisionTest()), calling OnEnter for each Node that started overlapping the TriggerVolume since the last call,
+// and OnExit for each Node that stopped overlapping. If no Nodes are provided, the TriggerVolume is tested against the rest of the
+// scene it's in.
+// The Node reported for an overlapping BoundingObject is the Node it's parented to (as in, the Node the BoundingObject gives
+// collision to), or the BoundingObject itself if it's parented directly to the scene's root or isn't parented at all.
+// Nodes that were overlapping and aren't passed anymore are treated as having exited. A Node that was in a scene when it entered
+// the TriggerVolume and has since been removed from it also counts as having exited, even if it's still passed to Update().
+func (trigger *TriggerVolume) Update(others ...INode) {
+
+	current := map[INode]bool{}
+	entered := []triggerOverlap{}
+
+	if len(others) == 0 {
+		if root := trigger.Root(); root != nil {
+			others = []INode{root}
+		}
+	}
+
+	// Without Bounds, nothing overlaps the TriggerVolume.
+	collisions := []*Collision{}
+	if trigger.Bounds != nil {
+		collisions = trigger.Bounds.CollisionTest(0, 0, 0, others...)
+	}
+
+	for _, collision := range collisions {
+
+		// The TriggerVolume's own Bounds (and anything else parented to it) don't trigger it.
+		if trigger.isAncestorOf(collision.BoundingObject) {
+			continue
+		}
+
+		node := collision.BoundingObject
+		if parent := node.Parent(); parent != nil && parent != node.Root() {
+			node = parent
+		}
+
+		if current[node] {
+			continue
+		}
+
+		if len(trigger.Tags) > 0 && !node.Properties().Has(trigger.Tags...) && !collision.BoundingObject.Properties().Has(trigger.Tags...) {
+			continue
+		}
+
+		current[node] = true
+
+		if !trigger.IsOverlapping(node) {
+			entered = append(entered, triggerOverlap{Node: node, inTree: node.Root() != nil})
+		}
+
+	}
+
+	exited := []INode{}
+	stillOverlapping := make([]triggerOverlap, 0, len(trigger.overlapping)+len(entered))
+
+	for _, overlap := range trigger.overlapping {
+
+		removedFromTree := overlap.inTree && overlap.Node.Root() == nil
+
+		if current[overlap.Node] && !removedFromTree {
+			stillOverlapping = append(stillOverlapping, overlap)
+		} else {
+			exited = append(exited, overlap.Node)
+		}
+
+	}
+
+	for _, overlap := range entered {
+		stillOverlapping = append(stillOverlapping, overlap)
+	}
+
+	trigger.overlapping = stillOverlapping
+
+	// The callbacks are called after the TriggerVolume's state is updated, so they're free to alter the scene or the TriggerVolume.
+
+	if trigger.OnExit != nil {
+		for _, node := range exited {
+			trigger.OnExit(node)
+		}
+	}
+
+	if trigger.OnEnter != nil {
+		for _, overlap := range entered {
+			trigger.OnEnter(overlap.Node)
+		}
+	}
+
+}
+
+// isAncestorOf returns if the given Node is the TriggerVolume or is parented to it (directly or indirectly).
+func (trigger *TriggerVolume) isAncestorOf(node INode) bool {
+	for ; node != nil; node = node.Parent() {
+		if node == trigger {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOverlapping returns if the Node provided was overlapping the TriggerVolume as of the last call to TriggerVolume.Update().
+func (trigger *TriggerVolume) IsOverlapping(node INode) bool {
+	for _, overlap := range trigger.overlapping {
+		if overlap.Node == node {
+			return true
+		}
+	}
+	return false
+}
+
+// Overlapping returns the Nodes that were overlapping the TriggerVolume as of the last call to TriggerVolume.Update(), in the
+// order they entered it.
+func (trigger *TriggerVolume) Overlapping() NodeFilter {
+	out := make(NodeFilter, 0, len(trigger.overlapping))
+	for _, overlap := range trigger.overlapping {
+		out = append(out, overlap.Node)
+	}
+	return out
+}
+
+// Clear clears the TriggerVolume's overlapping Nodes, calling OnExit for each of them.
+func (trigger *TriggerVolume) Clear() {
+
+	exited := trigger.overlapping
+	trigger.overlapping = []triggerOverlap{}
+
+	if trigger.OnExit != nil {
+		for _, overlap := range exited {
+			trigger.OnExit(overlap.Node)
+		}
+	}
+
+}
+
+////////
+
+// AddChildren parents the provided children Nodes to the passed parent Node, inheriting its transformations and being under it in the scenegraph
+// hierarchy. If the children are already parented to other Nodes, they are unparented before doing so.
+func (trigger *TriggerVolume) AddChildren(children ...INode) {
+	// We do this manually so that addChildren() parents the children to the TriggerVolume, rather than to the TriggerVolume.Node.
+	trigger.addChildren(trigger, children...)
+}
+
+// Unparent unparents the TriggerVolume from its parent, removing it from the scenegraph.
+func (trigger *TriggerVolume) Unparent() {
+	if trigger.parent != nil {
+		trigger.parent.RemoveChildren(trigger)
+	}
+}
+
+// Type returns the NodeType for this object.
+func (trigger *TriggerVolume) Type() NodeType {
+	return NodeTypeTriggerVolume
+}
@@ -0,0 +1,187 @@
+package tetra3d
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTriggerVolume(t *testing.T) {
+
+	scene := NewScene("trigger test")
+
+	trigger := NewTriggerVolumeSphere("trigger", 1)
+	scene.Root.AddChildren(trigger)
+
+	player := NewNode("player")
+	player.AddChildren(NewBoundingSphere("player bounds", 0.5))
+	player.SetLocalPosition(10, 0, 0)
+	scene.Root.AddChildren(player)
+
+	entered := 0
+	exited := 0
+
+	trigger.OnEnter = func(other INode) {
+		if other != player {
+			t.Fatalf("expected the player to enter the trigger, got %s", other.Name())
+		}
+		entered++
+	}
+	trigger.OnExit = func(other INode) {
+		if other != player {
+			t.Fatalf("expected the player to exit the trigger, got %s", other.Name())
+		}
+		exited++
+	}
+
+	trigger.Update(scene.Root.Children()...)
+
+	if entered != 0 || exited != 0 {
+		t.Fatalf("no nodes should have entered or exited the trigger yet; entered: %d, exited: %d", entered, exited)
+	}
+
+	player.SetLocalPosition(0.5, 0, 0)
+	trigger.Update(scene.Root.Children()...)
+	trigger.Update(scene.Root.Children()...)
+
+	if entered != 1 || exited != 0 || !trigger.IsOverlapping(player) {
+		t.Fatalf("the player should have entered the trigger once; entered: %d, exited: %d", entered, exited)
+	}
+
+	player.SetLocalPosition(10, 0, 0)
+	trigger.Update(scene.Root.Children()...)
+
+	if entered != 1 || exited != 1 || trigger.IsOverlapping(player) {
+		t.Fatalf("the player should have exited the trigger once; entered: %d, exited: %d", entered, exited)
+	}
+
+	// The Bounds move along with the TriggerVolume.
+	trigger.SetLocalPosition(10, 0, 0)
+	trigger.Update(scene.Root.Children()...)
+
+	if entered != 2 || !trigger.IsOverlapping(player) {
+		t.Fatalf("moving the trigger onto the player should have made the player enter it; entered: %d, exited: %d", entered, exited)
+	}
+
+	// Removing an overlapping node from the scene should count as it exiting, even if it's still tested against.
+	player.Unparent()
+	trigger.Update(player)
+
+	if entered != 2 || exited != 2 || len(trigger.Overlapping()) != 0 {
+		t.Fatalf("removing the player from the scene should have made it exit the trigger; entered: %d, exited: %d", entered, exited)
+	}
+
+}
+
+func TestTriggerVolumeTags(t *testing.T) {
+
+	scene := NewScene("trigger tags test")
+
+	checkpoint := NewTriggerVolumeBox("checkpoint", 2, 2, 2)
+	checkpoint.Tags = []string{"player"}
+	scene.Root.AddChildren(checkpoint)
+
+	player := NewBoundingSphere("player", 0.5)
+	player.Properties().Get("player").Set(true)
+	player.SetLocalPosition(10, 0, 0)
+
+	enemy := NewNode("enemy")
+	enemy.AddChildren(NewBoundingSphere("enemy bounds", 0.5))
+
+	scene.Root.AddChildren(player, enemy)
+
+	entered := []INode{}
+	checkpoint.OnEnter = func(other INode) { entered = append(entered, other) }
+
+	// With no Nodes given, the TriggerVolume tests against the rest of its scene.
+	checkpoint.Update()
+
+	if len(entered) != 0 {
+		t.Fatalf("the enemy doesn't have the player tag, and so shouldn't enter the checkpoint")
+	}
+
+	player.SetLocalPosition(0.5, 0, 0)
+	checkpoint.Update()
+
+	if len(entered) != 1 || entered[0] != player || !checkpoint.IsOverlapping(player) || checkpoint.IsOverlapping(enemy) {
+		t.Fatalf("only the player should have entered the checkpoint; got %v", entered)
+	}
+
+}
+
+func TestTriggerVolumeReportsCollidingNodes(t *testing.T) {
+
+	scene := NewScene("trigger nodes test")
+
+	door := NewTriggerVolumeBox("door", 2, 2, 2)
+	scene.Root.AddChildren(door)
+
+	// The crates are grouped under a level Node, but it's the crates that enter the TriggerVolume, not the level.
+	level := NewNode("level")
+	scene.Root.AddChildren(level)
+
+	crates := []*Model{}
+	for i := 0; i < 3; i++ {
+		crate := NewModel(NewCube(), fmt.Sprintf("crate %d", i))
+		crate.AddChildren(NewBoundingAABB("BoundingAABB", 1, 1, 1))
+		crate.SetLocalPosition(float64(i)*10, 0, 0)
+		level.AddChildren(crate)
+		crates = append(crates, crate)
+	}
+
+	entered := []INode{}
+	door.OnEnter = func(other INode) { entered = append(entered, other) }
+
+	door.Update()
+
+	if len(entered) != 1 || entered[0] != crates[0] {
+		t.Fatalf("only the first crate should have entered the door; got %v", entered)
+	}
+
+	crates[2].SetLocalPosition(0.5, 0, 0)
+
+	// Passing the level tests against the crates under it in the same way.
+	door.Update(level)
+
+	if len(entered) != 2 || entered[1] != crates[2] || !door.IsOverlapping(crates[0]) || door.IsOverlapping(level) {
+		t.Fatalf("the third crate should have entered the door; got %v", entered)
+	}
+
+}
+
+func TestTriggerVolumeNode(t *testing.T) {
+
+	scene := NewScene("trigger node test")
+
+	trigger := NewTriggerVolumeSphere("checkpoint", 1)
+	trigger.Tags = []string{"player"}
+	scene.Root.AddChildren(trigger)
+
+	if found, ok := scene.Root.Get("checkpoint").(*TriggerVolume); !ok || found != trigger || trigger.Type() != NodeTypeTriggerVolume {
+		t.Fatalf("the trigger should be findable in the scene as a TriggerVolume")
+	}
+
+	if triggers := scene.Root.ChildrenRecursive().TriggerVolumes(); len(triggers) != 1 || triggers[0] != trigger {
+		t.Fatalf("the scene should have one TriggerVolume; got %v", triggers)
+	}
+
+	clone := trigger.Clone().(*TriggerVolume)
+	clone.SetLocalPosition(10, 0, 0)
+	scene.Root.AddChildren(clone)
+
+	if clone.Bounds == trigger.Bounds || clone.Bounds.(INode).Parent() != clone || len(clone.Tags) != 1 || clone.Tags[0] != "player" {
+		t.Fatalf("the clone should have its own Bounds parented to it, along with the original's Tags")
+	}
+
+	player := NewBoundingSphere("player", 0.5)
+	player.Properties().Get("player").Set(true)
+	player.SetLocalPosition(10, 0, 0)
+	scene.Root.AddChildren(player)
+
+	trigger.Update()
+	clone.Update()
+
+	if trigger.IsOverlapping(player) || !clone.IsOverlapping(player) {
+		t.Fatalf("only the clone should have the player overlapping it")
+	}
+
+}
@@ -1,6 +1,10 @@
 package tetra3d
 
-import "github.com/kvartborg/vector"
+import (
+	"math"
+
+	"github.com/kvartborg/vector"
+)
 
 type FinishMode int
 
@@ -14,3 +18,47 @@ const (
 func Distance(posOne, posTwo vector.Vector) float64 {
 	return posOne.Sub(posTwo).Magnitude()
 }
+
+// Reflect returns a new vector.Vector that is the provided vector reflected off of a surface with the given normal (which should be
+// normalized), like a ball bouncing off of a wall.
+func Reflect(vec, normal vector.Vector) vector.Vector {
+	d := 2 * dot(vec, normal)
+	return vector.Vector{
+		vec[0] - normal[0]*d,
+		vec[1] - normal[1]*d,
+		vec[2] - normal[2]*d,
+	}
+}
+
+// Project returns a new vector.Vector that is the provided vector projected onto the onto vector (i.e. the component of vec that points
+// in the direction of onto). If onto has a length of 0, a zero vector is returned.
+func Project(vec, onto vector.Vector) vector.Vector {
+	magSquared := fastVectorMagnitudeSquared(onto)
+	if magSquared == 0 {
+		return vector.Vector{0, 0, 0}
+	}
+	scale := dot(vec, onto) / magSquared
+	return vector.Vector{onto[0] * scale, onto[1] * scale, onto[2] * scale}
+}
+
+// Lerp returns a new vector.Vector that is linearly interpolated between a and b by the percentage t (where t = 0 returns a
+// copy of a, and t = 1 returns a copy of b). t isn't clamped.
+func Lerp(a, b vector.Vector, t float64) vector.Vector {
+	return vector.Vector{
+		a[0] + (b[0]-a[0])*t,
+		a[1] + (b[1]-a[1])*t,
+		a[2] + (b[2]-a[2])*t,
+	}
+}
+
+// ClampMagnitude returns a copy of the provided vector.Vector, shortened to have a magnitude of max if it's longer than that.
+func ClampMagnitude(vec vector.Vector, max float64) vector.Vector {
+	out := vector.Vector{vec[0], vec[1], vec[2]}
+	if mag := math.Sqrt(fastVectorMagnitudeSquared(out)); mag > max && mag > 0 {
+		scale := max / mag
+		out[0] *= scale
+		out[1] *= scale
+		out[2] *= scale
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package tetra3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kvartborg/vector"
+)
+
+func vectorsNear(a, b vector.Vector) bool {
+	return fastVectorDistanceSquared(a, b) < 0.000001
+}
+
+func TestVectorHelpers(t *testing.T) {
+
+	if r := Reflect(vector.Vector{1, -1, 0}, vector.Vector{0, 1, 0}); !vectorsNear(r, vector.Vector{1, 1, 0}) {
+		t.Errorf("Reflect: expected [1 1 0], got %v", r)
+	}
+
+	if p := Project(vector.Vector{3, 4, 5}, vector.Vector{0, 2, 0}); !vectorsNear(p, vector.Vector{0, 4, 0}) {
+		t.Errorf("Project: expected [0 4 0], got %v", p)
+	}
+
+	if p := Project(vector.Vector{3, 4, 5}, vector.Vector{0, 0, 0}); !vectorsNear(p, vector.Vector{0, 0, 0}) {
+		t.Errorf("Project onto a zero vector: expected [0 0 0], got %v", p)
+	}
+
+	if l := Lerp(vector.Vector{0, 0, 0}, vector.Vector{2, 4, -8}, 0.25); !vectorsNear(l, vector.Vector{0.5, 1, -2}) {
+		t.Errorf("Lerp: expected [0.5 1 -2], got %v", l)
+	}
+
+	original := vector.Vector{0, 3, 4}
+
+	if c := ClampMagnitude(original, 1); !vectorsNear(c, vector.Vector{0, 0.6, 0.8}) {
+		t.Errorf("ClampMagnitude: expected [0 0.6 0.8], got %v", c)
+	}
+
+	if c := ClampMagnitude(original, 10); !vectorsNear(c, original) {
+		t.Errorf("ClampMagnitude below the maximum: expected %v, got %v", original, c)
+	}
+
+	if !vectorsNear(original, vector.Vector{0, 3, 4}) {
+		t.Errorf("ClampMagnitude modified its input vector: %v", original)
+	}
+
+}
+
+func TestLookAtMatrix(t *testing.T) {
+
+	from := vector.Vector{0, 0, 0}
+	to := vector.Vector{10, 0, 0}
+
+	lookAt := NewLookAtMatrix(from, to, vector.Vector{0, 1, 0})
+
+	if forward := lookAt.Row(2)[:3]; !vectorsNear(forward, vector.Vector{1, 0, 0}) {
+		t.Errorf("expected the look-at matrix's Z axis to point towards the target, got %v", forward)
+	}
+
+	if up := lookAt.Row(1)[:3]; !vectorsNear(up, vector.Vector{0, 1, 0}) {
+		t.Errorf("expected the look-at matrix's Y axis to point up, got %v", up)
+	}
+
+	if !lookAt.HasValidRotation() || math.Abs(lookAt.Row(0).Magnitude()-1) > 0.0001 {
+		t.Errorf("expected the look-at matrix to be a pure rotation, got %v", lookAt)
+	}
+
+}
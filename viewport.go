@@ -0,0 +1,143 @@
+package tetra3d
+
+import (
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	ViewportLayoutGrid    = iota // The Cameras are laid out in a grid that's as square as possible, with the last row's Cameras sharing its full width
+	ViewportLayoutColumns        // The Cameras are laid out side by side, from left to right
+	ViewportLayoutRows           // The Cameras are laid out on top of each other, from top to bottom
+)
+
+// Viewport manages a set of Cameras that share the screen, for local multiplayer split-screen. It divides the screen into regions
+// (one per Camera) according to its Layout, resizes each Camera to match its region (so that each Camera's view has the correct
+// aspect ratio, rather than being stretched), and composites the Cameras' results onto the screen.
+type Viewport struct {
+	Cameras []*Camera // The Cameras sharing the screen, in order (from the top-left).
+	Layout  int       // How the screen is divided between the Cameras (ViewportLayoutGrid, ViewportLayoutColumns, or ViewportLayoutRows); defaults to ViewportLayoutGrid.
+
+	// Scale is the resolution the Cameras render at, relative to the size of their regions of the screen (so 0.5 renders at half
+	// resolution, for a chunkier look or better performance). Defaults to 1.
+	Scale float64
+
+	Gap    int           // The gap between the Cameras' regions of the screen, in pixels; defaults to 0.
+	Filter ebiten.Filter // The filter used when the Cameras' results are scaled to fit their regions; defaults to ebiten.FilterNearest.
+}
+
+// NewViewport creates a new Viewport that divides the screen between the given Cameras.
+func NewViewport(cameras ...*Camera) *Viewport {
+	return &Viewport{
+		Cameras: cameras,
+		Layout:  ViewportLayoutGrid,
+		Scale:   1,
+	}
+}
+
+// Regions returns the regions of a screen of the given size that each of the Viewport's Cameras is drawn to, in order.
+func (viewport *Viewport) Regions(screenW, screenH int) []image.Rectangle {
+
+	count := len(viewport.Cameras)
+
+	if count == 0 {
+		return nil
+	}
+
+	columns, rows := count, 1
+
+	switch viewport.Layout {
+	case ViewportLayoutRows:
+		columns, rows = 1, count
+	case ViewportLayoutGrid:
+		columns = int(math.Ceil(math.Sqrt(float64(count))))
+		rows = int(math.Ceil(float64(count) / float64(columns)))
+	}
+
+	// split divides the given length into the given number of spans separated by the gap, returning where each span starts (with
+	// an extra one at the end); each span ends a gap's width before the next one starts.
+	split := func(length, spans int) []int {
+		edges := make([]int, spans+1)
+		available := length - viewport.Gap*(spans-1)
+		for i := 0; i <= spans; i++ {
+			edges[i] = available*i/spans + viewport.Gap*i
+		}
+		return edges
+	}
+
+	regions := make([]image.Rectangle, 0, count)
+
+	rowEdges := split(screenH, rows)
+
+	for row := 0; row < rows; row++ {
+
+		// The last row may have fewer Cameras than the others, in which case they share its full width.
+		inRow := columns
+		if remaining := count - row*columns; remaining < columns {
+			inRow = remaining
+		}
+
+		columnEdges := split(screenW, inRow)
+
+		for column := 0; column < inRow; column++ {
+			regions = append(regions, image.Rect(
+				columnEdges[column],
+				rowEdges[row],
+				columnEdges[column+1]-viewport.Gap,
+				rowEdges[row+1]-viewport.Gap,
+			))
+		}
+
+	}
+
+	return regions
+
+}
+
+// Resize resizes the Viewport's Cameras to match their regions of a screen of the given size (scaled by the Viewport's Scale).
+// Cameras that are already the correct size are left alone.
+func (viewport *Viewport) Resize(screenW, screenH int) {
+
+	for i, region := range viewport.Regions(screenW, screenH) {
+		w := int(math.Max(math.Round(float64(region.Dx())*viewport.Scale), 1))
+		h := int(math.Max(math.Round(float64(region.Dy())*viewport.Scale), 1))
+		viewport.Cameras[i].Resize(w, h)
+	}
+
+}
+
+// Render resizes the Viewport's Cameras to fit the given screen, clears them, renders the Scene with each of them, and then draws
+// their results to the screen. To render something other than the entire Scene (or to render overlays, for example), call
+// Viewport.Resize(), render with the Cameras as desired, and then call Viewport.Draw() instead.
+func (viewport *Viewport) Render(screen *ebiten.Image, scene *Scene) {
+
+	viewport.Resize(screen.Size())
+
+	for _, camera := range viewport.Cameras {
+		camera.Clear()
+		camera.RenderNodes(scene, scene.Root)
+	}
+
+	viewport.Draw(screen)
+
+}
+
+// Draw draws the results of the Viewport's Cameras (see Camera.ColorTexture()) to their regions of the given screen, scaling them to
+// fit.
+func (viewport *Viewport) Draw(screen *ebiten.Image) {
+
+	for i, region := range viewport.Regions(screen.Size()) {
+
+		result := viewport.Cameras[i].ColorTexture()
+		w, h := result.Size()
+
+		opt := &ebiten.DrawImageOptions{Filter: viewport.Filter}
+		opt.GeoM.Scale(float64(region.Dx())/float64(w), float64(region.Dy())/float64(h))
+		opt.GeoM.Translate(float64(region.Min.X), float64(region.Min.Y))
+		screen.DrawImage(result, opt)
+
+	}
+
+}
@@ -0,0 +1,42 @@
+package tetra3d
+
+import (
+	"image"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestViewport(t *testing.T) {
+
+	viewport := NewViewport(NewCamera(16, 16), NewCamera(16, 16), NewCamera(16, 16))
+	viewport.Gap = 2
+
+	expected := []image.Rectangle{
+		image.Rect(0, 0, 99, 49),
+		image.Rect(101, 0, 200, 49),
+		image.Rect(0, 51, 200, 100),
+	}
+
+	for i, region := range viewport.Regions(200, 100) {
+		if region != expected[i] {
+			t.Errorf("expected region %d to be %v, got %v", i, expected[i], region)
+		}
+	}
+
+	viewport.Layout = ViewportLayoutRows
+	viewport.Scale = 0.5
+
+	scene := NewScene("viewport")
+	scene.Root.AddChildren(NewModel(NewCube(), "cube"))
+
+	screen := ebiten.NewImage(120, 94)
+	viewport.Render(screen, scene)
+
+	for i, camera := range viewport.Cameras {
+		if w, h := camera.Size(); w != 60 || h != 15 {
+			t.Errorf("expected camera %d to be resized to half of its region of the screen, got %d x %d", i, w, h)
+		}
+	}
+
+}
@@ -0,0 +1,543 @@
+package tetra3d
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/kvartborg/vector"
+)
+
+// VoxLoadOptions represents options one can use to tweak how MagicaVoxel .vox files are loaded into Tetra3D.
+type VoxLoadOptions struct {
+	VoxelSize float64 // The size of each voxel in Tetra3D units. Defaults to 1.
+}
+
+// DefaultVoxLoadOptions creates an instance of VoxLoadOptions with some sensible defaults.
+func DefaultVoxLoadOptions() *VoxLoadOptions {
+	return &VoxLoadOptions{
+		VoxelSize: 1,
+	}
+}
+
+// LoadVoxFile loads a MagicaVoxel .vox file from the filepath given, using a provided VoxLoadOptions struct to alter how the file is
+// loaded. Passing nil for loadOptions will load the file using default load options. See LoadVoxData() for more information.
+// LoadVoxFile will return a Library, and an error if the process fails.
+func LoadVoxFile(path string, loadOptions *VoxLoadOptions) (*Library, error) {
+
+	fileData, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return LoadVoxData(fileData, loadOptions)
+
+}
+
+// LoadVoxData loads MagicaVoxel .vox data, using a provided VoxLoadOptions struct to alter how the data is loaded. Passing nil for
+// loadOptions will load the data using default load options.
+//
+// Each voxel model in the data becomes a Mesh in the returned Library, named "Model" followed by the model's index (i.e. "Model0").
+// The Meshes are built by greedy meshing, where neighboring voxel faces of the same color are merged into larger rectangles, so they
+// have as few triangles as possible. Each vertex is colored using the palette, and has a UV value that points to its color's
+// position in a 256x1 palette texture, in case you'd rather texture the Mesh. Meshes are centered on their models' pivots (the center
+// of each model's bounding box, as in MagicaVoxel), and are converted from MagicaVoxel's Z-up coordinate system to Tetra3D's Y-up.
+//
+// The Library also has a single Scene (which is also the ExportedScene), with a Model for each shape in the data's scene graph,
+// positioned, rotated, and parented as they are in MagicaVoxel; if the data has no scene graph, there's simply a Model for each Mesh
+// at the origin. LoadVoxData will return a Library, and an error if the process fails.
+func LoadVoxData(data []byte, loadOptions *VoxLoadOptions) (*Library, error) {
+
+	if loadOptions == nil {
+		loadOptions = DefaultVoxLoadOptions()
+	}
+
+	reader := &voxReader{data: data}
+
+	if string(reader.bytes(4)) != "VOX " {
+		return nil, errors.New("error loading vox data: data is not a MagicaVoxel .vox file")
+	}
+
+	reader.int() // Version
+
+	if string(reader.bytes(4)) != "MAIN" {
+		return nil, errors.New("error loading vox data: MAIN chunk not found")
+	}
+
+	reader.int() // Content size
+	reader.int() // Children size
+
+	sizes := [][3]int{}
+	models := [][][4]uint8{}
+	palette := voxDefaultPalette()
+	nodes := map[int]*voxSceneNode{}
+
+	for reader.err == nil && reader.index < len(reader.data) {
+
+		id := string(reader.bytes(4))
+		contentSize := reader.int()
+		childrenSize := reader.int()
+
+		content := &voxReader{data: reader.bytes(contentSize)}
+		reader.bytes(childrenSize)
+
+		switch id {
+
+		case "SIZE":
+			sizes = append(sizes, [3]int{content.int(), content.int(), content.int()})
+
+		case "XYZI":
+			count := content.int()
+			voxels := make([][4]uint8, 0, count)
+			for i := 0; i < count && content.err == nil; i++ {
+				v := content.bytes(4)
+				if len(v) == 4 {
+					voxels = append(voxels, [4]uint8{v[0], v[1], v[2], v[3]})
+				}
+			}
+			models = append(models, voxels)
+
+		case "RGBA":
+			// Color indices in voxels start at 1, so the RGBA chunk's first color is the palette's second.
+			for i := 0; i < 255; i++ {
+				c := content.bytes(4)
+				if len(c) == 4 {
+					palette[i+1] = NewColor(float32(c[0])/255, float32(c[1])/255, float32(c[2])/255, float32(c[3])/255)
+				}
+			}
+
+		case "nTRN":
+			node := &voxSceneNode{Type: id, ID: content.int(), Attributes: content.dict()}
+			node.Children = []int{content.int()}
+			content.int() // Reserved
+			content.int() // Layer ID
+			if frameCount := content.int(); frameCount > 0 {
+				node.Frame = content.dict()
+			}
+			nodes[node.ID] = node
+
+		case "nGRP":
+			node := &voxSceneNode{Type: id, ID: content.int(), Attributes: content.dict()}
+			childCount := content.int()
+			for i := 0; i < childCount && content.err == nil; i++ {
+				node.Children = append(node.Children, content.int())
+			}
+			nodes[node.ID] = node
+
+		case "nSHP":
+			node := &voxSceneNode{Type: id, ID: content.int(), Attributes: content.dict()}
+			if modelCount := content.int(); modelCount > 0 {
+				node.Model = content.int()
+			}
+			nodes[node.ID] = node
+
+		}
+
+		if content.err != nil {
+			return nil, fmt.Errorf("error loading vox data: malformed %s chunk: %w", id, content.err)
+		}
+
+	}
+
+	if reader.err != nil {
+		return nil, fmt.Errorf("error loading vox data: %w", reader.err)
+	}
+
+	if len(sizes) != len(models) {
+		return nil, errors.New("error loading vox data: mismatched number of SIZE and XYZI chunks")
+	}
+
+	library := NewLibrary()
+
+	scene := library.AddScene("Scene")
+	scene.library = library
+	library.ExportedScene = scene
+
+	meshes := make([]*Mesh, len(models))
+
+	for i := range models {
+		mesh := voxGreedyMesh("Model"+strconv.Itoa(i), sizes[i], models[i], palette)
+		mesh.library = library
+		library.Meshes[mesh.Name] = mesh
+		meshes[i] = mesh
+	}
+
+	if root, exists := nodes[0]; exists {
+
+		var addNode func(parent INode, node *voxSceneNode)
+
+		addNode = func(parent INode, node *voxSceneNode) {
+
+			if node == nil || node.Type != "nTRN" || len(node.Children) == 0 {
+				return
+			}
+
+			child := nodes[node.Children[0]]
+
+			if child == nil {
+				return
+			}
+
+			name := node.Attributes["_name"]
+
+			var obj INode
+
+			if child.Type == "nSHP" {
+
+				if child.Model < 0 || child.Model >= len(meshes) {
+					return
+				}
+
+				if name == "" {
+					name = "Model" + strconv.Itoa(node.ID)
+				}
+
+				obj = NewModel(meshes[child.Model], name)
+
+			} else {
+
+				if name == "" {
+					name = "Group" + strconv.Itoa(node.ID)
+				}
+
+				obj = NewNode(name)
+
+			}
+
+			obj.setLibrary(library)
+
+			if t, exists := node.Frame["_t"]; exists {
+				pos := vector.Vector{0, 0, 0}
+				for i, value := range strings.Fields(t) {
+					if i < 3 {
+						pos[i], _ = strconv.ParseFloat(value, 64)
+					}
+				}
+				obj.SetLocalPositionVec(pos)
+			}
+
+			if r, exists := node.Frame["_r"]; exists {
+				if packed, err := strconv.Atoi(r); err == nil {
+					obj.SetLocalRotation(voxRotation(packed))
+				}
+			}
+
+			if node.Attributes["_hidden"] == "1" {
+				obj.SetVisible(false, false)
+			}
+
+			parent.AddChildren(obj)
+
+			if child.Type == "nGRP" {
+				for _, id := range child.Children {
+					addNode(obj, nodes[id])
+				}
+			}
+
+		}
+
+		// The root transform node is always at the origin, so its group's children are added directly to the Scene.
+		var group *voxSceneNode
+		if root.Type == "nTRN" && len(root.Children) > 0 {
+			group = nodes[root.Children[0]]
+		}
+
+		if group != nil && group.Type == "nGRP" {
+			for _, id := range group.Children {
+				addNode(scene.Root, nodes[id])
+			}
+		} else {
+			addNode(scene.Root, root)
+		}
+
+	} else {
+
+		for _, mesh := range meshes {
+			model := NewModel(mesh, mesh.Name)
+			model.setLibrary(library)
+			scene.Root.AddChildren(model)
+		}
+
+	}
+
+	// MagicaVoxel is Z-up, just like Blender, so we can use the same conversion.
+	importOptions := NewImportOptionsBlender()
+	if loadOptions.VoxelSize > 0 {
+		importOptions.Scale = loadOptions.VoxelSize
+	}
+
+	converter := importOptions.converter()
+
+	for _, mesh := range meshes {
+		converter.mesh(mesh)
+	}
+
+	fixes := map[string]importConversionFix{}
+
+	for _, node := range scene.Root.Children() {
+		converter.node(node, library, NewMatrix4(), fixes)
+	}
+
+	return library, nil
+
+}
+
+// voxSceneNode is a node in a .vox file's scene graph; either a transform (nTRN), a group (nGRP), or a shape (nSHP).
+type voxSceneNode struct {
+	Type       string
+	ID         int
+	Attributes map[string]string
+	Frame      map[string]string // The transform node's (first) frame, containing its translation and rotation
+	Children   []int
+	Model      int // The model used by a shape node
+}
+
+// voxReader reads little-endian values from .vox data, recording the first error encountered (after which reads return zero values).
+type voxReader struct {
+	data  []byte
+	index int
+	err   error
+}
+
+func (reader *voxReader) bytes(count int) []byte {
+
+	if reader.err != nil {
+		return nil
+	}
+
+	if count < 0 || reader.index+count > len(reader.data) {
+		reader.err = errors.New("unexpected end of data")
+		return nil
+	}
+
+	out := reader.data[reader.index : reader.index+count]
+	reader.index += count
+	return out
+
+}
+
+func (reader *voxReader) int() int {
+	b := reader.bytes(4)
+	if len(b) < 4 {
+		return 0
+	}
+	return int(int32(binary.LittleEndian.Uint32(b)))
+}
+
+func (reader *voxReader) string() string {
+	return string(reader.bytes(reader.int()))
+}
+
+func (reader *voxReader) dict() map[string]string {
+	dict := map[string]string{}
+	count := reader.int()
+	for i := 0; i < count && reader.err == nil; i++ {
+		key := reader.string()
+		dict[key] = reader.string()
+	}
+	return dict
+}
+
+// voxRotation returns the rotation matrix for a rotation packed into a byte, as stored in .vox files. The index of the non-zero entry
+// in the first and second rows are stored in the first two pairs of bits, and the signs of the entries in the three rows are stored in
+// the next three bits.
+func voxRotation(packed int) Matrix4 {
+
+	first := packed & 3
+	second := (packed >> 2) & 3
+	indices := [3]int{first, second, 3 - first - second}
+
+	mat := NewMatrix4()
+	mat.SetRow(0, vector.Vector{0, 0, 0, 0})
+	mat.SetRow(1, vector.Vector{0, 0, 0, 0})
+	mat.SetRow(2, vector.Vector{0, 0, 0, 0})
+
+	for row := 0; row < 3; row++ {
+
+		sign := 1.0
+		if packed&(1<<(4+row)) > 0 {
+			sign = -1
+		}
+
+		// MagicaVoxel's rotation matrices transform column vectors, while Tetra3D's transform row vectors, so we transpose it.
+		mat[indices[row]%3][row] = sign
+
+	}
+
+	return mat
+
+}
+
+// voxGreedyMesh creates a Mesh for a voxel model of the given size, merging neighboring faces of the same color into larger rectangles.
+// The Mesh is centered on the model's pivot, and is in MagicaVoxel's (Z-up) coordinate system.
+func voxGreedyMesh(name string, size [3]int, voxels [][4]uint8, palette []*Color) *Mesh {
+
+	grid := make([]uint8, size[0]*size[1]*size[2])
+
+	get := func(pos [3]int) uint8 {
+		for i := 0; i < 3; i++ {
+			if pos[i] < 0 || pos[i] >= size[i] {
+				return 0
+			}
+		}
+		return grid[pos[0]+pos[1]*size[0]+pos[2]*size[0]*size[1]]
+	}
+
+	for _, v := range voxels {
+		if int(v[0]) < size[0] && int(v[1]) < size[1] && int(v[2]) < size[2] {
+			grid[int(v[0])+int(v[1])*size[0]+int(v[2])*size[0]*size[1]] = v[3]
+		}
+	}
+
+	// MagicaVoxel places models by the center of their bounding boxes (rounded down).
+	pivot := vector.Vector{float64(size[0] / 2), float64(size[1] / 2), float64(size[2] / 2)}
+
+	builder := NewMeshBuilder(name)
+
+	for d := 0; d < 3; d++ {
+
+		u := (d + 1) % 3
+		v := (d + 2) % 3
+
+		mask := make([]uint8, size[u]*size[v])
+
+		for _, side := range []int{-1, 1} {
+
+			for slice := 0; slice < size[d]; slice++ {
+
+				// Build a mask of the faces in this slice that are visible from this side.
+				for j := 0; j < size[v]; j++ {
+					for i := 0; i < size[u]; i++ {
+
+						pos := [3]int{}
+						pos[d], pos[u], pos[v] = slice, i, j
+
+						neighbor := pos
+						neighbor[d] += side
+
+						mask[i+j*size[u]] = 0
+						if color := get(pos); color != 0 && get(neighbor) == 0 {
+							mask[i+j*size[u]] = color
+						}
+
+					}
+				}
+
+				// Greedily merge the faces into rectangles.
+				for j := 0; j < size[v]; j++ {
+
+					for i := 0; i < size[u]; {
+
+						color := mask[i+j*size[u]]
+
+						if color == 0 {
+							i++
+							continue
+						}
+
+						width := 1
+						for i+width < size[u] && mask[i+width+j*size[u]] == color {
+							width++
+						}
+
+						height := 1
+
+					heightSearch:
+						for j+height < size[v] {
+							for k := 0; k < width; k++ {
+								if mask[i+k+(j+height)*size[u]] != color {
+									break heightSearch
+								}
+							}
+							height++
+						}
+
+						for y := 0; y < height; y++ {
+							for x := 0; x < width; x++ {
+								mask[i+x+(j+y)*size[u]] = 0
+							}
+						}
+
+						corner := vector.Vector{0, 0, 0}
+						corner[d] = float64(slice)
+						if side > 0 {
+							corner[d]++
+						}
+						corner[u] = float64(i)
+						corner[v] = float64(j)
+						corner = corner.Sub(pivot)
+
+						du := vector.Vector{0, 0, 0}
+						du[u] = float64(width)
+						dv := vector.Vector{0, 0, 0}
+						dv[v] = float64(height)
+
+						builder.SetColor(palette[color])
+						builder.SetUV((float64(color)+0.5)/256, 0.5)
+
+						if side > 0 {
+							builder.AddQuad(corner, corner.Add(du), corner.Add(du).Add(dv), corner.Add(dv))
+						} else {
+							builder.AddQuad(corner, corner.Add(dv), corner.Add(du).Add(dv), corner.Add(du))
+						}
+
+						i += width
+
+					}
+
+				}
+
+			}
+
+		}
+
+	}
+
+	return builder.Build()
+
+}
+
+// voxDefaultPalette returns MagicaVoxel's default palette, which is used if a .vox file doesn't have its own. Index 0 is empty, indices
+// 1 - 215 are a 6x6x6 color cube (without black), and the rest are ramps of blue, green, red, and gray.
+func voxDefaultPalette() []*Color {
+
+	palette := make([]*Color, 0, 256)
+	palette = append(palette, NewColor(0, 0, 0, 0))
+
+	steps := []float32{1, 0.8, 0.6, 0.4, 0.2, 0}
+
+	for _, r := range steps {
+		for _, g := range steps {
+			for _, b := range steps {
+				if r > 0 || g > 0 || b > 0 {
+					palette = append(palette, NewColor(r, g, b, 1))
+				}
+			}
+		}
+	}
+
+	ramp := []float32{0xee, 0xdd, 0xbb, 0xaa, 0x88, 0x77, 0x55, 0x44, 0x22, 0x11}
+
+	for channel := 0; channel < 4; channel++ {
+		for _, value := range ramp {
+			value /= 255
+			switch channel {
+			case 0:
+				palette = append(palette, NewColor(0, 0, value, 1))
+			case 1:
+				palette = append(palette, NewColor(0, value, 0, 1))
+			case 2:
+				palette = append(palette, NewColor(value, 0, 0, 1))
+			case 3:
+				palette = append(palette, NewColor(value, value, value, 1))
+			}
+		}
+	}
+
+	return palette
+
+}
@@ -0,0 +1,79 @@
+package tetra3d
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestLoadVoxData(t *testing.T) {
+
+	chunk := func(id string, content []byte) []byte {
+		buffer := &bytes.Buffer{}
+		buffer.WriteString(id)
+		binary.Write(buffer, binary.LittleEndian, int32(len(content)))
+		binary.Write(buffer, binary.LittleEndian, int32(0))
+		buffer.Write(content)
+		return buffer.Bytes()
+	}
+
+	ints := func(values ...int32) []byte {
+		buffer := &bytes.Buffer{}
+		binary.Write(buffer, binary.LittleEndian, values)
+		return buffer.Bytes()
+	}
+
+	// A 2x1x1 model of two voxels sharing the same color, which should be merged into a single box.
+	children := &bytes.Buffer{}
+	children.Write(chunk("SIZE", ints(2, 1, 1)))
+	children.Write(chunk("XYZI", append(ints(2), 0, 0, 0, 1, 1, 0, 0, 1)))
+
+	palette := make([]byte, 256*4)
+	copy(palette, []byte{255, 0, 0, 255})
+	children.Write(chunk("RGBA", palette))
+
+	data := &bytes.Buffer{}
+	data.WriteString("VOX ")
+	data.Write(ints(150))
+	data.WriteString("MAIN")
+	data.Write(ints(0, int32(children.Len())))
+	data.Write(children.Bytes())
+
+	library, err := LoadVoxData(data.Bytes(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mesh := library.Meshes["Model0"]
+	if mesh == nil {
+		t.Fatal("expected a Mesh named Model0")
+	}
+
+	if len(mesh.Triangles) != 12 {
+		t.Errorf("expected 12 triangles after greedy meshing, got %d", len(mesh.Triangles))
+	}
+
+	dim := mesh.Dimensions
+	if dim.Width() != 2 || dim.Height() != 1 || dim.Depth() != 1 {
+		t.Errorf("expected dimensions of 2x1x1, got %fx%fx%f", dim.Width(), dim.Height(), dim.Depth())
+	}
+
+	for i, colors := range mesh.VertexColors {
+		if c := colors[0]; c.R != 1 || c.G != 0 || c.B != 0 {
+			t.Fatalf("vertex %d: expected the palette's red, got %v", i, c)
+		}
+	}
+
+	for _, tri := range mesh.Triangles {
+		for _, index := range tri.VertexIndices() {
+			if mesh.VertexNormals[index].Sub(tri.Normal).Magnitude() > 0.0001 {
+				t.Fatalf("triangle %d: expected vertex normals to match the triangle's normal %v, got %v", tri.ID, tri.Normal, mesh.VertexNormals[index])
+			}
+		}
+	}
+
+	if models := library.ExportedScene.Root.Children(); len(models) != 1 || models[0].(*Model).Mesh != mesh {
+		t.Errorf("expected the Scene to have a single Model using the Mesh")
+	}
+
+}
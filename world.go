@@ -26,6 +26,21 @@ type World struct {
 	FogRange        []float32
 	LightingOn      bool          // If lighting is enabled when rendering the scene.
 	AmbientLight    *AmbientLight // Ambient lighting for this world
+
+	// HeightFogEnabled enables height fog, which fogs things depending on their height in the world, for fog that pools in valleys or
+	// swamps (or rises into the sky). The fog fades from nothing at a world Y of HeightFogStart to HeightFogOpacity at a world Y of
+	// HeightFogEnd, so if HeightFogEnd is below HeightFogStart, the fog is thickest at the bottom, and otherwise, it's thickest at the
+	// top. Height fog is blended over the depth-based fog (see World.FogMode) in the same way as FogVolumes are, and is calculated for
+	// each vertex. Defaults to false, with the fog fading from a Y of 0 to a Y of -10.
+	HeightFogEnabled bool
+	HeightFogColor   *Color  // The color of the height fog; its alpha is ignored. Defaults to white.
+	HeightFogStart   float64 // The world Y at which the height fog starts.
+	HeightFogEnd     float64 // The world Y at which the height fog reaches its full opacity.
+	HeightFogOpacity float64 // The opacity of the height fog at HeightFogEnd and beyond, from 0 to 1; defaults to 1.
+
+	// Skybox is rendered behind everything else when rendering a Scene using the World (see NewSkyboxPanorama() and
+	// NewSkyboxCubemap()). Defaults to nil.
+	Skybox *Skybox
 }
 
 // NewWorld creates a new World with the specified name and default values for fog, lighting, etc).
@@ -39,6 +54,10 @@ func NewWorld(name string) *World {
 		DitheredFogSize: 0,
 		ClearColor:      NewColor(0.08, 0.09, 0.1, 1),
 		AmbientLight:    NewAmbientLight("ambient light", 1, 1, 1, 0),
+
+		HeightFogColor:   NewColor(1, 1, 1, 1),
+		HeightFogEnd:     -10,
+		HeightFogOpacity: 1,
 	}
 
 }
@@ -57,6 +76,16 @@ func (world *World) Clone() *World {
 	newWorld.AmbientLight = world.AmbientLight.Clone().(*AmbientLight)
 	newWorld.DitheredFogSize = world.DitheredFogSize
 
+	newWorld.HeightFogEnabled = world.HeightFogEnabled
+	newWorld.HeightFogColor = world.HeightFogColor.Clone()
+	newWorld.HeightFogStart = world.HeightFogStart
+	newWorld.HeightFogEnd = world.HeightFogEnd
+	newWorld.HeightFogOpacity = world.HeightFogOpacity
+
+	if world.Skybox != nil {
+		newWorld.Skybox = world.Skybox.Clone()
+	}
+
 	return newWorld
 
 }